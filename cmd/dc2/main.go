@@ -12,6 +12,7 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -27,10 +28,22 @@ var (
 	level             = flag.String("log-level", "", "Log level")
 	addr              = flag.String("addr", "", "Address to listen on")
 	instanceNetwork   = flag.String("instance-network", "", "Instance workload network name (optional; defaults to container network or bridge)")
+	mainVolumePath    = flag.String("main-volume-host-path", "", "Absolute host directory for EBS volume files (optional; defaults to a Docker volume)")
+	mainImage         = flag.String("main-image", "", "Image of the container managing EBS volume files (optional; defaults to alpine)")
+	volumeRoot        = flag.String("volume-root", "", "Absolute path EBS volume files are stored at inside containers (optional; defaults to /dc2)")
+	publishPort       = flag.String("publish-port", "", "Container TCP port each instance publishes on its public IP, a per-instance loopback address (disabled when empty)")
+	statePath         = flag.String("state-path", "", "JSON file used to persist resource state across restarts (optional; state is kept in memory when empty)")
 	exitResourceMode  = flag.String("exit-resource-mode", "", "Exit resource mode: cleanup|keep|assert")
 	testProfile       = flag.String("test-profile", "", "YAML test profile input for delay/fault injection (filepath or inline YAML)")
+	seed              = flag.String("seed", "", "YAML seed input declaring resources created at startup (filepath or inline YAML)")
 	spotReclaimAfter  = flag.String("spot-reclaim-after", "", "Delay before simulated AWS spot reclaim termination (disabled when empty)")
 	spotReclaimNotice = flag.String("spot-reclaim-notice", "", "Interruption notice window before simulated spot reclaim termination")
+	scaleInDrainDelay = flag.String("scale-in-drain-delay", "", "Time instances removed by ASG scale-in stay in Terminating:Wait before termination (disabled when empty)")
+	passwordDataDelay = flag.String("password-data-delay", "", "Time instances must be running before GetPasswordData returns their password (available right away when empty)")
+	defaultArch       = flag.String("default-architecture", "", "Architecture instances run by default: x86_64|arm64|i386 (optional; defaults to the image architecture)")
+	noResourceLimits  = flag.Bool("disable-resource-limits", false, "Launch instances without the CPU and memory limits of their instance type")
+	metrics           = flag.Bool("metrics", false, "Serve Prometheus metrics at /metrics")
+	eventStream       = flag.Bool("event-stream", false, "Stream resource state changes as Server-Sent Events at /events")
 )
 
 func main() {
@@ -82,6 +95,27 @@ func main() {
 		workloadNetwork = strings.TrimSpace(os.Getenv("INSTANCE_NETWORK"))
 	}
 
+	mainVolumeHostPath := strings.TrimSpace(*mainVolumePath)
+	if mainVolumeHostPath == "" {
+		mainVolumeHostPath = strings.TrimSpace(os.Getenv("DC2_MAIN_VOLUME_HOST_PATH"))
+	}
+	mainImageName := strings.TrimSpace(*mainImage)
+	if mainImageName == "" {
+		mainImageName = strings.TrimSpace(os.Getenv("DC2_MAIN_IMAGE"))
+	}
+	volumeRootPath := strings.TrimSpace(*volumeRoot)
+	if volumeRootPath == "" {
+		volumeRootPath = strings.TrimSpace(os.Getenv("DC2_VOLUME_ROOT"))
+	}
+	publishPortValue, err := parseOptionalPort(*publishPort, "DC2_PUBLISH_PORT")
+	if err != nil {
+		log.Fatal(err)
+	}
+	stateFilePath := strings.TrimSpace(*statePath)
+	if stateFilePath == "" {
+		stateFilePath = strings.TrimSpace(os.Getenv("DC2_STATE_PATH"))
+	}
+
 	exitModeRaw := strings.TrimSpace(*exitResourceMode)
 	if exitModeRaw == "" {
 		exitModeRaw = strings.TrimSpace(os.Getenv("DC2_EXIT_RESOURCE_MODE"))
@@ -97,6 +131,10 @@ func main() {
 	if testProfileInput == "" {
 		testProfileInput = strings.TrimSpace(os.Getenv("DC2_TEST_PROFILE"))
 	}
+	seedInput := strings.TrimSpace(*seed)
+	if seedInput == "" {
+		seedInput = strings.TrimSpace(os.Getenv("DC2_SEED"))
+	}
 	spotReclaimAfterValue, err := parseOptionalDuration(*spotReclaimAfter, "DC2_SPOT_RECLAIM_AFTER")
 	if err != nil {
 		log.Fatal(err)
@@ -111,30 +149,112 @@ func main() {
 	if spotReclaimNoticeValue < 0 {
 		log.Fatal("spot reclaim notice duration must be >= 0")
 	}
+	scaleInDrainDelayValue, err := parseOptionalDuration(*scaleInDrainDelay, "DC2_SCALE_IN_DRAIN_DELAY")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if scaleInDrainDelayValue < 0 {
+		log.Fatal("scale-in drain delay must be >= 0")
+	}
+	passwordDataDelayValue, err := parseOptionalDuration(*passwordDataDelay, "DC2_PASSWORD_DATA_DELAY")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if passwordDataDelayValue < 0 {
+		log.Fatal("password data delay must be >= 0")
+	}
+	defaultArchitecture := strings.TrimSpace(*defaultArch)
+	if defaultArchitecture == "" {
+		defaultArchitecture = strings.TrimSpace(os.Getenv("DC2_DEFAULT_ARCHITECTURE"))
+	}
+	disableResourceLimits, err := parseOptionalBool(*noResourceLimits, "DC2_DISABLE_RESOURCE_LIMITS")
+	if err != nil {
+		log.Fatal(err)
+	}
+	metricsEnabled, err := parseOptionalBool(*metrics, "DC2_METRICS")
+	if err != nil {
+		log.Fatal(err)
+	}
+	eventStreamEnabled, err := parseOptionalBool(*eventStream, "DC2_EVENT_STREAM")
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	slog.Debug(
 		"starting server",
 		slog.String("addr", listenAddr),
 		slog.String("instance_network", workloadNetwork),
+		slog.String("main_volume_host_path", mainVolumeHostPath),
+		slog.String("main_image", mainImageName),
+		slog.String("volume_root", volumeRootPath),
+		slog.Int("publish_port", publishPortValue),
+		slog.String("state_path", stateFilePath),
 		slog.String("exit_resource_mode", string(exitMode)),
 		slog.String("test_profile", testProfileInput),
+		slog.String("seed", seedInput),
 		slog.Duration("spot_reclaim_after", spotReclaimAfterValue),
 		slog.Duration("spot_reclaim_notice", spotReclaimNoticeValue),
+		slog.Duration("scale_in_drain_delay", scaleInDrainDelayValue),
+		slog.Duration("password_data_delay", passwordDataDelayValue),
+		slog.String("default_architecture", defaultArchitecture),
+		slog.Bool("disable_resource_limits", disableResourceLimits),
+		slog.Bool("metrics", metricsEnabled),
+		slog.Bool("event_stream", eventStreamEnabled),
 	)
 
 	opts := []dc2.Option{}
 	if workloadNetwork != "" {
 		opts = append(opts, dc2.WithInstanceNetwork(workloadNetwork))
 	}
+	if mainVolumeHostPath != "" {
+		opts = append(opts, dc2.WithMainVolumeHostPath(mainVolumeHostPath))
+	}
+	if mainImageName != "" {
+		opts = append(opts, dc2.WithMainImage(mainImageName))
+	}
+	if volumeRootPath != "" {
+		opts = append(opts, dc2.WithVolumeRoot(volumeRootPath))
+	}
+	if publishPortValue > 0 {
+		opts = append(opts, dc2.WithPublishPort(publishPortValue))
+	}
+	if stateFilePath != "" {
+		opts = append(opts, dc2.WithStatePath(stateFilePath))
+	}
 	if testProfileInput != "" {
 		opts = append(opts, dc2.WithTestProfileInput(testProfileInput))
 	}
+	if seedInput != "" {
+		startupSeed, err := dc2.LoadSeed(seedInput)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts = append(opts, dc2.WithSeed(startupSeed))
+	}
 	if spotReclaimAfterValue > 0 {
 		opts = append(opts, dc2.WithSpotReclaimAfter(spotReclaimAfterValue))
 	}
 	if strings.TrimSpace(*spotReclaimNotice) != "" || strings.TrimSpace(os.Getenv("DC2_SPOT_RECLAIM_NOTICE")) != "" {
 		opts = append(opts, dc2.WithSpotReclaimNotice(spotReclaimNoticeValue))
 	}
+	if scaleInDrainDelayValue > 0 {
+		opts = append(opts, dc2.WithScaleInDrainDelay(scaleInDrainDelayValue))
+	}
+	if passwordDataDelayValue > 0 {
+		opts = append(opts, dc2.WithPasswordDataDelay(passwordDataDelayValue))
+	}
+	if defaultArchitecture != "" {
+		opts = append(opts, dc2.WithDefaultArchitecture(defaultArchitecture))
+	}
+	if disableResourceLimits {
+		opts = append(opts, dc2.WithoutResourceLimits())
+	}
+	if metricsEnabled {
+		opts = append(opts, dc2.WithMetrics())
+	}
+	if eventStreamEnabled {
+		opts = append(opts, dc2.WithEventStream())
+	}
 	opts = append(opts, dc2.WithExitResourceMode(exitMode))
 	srv, err := dc2.NewServer(listenAddr, opts...)
 	if err != nil {
@@ -236,3 +356,37 @@ func parseOptionalDuration(flagValue string, envVar string) (time.Duration, erro
 	}
 	return d, nil
 }
+
+// parseOptionalPort parses flagValue as a TCP port number, falling back to
+// the value of envVar. It returns zero when neither is set.
+func parseOptionalPort(flagValue string, envVar string) (int, error) {
+	raw := strings.TrimSpace(flagValue)
+	if raw == "" {
+		raw = strings.TrimSpace(os.Getenv(envVar))
+	}
+	if raw == "" {
+		return 0, nil
+	}
+	port, err := strconv.ParseUint(raw, 10, 16)
+	if err != nil || port == 0 {
+		return 0, fmt.Errorf("invalid port for %s: %q", envVar, raw)
+	}
+	return int(port), nil
+}
+
+// parseOptionalBool returns flagValue when set, falling back to the value of
+// envVar.
+func parseOptionalBool(flagValue bool, envVar string) (bool, error) {
+	if flagValue {
+		return true, nil
+	}
+	raw := strings.TrimSpace(os.Getenv(envVar))
+	if raw == "" {
+		return false, nil
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("invalid boolean for %s: %w", envVar, err)
+	}
+	return v, nil
+}
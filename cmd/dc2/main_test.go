@@ -122,3 +122,75 @@ func TestParseOptionalDuration(t *testing.T) {
 		assert.Contains(t, err.Error(), "invalid duration for "+envKey)
 	})
 }
+
+func TestParseOptionalBool(t *testing.T) {
+	const envKey = "DC2_TEST_PARSE_OPTIONAL_BOOL"
+
+	t.Run("returns false when unset", func(t *testing.T) {
+		t.Parallel()
+
+		got, err := parseOptionalBool(false, envKey)
+		require.NoError(t, err)
+		assert.False(t, got)
+	})
+
+	t.Run("flag value overrides env", func(t *testing.T) {
+		t.Setenv(envKey, "false")
+
+		got, err := parseOptionalBool(true, envKey)
+		require.NoError(t, err)
+		assert.True(t, got)
+	})
+
+	t.Run("uses env when flag is unset", func(t *testing.T) {
+		t.Setenv(envKey, "true")
+
+		got, err := parseOptionalBool(false, envKey)
+		require.NoError(t, err)
+		assert.True(t, got)
+	})
+
+	t.Run("returns parse error for invalid env", func(t *testing.T) {
+		t.Setenv(envKey, "maybe")
+
+		_, err := parseOptionalBool(false, envKey)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid boolean for "+envKey)
+	})
+}
+
+func TestParseOptionalPort(t *testing.T) {
+	const envKey = "DC2_TEST_PARSE_OPTIONAL_PORT"
+
+	t.Run("returns zero when unset", func(t *testing.T) {
+		t.Parallel()
+
+		got, err := parseOptionalPort("", envKey)
+		require.NoError(t, err)
+		assert.Zero(t, got)
+	})
+
+	t.Run("flag value overrides env", func(t *testing.T) {
+		t.Setenv(envKey, "8080")
+
+		got, err := parseOptionalPort("80", envKey)
+		require.NoError(t, err)
+		assert.Equal(t, 80, got)
+	})
+
+	t.Run("uses env when flag is unset", func(t *testing.T) {
+		t.Setenv(envKey, "8080")
+
+		got, err := parseOptionalPort("", envKey)
+		require.NoError(t, err)
+		assert.Equal(t, 8080, got)
+	})
+
+	t.Run("returns error for invalid ports", func(t *testing.T) {
+		for _, value := range []string{"http", "0", "-1", "65536"} {
+			_, err := parseOptionalPort(value, envKey)
+			require.Error(t, err, value)
+			assert.Contains(t, err.Error(), "invalid port for "+envKey)
+		}
+	})
+}
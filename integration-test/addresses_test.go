@@ -0,0 +1,91 @@
+package dc2_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestElasticIPAddressLifecycle(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		allocOut, err := e.Client.AllocateAddress(ctx, &ec2.AllocateAddressInput{
+			Domain: ec2types.DomainTypeVpc,
+		})
+		require.NoError(t, err)
+		allocationID := aws.ToString(allocOut.AllocationId)
+		publicIP := aws.ToString(allocOut.PublicIp)
+		require.NotEmpty(t, allocationID)
+		require.NotEmpty(t, publicIP)
+		t.Cleanup(func() {
+			cleanupCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, _ = e.Client.ReleaseAddress(cleanupCtx, &ec2.ReleaseAddressInput{AllocationId: aws.String(allocationID)})
+		})
+
+		runOut, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:      aws.String("nginx"),
+			InstanceType: "my-type",
+			MinCount:     aws.Int32(1),
+			MaxCount:     aws.Int32(1),
+		})
+		require.NoError(t, err)
+		require.Len(t, runOut.Instances, 1)
+		instanceID := aws.ToString(runOut.Instances[0].InstanceId)
+		t.Cleanup(func() {
+			cleanupCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, err := e.Client.TerminateInstances(cleanupCtx, &ec2.TerminateInstancesInput{InstanceIds: []string{instanceID}})
+			if err != nil && !isInstanceNotFound(err) {
+				assert.NoError(t, err)
+			}
+		})
+
+		assocOut, err := e.Client.AssociateAddress(ctx, &ec2.AssociateAddressInput{
+			AllocationId: aws.String(allocationID),
+			InstanceId:   aws.String(instanceID),
+		})
+		require.NoError(t, err)
+		associationID := aws.ToString(assocOut.AssociationId)
+		require.NotEmpty(t, associationID)
+
+		describeInstancesOut, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+			InstanceIds: []string{instanceID},
+		})
+		require.NoError(t, err)
+		require.Len(t, describeInstancesOut.Reservations, 1)
+		require.Len(t, describeInstancesOut.Reservations[0].Instances, 1)
+		instance := describeInstancesOut.Reservations[0].Instances[0]
+		assert.Equal(t, publicIP, aws.ToString(instance.PublicIpAddress))
+		assert.Contains(t, aws.ToString(instance.PublicDnsName), "ec2-")
+
+		describeOut, err := e.Client.DescribeAddresses(ctx, &ec2.DescribeAddressesInput{
+			Filters: []ec2types.Filter{{Name: aws.String("instance-id"), Values: []string{instanceID}}},
+		})
+		require.NoError(t, err)
+		require.Len(t, describeOut.Addresses, 1)
+		assert.Equal(t, allocationID, aws.ToString(describeOut.Addresses[0].AllocationId))
+		assert.Equal(t, associationID, aws.ToString(describeOut.Addresses[0].AssociationId))
+		assert.Equal(t, publicIP, aws.ToString(describeOut.Addresses[0].PublicIp))
+
+		_, err = e.Client.ReleaseAddress(ctx, &ec2.ReleaseAddressInput{AllocationId: aws.String(allocationID)})
+		var apiErr smithy.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "InvalidIPAddress.InUse", apiErr.ErrorCode())
+
+		_, err = e.Client.DisassociateAddress(ctx, &ec2.DisassociateAddressInput{AssociationId: aws.String(associationID)})
+		require.NoError(t, err)
+		_, err = e.Client.ReleaseAddress(ctx, &ec2.ReleaseAddressInput{AllocationId: aws.String(allocationID)})
+		require.NoError(t, err)
+
+		_, err = e.Client.DescribeAddresses(ctx, &ec2.DescribeAddressesInput{AllocationIds: []string{allocationID}})
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "InvalidAllocationID.NotFound", apiErr.ErrorCode())
+	})
+}
@@ -50,7 +50,7 @@ func TestAutoScalingGroupLifecycle(t *testing.T) {
 			MinSize:              aws.Int32(1),
 			MaxSize:              aws.Int32(3),
 			DesiredCapacity:      aws.Int32(1),
-			VPCZoneIdentifier:    aws.String("subnet-dc2"),
+			VPCZoneIdentifier:    aws.String(defaultSubnetID),
 			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
 				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
 				Version:          aws.String("$Default"),
@@ -130,6 +130,342 @@ func TestAutoScalingGroupLifecycle(t *testing.T) {
 	})
 }
 
+func TestDescribeScalingActivities(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		launchTemplateName := fmt.Sprintf("lt-asg-activities-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+		autoScalingGroupName := fmt.Sprintf("asg-activities-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+
+		lt, err := e.Client.CreateLaunchTemplate(ctx, &ec2.CreateLaunchTemplateInput{
+			LaunchTemplateName: aws.String(launchTemplateName),
+			LaunchTemplateData: &ec2types.RequestLaunchTemplateData{
+				ImageId:      aws.String("nginx"),
+				InstanceType: ec2types.InstanceTypeA1Large,
+			},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, lt.LaunchTemplate)
+
+		_, err = e.AutoScalingClient.CreateAutoScalingGroup(ctx, &autoscaling.CreateAutoScalingGroupInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			MinSize:              aws.Int32(0),
+			MaxSize:              aws.Int32(1),
+			DesiredCapacity:      aws.Int32(0),
+			VPCZoneIdentifier:    aws.String(defaultSubnetID),
+			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
+				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
+				Version:          aws.String("$Default"),
+			},
+		})
+		require.NoError(t, err)
+
+		t.Cleanup(func() {
+			cleanupAutoScalingGroup(t, e, autoScalingGroupName)
+		})
+
+		for _, desiredCapacity := range []int32{1, 0} {
+			_, err = e.AutoScalingClient.SetDesiredCapacity(ctx, &autoscaling.SetDesiredCapacityInput{
+				AutoScalingGroupName: aws.String(autoScalingGroupName),
+				DesiredCapacity:      aws.Int32(desiredCapacity),
+			})
+			require.NoError(t, err)
+		}
+
+		out, err := e.AutoScalingClient.DescribeScalingActivities(ctx, &autoscaling.DescribeScalingActivitiesInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+		})
+		require.NoError(t, err)
+		require.Len(t, out.Activities, 2)
+		assert.Contains(t, aws.ToString(out.Activities[0].Cause), "shrinking the capacity from 1 to 0")
+		assert.Contains(t, aws.ToString(out.Activities[1].Cause), "increasing the capacity from 0 to 1")
+		for _, activity := range out.Activities {
+			assert.NotEmpty(t, aws.ToString(activity.ActivityId))
+			assert.Equal(t, autoScalingGroupName, aws.ToString(activity.AutoScalingGroupName))
+			assert.Equal(t, autoscalingtypes.ScalingActivityStatusCodeSuccessful, activity.StatusCode)
+			assert.NotNil(t, activity.StartTime)
+			assert.NotNil(t, activity.EndTime)
+		}
+	})
+}
+
+func TestDescribeAutoScalingInstances(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		launchTemplateName := fmt.Sprintf("lt-asg-instances-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+		autoScalingGroupName := fmt.Sprintf("asg-instances-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+
+		lt, err := e.Client.CreateLaunchTemplate(ctx, &ec2.CreateLaunchTemplateInput{
+			LaunchTemplateName: aws.String(launchTemplateName),
+			LaunchTemplateData: &ec2types.RequestLaunchTemplateData{
+				ImageId:      aws.String("nginx"),
+				InstanceType: ec2types.InstanceTypeA1Large,
+			},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, lt.LaunchTemplate)
+
+		_, err = e.AutoScalingClient.CreateAutoScalingGroup(ctx, &autoscaling.CreateAutoScalingGroupInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			MinSize:              aws.Int32(2),
+			MaxSize:              aws.Int32(2),
+			DesiredCapacity:      aws.Int32(2),
+			VPCZoneIdentifier:    aws.String(defaultSubnetID),
+			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
+				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
+				Version:          aws.String("$Default"),
+			},
+		})
+		require.NoError(t, err)
+
+		t.Cleanup(func() {
+			cleanupAutoScalingGroup(t, e, autoScalingGroupName)
+		})
+
+		describeResp, err := e.AutoScalingClient.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
+			AutoScalingGroupNames: []string{autoScalingGroupName},
+		})
+		require.NoError(t, err)
+		require.Len(t, describeResp.AutoScalingGroups, 1)
+		require.Len(t, describeResp.AutoScalingGroups[0].Instances, 2)
+		instanceIDs := make([]string, 0, 2)
+		for _, instance := range describeResp.AutoScalingGroups[0].Instances {
+			instanceIDs = append(instanceIDs, aws.ToString(instance.InstanceId))
+		}
+
+		out, err := e.AutoScalingClient.DescribeAutoScalingInstances(ctx, &autoscaling.DescribeAutoScalingInstancesInput{
+			InstanceIds: instanceIDs,
+		})
+		require.NoError(t, err)
+		require.Len(t, out.AutoScalingInstances, 2)
+		for _, instance := range out.AutoScalingInstances {
+			assert.Contains(t, instanceIDs, aws.ToString(instance.InstanceId))
+			assert.Equal(t, autoScalingGroupName, aws.ToString(instance.AutoScalingGroupName))
+			assert.Equal(t, "InService", aws.ToString(instance.LifecycleState))
+			assert.Equal(t, "HEALTHY", aws.ToString(instance.HealthStatus))
+		}
+	})
+}
+
+func TestAutoScalingSetInstanceProtection(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		launchTemplateName := fmt.Sprintf("lt-asg-protection-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+		autoScalingGroupName := fmt.Sprintf("asg-protection-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+
+		lt, err := e.Client.CreateLaunchTemplate(ctx, &ec2.CreateLaunchTemplateInput{
+			LaunchTemplateName: aws.String(launchTemplateName),
+			LaunchTemplateData: &ec2types.RequestLaunchTemplateData{
+				ImageId:      aws.String("nginx"),
+				InstanceType: ec2types.InstanceTypeA1Large,
+			},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, lt.LaunchTemplate)
+
+		_, err = e.AutoScalingClient.CreateAutoScalingGroup(ctx, &autoscaling.CreateAutoScalingGroupInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			MinSize:              aws.Int32(0),
+			MaxSize:              aws.Int32(2),
+			DesiredCapacity:      aws.Int32(2),
+			VPCZoneIdentifier:    aws.String(defaultSubnetID),
+			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
+				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
+				Version:          aws.String("$Default"),
+			},
+		})
+		require.NoError(t, err)
+
+		t.Cleanup(func() {
+			cleanupAutoScalingGroup(t, e, autoScalingGroupName)
+		})
+
+		describeResp, err := e.AutoScalingClient.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
+			AutoScalingGroupNames: []string{autoScalingGroupName},
+		})
+		require.NoError(t, err)
+		require.Len(t, describeResp.AutoScalingGroups, 1)
+		require.Len(t, describeResp.AutoScalingGroups[0].Instances, 2)
+		protectedID := aws.ToString(describeResp.AutoScalingGroups[0].Instances[0].InstanceId)
+
+		_, err = e.AutoScalingClient.SetInstanceProtection(ctx, &autoscaling.SetInstanceProtectionInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			InstanceIds:          []string{protectedID},
+			ProtectedFromScaleIn: aws.Bool(true),
+		})
+		require.NoError(t, err)
+
+		_, err = e.AutoScalingClient.SetDesiredCapacity(ctx, &autoscaling.SetDesiredCapacityInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			DesiredCapacity:      aws.Int32(1),
+		})
+		require.NoError(t, err)
+
+		describeResp, err = e.AutoScalingClient.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
+			AutoScalingGroupNames: []string{autoScalingGroupName},
+		})
+		require.NoError(t, err)
+		require.Len(t, describeResp.AutoScalingGroups, 1)
+		require.Len(t, describeResp.AutoScalingGroups[0].Instances, 1)
+		instance := describeResp.AutoScalingGroups[0].Instances[0]
+		assert.Equal(t, protectedID, aws.ToString(instance.InstanceId))
+		assert.True(t, aws.ToBool(instance.ProtectedFromScaleIn))
+	})
+}
+
+func TestAutoScalingELBHealthCheckReplacesFailingInstances(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		launchTemplateName := fmt.Sprintf("lt-asg-elb-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+		autoScalingGroupName := fmt.Sprintf("asg-elb-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+
+		lt, err := e.Client.CreateLaunchTemplate(ctx, &ec2.CreateLaunchTemplateInput{
+			LaunchTemplateName: aws.String(launchTemplateName),
+			LaunchTemplateData: &ec2types.RequestLaunchTemplateData{
+				ImageId:      aws.String("nginx"),
+				InstanceType: ec2types.InstanceTypeA1Large,
+			},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, lt.LaunchTemplate)
+
+		// nginx answers 404 for this path, failing the health check
+		_, err = e.AutoScalingClient.CreateAutoScalingGroup(ctx, &autoscaling.CreateAutoScalingGroupInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			MinSize:              aws.Int32(1),
+			MaxSize:              aws.Int32(1),
+			DesiredCapacity:      aws.Int32(1),
+			VPCZoneIdentifier:    aws.String(defaultSubnetID),
+			HealthCheckType:      aws.String("ELB"),
+			TargetGroupARNs:      []string{"http://:80/missing-health-check"},
+			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
+				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
+				Version:          aws.String("$Default"),
+			},
+		})
+		require.NoError(t, err)
+
+		t.Cleanup(func() {
+			cleanupAutoScalingGroup(t, e, autoScalingGroupName)
+		})
+
+		describeResp, err := e.AutoScalingClient.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
+			AutoScalingGroupNames: []string{autoScalingGroupName},
+		})
+		require.NoError(t, err)
+		require.Len(t, describeResp.AutoScalingGroups, 1)
+		group := describeResp.AutoScalingGroups[0]
+		assert.Equal(t, "ELB", aws.ToString(group.HealthCheckType))
+		assert.Equal(t, []string{"http://:80/missing-health-check"}, group.TargetGroupARNs)
+
+		require.Eventually(t, func() bool {
+			out, err := e.AutoScalingClient.DescribeScalingActivities(ctx, &autoscaling.DescribeScalingActivitiesInput{
+				AutoScalingGroupName: aws.String(autoScalingGroupName),
+			})
+			if err != nil {
+				return false
+			}
+			for _, activity := range out.Activities {
+				if strings.Contains(aws.ToString(activity.Cause), "ELB") {
+					return true
+				}
+			}
+			return false
+		}, 30*time.Second, 250*time.Millisecond)
+	})
+}
+
+func TestAutoScalingSimpleScalingPolicy(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		launchTemplateName := fmt.Sprintf("lt-asg-policy-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+		autoScalingGroupName := fmt.Sprintf("asg-policy-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+
+		lt, err := e.Client.CreateLaunchTemplate(ctx, &ec2.CreateLaunchTemplateInput{
+			LaunchTemplateName: aws.String(launchTemplateName),
+			LaunchTemplateData: &ec2types.RequestLaunchTemplateData{
+				ImageId:      aws.String("nginx"),
+				InstanceType: ec2types.InstanceTypeA1Large,
+			},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, lt.LaunchTemplate)
+
+		_, err = e.AutoScalingClient.CreateAutoScalingGroup(ctx, &autoscaling.CreateAutoScalingGroupInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			MinSize:              aws.Int32(0),
+			MaxSize:              aws.Int32(2),
+			DesiredCapacity:      aws.Int32(0),
+			DefaultCooldown:      aws.Int32(3600),
+			VPCZoneIdentifier:    aws.String(defaultSubnetID),
+			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
+				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
+				Version:          aws.String("$Default"),
+			},
+		})
+		require.NoError(t, err)
+
+		t.Cleanup(func() {
+			cleanupAutoScalingGroup(t, e, autoScalingGroupName)
+		})
+
+		putOut, err := e.AutoScalingClient.PutScalingPolicy(ctx, &autoscaling.PutScalingPolicyInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			PolicyName:           aws.String("scale-out"),
+			PolicyType:           aws.String("SimpleScaling"),
+			AdjustmentType:       aws.String("ChangeInCapacity"),
+			ScalingAdjustment:    aws.Int32(1),
+		})
+		require.NoError(t, err)
+		assert.NotEmpty(t, aws.ToString(putOut.PolicyARN))
+
+		describeOut, err := e.AutoScalingClient.DescribePolicies(ctx, &autoscaling.DescribePoliciesInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+		})
+		require.NoError(t, err)
+		require.Len(t, describeOut.ScalingPolicies, 1)
+		assert.Equal(t, aws.ToString(putOut.PolicyARN), aws.ToString(describeOut.ScalingPolicies[0].PolicyARN))
+		assert.Equal(t, int32(1), aws.ToInt32(describeOut.ScalingPolicies[0].ScalingAdjustment))
+
+		executePolicy := func() error {
+			_, err := e.AutoScalingClient.ExecutePolicy(ctx, &autoscaling.ExecutePolicyInput{
+				AutoScalingGroupName: aws.String(autoScalingGroupName),
+				PolicyName:           aws.String("scale-out"),
+				HonorCooldown:        aws.Bool(true),
+			})
+			return err
+		}
+		desiredCapacity := func() int32 {
+			out, err := e.AutoScalingClient.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
+				AutoScalingGroupNames: []string{autoScalingGroupName},
+			})
+			require.NoError(t, err)
+			require.Len(t, out.AutoScalingGroups, 1)
+			return aws.ToInt32(out.AutoScalingGroups[0].DesiredCapacity)
+		}
+
+		require.NoError(t, executePolicy())
+		assert.Equal(t, int32(1), desiredCapacity())
+
+		// The group is still within its cooldown window
+		err = executePolicy()
+		var apiErr smithy.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "ScalingActivityInProgress", apiErr.ErrorCode())
+		assert.Equal(t, int32(1), desiredCapacity())
+
+		_, err = e.AutoScalingClient.DeletePolicy(ctx, &autoscaling.DeletePolicyInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			PolicyName:           aws.String("scale-out"),
+		})
+		require.NoError(t, err)
+		describeOut, err = e.AutoScalingClient.DescribePolicies(ctx, &autoscaling.DescribePoliciesInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+		})
+		require.NoError(t, err)
+		assert.Empty(t, describeOut.ScalingPolicies)
+	})
+}
+
 func TestAutoScalingGroupPlacementCompatibility(t *testing.T) {
 	t.Parallel()
 	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
@@ -211,7 +547,7 @@ func TestLaunchInstancesCachesByClientToken(t *testing.T) {
 			MinSize:              aws.Int32(0),
 			MaxSize:              aws.Int32(2),
 			DesiredCapacity:      aws.Int32(0),
-			VPCZoneIdentifier:    aws.String("subnet-dc2"),
+			VPCZoneIdentifier:    aws.String(defaultSubnetID),
 			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
 				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
 				Version:          aws.String("$Default"),
@@ -226,7 +562,7 @@ func TestLaunchInstancesCachesByClientToken(t *testing.T) {
 			AutoScalingGroupName: aws.String(autoScalingGroupName),
 			ClientToken:          aws.String(clientToken),
 			RequestedCapacity:    aws.Int32(1),
-			SubnetIds:            []string{"subnet-dc2"},
+			SubnetIds:            []string{defaultSubnetID},
 		})
 		require.NoError(t, err)
 		require.NotNil(t, firstResp.AutoScalingGroupName)
@@ -236,7 +572,7 @@ func TestLaunchInstancesCachesByClientToken(t *testing.T) {
 		require.Len(t, firstResp.Instances, 1)
 		require.Len(t, firstResp.Instances[0].InstanceIds, 1)
 		firstInstanceID := firstResp.Instances[0].InstanceIds[0]
-		assert.Equal(t, "subnet-dc2", aws.ToString(firstResp.Instances[0].SubnetId))
+		assert.Equal(t, defaultSubnetID, aws.ToString(firstResp.Instances[0].SubnetId))
 		assert.Equal(t, "us-east-1a", aws.ToString(firstResp.Instances[0].AvailabilityZone))
 		assert.Equal(t, "use1-az1", aws.ToString(firstResp.Instances[0].AvailabilityZoneId))
 		assert.Equal(t, "OnDemand", aws.ToString(firstResp.Instances[0].MarketType))
@@ -245,7 +581,7 @@ func TestLaunchInstancesCachesByClientToken(t *testing.T) {
 			AutoScalingGroupName: aws.String(autoScalingGroupName),
 			ClientToken:          aws.String(clientToken),
 			RequestedCapacity:    aws.Int32(1),
-			SubnetIds:            []string{"subnet-dc2"},
+			SubnetIds:            []string{defaultSubnetID},
 		})
 		require.NoError(t, err)
 		require.Len(t, secondResp.Instances, 1)
@@ -322,7 +658,8 @@ func TestAutoScalingGroupInstancesExposeSubnetMetadata(t *testing.T) {
 	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
 		launchTemplateName := fmt.Sprintf("lt-asg-subnet-%s", strings.ReplaceAll(t.Name(), "/", "-"))
 		autoScalingGroupName := fmt.Sprintf("asg-subnet-%s", strings.ReplaceAll(t.Name(), "/", "-"))
-		expectedSubnetID := "subnet-dc2"
+		vpcID, subnetIDs := createTestSubnets(t, ctx, e, "10.30.0.0/16", "us-east-1b")
+		expectedSubnetID := subnetIDs[0]
 
 		lt, err := e.Client.CreateLaunchTemplate(ctx, &ec2.CreateLaunchTemplateInput{
 			LaunchTemplateName: aws.String(launchTemplateName),
@@ -332,61 +669,597 @@ func TestAutoScalingGroupInstancesExposeSubnetMetadata(t *testing.T) {
 			},
 		})
 		require.NoError(t, err)
-		require.NotNil(t, lt.LaunchTemplate)
-		require.NotNil(t, lt.LaunchTemplate.LaunchTemplateId)
+		require.NotNil(t, lt.LaunchTemplate)
+		require.NotNil(t, lt.LaunchTemplate.LaunchTemplateId)
+
+		_, err = e.AutoScalingClient.CreateAutoScalingGroup(ctx, &autoscaling.CreateAutoScalingGroupInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			MinSize:              aws.Int32(1),
+			MaxSize:              aws.Int32(1),
+			DesiredCapacity:      aws.Int32(1),
+			VPCZoneIdentifier:    aws.String(expectedSubnetID),
+			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
+				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
+				Version:          aws.String("$Default"),
+			},
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			cleanupAutoScalingGroup(t, e, autoScalingGroupName)
+		})
+
+		var instanceID string
+		require.Eventually(t, func() bool {
+			out, err := e.AutoScalingClient.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
+				AutoScalingGroupNames: []string{autoScalingGroupName},
+			})
+			if err != nil || len(out.AutoScalingGroups) != 1 {
+				return false
+			}
+			group := out.AutoScalingGroups[0]
+			if len(group.Instances) != 1 || group.Instances[0].InstanceId == nil {
+				return false
+			}
+			instanceID = aws.ToString(group.Instances[0].InstanceId)
+			return instanceID != ""
+		}, 20*time.Second, 250*time.Millisecond)
+
+		describeOut, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+			InstanceIds: []string{instanceID},
+		})
+		require.NoError(t, err)
+		require.Len(t, describeOut.Reservations, 1)
+		require.Len(t, describeOut.Reservations[0].Instances, 1)
+		instance := describeOut.Reservations[0].Instances[0]
+		require.NotNil(t, instance.SubnetId)
+		assert.Equal(t, expectedSubnetID, aws.ToString(instance.SubnetId))
+		assert.Equal(t, vpcID, aws.ToString(instance.VpcId))
+		require.NotNil(t, instance.Placement)
+		assert.Equal(t, "us-east-1b", aws.ToString(instance.Placement.AvailabilityZone))
+	})
+}
+
+func TestAutoScalingGroupUpdateVPCZoneIdentifierReplacesInstances(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		launchTemplateName := fmt.Sprintf("lt-asg-subnet-update-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+		autoScalingGroupName := fmt.Sprintf("asg-subnet-update-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+		_, subnetIDs := createTestSubnets(t, ctx, e, "10.31.0.0/16", "us-east-1a", "us-east-1b", "us-east-1c")
+
+		lt, err := e.Client.CreateLaunchTemplate(ctx, &ec2.CreateLaunchTemplateInput{
+			LaunchTemplateName: aws.String(launchTemplateName),
+			LaunchTemplateData: &ec2types.RequestLaunchTemplateData{
+				ImageId:      aws.String("nginx"),
+				InstanceType: ec2types.InstanceTypeA1Large,
+			},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, lt.LaunchTemplate)
+
+		_, err = e.AutoScalingClient.CreateAutoScalingGroup(ctx, &autoscaling.CreateAutoScalingGroupInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			MinSize:              aws.Int32(1),
+			MaxSize:              aws.Int32(1),
+			DesiredCapacity:      aws.Int32(1),
+			VPCZoneIdentifier:    aws.String(subnetIDs[0]),
+			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
+				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
+			},
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			cleanupAutoScalingGroup(t, e, autoScalingGroupName)
+		})
+
+		describeGroup := func() autoscalingtypes.AutoScalingGroup {
+			out, err := e.AutoScalingClient.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
+				AutoScalingGroupNames: []string{autoScalingGroupName},
+			})
+			require.NoError(t, err)
+			require.Len(t, out.AutoScalingGroups, 1)
+			return out.AutoScalingGroups[0]
+		}
+		instanceSubnetID := func(instanceID string) string {
+			out, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+				InstanceIds: []string{instanceID},
+			})
+			require.NoError(t, err)
+			require.Len(t, out.Reservations, 1)
+			require.Len(t, out.Reservations[0].Instances, 1)
+			return aws.ToString(out.Reservations[0].Instances[0].SubnetId)
+		}
+		require.Eventually(t, func() bool {
+			return len(describeGroup().Instances) == 1
+		}, 20*time.Second, 250*time.Millisecond)
+		instanceID := aws.ToString(describeGroup().Instances[0].InstanceId)
+		assert.Equal(t, subnetIDs[0], instanceSubnetID(instanceID))
+
+		_, err = e.AutoScalingClient.UpdateAutoScalingGroup(ctx, &autoscaling.UpdateAutoScalingGroupInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			VPCZoneIdentifier:    aws.String(subnetIDs[1]),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, subnetIDs[1], aws.ToString(describeGroup().VPCZoneIdentifier))
+
+		var replacementID string
+		require.Eventually(t, func() bool {
+			group := describeGroup()
+			if len(group.Instances) != 1 {
+				return false
+			}
+			replacementID = aws.ToString(group.Instances[0].InstanceId)
+			return replacementID != instanceID
+		}, 30*time.Second, 250*time.Millisecond)
+		assert.Equal(t, subnetIDs[1], instanceSubnetID(replacementID))
+
+		// Adding a subnet keeps the instances in the remaining ones.
+		_, err = e.AutoScalingClient.UpdateAutoScalingGroup(ctx, &autoscaling.UpdateAutoScalingGroupInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			VPCZoneIdentifier:    aws.String(subnetIDs[1] + "," + subnetIDs[2]),
+		})
+		require.NoError(t, err)
+		group := describeGroup()
+		assert.Equal(t, subnetIDs[1]+","+subnetIDs[2], aws.ToString(group.VPCZoneIdentifier))
+		require.Len(t, group.Instances, 1)
+		assert.Equal(t, replacementID, aws.ToString(group.Instances[0].InstanceId))
+	})
+}
+
+func TestAutoScalingGroupInstancesFilterableByGroupNameTag(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		launchTemplateName := fmt.Sprintf("lt-asg-group-tag-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+		autoScalingGroupName := fmt.Sprintf("asg-group-tag-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+
+		lt, err := e.Client.CreateLaunchTemplate(ctx, &ec2.CreateLaunchTemplateInput{
+			LaunchTemplateName: aws.String(launchTemplateName),
+			LaunchTemplateData: &ec2types.RequestLaunchTemplateData{
+				ImageId:      aws.String("nginx"),
+				InstanceType: ec2types.InstanceTypeA1Large,
+			},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, lt.LaunchTemplate)
+		require.NotNil(t, lt.LaunchTemplate.LaunchTemplateId)
+
+		_, err = e.AutoScalingClient.CreateAutoScalingGroup(ctx, &autoscaling.CreateAutoScalingGroupInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			MinSize:              aws.Int32(2),
+			MaxSize:              aws.Int32(2),
+			DesiredCapacity:      aws.Int32(2),
+			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
+				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
+				Version:          aws.String("$Default"),
+			},
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			cleanupAutoScalingGroup(t, e, autoScalingGroupName)
+		})
+
+		groupOut, err := e.AutoScalingClient.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
+			AutoScalingGroupNames: []string{autoScalingGroupName},
+		})
+		require.NoError(t, err)
+		require.Len(t, groupOut.AutoScalingGroups, 1)
+		groupInstanceIDs := make([]string, 0, len(groupOut.AutoScalingGroups[0].Instances))
+		for _, instance := range groupOut.AutoScalingGroups[0].Instances {
+			require.NotNil(t, instance.InstanceId)
+			groupInstanceIDs = append(groupInstanceIDs, *instance.InstanceId)
+		}
+		require.Len(t, groupInstanceIDs, 2)
+
+		runOut, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:      aws.String("nginx"),
+			InstanceType: "my-type",
+			MinCount:     aws.Int32(1),
+			MaxCount:     aws.Int32(1),
+		})
+		require.NoError(t, err)
+		require.Len(t, runOut.Instances, 1)
+		standaloneInstanceID := aws.ToString(runOut.Instances[0].InstanceId)
+		t.Cleanup(func() {
+			cleanupCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, err := e.Client.TerminateInstances(cleanupCtx, &ec2.TerminateInstancesInput{
+				InstanceIds: []string{standaloneInstanceID},
+			})
+			if err != nil && !isInstanceNotFound(err) {
+				t.Logf("cleanup terminate standalone instance %s returned error: %v", standaloneInstanceID, err)
+			}
+		})
+
+		filteredOut, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+			Filters: []ec2types.Filter{
+				{
+					Name:   aws.String("tag:aws:autoscaling:groupName"),
+					Values: []string{autoScalingGroupName},
+				},
+			},
+		})
+		require.NoError(t, err)
+		filteredIDs := make([]string, 0)
+		for _, reservation := range filteredOut.Reservations {
+			for _, instance := range reservation.Instances {
+				require.NotNil(t, instance.InstanceId)
+				filteredIDs = append(filteredIDs, *instance.InstanceId)
+				tagsByKey := make(map[string]string)
+				for _, tag := range instance.Tags {
+					tagsByKey[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+				}
+				assert.Equal(t, autoScalingGroupName, tagsByKey["aws:autoscaling:groupName"])
+			}
+		}
+		assert.ElementsMatch(t, groupInstanceIDs, filteredIDs)
+		assert.NotContains(t, filteredIDs, standaloneInstanceID)
+
+		tagKeyOut, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+			InstanceIds: []string{standaloneInstanceID},
+			Filters: []ec2types.Filter{
+				{
+					Name:   aws.String("tag-key"),
+					Values: []string{"aws:autoscaling:groupName"},
+				},
+			},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, tagKeyOut.Reservations)
+	})
+}
+
+func TestAutoScalingGroupInstancesExposeReservedTags(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		launchTemplateName := fmt.Sprintf("lt-asg-reserved-tags-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+		autoScalingGroupName := fmt.Sprintf("asg-reserved-tags-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+
+		lt, err := e.Client.CreateLaunchTemplate(ctx, &ec2.CreateLaunchTemplateInput{
+			LaunchTemplateName: aws.String(launchTemplateName),
+			LaunchTemplateData: &ec2types.RequestLaunchTemplateData{
+				ImageId:      aws.String("nginx"),
+				InstanceType: ec2types.InstanceTypeA1Large,
+			},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, lt.LaunchTemplate)
+		launchTemplateID := aws.ToString(lt.LaunchTemplate.LaunchTemplateId)
+		require.NotEmpty(t, launchTemplateID)
+
+		_, err = e.AutoScalingClient.CreateAutoScalingGroup(ctx, &autoscaling.CreateAutoScalingGroupInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			MinSize:              aws.Int32(1),
+			MaxSize:              aws.Int32(1),
+			DesiredCapacity:      aws.Int32(1),
+			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
+				LaunchTemplateId: aws.String(launchTemplateID),
+				Version:          aws.String("$Default"),
+			},
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			cleanupAutoScalingGroup(t, e, autoScalingGroupName)
+		})
+
+		groupOut, err := e.AutoScalingClient.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
+			AutoScalingGroupNames: []string{autoScalingGroupName},
+		})
+		require.NoError(t, err)
+		require.Len(t, groupOut.AutoScalingGroups, 1)
+		require.Len(t, groupOut.AutoScalingGroups[0].Instances, 1)
+		instanceID := aws.ToString(groupOut.AutoScalingGroups[0].Instances[0].InstanceId)
+		require.NotEmpty(t, instanceID)
+
+		expectedTags := map[string]string{
+			"aws:autoscaling:groupName":     autoScalingGroupName,
+			"aws:ec2launchtemplate:id":      launchTemplateID,
+			"aws:ec2launchtemplate:version": "1",
+		}
+
+		describeOut, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+			InstanceIds: []string{instanceID},
+		})
+		require.NoError(t, err)
+		require.Len(t, describeOut.Reservations, 1)
+		require.Len(t, describeOut.Reservations[0].Instances, 1)
+		instanceTags := make(map[string]string)
+		for _, tag := range describeOut.Reservations[0].Instances[0].Tags {
+			instanceTags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+		}
+		for key, value := range expectedTags {
+			assert.Equal(t, value, instanceTags[key], "instance tag %s", key)
+		}
+
+		tagsOut, err := e.Client.DescribeTags(ctx, &ec2.DescribeTagsInput{
+			Filters: []ec2types.Filter{
+				{Name: aws.String("resource-id"), Values: []string{instanceID}},
+				{Name: aws.String("resource-type"), Values: []string{"instance"}},
+			},
+		})
+		require.NoError(t, err)
+		describedTags := make(map[string]string)
+		for _, tag := range tagsOut.Tags {
+			assert.Equal(t, instanceID, aws.ToString(tag.ResourceId))
+			assert.Equal(t, ec2types.ResourceTypeInstance, tag.ResourceType)
+			describedTags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+		}
+		for key, value := range expectedTags {
+			assert.Equal(t, value, describedTags[key], "described tag %s", key)
+		}
+
+		byKeyOut, err := e.Client.DescribeTags(ctx, &ec2.DescribeTagsInput{
+			Filters: []ec2types.Filter{
+				{Name: aws.String("key"), Values: []string{"aws:autoscaling:groupName"}},
+				{Name: aws.String("value"), Values: []string{autoScalingGroupName}},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, byKeyOut.Tags, 1)
+		assert.Equal(t, instanceID, aws.ToString(byKeyOut.Tags[0].ResourceId))
+	})
+}
+
+func TestAutoScalingGroupAllowsZeroMinAndDesired(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		launchTemplateName := fmt.Sprintf("lt-asg-zero-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+		autoScalingGroupName := fmt.Sprintf("asg-zero-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+
+		lt, err := e.Client.CreateLaunchTemplate(ctx, &ec2.CreateLaunchTemplateInput{
+			LaunchTemplateName: aws.String(launchTemplateName),
+			LaunchTemplateData: &ec2types.RequestLaunchTemplateData{
+				ImageId:      aws.String("nginx"),
+				InstanceType: ec2types.InstanceTypeA1Large,
+			},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, lt.LaunchTemplate)
+		require.NotNil(t, lt.LaunchTemplate.LaunchTemplateId)
+
+		_, err = e.AutoScalingClient.CreateAutoScalingGroup(ctx, &autoscaling.CreateAutoScalingGroupInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			MinSize:              aws.Int32(0),
+			MaxSize:              aws.Int32(2),
+			DesiredCapacity:      aws.Int32(0),
+			VPCZoneIdentifier:    aws.String(defaultSubnetID),
+			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
+				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
+				Version:          aws.String("$Default"),
+			},
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			cleanupAutoScalingGroup(t, e, autoScalingGroupName)
+		})
+
+		require.Eventually(t, func() bool {
+			out, err := e.AutoScalingClient.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
+				AutoScalingGroupNames: []string{autoScalingGroupName},
+			})
+			if err != nil || len(out.AutoScalingGroups) != 1 {
+				return false
+			}
+			group := out.AutoScalingGroups[0]
+			if group.MinSize == nil || group.MaxSize == nil || group.DesiredCapacity == nil {
+				return false
+			}
+			return *group.MinSize == 0 &&
+				*group.MaxSize == 2 &&
+				*group.DesiredCapacity == 0 &&
+				len(group.Instances) == 0
+		}, 20*time.Second, 250*time.Millisecond)
+
+		_, err = e.AutoScalingClient.SetDesiredCapacity(ctx, &autoscaling.SetDesiredCapacityInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			DesiredCapacity:      aws.Int32(1),
+		})
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			out, err := e.AutoScalingClient.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
+				AutoScalingGroupNames: []string{autoScalingGroupName},
+			})
+			if err != nil || len(out.AutoScalingGroups) != 1 {
+				return false
+			}
+			group := out.AutoScalingGroups[0]
+			return group.DesiredCapacity != nil &&
+				*group.DesiredCapacity == 1 &&
+				len(group.Instances) == 1
+		}, 20*time.Second, 250*time.Millisecond)
+
+		_, err = e.AutoScalingClient.SetDesiredCapacity(ctx, &autoscaling.SetDesiredCapacityInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			DesiredCapacity:      aws.Int32(0),
+		})
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			out, err := e.AutoScalingClient.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
+				AutoScalingGroupNames: []string{autoScalingGroupName},
+			})
+			if err != nil || len(out.AutoScalingGroups) != 1 {
+				return false
+			}
+			group := out.AutoScalingGroups[0]
+			return group.DesiredCapacity != nil &&
+				*group.DesiredCapacity == 0 &&
+				len(group.Instances) == 0
+		}, 20*time.Second, 250*time.Millisecond)
+	})
+}
+
+func TestAutoScalingDescribeLifecycleHookTypes(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		out, err := e.AutoScalingClient.DescribeLifecycleHookTypes(ctx, &autoscaling.DescribeLifecycleHookTypesInput{})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{
+			"autoscaling:EC2_INSTANCE_LAUNCHING",
+			"autoscaling:EC2_INSTANCE_TERMINATING",
+		}, out.LifecycleHookTypes)
+	})
+}
+
+func TestAutoScalingLifecycleHooksHoldInstancesInWaitStates(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		launchTemplateName := fmt.Sprintf("lt-asg-hooks-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+		autoScalingGroupName := fmt.Sprintf("asg-hooks-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+
+		lt, err := e.Client.CreateLaunchTemplate(ctx, &ec2.CreateLaunchTemplateInput{
+			LaunchTemplateName: aws.String(launchTemplateName),
+			LaunchTemplateData: &ec2types.RequestLaunchTemplateData{
+				ImageId:      aws.String("nginx"),
+				InstanceType: ec2types.InstanceTypeA1Large,
+			},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, lt.LaunchTemplate)
+
+		_, err = e.AutoScalingClient.CreateAutoScalingGroup(ctx, &autoscaling.CreateAutoScalingGroupInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			MinSize:              aws.Int32(0),
+			MaxSize:              aws.Int32(1),
+			DesiredCapacity:      aws.Int32(0),
+			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
+				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
+			},
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			cleanupAutoScalingGroup(t, e, autoScalingGroupName)
+		})
+
+		_, err = e.AutoScalingClient.PutLifecycleHook(ctx, &autoscaling.PutLifecycleHookInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			LifecycleHookName:    aws.String("launching"),
+			LifecycleTransition:  aws.String("autoscaling:EC2_INSTANCE_LAUNCHING"),
+			DefaultResult:        aws.String("CONTINUE"),
+			HeartbeatTimeout:     aws.Int32(300),
+			NotificationMetadata: aws.String("bootstrap"),
+		})
+		require.NoError(t, err)
+		_, err = e.AutoScalingClient.PutLifecycleHook(ctx, &autoscaling.PutLifecycleHookInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			LifecycleHookName:    aws.String("terminating"),
+			LifecycleTransition:  aws.String("autoscaling:EC2_INSTANCE_TERMINATING"),
+		})
+		require.NoError(t, err)
+
+		hooksOut, err := e.AutoScalingClient.DescribeLifecycleHooks(ctx, &autoscaling.DescribeLifecycleHooksInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+		})
+		require.NoError(t, err)
+		require.Len(t, hooksOut.LifecycleHooks, 2)
+		launchingHook := hooksOut.LifecycleHooks[0]
+		assert.Equal(t, "launching", aws.ToString(launchingHook.LifecycleHookName))
+		assert.Equal(t, "autoscaling:EC2_INSTANCE_LAUNCHING", aws.ToString(launchingHook.LifecycleTransition))
+		assert.Equal(t, "CONTINUE", aws.ToString(launchingHook.DefaultResult))
+		assert.Equal(t, int32(300), aws.ToInt32(launchingHook.HeartbeatTimeout))
+		assert.Equal(t, int32(30000), aws.ToInt32(launchingHook.GlobalTimeout))
+		assert.Equal(t, "bootstrap", aws.ToString(launchingHook.NotificationMetadata))
+		terminatingHook := hooksOut.LifecycleHooks[1]
+		assert.Equal(t, "terminating", aws.ToString(terminatingHook.LifecycleHookName))
+		assert.Equal(t, "ABANDON", aws.ToString(terminatingHook.DefaultResult))
+		assert.Equal(t, int32(3600), aws.ToInt32(terminatingHook.HeartbeatTimeout))
+
+		_, err = e.AutoScalingClient.SetDesiredCapacity(ctx, &autoscaling.SetDesiredCapacityInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			DesiredCapacity:      aws.Int32(1),
+		})
+		require.NoError(t, err)
+
+		instanceLifecycleState := func() (string, autoscalingtypes.LifecycleState) {
+			out, err := e.AutoScalingClient.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
+				AutoScalingGroupNames: []string{autoScalingGroupName},
+			})
+			if err != nil || len(out.AutoScalingGroups) != 1 || len(out.AutoScalingGroups[0].Instances) != 1 {
+				return "", ""
+			}
+			instance := out.AutoScalingGroups[0].Instances[0]
+			return aws.ToString(instance.InstanceId), instance.LifecycleState
+		}
+
+		var instanceID string
+		require.Eventually(t, func() bool {
+			var state autoscalingtypes.LifecycleState
+			instanceID, state = instanceLifecycleState()
+			return state == autoscalingtypes.LifecycleStatePendingWait
+		}, 20*time.Second, 250*time.Millisecond)
+		require.Never(t, func() bool {
+			_, state := instanceLifecycleState()
+			return state != autoscalingtypes.LifecycleStatePendingWait
+		}, time.Second, 250*time.Millisecond)
+
+		_, err = e.AutoScalingClient.RecordLifecycleActionHeartbeat(ctx, &autoscaling.RecordLifecycleActionHeartbeatInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			LifecycleHookName:    aws.String("launching"),
+			InstanceId:           aws.String(instanceID),
+		})
+		require.NoError(t, err)
+		_, err = e.AutoScalingClient.CompleteLifecycleAction(ctx, &autoscaling.CompleteLifecycleActionInput{
+			AutoScalingGroupName:  aws.String(autoScalingGroupName),
+			LifecycleHookName:     aws.String("launching"),
+			InstanceId:            aws.String(instanceID),
+			LifecycleActionResult: aws.String("CONTINUE"),
+		})
+		require.NoError(t, err)
+		id, state := instanceLifecycleState()
+		assert.Equal(t, instanceID, id)
+		assert.Equal(t, autoscalingtypes.LifecycleStateInService, state)
+
+		_, err = e.AutoScalingClient.CompleteLifecycleAction(ctx, &autoscaling.CompleteLifecycleActionInput{
+			AutoScalingGroupName:  aws.String(autoScalingGroupName),
+			LifecycleHookName:     aws.String("launching"),
+			InstanceId:            aws.String(instanceID),
+			LifecycleActionResult: aws.String("CONTINUE"),
+		})
+		var apiErr smithy.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "ValidationError", apiErr.ErrorCode())
+
+		_, err = e.AutoScalingClient.SetDesiredCapacity(ctx, &autoscaling.SetDesiredCapacityInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			DesiredCapacity:      aws.Int32(0),
+		})
+		require.NoError(t, err)
+		id, state = instanceLifecycleState()
+		assert.Equal(t, instanceID, id)
+		assert.Equal(t, autoscalingtypes.LifecycleStateTerminatingWait, state)
+
+		_, err = e.AutoScalingClient.CompleteLifecycleAction(ctx, &autoscaling.CompleteLifecycleActionInput{
+			AutoScalingGroupName:  aws.String(autoScalingGroupName),
+			LifecycleHookName:     aws.String("terminating"),
+			InstanceId:            aws.String(instanceID),
+			LifecycleActionResult: aws.String("CONTINUE"),
+		})
+		require.NoError(t, err)
+		out, err := e.AutoScalingClient.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
+			AutoScalingGroupNames: []string{autoScalingGroupName},
+		})
+		require.NoError(t, err)
+		require.Len(t, out.AutoScalingGroups, 1)
+		assert.Empty(t, out.AutoScalingGroups[0].Instances)
 
-		_, err = e.AutoScalingClient.CreateAutoScalingGroup(ctx, &autoscaling.CreateAutoScalingGroupInput{
+		_, err = e.AutoScalingClient.DeleteLifecycleHook(ctx, &autoscaling.DeleteLifecycleHookInput{
 			AutoScalingGroupName: aws.String(autoScalingGroupName),
-			MinSize:              aws.Int32(1),
-			MaxSize:              aws.Int32(1),
-			DesiredCapacity:      aws.Int32(1),
-			VPCZoneIdentifier:    aws.String(expectedSubnetID),
-			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
-				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
-				Version:          aws.String("$Default"),
-			},
+			LifecycleHookName:    aws.String("launching"),
 		})
 		require.NoError(t, err)
-		t.Cleanup(func() {
-			cleanupAutoScalingGroup(t, e, autoScalingGroupName)
-		})
-
-		var instanceID string
-		require.Eventually(t, func() bool {
-			out, err := e.AutoScalingClient.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
-				AutoScalingGroupNames: []string{autoScalingGroupName},
-			})
-			if err != nil || len(out.AutoScalingGroups) != 1 {
-				return false
-			}
-			group := out.AutoScalingGroups[0]
-			if len(group.Instances) != 1 || group.Instances[0].InstanceId == nil {
-				return false
-			}
-			instanceID = aws.ToString(group.Instances[0].InstanceId)
-			return instanceID != ""
-		}, 20*time.Second, 250*time.Millisecond)
-
-		describeOut, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
-			InstanceIds: []string{instanceID},
+		hooksOut, err = e.AutoScalingClient.DescribeLifecycleHooks(ctx, &autoscaling.DescribeLifecycleHooksInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
 		})
 		require.NoError(t, err)
-		require.Len(t, describeOut.Reservations, 1)
-		require.Len(t, describeOut.Reservations[0].Instances, 1)
-		instance := describeOut.Reservations[0].Instances[0]
-		require.NotNil(t, instance.SubnetId)
-		assert.Equal(t, expectedSubnetID, aws.ToString(instance.SubnetId))
-		require.NotNil(t, instance.VpcId)
-		assert.NotEmpty(t, aws.ToString(instance.VpcId))
-		assert.True(t, strings.HasPrefix(aws.ToString(instance.VpcId), "vpc-"))
+		require.Len(t, hooksOut.LifecycleHooks, 1)
+		assert.Equal(t, "terminating", aws.ToString(hooksOut.LifecycleHooks[0].LifecycleHookName))
 	})
 }
 
-func TestAutoScalingGroupAllowsZeroMinAndDesired(t *testing.T) {
+func TestAutoScalingScheduledActionChangesDesiredCapacity(t *testing.T) {
 	t.Parallel()
 	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
-		launchTemplateName := fmt.Sprintf("lt-asg-zero-%s", strings.ReplaceAll(t.Name(), "/", "-"))
-		autoScalingGroupName := fmt.Sprintf("asg-zero-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+		launchTemplateName := fmt.Sprintf("lt-asg-scheduled-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+		autoScalingGroupName := fmt.Sprintf("asg-scheduled-%s", strings.ReplaceAll(t.Name(), "/", "-"))
 
 		lt, err := e.Client.CreateLaunchTemplate(ctx, &ec2.CreateLaunchTemplateInput{
 			LaunchTemplateName: aws.String(launchTemplateName),
@@ -397,17 +1270,14 @@ func TestAutoScalingGroupAllowsZeroMinAndDesired(t *testing.T) {
 		})
 		require.NoError(t, err)
 		require.NotNil(t, lt.LaunchTemplate)
-		require.NotNil(t, lt.LaunchTemplate.LaunchTemplateId)
 
 		_, err = e.AutoScalingClient.CreateAutoScalingGroup(ctx, &autoscaling.CreateAutoScalingGroupInput{
 			AutoScalingGroupName: aws.String(autoScalingGroupName),
 			MinSize:              aws.Int32(0),
-			MaxSize:              aws.Int32(2),
+			MaxSize:              aws.Int32(1),
 			DesiredCapacity:      aws.Int32(0),
-			VPCZoneIdentifier:    aws.String("subnet-dc2"),
 			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
 				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
-				Version:          aws.String("$Default"),
 			},
 		})
 		require.NoError(t, err)
@@ -415,29 +1285,32 @@ func TestAutoScalingGroupAllowsZeroMinAndDesired(t *testing.T) {
 			cleanupAutoScalingGroup(t, e, autoScalingGroupName)
 		})
 
-		require.Eventually(t, func() bool {
-			out, err := e.AutoScalingClient.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
-				AutoScalingGroupNames: []string{autoScalingGroupName},
-			})
-			if err != nil || len(out.AutoScalingGroups) != 1 {
-				return false
-			}
-			group := out.AutoScalingGroups[0]
-			if group.MinSize == nil || group.MaxSize == nil || group.DesiredCapacity == nil {
-				return false
-			}
-			return *group.MinSize == 0 &&
-				*group.MaxSize == 2 &&
-				*group.DesiredCapacity == 0 &&
-				len(group.Instances) == 0
-		}, 20*time.Second, 250*time.Millisecond)
-
-		_, err = e.AutoScalingClient.SetDesiredCapacity(ctx, &autoscaling.SetDesiredCapacityInput{
+		startTime := time.Now().Add(3 * time.Second).UTC()
+		_, err = e.AutoScalingClient.PutScheduledUpdateGroupAction(ctx, &autoscaling.PutScheduledUpdateGroupActionInput{
 			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			ScheduledActionName:  aws.String("scale-out"),
+			StartTime:            aws.Time(startTime),
 			DesiredCapacity:      aws.Int32(1),
 		})
 		require.NoError(t, err)
 
+		actionsOut, err := e.AutoScalingClient.DescribeScheduledActions(ctx, &autoscaling.DescribeScheduledActionsInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+		})
+		require.NoError(t, err)
+		require.Len(t, actionsOut.ScheduledUpdateGroupActions, 1)
+		action := actionsOut.ScheduledUpdateGroupActions[0]
+		assert.Equal(t, "scale-out", aws.ToString(action.ScheduledActionName))
+		assert.Equal(t, int32(1), aws.ToInt32(action.DesiredCapacity))
+		assert.WithinDuration(t, startTime, aws.ToTime(action.StartTime), time.Second)
+
+		out, err := e.AutoScalingClient.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
+			AutoScalingGroupNames: []string{autoScalingGroupName},
+		})
+		require.NoError(t, err)
+		require.Len(t, out.AutoScalingGroups, 1)
+		assert.Equal(t, int32(0), aws.ToInt32(out.AutoScalingGroups[0].DesiredCapacity))
+
 		require.Eventually(t, func() bool {
 			out, err := e.AutoScalingClient.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
 				AutoScalingGroupNames: []string{autoScalingGroupName},
@@ -446,29 +1319,27 @@ func TestAutoScalingGroupAllowsZeroMinAndDesired(t *testing.T) {
 				return false
 			}
 			group := out.AutoScalingGroups[0]
-			return group.DesiredCapacity != nil &&
-				*group.DesiredCapacity == 1 &&
-				len(group.Instances) == 1
+			return aws.ToInt32(group.DesiredCapacity) == 1 && len(group.Instances) == 1
 		}, 20*time.Second, 250*time.Millisecond)
 
-		_, err = e.AutoScalingClient.SetDesiredCapacity(ctx, &autoscaling.SetDesiredCapacityInput{
+		actionsOut, err = e.AutoScalingClient.DescribeScheduledActions(ctx, &autoscaling.DescribeScheduledActionsInput{
 			AutoScalingGroupName: aws.String(autoScalingGroupName),
-			DesiredCapacity:      aws.Int32(0),
 		})
 		require.NoError(t, err)
+		assert.Empty(t, actionsOut.ScheduledUpdateGroupActions)
 
-		require.Eventually(t, func() bool {
-			out, err := e.AutoScalingClient.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
-				AutoScalingGroupNames: []string{autoScalingGroupName},
-			})
-			if err != nil || len(out.AutoScalingGroups) != 1 {
-				return false
-			}
-			group := out.AutoScalingGroups[0]
-			return group.DesiredCapacity != nil &&
-				*group.DesiredCapacity == 0 &&
-				len(group.Instances) == 0
-		}, 20*time.Second, 250*time.Millisecond)
+		_, err = e.AutoScalingClient.PutScheduledUpdateGroupAction(ctx, &autoscaling.PutScheduledUpdateGroupActionInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			ScheduledActionName:  aws.String("nightly"),
+			Recurrence:           aws.String("0 2 * * *"),
+			DesiredCapacity:      aws.Int32(0),
+		})
+		require.NoError(t, err)
+		_, err = e.AutoScalingClient.DeleteScheduledAction(ctx, &autoscaling.DeleteScheduledActionInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			ScheduledActionName:  aws.String("nightly"),
+		})
+		require.NoError(t, err)
 	})
 }
 
@@ -494,7 +1365,7 @@ func TestAutoScalingWarmPoolStoppedInstancesCanBeRestarted(t *testing.T) {
 			MinSize:              aws.Int32(1),
 			MaxSize:              aws.Int32(2),
 			DesiredCapacity:      aws.Int32(1),
-			VPCZoneIdentifier:    aws.String("subnet-dc2"),
+			VPCZoneIdentifier:    aws.String(defaultSubnetID),
 			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
 				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
 				Version:          aws.String("$Default"),
@@ -616,7 +1487,7 @@ func TestAutoScalingWarmPoolPoolStateUpdateReconcilesExistingInstances(t *testin
 			MinSize:              aws.Int32(1),
 			MaxSize:              aws.Int32(2),
 			DesiredCapacity:      aws.Int32(1),
-			VPCZoneIdentifier:    aws.String("subnet-dc2"),
+			VPCZoneIdentifier:    aws.String(defaultSubnetID),
 			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
 				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
 				Version:          aws.String("$Default"),
@@ -705,7 +1576,7 @@ func TestAutoScalingWarmPoolMaxGroupPreparedCapacityControlsSize(t *testing.T) {
 			MinSize:              aws.Int32(1),
 			MaxSize:              aws.Int32(4),
 			DesiredCapacity:      aws.Int32(1),
-			VPCZoneIdentifier:    aws.String("subnet-dc2"),
+			VPCZoneIdentifier:    aws.String(defaultSubnetID),
 			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
 				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
 				Version:          aws.String("$Default"),
@@ -796,7 +1667,7 @@ func TestAutoScalingWarmPoolHibernatedScaleOutConsumesWarmInstance(t *testing.T)
 			MinSize:              aws.Int32(1),
 			MaxSize:              aws.Int32(2),
 			DesiredCapacity:      aws.Int32(1),
-			VPCZoneIdentifier:    aws.String("subnet-dc2"),
+			VPCZoneIdentifier:    aws.String(defaultSubnetID),
 			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
 				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
 				Version:          aws.String("$Default"),
@@ -893,7 +1764,7 @@ func TestAutoScalingWarmPoolLaunchTemplateUpdateRecyclesWarmInstances(t *testing
 			MinSize:              aws.Int32(1),
 			MaxSize:              aws.Int32(3),
 			DesiredCapacity:      aws.Int32(1),
-			VPCZoneIdentifier:    aws.String("subnet-dc2"),
+			VPCZoneIdentifier:    aws.String(defaultSubnetID),
 			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
 				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
 				Version:          aws.String("$Default"),
@@ -1044,7 +1915,7 @@ func TestAutoScalingScaleOutConsumesWarmInstance(t *testing.T) {
 			MinSize:              aws.Int32(1),
 			MaxSize:              aws.Int32(2),
 			DesiredCapacity:      aws.Int32(1),
-			VPCZoneIdentifier:    aws.String("subnet-dc2"),
+			VPCZoneIdentifier:    aws.String(defaultSubnetID),
 			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
 				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
 				Version:          aws.String("$Default"),
@@ -1158,7 +2029,7 @@ func TestAutoScalingWarmPoolReuseOnScaleInMovesInstanceToWarmPool(t *testing.T)
 			MinSize:              aws.Int32(1),
 			MaxSize:              aws.Int32(2),
 			DesiredCapacity:      aws.Int32(2),
-			VPCZoneIdentifier:    aws.String("subnet-dc2"),
+			VPCZoneIdentifier:    aws.String(defaultSubnetID),
 			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
 				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
 				Version:          aws.String("$Default"),
@@ -1300,7 +2171,7 @@ func TestAutoScalingWarmPoolDeleteMarksPendingDeleteStatus(t *testing.T) {
 				MinSize:              aws.Int32(1),
 				MaxSize:              aws.Int32(2),
 				DesiredCapacity:      aws.Int32(1),
-				VPCZoneIdentifier:    aws.String("subnet-dc2"),
+				VPCZoneIdentifier:    aws.String(defaultSubnetID),
 				LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
 					LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
 					Version:          aws.String("$Default"),
@@ -1365,7 +2236,7 @@ func TestAutoScalingWarmPoolDeleteCompletesAsynchronously(t *testing.T) {
 			MinSize:              aws.Int32(1),
 			MaxSize:              aws.Int32(2),
 			DesiredCapacity:      aws.Int32(1),
-			VPCZoneIdentifier:    aws.String("subnet-dc2"),
+			VPCZoneIdentifier:    aws.String(defaultSubnetID),
 			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
 				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
 				Version:          aws.String("$Default"),
@@ -1431,7 +2302,7 @@ func TestAutoScalingGroupDescribeFiltersByTag(t *testing.T) {
 				MinSize:              aws.Int32(1),
 				MaxSize:              aws.Int32(2),
 				DesiredCapacity:      aws.Int32(1),
-				VPCZoneIdentifier:    aws.String("subnet-dc2"),
+				VPCZoneIdentifier:    aws.String(defaultSubnetID),
 				LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
 					LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
 					Version:          aws.String("$Default"),
@@ -1515,7 +2386,7 @@ func TestAutoScalingGroupCreateWithTags(t *testing.T) {
 			MinSize:              aws.Int32(1),
 			MaxSize:              aws.Int32(2),
 			DesiredCapacity:      aws.Int32(1),
-			VPCZoneIdentifier:    aws.String("subnet-dc2"),
+			VPCZoneIdentifier:    aws.String(defaultSubnetID),
 			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
 				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
 				Version:          aws.String("$Default"),
@@ -1615,7 +2486,7 @@ func TestAutoScalingGroupUsesExplicitLaunchTemplateVersion(t *testing.T) {
 			MinSize:              aws.Int32(1),
 			MaxSize:              aws.Int32(1),
 			DesiredCapacity:      aws.Int32(1),
-			VPCZoneIdentifier:    aws.String("subnet-dc2"),
+			VPCZoneIdentifier:    aws.String(defaultSubnetID),
 			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
 				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
 				Version:          aws.String("2"),
@@ -1680,7 +2551,7 @@ func TestAutoScalingGroupMixedInstancesPolicyUsesLaunchTemplateInstanceRequireme
 			MinSize:              aws.Int32(1),
 			MaxSize:              aws.Int32(2),
 			DesiredCapacity:      aws.Int32(1),
-			VPCZoneIdentifier:    aws.String("subnet-dc2"),
+			VPCZoneIdentifier:    aws.String(defaultSubnetID),
 			MixedInstancesPolicy: &autoscalingtypes.MixedInstancesPolicy{
 				LaunchTemplate: &autoscalingtypes.LaunchTemplate{
 					LaunchTemplateSpecification: &autoscalingtypes.LaunchTemplateSpecification{
@@ -1737,6 +2608,71 @@ func TestAutoScalingGroupMixedInstancesPolicyUsesLaunchTemplateInstanceRequireme
 	})
 }
 
+func TestAutoScalingGroupMixedInstancesPolicyOverridesRoundRobin(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		launchTemplateName := fmt.Sprintf("lt-asg-overrides-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+		autoScalingGroupName := fmt.Sprintf("asg-overrides-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+		overrideInstanceTypes := []string{
+			string(ec2types.InstanceTypeA1Large),
+			string(ec2types.InstanceTypeA1Xlarge),
+		}
+
+		lt, err := e.Client.CreateLaunchTemplate(ctx, &ec2.CreateLaunchTemplateInput{
+			LaunchTemplateName: aws.String(launchTemplateName),
+			LaunchTemplateData: &ec2types.RequestLaunchTemplateData{
+				ImageId:      aws.String("nginx"),
+				InstanceType: ec2types.InstanceTypeA1Medium,
+			},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, lt.LaunchTemplate)
+
+		overrides := make([]autoscalingtypes.LaunchTemplateOverrides, 0, len(overrideInstanceTypes))
+		for _, instanceType := range overrideInstanceTypes {
+			overrides = append(overrides, autoscalingtypes.LaunchTemplateOverrides{InstanceType: aws.String(instanceType)})
+		}
+		_, err = e.AutoScalingClient.CreateAutoScalingGroup(ctx, &autoscaling.CreateAutoScalingGroupInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			MinSize:              aws.Int32(0),
+			MaxSize:              aws.Int32(2),
+			DesiredCapacity:      aws.Int32(2),
+			VPCZoneIdentifier:    aws.String(defaultSubnetID),
+			MixedInstancesPolicy: &autoscalingtypes.MixedInstancesPolicy{
+				LaunchTemplate: &autoscalingtypes.LaunchTemplate{
+					LaunchTemplateSpecification: &autoscalingtypes.LaunchTemplateSpecification{
+						LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
+						Version:          aws.String("$Default"),
+					},
+					Overrides: overrides,
+				},
+			},
+		})
+		require.NoError(t, err)
+
+		t.Cleanup(func() {
+			cleanupAutoScalingGroup(t, e, autoScalingGroupName)
+		})
+
+		describeResp, err := e.AutoScalingClient.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
+			AutoScalingGroupNames: []string{autoScalingGroupName},
+		})
+		require.NoError(t, err)
+		require.Len(t, describeResp.AutoScalingGroups, 1)
+		group := describeResp.AutoScalingGroups[0]
+		require.NotNil(t, group.MixedInstancesPolicy)
+		require.NotNil(t, group.MixedInstancesPolicy.LaunchTemplate)
+		require.Len(t, group.MixedInstancesPolicy.LaunchTemplate.Overrides, 2)
+
+		require.Len(t, group.Instances, 2)
+		instanceTypes := make([]string, 0, len(group.Instances))
+		for _, instance := range group.Instances {
+			instanceTypes = append(instanceTypes, aws.ToString(instance.InstanceType))
+		}
+		assert.ElementsMatch(t, overrideInstanceTypes, instanceTypes)
+	})
+}
+
 func TestAutoScalingGroupLaunchTemplateUserDataAppliedToInstances(t *testing.T) {
 	t.Parallel()
 	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
@@ -1762,7 +2698,7 @@ func TestAutoScalingGroupLaunchTemplateUserDataAppliedToInstances(t *testing.T)
 			MinSize:              aws.Int32(1),
 			MaxSize:              aws.Int32(2),
 			DesiredCapacity:      aws.Int32(2),
-			VPCZoneIdentifier:    aws.String("subnet-dc2"),
+			VPCZoneIdentifier:    aws.String(defaultSubnetID),
 			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
 				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
 				Version:          aws.String("$Default"),
@@ -1818,6 +2754,82 @@ func TestAutoScalingGroupLaunchTemplateUserDataAppliedToInstances(t *testing.T)
 	})
 }
 
+func TestAutoScalingGroupLaunchTemplateKeyNameAndSecurityGroups(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		launchTemplateName := fmt.Sprintf("lt-asg-key-sg-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+		autoScalingGroupName := fmt.Sprintf("asg-key-sg-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+
+		securityGroup, err := e.Client.CreateSecurityGroup(ctx, &ec2.CreateSecurityGroupInput{
+			GroupName:   aws.String(fmt.Sprintf("sg-%d", time.Now().UnixNano())),
+			Description: aws.String("dc2 launch template security group"),
+			VpcId:       aws.String("vpc-00000000000000000"),
+		})
+		require.NoError(t, err)
+		securityGroupID := aws.ToString(securityGroup.GroupId)
+		t.Cleanup(func() {
+			cleanupCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, _ = e.Client.DeleteSecurityGroup(cleanupCtx, &ec2.DeleteSecurityGroupInput{GroupId: aws.String(securityGroupID)})
+		})
+
+		lt, err := e.Client.CreateLaunchTemplate(ctx, &ec2.CreateLaunchTemplateInput{
+			LaunchTemplateName: aws.String(launchTemplateName),
+			LaunchTemplateData: &ec2types.RequestLaunchTemplateData{
+				ImageId:          aws.String("nginx"),
+				InstanceType:     ec2types.InstanceTypeA1Large,
+				KeyName:          aws.String("dc2-key"),
+				SecurityGroupIds: []string{securityGroupID},
+			},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, lt.LaunchTemplate)
+
+		versions, err := e.Client.DescribeLaunchTemplateVersions(ctx, &ec2.DescribeLaunchTemplateVersionsInput{
+			LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
+		})
+		require.NoError(t, err)
+		require.Len(t, versions.LaunchTemplateVersions, 1)
+		data := versions.LaunchTemplateVersions[0].LaunchTemplateData
+		require.NotNil(t, data)
+		assert.Equal(t, "dc2-key", aws.ToString(data.KeyName))
+		assert.Equal(t, []string{securityGroupID}, data.SecurityGroupIds)
+
+		_, err = e.AutoScalingClient.CreateAutoScalingGroup(ctx, &autoscaling.CreateAutoScalingGroupInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			MinSize:              aws.Int32(1),
+			MaxSize:              aws.Int32(1),
+			DesiredCapacity:      aws.Int32(1),
+			VPCZoneIdentifier:    aws.String(defaultSubnetID),
+			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
+				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
+				Version:          aws.String("$Default"),
+			},
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			cleanupAutoScalingGroup(t, e, autoScalingGroupName)
+		})
+
+		describeResp, err := e.AutoScalingClient.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
+			AutoScalingGroupNames: []string{autoScalingGroupName},
+		})
+		require.NoError(t, err)
+		require.Len(t, describeResp.AutoScalingGroups, 1)
+		require.Len(t, describeResp.AutoScalingGroups[0].Instances, 1)
+		instanceID := aws.ToString(describeResp.AutoScalingGroups[0].Instances[0].InstanceId)
+
+		instances, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{InstanceIds: []string{instanceID}})
+		require.NoError(t, err)
+		require.Len(t, instances.Reservations, 1)
+		require.Len(t, instances.Reservations[0].Instances, 1)
+		instance := instances.Reservations[0].Instances[0]
+		assert.Equal(t, "dc2-key", aws.ToString(instance.KeyName))
+		require.Len(t, instance.SecurityGroups, 1)
+		assert.Equal(t, securityGroupID, aws.ToString(instance.SecurityGroups[0].GroupId))
+	})
+}
+
 func TestAutoScalingGroupLaunchTemplateBlockDeviceMappings(t *testing.T) {
 	t.Parallel()
 	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
@@ -1851,7 +2863,7 @@ func TestAutoScalingGroupLaunchTemplateBlockDeviceMappings(t *testing.T) {
 			MinSize:              aws.Int32(1),
 			MaxSize:              aws.Int32(1),
 			DesiredCapacity:      aws.Int32(1),
-			VPCZoneIdentifier:    aws.String("subnet-dc2"),
+			VPCZoneIdentifier:    aws.String(defaultSubnetID),
 			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
 				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
 				Version:          aws.String("$Default"),
@@ -1923,7 +2935,109 @@ func TestAutoScalingGroupLaunchTemplateBlockDeviceMappings(t *testing.T) {
 				return false
 			}
 			for _, volume := range volumesOut.Volumes {
-				if volume.VolumeId != nil && *volume.VolumeId == volumeID {
+				if volume.VolumeId != nil && *volume.VolumeId == volumeID {
+					return false
+				}
+			}
+			return true
+		}, 20*time.Second, 250*time.Millisecond)
+	})
+}
+
+func TestAutoScalingGroupLaunchTemplateMultipleBlockDeviceMappings(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		deviceNames := []string{"/dev/sdf", "/dev/sdg", "/dev/sdh"}
+		launchTemplateName := fmt.Sprintf("lt-asg-multi-bdm-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+		autoScalingGroupName := fmt.Sprintf("asg-multi-bdm-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+
+		mappings := make([]ec2types.LaunchTemplateBlockDeviceMappingRequest, len(deviceNames))
+		for i, deviceName := range deviceNames {
+			mappings[i] = ec2types.LaunchTemplateBlockDeviceMappingRequest{
+				DeviceName: aws.String(deviceName),
+				Ebs: &ec2types.LaunchTemplateEbsBlockDeviceRequest{
+					DeleteOnTermination: aws.Bool(true),
+					VolumeSize:          aws.Int32(int32(i + 1)),
+					VolumeType:          ec2types.VolumeTypeGp3,
+				},
+			}
+		}
+		lt, err := e.Client.CreateLaunchTemplate(ctx, &ec2.CreateLaunchTemplateInput{
+			LaunchTemplateName: aws.String(launchTemplateName),
+			LaunchTemplateData: &ec2types.RequestLaunchTemplateData{
+				ImageId:             aws.String("nginx"),
+				InstanceType:        ec2types.InstanceTypeA1Large,
+				BlockDeviceMappings: mappings,
+			},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, lt.LaunchTemplate)
+
+		_, err = e.AutoScalingClient.CreateAutoScalingGroup(ctx, &autoscaling.CreateAutoScalingGroupInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			MinSize:              aws.Int32(1),
+			MaxSize:              aws.Int32(1),
+			DesiredCapacity:      aws.Int32(1),
+			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
+				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
+				Version:          aws.String("$Default"),
+			},
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			cleanupAutoScalingGroup(t, e, autoScalingGroupName)
+		})
+
+		var instance ec2types.Instance
+		require.Eventually(t, func() bool {
+			groupOut, err := e.AutoScalingClient.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
+				AutoScalingGroupNames: []string{autoScalingGroupName},
+			})
+			if err != nil || len(groupOut.AutoScalingGroups) != 1 || len(groupOut.AutoScalingGroups[0].Instances) != 1 {
+				return false
+			}
+			instancesOut, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+				InstanceIds: []string{aws.ToString(groupOut.AutoScalingGroups[0].Instances[0].InstanceId)},
+			})
+			if err != nil || len(instancesOut.Reservations) != 1 || len(instancesOut.Reservations[0].Instances) != 1 {
+				return false
+			}
+			instance = instancesOut.Reservations[0].Instances[0]
+			return len(instance.BlockDeviceMappings) == len(deviceNames)
+		}, 20*time.Second, 250*time.Millisecond)
+
+		volumeIDs := make([]string, len(deviceNames))
+		for i, mapping := range instance.BlockDeviceMappings {
+			assert.Equal(t, deviceNames[i], aws.ToString(mapping.DeviceName))
+			require.NotNil(t, mapping.Ebs)
+			assert.True(t, aws.ToBool(mapping.Ebs.DeleteOnTermination))
+			volumeIDs[i] = aws.ToString(mapping.Ebs.VolumeId)
+		}
+		volumesOut, err := e.Client.DescribeVolumes(ctx, &ec2.DescribeVolumesInput{VolumeIds: volumeIDs})
+		require.NoError(t, err)
+		require.Len(t, volumesOut.Volumes, len(deviceNames))
+		sizesByDevice := make(map[string]int32, len(deviceNames))
+		for _, volume := range volumesOut.Volumes {
+			require.Len(t, volume.Attachments, 1)
+			assert.Equal(t, aws.ToString(instance.InstanceId), aws.ToString(volume.Attachments[0].InstanceId))
+			sizesByDevice[aws.ToString(volume.Attachments[0].Device)] = aws.ToInt32(volume.Size)
+		}
+		assert.Equal(t, map[string]int32{"/dev/sdf": 1, "/dev/sdg": 2, "/dev/sdh": 3}, sizesByDevice)
+
+		_, err = e.AutoScalingClient.UpdateAutoScalingGroup(ctx, &autoscaling.UpdateAutoScalingGroupInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			MinSize:              aws.Int32(0),
+			DesiredCapacity:      aws.Int32(0),
+		})
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			volumesOut, err := e.Client.DescribeVolumes(ctx, &ec2.DescribeVolumesInput{})
+			if err != nil {
+				return false
+			}
+			for _, volume := range volumesOut.Volumes {
+				if slices.Contains(volumeIDs, aws.ToString(volume.VolumeId)) {
 					return false
 				}
 			}
@@ -1958,7 +3072,7 @@ func TestAutoScalingGroupDetachInstancesReplacesInstance(t *testing.T) {
 			MinSize:              aws.Int32(1),
 			MaxSize:              aws.Int32(3),
 			DesiredCapacity:      aws.Int32(2),
-			VPCZoneIdentifier:    aws.String("subnet-dc2"),
+			VPCZoneIdentifier:    aws.String(defaultSubnetID),
 			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
 				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
 				Version:          aws.String("$Default"),
@@ -2145,7 +3259,7 @@ func TestAutoScalingGroupDetachInstancesReturnsAWSStyleNotPartError(t *testing.T
 			MinSize:              aws.Int32(0),
 			MaxSize:              aws.Int32(1),
 			DesiredCapacity:      aws.Int32(1),
-			VPCZoneIdentifier:    aws.String("subnet-dc2"),
+			VPCZoneIdentifier:    aws.String(defaultSubnetID),
 			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
 				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
 				Version:          aws.String("$Default"),
@@ -2210,7 +3324,7 @@ func TestAutoScalingGroupReplacesOutOfBandDeletedInstance(t *testing.T) {
 			MinSize:              aws.Int32(1),
 			MaxSize:              aws.Int32(1),
 			DesiredCapacity:      aws.Int32(1),
-			VPCZoneIdentifier:    aws.String("subnet-dc2"),
+			VPCZoneIdentifier:    aws.String(defaultSubnetID),
 			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
 				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
 				Version:          aws.String("$Default"),
@@ -2280,7 +3394,7 @@ func TestAutoScalingGroupReplacesOutOfBandDeletedInstanceOnEC2Describe(t *testin
 			MinSize:              aws.Int32(1),
 			MaxSize:              aws.Int32(1),
 			DesiredCapacity:      aws.Int32(1),
-			VPCZoneIdentifier:    aws.String("subnet-dc2"),
+			VPCZoneIdentifier:    aws.String(defaultSubnetID),
 			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
 				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
 				Version:          aws.String("$Default"),
@@ -2356,7 +3470,7 @@ func TestAutoScalingGroupReplacesOutOfBandStoppedInstance(t *testing.T) {
 			MinSize:              aws.Int32(1),
 			MaxSize:              aws.Int32(1),
 			DesiredCapacity:      aws.Int32(1),
-			VPCZoneIdentifier:    aws.String("subnet-dc2"),
+			VPCZoneIdentifier:    aws.String(defaultSubnetID),
 			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
 				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
 				Version:          aws.String("$Default"),
@@ -2427,7 +3541,7 @@ func TestAutoScalingGroupReplacesUnhealthyInstance(t *testing.T) {
 			MinSize:              aws.Int32(1),
 			MaxSize:              aws.Int32(1),
 			DesiredCapacity:      aws.Int32(1),
-			VPCZoneIdentifier:    aws.String("subnet-dc2"),
+			VPCZoneIdentifier:    aws.String(defaultSubnetID),
 			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
 				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
 				Version:          aws.String("$Default"),
@@ -2483,6 +3597,393 @@ func TestAutoScalingGroupReplacesUnhealthyInstance(t *testing.T) {
 	})
 }
 
+func TestAutoScalingGroupAttachInstances(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		launchTemplateName := fmt.Sprintf("lt-asg-attach-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+		autoScalingGroupName := fmt.Sprintf("asg-attach-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+
+		lt, err := e.Client.CreateLaunchTemplate(ctx, &ec2.CreateLaunchTemplateInput{
+			LaunchTemplateName: aws.String(launchTemplateName),
+			LaunchTemplateData: &ec2types.RequestLaunchTemplateData{
+				ImageId:      aws.String("nginx"),
+				InstanceType: ec2types.InstanceTypeA1Large,
+			},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, lt.LaunchTemplate)
+
+		_, err = e.AutoScalingClient.CreateAutoScalingGroup(ctx, &autoscaling.CreateAutoScalingGroupInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			MinSize:              aws.Int32(0),
+			MaxSize:              aws.Int32(2),
+			DesiredCapacity:      aws.Int32(0),
+			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
+				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
+			},
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			cleanupAutoScalingGroup(t, e, autoScalingGroupName)
+		})
+
+		runOut, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:      aws.String("nginx"),
+			InstanceType: "my-type",
+			MinCount:     aws.Int32(3),
+			MaxCount:     aws.Int32(3),
+		})
+		require.NoError(t, err)
+		require.Len(t, runOut.Instances, 3)
+		instanceIDs := make([]string, 0, len(runOut.Instances))
+		for _, instance := range runOut.Instances {
+			instanceIDs = append(instanceIDs, aws.ToString(instance.InstanceId))
+		}
+		t.Cleanup(func() {
+			cleanupCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, err := e.Client.TerminateInstances(cleanupCtx, &ec2.TerminateInstancesInput{
+				InstanceIds: instanceIDs,
+			})
+			if err != nil && !isInstanceNotFound(err) {
+				t.Logf("cleanup terminate standalone instances %v returned error: %v", instanceIDs, err)
+			}
+		})
+		require.Eventually(t, func() bool {
+			out, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{InstanceIds: instanceIDs})
+			if err != nil {
+				return false
+			}
+			for _, reservation := range out.Reservations {
+				for _, instance := range reservation.Instances {
+					if instance.State == nil || instance.State.Name != ec2types.InstanceStateNameRunning {
+						return false
+					}
+				}
+			}
+			return true
+		}, 20*time.Second, 250*time.Millisecond)
+
+		_, err = e.AutoScalingClient.AttachInstances(ctx, &autoscaling.AttachInstancesInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			InstanceIds:          instanceIDs[:2],
+		})
+		require.NoError(t, err)
+
+		out, err := e.AutoScalingClient.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
+			AutoScalingGroupNames: []string{autoScalingGroupName},
+		})
+		require.NoError(t, err)
+		require.Len(t, out.AutoScalingGroups, 1)
+		group := out.AutoScalingGroups[0]
+		assert.Equal(t, int32(2), aws.ToInt32(group.DesiredCapacity))
+		groupInstanceIDs := make([]string, 0, len(group.Instances))
+		for _, instance := range group.Instances {
+			groupInstanceIDs = append(groupInstanceIDs, aws.ToString(instance.InstanceId))
+			assert.Equal(t, autoscalingtypes.LifecycleStateInService, instance.LifecycleState)
+		}
+		assert.ElementsMatch(t, instanceIDs[:2], groupInstanceIDs)
+
+		describeOut, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+			Filters: []ec2types.Filter{
+				{
+					Name:   aws.String("tag:aws:autoscaling:groupName"),
+					Values: []string{autoScalingGroupName},
+				},
+			},
+		})
+		require.NoError(t, err)
+		taggedIDs := make([]string, 0)
+		for _, reservation := range describeOut.Reservations {
+			for _, instance := range reservation.Instances {
+				taggedIDs = append(taggedIDs, aws.ToString(instance.InstanceId))
+			}
+		}
+		assert.ElementsMatch(t, instanceIDs[:2], taggedIDs)
+
+		// The group is at MaxSize, so attaching another instance fails.
+		_, err = e.AutoScalingClient.AttachInstances(ctx, &autoscaling.AttachInstancesInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			InstanceIds:          instanceIDs[2:],
+		})
+		var apiErr smithy.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "ValidationError", apiErr.ErrorCode())
+	})
+}
+
+func TestAutoScalingGroupSetInstanceHealthReplacesInstance(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		launchTemplateName := fmt.Sprintf("lt-asg-set-health-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+		autoScalingGroupName := fmt.Sprintf("asg-set-health-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+
+		lt, err := e.Client.CreateLaunchTemplate(ctx, &ec2.CreateLaunchTemplateInput{
+			LaunchTemplateName: aws.String(launchTemplateName),
+			LaunchTemplateData: &ec2types.RequestLaunchTemplateData{
+				ImageId:      aws.String("nginx"),
+				InstanceType: ec2types.InstanceTypeA1Large,
+			},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, lt.LaunchTemplate)
+
+		_, err = e.AutoScalingClient.CreateAutoScalingGroup(ctx, &autoscaling.CreateAutoScalingGroupInput{
+			AutoScalingGroupName:   aws.String(autoScalingGroupName),
+			MinSize:                aws.Int32(1),
+			MaxSize:                aws.Int32(1),
+			DesiredCapacity:        aws.Int32(1),
+			HealthCheckGracePeriod: aws.Int32(3600),
+			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
+				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
+			},
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			cleanupAutoScalingGroup(t, e, autoScalingGroupName)
+		})
+
+		describeGroup := func() autoscalingtypes.AutoScalingGroup {
+			out, err := e.AutoScalingClient.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
+				AutoScalingGroupNames: []string{autoScalingGroupName},
+			})
+			require.NoError(t, err)
+			require.Len(t, out.AutoScalingGroups, 1)
+			return out.AutoScalingGroups[0]
+		}
+		require.Eventually(t, func() bool {
+			return len(describeGroup().Instances) == 1
+		}, 20*time.Second, 250*time.Millisecond)
+		group := describeGroup()
+		assert.Equal(t, int32(3600), aws.ToInt32(group.HealthCheckGracePeriod))
+		instanceID := aws.ToString(group.Instances[0].InstanceId)
+		require.NotEmpty(t, instanceID)
+
+		// The instance is within the grace period, so the change is ignored.
+		_, err = e.AutoScalingClient.SetInstanceHealth(ctx, &autoscaling.SetInstanceHealthInput{
+			InstanceId:   aws.String(instanceID),
+			HealthStatus: aws.String("Unhealthy"),
+		})
+		require.NoError(t, err)
+		group = describeGroup()
+		require.Len(t, group.Instances, 1)
+		assert.Equal(t, instanceID, aws.ToString(group.Instances[0].InstanceId))
+		assert.Equal(t, "Healthy", aws.ToString(group.Instances[0].HealthStatus))
+
+		_, err = e.AutoScalingClient.SetInstanceHealth(ctx, &autoscaling.SetInstanceHealthInput{
+			InstanceId:               aws.String(instanceID),
+			HealthStatus:             aws.String("Unhealthy"),
+			ShouldRespectGracePeriod: aws.Bool(false),
+		})
+		require.NoError(t, err)
+		require.Eventually(t, func() bool {
+			group := describeGroup()
+			return len(group.Instances) == 1 && aws.ToString(group.Instances[0].InstanceId) != instanceID
+		}, 30*time.Second, 250*time.Millisecond)
+
+		_, err = e.AutoScalingClient.SetInstanceHealth(ctx, &autoscaling.SetInstanceHealthInput{
+			InstanceId:   aws.String(instanceID),
+			HealthStatus: aws.String("Unhealthy"),
+		})
+		var apiErr smithy.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "ValidationError", apiErr.ErrorCode())
+	})
+}
+
+func TestAutoScalingGroupTerminateInstanceInAutoScalingGroup(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		launchTemplateName := fmt.Sprintf("lt-asg-terminate-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+		autoScalingGroupName := fmt.Sprintf("asg-terminate-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+
+		lt, err := e.Client.CreateLaunchTemplate(ctx, &ec2.CreateLaunchTemplateInput{
+			LaunchTemplateName: aws.String(launchTemplateName),
+			LaunchTemplateData: &ec2types.RequestLaunchTemplateData{
+				ImageId:      aws.String("nginx"),
+				InstanceType: ec2types.InstanceTypeA1Large,
+			},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, lt.LaunchTemplate)
+
+		_, err = e.AutoScalingClient.CreateAutoScalingGroup(ctx, &autoscaling.CreateAutoScalingGroupInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			MinSize:              aws.Int32(1),
+			MaxSize:              aws.Int32(2),
+			DesiredCapacity:      aws.Int32(2),
+			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
+				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
+			},
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			cleanupAutoScalingGroup(t, e, autoScalingGroupName)
+		})
+
+		describeGroup := func() autoscalingtypes.AutoScalingGroup {
+			out, err := e.AutoScalingClient.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
+				AutoScalingGroupNames: []string{autoScalingGroupName},
+			})
+			require.NoError(t, err)
+			require.Len(t, out.AutoScalingGroups, 1)
+			return out.AutoScalingGroups[0]
+		}
+		require.Eventually(t, func() bool {
+			return len(describeGroup().Instances) == 2
+		}, 20*time.Second, 250*time.Millisecond)
+		instanceID := aws.ToString(describeGroup().Instances[0].InstanceId)
+
+		// Without decrementing, the instance is replaced.
+		out, err := e.AutoScalingClient.TerminateInstanceInAutoScalingGroup(ctx, &autoscaling.TerminateInstanceInAutoScalingGroupInput{
+			InstanceId:                     aws.String(instanceID),
+			ShouldDecrementDesiredCapacity: aws.Bool(false),
+		})
+		require.NoError(t, err)
+		require.NotNil(t, out.Activity)
+		assert.Equal(t, autoScalingGroupName, aws.ToString(out.Activity.AutoScalingGroupName))
+		assert.NotEmpty(t, aws.ToString(out.Activity.ActivityId))
+		require.Eventually(t, func() bool {
+			group := describeGroup()
+			if len(group.Instances) != 2 {
+				return false
+			}
+			for _, instance := range group.Instances {
+				if aws.ToString(instance.InstanceId) == instanceID {
+					return false
+				}
+			}
+			return true
+		}, 30*time.Second, 250*time.Millisecond)
+		assert.Equal(t, int32(2), aws.ToInt32(describeGroup().DesiredCapacity))
+
+		// Decrementing shrinks the group without a replacement.
+		instanceID = aws.ToString(describeGroup().Instances[0].InstanceId)
+		_, err = e.AutoScalingClient.TerminateInstanceInAutoScalingGroup(ctx, &autoscaling.TerminateInstanceInAutoScalingGroupInput{
+			InstanceId:                     aws.String(instanceID),
+			ShouldDecrementDesiredCapacity: aws.Bool(true),
+		})
+		require.NoError(t, err)
+		group := describeGroup()
+		assert.Equal(t, int32(1), aws.ToInt32(group.DesiredCapacity))
+		require.Len(t, group.Instances, 1)
+		assert.NotEqual(t, instanceID, aws.ToString(group.Instances[0].InstanceId))
+
+		// The group is at MinSize, so it can't be decremented further.
+		_, err = e.AutoScalingClient.TerminateInstanceInAutoScalingGroup(ctx, &autoscaling.TerminateInstanceInAutoScalingGroupInput{
+			InstanceId:                     group.Instances[0].InstanceId,
+			ShouldDecrementDesiredCapacity: aws.Bool(true),
+		})
+		require.Error(t, err)
+
+		_, err = e.AutoScalingClient.TerminateInstanceInAutoScalingGroup(ctx, &autoscaling.TerminateInstanceInAutoScalingGroupInput{
+			InstanceId:                     aws.String(instanceID),
+			ShouldDecrementDesiredCapacity: aws.Bool(false),
+		})
+		var apiErr smithy.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "ValidationError", apiErr.ErrorCode())
+	})
+}
+
+func TestAutoScalingGroupEnterAndExitStandby(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		launchTemplateName := fmt.Sprintf("lt-asg-standby-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+		autoScalingGroupName := fmt.Sprintf("asg-standby-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+
+		lt, err := e.Client.CreateLaunchTemplate(ctx, &ec2.CreateLaunchTemplateInput{
+			LaunchTemplateName: aws.String(launchTemplateName),
+			LaunchTemplateData: &ec2types.RequestLaunchTemplateData{
+				ImageId:      aws.String("nginx"),
+				InstanceType: ec2types.InstanceTypeA1Large,
+			},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, lt.LaunchTemplate)
+
+		_, err = e.AutoScalingClient.CreateAutoScalingGroup(ctx, &autoscaling.CreateAutoScalingGroupInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			MinSize:              aws.Int32(0),
+			MaxSize:              aws.Int32(2),
+			DesiredCapacity:      aws.Int32(2),
+			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
+				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
+			},
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			cleanupAutoScalingGroup(t, e, autoScalingGroupName)
+		})
+
+		describeGroup := func() autoscalingtypes.AutoScalingGroup {
+			out, err := e.AutoScalingClient.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
+				AutoScalingGroupNames: []string{autoScalingGroupName},
+			})
+			require.NoError(t, err)
+			require.Len(t, out.AutoScalingGroups, 1)
+			return out.AutoScalingGroups[0]
+		}
+		lifecycleStates := func() map[string]autoscalingtypes.LifecycleState {
+			states := make(map[string]autoscalingtypes.LifecycleState)
+			for _, instance := range describeGroup().Instances {
+				states[aws.ToString(instance.InstanceId)] = instance.LifecycleState
+			}
+			return states
+		}
+		require.Eventually(t, func() bool {
+			return len(describeGroup().Instances) == 2
+		}, 20*time.Second, 250*time.Millisecond)
+		instanceID := aws.ToString(describeGroup().Instances[0].InstanceId)
+
+		enterOut, err := e.AutoScalingClient.EnterStandby(ctx, &autoscaling.EnterStandbyInput{
+			AutoScalingGroupName:           aws.String(autoScalingGroupName),
+			InstanceIds:                    []string{instanceID},
+			ShouldDecrementDesiredCapacity: aws.Bool(true),
+		})
+		require.NoError(t, err)
+		require.Len(t, enterOut.Activities, 1)
+
+		group := describeGroup()
+		assert.Equal(t, int32(1), aws.ToInt32(group.DesiredCapacity))
+		assert.Equal(t, autoscalingtypes.LifecycleStateStandby, lifecycleStates()[instanceID])
+
+		// The desired capacity was decremented, so no replacement is launched.
+		require.Never(t, func() bool {
+			return len(describeGroup().Instances) != 2
+		}, 3*time.Second, 250*time.Millisecond)
+		require.Eventually(t, func() bool {
+			out, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{InstanceIds: []string{instanceID}})
+			if err != nil || len(out.Reservations) != 1 || len(out.Reservations[0].Instances) != 1 {
+				return false
+			}
+			state := out.Reservations[0].Instances[0].State
+			return state != nil && state.Name == ec2types.InstanceStateNameStopped
+		}, 20*time.Second, 250*time.Millisecond)
+
+		exitOut, err := e.AutoScalingClient.ExitStandby(ctx, &autoscaling.ExitStandbyInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			InstanceIds:          []string{instanceID},
+		})
+		require.NoError(t, err)
+		require.Len(t, exitOut.Activities, 1)
+
+		group = describeGroup()
+		assert.Equal(t, int32(2), aws.ToInt32(group.DesiredCapacity))
+		require.Len(t, group.Instances, 2)
+		assert.Equal(t, autoscalingtypes.LifecycleStateInService, lifecycleStates()[instanceID])
+
+		// Exiting standby again fails, since the instance is in service.
+		_, err = e.AutoScalingClient.ExitStandby(ctx, &autoscaling.ExitStandbyInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			InstanceIds:          []string{instanceID},
+		})
+		var apiErr smithy.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "ValidationError", apiErr.ErrorCode())
+	})
+}
+
 func buildASGHealthCheckTestImage(t *testing.T, ctx context.Context, dockerHost string) string {
 	t.Helper()
 
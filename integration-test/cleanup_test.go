@@ -0,0 +1,90 @@
+package dc2_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInternalCleanupEndpoint(t *testing.T) {
+	t.Parallel()
+
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		runInstances := func(suite string) []string {
+			out, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+				ImageId:      aws.String("nginx"),
+				InstanceType: types.InstanceTypeT3Micro,
+				MinCount:     aws.Int32(1),
+				MaxCount:     aws.Int32(1),
+				TagSpecifications: []types.TagSpecification{
+					{
+						ResourceType: types.ResourceTypeInstance,
+						Tags:         []types.Tag{{Key: aws.String("suite"), Value: aws.String(suite)}},
+					},
+				},
+			})
+			require.NoError(t, err)
+			instanceIDs := make([]string, 0, len(out.Instances))
+			for _, instance := range out.Instances {
+				instanceIDs = append(instanceIDs, aws.ToString(instance.InstanceId))
+			}
+			t.Cleanup(func() {
+				cleanupCtx, cancel := cleanupAPICtx(t)
+				defer cancel()
+				_, err := e.Client.TerminateInstances(cleanupCtx, &ec2.TerminateInstancesInput{InstanceIds: instanceIDs})
+				if err != nil && !isInstanceNotFound(err) {
+					t.Logf("cleanup terminate instances %v returned error: %v", instanceIDs, err)
+				}
+			})
+			return instanceIDs
+		}
+		cleanupIDs := runInstances("cleanup")
+		keptIDs := runInstances("kept")
+
+		cleanup := func(body string) *http.Response {
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.Endpoint+"/_dc2/cleanup", strings.NewReader(body))
+			require.NoError(t, err)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			t.Cleanup(func() { resp.Body.Close() })
+			return resp
+		}
+
+		// Cleaning up everything by accident is not allowed
+		resp := cleanup(`{"tags": {}}`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp = cleanup(`{"tags": {"suite": "cleanup"}}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var result struct {
+			InstanceIDs []string `json:"instanceIds"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.Equal(t, cleanupIDs, result.InstanceIDs)
+
+		describeOut, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+			InstanceIds: append(append([]string{}, cleanupIDs...), keptIDs...),
+		})
+		require.NoError(t, err)
+		states := make(map[string]types.InstanceStateName)
+		for _, reservation := range describeOut.Reservations {
+			for _, instance := range reservation.Instances {
+				states[aws.ToString(instance.InstanceId)] = instance.State.Name
+			}
+		}
+		for _, id := range cleanupIDs {
+			assert.Contains(t, []types.InstanceStateName{types.InstanceStateNameShuttingDown, types.InstanceStateNameTerminated}, states[id])
+		}
+		for _, id := range keptIDs {
+			assert.NotContains(t, []types.InstanceStateName{types.InstanceStateNameShuttingDown, types.InstanceStateNameTerminated}, states[id])
+		}
+	})
+}
@@ -42,6 +42,21 @@ func TestResourceIDFormats(t *testing.T) {
 			require.NoError(t, terminateErr)
 		})
 
+		// The ID round-trips through the APIs that map it back to the container
+		describeResp, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+			InstanceIds: []string{instanceID},
+		})
+		require.NoError(t, err)
+		require.Len(t, describeResp.Reservations, 1)
+		require.Len(t, describeResp.Reservations[0].Instances, 1)
+		assert.Equal(t, instanceID, aws.ToString(describeResp.Reservations[0].Instances[0].InstanceId))
+		statusResp, err := e.Client.DescribeInstanceStatus(ctx, &ec2.DescribeInstanceStatusInput{
+			InstanceIds: []string{instanceID},
+		})
+		require.NoError(t, err)
+		require.Len(t, statusResp.InstanceStatuses, 1)
+		assert.Equal(t, instanceID, aws.ToString(statusResp.InstanceStatuses[0].InstanceId))
+
 		volumeResp, err := e.Client.CreateVolume(ctx, &ec2.CreateVolumeInput{
 			AvailabilityZone: aws.String("us-east-1a"),
 			Size:             aws.Int32(1),
@@ -0,0 +1,180 @@
+package dc2_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescribeImages(t *testing.T) {
+	t.Parallel()
+
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		// Launching an instance pulls nginx, so it's present locally
+		runResp, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:      aws.String("nginx"),
+			InstanceType: "my-type",
+			MinCount:     aws.Int32(1),
+			MaxCount:     aws.Int32(1),
+		})
+		require.NoError(t, err)
+		require.Len(t, runResp.Instances, 1)
+		instanceID := aws.ToString(runResp.Instances[0].InstanceId)
+		launchedIDs := []string{instanceID}
+		t.Cleanup(func() {
+			apiCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, err := e.Client.TerminateInstances(apiCtx, &ec2.TerminateInstancesInput{
+				InstanceIds: launchedIDs,
+			})
+			require.NoError(t, err)
+		})
+
+		registryOut, err := e.Client.DescribeImages(ctx, &ec2.DescribeImagesInput{
+			Filters: []types.Filter{
+				{Name: aws.String("image-id"), Values: []string{"nginx"}},
+				{Name: aws.String("owner-alias"), Values: []string{"amazon"}},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, registryOut.Images, 1)
+		nginx := registryOut.Images[0]
+		assert.Equal(t, "nginx", aws.ToString(nginx.Name))
+		assert.Equal(t, types.ImageStateAvailable, nginx.State)
+		assert.Equal(t, types.DeviceTypeEbs, nginx.RootDeviceType)
+		assert.NotEmpty(t, nginx.Architecture)
+
+		createOut, err := e.Client.CreateImage(ctx, &ec2.CreateImageInput{
+			InstanceId:  aws.String(instanceID),
+			Name:        aws.String(t.Name()),
+			Description: aws.String("created from nginx"),
+			TagSpecifications: []types.TagSpecification{
+				{
+					ResourceType: types.ResourceTypeImage,
+					Tags:         []types.Tag{{Key: aws.String("Source"), Value: aws.String("nginx")}},
+				},
+			},
+		})
+		require.NoError(t, err)
+		imageID := aws.ToString(createOut.ImageId)
+		assert.Regexp(t, `^ami-[0-9a-f]{17}$`, imageID)
+
+		selfOut, err := e.Client.DescribeImages(ctx, &ec2.DescribeImagesInput{
+			Owners: []string{"self"},
+		})
+		require.NoError(t, err)
+		require.Len(t, selfOut.Images, 1)
+		image := selfOut.Images[0]
+		assert.Equal(t, imageID, aws.ToString(image.ImageId))
+		assert.Equal(t, t.Name(), aws.ToString(image.Name))
+		assert.Equal(t, "created from nginx", aws.ToString(image.Description))
+		assert.Equal(t, nginx.Architecture, image.Architecture)
+		assert.Equal(t, types.ImageStateAvailable, image.State)
+		assert.Equal(t, types.DeviceTypeEbs, image.RootDeviceType)
+		assert.Equal(t, []types.Tag{{Key: aws.String("Source"), Value: aws.String("nginx")}}, image.Tags)
+
+		// The created AMI can be launched
+		amiRunResp, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:      aws.String(imageID),
+			InstanceType: "my-type",
+			MinCount:     aws.Int32(1),
+			MaxCount:     aws.Int32(1),
+		})
+		require.NoError(t, err)
+		require.Len(t, amiRunResp.Instances, 1)
+		launchedIDs = append(launchedIDs, aws.ToString(amiRunResp.Instances[0].InstanceId))
+		assert.Equal(t, imageID, aws.ToString(amiRunResp.Instances[0].ImageId))
+	})
+}
+
+func TestCopyImage(t *testing.T) {
+	t.Parallel()
+
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		runResp, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:      aws.String("nginx"),
+			InstanceType: "my-type",
+			MinCount:     aws.Int32(1),
+			MaxCount:     aws.Int32(1),
+		})
+		require.NoError(t, err)
+		require.Len(t, runResp.Instances, 1)
+		launchedIDs := []string{aws.ToString(runResp.Instances[0].InstanceId)}
+		t.Cleanup(func() {
+			apiCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, err := e.Client.TerminateInstances(apiCtx, &ec2.TerminateInstancesInput{
+				InstanceIds: launchedIDs,
+			})
+			require.NoError(t, err)
+		})
+
+		createOut, err := e.Client.CreateImage(ctx, &ec2.CreateImageInput{
+			InstanceId:  runResp.Instances[0].InstanceId,
+			Name:        aws.String(t.Name()),
+			Description: aws.String("created from nginx"),
+		})
+		require.NoError(t, err)
+		imageID := aws.ToString(createOut.ImageId)
+
+		copyOut, err := e.Client.CopyImage(ctx, &ec2.CopyImageInput{
+			SourceImageId: aws.String(imageID),
+			SourceRegion:  aws.String(e.Region),
+			Name:          aws.String(t.Name() + "-copy"),
+			TagSpecifications: []types.TagSpecification{
+				{
+					ResourceType: types.ResourceTypeImage,
+					Tags:         []types.Tag{{Key: aws.String("Copy"), Value: aws.String("true")}},
+				},
+			},
+		})
+		require.NoError(t, err)
+		copyID := aws.ToString(copyOut.ImageId)
+		assert.Regexp(t, `^ami-[0-9a-f]{17}$`, copyID)
+		assert.NotEqual(t, imageID, copyID)
+
+		describeOut, err := e.Client.DescribeImages(ctx, &ec2.DescribeImagesInput{
+			ImageIds: []string{imageID, copyID},
+		})
+		require.NoError(t, err)
+		require.Len(t, describeOut.Images, 2)
+		images := make(map[string]types.Image)
+		for _, image := range describeOut.Images {
+			images[aws.ToString(image.ImageId)] = image
+		}
+		copied := images[copyID]
+		assert.Equal(t, t.Name()+"-copy", aws.ToString(copied.Name))
+		assert.Equal(t, "created from nginx", aws.ToString(copied.Description))
+		assert.Equal(t, images[imageID].Architecture, copied.Architecture)
+		assert.Equal(t, images[imageID].BlockDeviceMappings, copied.BlockDeviceMappings)
+		assert.Equal(t, []types.Tag{{Key: aws.String("Copy"), Value: aws.String("true")}}, copied.Tags)
+
+		// The copy can be launched
+		copyRunResp, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:      aws.String(copyID),
+			InstanceType: "my-type",
+			MinCount:     aws.Int32(1),
+			MaxCount:     aws.Int32(1),
+		})
+		require.NoError(t, err)
+		require.Len(t, copyRunResp.Instances, 1)
+		launchedIDs = append(launchedIDs, aws.ToString(copyRunResp.Instances[0].InstanceId))
+		assert.Equal(t, copyID, aws.ToString(copyRunResp.Instances[0].ImageId))
+		assert.Equal(t, types.InstanceStateNameRunning, copyRunResp.Instances[0].State.Name)
+
+		_, err = e.Client.CopyImage(ctx, &ec2.CopyImageInput{
+			SourceImageId: aws.String("ami-0123456789abcdef0"),
+			SourceRegion:  aws.String(e.Region),
+			Name:          aws.String(t.Name() + "-missing"),
+		})
+		var apiErr smithy.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "InvalidAMIID.NotFound", apiErr.ErrorCode())
+	})
+}
@@ -0,0 +1,158 @@
+package dc2_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstanceProfileCredentialsViaIMDS(t *testing.T) {
+	t.Parallel()
+	requireContainerModeForIMDSTest(t)
+	const profileName = "dc2-test-profile"
+
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		runResp, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:            aws.String("nginx"),
+			InstanceType:       "my-type",
+			MinCount:           aws.Int32(1),
+			MaxCount:           aws.Int32(1),
+			IamInstanceProfile: &ec2types.IamInstanceProfileSpecification{Name: aws.String(profileName)},
+		})
+		require.NoError(t, err)
+		require.Len(t, runResp.Instances, 1)
+		instance := runResp.Instances[0]
+		require.NotNil(t, instance.IamInstanceProfile)
+		assert.True(t, strings.HasSuffix(aws.ToString(instance.IamInstanceProfile.Arn), ":instance-profile/"+profileName))
+
+		instanceID := aws.ToString(instance.InstanceId)
+		containerID := containerIDForInstanceID(t, ctx, e.DockerHost, instanceID)
+
+		t.Cleanup(func() {
+			cleanupCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, err := e.Client.TerminateInstances(cleanupCtx, &ec2.TerminateInstancesInput{
+				InstanceIds: []string{instanceID},
+			})
+			require.NoError(t, err)
+		})
+
+		token := fetchIMDSToken(t, ctx, e.DockerHost, containerID)
+
+		rolesOutput, err := curlIMDS(ctx, e.DockerHost, containerID, "/latest/meta-data/iam/security-credentials/", token)
+		require.NoError(t, err, "IMDS security credentials listing output: %s", string(rolesOutput))
+		assert.Equal(t, profileName, strings.TrimSpace(string(rolesOutput)))
+
+		credentialsOutput, err := curlIMDS(ctx, e.DockerHost, containerID, "/latest/meta-data/iam/security-credentials/"+profileName, token)
+		require.NoError(t, err, "IMDS security credentials output: %s", string(credentialsOutput))
+		var credentials struct {
+			Code            string
+			Type            string
+			AccessKeyID     string `json:"AccessKeyId"`
+			SecretAccessKey string
+			Token           string
+			Expiration      time.Time
+		}
+		require.NoError(t, json.Unmarshal(credentialsOutput, &credentials))
+		assert.Equal(t, "Success", credentials.Code)
+		assert.Equal(t, "AWS-HMAC", credentials.Type)
+		assert.NotEmpty(t, credentials.AccessKeyID)
+		assert.NotEmpty(t, credentials.SecretAccessKey)
+		assert.NotEmpty(t, credentials.Token)
+		assert.True(t, credentials.Expiration.After(time.Now()))
+
+		infoOutput, err := curlIMDS(ctx, e.DockerHost, containerID, "/latest/meta-data/iam/info", token)
+		require.NoError(t, err, "IMDS iam info output: %s", string(infoOutput))
+		var info struct {
+			Code               string
+			InstanceProfileArn string
+			InstanceProfileID  string `json:"InstanceProfileId"`
+		}
+		require.NoError(t, json.Unmarshal(infoOutput, &info))
+		assert.Equal(t, "Success", info.Code)
+		assert.Equal(t, aws.ToString(instance.IamInstanceProfile.Arn), info.InstanceProfileArn)
+		assert.Equal(t, aws.ToString(instance.IamInstanceProfile.Id), info.InstanceProfileID)
+
+		missingRoleOutput, err := curlIMDS(ctx, e.DockerHost, containerID, "/latest/meta-data/iam/security-credentials/other-role", token)
+		require.Error(t, err)
+		assert.Contains(t, string(missingRoleOutput), "404")
+	})
+}
+
+func TestAssociateIamInstanceProfile(t *testing.T) {
+	t.Parallel()
+
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		runResp, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:      aws.String("nginx"),
+			InstanceType: "my-type",
+			MinCount:     aws.Int32(1),
+			MaxCount:     aws.Int32(1),
+		})
+		require.NoError(t, err)
+		require.Len(t, runResp.Instances, 1)
+		instanceID := aws.ToString(runResp.Instances[0].InstanceId)
+		assert.Nil(t, runResp.Instances[0].IamInstanceProfile)
+
+		t.Cleanup(func() {
+			cleanupCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, err := e.Client.TerminateInstances(cleanupCtx, &ec2.TerminateInstancesInput{
+				InstanceIds: []string{instanceID},
+			})
+			require.NoError(t, err)
+		})
+
+		const profileArn = "arn:aws:iam::123456789012:instance-profile/app/worker"
+		associateOut, err := e.Client.AssociateIamInstanceProfile(ctx, &ec2.AssociateIamInstanceProfileInput{
+			InstanceId:         aws.String(instanceID),
+			IamInstanceProfile: &ec2types.IamInstanceProfileSpecification{Arn: aws.String(profileArn)},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, associateOut.IamInstanceProfileAssociation)
+		association := associateOut.IamInstanceProfileAssociation
+		assert.Equal(t, instanceID, aws.ToString(association.InstanceId))
+		require.NotNil(t, association.IamInstanceProfile)
+		assert.Equal(t, profileArn, aws.ToString(association.IamInstanceProfile.Arn))
+
+		describeInstanceProfile := func() *ec2types.IamInstanceProfile {
+			out, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{InstanceIds: []string{instanceID}})
+			require.NoError(t, err)
+			require.Len(t, out.Reservations, 1)
+			require.Len(t, out.Reservations[0].Instances, 1)
+			return out.Reservations[0].Instances[0].IamInstanceProfile
+		}
+		described := describeInstanceProfile()
+		require.NotNil(t, described)
+		assert.Equal(t, profileArn, aws.ToString(described.Arn))
+
+		_, err = e.Client.AssociateIamInstanceProfile(ctx, &ec2.AssociateIamInstanceProfileInput{
+			InstanceId:         aws.String(instanceID),
+			IamInstanceProfile: &ec2types.IamInstanceProfileSpecification{Name: aws.String("other")},
+		})
+		var apiErr smithy.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "IncorrectState", apiErr.ErrorCode())
+
+		_, err = e.Client.DisassociateIamInstanceProfile(ctx, &ec2.DisassociateIamInstanceProfileInput{
+			AssociationId: association.AssociationId,
+		})
+		require.NoError(t, err)
+		assert.Nil(t, describeInstanceProfile())
+
+		_, err = e.Client.DisassociateIamInstanceProfile(ctx, &ec2.DisassociateIamInstanceProfileInput{
+			AssociationId: association.AssociationId,
+		})
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "InvalidAssociationID.NotFound", apiErr.ErrorCode())
+	})
+}
@@ -30,6 +30,26 @@ func TestDescribeInstanceTypes(t *testing.T) {
 	})
 }
 
+func TestDescribeInstanceTypesReportsCapacity(t *testing.T) {
+	t.Parallel()
+
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		resp, err := e.Client.DescribeInstanceTypes(ctx, &ec2.DescribeInstanceTypesInput{
+			InstanceTypes: []ec2types.InstanceType{ec2types.InstanceTypeT3Micro},
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.InstanceTypes, 1)
+		instanceType := resp.InstanceTypes[0]
+		assert.Equal(t, ec2types.InstanceTypeT3Micro, instanceType.InstanceType)
+		require.NotNil(t, instanceType.VCpuInfo)
+		assert.Equal(t, int32(2), aws.ToInt32(instanceType.VCpuInfo.DefaultVCpus))
+		require.NotNil(t, instanceType.MemoryInfo)
+		assert.Equal(t, int64(1024), aws.ToInt64(instanceType.MemoryInfo.SizeInMiB))
+		require.NotNil(t, instanceType.ProcessorInfo)
+		assert.Contains(t, instanceType.ProcessorInfo.SupportedArchitectures, ec2types.ArchitectureTypeX8664)
+	})
+}
+
 func TestDescribeInstanceTypeOfferingsGlobalAvailability(t *testing.T) {
 	t.Parallel()
 
@@ -44,6 +44,8 @@ const (
 	imdsTokenHeader      = "X-aws-ec2-metadata-token"
 	imdsTokenTTLField    = "X-aws-ec2-metadata-token-ttl-seconds"
 	serverStartupTimeout = 60 * time.Second
+	// defaultSubnetID is the subnet dc2 always provides
+	defaultSubnetID = "subnet-00000000000000000"
 )
 
 type testMode string
@@ -649,6 +651,70 @@ func TestInstanceUserDataViaIMDS(t *testing.T) {
 	})
 }
 
+func TestModifyInstanceUserDataViaIMDS(t *testing.T) {
+	t.Parallel()
+	requireContainerModeForIMDSTest(t)
+	const (
+		userData        = "#!/bin/sh\necho before-modify\n"
+		updatedUserData = "#!/bin/sh\necho after-modify\n"
+	)
+
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		runResp, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:      aws.String("nginx"),
+			InstanceType: "my-type",
+			UserData:     aws.String(base64.StdEncoding.EncodeToString([]byte(userData))),
+			MinCount:     aws.Int32(1),
+			MaxCount:     aws.Int32(1),
+		})
+		require.NoError(t, err)
+		require.Len(t, runResp.Instances, 1)
+		instanceID := aws.ToString(runResp.Instances[0].InstanceId)
+		t.Cleanup(func() {
+			cleanupCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, err := e.Client.TerminateInstances(cleanupCtx, &ec2.TerminateInstancesInput{
+				InstanceIds: []string{instanceID},
+			})
+			require.NoError(t, err)
+		})
+
+		_, err = e.Client.ModifyInstanceAttribute(ctx, &ec2.ModifyInstanceAttributeInput{
+			InstanceId: aws.String(instanceID),
+			UserData:   &types.BlobAttributeValue{Value: []byte(updatedUserData)},
+		})
+		var apiErr smithy.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "IncorrectInstanceState", apiErr.ErrorCode())
+
+		_, err = e.Client.StopInstances(ctx, &ec2.StopInstancesInput{InstanceIds: []string{instanceID}})
+		require.NoError(t, err)
+		_, err = e.Client.ModifyInstanceAttribute(ctx, &ec2.ModifyInstanceAttributeInput{
+			InstanceId: aws.String(instanceID),
+			UserData:   &types.BlobAttributeValue{Value: []byte(updatedUserData)},
+		})
+		require.NoError(t, err)
+		_, err = e.Client.StartInstances(ctx, &ec2.StartInstancesInput{InstanceIds: []string{instanceID}})
+		require.NoError(t, err)
+
+		containerID := containerIDForInstanceID(t, ctx, e.DockerHost, instanceID)
+		token := fetchIMDSToken(t, ctx, e.DockerHost, containerID)
+		userDataOutput, err := curlIMDS(ctx, e.DockerHost, containerID, "/latest/user-data", token)
+		require.NoError(t, err, "curl user-data output: %s", string(userDataOutput))
+		assert.Equal(t, updatedUserData, string(userDataOutput))
+
+		attribute, err := e.Client.DescribeInstanceAttribute(ctx, &ec2.DescribeInstanceAttributeInput{
+			InstanceId: aws.String(instanceID),
+			Attribute:  types.InstanceAttributeNameUserData,
+		})
+		require.NoError(t, err)
+		require.NotNil(t, attribute.UserData)
+		decoded, err := base64.StdEncoding.DecodeString(aws.ToString(attribute.UserData.Value))
+		require.NoError(t, err)
+		assert.Equal(t, updatedUserData, string(decoded))
+	})
+}
+
 func TestInstanceMetadataRequiresToken(t *testing.T) {
 	t.Parallel()
 	requireContainerModeForIMDSTest(t)
@@ -787,6 +853,67 @@ func TestInstanceTagsViaIMDS(t *testing.T) {
 	})
 }
 
+func TestInstancePlacementAndNetworkViaIMDS(t *testing.T) {
+	t.Parallel()
+	requireContainerModeForIMDSTest(t)
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		runResp, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:      aws.String("nginx"),
+			InstanceType: "my-type",
+			MinCount:     aws.Int32(1),
+			MaxCount:     aws.Int32(1),
+		})
+		require.NoError(t, err)
+		require.Len(t, runResp.Instances, 1)
+		require.NotNil(t, runResp.Instances[0].InstanceId)
+
+		instanceID := *runResp.Instances[0].InstanceId
+		containerID := containerIDForInstanceID(t, ctx, e.DockerHost, instanceID)
+
+		t.Cleanup(func() {
+			cleanupCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, err := e.Client.TerminateInstances(cleanupCtx, &ec2.TerminateInstancesInput{
+				InstanceIds: []string{instanceID},
+			})
+			require.NoError(t, err)
+		})
+
+		describeOut, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+			InstanceIds: []string{instanceID},
+		})
+		require.NoError(t, err)
+		require.Len(t, describeOut.Reservations, 1)
+		require.Len(t, describeOut.Reservations[0].Instances, 1)
+		instance := describeOut.Reservations[0].Instances[0]
+		require.NotNil(t, instance.Placement)
+		require.NotEmpty(t, aws.ToString(instance.PrivateIpAddress))
+
+		token := fetchIMDSToken(t, ctx, e.DockerHost, containerID)
+
+		azOutput, err := curlIMDS(ctx, e.DockerHost, containerID, "/latest/meta-data/placement/availability-zone", token)
+		require.NoError(t, err, "IMDS availability zone output: %s", string(azOutput))
+		assert.Equal(t, aws.ToString(instance.Placement.AvailabilityZone), strings.TrimSpace(string(azOutput)))
+
+		localIPv4Output, err := curlIMDS(ctx, e.DockerHost, containerID, "/latest/meta-data/local-ipv4", token)
+		require.NoError(t, err, "IMDS local-ipv4 output: %s", string(localIPv4Output))
+		assert.Equal(t, aws.ToString(instance.PrivateIpAddress), strings.TrimSpace(string(localIPv4Output)))
+
+		hostnameOutput, err := curlIMDS(ctx, e.DockerHost, containerID, "/latest/meta-data/local-hostname", token)
+		require.NoError(t, err, "IMDS local-hostname output: %s", string(hostnameOutput))
+		assert.Equal(t, aws.ToString(instance.PrivateDnsName), strings.TrimSpace(string(hostnameOutput)))
+
+		listingOutput, err := curlIMDS(ctx, e.DockerHost, containerID, "/latest/meta-data/", token)
+		require.NoError(t, err, "IMDS metadata listing output: %s", string(listingOutput))
+		entries := strings.Split(strings.TrimSpace(string(listingOutput)), "\n")
+		assert.Subset(t, entries, []string{"hostname", "instance-id", "local-hostname", "local-ipv4", "mac", "placement/"})
+
+		placementOutput, err := curlIMDS(ctx, e.DockerHost, containerID, "/latest/meta-data/placement/", token)
+		require.NoError(t, err, "IMDS placement listing output: %s", string(placementOutput))
+		assert.Equal(t, []string{"availability-zone", "region"}, strings.Split(strings.TrimSpace(string(placementOutput)), "\n"))
+	})
+}
+
 func TestInstanceMetadataOptionsCanDisableIMDSAtRuntime(t *testing.T) {
 	t.Parallel()
 	requireContainerModeForIMDSTest(t)
@@ -868,6 +995,161 @@ func TestInstanceMetadataOptionsCanDisableIMDSAtRuntime(t *testing.T) {
 	})
 }
 
+func TestInstanceMetadataOptionsHTTPTokens(t *testing.T) {
+	t.Parallel()
+	requireContainerModeForIMDSTest(t)
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		runResp, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:      aws.String("nginx"),
+			InstanceType: "my-type",
+			MinCount:     aws.Int32(1),
+			MaxCount:     aws.Int32(1),
+		})
+		require.NoError(t, err)
+		require.Len(t, runResp.Instances, 1)
+		require.NotNil(t, runResp.Instances[0].InstanceId)
+
+		instanceID := *runResp.Instances[0].InstanceId
+		containerID := containerIDForInstanceID(t, ctx, e.DockerHost, instanceID)
+
+		t.Cleanup(func() {
+			cleanupCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, err := e.Client.TerminateInstances(cleanupCtx, &ec2.TerminateInstancesInput{
+				InstanceIds: []string{instanceID},
+			})
+			require.NoError(t, err)
+		})
+
+		describeMetadataOptions := func() *types.InstanceMetadataOptionsResponse {
+			t.Helper()
+			describeOutput, describeErr := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+				InstanceIds: []string{instanceID},
+			})
+			require.NoError(t, describeErr)
+			require.Len(t, describeOutput.Reservations, 1)
+			require.Len(t, describeOutput.Reservations[0].Instances, 1)
+			metadataOptions := describeOutput.Reservations[0].Instances[0].MetadataOptions
+			require.NotNil(t, metadataOptions)
+			return metadataOptions
+		}
+		defaultOptions := describeMetadataOptions()
+		assert.Equal(t, types.HttpTokensStateRequired, defaultOptions.HttpTokens)
+		assert.Equal(t, int32(1), aws.ToInt32(defaultOptions.HttpPutResponseHopLimit))
+
+		requiredOutput, err := curlIMDS(ctx, e.DockerHost, containerID, "/latest/meta-data/instance-id", "")
+		require.Error(t, err)
+		assert.Contains(t, string(requiredOutput), "401")
+
+		optionalOutput, err := e.Client.ModifyInstanceMetadataOptions(ctx, &ec2.ModifyInstanceMetadataOptionsInput{
+			InstanceId:              aws.String(instanceID),
+			HttpTokens:              types.HttpTokensStateOptional,
+			HttpPutResponseHopLimit: aws.Int32(2),
+		})
+		require.NoError(t, err)
+		require.NotNil(t, optionalOutput.InstanceMetadataOptions)
+		assert.Equal(t, types.HttpTokensStateOptional, optionalOutput.InstanceMetadataOptions.HttpTokens)
+		assert.Equal(t, int32(2), aws.ToInt32(optionalOutput.InstanceMetadataOptions.HttpPutResponseHopLimit))
+		optionalOptions := describeMetadataOptions()
+		assert.Equal(t, types.HttpTokensStateOptional, optionalOptions.HttpTokens)
+		assert.Equal(t, int32(2), aws.ToInt32(optionalOptions.HttpPutResponseHopLimit))
+
+		imdsV1Output, err := curlIMDS(ctx, e.DockerHost, containerID, "/latest/meta-data/instance-id", "")
+		require.NoError(t, err, "IMDSv1 instance-id output: %s", string(imdsV1Output))
+		assert.Equal(t, instanceID, strings.TrimSpace(string(imdsV1Output)))
+
+		invalidTokenOutput, err := curlIMDS(ctx, e.DockerHost, containerID, "/latest/meta-data/instance-id", "invalid-token")
+		require.Error(t, err)
+		assert.Contains(t, string(invalidTokenOutput), "401")
+
+		_, err = e.Client.ModifyInstanceMetadataOptions(ctx, &ec2.ModifyInstanceMetadataOptionsInput{
+			InstanceId: aws.String(instanceID),
+			HttpTokens: types.HttpTokensStateRequired,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, types.HttpTokensStateRequired, describeMetadataOptions().HttpTokens)
+
+		rejectedOutput, err := curlIMDS(ctx, e.DockerHost, containerID, "/latest/meta-data/instance-id", "")
+		require.Error(t, err)
+		assert.Contains(t, string(rejectedOutput), "401")
+
+		token := fetchIMDSToken(t, ctx, e.DockerHost, containerID)
+		imdsV2Output, err := curlIMDS(ctx, e.DockerHost, containerID, "/latest/meta-data/instance-id", token)
+		require.NoError(t, err, "IMDSv2 instance-id output: %s", string(imdsV2Output))
+		assert.Equal(t, instanceID, strings.TrimSpace(string(imdsV2Output)))
+	})
+}
+
+func TestMonitorInstances(t *testing.T) {
+	t.Parallel()
+
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		runResp, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:      aws.String("nginx"),
+			InstanceType: "my-type",
+			MinCount:     aws.Int32(2),
+			MaxCount:     aws.Int32(2),
+			Monitoring:   &types.RunInstancesMonitoringEnabled{Enabled: aws.Bool(true)},
+		})
+		require.NoError(t, err)
+		require.Len(t, runResp.Instances, 2)
+		instanceIDs := []string{
+			aws.ToString(runResp.Instances[0].InstanceId),
+			aws.ToString(runResp.Instances[1].InstanceId),
+		}
+
+		t.Cleanup(func() {
+			cleanupCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, err := e.Client.TerminateInstances(cleanupCtx, &ec2.TerminateInstancesInput{
+				InstanceIds: instanceIDs,
+			})
+			require.NoError(t, err)
+		})
+
+		describeMonitoringState := func(instanceID string) types.MonitoringState {
+			t.Helper()
+			out, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{InstanceIds: []string{instanceID}})
+			require.NoError(t, err)
+			require.Len(t, out.Reservations, 1)
+			require.Len(t, out.Reservations[0].Instances, 1)
+			require.NotNil(t, out.Reservations[0].Instances[0].Monitoring)
+			return out.Reservations[0].Instances[0].Monitoring.State
+		}
+		assert.Equal(t, types.MonitoringStateEnabled, describeMonitoringState(instanceIDs[0]))
+
+		unmonitorOut, err := e.Client.UnmonitorInstances(ctx, &ec2.UnmonitorInstancesInput{
+			InstanceIds: instanceIDs[:1],
+		})
+		require.NoError(t, err)
+		require.Len(t, unmonitorOut.InstanceMonitorings, 1)
+		assert.Equal(t, types.MonitoringStateDisabling, unmonitorOut.InstanceMonitorings[0].Monitoring.State)
+		assert.Equal(t, types.MonitoringStateDisabled, describeMonitoringState(instanceIDs[0]))
+
+		_, err = e.Client.MonitorInstances(ctx, &ec2.MonitorInstancesInput{
+			InstanceIds: instanceIDs,
+			DryRun:      aws.Bool(true),
+		})
+		var apiErr smithy.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "DryRunOperation", apiErr.ErrorCode())
+		assert.Equal(t, types.MonitoringStateDisabled, describeMonitoringState(instanceIDs[0]))
+
+		monitorOut, err := e.Client.MonitorInstances(ctx, &ec2.MonitorInstancesInput{
+			InstanceIds: instanceIDs,
+		})
+		require.NoError(t, err)
+		require.Len(t, monitorOut.InstanceMonitorings, 2)
+		for i, monitoring := range monitorOut.InstanceMonitorings {
+			assert.Equal(t, instanceIDs[i], aws.ToString(monitoring.InstanceId))
+			assert.Equal(t, types.MonitoringStatePending, monitoring.Monitoring.State)
+		}
+		for _, instanceID := range instanceIDs {
+			assert.Equal(t, types.MonitoringStateEnabled, describeMonitoringState(instanceID))
+		}
+	})
+}
+
 func TestStartStopInstances(t *testing.T) {
 	t.Parallel()
 
@@ -976,76 +1258,326 @@ func TestStartStopInstances(t *testing.T) {
 	})
 }
 
-func TestTerminateInstances(t *testing.T) {
+func TestRebootInstances(t *testing.T) {
 	t.Parallel()
+
 	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
-		t.Run("non existing", func(t *testing.T) {
-			t.Parallel()
-			terminateInstancesOutput, err := e.Client.TerminateInstances(ctx, &ec2.TerminateInstancesInput{
-				InstanceIds: []string{"does-not-exist"},
-			})
-			require.Nil(t, terminateInstancesOutput)
-			require.Error(t, err)
-			var apiErr smithy.APIError
-			require.ErrorAs(t, err, &apiErr)
-			assert.Equal(t, "InvalidInstanceID.NotFound", apiErr.ErrorCode())
+		runInstancesOutput, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:      aws.String("nginx"),
+			InstanceType: "my-type",
+			MinCount:     aws.Int32(1),
+			MaxCount:     aws.Int32(1),
 		})
-
-		t.Run("non existing with container", func(t *testing.T) {
-			t.Parallel()
-			const imageName = "nginx"
-			dockerOpts := []client.Opt{
-				client.FromEnv,
-			}
-			if e.DockerHost != "" {
-				dockerOpts = append(dockerOpts, client.WithHost(e.DockerHost))
-			}
-			cli, err := client.New(dockerOpts...)
-			require.NoError(t, err)
-
-			pullProgress, err := cli.ImagePull(ctx, imageName, client.ImagePullOptions{})
-			require.NoError(t, err)
-			_, err = io.ReadAll(pullProgress)
-			require.NoError(t, err)
-			err = pullProgress.Close()
-			require.NoError(t, err)
-			containerConfig := &container.Config{
-				Image: imageName,
-			}
-			hostConfig := &container.HostConfig{}
-			networkingConfig := &network.NetworkingConfig{}
-			cont, err := cli.ContainerCreate(ctx, client.ContainerCreateOptions{
-				Config:           containerConfig,
-				HostConfig:       hostConfig,
-				NetworkingConfig: networkingConfig,
+		require.NoError(t, err)
+		instanceID := *runInstancesOutput.Instances[0].InstanceId
+		t.Cleanup(func() {
+			apiCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, err := e.Client.TerminateInstances(apiCtx, &ec2.TerminateInstancesInput{
+				InstanceIds: []string{instanceID},
 			})
 			require.NoError(t, err)
-			_, err = cli.ContainerStart(ctx, cont.ID, client.ContainerStartOptions{})
-			require.NoError(t, err)
+		})
 
-			t.Cleanup(func() {
-				_, err := cli.ContainerRemove(ctx, cont.ID, client.ContainerRemoveOptions{Force: true, RemoveVolumes: true})
-				require.NoError(t, err)
+		containerID := containerIDForInstanceID(t, ctx, e.DockerHost, instanceID)
+		startedAt := func() string {
+			t.Helper()
+			out, err := dockerCommandContext(ctx, e.DockerHost, "inspect", "--format", "{{.State.StartedAt}}", containerID).CombinedOutput()
+			require.NoError(t, err, "docker inspect output: %s", string(out))
+			return strings.TrimSpace(string(out))
+		}
+		startedBefore := startedAt()
+
+		t.Run("dry run", func(t *testing.T) {
+			_, err := e.Client.RebootInstances(ctx, &ec2.RebootInstancesInput{
+				InstanceIds: []string{instanceID},
+				DryRun:      aws.Bool(true),
 			})
+			var apiErr smithy.APIError
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, "DryRunOperation", apiErr.ErrorCode())
+			assert.Equal(t, startedBefore, startedAt())
+		})
 
-			terminateInstancesOutput, err := e.Client.TerminateInstances(ctx, &ec2.TerminateInstancesInput{
-				InstanceIds: []string{cont.ID},
+		t.Run("unknown instance", func(t *testing.T) {
+			_, err := e.Client.RebootInstances(ctx, &ec2.RebootInstancesInput{
+				InstanceIds: []string{"i-0123456789abcdef0"},
 			})
-			require.Nil(t, terminateInstancesOutput)
-			require.Error(t, err)
 			var apiErr smithy.APIError
 			require.ErrorAs(t, err, &apiErr)
 			assert.Equal(t, "InvalidInstanceID.NotFound", apiErr.ErrorCode())
 		})
 
-		t.Run("terminate with dry run", func(t *testing.T) {
-			t.Parallel()
+		t.Run("reboot", func(t *testing.T) {
+			_, err := e.Client.RebootInstances(ctx, &ec2.RebootInstancesInput{
+				InstanceIds: []string{instanceID},
+			})
+			require.NoError(t, err)
 
-			runInstancesOutput, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
-				ImageId:      aws.String("nginx"),
-				InstanceType: "my-type",
-				MinCount:     aws.Int32(1),
-				MaxCount:     aws.Int32(1),
+			assert.Equal(t, containerID, containerIDForInstanceID(t, ctx, e.DockerHost, instanceID))
+			assert.NotEqual(t, startedBefore, startedAt())
+
+			statusOutput, err := e.Client.DescribeInstanceStatus(ctx, &ec2.DescribeInstanceStatusInput{
+				InstanceIds: []string{instanceID},
+			})
+			require.NoError(t, err)
+			require.Len(t, statusOutput.InstanceStatuses, 1)
+			assert.Equal(t, instanceID, aws.ToString(statusOutput.InstanceStatuses[0].InstanceId))
+			assert.Equal(t, types.InstanceStateNameRunning, statusOutput.InstanceStatuses[0].InstanceState.Name)
+
+			describeOutput, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+				InstanceIds: []string{instanceID},
+			})
+			require.NoError(t, err)
+			require.Len(t, describeOutput.Reservations, 1)
+			require.Len(t, describeOutput.Reservations[0].Instances, 1)
+			assert.Empty(t, aws.ToString(describeOutput.Reservations[0].Instances[0].StateTransitionReason))
+		})
+	})
+}
+
+func TestInstanceActionsDryRun(t *testing.T) {
+	t.Parallel()
+
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		runInstancesOutput, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:      aws.String("nginx"),
+			InstanceType: "my-type",
+			MinCount:     aws.Int32(1),
+			MaxCount:     aws.Int32(1),
+			TagSpecifications: []types.TagSpecification{
+				{
+					ResourceType: types.ResourceTypeInstance,
+					Tags:         []types.Tag{{Key: aws.String("existing"), Value: aws.String("value")}},
+				},
+			},
+		})
+		require.NoError(t, err)
+		instanceID := *runInstancesOutput.Instances[0].InstanceId
+		t.Cleanup(func() {
+			apiCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, err := e.Client.TerminateInstances(apiCtx, &ec2.TerminateInstancesInput{
+				InstanceIds: []string{instanceID},
+			})
+			require.NoError(t, err)
+		})
+		_, err = e.Client.StopInstances(ctx, &ec2.StopInstancesInput{
+			InstanceIds: []string{instanceID},
+		})
+		require.NoError(t, err)
+
+		dryRunTag := fmt.Sprintf("dry-run-%d", time.Now().UnixNano())
+		testCases := []struct {
+			name string
+			call func() error
+		}{
+			{
+				name: "RunInstances",
+				call: func() error {
+					_, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+						ImageId:      aws.String("nginx"),
+						InstanceType: "my-type",
+						MinCount:     aws.Int32(1),
+						MaxCount:     aws.Int32(1),
+						DryRun:       aws.Bool(true),
+						TagSpecifications: []types.TagSpecification{
+							{
+								ResourceType: types.ResourceTypeInstance,
+								Tags:         []types.Tag{{Key: aws.String(dryRunTag), Value: aws.String("value")}},
+							},
+						},
+					})
+					return err
+				},
+			},
+			{
+				name: "StartInstances",
+				call: func() error {
+					_, err := e.Client.StartInstances(ctx, &ec2.StartInstancesInput{
+						InstanceIds: []string{instanceID},
+						DryRun:      aws.Bool(true),
+					})
+					return err
+				},
+			},
+			{
+				name: "RebootInstances",
+				call: func() error {
+					_, err := e.Client.RebootInstances(ctx, &ec2.RebootInstancesInput{
+						InstanceIds: []string{instanceID},
+						DryRun:      aws.Bool(true),
+					})
+					return err
+				},
+			},
+			{
+				name: "CreateTags",
+				call: func() error {
+					_, err := e.Client.CreateTags(ctx, &ec2.CreateTagsInput{
+						Resources: []string{instanceID},
+						Tags:      []types.Tag{{Key: aws.String(dryRunTag), Value: aws.String("value")}},
+						DryRun:    aws.Bool(true),
+					})
+					return err
+				},
+			},
+			{
+				name: "DeleteTags",
+				call: func() error {
+					_, err := e.Client.DeleteTags(ctx, &ec2.DeleteTagsInput{
+						Resources: []string{instanceID},
+						Tags:      []types.Tag{{Key: aws.String("existing")}},
+						DryRun:    aws.Bool(true),
+					})
+					return err
+				},
+			},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				var apiErr smithy.APIError
+				require.ErrorAs(t, tc.call(), &apiErr)
+				assert.Equal(t, "DryRunOperation", apiErr.ErrorCode())
+			})
+		}
+
+		// None of the dry runs had side effects
+		tagged, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+			Filters: []types.Filter{{Name: aws.String("tag-key"), Values: []string{dryRunTag}}},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, tagged.Reservations)
+
+		describeOutput, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+			InstanceIds: []string{instanceID},
+		})
+		require.NoError(t, err)
+		require.Len(t, describeOutput.Reservations, 1)
+		require.Len(t, describeOutput.Reservations[0].Instances, 1)
+		instance := describeOutput.Reservations[0].Instances[0]
+		assert.Equal(t, types.InstanceStateNameStopped, instance.State.Name)
+		require.Len(t, instance.Tags, 1)
+		assert.Equal(t, "existing", aws.ToString(instance.Tags[0].Key))
+	})
+}
+
+func TestStopInstancesAsyncStateTransitions(t *testing.T) {
+	t.Parallel()
+
+	testWithServerWithOptionsAndEnvForMode(
+		t,
+		testModeHost,
+		[]dc2.Option{dc2.WithAsyncStateTransitions()},
+		nil,
+		func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+			runInstancesOutput, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+				ImageId:      aws.String("nginx"),
+				InstanceType: "my-type",
+				MinCount:     aws.Int32(1),
+				MaxCount:     aws.Int32(1),
+			})
+			require.NoError(t, err)
+			instanceID := *runInstancesOutput.Instances[0].InstanceId
+			t.Cleanup(func() {
+				apiCtx, cancel := cleanupAPICtx(t)
+				defer cancel()
+				_, err := e.Client.TerminateInstances(apiCtx, &ec2.TerminateInstancesInput{
+					InstanceIds: []string{instanceID},
+				})
+				require.NoError(t, err)
+			})
+
+			stopInstancesOutput, err := e.Client.StopInstances(ctx, &ec2.StopInstancesInput{
+				InstanceIds: []string{instanceID},
+			})
+			require.NoError(t, err)
+			require.Len(t, stopInstancesOutput.StoppingInstances, 1)
+			assert.Equal(t, types.InstanceStateNameRunning, stopInstancesOutput.StoppingInstances[0].PreviousState.Name)
+			assert.Equal(t, types.InstanceStateNameStopping, stopInstancesOutput.StoppingInstances[0].CurrentState.Name)
+
+			waiter := ec2.NewInstanceStoppedWaiter(e.Client, func(o *ec2.InstanceStoppedWaiterOptions) {
+				o.MinDelay = 100 * time.Millisecond
+				o.MaxDelay = time.Second
+			})
+			err = waiter.Wait(ctx, &ec2.DescribeInstancesInput{
+				InstanceIds: []string{instanceID},
+			}, 30*time.Second)
+			require.NoError(t, err)
+		},
+	)
+}
+
+func TestTerminateInstances(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		t.Run("non existing", func(t *testing.T) {
+			t.Parallel()
+			terminateInstancesOutput, err := e.Client.TerminateInstances(ctx, &ec2.TerminateInstancesInput{
+				InstanceIds: []string{"does-not-exist"},
+			})
+			require.Nil(t, terminateInstancesOutput)
+			require.Error(t, err)
+			var apiErr smithy.APIError
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, "InvalidInstanceID.NotFound", apiErr.ErrorCode())
+		})
+
+		t.Run("non existing with container", func(t *testing.T) {
+			t.Parallel()
+			const imageName = "nginx"
+			dockerOpts := []client.Opt{
+				client.FromEnv,
+			}
+			if e.DockerHost != "" {
+				dockerOpts = append(dockerOpts, client.WithHost(e.DockerHost))
+			}
+			cli, err := client.New(dockerOpts...)
+			require.NoError(t, err)
+
+			pullProgress, err := cli.ImagePull(ctx, imageName, client.ImagePullOptions{})
+			require.NoError(t, err)
+			_, err = io.ReadAll(pullProgress)
+			require.NoError(t, err)
+			err = pullProgress.Close()
+			require.NoError(t, err)
+			containerConfig := &container.Config{
+				Image: imageName,
+			}
+			hostConfig := &container.HostConfig{}
+			networkingConfig := &network.NetworkingConfig{}
+			cont, err := cli.ContainerCreate(ctx, client.ContainerCreateOptions{
+				Config:           containerConfig,
+				HostConfig:       hostConfig,
+				NetworkingConfig: networkingConfig,
+			})
+			require.NoError(t, err)
+			_, err = cli.ContainerStart(ctx, cont.ID, client.ContainerStartOptions{})
+			require.NoError(t, err)
+
+			t.Cleanup(func() {
+				_, err := cli.ContainerRemove(ctx, cont.ID, client.ContainerRemoveOptions{Force: true, RemoveVolumes: true})
+				require.NoError(t, err)
+			})
+
+			terminateInstancesOutput, err := e.Client.TerminateInstances(ctx, &ec2.TerminateInstancesInput{
+				InstanceIds: []string{cont.ID},
+			})
+			require.Nil(t, terminateInstancesOutput)
+			require.Error(t, err)
+			var apiErr smithy.APIError
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, "InvalidInstanceID.NotFound", apiErr.ErrorCode())
+		})
+
+		t.Run("terminate with dry run", func(t *testing.T) {
+			t.Parallel()
+
+			runInstancesOutput, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+				ImageId:      aws.String("nginx"),
+				InstanceType: "my-type",
+				MinCount:     aws.Int32(1),
+				MaxCount:     aws.Int32(1),
 			})
 			require.NoError(t, err)
 
@@ -1064,6 +1596,37 @@ func TestTerminateInstances(t *testing.T) {
 			require.NoError(t, err)
 		})
 
+		t.Run("terminate stopped instance", func(t *testing.T) {
+			t.Parallel()
+
+			runInstancesOutput, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+				ImageId:      aws.String("nginx"),
+				InstanceType: "my-type",
+				MinCount:     aws.Int32(1),
+				MaxCount:     aws.Int32(1),
+			})
+			require.NoError(t, err)
+			require.Len(t, runInstancesOutput.Instances, 1)
+			instanceID := *runInstancesOutput.Instances[0].InstanceId
+
+			_, err = e.Client.StopInstances(ctx, &ec2.StopInstancesInput{
+				InstanceIds: []string{instanceID},
+			})
+			require.NoError(t, err)
+
+			terminateInstancesOutput, err := e.Client.TerminateInstances(ctx, &ec2.TerminateInstancesInput{
+				InstanceIds: []string{instanceID},
+			})
+			require.NoError(t, err)
+			require.Len(t, terminateInstancesOutput.TerminatingInstances, 1)
+			change := terminateInstancesOutput.TerminatingInstances[0]
+			assert.Equal(t, instanceID, aws.ToString(change.InstanceId))
+			require.NotNil(t, change.PreviousState)
+			assert.Equal(t, types.InstanceStateNameStopped, change.PreviousState.Name)
+			require.NotNil(t, change.CurrentState)
+			assert.Equal(t, types.InstanceStateNameTerminated, change.CurrentState.Name)
+		})
+
 		t.Run("terminate with force and skip os shutdown", func(t *testing.T) {
 			t.Parallel()
 
@@ -1103,75 +1666,198 @@ func TestTerminateInstances(t *testing.T) {
 	})
 }
 
-func TestDescribeSecurityGroups(t *testing.T) {
+func TestInstanceTerminationProtection(t *testing.T) {
 	t.Parallel()
+
 	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
-		describeOut, err := e.Client.DescribeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{})
+		runInstancesOutput, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:      aws.String("nginx"),
+			InstanceType: "my-type",
+			MinCount:     aws.Int32(1),
+			MaxCount:     aws.Int32(1),
+		})
 		require.NoError(t, err)
-		require.Len(t, describeOut.SecurityGroups, 1)
-		require.NotNil(t, describeOut.SecurityGroups[0].GroupId)
-		require.NotNil(t, describeOut.SecurityGroups[0].GroupName)
-		assert.Equal(t, "default", aws.ToString(describeOut.SecurityGroups[0].GroupName))
+		instanceID := *runInstancesOutput.Instances[0].InstanceId
 
-		describeByNameOut, err := e.Client.DescribeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{
-			GroupNames: []string{"default"},
+		_, err = e.Client.ModifyInstanceAttribute(ctx, &ec2.ModifyInstanceAttributeInput{
+			InstanceId:            aws.String(instanceID),
+			DisableApiTermination: &types.AttributeBooleanValue{Value: aws.Bool(true)},
 		})
 		require.NoError(t, err)
-		require.Len(t, describeByNameOut.SecurityGroups, 1)
-		assert.Equal(
-			t,
-			aws.ToString(describeOut.SecurityGroups[0].GroupId),
-			aws.ToString(describeByNameOut.SecurityGroups[0].GroupId),
-		)
 
-		describeByFilterOut, err := e.Client.DescribeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{
-			Filters: []types.Filter{
-				{
-					Name:   aws.String("group-name"),
-					Values: []string{"default"},
-				},
-			},
+		attribute, err := e.Client.DescribeInstanceAttribute(ctx, &ec2.DescribeInstanceAttributeInput{
+			InstanceId: aws.String(instanceID),
+			Attribute:  types.InstanceAttributeNameDisableApiTermination,
 		})
 		require.NoError(t, err)
-		require.Len(t, describeByFilterOut.SecurityGroups, 1)
-		assert.Equal(
-			t,
-			aws.ToString(describeOut.SecurityGroups[0].GroupId),
-			aws.ToString(describeByFilterOut.SecurityGroups[0].GroupId),
-		)
-	})
-}
+		require.NotNil(t, attribute.DisableApiTermination)
+		assert.True(t, aws.ToBool(attribute.DisableApiTermination.Value))
 
-func TestSecurityGroupLifecycle(t *testing.T) {
-	t.Parallel()
-	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
-		groupName := fmt.Sprintf("sg-%d", time.Now().UnixNano())
-		createOut, err := e.Client.CreateSecurityGroup(ctx, &ec2.CreateSecurityGroupInput{
-			GroupName:   aws.String(groupName),
-			Description: aws.String("dc2 integration test security group"),
-			VpcId:       aws.String("vpc-00000000000000000"),
+		_, err = e.Client.TerminateInstances(ctx, &ec2.TerminateInstancesInput{
+			InstanceIds: []string{instanceID},
+		})
+		var apiErr smithy.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "OperationNotPermitted", apiErr.ErrorCode())
+
+		describeOutput, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+			InstanceIds: []string{instanceID},
 		})
 		require.NoError(t, err)
-		require.NotNil(t, createOut.GroupId)
+		require.Len(t, describeOutput.Reservations, 1)
+		require.Len(t, describeOutput.Reservations[0].Instances, 1)
+		assert.Equal(t, types.InstanceStateNameRunning, describeOutput.Reservations[0].Instances[0].State.Name)
 
-		_, err = e.Client.CreateTags(ctx, &ec2.CreateTagsInput{
-			Resources: []string{aws.ToString(createOut.GroupId)},
-			Tags: []types.Tag{
-				{
-					Key:   aws.String("e2e.aws"),
-					Value: aws.String("true"),
-				},
-			},
+		_, err = e.Client.ModifyInstanceAttribute(ctx, &ec2.ModifyInstanceAttributeInput{
+			InstanceId:            aws.String(instanceID),
+			DisableApiTermination: &types.AttributeBooleanValue{Value: aws.Bool(false)},
 		})
 		require.NoError(t, err)
 
-		_, err = e.Client.AuthorizeSecurityGroupIngress(ctx, &ec2.AuthorizeSecurityGroupIngressInput{
-			GroupId: createOut.GroupId,
-			IpPermissions: []types.IpPermission{
-				{
-					IpProtocol: aws.String("tcp"),
-					FromPort:   aws.Int32(22),
-					ToPort:     aws.Int32(22),
+		terminateOutput, err := e.Client.TerminateInstances(ctx, &ec2.TerminateInstancesInput{
+			InstanceIds: []string{instanceID},
+		})
+		require.NoError(t, err)
+		require.Len(t, terminateOutput.TerminatingInstances, 1)
+		assert.Equal(t, instanceID, aws.ToString(terminateOutput.TerminatingInstances[0].InstanceId))
+	})
+}
+
+func TestInstanceStoreResetsFilesystemOnStopStart(t *testing.T) {
+	t.Parallel()
+
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		const markerPath = "/tmp/dc2-volume-type-marker"
+
+		// markerSurvivesRestart writes a file to a new instance, stops and
+		// starts it, and reports whether the file survived.
+		markerSurvivesRestart := func(t *testing.T, tags []types.Tag) bool {
+			t.Helper()
+			runOut, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+				ImageId:      aws.String("nginx"),
+				InstanceType: "my-type",
+				MinCount:     aws.Int32(1),
+				MaxCount:     aws.Int32(1),
+				TagSpecifications: []types.TagSpecification{
+					{ResourceType: types.ResourceTypeInstance, Tags: tags},
+				},
+			})
+			require.NoError(t, err)
+			instanceID := aws.ToString(runOut.Instances[0].InstanceId)
+			t.Cleanup(func() {
+				cleanupCtx, cancel := cleanupAPICtx(t)
+				defer cancel()
+				_, err := e.Client.TerminateInstances(cleanupCtx, &ec2.TerminateInstancesInput{
+					InstanceIds: []string{instanceID},
+				})
+				if err != nil && !isInstanceNotFound(err) {
+					t.Logf("cleanup terminate instance %s returned error: %v", instanceID, err)
+				}
+			})
+
+			containerID := containerIDForInstanceID(t, ctx, e.DockerHost, instanceID)
+			out, err := dockerCommandContext(ctx, e.DockerHost, "exec", containerID, "touch", markerPath).CombinedOutput()
+			require.NoError(t, err, "docker exec output: %s", string(out))
+
+			_, err = e.Client.StopInstances(ctx, &ec2.StopInstancesInput{InstanceIds: []string{instanceID}})
+			require.NoError(t, err)
+			_, err = e.Client.StartInstances(ctx, &ec2.StartInstancesInput{InstanceIds: []string{instanceID}})
+			require.NoError(t, err)
+
+			describeOut, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+				InstanceIds: []string{instanceID},
+			})
+			require.NoError(t, err)
+			require.Len(t, describeOut.Reservations, 1)
+			require.Len(t, describeOut.Reservations[0].Instances, 1)
+			assert.Equal(t, types.InstanceStateNameRunning, describeOut.Reservations[0].Instances[0].State.Name)
+
+			containerID = containerIDForInstanceID(t, ctx, e.DockerHost, instanceID)
+			err = dockerCommandContext(ctx, e.DockerHost, "exec", containerID, "test", "-e", markerPath).Run()
+			return err == nil
+		}
+
+		t.Run("default", func(t *testing.T) {
+			t.Parallel()
+			assert.True(t, markerSurvivesRestart(t, []types.Tag{{Key: aws.String("Name"), Value: aws.String("ebs")}}))
+		})
+
+		t.Run("instance-store", func(t *testing.T) {
+			t.Parallel()
+			assert.False(t, markerSurvivesRestart(t, []types.Tag{{Key: aws.String("dc2:volume-type"), Value: aws.String("instance-store")}}))
+		})
+	})
+}
+
+func TestDescribeSecurityGroups(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		describeOut, err := e.Client.DescribeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{})
+		require.NoError(t, err)
+		require.Len(t, describeOut.SecurityGroups, 1)
+		require.NotNil(t, describeOut.SecurityGroups[0].GroupId)
+		require.NotNil(t, describeOut.SecurityGroups[0].GroupName)
+		assert.Equal(t, "default", aws.ToString(describeOut.SecurityGroups[0].GroupName))
+
+		describeByNameOut, err := e.Client.DescribeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{
+			GroupNames: []string{"default"},
+		})
+		require.NoError(t, err)
+		require.Len(t, describeByNameOut.SecurityGroups, 1)
+		assert.Equal(
+			t,
+			aws.ToString(describeOut.SecurityGroups[0].GroupId),
+			aws.ToString(describeByNameOut.SecurityGroups[0].GroupId),
+		)
+
+		describeByFilterOut, err := e.Client.DescribeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{
+			Filters: []types.Filter{
+				{
+					Name:   aws.String("group-name"),
+					Values: []string{"default"},
+				},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, describeByFilterOut.SecurityGroups, 1)
+		assert.Equal(
+			t,
+			aws.ToString(describeOut.SecurityGroups[0].GroupId),
+			aws.ToString(describeByFilterOut.SecurityGroups[0].GroupId),
+		)
+	})
+}
+
+func TestSecurityGroupLifecycle(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		groupName := fmt.Sprintf("sg-%d", time.Now().UnixNano())
+		createOut, err := e.Client.CreateSecurityGroup(ctx, &ec2.CreateSecurityGroupInput{
+			GroupName:   aws.String(groupName),
+			Description: aws.String("dc2 integration test security group"),
+			VpcId:       aws.String("vpc-00000000000000000"),
+		})
+		require.NoError(t, err)
+		require.NotNil(t, createOut.GroupId)
+
+		_, err = e.Client.CreateTags(ctx, &ec2.CreateTagsInput{
+			Resources: []string{aws.ToString(createOut.GroupId)},
+			Tags: []types.Tag{
+				{
+					Key:   aws.String("e2e.aws"),
+					Value: aws.String("true"),
+				},
+			},
+		})
+		require.NoError(t, err)
+
+		_, err = e.Client.AuthorizeSecurityGroupIngress(ctx, &ec2.AuthorizeSecurityGroupIngressInput{
+			GroupId: createOut.GroupId,
+			IpPermissions: []types.IpPermission{
+				{
+					IpProtocol: aws.String("tcp"),
+					FromPort:   aws.Int32(22),
+					ToPort:     aws.Int32(22),
 					IpRanges: []types.IpRange{
 						{
 							CidrIp:      aws.String("0.0.0.0/0"),
@@ -1207,6 +1893,81 @@ func TestSecurityGroupLifecycle(t *testing.T) {
 	})
 }
 
+func TestSecurityGroupRules(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		createOut, err := e.Client.CreateSecurityGroup(ctx, &ec2.CreateSecurityGroupInput{
+			GroupName:   aws.String(fmt.Sprintf("sg-rules-%d", time.Now().UnixNano())),
+			Description: aws.String("dc2 integration test security group rules"),
+		})
+		require.NoError(t, err)
+		groupID := aws.ToString(createOut.GroupId)
+		t.Cleanup(func() {
+			_, _ = e.Client.DeleteSecurityGroup(context.Background(), &ec2.DeleteSecurityGroupInput{GroupId: createOut.GroupId})
+		})
+		sshPermission := types.IpPermission{
+			IpProtocol: aws.String("tcp"),
+			FromPort:   aws.Int32(22),
+			ToPort:     aws.Int32(22),
+			IpRanges: []types.IpRange{
+				{CidrIp: aws.String("10.0.0.0/8"), Description: aws.String("ssh")},
+			},
+		}
+		describeGroup := func() types.SecurityGroup {
+			out, err := e.Client.DescribeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{
+				GroupIds: []string{groupID},
+			})
+			require.NoError(t, err)
+			require.Len(t, out.SecurityGroups, 1)
+			return out.SecurityGroups[0]
+		}
+
+		group := describeGroup()
+		assert.Empty(t, group.IpPermissions)
+		require.Len(t, group.IpPermissionsEgress, 1)
+		assert.Equal(t, "-1", aws.ToString(group.IpPermissionsEgress[0].IpProtocol))
+
+		_, err = e.Client.AuthorizeSecurityGroupIngress(ctx, &ec2.AuthorizeSecurityGroupIngressInput{
+			GroupId:       createOut.GroupId,
+			IpPermissions: []types.IpPermission{sshPermission},
+		})
+		require.NoError(t, err)
+		group = describeGroup()
+		require.Len(t, group.IpPermissions, 1)
+		assert.Equal(t, "tcp", aws.ToString(group.IpPermissions[0].IpProtocol))
+		assert.Equal(t, int32(22), aws.ToInt32(group.IpPermissions[0].FromPort))
+		assert.Equal(t, int32(22), aws.ToInt32(group.IpPermissions[0].ToPort))
+		require.Len(t, group.IpPermissions[0].IpRanges, 1)
+		assert.Equal(t, "10.0.0.0/8", aws.ToString(group.IpPermissions[0].IpRanges[0].CidrIp))
+		assert.Equal(t, "ssh", aws.ToString(group.IpPermissions[0].IpRanges[0].Description))
+
+		_, err = e.Client.AuthorizeSecurityGroupIngress(ctx, &ec2.AuthorizeSecurityGroupIngressInput{
+			GroupId:       createOut.GroupId,
+			IpPermissions: []types.IpPermission{sshPermission},
+		})
+		var apiErr smithy.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "InvalidPermission.Duplicate", apiErr.ErrorCode())
+
+		_, err = e.Client.RevokeSecurityGroupIngress(ctx, &ec2.RevokeSecurityGroupIngressInput{
+			GroupId:       createOut.GroupId,
+			IpPermissions: []types.IpPermission{sshPermission},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, describeGroup().IpPermissions)
+
+		_, err = e.Client.RevokeSecurityGroupEgress(ctx, &ec2.RevokeSecurityGroupEgressInput{
+			GroupId: createOut.GroupId,
+			IpPermissions: []types.IpPermission{{
+				IpProtocol: aws.String("-1"),
+				IpRanges:   []types.IpRange{{CidrIp: aws.String("0.0.0.0/0")}},
+			}},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, describeGroup().IpPermissionsEgress)
+	})
+}
+
 func TestDescribeSubnets(t *testing.T) {
 	t.Parallel()
 	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
@@ -1377,6 +2138,55 @@ func TestDescribeInstanceStatus(t *testing.T) {
 	})
 }
 
+func TestInstanceEventEndpoint(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		runInstancesOutput, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:      aws.String("nginx"),
+			InstanceType: "my-type",
+			MinCount:     aws.Int32(1),
+			MaxCount:     aws.Int32(1),
+		})
+		require.NoError(t, err)
+		require.Len(t, runInstancesOutput.Instances, 1)
+		instanceID := aws.ToString(runInstancesOutput.Instances[0].InstanceId)
+		t.Cleanup(func() {
+			cleanupCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, _ = e.Client.TerminateInstances(cleanupCtx, &ec2.TerminateInstancesInput{
+				InstanceIds: []string{instanceID},
+			})
+		})
+
+		notBefore := time.Now().Add(14 * 24 * time.Hour).UTC().Truncate(time.Second)
+		body := fmt.Sprintf(
+			`{"instanceId": %q, "code": "instance-retirement", "notBefore": %q}`,
+			instanceID, notBefore.Format(time.RFC3339),
+		)
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.Endpoint+"/_dc2/instance-event", strings.NewReader(body))
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		statusOutput, err := e.Client.DescribeInstanceStatus(ctx, &ec2.DescribeInstanceStatusInput{
+			InstanceIds:         []string{instanceID},
+			IncludeAllInstances: aws.Bool(true),
+		})
+		require.NoError(t, err)
+		require.Len(t, statusOutput.InstanceStatuses, 1)
+		events := statusOutput.InstanceStatuses[0].Events
+		require.Len(t, events, 1)
+		assert.Equal(t, types.EventCodeInstanceRetirement, events[0].Code)
+		assert.NotEmpty(t, aws.ToString(events[0].InstanceEventId))
+		assert.NotEmpty(t, aws.ToString(events[0].Description))
+		require.NotNil(t, events[0].NotBefore)
+		assert.True(t, notBefore.Equal(*events[0].NotBefore))
+		assert.Nil(t, events[0].NotAfter)
+	})
+}
+
 func TestInstanceLifecycleTransitionReasons(t *testing.T) {
 	t.Parallel()
 	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
@@ -1513,6 +2323,8 @@ func TestRunInstance(t *testing.T) {
 			expectedArch = "x86_64"
 		}
 		assert.Equal(t, types.ArchitectureValues(expectedArch), instance.Architecture)
+		assert.Empty(t, instance.Platform)
+		assert.Equal(t, "Linux/UNIX", aws.ToString(instance.PlatformDetails))
 		require.NotNil(t, instance.Placement)
 		require.NotNil(t, instance.Placement.AvailabilityZone)
 		assert.Equal(t, e.Region+"a", *instance.Placement.AvailabilityZone)
@@ -1693,6 +2505,123 @@ func TestDescribeInstancesAdditionalFilters(t *testing.T) {
 	})
 }
 
+func TestDescribeInstancesSecurityGroupFilters(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		groupName := fmt.Sprintf("sg-filter-%d", time.Now().UnixNano())
+		createGroupOut, err := e.Client.CreateSecurityGroup(ctx, &ec2.CreateSecurityGroupInput{
+			GroupName:   aws.String(groupName),
+			Description: aws.String("dc2 instance filter security group"),
+		})
+		require.NoError(t, err)
+		groupID := aws.ToString(createGroupOut.GroupId)
+		require.NotEmpty(t, groupID)
+
+		runInGroup, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:          aws.String("nginx"),
+			InstanceType:     "my-type",
+			MinCount:         aws.Int32(1),
+			MaxCount:         aws.Int32(1),
+			SecurityGroupIds: []string{groupID},
+			TagSpecifications: []types.TagSpecification{
+				{
+					ResourceType: types.ResourceTypeInstance,
+					Tags: []types.Tag{
+						{Key: aws.String("sg-filter"), Value: aws.String(groupName)},
+					},
+				},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, runInGroup.Instances, 1)
+		inGroupID := aws.ToString(runInGroup.Instances[0].InstanceId)
+
+		runDefault, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:      aws.String("nginx"),
+			InstanceType: "my-type",
+			MinCount:     aws.Int32(1),
+			MaxCount:     aws.Int32(1),
+		})
+		require.NoError(t, err)
+		require.Len(t, runDefault.Instances, 1)
+		defaultID := aws.ToString(runDefault.Instances[0].InstanceId)
+
+		t.Cleanup(func() {
+			cleanupCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, err := e.Client.TerminateInstances(cleanupCtx, &ec2.TerminateInstancesInput{
+				InstanceIds: []string{inGroupID, defaultID},
+			})
+			if err != nil && !isInstanceNotFound(err) {
+				require.NoError(t, err)
+			}
+		})
+
+		describeIDs := func(filters []types.Filter) []string {
+			t.Helper()
+			out, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+				InstanceIds: []string{inGroupID, defaultID},
+				Filters:     filters,
+			})
+			require.NoError(t, err)
+			var ids []string
+			for _, reservation := range out.Reservations {
+				for _, instance := range reservation.Instances {
+					ids = append(ids, aws.ToString(instance.InstanceId))
+				}
+			}
+			return ids
+		}
+
+		assert.Equal(t, []string{inGroupID}, describeIDs([]types.Filter{
+			{Name: aws.String("group-id"), Values: []string{groupID}},
+		}))
+		assert.Equal(t, []string{inGroupID}, describeIDs([]types.Filter{
+			{Name: aws.String("group-name"), Values: []string{groupName}},
+		}))
+		assert.Equal(t, []string{defaultID}, describeIDs([]types.Filter{
+			{Name: aws.String("instance.group-name"), Values: []string{"default"}},
+		}))
+		assert.Equal(t, []string{inGroupID}, describeIDs([]types.Filter{
+			{Name: aws.String("group-id"), Values: []string{groupID}},
+			{Name: aws.String("instance-state-name"), Values: []string{"running"}},
+			{Name: aws.String("tag:sg-filter"), Values: []string{groupName}},
+		}))
+		assert.Empty(t, describeIDs([]types.Filter{
+			{Name: aws.String("group-id"), Values: []string{groupID}},
+			{Name: aws.String("instance-state-name"), Values: []string{"stopped"}},
+		}))
+
+		groupsOut, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+			InstanceIds: []string{inGroupID, defaultID},
+		})
+		require.NoError(t, err)
+		expectedGroups := map[string][]types.GroupIdentifier{
+			inGroupID: {{GroupId: aws.String(groupID), GroupName: aws.String(groupName)}},
+			defaultID: {{GroupId: aws.String("sg-00000000000000000"), GroupName: aws.String("default")}},
+		}
+		for _, reservation := range groupsOut.Reservations {
+			for _, instance := range reservation.Instances {
+				instanceID := aws.ToString(instance.InstanceId)
+				assert.Equal(t, expectedGroups[instanceID], instance.SecurityGroups, instanceID)
+				require.Len(t, instance.NetworkInterfaces, 1)
+				assert.Equal(t, instance.SecurityGroups, instance.NetworkInterfaces[0].Groups, instanceID)
+			}
+		}
+
+		_, err = e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:          aws.String("nginx"),
+			InstanceType:     "my-type",
+			MinCount:         aws.Int32(1),
+			MaxCount:         aws.Int32(1),
+			SecurityGroupIds: []string{"sg-does-not-exist"},
+		})
+		var apiErr smithy.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "InvalidGroup.NotFound", apiErr.ErrorCode())
+	})
+}
+
 func TestInstanceTags(t *testing.T) {
 	t.Parallel()
 
@@ -1894,3 +2823,822 @@ func TestInstanceTags(t *testing.T) {
 		require.Len(t, describeInstancesByTagOutput7.Reservations, 0)
 	})
 }
+
+func TestRunInstancesAppliesInstanceTypeResourceLimits(t *testing.T) {
+	t.Parallel()
+
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		runOut, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:      aws.String("nginx"),
+			InstanceType: types.InstanceTypeT2Nano,
+			MinCount:     aws.Int32(1),
+			MaxCount:     aws.Int32(1),
+		})
+		require.NoError(t, err)
+		require.Len(t, runOut.Instances, 1)
+		instanceID := aws.ToString(runOut.Instances[0].InstanceId)
+		t.Cleanup(func() {
+			cleanupCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, err := e.Client.TerminateInstances(cleanupCtx, &ec2.TerminateInstancesInput{
+				InstanceIds: []string{instanceID},
+			})
+			if err != nil && !isInstanceNotFound(err) {
+				t.Logf("cleanup terminate instance %s returned error: %v", instanceID, err)
+			}
+		})
+
+		// t2.nano has 1 vCPU and 512 MiB of memory.
+		containerID := containerIDForInstanceID(t, ctx, e.DockerHost, instanceID)
+		inspectOut, inspectErr := dockerCommandContext(
+			ctx,
+			e.DockerHost,
+			"inspect",
+			"--format",
+			"{{.HostConfig.Memory}}|{{.HostConfig.NanoCpus}}",
+			containerID,
+		).CombinedOutput()
+		require.NoError(t, inspectErr, "docker inspect output: %s", string(inspectOut))
+		parts := strings.SplitN(strings.TrimSpace(string(inspectOut)), "|", 2)
+		require.Len(t, parts, 2)
+		assert.Equal(t, strconv.Itoa(512*1024*1024), parts[0])
+		assert.Equal(t, strconv.Itoa(1e9), parts[1])
+	})
+}
+
+func TestRunInstancesCreditSpecification(t *testing.T) {
+	t.Parallel()
+
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		runInstances := func(instanceType types.InstanceType, creditSpecification *types.CreditSpecificationRequest) (*ec2.RunInstancesOutput, error) {
+			out, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+				ImageId:             aws.String("nginx"),
+				InstanceType:        instanceType,
+				MinCount:            aws.Int32(1),
+				MaxCount:            aws.Int32(1),
+				CreditSpecification: creditSpecification,
+			})
+			if err == nil {
+				instanceID := aws.ToString(out.Instances[0].InstanceId)
+				t.Cleanup(func() {
+					cleanupCtx, cancel := cleanupAPICtx(t)
+					defer cancel()
+					_, err := e.Client.TerminateInstances(cleanupCtx, &ec2.TerminateInstancesInput{
+						InstanceIds: []string{instanceID},
+					})
+					if err != nil && !isInstanceNotFound(err) {
+						t.Logf("cleanup terminate instance %s returned error: %v", instanceID, err)
+					}
+				})
+			}
+			return out, err
+		}
+
+		unlimitedOut, err := runInstances(types.InstanceTypeT2Micro, &types.CreditSpecificationRequest{
+			CpuCredits: aws.String("unlimited"),
+		})
+		require.NoError(t, err)
+		unlimitedID := aws.ToString(unlimitedOut.Instances[0].InstanceId)
+
+		defaultOut, err := runInstances(types.InstanceTypeT2Micro, nil)
+		require.NoError(t, err)
+		defaultID := aws.ToString(defaultOut.Instances[0].InstanceId)
+
+		_, err = runInstances(types.InstanceTypeM5Large, &types.CreditSpecificationRequest{
+			CpuCredits: aws.String("unlimited"),
+		})
+		var apiErr smithy.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "InvalidParameterCombination", apiErr.ErrorCode())
+
+		out, err := e.Client.DescribeInstanceCreditSpecifications(ctx, &ec2.DescribeInstanceCreditSpecificationsInput{
+			InstanceIds: []string{unlimitedID, defaultID},
+		})
+		require.NoError(t, err)
+		cpuCredits := make(map[string]string, len(out.InstanceCreditSpecifications))
+		for _, spec := range out.InstanceCreditSpecifications {
+			cpuCredits[aws.ToString(spec.InstanceId)] = aws.ToString(spec.CpuCredits)
+		}
+		assert.Equal(t, map[string]string{
+			unlimitedID: "unlimited",
+			defaultID:   "standard",
+		}, cpuCredits)
+	})
+}
+
+func TestModifyInstanceCreditSpecification(t *testing.T) {
+	t.Parallel()
+
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		runOut, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:      aws.String("nginx"),
+			InstanceType: types.InstanceTypeT2Micro,
+			MinCount:     aws.Int32(1),
+			MaxCount:     aws.Int32(1),
+		})
+		require.NoError(t, err)
+		instanceID := aws.ToString(runOut.Instances[0].InstanceId)
+		t.Cleanup(func() {
+			cleanupCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, err := e.Client.TerminateInstances(cleanupCtx, &ec2.TerminateInstancesInput{
+				InstanceIds: []string{instanceID},
+			})
+			if err != nil && !isInstanceNotFound(err) {
+				t.Logf("cleanup terminate instance %s returned error: %v", instanceID, err)
+			}
+		})
+
+		const missingID = "i-0123456789abcdef0"
+		modifyOut, err := e.Client.ModifyInstanceCreditSpecification(ctx, &ec2.ModifyInstanceCreditSpecificationInput{
+			InstanceCreditSpecifications: []types.InstanceCreditSpecificationRequest{
+				{InstanceId: aws.String(instanceID), CpuCredits: aws.String("unlimited")},
+				{InstanceId: aws.String(missingID), CpuCredits: aws.String("unlimited")},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, modifyOut.SuccessfulInstanceCreditSpecifications, 1)
+		assert.Equal(t, instanceID, aws.ToString(modifyOut.SuccessfulInstanceCreditSpecifications[0].InstanceId))
+		require.Len(t, modifyOut.UnsuccessfulInstanceCreditSpecifications, 1)
+		unsuccessful := modifyOut.UnsuccessfulInstanceCreditSpecifications[0]
+		assert.Equal(t, missingID, aws.ToString(unsuccessful.InstanceId))
+		require.NotNil(t, unsuccessful.Error)
+		assert.Equal(t, types.UnsuccessfulInstanceCreditSpecificationErrorCodeInstanceNotFound, unsuccessful.Error.Code)
+
+		describeOut, err := e.Client.DescribeInstanceCreditSpecifications(ctx, &ec2.DescribeInstanceCreditSpecificationsInput{
+			InstanceIds: []string{instanceID},
+		})
+		require.NoError(t, err)
+		require.Len(t, describeOut.InstanceCreditSpecifications, 1)
+		assert.Equal(t, "unlimited", aws.ToString(describeOut.InstanceCreditSpecifications[0].CpuCredits))
+	})
+}
+
+func TestModifyInstanceCreditSpecificationToggle(t *testing.T) {
+	t.Parallel()
+
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		runOut, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:      aws.String("nginx"),
+			InstanceType: types.InstanceTypeT3Micro,
+			MinCount:     aws.Int32(1),
+			MaxCount:     aws.Int32(1),
+		})
+		require.NoError(t, err)
+		instanceID := aws.ToString(runOut.Instances[0].InstanceId)
+		t.Cleanup(func() {
+			cleanupCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, err := e.Client.TerminateInstances(cleanupCtx, &ec2.TerminateInstancesInput{
+				InstanceIds: []string{instanceID},
+			})
+			if err != nil && !isInstanceNotFound(err) {
+				t.Logf("cleanup terminate instance %s returned error: %v", instanceID, err)
+			}
+		})
+
+		describeCPUCredits := func() string {
+			describeOut, err := e.Client.DescribeInstanceCreditSpecifications(ctx, &ec2.DescribeInstanceCreditSpecificationsInput{
+				InstanceIds: []string{instanceID},
+			})
+			require.NoError(t, err)
+			require.Len(t, describeOut.InstanceCreditSpecifications, 1)
+			return aws.ToString(describeOut.InstanceCreditSpecifications[0].CpuCredits)
+		}
+		// T3 instances launch in unlimited mode by default
+		assert.Equal(t, "unlimited", describeCPUCredits())
+
+		for _, cpuCredits := range []string{"standard", "unlimited", "standard"} {
+			modifyOut, err := e.Client.ModifyInstanceCreditSpecification(ctx, &ec2.ModifyInstanceCreditSpecificationInput{
+				InstanceCreditSpecifications: []types.InstanceCreditSpecificationRequest{
+					{InstanceId: aws.String(instanceID), CpuCredits: aws.String(cpuCredits)},
+				},
+			})
+			require.NoError(t, err)
+			require.Len(t, modifyOut.SuccessfulInstanceCreditSpecifications, 1)
+			assert.Empty(t, modifyOut.UnsuccessfulInstanceCreditSpecifications)
+			assert.Equal(t, cpuCredits, describeCPUCredits())
+		}
+	})
+}
+
+func TestDescribeTagsFiltersByResourceType(t *testing.T) {
+	t.Parallel()
+
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		tagKey := "describe-tags-" + strings.ReplaceAll(t.Name(), "/", "-")
+		tagSpecification := func(resourceType types.ResourceType) []types.TagSpecification {
+			return []types.TagSpecification{
+				{
+					ResourceType: resourceType,
+					Tags: []types.Tag{
+						{
+							Key:   aws.String(tagKey),
+							Value: aws.String("value"),
+						},
+					},
+				},
+			}
+		}
+
+		runInstancesOutput, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:           aws.String("nginx"),
+			InstanceType:      types.InstanceTypeT2Micro,
+			MinCount:          aws.Int32(2),
+			MaxCount:          aws.Int32(2),
+			TagSpecifications: tagSpecification(types.ResourceTypeInstance),
+		})
+		require.NoError(t, err)
+		require.Len(t, runInstancesOutput.Instances, 2)
+		instanceIDs := make([]string, 0, len(runInstancesOutput.Instances))
+		for _, instance := range runInstancesOutput.Instances {
+			instanceIDs = append(instanceIDs, aws.ToString(instance.InstanceId))
+		}
+		t.Cleanup(func() {
+			cleanupCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, err := e.Client.TerminateInstances(cleanupCtx, &ec2.TerminateInstancesInput{
+				InstanceIds: instanceIDs,
+			})
+			if err != nil && !isInstanceNotFound(err) {
+				t.Logf("cleanup terminate instances %v returned error: %v", instanceIDs, err)
+			}
+		})
+
+		volume, err := e.Client.CreateVolume(ctx, &ec2.CreateVolumeInput{
+			AvailabilityZone:  aws.String("us-west-2a"),
+			Size:              aws.Int32(1),
+			TagSpecifications: tagSpecification(types.ResourceTypeVolume),
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			cleanupCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			if _, err := e.Client.DeleteVolume(cleanupCtx, &ec2.DeleteVolumeInput{VolumeId: volume.VolumeId}); err != nil {
+				t.Logf("cleanup delete volume %s returned error: %v", aws.ToString(volume.VolumeId), err)
+			}
+		})
+
+		describeTags := func(filters ...types.Filter) []types.TagDescription {
+			out, err := e.Client.DescribeTags(ctx, &ec2.DescribeTagsInput{
+				Filters: append(filters, types.Filter{
+					Name:   aws.String("key"),
+					Values: []string{tagKey},
+				}),
+			})
+			require.NoError(t, err)
+			return out.Tags
+		}
+
+		assert.Len(t, describeTags(), 3)
+
+		instanceTags := describeTags(types.Filter{
+			Name:   aws.String("resource-type"),
+			Values: []string{"instance"},
+		})
+		resourceIDs := make([]string, 0, len(instanceTags))
+		for _, tag := range instanceTags {
+			assert.Equal(t, types.ResourceTypeInstance, tag.ResourceType)
+			assert.Equal(t, tagKey, aws.ToString(tag.Key))
+			assert.Equal(t, "value", aws.ToString(tag.Value))
+			resourceIDs = append(resourceIDs, aws.ToString(tag.ResourceId))
+		}
+		assert.ElementsMatch(t, instanceIDs, resourceIDs)
+
+		volumeTags := describeTags(types.Filter{
+			Name:   aws.String("resource-id"),
+			Values: []string{aws.ToString(volume.VolumeId)},
+		})
+		require.Len(t, volumeTags, 1)
+		assert.Equal(t, types.ResourceTypeVolume, volumeTags[0].ResourceType)
+
+		// Paginate through the instance and volume tags one at a time.
+		var pages int
+		var pagedIDs []string
+		paginator := ec2.NewDescribeTagsPaginator(e.Client, &ec2.DescribeTagsInput{
+			Filters: []types.Filter{
+				{
+					Name:   aws.String("key"),
+					Values: []string{tagKey},
+				},
+			},
+			MaxResults: aws.Int32(1),
+		})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			require.NoError(t, err)
+			pages++
+			for _, tag := range page.Tags {
+				pagedIDs = append(pagedIDs, aws.ToString(tag.ResourceId))
+			}
+		}
+		assert.Equal(t, 3, pages)
+		assert.ElementsMatch(t, append(instanceIDs, aws.ToString(volume.VolumeId)), pagedIDs)
+	})
+}
+
+func TestDescribeInstancesPagination(t *testing.T) {
+	t.Parallel()
+
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		tagKey := "pagination-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+		runInstances := func(count int32) []string {
+			t.Helper()
+			out, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+				ImageId:      aws.String("nginx"),
+				InstanceType: "my-type",
+				MinCount:     aws.Int32(count),
+				MaxCount:     aws.Int32(count),
+				TagSpecifications: []types.TagSpecification{
+					{
+						ResourceType: types.ResourceTypeInstance,
+						Tags:         []types.Tag{{Key: aws.String(tagKey), Value: aws.String("true")}},
+					},
+				},
+			})
+			require.NoError(t, err)
+			require.Len(t, out.Instances, int(count))
+			instanceIDs := make([]string, 0, len(out.Instances))
+			for _, instance := range out.Instances {
+				instanceIDs = append(instanceIDs, aws.ToString(instance.InstanceId))
+			}
+			t.Cleanup(func() {
+				apiCtx, cancel := cleanupAPICtx(t)
+				defer cancel()
+				_, err := e.Client.TerminateInstances(apiCtx, &ec2.TerminateInstancesInput{
+					InstanceIds: instanceIDs,
+				})
+				require.NoError(t, err)
+			})
+			return instanceIDs
+		}
+		instanceIDs := runInstances(5)
+
+		describePage := func(nextToken *string) ([]string, *string) {
+			t.Helper()
+			out, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+				Filters: []types.Filter{
+					{
+						Name:   aws.String("tag-key"),
+						Values: []string{tagKey},
+					},
+				},
+				MaxResults: aws.Int32(2),
+				NextToken:  nextToken,
+			})
+			require.NoError(t, err)
+			var pageIDs []string
+			for _, reservation := range out.Reservations {
+				for _, instance := range reservation.Instances {
+					pageIDs = append(pageIDs, aws.ToString(instance.InstanceId))
+				}
+			}
+			assert.LessOrEqual(t, len(pageIDs), 2)
+			return pageIDs, out.NextToken
+		}
+
+		var pages int
+		var pagedIDs []string
+		var nextToken *string
+		for {
+			pageIDs, token := describePage(nextToken)
+			pages++
+			pagedIDs = append(pagedIDs, pageIDs...)
+			if token == nil {
+				break
+			}
+			nextToken = token
+		}
+		assert.Equal(t, 3, pages)
+		assert.ElementsMatch(t, instanceIDs, pagedIDs)
+
+		// Launching instances while paginating must not repeat or skip any of
+		// the instances that existed when pagination started.
+		pagedIDs, nextToken = describePage(nil)
+		require.NotNil(t, nextToken)
+		launchedIDs := runInstances(2)
+		for nextToken != nil {
+			var pageIDs []string
+			pageIDs, nextToken = describePage(nextToken)
+			pagedIDs = append(pagedIDs, pageIDs...)
+		}
+		seen := make(map[string]int, len(pagedIDs))
+		for _, instanceID := range pagedIDs {
+			seen[instanceID]++
+			assert.Equal(t, 1, seen[instanceID], "instance %s returned more than once", instanceID)
+			assert.Contains(t, append(slices.Clone(instanceIDs), launchedIDs...), instanceID)
+		}
+		for _, instanceID := range instanceIDs {
+			assert.Contains(t, seen, instanceID)
+		}
+	})
+}
+
+func TestModifyInstanceAttributeInstanceType(t *testing.T) {
+	t.Parallel()
+
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		runInstancesOutput, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:      aws.String("nginx"),
+			InstanceType: "t3.micro",
+			MinCount:     aws.Int32(1),
+			MaxCount:     aws.Int32(1),
+		})
+		require.NoError(t, err)
+		instanceID := *runInstancesOutput.Instances[0].InstanceId
+		t.Cleanup(func() {
+			apiCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, err := e.Client.TerminateInstances(apiCtx, &ec2.TerminateInstancesInput{
+				InstanceIds: []string{instanceID},
+			})
+			require.NoError(t, err)
+		})
+
+		describeInstance := func() types.Instance {
+			t.Helper()
+			describeOutput, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+				InstanceIds: []string{instanceID},
+			})
+			require.NoError(t, err)
+			require.Len(t, describeOutput.Reservations, 1)
+			require.Len(t, describeOutput.Reservations[0].Instances, 1)
+			return describeOutput.Reservations[0].Instances[0]
+		}
+
+		_, err = e.Client.ModifyInstanceAttribute(ctx, &ec2.ModifyInstanceAttributeInput{
+			InstanceId:   aws.String(instanceID),
+			InstanceType: &types.AttributeValue{Value: aws.String("t3.large")},
+		})
+		var apiErr smithy.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "IncorrectInstanceState", apiErr.ErrorCode())
+		assert.Equal(t, types.InstanceTypeT3Micro, describeInstance().InstanceType)
+
+		_, err = e.Client.StopInstances(ctx, &ec2.StopInstancesInput{
+			InstanceIds: []string{instanceID},
+		})
+		require.NoError(t, err)
+		privateDNSName := aws.ToString(describeInstance().PrivateDnsName)
+
+		_, err = e.Client.ModifyInstanceAttribute(ctx, &ec2.ModifyInstanceAttributeInput{
+			InstanceId:   aws.String(instanceID),
+			InstanceType: &types.AttributeValue{Value: aws.String("t3.large")},
+		})
+		require.NoError(t, err)
+		_, err = e.Client.ModifyInstanceAttribute(ctx, &ec2.ModifyInstanceAttributeInput{
+			InstanceId: aws.String(instanceID),
+			UserData:   &types.BlobAttributeValue{Value: []byte("#!/bin/sh\necho resized\n")},
+		})
+		require.NoError(t, err)
+
+		stopped := describeInstance()
+		assert.Equal(t, types.InstanceStateNameStopped, stopped.State.Name)
+		assert.Equal(t, types.InstanceTypeT3Large, stopped.InstanceType)
+		assert.Equal(t, privateDNSName, aws.ToString(stopped.PrivateDnsName))
+
+		_, err = e.Client.StartInstances(ctx, &ec2.StartInstancesInput{
+			InstanceIds: []string{instanceID},
+		})
+		require.NoError(t, err)
+
+		started := describeInstance()
+		assert.Equal(t, types.InstanceStateNameRunning, started.State.Name)
+		assert.Equal(t, types.InstanceTypeT3Large, started.InstanceType)
+
+		containerID := containerIDForInstanceID(t, ctx, e.DockerHost, instanceID)
+		out, err := dockerCommandContext(ctx, e.DockerHost, "inspect", "--format", `{{index .Config.Labels "dc2:user-data"}}`, containerID).CombinedOutput()
+		require.NoError(t, err, "docker inspect output: %s", string(out))
+		assert.Equal(t, "#!/bin/sh\necho resized", strings.TrimSpace(string(out)))
+	})
+}
+
+func TestDescribeInstanceAttributeBlockDeviceMappingAndGroupSet(t *testing.T) {
+	t.Parallel()
+
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		groupName := fmt.Sprintf("sg-attribute-%d", time.Now().UnixNano())
+		createGroupOut, err := e.Client.CreateSecurityGroup(ctx, &ec2.CreateSecurityGroupInput{
+			GroupName:   aws.String(groupName),
+			Description: aws.String("dc2 instance attribute security group"),
+		})
+		require.NoError(t, err)
+		groupID := aws.ToString(createGroupOut.GroupId)
+
+		runInstancesOutput, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:          aws.String("nginx"),
+			InstanceType:     "my-type",
+			MinCount:         aws.Int32(1),
+			MaxCount:         aws.Int32(1),
+			SecurityGroupIds: []string{groupID},
+			UserData:         aws.String(base64.StdEncoding.EncodeToString([]byte("#!/bin/sh\necho hello\n"))),
+			BlockDeviceMappings: []types.BlockDeviceMapping{
+				{
+					DeviceName: aws.String("/dev/sdf"),
+					Ebs: &types.EbsBlockDevice{
+						VolumeSize:          aws.Int32(1),
+						DeleteOnTermination: aws.Bool(true),
+					},
+				},
+			},
+		})
+		require.NoError(t, err)
+		instanceID := aws.ToString(runInstancesOutput.Instances[0].InstanceId)
+		t.Cleanup(func() {
+			apiCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, err := e.Client.TerminateInstances(apiCtx, &ec2.TerminateInstancesInput{
+				InstanceIds: []string{instanceID},
+			})
+			require.NoError(t, err)
+		})
+
+		blockDevices, err := e.Client.DescribeInstanceAttribute(ctx, &ec2.DescribeInstanceAttributeInput{
+			InstanceId: aws.String(instanceID),
+			Attribute:  types.InstanceAttributeNameBlockDeviceMapping,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, instanceID, aws.ToString(blockDevices.InstanceId))
+		require.Len(t, blockDevices.BlockDeviceMappings, 1)
+		assert.Equal(t, "/dev/sdf", aws.ToString(blockDevices.BlockDeviceMappings[0].DeviceName))
+		require.NotNil(t, blockDevices.BlockDeviceMappings[0].Ebs)
+		assert.True(t, strings.HasPrefix(aws.ToString(blockDevices.BlockDeviceMappings[0].Ebs.VolumeId), "vol-"))
+		assert.Equal(t, types.AttachmentStatusAttached, blockDevices.BlockDeviceMappings[0].Ebs.Status)
+		assert.True(t, aws.ToBool(blockDevices.BlockDeviceMappings[0].Ebs.DeleteOnTermination))
+		assert.Empty(t, blockDevices.Groups)
+
+		groups, err := e.Client.DescribeInstanceAttribute(ctx, &ec2.DescribeInstanceAttributeInput{
+			InstanceId: aws.String(instanceID),
+			Attribute:  types.InstanceAttributeNameGroupSet,
+		})
+		require.NoError(t, err)
+		require.Len(t, groups.Groups, 1)
+		assert.Equal(t, groupID, aws.ToString(groups.Groups[0].GroupId))
+		assert.Equal(t, groupName, aws.ToString(groups.Groups[0].GroupName))
+		assert.Empty(t, groups.BlockDeviceMappings)
+
+		instanceType, err := e.Client.DescribeInstanceAttribute(ctx, &ec2.DescribeInstanceAttributeInput{
+			InstanceId: aws.String(instanceID),
+			Attribute:  types.InstanceAttributeNameInstanceType,
+		})
+		require.NoError(t, err)
+		require.NotNil(t, instanceType.InstanceType)
+		assert.Equal(t, "my-type", aws.ToString(instanceType.InstanceType.Value))
+
+		userData, err := e.Client.DescribeInstanceAttribute(ctx, &ec2.DescribeInstanceAttributeInput{
+			InstanceId: aws.String(instanceID),
+			Attribute:  types.InstanceAttributeNameUserData,
+		})
+		require.NoError(t, err)
+		require.NotNil(t, userData.UserData)
+		decoded, err := base64.StdEncoding.DecodeString(aws.ToString(userData.UserData.Value))
+		require.NoError(t, err)
+		assert.Equal(t, "#!/bin/sh\necho hello\n", string(decoded))
+	})
+}
+
+func TestRunInstancesClientTokenIdempotency(t *testing.T) {
+	t.Parallel()
+
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		clientToken := "run-instances-" + strings.ReplaceAll(t.Name(), "/", "-")
+		runInstances := func() *ec2.RunInstancesOutput {
+			out, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+				ImageId:      aws.String("nginx"),
+				InstanceType: types.InstanceTypeT2Micro,
+				MinCount:     aws.Int32(2),
+				MaxCount:     aws.Int32(2),
+				ClientToken:  aws.String(clientToken),
+			})
+			require.NoError(t, err)
+			return out
+		}
+		instanceIDsOf := func(instances []types.Instance) []string {
+			ids := make([]string, 0, len(instances))
+			for _, instance := range instances {
+				ids = append(ids, aws.ToString(instance.InstanceId))
+			}
+			slices.Sort(ids)
+			return ids
+		}
+
+		first := runInstances()
+		require.Len(t, first.Instances, 2)
+		require.NotEmpty(t, aws.ToString(first.ReservationId))
+		instanceIDs := instanceIDsOf(first.Instances)
+		t.Cleanup(func() {
+			cleanupCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, err := e.Client.TerminateInstances(cleanupCtx, &ec2.TerminateInstancesInput{
+				InstanceIds: instanceIDs,
+			})
+			if err != nil && !isInstanceNotFound(err) {
+				t.Logf("cleanup terminate instances %v returned error: %v", instanceIDs, err)
+			}
+		})
+
+		// Retrying with the same token returns the original reservation
+		// instead of launching new instances.
+		retry := runInstances()
+		assert.Equal(t, aws.ToString(first.ReservationId), aws.ToString(retry.ReservationId))
+		assert.Equal(t, instanceIDs, instanceIDsOf(retry.Instances))
+
+		describeOut, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+			Filters: []types.Filter{
+				{
+					Name:   aws.String("reservation-id"),
+					Values: []string{aws.ToString(first.ReservationId)},
+				},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, describeOut.Reservations, 1)
+		reservation := describeOut.Reservations[0]
+		assert.Equal(t, aws.ToString(first.ReservationId), aws.ToString(reservation.ReservationId))
+		assert.Equal(t, instanceIDs, instanceIDsOf(reservation.Instances))
+		for _, instance := range reservation.Instances {
+			assert.Equal(t, clientToken, aws.ToString(instance.ClientToken))
+		}
+
+		byTokenOut, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+			Filters: []types.Filter{
+				{
+					Name:   aws.String("client-token"),
+					Values: []string{clientToken},
+				},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, byTokenOut.Reservations, 1)
+		assert.Equal(t, instanceIDs, instanceIDsOf(byTokenOut.Reservations[0].Instances))
+	})
+}
+
+func TestGetConsoleOutput(t *testing.T) {
+	t.Parallel()
+
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		runOut, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:      aws.String("nginx"),
+			InstanceType: types.InstanceTypeT2Micro,
+			MinCount:     aws.Int32(1),
+			MaxCount:     aws.Int32(1),
+		})
+		require.NoError(t, err)
+		instanceID := aws.ToString(runOut.Instances[0].InstanceId)
+		t.Cleanup(func() {
+			cleanupCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, err := e.Client.TerminateInstances(cleanupCtx, &ec2.TerminateInstancesInput{
+				InstanceIds: []string{instanceID},
+			})
+			if err != nil && !isInstanceNotFound(err) {
+				t.Logf("cleanup terminate instance %s returned error: %v", instanceID, err)
+			}
+		})
+
+		// Write to the stdout of the instance's main process, which is
+		// what ends up in the console output.
+		const marker = "dc2 console output marker"
+		containerID := containerIDForInstanceID(t, ctx, e.DockerHost, instanceID)
+		out, err := dockerCommandContext(ctx, e.DockerHost, "exec", containerID, "sh", "-c", "echo '"+marker+"' > /proc/1/fd/1").CombinedOutput()
+		require.NoError(t, err, "docker exec output: %s", string(out))
+
+		var consoleOutput string
+		require.Eventually(t, func() bool {
+			out, err := e.Client.GetConsoleOutput(ctx, &ec2.GetConsoleOutputInput{
+				InstanceId: aws.String(instanceID),
+			})
+			if err != nil || out.Output == nil {
+				return false
+			}
+			decoded, err := base64.StdEncoding.DecodeString(aws.ToString(out.Output))
+			if err != nil {
+				return false
+			}
+			consoleOutput = string(decoded)
+			return strings.Contains(consoleOutput, marker) && out.Timestamp != nil
+		}, 10*time.Second, 250*time.Millisecond, "console output: %s", consoleOutput)
+		assert.Equal(t, 1, strings.Count(consoleOutput, marker))
+
+		_, err = e.Client.GetConsoleOutput(ctx, &ec2.GetConsoleOutputInput{
+			InstanceId: aws.String("i-0123456789abcdef0"),
+		})
+		require.True(t, isInstanceNotFound(err), "unexpected error: %v", err)
+	})
+}
+
+func TestRunInstanceDefaultArchitecture(t *testing.T) {
+	t.Parallel()
+
+	const architecture = "arm64"
+	mode := configuredTestMode()
+	var (
+		serverOpts []dc2.Option
+		serverEnv  map[string]string
+	)
+	if mode == testModeContainer {
+		serverEnv = map[string]string{"DC2_DEFAULT_ARCHITECTURE": architecture}
+	} else {
+		serverOpts = []dc2.Option{dc2.WithDefaultArchitecture(architecture)}
+	}
+	testWithServerWithOptionsAndEnvForMode(t, mode, serverOpts, serverEnv, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		// nginx is a multi-arch image, so the arm64 variant is pulled even
+		// on amd64 hosts. Without emulation the container might not stay up,
+		// but the instance still reports the requested architecture.
+		runInstancesOutput, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:      aws.String("nginx"),
+			InstanceType: types.InstanceTypeT4gMicro,
+			MinCount:     aws.Int32(1),
+			MaxCount:     aws.Int32(1),
+		})
+		require.NoError(t, err)
+		require.Len(t, runInstancesOutput.Instances, 1)
+		instanceID := aws.ToString(runInstancesOutput.Instances[0].InstanceId)
+		t.Cleanup(func() {
+			apiCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, _ = e.Client.TerminateInstances(apiCtx, &ec2.TerminateInstancesInput{
+				InstanceIds: []string{instanceID},
+			})
+		})
+		assert.Equal(t, types.ArchitectureValuesArm64, runInstancesOutput.Instances[0].Architecture)
+
+		describeOutput, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+			InstanceIds: []string{instanceID},
+		})
+		require.NoError(t, err)
+		require.Len(t, describeOutput.Reservations, 1)
+		require.Len(t, describeOutput.Reservations[0].Instances, 1)
+		assert.Equal(t, types.ArchitectureValuesArm64, describeOutput.Reservations[0].Instances[0].Architecture)
+
+		containerID := containerIDForInstanceID(t, ctx, e.DockerHost, instanceID)
+		imageID, err := dockerCommandContext(ctx, e.DockerHost, "inspect", "--format", "{{.Image}}", containerID).Output()
+		require.NoError(t, err)
+		out, err := dockerCommandContext(ctx, e.DockerHost, "image", "inspect", "--format", "{{.Architecture}}", strings.TrimSpace(string(imageID))).CombinedOutput()
+		require.NoError(t, err, "docker image inspect output: %s", string(out))
+		assert.Equal(t, architecture, strings.TrimSpace(string(out)))
+	})
+}
+
+func TestRunInstancePublishPort(t *testing.T) {
+	t.Parallel()
+
+	const publishPort = 80
+	testWithServerWithOptionsAndEnvForMode(
+		t,
+		testModeHost,
+		[]dc2.Option{dc2.WithPublishPort(publishPort)},
+		nil,
+		func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+			runInstancesOutput, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+				ImageId:      aws.String("nginx"),
+				InstanceType: types.InstanceTypeT3Micro,
+				MinCount:     aws.Int32(2),
+				MaxCount:     aws.Int32(2),
+			})
+			require.NoError(t, err)
+			require.Len(t, runInstancesOutput.Instances, 2)
+			var instanceIDs []string
+			for _, instance := range runInstancesOutput.Instances {
+				instanceIDs = append(instanceIDs, aws.ToString(instance.InstanceId))
+			}
+			t.Cleanup(func() {
+				apiCtx, cancel := cleanupAPICtx(t)
+				defer cancel()
+				_, _ = e.Client.TerminateInstances(apiCtx, &ec2.TerminateInstancesInput{
+					InstanceIds: instanceIDs,
+				})
+			})
+
+			describeOutput, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+				InstanceIds: instanceIDs,
+			})
+			require.NoError(t, err)
+			require.Len(t, describeOutput.Reservations, 1)
+			require.Len(t, describeOutput.Reservations[0].Instances, 2)
+			// Every instance publishes the same port on its own address
+			publicIPs := make(map[string]bool)
+			for _, instance := range describeOutput.Reservations[0].Instances {
+				publicIP := aws.ToString(instance.PublicIpAddress)
+				require.NotEmpty(t, publicIP)
+				assert.NotEqual(t, aws.ToString(instance.PrivateIpAddress), publicIP)
+				assert.False(t, publicIPs[publicIP], publicIP)
+				publicIPs[publicIP] = true
+
+				endpoint := "http://" + net.JoinHostPort(publicIP, strconv.Itoa(publishPort)) + "/"
+				require.EventuallyWithT(t, func(c *assert.CollectT) {
+					req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+					require.NoError(c, err)
+					resp, err := http.DefaultClient.Do(req)
+					require.NoError(c, err)
+					defer resp.Body.Close()
+					body, err := io.ReadAll(resp.Body)
+					require.NoError(c, err)
+					assert.Equal(c, http.StatusOK, resp.StatusCode)
+					assert.Contains(c, string(body), "nginx")
+				}, 30*time.Second, 250*time.Millisecond)
+			}
+		},
+	)
+}
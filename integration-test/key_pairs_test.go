@@ -0,0 +1,198 @@
+package dc2_test
+
+import (
+	"context"
+	"crypto/md5" //nolint:gosec // AWS fingerprints imported RSA keys with MD5
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestKeyPairCreateDescribeDelete(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		keyName := fmt.Sprintf("kp-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+		createResp, err := e.Client.CreateKeyPair(ctx, &ec2.CreateKeyPairInput{
+			KeyName: aws.String(keyName),
+			TagSpecifications: []ec2types.TagSpecification{
+				{
+					ResourceType: ec2types.ResourceTypeKeyPair,
+					Tags:         []ec2types.Tag{{Key: aws.String("Role"), Value: aws.String("ci")}},
+				},
+			},
+		})
+		require.NoError(t, err)
+		keyPairID := aws.ToString(createResp.KeyPairId)
+		assert.True(t, strings.HasPrefix(keyPairID, "key-"))
+		assert.Equal(t, keyName, aws.ToString(createResp.KeyName))
+
+		block, _ := pem.Decode([]byte(aws.ToString(createResp.KeyMaterial)))
+		require.NotNil(t, block)
+		assert.Equal(t, "RSA PRIVATE KEY", block.Type)
+		_, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		require.NoError(t, err)
+		// SHA-1 digests are 20 bytes, printed as colon separated hex
+		assert.Len(t, strings.Split(aws.ToString(createResp.KeyFingerprint), ":"), 20)
+
+		_, err = e.Client.CreateKeyPair(ctx, &ec2.CreateKeyPairInput{KeyName: aws.String(keyName)})
+		var apiErr smithy.APIError
+		require.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, "InvalidKeyPair.Duplicate", apiErr.ErrorCode())
+
+		describeResp, err := e.Client.DescribeKeyPairs(ctx, &ec2.DescribeKeyPairsInput{
+			KeyNames:         []string{keyName},
+			IncludePublicKey: aws.Bool(true),
+		})
+		require.NoError(t, err)
+		require.Len(t, describeResp.KeyPairs, 1)
+		keyPair := describeResp.KeyPairs[0]
+		assert.Equal(t, keyPairID, aws.ToString(keyPair.KeyPairId))
+		assert.Equal(t, aws.ToString(createResp.KeyFingerprint), aws.ToString(keyPair.KeyFingerprint))
+		assert.Equal(t, ec2types.KeyTypeRsa, keyPair.KeyType)
+		assert.True(t, strings.HasPrefix(aws.ToString(keyPair.PublicKey), "ssh-rsa "))
+		assert.NotNil(t, keyPair.CreateTime)
+		require.Len(t, keyPair.Tags, 1)
+		assert.Equal(t, "Role", aws.ToString(keyPair.Tags[0].Key))
+
+		filterResp, err := e.Client.DescribeKeyPairs(ctx, &ec2.DescribeKeyPairsInput{
+			Filters: []ec2types.Filter{
+				{Name: aws.String("key-pair-id"), Values: []string{keyPairID}},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, filterResp.KeyPairs, 1)
+		assert.Equal(t, keyName, aws.ToString(filterResp.KeyPairs[0].KeyName))
+
+		_, err = e.Client.DeleteKeyPair(ctx, &ec2.DeleteKeyPairInput{KeyName: aws.String(keyName)})
+		require.NoError(t, err)
+
+		_, err = e.Client.DescribeKeyPairs(ctx, &ec2.DescribeKeyPairsInput{KeyNames: []string{keyName}})
+		require.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, "InvalidKeyPair.NotFound", apiErr.ErrorCode())
+
+		_, err = e.Client.DeleteKeyPair(ctx, &ec2.DeleteKeyPairInput{KeyPairId: aws.String(keyPairID)})
+		require.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, "InvalidKeyPair.NotFound", apiErr.ErrorCode())
+	})
+}
+
+func TestKeyPairImport(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		publicKey, err := ssh.NewPublicKey(&privateKey.PublicKey)
+		require.NoError(t, err)
+		der, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+		require.NoError(t, err)
+		sum := md5.Sum(der) //nolint:gosec // see import
+		var expectedFingerprint []string
+		for _, b := range sum {
+			expectedFingerprint = append(expectedFingerprint, fmt.Sprintf("%02x", b))
+		}
+
+		keyName := fmt.Sprintf("kp-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+		importResp, err := e.Client.ImportKeyPair(ctx, &ec2.ImportKeyPairInput{
+			KeyName:           aws.String(keyName),
+			PublicKeyMaterial: ssh.MarshalAuthorizedKey(publicKey),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, strings.Join(expectedFingerprint, ":"), aws.ToString(importResp.KeyFingerprint))
+		defer func() {
+			apiCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, err := e.Client.DeleteKeyPair(apiCtx, &ec2.DeleteKeyPairInput{KeyPairId: importResp.KeyPairId})
+			require.NoError(t, err)
+		}()
+
+		describeResp, err := e.Client.DescribeKeyPairs(ctx, &ec2.DescribeKeyPairsInput{
+			KeyPairIds:       []string{aws.ToString(importResp.KeyPairId)},
+			IncludePublicKey: aws.Bool(true),
+		})
+		require.NoError(t, err)
+		require.Len(t, describeResp.KeyPairs, 1)
+		described, _, _, _, err := ssh.ParseAuthorizedKey([]byte(aws.ToString(describeResp.KeyPairs[0].PublicKey)))
+		require.NoError(t, err)
+		assert.Equal(t, publicKey.Marshal(), described.Marshal())
+
+		_, err = e.Client.ImportKeyPair(ctx, &ec2.ImportKeyPairInput{
+			KeyName:           aws.String(keyName),
+			PublicKeyMaterial: ssh.MarshalAuthorizedKey(publicKey),
+		})
+		var apiErr smithy.APIError
+		require.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, "InvalidKeyPair.Duplicate", apiErr.ErrorCode())
+
+		_, err = e.Client.ImportKeyPair(ctx, &ec2.ImportKeyPairInput{
+			KeyName:           aws.String(keyName + "-invalid"),
+			PublicKeyMaterial: []byte("not a public key"),
+		})
+		require.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, "InvalidKey.Format", apiErr.ErrorCode())
+	})
+}
+
+func TestGetPasswordData(t *testing.T) {
+	t.Parallel()
+
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		keyName := fmt.Sprintf("password-key-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+		createResp, err := e.Client.CreateKeyPair(ctx, &ec2.CreateKeyPairInput{KeyName: aws.String(keyName)})
+		require.NoError(t, err)
+		block, _ := pem.Decode([]byte(aws.ToString(createResp.KeyMaterial)))
+		require.NotNil(t, block)
+		privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		require.NoError(t, err)
+
+		runOutput, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:      aws.String("nginx"),
+			InstanceType: ec2types.InstanceTypeT3Micro,
+			MinCount:     aws.Int32(1),
+			MaxCount:     aws.Int32(1),
+			KeyName:      aws.String(keyName),
+		})
+		require.NoError(t, err)
+		require.Len(t, runOutput.Instances, 1)
+		instanceID := aws.ToString(runOutput.Instances[0].InstanceId)
+		t.Cleanup(func() {
+			apiCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, _ = e.Client.TerminateInstances(apiCtx, &ec2.TerminateInstancesInput{InstanceIds: []string{instanceID}})
+			_, _ = e.Client.DeleteKeyPair(apiCtx, &ec2.DeleteKeyPairInput{KeyName: aws.String(keyName)})
+		})
+
+		passwordOutput, err := e.Client.GetPasswordData(ctx, &ec2.GetPasswordDataInput{InstanceId: aws.String(instanceID)})
+		require.NoError(t, err)
+		assert.Equal(t, instanceID, aws.ToString(passwordOutput.InstanceId))
+		require.NotNil(t, passwordOutput.Timestamp)
+		require.NotEmpty(t, aws.ToString(passwordOutput.PasswordData))
+		encrypted, err := base64.StdEncoding.DecodeString(aws.ToString(passwordOutput.PasswordData))
+		require.NoError(t, err)
+		password, err := rsa.DecryptPKCS1v15(nil, privateKey, encrypted)
+		require.NoError(t, err)
+		assert.NotEmpty(t, string(password))
+
+		// The password doesn't change between calls
+		passwordOutput, err = e.Client.GetPasswordData(ctx, &ec2.GetPasswordDataInput{InstanceId: aws.String(instanceID)})
+		require.NoError(t, err)
+		encrypted, err = base64.StdEncoding.DecodeString(aws.ToString(passwordOutput.PasswordData))
+		require.NoError(t, err)
+		again, err := rsa.DecryptPKCS1v15(nil, privateKey, encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, password, again)
+	})
+}
@@ -129,6 +129,34 @@ func TestLaunchTemplateDescribeDelete(t *testing.T) {
 	})
 }
 
+func TestLaunchTemplateClientTokenIdempotency(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		launchTemplateName := fmt.Sprintf("lt-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+		input := &ec2.CreateLaunchTemplateInput{
+			ClientToken:        aws.String("lt-client-token"),
+			LaunchTemplateName: aws.String(launchTemplateName),
+			LaunchTemplateData: &ec2types.RequestLaunchTemplateData{
+				ImageId:      aws.String("nginx"),
+				InstanceType: ec2types.InstanceTypeA1Large,
+			},
+		}
+		first, err := e.Client.CreateLaunchTemplate(ctx, input)
+		require.NoError(t, err)
+		require.NotNil(t, first.LaunchTemplate)
+		second, err := e.Client.CreateLaunchTemplate(ctx, input)
+		require.NoError(t, err)
+		require.NotNil(t, second.LaunchTemplate)
+		assert.Equal(t, aws.ToString(first.LaunchTemplate.LaunchTemplateId), aws.ToString(second.LaunchTemplate.LaunchTemplateId))
+
+		describeResp, err := e.Client.DescribeLaunchTemplates(ctx, &ec2.DescribeLaunchTemplatesInput{
+			LaunchTemplateNames: []string{launchTemplateName},
+		})
+		require.NoError(t, err)
+		assert.Len(t, describeResp.LaunchTemplates, 1)
+	})
+}
+
 func TestLaunchTemplateVersions(t *testing.T) {
 	t.Parallel()
 	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
@@ -263,6 +291,16 @@ func TestLaunchTemplateVersions(t *testing.T) {
 		assert.Equal(t, int64(2), *describeDefaultVersionResp.LaunchTemplateVersions[0].VersionNumber)
 		require.NotNil(t, describeDefaultVersionResp.LaunchTemplateVersions[0].DefaultVersion)
 		assert.True(t, *describeDefaultVersionResp.LaunchTemplateVersions[0].DefaultVersion)
+
+		filteredResp, err := e.Client.DescribeLaunchTemplateVersions(ctx, &ec2.DescribeLaunchTemplateVersionsInput{
+			LaunchTemplateId: aws.String(launchTemplateID),
+			Filters: []ec2types.Filter{
+				{Name: aws.String("is-default-version"), Values: []string{"true"}},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, filteredResp.LaunchTemplateVersions, 1)
+		assert.Equal(t, int64(2), aws.ToInt64(filteredResp.LaunchTemplateVersions[0].VersionNumber))
 	})
 }
 
@@ -461,6 +499,23 @@ func TestRunInstancesWithLaunchTemplateDefaultVersion(t *testing.T) {
 		}
 		assert.Contains(t, filteredInstanceIDs, instanceID)
 
+		launchTemplateFilterResp, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+			Filters: []ec2types.Filter{
+				{
+					Name:   aws.String("launch-template-id"),
+					Values: []string{aws.ToString(createResp.LaunchTemplate.LaunchTemplateId)},
+				},
+				{
+					Name:   aws.String("launch-template-version"),
+					Values: []string{"1"},
+				},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, launchTemplateFilterResp.Reservations, 1)
+		require.Len(t, launchTemplateFilterResp.Reservations[0].Instances, 1)
+		assert.Equal(t, instanceID, aws.ToString(launchTemplateFilterResp.Reservations[0].Instances[0].InstanceId))
+
 		t.Cleanup(func() {
 			apiCtx, cancel := cleanupAPICtx(t)
 			defer cancel()
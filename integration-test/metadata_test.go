@@ -1,13 +1,21 @@
 package dc2_test
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2"
 )
 
 func TestInternalMetadataEndpoint(t *testing.T) {
@@ -41,3 +49,101 @@ func TestInternalMetadataEndpoint(t *testing.T) {
 		assert.NotEmpty(t, payload.Build.GoVersion)
 	})
 }
+
+func TestHealthEndpoints(t *testing.T) {
+	t.Parallel()
+
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		for _, path := range []string{"/healthz", "/readyz"} {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, e.Endpoint+path, nil)
+			require.NoError(t, err)
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode, path)
+		}
+	})
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	t.Parallel()
+
+	testWithServerWithOptionsAndEnvForMode(
+		t,
+		configuredTestMode(),
+		[]dc2.Option{dc2.WithMetrics()},
+		map[string]string{"DC2_METRICS": "true"},
+		func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+			for range 3 {
+				_, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{})
+				require.NoError(t, err)
+			}
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, e.Endpoint+"/metrics", nil)
+			require.NoError(t, err)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Contains(t, string(body), `dc2_actions_total{action="DescribeInstances"} 3`)
+			assert.Contains(t, string(body), `dc2_resources{type="instance"}`)
+		},
+	)
+}
+
+func TestEventStreamEndpoint(t *testing.T) {
+	t.Parallel()
+
+	testWithServerWithOptionsAndEnvForMode(
+		t,
+		configuredTestMode(),
+		[]dc2.Option{dc2.WithEventStream()},
+		map[string]string{"DC2_EVENT_STREAM": "true"},
+		func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+			streamCtx, cancel := context.WithTimeout(ctx, time.Minute)
+			defer cancel()
+			req, err := http.NewRequestWithContext(streamCtx, http.MethodGet, e.Endpoint+"/events", nil)
+			require.NoError(t, err)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+			runOutput, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+				ImageId:      aws.String("nginx"),
+				InstanceType: "my-type",
+				MinCount:     aws.Int32(1),
+				MaxCount:     aws.Int32(1),
+			})
+			require.NoError(t, err)
+			instanceID := aws.ToString(runOutput.Instances[0].InstanceId)
+			t.Cleanup(func() {
+				apiCtx, cancel := cleanupAPICtx(t)
+				defer cancel()
+				_, _ = e.Client.TerminateInstances(apiCtx, &ec2.TerminateInstancesInput{InstanceIds: []string{instanceID}})
+			})
+
+			scanner := bufio.NewScanner(resp.Body)
+			for scanner.Scan() {
+				data, found := strings.CutPrefix(scanner.Text(), "data: ")
+				if !found {
+					continue
+				}
+				var event dc2.StateChangeEvent
+				require.NoError(t, json.Unmarshal([]byte(data), &event))
+				if event.Type == dc2.EventTypeInstanceStateChange && event.ResourceID == instanceID && event.State == "running" {
+					assert.Equal(t, "instance", string(event.ResourceType))
+					assert.Equal(t, "pending", event.PreviousState)
+					assert.WithinDuration(t, time.Now(), event.Timestamp, time.Minute)
+					return
+				}
+			}
+			require.NoError(t, scanner.Err())
+			t.Fatal("event stream ended without a running event")
+		},
+	)
+}
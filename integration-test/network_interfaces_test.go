@@ -0,0 +1,117 @@
+package dc2_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescribeNetworkInterfacesByInstance(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		runOut, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:      aws.String("nginx"),
+			InstanceType: "my-type",
+			MinCount:     aws.Int32(1),
+			MaxCount:     aws.Int32(1),
+		})
+		require.NoError(t, err)
+		require.Len(t, runOut.Instances, 1)
+		instanceID := aws.ToString(runOut.Instances[0].InstanceId)
+		t.Cleanup(func() {
+			cleanupCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, err := e.Client.TerminateInstances(cleanupCtx, &ec2.TerminateInstancesInput{InstanceIds: []string{instanceID}})
+			if err != nil && !isInstanceNotFound(err) {
+				assert.NoError(t, err)
+			}
+		})
+
+		describeOut, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{InstanceIds: []string{instanceID}})
+		require.NoError(t, err)
+		require.Len(t, describeOut.Reservations, 1)
+		require.Len(t, describeOut.Reservations[0].Instances, 1)
+		instance := describeOut.Reservations[0].Instances[0]
+		require.Len(t, instance.NetworkInterfaces, 1)
+
+		out, err := e.Client.DescribeNetworkInterfaces(ctx, &ec2.DescribeNetworkInterfacesInput{
+			Filters: []ec2types.Filter{{Name: aws.String("attachment.instance-id"), Values: []string{instanceID}}},
+		})
+		require.NoError(t, err)
+		require.Len(t, out.NetworkInterfaces, 1)
+		networkInterface := out.NetworkInterfaces[0]
+		assert.Equal(t, aws.ToString(instance.NetworkInterfaces[0].NetworkInterfaceId), aws.ToString(networkInterface.NetworkInterfaceId))
+		require.NotNil(t, networkInterface.Attachment)
+		assert.Equal(t, instanceID, aws.ToString(networkInterface.Attachment.InstanceId))
+		assert.Equal(t, aws.ToString(instance.PrivateIpAddress), aws.ToString(networkInterface.PrivateIpAddress))
+		assert.Equal(t, aws.ToString(instance.NetworkInterfaces[0].MacAddress), aws.ToString(networkInterface.MacAddress))
+		assert.Equal(t, aws.ToString(instance.SubnetId), aws.ToString(networkInterface.SubnetId))
+
+		byIDOut, err := e.Client.DescribeNetworkInterfaces(ctx, &ec2.DescribeNetworkInterfacesInput{
+			NetworkInterfaceIds: []string{aws.ToString(networkInterface.NetworkInterfaceId)},
+		})
+		require.NoError(t, err)
+		require.Len(t, byIDOut.NetworkInterfaces, 1)
+		assert.Equal(t, instanceID, aws.ToString(byIDOut.NetworkInterfaces[0].Attachment.InstanceId))
+	})
+}
+
+func TestAssignSecondaryPrivateIPAddresses(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		runOut, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:      aws.String("nginx"),
+			InstanceType: "my-type",
+			MinCount:     aws.Int32(1),
+			MaxCount:     aws.Int32(1),
+		})
+		require.NoError(t, err)
+		require.Len(t, runOut.Instances, 1)
+		instanceID := aws.ToString(runOut.Instances[0].InstanceId)
+		t.Cleanup(func() {
+			cleanupCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, err := e.Client.TerminateInstances(cleanupCtx, &ec2.TerminateInstancesInput{InstanceIds: []string{instanceID}})
+			if err != nil && !isInstanceNotFound(err) {
+				assert.NoError(t, err)
+			}
+		})
+		require.Len(t, runOut.Instances[0].NetworkInterfaces, 1)
+		networkInterfaceID := aws.ToString(runOut.Instances[0].NetworkInterfaces[0].NetworkInterfaceId)
+
+		assignOut, err := e.Client.AssignPrivateIpAddresses(ctx, &ec2.AssignPrivateIpAddressesInput{
+			NetworkInterfaceId: aws.String(networkInterfaceID),
+			PrivateIpAddresses: []string{"10.0.0.20", "10.0.0.21"},
+		})
+		require.NoError(t, err)
+		assert.Len(t, assignOut.AssignedPrivateIpAddresses, 2)
+
+		secondaryIPs := func() []string {
+			out, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{InstanceIds: []string{instanceID}})
+			require.NoError(t, err)
+			require.Len(t, out.Reservations, 1)
+			require.Len(t, out.Reservations[0].Instances, 1)
+			require.Len(t, out.Reservations[0].Instances[0].NetworkInterfaces, 1)
+			var ips []string
+			for _, addr := range out.Reservations[0].Instances[0].NetworkInterfaces[0].PrivateIpAddresses {
+				if !aws.ToBool(addr.Primary) {
+					ips = append(ips, aws.ToString(addr.PrivateIpAddress))
+				}
+			}
+			return ips
+		}
+		assert.Equal(t, []string{"10.0.0.20", "10.0.0.21"}, secondaryIPs())
+
+		_, err = e.Client.UnassignPrivateIpAddresses(ctx, &ec2.UnassignPrivateIpAddressesInput{
+			NetworkInterfaceId: aws.String(networkInterfaceID),
+			PrivateIpAddresses: []string{"10.0.0.20"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"10.0.0.21"}, secondaryIPs())
+	})
+}
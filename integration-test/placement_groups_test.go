@@ -0,0 +1,101 @@
+package dc2_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClusterPlacementGroup(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		groupName := fmt.Sprintf("pg-cluster-%d", time.Now().UnixNano())
+		createOut, err := e.Client.CreatePlacementGroup(ctx, &ec2.CreatePlacementGroupInput{
+			GroupName: aws.String(groupName),
+			Strategy:  ec2types.PlacementStrategyCluster,
+		})
+		require.NoError(t, err)
+		require.NotNil(t, createOut.PlacementGroup)
+		assert.Equal(t, groupName, aws.ToString(createOut.PlacementGroup.GroupName))
+		assert.Equal(t, ec2types.PlacementStrategyCluster, createOut.PlacementGroup.Strategy)
+		assert.Equal(t, ec2types.PlacementGroupStateAvailable, createOut.PlacementGroup.State)
+
+		describeOut, err := e.Client.DescribePlacementGroups(ctx, &ec2.DescribePlacementGroupsInput{
+			GroupNames: []string{groupName},
+		})
+		require.NoError(t, err)
+		require.Len(t, describeOut.PlacementGroups, 1)
+		assert.Equal(t, aws.ToString(createOut.PlacementGroup.GroupId), aws.ToString(describeOut.PlacementGroups[0].GroupId))
+
+		_, err = e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:      aws.String("nginx"),
+			InstanceType: "my-type",
+			MinCount:     aws.Int32(1),
+			MaxCount:     aws.Int32(1),
+			Placement:    &ec2types.Placement{GroupName: aws.String(groupName + "-missing")},
+		})
+		var apiErr smithy.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "InvalidPlacementGroup.Unknown", apiErr.ErrorCode())
+
+		runOut, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:      aws.String("nginx"),
+			InstanceType: "my-type",
+			MinCount:     aws.Int32(2),
+			MaxCount:     aws.Int32(2),
+			Placement:    &ec2types.Placement{GroupName: aws.String(groupName)},
+		})
+		require.NoError(t, err)
+		require.Len(t, runOut.Instances, 2)
+		instanceIDs := make([]string, 0, len(runOut.Instances))
+		for _, instance := range runOut.Instances {
+			instanceIDs = append(instanceIDs, aws.ToString(instance.InstanceId))
+			require.NotNil(t, instance.Placement)
+			assert.Equal(t, groupName, aws.ToString(instance.Placement.GroupName))
+		}
+		t.Cleanup(func() {
+			cleanupCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, err := e.Client.TerminateInstances(cleanupCtx, &ec2.TerminateInstancesInput{InstanceIds: instanceIDs})
+			if err != nil && !isInstanceNotFound(err) {
+				assert.NoError(t, err)
+			}
+		})
+
+		describeInstancesOut, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+			Filters: []ec2types.Filter{{Name: aws.String("placement-group-name"), Values: []string{groupName}}},
+		})
+		require.NoError(t, err)
+		var described []string
+		for _, reservation := range describeInstancesOut.Reservations {
+			for _, instance := range reservation.Instances {
+				described = append(described, aws.ToString(instance.InstanceId))
+				assert.Equal(t, groupName, aws.ToString(instance.Placement.GroupName))
+			}
+		}
+		assert.ElementsMatch(t, instanceIDs, described)
+
+		_, err = e.Client.DeletePlacementGroup(ctx, &ec2.DeletePlacementGroupInput{GroupName: aws.String(groupName)})
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "InvalidPlacementGroup.InUse", apiErr.ErrorCode())
+
+		_, err = e.Client.TerminateInstances(ctx, &ec2.TerminateInstancesInput{InstanceIds: instanceIDs})
+		require.NoError(t, err)
+		_, err = e.Client.DeletePlacementGroup(ctx, &ec2.DeletePlacementGroupInput{GroupName: aws.String(groupName)})
+		require.NoError(t, err)
+
+		_, err = e.Client.DescribePlacementGroups(ctx, &ec2.DescribePlacementGroupsInput{
+			GroupNames: []string{groupName},
+		})
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "InvalidPlacementGroup.Unknown", apiErr.ErrorCode())
+	})
+}
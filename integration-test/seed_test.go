@@ -0,0 +1,97 @@
+package dc2_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2"
+)
+
+func TestServerStartsWithSeededResources(t *testing.T) {
+	t.Parallel()
+
+	seed, err := dc2.LoadSeed(`
+launchTemplates:
+  - name: seed-template
+    imageId: nginx
+    instanceType: t3.micro
+instances:
+  - count: 2
+    imageId: nginx
+    instanceType: t3.nano
+    tags:
+      Name: seeded
+autoScalingGroups:
+  - name: seed-asg
+    launchTemplate: seed-template
+    minSize: 1
+    maxSize: 2
+`)
+	require.NoError(t, err)
+
+	testWithServerWithOptionsAndEnvForMode(
+		t,
+		testModeHost,
+		[]dc2.Option{dc2.WithSeed(seed)},
+		nil,
+		func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+			defer cleanupAutoScalingGroup(t, e, "seed-asg")
+
+			describeResp, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+				Filters: []ec2types.Filter{
+					{Name: aws.String("tag:Name"), Values: []string{"seeded"}},
+				},
+			})
+			require.NoError(t, err)
+			var instanceIDs []string
+			for _, reservation := range describeResp.Reservations {
+				for _, instance := range reservation.Instances {
+					assert.Equal(t, ec2types.InstanceType("t3.nano"), instance.InstanceType)
+					instanceIDs = append(instanceIDs, aws.ToString(instance.InstanceId))
+				}
+			}
+			require.Len(t, instanceIDs, 2)
+			defer func() {
+				apiCtx, cancel := cleanupAPICtx(t)
+				defer cancel()
+				_, err := e.Client.TerminateInstances(apiCtx, &ec2.TerminateInstancesInput{
+					InstanceIds: instanceIDs,
+				})
+				require.NoError(t, err)
+			}()
+
+			ltResp, err := e.Client.DescribeLaunchTemplates(ctx, &ec2.DescribeLaunchTemplatesInput{
+				LaunchTemplateNames: []string{"seed-template"},
+			})
+			require.NoError(t, err)
+			require.Len(t, ltResp.LaunchTemplates, 1)
+
+			groupResp, err := e.AutoScalingClient.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
+				AutoScalingGroupNames: []string{"seed-asg"},
+			})
+			require.NoError(t, err)
+			require.Len(t, groupResp.AutoScalingGroups, 1)
+			assert.Len(t, groupResp.AutoScalingGroups[0].Instances, 1)
+		},
+	)
+}
+
+func TestServerFailsOnInvalidSeed(t *testing.T) {
+	t.Parallel()
+
+	_, err := dc2.NewServer("127.0.0.1:0", dc2.WithSeed(&dc2.Seed{
+		AutoScalingGroups: []dc2.SeedAutoScalingGroup{
+			{Name: "missing-template-asg", LaunchTemplate: "does-not-exist", MaxSize: 1},
+		},
+	}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "seeding initial resources")
+	assert.Contains(t, err.Error(), `creating seed auto scaling group "missing-template-asg"`)
+}
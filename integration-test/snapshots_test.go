@@ -0,0 +1,275 @@
+package dc2_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2"
+)
+
+func TestCreateDescribeDeleteSnapshot(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		const (
+			volumeSize  = 1
+			description = "nightly backup"
+		)
+		volume, err := e.Client.CreateVolume(ctx, &ec2.CreateVolumeInput{
+			AvailabilityZone: aws.String("us-west-2a"),
+			Size:             aws.Int32(volumeSize),
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			cleanupCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, err := e.Client.DeleteVolume(cleanupCtx, &ec2.DeleteVolumeInput{VolumeId: volume.VolumeId})
+			assert.NoError(t, err)
+		})
+
+		snapshot, err := e.Client.CreateSnapshot(ctx, &ec2.CreateSnapshotInput{
+			VolumeId:    volume.VolumeId,
+			Description: aws.String(description),
+			TagSpecifications: []ec2types.TagSpecification{
+				{
+					ResourceType: ec2types.ResourceTypeSnapshot,
+					Tags:         []ec2types.Tag{{Key: aws.String("Name"), Value: aws.String("backup")}},
+				},
+			},
+		})
+		require.NoError(t, err)
+		snapshotID := aws.ToString(snapshot.SnapshotId)
+		require.True(t, strings.HasPrefix(snapshotID, "snap-"), snapshotID)
+		assert.Equal(t, aws.ToString(volume.VolumeId), aws.ToString(snapshot.VolumeId))
+		assert.Equal(t, ec2types.SnapshotStateCompleted, snapshot.State)
+		assert.Equal(t, int32(volumeSize), aws.ToInt32(snapshot.VolumeSize))
+		assert.Equal(t, description, aws.ToString(snapshot.Description))
+
+		// A second snapshot to exercise filters and pagination
+		otherSnapshot, err := e.Client.CreateSnapshot(ctx, &ec2.CreateSnapshotInput{VolumeId: volume.VolumeId})
+		require.NoError(t, err)
+		otherSnapshotID := aws.ToString(otherSnapshot.SnapshotId)
+		t.Cleanup(func() {
+			cleanupCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, err := e.Client.DeleteSnapshot(cleanupCtx, &ec2.DeleteSnapshotInput{SnapshotId: aws.String(otherSnapshotID)})
+			assert.NoError(t, err)
+		})
+
+		byVolume, err := e.Client.DescribeSnapshots(ctx, &ec2.DescribeSnapshotsInput{
+			Filters: []ec2types.Filter{{Name: aws.String("volume-id"), Values: []string{aws.ToString(volume.VolumeId)}}},
+		})
+		require.NoError(t, err)
+		assert.Len(t, byVolume.Snapshots, 2)
+
+		byID, err := e.Client.DescribeSnapshots(ctx, &ec2.DescribeSnapshotsInput{
+			Filters: []ec2types.Filter{{Name: aws.String("snapshot-id"), Values: []string{snapshotID}}},
+		})
+		require.NoError(t, err)
+		require.Len(t, byID.Snapshots, 1)
+		assert.Equal(t, snapshotID, aws.ToString(byID.Snapshots[0].SnapshotId))
+		assert.Contains(t, byID.Snapshots[0].Tags, ec2types.Tag{Key: aws.String("Name"), Value: aws.String("backup")})
+
+		var pagedIDs []string
+		paginator := ec2.NewDescribeSnapshotsPaginator(e.Client, &ec2.DescribeSnapshotsInput{
+			Filters:    []ec2types.Filter{{Name: aws.String("volume-id"), Values: []string{aws.ToString(volume.VolumeId)}}},
+			MaxResults: aws.Int32(1),
+		})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(page.Snapshots), 1)
+			for _, s := range page.Snapshots {
+				pagedIDs = append(pagedIDs, aws.ToString(s.SnapshotId))
+			}
+		}
+		assert.ElementsMatch(t, []string{snapshotID, otherSnapshotID}, pagedIDs)
+
+		_, err = e.Client.DeleteSnapshot(ctx, &ec2.DeleteSnapshotInput{SnapshotId: aws.String(snapshotID)})
+		require.NoError(t, err)
+		_, err = e.Client.DescribeSnapshots(ctx, &ec2.DescribeSnapshotsInput{SnapshotIds: []string{snapshotID}})
+		var apiErr smithy.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "InvalidSnapshot.NotFound", apiErr.ErrorCode())
+	})
+}
+
+func TestCreateVolumeFromSnapshot(t *testing.T) {
+	t.Parallel()
+
+	hostPath := t.TempDir()
+	testWithServerWithOptionsAndEnvForMode(
+		t,
+		testModeHost,
+		[]dc2.Option{dc2.WithMainVolumeHostPath(hostPath)},
+		nil,
+		func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+			const volumeSize = 1
+			contents := []byte("written before the snapshot")
+			volumeFile := func(volumeID string) string {
+				return filepath.Join(hostPath, strings.TrimPrefix(volumeID, "vol-"))
+			}
+			writeVolume := func(volumeID string, data []byte) {
+				f, err := os.OpenFile(volumeFile(volumeID), os.O_WRONLY, 0)
+				require.NoError(t, err)
+				_, err = f.WriteAt(data, 0)
+				require.NoError(t, err)
+				require.NoError(t, f.Close())
+			}
+			readVolume := func(volumeID string, n int) []byte {
+				f, err := os.Open(volumeFile(volumeID))
+				require.NoError(t, err)
+				defer f.Close()
+				data := make([]byte, n)
+				_, err = f.ReadAt(data, 0)
+				require.NoError(t, err)
+				return data
+			}
+
+			volume, err := e.Client.CreateVolume(ctx, &ec2.CreateVolumeInput{
+				AvailabilityZone: aws.String("us-east-1a"),
+				Size:             aws.Int32(volumeSize),
+			})
+			require.NoError(t, err)
+			volumeID := aws.ToString(volume.VolumeId)
+			writeVolume(volumeID, contents)
+
+			snapshot, err := e.Client.CreateSnapshot(ctx, &ec2.CreateSnapshotInput{VolumeId: volume.VolumeId})
+			require.NoError(t, err)
+			snapshotID := aws.ToString(snapshot.SnapshotId)
+
+			// Later writes to the source volume don't change the snapshot
+			writeVolume(volumeID, []byte(strings.Repeat("x", len(contents))))
+
+			restored, err := e.Client.CreateVolume(ctx, &ec2.CreateVolumeInput{
+				AvailabilityZone: aws.String("us-east-1a"),
+				SnapshotId:       aws.String(snapshotID),
+			})
+			require.NoError(t, err)
+			restoredID := aws.ToString(restored.VolumeId)
+			assert.Equal(t, int32(volumeSize), aws.ToInt32(restored.Size))
+			assert.Equal(t, snapshotID, aws.ToString(restored.SnapshotId))
+			assert.Equal(t, contents, readVolume(restoredID, len(contents)))
+
+			describeOut, err := e.Client.DescribeVolumes(ctx, &ec2.DescribeVolumesInput{VolumeIds: []string{restoredID}})
+			require.NoError(t, err)
+			require.Len(t, describeOut.Volumes, 1)
+			assert.Equal(t, int32(volumeSize), aws.ToInt32(describeOut.Volumes[0].Size))
+
+			_, err = e.Client.CreateVolume(ctx, &ec2.CreateVolumeInput{
+				AvailabilityZone: aws.String("us-east-1a"),
+				SnapshotId:       aws.String("snap-0123456789abcdef0"),
+			})
+			var apiErr smithy.APIError
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, "InvalidSnapshot.NotFound", apiErr.ErrorCode())
+
+			_, err = e.Client.DeleteSnapshot(ctx, &ec2.DeleteSnapshotInput{SnapshotId: aws.String(snapshotID)})
+			require.NoError(t, err)
+			_, err = os.Stat(filepath.Join(hostPath, strings.TrimPrefix(snapshotID, "snap-")+".snapshot"))
+			assert.True(t, errors.Is(err, os.ErrNotExist), "unexpected error: %v", err)
+
+			for _, id := range []string{volumeID, restoredID} {
+				_, err := e.Client.DeleteVolume(ctx, &ec2.DeleteVolumeInput{VolumeId: aws.String(id)})
+				require.NoError(t, err)
+			}
+		},
+	)
+}
+
+func TestCopySnapshot(t *testing.T) {
+	t.Parallel()
+
+	hostPath := t.TempDir()
+	testWithServerWithOptionsAndEnvForMode(
+		t,
+		testModeHost,
+		[]dc2.Option{dc2.WithMainVolumeHostPath(hostPath)},
+		nil,
+		func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+			contents := []byte("written before the snapshot")
+			volume, err := e.Client.CreateVolume(ctx, &ec2.CreateVolumeInput{
+				AvailabilityZone: aws.String("us-east-1a"),
+				Size:             aws.Int32(1),
+			})
+			require.NoError(t, err)
+			volumeID := aws.ToString(volume.VolumeId)
+			f, err := os.OpenFile(filepath.Join(hostPath, strings.TrimPrefix(volumeID, "vol-")), os.O_WRONLY, 0)
+			require.NoError(t, err)
+			_, err = f.WriteAt(contents, 0)
+			require.NoError(t, err)
+			require.NoError(t, f.Close())
+
+			snapshot, err := e.Client.CreateSnapshot(ctx, &ec2.CreateSnapshotInput{VolumeId: volume.VolumeId})
+			require.NoError(t, err)
+			snapshotID := aws.ToString(snapshot.SnapshotId)
+
+			copyOut, err := e.Client.CopySnapshot(ctx, &ec2.CopySnapshotInput{
+				SourceSnapshotId: aws.String(snapshotID),
+				SourceRegion:     aws.String(e.Region),
+				Description:      aws.String("copied snapshot"),
+				TagSpecifications: []ec2types.TagSpecification{
+					{
+						ResourceType: ec2types.ResourceTypeSnapshot,
+						Tags:         []ec2types.Tag{{Key: aws.String("Copy"), Value: aws.String("true")}},
+					},
+				},
+			})
+			require.NoError(t, err)
+			copyID := aws.ToString(copyOut.SnapshotId)
+			assert.Regexp(t, `^snap-[0-9a-f]{17}$`, copyID)
+
+			// The copy doesn't depend on the source snapshot
+			_, err = e.Client.DeleteSnapshot(ctx, &ec2.DeleteSnapshotInput{SnapshotId: aws.String(snapshotID)})
+			require.NoError(t, err)
+
+			describeOut, err := e.Client.DescribeSnapshots(ctx, &ec2.DescribeSnapshotsInput{SnapshotIds: []string{copyID}})
+			require.NoError(t, err)
+			require.Len(t, describeOut.Snapshots, 1)
+			copied := describeOut.Snapshots[0]
+			assert.Equal(t, int32(1), aws.ToInt32(copied.VolumeSize))
+			assert.Equal(t, "copied snapshot", aws.ToString(copied.Description))
+			assert.Equal(t, ec2types.SnapshotStateCompleted, copied.State)
+			assert.Equal(t, []ec2types.Tag{{Key: aws.String("Copy"), Value: aws.String("true")}}, copied.Tags)
+
+			restored, err := e.Client.CreateVolume(ctx, &ec2.CreateVolumeInput{
+				AvailabilityZone: aws.String("us-east-1a"),
+				SnapshotId:       aws.String(copyID),
+			})
+			require.NoError(t, err)
+			restoredID := aws.ToString(restored.VolumeId)
+			f, err = os.Open(filepath.Join(hostPath, strings.TrimPrefix(restoredID, "vol-")))
+			require.NoError(t, err)
+			data := make([]byte, len(contents))
+			_, err = f.ReadAt(data, 0)
+			require.NoError(t, err)
+			require.NoError(t, f.Close())
+			assert.Equal(t, contents, data)
+
+			_, err = e.Client.CopySnapshot(ctx, &ec2.CopySnapshotInput{
+				SourceSnapshotId: aws.String(snapshotID),
+				SourceRegion:     aws.String(e.Region),
+			})
+			var apiErr smithy.APIError
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, "InvalidSnapshot.NotFound", apiErr.ErrorCode())
+
+			_, err = e.Client.DeleteSnapshot(ctx, &ec2.DeleteSnapshotInput{SnapshotId: aws.String(copyID)})
+			require.NoError(t, err)
+			for _, id := range []string{volumeID, restoredID} {
+				_, err := e.Client.DeleteVolume(ctx, &ec2.DeleteVolumeInput{VolumeId: aws.String(id)})
+				require.NoError(t, err)
+			}
+		},
+	)
+}
@@ -3,6 +3,8 @@ package dc2_test
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"net/http"
 	"strings"
 	"testing"
 	"time"
@@ -264,6 +266,80 @@ func TestDescribeSpotInstanceRequestsTracksUserTermination(t *testing.T) {
 	})
 }
 
+func TestCancelSpotInstanceRequestsLeavesInstanceRunning(t *testing.T) {
+	t.Parallel()
+
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		runResp, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:      aws.String("nginx"),
+			InstanceType: ec2types.InstanceType("spot-cancel-test"),
+			MinCount:     aws.Int32(1),
+			MaxCount:     aws.Int32(1),
+			InstanceMarketOptions: &ec2types.InstanceMarketOptionsRequest{
+				MarketType: ec2types.MarketTypeSpot,
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, runResp.Instances, 1)
+		instanceID := aws.ToString(runResp.Instances[0].InstanceId)
+
+		requestsOut, err := e.Client.DescribeSpotInstanceRequests(ctx, &ec2.DescribeSpotInstanceRequestsInput{
+			Filters: []ec2types.Filter{
+				{Name: aws.String("instance-id"), Values: []string{instanceID}},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, requestsOut.SpotInstanceRequests, 1)
+		requestID := aws.ToString(requestsOut.SpotInstanceRequests[0].SpotInstanceRequestId)
+		require.NotEmpty(t, requestID)
+
+		cancelOut, err := e.Client.CancelSpotInstanceRequests(ctx, &ec2.CancelSpotInstanceRequestsInput{
+			SpotInstanceRequestIds: []string{requestID},
+		})
+		require.NoError(t, err)
+		require.Len(t, cancelOut.CancelledSpotInstanceRequests, 1)
+		assert.Equal(t, requestID, aws.ToString(cancelOut.CancelledSpotInstanceRequests[0].SpotInstanceRequestId))
+		assert.Equal(t, ec2types.CancelSpotInstanceRequestStateCancelled, cancelOut.CancelledSpotInstanceRequests[0].State)
+
+		describeOut, err := e.Client.DescribeSpotInstanceRequests(ctx, &ec2.DescribeSpotInstanceRequestsInput{
+			SpotInstanceRequestIds: []string{requestID},
+		})
+		require.NoError(t, err)
+		require.Len(t, describeOut.SpotInstanceRequests, 1)
+		request := describeOut.SpotInstanceRequests[0]
+		assert.Equal(t, ec2types.SpotInstanceStateCancelled, request.State)
+		assert.Equal(t, instanceID, aws.ToString(request.InstanceId))
+		require.NotNil(t, request.Status)
+		assert.Equal(t, "request-canceled-and-instance-running", aws.ToString(request.Status.Code))
+
+		instancesOut, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+			InstanceIds: []string{instanceID},
+		})
+		require.NoError(t, err)
+		require.Len(t, instancesOut.Reservations, 1)
+		require.Len(t, instancesOut.Reservations[0].Instances, 1)
+		assert.Equal(t, ec2types.InstanceStateNameRunning, instancesOut.Reservations[0].Instances[0].State.Name)
+
+		_, err = e.Client.TerminateInstances(ctx, &ec2.TerminateInstancesInput{
+			InstanceIds: []string{instanceID},
+		})
+		require.NoError(t, err)
+
+		assert.Eventually(t, func() bool {
+			out, describeErr := e.Client.DescribeSpotInstanceRequests(ctx, &ec2.DescribeSpotInstanceRequestsInput{
+				SpotInstanceRequestIds: []string{requestID},
+			})
+			if describeErr != nil || len(out.SpotInstanceRequests) != 1 {
+				return false
+			}
+			request := out.SpotInstanceRequests[0]
+			return request.State == ec2types.SpotInstanceStateCancelled &&
+				request.Status != nil &&
+				aws.ToString(request.Status.Code) == "instance-terminated-by-user"
+		}, 5*time.Second, 100*time.Millisecond)
+	})
+}
+
 func spotReclaimConfig(mode testMode, reclaimAfter time.Duration, reclaimNotice time.Duration) ([]dc2.Option, map[string]string) {
 	if mode == testModeHost {
 		return []dc2.Option{
@@ -276,3 +352,125 @@ func spotReclaimConfig(mode testMode, reclaimAfter time.Duration, reclaimNotice
 		"DC2_SPOT_RECLAIM_NOTICE": reclaimNotice.String(),
 	}
 }
+
+func TestRequestSpotInstances(t *testing.T) {
+	t.Parallel()
+
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		requestOut, err := e.Client.RequestSpotInstances(ctx, &ec2.RequestSpotInstancesInput{
+			InstanceCount: aws.Int32(1),
+			SpotPrice:     aws.String("0.10"),
+			LaunchSpecification: &ec2types.RequestSpotLaunchSpecification{
+				ImageId:      aws.String("nginx"),
+				InstanceType: ec2types.InstanceType("spot-request-test"),
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, requestOut.SpotInstanceRequests, 1)
+		requestID := aws.ToString(requestOut.SpotInstanceRequests[0].SpotInstanceRequestId)
+		require.True(t, strings.HasPrefix(requestID, "sir-"), requestID)
+
+		instanceID := ""
+		t.Cleanup(func() {
+			if instanceID == "" {
+				return
+			}
+			apiCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, _ = e.Client.TerminateInstances(apiCtx, &ec2.TerminateInstancesInput{
+				InstanceIds: []string{instanceID},
+			})
+		})
+
+		require.Eventually(t, func() bool {
+			out, describeErr := e.Client.DescribeSpotInstanceRequests(ctx, &ec2.DescribeSpotInstanceRequestsInput{
+				SpotInstanceRequestIds: []string{requestID},
+			})
+			if describeErr != nil || len(out.SpotInstanceRequests) != 1 {
+				return false
+			}
+			request := out.SpotInstanceRequests[0]
+			if request.Status == nil || aws.ToString(request.Status.Code) != "fulfilled" {
+				return false
+			}
+			instanceID = aws.ToString(request.InstanceId)
+			return string(request.State) == "active" && strings.HasPrefix(instanceID, "i-")
+		}, 5*time.Second, 100*time.Millisecond)
+
+		describeOut, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+			InstanceIds: []string{instanceID},
+		})
+		require.NoError(t, err)
+		require.Len(t, describeOut.Reservations, 1)
+		require.Len(t, describeOut.Reservations[0].Instances, 1)
+		assert.Equal(t, ec2types.InstanceLifecycleTypeSpot, describeOut.Reservations[0].Instances[0].InstanceLifecycle)
+
+		cancelOut, err := e.Client.CancelSpotInstanceRequests(ctx, &ec2.CancelSpotInstanceRequestsInput{
+			SpotInstanceRequestIds: []string{requestID},
+		})
+		require.NoError(t, err)
+		require.Len(t, cancelOut.CancelledSpotInstanceRequests, 1)
+		assert.Equal(t, ec2types.CancelSpotInstanceRequestStateCancelled, cancelOut.CancelledSpotInstanceRequests[0].State)
+	})
+}
+
+func TestSpotInterruptionEndpoint(t *testing.T) {
+	t.Parallel()
+	requireContainerModeForIMDSTest(t)
+
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		runResp, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:      aws.String("nginx"),
+			InstanceType: ec2types.InstanceType("my-type"),
+			MinCount:     aws.Int32(1),
+			MaxCount:     aws.Int32(1),
+			InstanceMarketOptions: &ec2types.InstanceMarketOptionsRequest{
+				MarketType: ec2types.MarketTypeSpot,
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, runResp.Instances, 1)
+		instanceID := aws.ToString(runResp.Instances[0].InstanceId)
+		t.Cleanup(func() {
+			apiCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, _ = e.Client.TerminateInstances(apiCtx, &ec2.TerminateInstancesInput{
+				InstanceIds: []string{instanceID},
+			})
+		})
+		containerID := containerIDForInstanceID(t, ctx, e.DockerHost, instanceID)
+		token := fetchIMDSToken(t, ctx, e.DockerHost, containerID)
+
+		body := fmt.Sprintf(`{"instanceId": %q, "notice": "3s"}`, instanceID)
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.Endpoint+"/_dc2/spot-interruption", strings.NewReader(body))
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		assert.Eventually(t, func() bool {
+			out, curlErr := curlIMDS(ctx, e.DockerHost, containerID, "/latest/meta-data/spot/instance-action", token)
+			if curlErr != nil {
+				return false
+			}
+			payload := map[string]string{}
+			if err := json.Unmarshal(out, &payload); err != nil {
+				return false
+			}
+			_, err := time.Parse(time.RFC3339, payload["time"])
+			return payload["action"] == "terminate" && err == nil
+		}, 3*time.Second, 100*time.Millisecond)
+
+		assert.Eventually(t, func() bool {
+			out, describeErr := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+				InstanceIds: []string{instanceID},
+			})
+			if describeErr != nil || len(out.Reservations) == 0 || len(out.Reservations[0].Instances) == 0 {
+				return false
+			}
+			instance := out.Reservations[0].Instances[0]
+			return instance.State != nil && instance.State.Name == ec2types.InstanceStateNameTerminated
+		}, 10*time.Second, 100*time.Millisecond)
+	})
+}
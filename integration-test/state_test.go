@@ -0,0 +1,105 @@
+package dc2_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	autoscalingtypes "github.com/aws/aws-sdk-go-v2/service/autoscaling/types"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2"
+)
+
+func TestStatePathRestoresResourcesAfterRestart(t *testing.T) {
+	t.Parallel()
+
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	serverOpts := []dc2.Option{
+		dc2.WithStatePath(statePath),
+		dc2.WithExitResourceMode(dc2.ExitResourceModeKeep),
+	}
+	launchTemplateName := fmt.Sprintf("lt-state-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+	autoScalingGroupName := fmt.Sprintf("asg-state-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+	var instanceID string
+
+	t.Run("initial server", func(t *testing.T) {
+		testWithServerWithOptionsAndEnvForMode(t, testModeHost, serverOpts, nil, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+			lt, err := e.Client.CreateLaunchTemplate(ctx, &ec2.CreateLaunchTemplateInput{
+				LaunchTemplateName: aws.String(launchTemplateName),
+				LaunchTemplateData: &ec2types.RequestLaunchTemplateData{
+					ImageId:      aws.String("nginx"),
+					InstanceType: ec2types.InstanceTypeA1Large,
+				},
+			})
+			require.NoError(t, err)
+
+			_, err = e.AutoScalingClient.CreateAutoScalingGroup(ctx, &autoscaling.CreateAutoScalingGroupInput{
+				AutoScalingGroupName: aws.String(autoScalingGroupName),
+				MinSize:              aws.Int32(1),
+				MaxSize:              aws.Int32(1),
+				LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
+					LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
+				},
+			})
+			require.NoError(t, err)
+
+			require.Eventually(t, func() bool {
+				out, err := e.AutoScalingClient.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
+					AutoScalingGroupNames: []string{autoScalingGroupName},
+				})
+				if err != nil || len(out.AutoScalingGroups) != 1 || len(out.AutoScalingGroups[0].Instances) != 1 {
+					return false
+				}
+				instanceID = aws.ToString(out.AutoScalingGroups[0].Instances[0].InstanceId)
+				return true
+			}, 20*time.Second, 250*time.Millisecond)
+		})
+	})
+	require.NotEmpty(t, instanceID)
+
+	t.Run("restarted server", func(t *testing.T) {
+		testWithServerWithOptionsAndEnvForMode(t, testModeHost, serverOpts, nil, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+			defer func() {
+				cleanupAutoScalingGroup(t, e, autoScalingGroupName)
+				apiCtx, cancel := cleanupAPICtx(t)
+				defer cancel()
+				_, err := e.Client.DeleteLaunchTemplate(apiCtx, &ec2.DeleteLaunchTemplateInput{
+					LaunchTemplateName: aws.String(launchTemplateName),
+				})
+				require.NoError(t, err)
+			}()
+
+			out, err := e.AutoScalingClient.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
+				AutoScalingGroupNames: []string{autoScalingGroupName},
+			})
+			require.NoError(t, err)
+			require.Len(t, out.AutoScalingGroups, 1)
+			group := out.AutoScalingGroups[0]
+			assert.Equal(t, launchTemplateName, aws.ToString(group.LaunchTemplate.LaunchTemplateName))
+			require.Len(t, group.Instances, 1)
+			assert.Equal(t, instanceID, aws.ToString(group.Instances[0].InstanceId))
+
+			describeOut, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+				InstanceIds: []string{instanceID},
+			})
+			require.NoError(t, err)
+			require.Len(t, describeOut.Reservations, 1)
+			require.Len(t, describeOut.Reservations[0].Instances, 1)
+			instance := describeOut.Reservations[0].Instances[0]
+			assert.Equal(t, ec2types.InstanceStateNameRunning, instance.State.Name)
+			assert.Contains(t, instance.Tags, ec2types.Tag{
+				Key:   aws.String("aws:autoscaling:groupName"),
+				Value: aws.String(autoScalingGroupName),
+			})
+		})
+	})
+}
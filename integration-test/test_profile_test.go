@@ -395,7 +395,7 @@ rules:
 				MinSize:              aws.Int32(1),
 				MaxSize:              aws.Int32(2),
 				DesiredCapacity:      aws.Int32(1),
-				VPCZoneIdentifier:    aws.String("subnet-dc2"),
+				VPCZoneIdentifier:    aws.String(defaultSubnetID),
 				LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
 					LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
 					Version:          aws.String("$Default"),
@@ -573,7 +573,7 @@ func TestRuntimeProfileUpdateUnblocksPendingASGScaleOut(t *testing.T) {
 			MinSize:              aws.Int32(0),
 			MaxSize:              aws.Int32(1),
 			DesiredCapacity:      aws.Int32(0),
-			VPCZoneIdentifier:    aws.String("subnet-dc2"),
+			VPCZoneIdentifier:    aws.String(defaultSubnetID),
 			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
 				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
 				Version:          aws.String("$Default"),
@@ -798,7 +798,7 @@ func TestDetachInstancesReturnsWithoutWaitingForDelayedReplacement(t *testing.T)
 			MinSize:              aws.Int32(1),
 			MaxSize:              aws.Int32(2),
 			DesiredCapacity:      aws.Int32(1),
-			VPCZoneIdentifier:    aws.String("subnet-dc2"),
+			VPCZoneIdentifier:    aws.String(defaultSubnetID),
 			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
 				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
 				Version:          aws.String("$Default"),
@@ -2,14 +2,22 @@ package dc2_test
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2"
 )
 
 func TestCreateDeleteVolume(t *testing.T) {
@@ -47,6 +55,78 @@ func TestCreateDeleteVolume(t *testing.T) {
 	})
 }
 
+func TestVolumeStateTransitions(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		const deviceName = "/dev/sdf"
+
+		runInstancesOutput, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:      aws.String("nginx"),
+			InstanceType: ec2types.InstanceTypeA1Large,
+			MinCount:     aws.Int32(1),
+			MaxCount:     aws.Int32(1),
+		})
+		require.NoError(t, err)
+		require.Len(t, runInstancesOutput.Instances, 1)
+		instanceID := aws.ToString(runInstancesOutput.Instances[0].InstanceId)
+		t.Cleanup(func() {
+			cleanupCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, err := e.Client.TerminateInstances(cleanupCtx, &ec2.TerminateInstancesInput{
+				InstanceIds: []string{instanceID},
+			})
+			assert.NoError(t, err)
+		})
+
+		volume, err := e.Client.CreateVolume(ctx, &ec2.CreateVolumeInput{
+			AvailabilityZone: runInstancesOutput.Instances[0].Placement.AvailabilityZone,
+			Size:             aws.Int32(1),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, ec2types.VolumeStateAvailable, volume.State)
+
+		volumeState := func() ec2types.VolumeState {
+			t.Helper()
+			out, err := e.Client.DescribeVolumes(ctx, &ec2.DescribeVolumesInput{
+				VolumeIds: []string{aws.ToString(volume.VolumeId)},
+			})
+			require.NoError(t, err)
+			require.Len(t, out.Volumes, 1)
+			return out.Volumes[0].State
+		}
+		assert.Equal(t, ec2types.VolumeStateAvailable, volumeState())
+
+		_, err = e.Client.AttachVolume(ctx, &ec2.AttachVolumeInput{
+			Device:     aws.String(deviceName),
+			InstanceId: aws.String(instanceID),
+			VolumeId:   volume.VolumeId,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, ec2types.VolumeStateInUse, volumeState())
+
+		_, err = e.Client.DeleteVolume(ctx, &ec2.DeleteVolumeInput{VolumeId: volume.VolumeId})
+		var apiErr smithy.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "VolumeInUse", apiErr.ErrorCode())
+		assert.Equal(t, ec2types.VolumeStateInUse, volumeState())
+
+		_, err = e.Client.DetachVolume(ctx, &ec2.DetachVolumeInput{
+			Device:     aws.String(deviceName),
+			InstanceId: aws.String(instanceID),
+			VolumeId:   volume.VolumeId,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, ec2types.VolumeStateAvailable, volumeState())
+
+		_, err = e.Client.DeleteVolume(ctx, &ec2.DeleteVolumeInput{VolumeId: volume.VolumeId})
+		require.NoError(t, err)
+		_, err = e.Client.DescribeVolumes(ctx, &ec2.DescribeVolumesInput{
+			VolumeIds: []string{aws.ToString(volume.VolumeId)},
+		})
+		require.Error(t, err)
+	})
+}
+
 func TestRunInstancesCreatesVolumesFromBlockDeviceMappings(t *testing.T) {
 	t.Parallel()
 	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
@@ -122,6 +202,83 @@ func TestRunInstancesCreatesVolumesFromBlockDeviceMappings(t *testing.T) {
 	})
 }
 
+func TestRunInstancesLaunchTemplateRootVolumeSize(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		const (
+			rootDeviceName = "/dev/xvda"
+			rootVolumeSize = 20
+		)
+		launchTemplateName := fmt.Sprintf("lt-root-volume-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+		createResp, err := e.Client.CreateLaunchTemplate(ctx, &ec2.CreateLaunchTemplateInput{
+			LaunchTemplateName: aws.String(launchTemplateName),
+			LaunchTemplateData: &ec2types.RequestLaunchTemplateData{
+				ImageId:      aws.String("nginx"),
+				InstanceType: ec2types.InstanceTypeA1Large,
+				BlockDeviceMappings: []ec2types.LaunchTemplateBlockDeviceMappingRequest{
+					{
+						DeviceName: aws.String(rootDeviceName),
+						Ebs: &ec2types.LaunchTemplateEbsBlockDeviceRequest{
+							DeleteOnTermination: aws.Bool(true),
+							VolumeSize:          aws.Int32(rootVolumeSize),
+							VolumeType:          ec2types.VolumeTypeGp3,
+						},
+					},
+				},
+			},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, createResp.LaunchTemplate)
+
+		runResp, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			LaunchTemplate: &ec2types.LaunchTemplateSpecification{
+				LaunchTemplateId: createResp.LaunchTemplate.LaunchTemplateId,
+				Version:          aws.String("$Default"),
+			},
+			MinCount: aws.Int32(1),
+			MaxCount: aws.Int32(1),
+		})
+		require.NoError(t, err)
+		require.Len(t, runResp.Instances, 1)
+		instanceID := aws.ToString(runResp.Instances[0].InstanceId)
+		require.NotEmpty(t, instanceID)
+
+		t.Cleanup(func() {
+			cleanupCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, err := e.Client.TerminateInstances(cleanupCtx, &ec2.TerminateInstancesInput{
+				InstanceIds: []string{instanceID},
+			})
+			assert.NoError(t, err)
+		})
+
+		describeResp, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+			InstanceIds: []string{instanceID},
+		})
+		require.NoError(t, err)
+		require.Len(t, describeResp.Reservations, 1)
+		require.Len(t, describeResp.Reservations[0].Instances, 1)
+		instance := describeResp.Reservations[0].Instances[0]
+		assert.Equal(t, rootDeviceName, aws.ToString(instance.RootDeviceName))
+		assert.Equal(t, ec2types.DeviceTypeEbs, instance.RootDeviceType)
+		require.Len(t, instance.BlockDeviceMappings, 1)
+		rootMapping := instance.BlockDeviceMappings[0]
+		assert.Equal(t, rootDeviceName, aws.ToString(rootMapping.DeviceName))
+		require.NotNil(t, rootMapping.Ebs)
+		assert.Equal(t, ec2types.AttachmentStatusAttached, rootMapping.Ebs.Status)
+		assert.True(t, aws.ToBool(rootMapping.Ebs.DeleteOnTermination))
+		rootVolumeID := aws.ToString(rootMapping.Ebs.VolumeId)
+		require.NotEmpty(t, rootVolumeID)
+
+		volumesResp, err := e.Client.DescribeVolumes(ctx, &ec2.DescribeVolumesInput{
+			VolumeIds: []string{rootVolumeID},
+		})
+		require.NoError(t, err)
+		require.Len(t, volumesResp.Volumes, 1)
+		assert.Equal(t, int32(rootVolumeSize), aws.ToInt32(volumesResp.Volumes[0].Size))
+	})
+}
+
 func TestRunInstancesBlockDeviceDeleteOnTermination(t *testing.T) {
 	t.Parallel()
 	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
@@ -190,6 +347,90 @@ func TestRunInstancesBlockDeviceDeleteOnTermination(t *testing.T) {
 	})
 }
 
+func TestRunInstancesBlockDeviceVolumeSizeAndType(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		runInstancesOutput, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:      aws.String("nginx"),
+			InstanceType: ec2types.InstanceTypeA1Large,
+			MinCount:     aws.Int32(1),
+			MaxCount:     aws.Int32(1),
+			BlockDeviceMappings: []ec2types.BlockDeviceMapping{
+				{
+					DeviceName: aws.String("/dev/sdf"),
+					Ebs: &ec2types.EbsBlockDevice{
+						DeleteOnTermination: aws.Bool(true),
+						VolumeSize:          aws.Int32(2),
+						VolumeType:          ec2types.VolumeTypeGp2,
+					},
+				},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, runInstancesOutput.Instances, 1)
+		require.NotNil(t, runInstancesOutput.Instances[0].InstanceId)
+		instanceID := *runInstancesOutput.Instances[0].InstanceId
+
+		var volumeID string
+		require.Eventually(t, func() bool {
+			describeOutput, err := e.Client.DescribeVolumes(ctx, &ec2.DescribeVolumesInput{})
+			if err != nil {
+				return false
+			}
+			if len(describeOutput.Volumes) != 1 {
+				return false
+			}
+			volume := describeOutput.Volumes[0]
+			if volume.VolumeId == nil || len(volume.Attachments) != 1 {
+				return false
+			}
+			if aws.ToInt32(volume.Size) != 2 || volume.VolumeType != ec2types.VolumeTypeGp2 {
+				return false
+			}
+			attachment := volume.Attachments[0]
+			if attachment.InstanceId == nil || attachment.Device == nil {
+				return false
+			}
+			if *attachment.InstanceId != instanceID || *attachment.Device != "/dev/sdf" {
+				return false
+			}
+			volumeID = *volume.VolumeId
+			return true
+		}, 10*time.Second, 250*time.Millisecond)
+
+		describeInstancesOutput, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+			InstanceIds: []string{instanceID},
+		})
+		require.NoError(t, err)
+		require.Len(t, describeInstancesOutput.Reservations, 1)
+		require.Len(t, describeInstancesOutput.Reservations[0].Instances, 1)
+		mappings := describeInstancesOutput.Reservations[0].Instances[0].BlockDeviceMappings
+		require.Len(t, mappings, 1)
+		assert.Equal(t, "/dev/sdf", aws.ToString(mappings[0].DeviceName))
+		require.NotNil(t, mappings[0].Ebs)
+		assert.Equal(t, volumeID, aws.ToString(mappings[0].Ebs.VolumeId))
+		assert.True(t, aws.ToBool(mappings[0].Ebs.DeleteOnTermination))
+
+		_, err = e.Client.TerminateInstances(ctx, &ec2.TerminateInstancesInput{
+			InstanceIds: []string{instanceID},
+		})
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			describeOutput, err := e.Client.DescribeVolumes(ctx, &ec2.DescribeVolumesInput{})
+			if err != nil {
+				return false
+			}
+			for _, volume := range describeOutput.Volumes {
+				if volume.VolumeId != nil && *volume.VolumeId == volumeID {
+					return false
+				}
+			}
+			return true
+		}, 10*time.Second, 250*time.Millisecond)
+	})
+}
+
 func TestAttachVolume(t *testing.T) {
 	t.Parallel()
 	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
@@ -325,6 +566,230 @@ func TestAttachVolume(t *testing.T) {
 	})
 }
 
+func TestDescribeVolumesFilters(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		const deviceName = "/dev/sdf"
+		tagValue := strings.ReplaceAll(t.Name(), "/", "-")
+
+		runInstancesOutput, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:      aws.String("nginx"),
+			InstanceType: ec2types.InstanceTypeA1Large,
+			MinCount:     aws.Int32(1),
+			MaxCount:     aws.Int32(1),
+		})
+		require.NoError(t, err)
+		require.Len(t, runInstancesOutput.Instances, 1)
+		instance := runInstancesOutput.Instances[0]
+		instanceID := aws.ToString(instance.InstanceId)
+		availabilityZone := aws.ToString(instance.Placement.AvailabilityZone)
+		t.Cleanup(func() {
+			cleanupCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, err := e.Client.TerminateInstances(cleanupCtx, &ec2.TerminateInstancesInput{
+				InstanceIds: []string{instanceID},
+			})
+			assert.NoError(t, err)
+		})
+
+		createVolume := func(availabilityZone string) string {
+			t.Helper()
+			volume, err := e.Client.CreateVolume(ctx, &ec2.CreateVolumeInput{
+				AvailabilityZone: aws.String(availabilityZone),
+				Size:             aws.Int32(1),
+				TagSpecifications: []ec2types.TagSpecification{
+					{
+						ResourceType: ec2types.ResourceTypeVolume,
+						Tags:         []ec2types.Tag{{Key: aws.String("Test"), Value: aws.String(tagValue)}},
+					},
+				},
+			})
+			require.NoError(t, err)
+			volumeID := aws.ToString(volume.VolumeId)
+			t.Cleanup(func() {
+				cleanupCtx, cancel := cleanupAPICtx(t)
+				defer cancel()
+				_, err := e.Client.DeleteVolume(cleanupCtx, &ec2.DeleteVolumeInput{VolumeId: aws.String(volumeID)})
+				assert.NoError(t, err)
+			})
+			return volumeID
+		}
+		volumeID := createVolume(availabilityZone)
+		otherZoneVolumeID := createVolume(availabilityZone + "-other")
+
+		describeVolumeIDs := func(filters ...ec2types.Filter) []string {
+			t.Helper()
+			out, err := e.Client.DescribeVolumes(ctx, &ec2.DescribeVolumesInput{
+				Filters: append(filters, ec2types.Filter{
+					Name:   aws.String("tag:Test"),
+					Values: []string{tagValue},
+				}),
+			})
+			require.NoError(t, err)
+			var volumeIDs []string
+			for _, volume := range out.Volumes {
+				volumeIDs = append(volumeIDs, aws.ToString(volume.VolumeId))
+			}
+			return volumeIDs
+		}
+		attachmentFilter := ec2types.Filter{
+			Name:   aws.String("attachment.instance-id"),
+			Values: []string{instanceID},
+		}
+
+		assert.ElementsMatch(t, []string{volumeID, otherZoneVolumeID}, describeVolumeIDs())
+		assert.Equal(t, []string{volumeID}, describeVolumeIDs(ec2types.Filter{
+			Name:   aws.String("availability-zone"),
+			Values: []string{availabilityZone},
+		}))
+		assert.Empty(t, describeVolumeIDs(attachmentFilter))
+
+		_, err = e.Client.AttachVolume(ctx, &ec2.AttachVolumeInput{
+			Device:     aws.String(deviceName),
+			InstanceId: aws.String(instanceID),
+			VolumeId:   aws.String(otherZoneVolumeID),
+		})
+		var apiErr smithy.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "InvalidVolume.ZoneMismatch", apiErr.ErrorCode())
+
+		_, err = e.Client.AttachVolume(ctx, &ec2.AttachVolumeInput{
+			Device:     aws.String(deviceName),
+			InstanceId: aws.String(instanceID),
+			VolumeId:   aws.String(volumeID),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{volumeID}, describeVolumeIDs(attachmentFilter))
+		assert.Equal(t, []string{volumeID}, describeVolumeIDs(ec2types.Filter{
+			Name:   aws.String("status"),
+			Values: []string{string(ec2types.VolumeStateInUse)},
+		}))
+
+		detachOutput, err := e.Client.DetachVolume(ctx, &ec2.DetachVolumeInput{
+			Device:     aws.String(deviceName),
+			InstanceId: aws.String(instanceID),
+			VolumeId:   aws.String(volumeID),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, ec2types.VolumeAttachmentStateDetached, detachOutput.State)
+		assert.Empty(t, describeVolumeIDs(attachmentFilter))
+		assert.ElementsMatch(t, []string{volumeID, otherZoneVolumeID}, describeVolumeIDs(ec2types.Filter{
+			Name:   aws.String("status"),
+			Values: []string{string(ec2types.VolumeStateAvailable)},
+		}))
+	})
+}
+
+func TestAttachVolumesConcurrently(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		const (
+			instanceCount = 4
+			deviceName    = "/dev/sdf"
+		)
+
+		runInstancesOutput, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:      aws.String("nginx"),
+			InstanceType: ec2types.InstanceTypeA1Large,
+			MinCount:     aws.Int32(instanceCount),
+			MaxCount:     aws.Int32(instanceCount),
+		})
+		require.NoError(t, err)
+		require.Len(t, runInstancesOutput.Instances, instanceCount)
+		instanceIDs := make([]string, instanceCount)
+		for i, instance := range runInstancesOutput.Instances {
+			instanceIDs[i] = aws.ToString(instance.InstanceId)
+		}
+		availabilityZone := aws.ToString(runInstancesOutput.Instances[0].Placement.AvailabilityZone)
+		t.Cleanup(func() {
+			cleanupCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, err := e.Client.TerminateInstances(cleanupCtx, &ec2.TerminateInstancesInput{
+				InstanceIds: instanceIDs,
+			})
+			assert.NoError(t, err)
+		})
+
+		volumeIDs := make([]string, instanceCount)
+		for i := range volumeIDs {
+			volume, err := e.Client.CreateVolume(ctx, &ec2.CreateVolumeInput{
+				AvailabilityZone: aws.String(availabilityZone),
+				Size:             aws.Int32(1),
+			})
+			require.NoError(t, err)
+			volumeIDs[i] = aws.ToString(volume.VolumeId)
+			t.Cleanup(func() {
+				cleanupCtx, cancel := cleanupAPICtx(t)
+				defer cancel()
+				_, err := e.Client.DeleteVolume(cleanupCtx, &ec2.DeleteVolumeInput{VolumeId: volume.VolumeId})
+				assert.NoError(t, err)
+			})
+		}
+
+		var wg sync.WaitGroup
+		attachErrs := make([]error, instanceCount)
+		for i := range instanceCount {
+			wg.Go(func() {
+				_, attachErrs[i] = e.Client.AttachVolume(ctx, &ec2.AttachVolumeInput{
+					Device:     aws.String(deviceName),
+					InstanceId: aws.String(instanceIDs[i]),
+					VolumeId:   aws.String(volumeIDs[i]),
+				})
+			})
+		}
+		wg.Wait()
+		for i, err := range attachErrs {
+			require.NoError(t, err, "attaching volume %s to instance %s", volumeIDs[i], instanceIDs[i])
+		}
+
+		describeOutput, err := e.Client.DescribeVolumes(ctx, &ec2.DescribeVolumesInput{
+			VolumeIds: volumeIDs,
+		})
+		require.NoError(t, err)
+		require.Len(t, describeOutput.Volumes, instanceCount)
+		attachedInstances := make(map[string]string, instanceCount)
+		for _, volume := range describeOutput.Volumes {
+			require.Len(t, volume.Attachments, 1, "volume %s", aws.ToString(volume.VolumeId))
+			attachedInstances[aws.ToString(volume.VolumeId)] = aws.ToString(volume.Attachments[0].InstanceId)
+		}
+		for i, volumeID := range volumeIDs {
+			assert.Equal(t, instanceIDs[i], attachedInstances[volumeID])
+		}
+
+		// Every instance must see its own volume, so each one can be formatted
+		// without clobbering another instance's device.
+		for _, instanceID := range instanceIDs {
+			containerID := containerIDForInstanceID(t, ctx, e.DockerHost, instanceID)
+			cmd := dockerCommandContext(ctx, e.DockerHost, "exec", containerID, "test", "-b", deviceName)
+			cmd.Stdout = t.Output()
+			cmd.Stderr = t.Output()
+			require.NoError(t, cmd.Run(), "instance %s", instanceID)
+		}
+
+		for i := range instanceCount {
+			wg.Go(func() {
+				_, attachErrs[i] = e.Client.DetachVolume(ctx, &ec2.DetachVolumeInput{
+					Device:     aws.String(deviceName),
+					InstanceId: aws.String(instanceIDs[i]),
+					VolumeId:   aws.String(volumeIDs[i]),
+				})
+			})
+		}
+		wg.Wait()
+		for i, err := range attachErrs {
+			require.NoError(t, err, "detaching volume %s from instance %s", volumeIDs[i], instanceIDs[i])
+		}
+
+		describeOutput, err = e.Client.DescribeVolumes(ctx, &ec2.DescribeVolumesInput{
+			VolumeIds: volumeIDs,
+		})
+		require.NoError(t, err)
+		for _, volume := range describeOutput.Volumes {
+			assert.Empty(t, volume.Attachments, "volume %s", aws.ToString(volume.VolumeId))
+		}
+	})
+}
+
 func TestDescribeVolumes(t *testing.T) {
 	t.Parallel()
 	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
@@ -445,3 +910,267 @@ func TestDescribeVolumesPagination(t *testing.T) {
 		}
 	})
 }
+
+func TestModifyVolume(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		volume, err := e.Client.CreateVolume(ctx, &ec2.CreateVolumeInput{
+			AvailabilityZone: aws.String("us-west-2a"),
+			Size:             aws.Int32(1),
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			cleanupCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, err := e.Client.DeleteVolume(cleanupCtx, &ec2.DeleteVolumeInput{VolumeId: volume.VolumeId})
+			assert.NoError(t, err)
+		})
+
+		modifyOut, err := e.Client.ModifyVolume(ctx, &ec2.ModifyVolumeInput{
+			VolumeId: volume.VolumeId,
+			Size:     aws.Int32(2),
+		})
+		require.NoError(t, err)
+		require.NotNil(t, modifyOut.VolumeModification)
+		assert.Equal(t, ec2types.VolumeModificationStateCompleted, modifyOut.VolumeModification.ModificationState)
+		assert.Equal(t, int32(1), aws.ToInt32(modifyOut.VolumeModification.OriginalSize))
+		assert.Equal(t, int32(2), aws.ToInt32(modifyOut.VolumeModification.TargetSize))
+
+		describeOut, err := e.Client.DescribeVolumes(ctx, &ec2.DescribeVolumesInput{
+			VolumeIds: []string{aws.ToString(volume.VolumeId)},
+		})
+		require.NoError(t, err)
+		require.Len(t, describeOut.Volumes, 1)
+		assert.Equal(t, int32(2), aws.ToInt32(describeOut.Volumes[0].Size))
+
+		modificationsOut, err := e.Client.DescribeVolumesModifications(ctx, &ec2.DescribeVolumesModificationsInput{
+			VolumeIds: []string{aws.ToString(volume.VolumeId)},
+		})
+		require.NoError(t, err)
+		require.Len(t, modificationsOut.VolumesModifications, 1)
+		modification := modificationsOut.VolumesModifications[0]
+		assert.Equal(t, aws.ToString(volume.VolumeId), aws.ToString(modification.VolumeId))
+		assert.Equal(t, ec2types.VolumeModificationStateCompleted, modification.ModificationState)
+		assert.Equal(t, int64(100), aws.ToInt64(modification.Progress))
+		assert.Equal(t, int32(2), aws.ToInt32(modification.TargetSize))
+		assert.NotNil(t, modification.EndTime)
+
+		// Volumes can't shrink
+		_, err = e.Client.ModifyVolume(ctx, &ec2.ModifyVolumeInput{
+			VolumeId: volume.VolumeId,
+			Size:     aws.Int32(1),
+		})
+		var apiErr smithy.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "InvalidParameterValue", apiErr.ErrorCode())
+	})
+}
+
+func TestModifyAttachedVolume(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		const (
+			deviceName = "/dev/sdf"
+			// Keep this pinned: test container must stay running and include
+			// blockdev so we can read the size of the attached device.
+			imageID = "redis:7.4.2-bookworm"
+		)
+
+		runInstancesOutput, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+			ImageId:      aws.String(imageID),
+			InstanceType: ec2types.InstanceTypeA1Large,
+			MinCount:     aws.Int32(1),
+			MaxCount:     aws.Int32(1),
+		})
+		require.NoError(t, err)
+		instance := runInstancesOutput.Instances[0]
+		instanceID := aws.ToString(instance.InstanceId)
+		t.Cleanup(func() {
+			cleanupCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, err := e.Client.TerminateInstances(cleanupCtx, &ec2.TerminateInstancesInput{
+				InstanceIds: []string{instanceID},
+			})
+			assert.NoError(t, err)
+		})
+
+		volume, err := e.Client.CreateVolume(ctx, &ec2.CreateVolumeInput{
+			AvailabilityZone: instance.Placement.AvailabilityZone,
+			Size:             aws.Int32(1),
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			cleanupCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, err := e.Client.DeleteVolume(cleanupCtx, &ec2.DeleteVolumeInput{VolumeId: volume.VolumeId})
+			assert.NoError(t, err)
+		})
+		_, err = e.Client.AttachVolume(ctx, &ec2.AttachVolumeInput{
+			Device:     aws.String(deviceName),
+			InstanceId: aws.String(instanceID),
+			VolumeId:   volume.VolumeId,
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			cleanupCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, err := e.Client.DetachVolume(cleanupCtx, &ec2.DetachVolumeInput{
+				Device:     aws.String(deviceName),
+				InstanceId: aws.String(instanceID),
+				VolumeId:   volume.VolumeId,
+			})
+			assert.NoError(t, err)
+		})
+
+		containerID := containerIDForInstanceID(t, ctx, e.DockerHost, instanceID)
+		deviceSize := func() string {
+			out, err := dockerCommandContext(ctx, e.DockerHost, "exec", containerID, "blockdev", "--getsize64", deviceName).Output()
+			require.NoError(t, err)
+			return strings.TrimSpace(string(out))
+		}
+		assert.Equal(t, fmt.Sprint(1024*1024*1024), deviceSize())
+
+		_, err = e.Client.ModifyVolume(ctx, &ec2.ModifyVolumeInput{
+			VolumeId: volume.VolumeId,
+			Size:     aws.Int32(2),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprint(2*1024*1024*1024), deviceSize())
+	})
+}
+
+func TestMainVolumeHostPath(t *testing.T) {
+	t.Parallel()
+
+	hostPath := t.TempDir()
+	testWithServerWithOptionsAndEnvForMode(
+		t,
+		testModeHost,
+		[]dc2.Option{dc2.WithMainVolumeHostPath(hostPath)},
+		nil,
+		func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+			volume, err := e.Client.CreateVolume(ctx, &ec2.CreateVolumeInput{
+				AvailabilityZone: aws.String("us-east-1a"),
+				Size:             aws.Int32(4),
+			})
+			require.NoError(t, err)
+			volumeID := aws.ToString(volume.VolumeId)
+
+			// Volume files are named after the executor volume ID, which
+			// drops the vol- prefix.
+			volumeFile := filepath.Join(hostPath, strings.TrimPrefix(volumeID, "vol-"))
+			info, err := os.Stat(volumeFile)
+			require.NoError(t, err)
+			assert.Equal(t, int64(4*1024*1024*1024), info.Size())
+
+			_, err = e.Client.DeleteVolume(ctx, &ec2.DeleteVolumeInput{VolumeId: volume.VolumeId})
+			require.NoError(t, err)
+			_, err = os.Stat(volumeFile)
+			assert.ErrorIs(t, err, os.ErrNotExist)
+		},
+	)
+}
+
+func TestMainVolumeHostPathMustBeAbsolute(t *testing.T) {
+	t.Parallel()
+
+	_, err := dc2.NewServer("127.0.0.1:0", dc2.WithMainVolumeHostPath("relative/path"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "must be absolute")
+}
+
+func TestMainImageAndVolumeRoot(t *testing.T) {
+	t.Parallel()
+
+	const (
+		// Tag the default main image under another name, like a mirror in a
+		// private registry would be
+		mirrorImage = "dc2-mirror.invalid/library/alpine:3.23.3"
+		volumeRoot  = "/var/lib/dc2-volumes"
+		deviceName  = "/dev/sdf"
+		imageID     = "redis:7.4.2-bookworm"
+	)
+	pullCmd := dockerCommand("", "pull", "alpine:3.23.3")
+	pullCmd.Stdout = t.Output()
+	pullCmd.Stderr = t.Output()
+	require.NoError(t, pullCmd.Run())
+	require.NoError(t, dockerCommand("", "tag", "alpine:3.23.3", mirrorImage).Run())
+	t.Cleanup(func() {
+		assert.NoError(t, dockerCommand("", "rmi", mirrorImage).Run())
+	})
+
+	testWithServerWithOptionsAndEnvForMode(
+		t,
+		testModeHost,
+		[]dc2.Option{dc2.WithMainImage(mirrorImage), dc2.WithVolumeRoot(volumeRoot)},
+		nil,
+		func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+			runInstancesOutput, err := e.Client.RunInstances(ctx, &ec2.RunInstancesInput{
+				ImageId:      aws.String(imageID),
+				InstanceType: ec2types.InstanceTypeA1Large,
+				MinCount:     aws.Int32(1),
+				MaxCount:     aws.Int32(1),
+			})
+			require.NoError(t, err)
+			require.Len(t, runInstancesOutput.Instances, 1)
+			instanceID := aws.ToString(runInstancesOutput.Instances[0].InstanceId)
+			t.Cleanup(func() {
+				cleanupCtx, cancel := cleanupAPICtx(t)
+				defer cancel()
+				_, err := e.Client.TerminateInstances(cleanupCtx, &ec2.TerminateInstancesInput{
+					InstanceIds: []string{instanceID},
+				})
+				assert.NoError(t, err)
+			})
+
+			volume, err := e.Client.CreateVolume(ctx, &ec2.CreateVolumeInput{
+				AvailabilityZone: runInstancesOutput.Instances[0].Placement.AvailabilityZone,
+				Size:             aws.Int32(1),
+			})
+			require.NoError(t, err)
+			volumeID := aws.ToString(volume.VolumeId)
+			t.Cleanup(func() {
+				cleanupCtx, cancel := cleanupAPICtx(t)
+				defer cancel()
+				_, err := e.Client.DeleteVolume(cleanupCtx, &ec2.DeleteVolumeInput{VolumeId: volume.VolumeId})
+				assert.NoError(t, err)
+			})
+
+			_, err = e.Client.AttachVolume(ctx, &ec2.AttachVolumeInput{
+				Device:     aws.String(deviceName),
+				InstanceId: aws.String(instanceID),
+				VolumeId:   volume.VolumeId,
+			})
+			require.NoError(t, err)
+
+			describeOutput, err := e.Client.DescribeVolumes(ctx, &ec2.DescribeVolumesInput{
+				VolumeIds: []string{volumeID},
+			})
+			require.NoError(t, err)
+			require.Len(t, describeOutput.Volumes, 1)
+			require.Len(t, describeOutput.Volumes[0].Attachments, 1)
+			assert.Equal(t, instanceID, aws.ToString(describeOutput.Volumes[0].Attachments[0].InstanceId))
+
+			// Instances mount the volume files at the configured root
+			containerID := containerIDForInstanceID(t, ctx, e.DockerHost, instanceID)
+			volumeFile := volumeRoot + "/" + strings.TrimPrefix(volumeID, "vol-")
+			require.NoError(t, dockerCommandContext(ctx, e.DockerHost, "exec", containerID, "test", "-f", volumeFile).Run())
+			require.NoError(t, dockerCommandContext(ctx, e.DockerHost, "exec", containerID, "test", "-b", deviceName).Run())
+
+			_, err = e.Client.DetachVolume(ctx, &ec2.DetachVolumeInput{
+				VolumeId:   volume.VolumeId,
+				Device:     aws.String(deviceName),
+				InstanceId: aws.String(instanceID),
+			})
+			require.NoError(t, err)
+		},
+	)
+}
+
+func TestVolumeRootMustBeAbsolute(t *testing.T) {
+	t.Parallel()
+
+	_, err := dc2.NewServer("127.0.0.1:0", dc2.WithVolumeRoot("relative/path"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "must be absolute")
+}
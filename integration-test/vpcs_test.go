@@ -0,0 +1,169 @@
+package dc2_test
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	autoscalingtypes "github.com/aws/aws-sdk-go-v2/service/autoscaling/types"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createTestSubnets creates a VPC with cidrBlock and one /24 subnet in each
+// of the given availability zones, returning the subnet IDs. Everything is
+// deleted when the test finishes.
+func createTestSubnets(t *testing.T, ctx context.Context, e *TestEnvironment, cidrBlock string, availabilityZones ...string) (string, []string) {
+	t.Helper()
+
+	vpcOut, err := e.Client.CreateVpc(ctx, &ec2.CreateVpcInput{CidrBlock: aws.String(cidrBlock)})
+	require.NoError(t, err)
+	require.NotNil(t, vpcOut.Vpc)
+	vpcID := aws.ToString(vpcOut.Vpc.VpcId)
+	t.Cleanup(func() {
+		cleanupCtx, cancel := cleanupAPICtx(t)
+		defer cancel()
+		_, _ = e.Client.DeleteVpc(cleanupCtx, &ec2.DeleteVpcInput{VpcId: aws.String(vpcID)})
+	})
+
+	base := netip.MustParsePrefix(cidrBlock).Addr().As4()
+	subnetIDs := make([]string, 0, len(availabilityZones))
+	for i, availabilityZone := range availabilityZones {
+		subnetBase := base
+		subnetBase[2] += byte(i)
+		subnetOut, err := e.Client.CreateSubnet(ctx, &ec2.CreateSubnetInput{
+			VpcId:            aws.String(vpcID),
+			CidrBlock:        aws.String(netip.PrefixFrom(netip.AddrFrom4(subnetBase), 24).String()),
+			AvailabilityZone: aws.String(availabilityZone),
+		})
+		require.NoError(t, err)
+		require.NotNil(t, subnetOut.Subnet)
+		subnetID := aws.ToString(subnetOut.Subnet.SubnetId)
+		// Registered before the VPC cleanup, so it runs first
+		t.Cleanup(func() {
+			cleanupCtx, cancel := cleanupAPICtx(t)
+			defer cancel()
+			_, _ = e.Client.DeleteSubnet(cleanupCtx, &ec2.DeleteSubnetInput{SubnetId: aws.String(subnetID)})
+		})
+		subnetIDs = append(subnetIDs, subnetID)
+	}
+	return vpcID, subnetIDs
+}
+
+func TestVPCAndSubnetLifecycle(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		vpcID, subnetIDs := createTestSubnets(t, ctx, e, "10.20.0.0/16", "us-east-1a", "us-east-1b")
+
+		vpcsOut, err := e.Client.DescribeVpcs(ctx, &ec2.DescribeVpcsInput{VpcIds: []string{vpcID}})
+		require.NoError(t, err)
+		require.Len(t, vpcsOut.Vpcs, 1)
+		assert.Equal(t, "10.20.0.0/16", aws.ToString(vpcsOut.Vpcs[0].CidrBlock))
+		assert.Equal(t, ec2types.VpcStateAvailable, vpcsOut.Vpcs[0].State)
+		assert.False(t, aws.ToBool(vpcsOut.Vpcs[0].IsDefault))
+
+		subnetsOut, err := e.Client.DescribeSubnets(ctx, &ec2.DescribeSubnetsInput{
+			Filters: []ec2types.Filter{{Name: aws.String("vpc-id"), Values: []string{vpcID}}},
+		})
+		require.NoError(t, err)
+		require.Len(t, subnetsOut.Subnets, 2)
+		zones := map[string]string{}
+		for _, subnet := range subnetsOut.Subnets {
+			zones[aws.ToString(subnet.SubnetId)] = aws.ToString(subnet.AvailabilityZone)
+		}
+		assert.Equal(t, map[string]string{subnetIDs[0]: "us-east-1a", subnetIDs[1]: "us-east-1b"}, zones)
+
+		var apiErr smithy.APIError
+		_, err = e.Client.CreateSubnet(ctx, &ec2.CreateSubnetInput{
+			VpcId:     aws.String(vpcID),
+			CidrBlock: aws.String("10.20.0.0/25"),
+		})
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "InvalidSubnet.Conflict", apiErr.ErrorCode())
+
+		_, err = e.Client.DeleteVpc(ctx, &ec2.DeleteVpcInput{VpcId: aws.String(vpcID)})
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "DependencyViolation", apiErr.ErrorCode())
+	})
+}
+
+func TestAutoScalingGroupSpansSubnets(t *testing.T) {
+	t.Parallel()
+	testWithServer(t, func(t *testing.T, ctx context.Context, e *TestEnvironment) {
+		launchTemplateName := fmt.Sprintf("lt-asg-subnets-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+		autoScalingGroupName := fmt.Sprintf("asg-subnets-%s", strings.ReplaceAll(t.Name(), "/", "-"))
+		_, subnetIDs := createTestSubnets(t, ctx, e, "10.21.0.0/16", "us-east-1a", "us-east-1b")
+
+		lt, err := e.Client.CreateLaunchTemplate(ctx, &ec2.CreateLaunchTemplateInput{
+			LaunchTemplateName: aws.String(launchTemplateName),
+			LaunchTemplateData: &ec2types.RequestLaunchTemplateData{
+				ImageId:      aws.String("nginx"),
+				InstanceType: ec2types.InstanceTypeA1Large,
+			},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, lt.LaunchTemplate)
+
+		var apiErr smithy.APIError
+		_, err = e.AutoScalingClient.CreateAutoScalingGroup(ctx, &autoscaling.CreateAutoScalingGroupInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			MinSize:              aws.Int32(0),
+			MaxSize:              aws.Int32(2),
+			VPCZoneIdentifier:    aws.String("subnet-0123456789abcdef0"),
+			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
+				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
+			},
+		})
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "ValidationError", apiErr.ErrorCode())
+
+		_, err = e.AutoScalingClient.CreateAutoScalingGroup(ctx, &autoscaling.CreateAutoScalingGroupInput{
+			AutoScalingGroupName: aws.String(autoScalingGroupName),
+			MinSize:              aws.Int32(2),
+			MaxSize:              aws.Int32(2),
+			DesiredCapacity:      aws.Int32(2),
+			VPCZoneIdentifier:    aws.String(strings.Join(subnetIDs, ",")),
+			LaunchTemplate: &autoscalingtypes.LaunchTemplateSpecification{
+				LaunchTemplateId: lt.LaunchTemplate.LaunchTemplateId,
+			},
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			cleanupAutoScalingGroup(t, e, autoScalingGroupName)
+		})
+
+		var group autoscalingtypes.AutoScalingGroup
+		require.Eventually(t, func() bool {
+			out, err := e.AutoScalingClient.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
+				AutoScalingGroupNames: []string{autoScalingGroupName},
+			})
+			if err != nil || len(out.AutoScalingGroups) != 1 {
+				return false
+			}
+			group = out.AutoScalingGroups[0]
+			return len(group.Instances) == 2
+		}, 30*time.Second, 250*time.Millisecond)
+
+		instanceIDs := make([]string, 0, len(group.Instances))
+		for _, instance := range group.Instances {
+			instanceIDs = append(instanceIDs, aws.ToString(instance.InstanceId))
+		}
+		describeOut, err := e.Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{InstanceIds: instanceIDs})
+		require.NoError(t, err)
+		zones := map[string]string{}
+		for _, reservation := range describeOut.Reservations {
+			for _, instance := range reservation.Instances {
+				zones[aws.ToString(instance.SubnetId)] = aws.ToString(instance.Placement.AvailabilityZone)
+			}
+		}
+		assert.Equal(t, map[string]string{subnetIDs[0]: "us-east-1a", subnetIDs[1]: "us-east-1b"}, zones)
+	})
+}
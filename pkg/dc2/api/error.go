@@ -6,10 +6,14 @@ import (
 )
 
 const (
-	ErrorCodeInvalidAction         = "InvalidAction"
-	ErrorCodeInstanceNotFound      = "InvalidInstanceID.NotFound"
-	ErrorCodeDryRunOperation       = "DryRunOperation"
-	ErrorCodeInvalidParameterValue = "InvalidParameterValue"
+	ErrorCodeInvalidAction          = "InvalidAction"
+	ErrorCodeInstanceNotFound       = "InvalidInstanceID.NotFound"
+	ErrorCodeDryRunOperation        = "DryRunOperation"
+	ErrorCodeInvalidParameterValue  = "InvalidParameterValue"
+	ErrorCodeIncorrectInstanceState = "IncorrectInstanceState"
+	ErrorCodeValidationError        = "ValidationError"
+	ErrorCodeOperationNotPermitted  = "OperationNotPermitted"
+	ErrorCodeVolumeInUse            = "VolumeInUse"
 
 	// Custom errors
 	ErrorCodeMethodNotAllowed = "MethodNotAllowed"
@@ -18,7 +22,9 @@ const (
 
 type Error struct {
 	Code string
-	Err  error
+	// Parameter is the request parameter that failed validation, if any
+	Parameter string
+	Err       error
 }
 
 func (e *Error) Unwrap() error {
@@ -42,7 +48,21 @@ func ErrWithCode(code string, err error) *Error {
 func InvalidParameterValueError(param string, value string) *Error {
 	//nolint
 	err := fmt.Errorf("Value (%s) for parameter %s is invalid.", value, param)
-	return ErrWithCode(ErrorCodeInvalidParameterValue, err)
+	return &Error{
+		Code:      ErrorCodeInvalidParameterValue,
+		Parameter: param,
+		Err:       err,
+	}
+}
+
+// ValidationError returns a ValidationError for the given request parameter,
+// which should also be named in the message, like AWS does.
+func ValidationError(param string, format string, args ...any) *Error {
+	return &Error{
+		Code:      ErrorCodeValidationError,
+		Parameter: param,
+		Err:       fmt.Errorf(format, args...),
+	}
 }
 
 func DryRunError() *Error {
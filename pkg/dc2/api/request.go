@@ -8,6 +8,7 @@ const (
 	ActionRunInstances Action = iota + 1
 	ActionDescribeInstances
 	ActionDescribeSpotInstanceRequests
+	ActionCancelSpotInstanceRequests
 	ActionDescribeInstanceStatus
 	ActionDescribeSecurityGroups
 	ActionCreateSecurityGroup
@@ -18,13 +19,16 @@ const (
 	ActionStopInstances
 	ActionStartInstances
 	ActionTerminateInstances
+	ActionRebootInstances
 	ActionModifyInstanceMetadataOptions
+	ActionModifyInstanceAttribute
 	ActionDescribeInstanceTypes
 	ActionDescribeInstanceTypeOfferings
 	ActionGetInstanceTypesFromInstanceRequirements
 	ActionCreateFleet
 	ActionCreateTags
 	ActionDeleteTags
+	ActionDescribeTags
 	ActionCreateVolume
 	ActionDeleteVolume
 	ActionAttachVolume
@@ -36,6 +40,10 @@ const (
 	ActionCreateLaunchTemplateVersion
 	ActionDescribeLaunchTemplateVersions
 	ActionModifyLaunchTemplate
+	ActionCreateKeyPair
+	ActionImportKeyPair
+	ActionDescribeKeyPairs
+	ActionDeleteKeyPair
 	ActionCreateAutoScalingGroup
 	ActionDescribeAutoScalingGroups
 	ActionLaunchInstances
@@ -47,6 +55,64 @@ const (
 	ActionPutWarmPool
 	ActionDescribeWarmPool
 	ActionDeleteWarmPool
+	ActionPutLifecycleHook
+	ActionDescribeLifecycleHooks
+	ActionDeleteLifecycleHook
+	ActionCompleteLifecycleAction
+	ActionRecordLifecycleActionHeartbeat
+	ActionPutScheduledUpdateGroupAction
+	ActionDescribeScheduledActions
+	ActionDeleteScheduledAction
+	ActionSetInstanceHealth
+	ActionTerminateInstanceInAutoScalingGroup
+	ActionAttachInstances
+	ActionEnterStandby
+	ActionExitStandby
+	ActionDescribeLifecycleHookTypes
+	ActionDescribeInstanceCreditSpecifications
+	ActionModifyInstanceCreditSpecification
+	ActionGetConsoleOutput
+	ActionCreateSnapshot
+	ActionDeleteSnapshot
+	ActionDescribeSnapshots
+	ActionModifyVolume
+	ActionDescribeVolumesModifications
+	ActionRevokeSecurityGroupIngress
+	ActionRevokeSecurityGroupEgress
+	ActionCreatePlacementGroup
+	ActionDescribePlacementGroups
+	ActionDeletePlacementGroup
+	ActionDescribeInstanceAttribute
+	ActionRequestSpotInstances
+	ActionAssociateIamInstanceProfile
+	ActionDisassociateIamInstanceProfile
+	ActionMonitorInstances
+	ActionUnmonitorInstances
+	ActionCreateImage
+	ActionDescribeImages
+	ActionDescribeScalingActivities
+	ActionPutScalingPolicy
+	ActionDescribePolicies
+	ActionDeletePolicy
+	ActionExecutePolicy
+	ActionDescribeAutoScalingInstances
+	ActionSetInstanceProtection
+	ActionAllocateAddress
+	ActionAssociateAddress
+	ActionDisassociateAddress
+	ActionReleaseAddress
+	ActionDescribeAddresses
+	ActionCreateVpc
+	ActionDescribeVpcs
+	ActionDeleteVpc
+	ActionCreateSubnet
+	ActionDeleteSubnet
+	ActionDescribeNetworkInterfaces
+	ActionAssignPrivateIPAddresses
+	ActionUnassignPrivateIPAddresses
+	ActionCopyImage
+	ActionCopySnapshot
+	ActionGetPasswordData
 )
 
 type Request interface {
@@ -86,6 +152,15 @@ type DeleteTagsRequest struct {
 
 func (r DeleteTagsRequest) Action() Action { return ActionDeleteTags }
 
+type DescribeTagsRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	PaginableRequest
+	Filters []Filter `url:"Filter"`
+}
+
+func (r DescribeTagsRequest) Action() Action { return ActionDescribeTags }
+
 type TagSpecification struct {
 	ResourceType types.ResourceType `url:"ResourceType" validate:"required"`
 	Tags         []Tag              `url:"Tag"`
@@ -0,0 +1,50 @@
+package api
+
+type AllocateAddressRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	Domain            *string            `url:"Domain"`
+	Address           *string            `url:"Address"`
+	TagSpecifications []TagSpecification `url:"TagSpecification"`
+}
+
+func (r AllocateAddressRequest) Action() Action { return ActionAllocateAddress }
+
+type AssociateAddressRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	AllocationID       *string `url:"AllocationId"`
+	PublicIP           *string `url:"PublicIp"`
+	InstanceID         *string `url:"InstanceId"`
+	AllowReassociation bool    `url:"AllowReassociation"`
+}
+
+func (r AssociateAddressRequest) Action() Action { return ActionAssociateAddress }
+
+type DisassociateAddressRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	AssociationID *string `url:"AssociationId"`
+	PublicIP      *string `url:"PublicIp"`
+}
+
+func (r DisassociateAddressRequest) Action() Action { return ActionDisassociateAddress }
+
+type ReleaseAddressRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	AllocationID *string `url:"AllocationId"`
+	PublicIP     *string `url:"PublicIp"`
+}
+
+func (r ReleaseAddressRequest) Action() Action { return ActionReleaseAddress }
+
+type DescribeAddressesRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	AllocationIDs []string `url:"AllocationId"`
+	PublicIPs     []string `url:"PublicIp"`
+	Filters       []Filter `url:"Filter"`
+}
+
+func (r DescribeAddressesRequest) Action() Action { return ActionDescribeAddresses }
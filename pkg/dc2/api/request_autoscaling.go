@@ -1,5 +1,7 @@
 package api
 
+import "time"
+
 type AutoScalingLaunchTemplateSpecification struct {
 	LaunchTemplateID   *string `url:"LaunchTemplateId" xml:"LaunchTemplateId"`
 	LaunchTemplateName *string `url:"LaunchTemplateName" xml:"LaunchTemplateName"`
@@ -35,15 +37,22 @@ type AutoScalingMixedInstancesPolicy struct {
 
 type CreateAutoScalingGroupRequest struct {
 	CommonRequest
-	AutoScalingGroupName string                                  `url:"AutoScalingGroupName" validate:"required"`
-	MinSize              *int                                    `url:"MinSize" validate:"required,gte=0"`
-	MaxSize              *int                                    `url:"MaxSize" validate:"required,gte=0"`
-	DesiredCapacity      *int                                    `url:"DesiredCapacity"`
-	LaunchTemplate       *AutoScalingLaunchTemplateSpecification `url:"LaunchTemplate"`
-	MixedInstancesPolicy *AutoScalingMixedInstancesPolicy        `url:"MixedInstancesPolicy"`
-	Tags                 []AutoScalingTag                        `url:"Tags"`
-	AvailabilityZones    []string                                `url:"AvailabilityZones"`
-	VPCZoneIdentifier    *string                                 `url:"VPCZoneIdentifier"`
+	AutoScalingGroupName             string                                  `url:"AutoScalingGroupName" validate:"required"`
+	MinSize                          *int                                    `url:"MinSize" validate:"required,gte=0"`
+	MaxSize                          *int                                    `url:"MaxSize" validate:"required,gte=0"`
+	DesiredCapacity                  *int                                    `url:"DesiredCapacity"`
+	LaunchTemplate                   *AutoScalingLaunchTemplateSpecification `url:"LaunchTemplate"`
+	MixedInstancesPolicy             *AutoScalingMixedInstancesPolicy        `url:"MixedInstancesPolicy"`
+	Tags                             []AutoScalingTag                        `url:"Tags"`
+	AvailabilityZones                []string                                `url:"AvailabilityZones"`
+	VPCZoneIdentifier                *string                                 `url:"VPCZoneIdentifier"`
+	HealthCheckType                  *string                                 `url:"HealthCheckType"`
+	HealthCheckGracePeriod           *int                                    `url:"HealthCheckGracePeriod"`
+	NewInstancesProtectedFromScaleIn *bool                                   `url:"NewInstancesProtectedFromScaleIn"`
+	TargetGroupARNs                  []string                                `url:"TargetGroupARNs"`
+	// ScaleInDrainSeconds is a dc2 extension that sets how long scaled in
+	// instances drain before being terminated.
+	ScaleInDrainSeconds *int `url:"ScaleInDrainSeconds"`
 }
 
 func (r CreateAutoScalingGroupRequest) Action() Action { return ActionCreateAutoScalingGroup }
@@ -78,14 +87,20 @@ type AutoScalingFilter struct {
 
 type UpdateAutoScalingGroupRequest struct {
 	CommonRequest
-	AutoScalingGroupName string                                  `url:"AutoScalingGroupName" validate:"required"`
-	MinSize              *int                                    `url:"MinSize"`
-	MaxSize              *int                                    `url:"MaxSize"`
-	DesiredCapacity      *int                                    `url:"DesiredCapacity"`
-	LaunchTemplate       *AutoScalingLaunchTemplateSpecification `url:"LaunchTemplate"`
-	MixedInstancesPolicy *AutoScalingMixedInstancesPolicy        `url:"MixedInstancesPolicy"`
-	AvailabilityZones    []string                                `url:"AvailabilityZones"`
-	VPCZoneIdentifier    *string                                 `url:"VPCZoneIdentifier"`
+	AutoScalingGroupName             string                                  `url:"AutoScalingGroupName" validate:"required"`
+	MinSize                          *int                                    `url:"MinSize"`
+	MaxSize                          *int                                    `url:"MaxSize"`
+	DesiredCapacity                  *int                                    `url:"DesiredCapacity"`
+	LaunchTemplate                   *AutoScalingLaunchTemplateSpecification `url:"LaunchTemplate"`
+	MixedInstancesPolicy             *AutoScalingMixedInstancesPolicy        `url:"MixedInstancesPolicy"`
+	AvailabilityZones                []string                                `url:"AvailabilityZones"`
+	VPCZoneIdentifier                *string                                 `url:"VPCZoneIdentifier"`
+	HealthCheckType                  *string                                 `url:"HealthCheckType"`
+	HealthCheckGracePeriod           *int                                    `url:"HealthCheckGracePeriod"`
+	NewInstancesProtectedFromScaleIn *bool                                   `url:"NewInstancesProtectedFromScaleIn"`
+	// ScaleInDrainSeconds is a dc2 extension, see
+	// CreateAutoScalingGroupRequest.
+	ScaleInDrainSeconds *int `url:"ScaleInDrainSeconds"`
 }
 
 func (r UpdateAutoScalingGroupRequest) Action() Action { return ActionUpdateAutoScalingGroup }
@@ -108,6 +123,31 @@ type DetachInstancesRequest struct {
 
 func (r DetachInstancesRequest) Action() Action { return ActionDetachInstances }
 
+type AttachInstancesRequest struct {
+	CommonRequest
+	AutoScalingGroupName string   `url:"AutoScalingGroupName" validate:"required"`
+	InstanceIDs          []string `url:"InstanceIds" validate:"required,min=1,dive,required"`
+}
+
+func (r AttachInstancesRequest) Action() Action { return ActionAttachInstances }
+
+type EnterStandbyRequest struct {
+	CommonRequest
+	AutoScalingGroupName           string   `url:"AutoScalingGroupName" validate:"required"`
+	InstanceIDs                    []string `url:"InstanceIds" validate:"required,min=1,dive,required"`
+	ShouldDecrementDesiredCapacity *bool    `url:"ShouldDecrementDesiredCapacity" validate:"required"`
+}
+
+func (r EnterStandbyRequest) Action() Action { return ActionEnterStandby }
+
+type ExitStandbyRequest struct {
+	CommonRequest
+	AutoScalingGroupName string   `url:"AutoScalingGroupName" validate:"required"`
+	InstanceIDs          []string `url:"InstanceIds" validate:"required,min=1,dive,required"`
+}
+
+func (r ExitStandbyRequest) Action() Action { return ActionExitStandby }
+
 type DeleteAutoScalingGroupRequest struct {
 	CommonRequest
 	AutoScalingGroupName string `url:"AutoScalingGroupName" validate:"required"`
@@ -140,6 +180,17 @@ type DescribeWarmPoolRequest struct {
 
 func (r DescribeWarmPoolRequest) Action() Action { return ActionDescribeWarmPool }
 
+type DescribeAutoScalingInstancesRequest struct {
+	CommonRequest
+	InstanceIDs []string `url:"InstanceIds"`
+	MaxRecords  *int     `url:"MaxRecords"`
+	NextToken   *string  `url:"NextToken"`
+}
+
+func (r DescribeAutoScalingInstancesRequest) Action() Action {
+	return ActionDescribeAutoScalingInstances
+}
+
 type DeleteWarmPoolRequest struct {
 	CommonRequest
 	AutoScalingGroupName string `url:"AutoScalingGroupName" validate:"required"`
@@ -148,6 +199,65 @@ type DeleteWarmPoolRequest struct {
 
 func (r DeleteWarmPoolRequest) Action() Action { return ActionDeleteWarmPool }
 
+type PutLifecycleHookRequest struct {
+	CommonRequest
+	AutoScalingGroupName  string  `url:"AutoScalingGroupName" validate:"required"`
+	LifecycleHookName     string  `url:"LifecycleHookName" validate:"required"`
+	LifecycleTransition   *string `url:"LifecycleTransition"`
+	DefaultResult         *string `url:"DefaultResult"`
+	HeartbeatTimeout      *int    `url:"HeartbeatTimeout"`
+	NotificationMetadata  *string `url:"NotificationMetadata"`
+	NotificationTargetARN *string `url:"NotificationTargetARN"`
+	RoleARN               *string `url:"RoleARN"`
+}
+
+func (r PutLifecycleHookRequest) Action() Action { return ActionPutLifecycleHook }
+
+type DescribeLifecycleHooksRequest struct {
+	CommonRequest
+	AutoScalingGroupName string   `url:"AutoScalingGroupName" validate:"required"`
+	LifecycleHookNames   []string `url:"LifecycleHookNames"`
+}
+
+func (r DescribeLifecycleHooksRequest) Action() Action { return ActionDescribeLifecycleHooks }
+
+type DeleteLifecycleHookRequest struct {
+	CommonRequest
+	AutoScalingGroupName string `url:"AutoScalingGroupName" validate:"required"`
+	LifecycleHookName    string `url:"LifecycleHookName" validate:"required"`
+}
+
+func (r DeleteLifecycleHookRequest) Action() Action { return ActionDeleteLifecycleHook }
+
+type DescribeLifecycleHookTypesRequest struct {
+	CommonRequest
+}
+
+func (r DescribeLifecycleHookTypesRequest) Action() Action { return ActionDescribeLifecycleHookTypes }
+
+type CompleteLifecycleActionRequest struct {
+	CommonRequest
+	AutoScalingGroupName  string  `url:"AutoScalingGroupName" validate:"required"`
+	LifecycleHookName     string  `url:"LifecycleHookName" validate:"required"`
+	LifecycleActionResult string  `url:"LifecycleActionResult" validate:"required"`
+	InstanceID            *string `url:"InstanceId"`
+	LifecycleActionToken  *string `url:"LifecycleActionToken"`
+}
+
+func (r CompleteLifecycleActionRequest) Action() Action { return ActionCompleteLifecycleAction }
+
+type RecordLifecycleActionHeartbeatRequest struct {
+	CommonRequest
+	AutoScalingGroupName string  `url:"AutoScalingGroupName" validate:"required"`
+	LifecycleHookName    string  `url:"LifecycleHookName" validate:"required"`
+	InstanceID           *string `url:"InstanceId"`
+	LifecycleActionToken *string `url:"LifecycleActionToken"`
+}
+
+func (r RecordLifecycleActionHeartbeatRequest) Action() Action {
+	return ActionRecordLifecycleActionHeartbeat
+}
+
 type AutoScalingTag struct {
 	Key               *string `url:"Key"`
 	Value             *string `url:"Value"`
@@ -164,3 +274,135 @@ type CreateOrUpdateAutoScalingTagsRequest struct {
 func (r CreateOrUpdateAutoScalingTagsRequest) Action() Action {
 	return ActionCreateOrUpdateAutoScalingTags
 }
+
+type PutScheduledUpdateGroupActionRequest struct {
+	CommonRequest
+	AutoScalingGroupName string     `url:"AutoScalingGroupName" validate:"required"`
+	ScheduledActionName  string     `url:"ScheduledActionName" validate:"required"`
+	StartTime            *time.Time `url:"StartTime"`
+	EndTime              *time.Time `url:"EndTime"`
+	Time                 *time.Time `url:"Time"`
+	Recurrence           *string    `url:"Recurrence"`
+	TimeZone             *string    `url:"TimeZone"`
+	MinSize              *int       `url:"MinSize"`
+	MaxSize              *int       `url:"MaxSize"`
+	DesiredCapacity      *int       `url:"DesiredCapacity"`
+}
+
+func (r PutScheduledUpdateGroupActionRequest) Action() Action {
+	return ActionPutScheduledUpdateGroupAction
+}
+
+type DescribeScheduledActionsRequest struct {
+	CommonRequest
+	AutoScalingGroupName *string    `url:"AutoScalingGroupName"`
+	ScheduledActionNames []string   `url:"ScheduledActionNames"`
+	StartTime            *time.Time `url:"StartTime"`
+	EndTime              *time.Time `url:"EndTime"`
+	MaxRecords           *int       `url:"MaxRecords"`
+	NextToken            *string    `url:"NextToken"`
+}
+
+func (r DescribeScheduledActionsRequest) Action() Action { return ActionDescribeScheduledActions }
+
+type DescribeScalingActivitiesRequest struct {
+	CommonRequest
+	ActivityIDs          []string `url:"ActivityIds"`
+	AutoScalingGroupName *string  `url:"AutoScalingGroupName"`
+	MaxRecords           *int     `url:"MaxRecords"`
+	NextToken            *string  `url:"NextToken"`
+}
+
+func (r DescribeScalingActivitiesRequest) Action() Action { return ActionDescribeScalingActivities }
+
+type DeleteScheduledActionRequest struct {
+	CommonRequest
+	AutoScalingGroupName string `url:"AutoScalingGroupName" validate:"required"`
+	ScheduledActionName  string `url:"ScheduledActionName" validate:"required"`
+}
+
+func (r DeleteScheduledActionRequest) Action() Action { return ActionDeleteScheduledAction }
+
+type PredefinedMetricSpecification struct {
+	PredefinedMetricType string  `url:"PredefinedMetricType" xml:"PredefinedMetricType"`
+	ResourceLabel        *string `url:"ResourceLabel" xml:"ResourceLabel"`
+}
+
+type TargetTrackingConfiguration struct {
+	PredefinedMetricSpecification *PredefinedMetricSpecification `url:"PredefinedMetricSpecification" xml:"PredefinedMetricSpecification"`
+	TargetValue                   *float64                       `url:"TargetValue" xml:"TargetValue"`
+	DisableScaleIn                *bool                          `url:"DisableScaleIn" xml:"DisableScaleIn"`
+}
+
+type PutScalingPolicyRequest struct {
+	CommonRequest
+	AutoScalingGroupName        string                       `url:"AutoScalingGroupName" validate:"required"`
+	PolicyName                  string                       `url:"PolicyName" validate:"required"`
+	PolicyType                  *string                      `url:"PolicyType"`
+	AdjustmentType              *string                      `url:"AdjustmentType"`
+	ScalingAdjustment           *int                         `url:"ScalingAdjustment"`
+	MinAdjustmentMagnitude      *int                         `url:"MinAdjustmentMagnitude"`
+	Cooldown                    *int                         `url:"Cooldown"`
+	TargetTrackingConfiguration *TargetTrackingConfiguration `url:"TargetTrackingConfiguration"`
+	Enabled                     *bool                        `url:"Enabled"`
+}
+
+func (r PutScalingPolicyRequest) Action() Action { return ActionPutScalingPolicy }
+
+type DescribePoliciesRequest struct {
+	CommonRequest
+	AutoScalingGroupName *string  `url:"AutoScalingGroupName"`
+	PolicyNames          []string `url:"PolicyNames"`
+	PolicyTypes          []string `url:"PolicyTypes"`
+	MaxRecords           *int     `url:"MaxRecords"`
+	NextToken            *string  `url:"NextToken"`
+}
+
+func (r DescribePoliciesRequest) Action() Action { return ActionDescribePolicies }
+
+type DeletePolicyRequest struct {
+	CommonRequest
+	AutoScalingGroupName *string `url:"AutoScalingGroupName"`
+	PolicyName           string  `url:"PolicyName" validate:"required"`
+}
+
+func (r DeletePolicyRequest) Action() Action { return ActionDeletePolicy }
+
+type ExecutePolicyRequest struct {
+	CommonRequest
+	AutoScalingGroupName *string  `url:"AutoScalingGroupName"`
+	PolicyName           string   `url:"PolicyName" validate:"required"`
+	HonorCooldown        *bool    `url:"HonorCooldown"`
+	MetricValue          *float64 `url:"MetricValue"`
+	BreachThreshold      *float64 `url:"BreachThreshold"`
+}
+
+func (r ExecutePolicyRequest) Action() Action { return ActionExecutePolicy }
+
+type SetInstanceHealthRequest struct {
+	CommonRequest
+	InstanceID               string `url:"InstanceId" validate:"required"`
+	HealthStatus             string `url:"HealthStatus" validate:"required"`
+	ShouldRespectGracePeriod *bool  `url:"ShouldRespectGracePeriod"`
+}
+
+func (r SetInstanceHealthRequest) Action() Action { return ActionSetInstanceHealth }
+
+type SetInstanceProtectionRequest struct {
+	CommonRequest
+	AutoScalingGroupName string   `url:"AutoScalingGroupName" validate:"required"`
+	InstanceIDs          []string `url:"InstanceIds" validate:"required,min=1,dive,required"`
+	ProtectedFromScaleIn *bool    `url:"ProtectedFromScaleIn" validate:"required"`
+}
+
+func (r SetInstanceProtectionRequest) Action() Action { return ActionSetInstanceProtection }
+
+type TerminateInstanceInAutoScalingGroupRequest struct {
+	CommonRequest
+	InstanceID                     string `url:"InstanceId" validate:"required"`
+	ShouldDecrementDesiredCapacity *bool  `url:"ShouldDecrementDesiredCapacity" validate:"required"`
+}
+
+func (r TerminateInstanceInAutoScalingGroupRequest) Action() Action {
+	return ActionTerminateInstanceInAutoScalingGroup
+}
@@ -0,0 +1,37 @@
+package api
+
+type CreateImageRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	InstanceID        string             `url:"InstanceId" validate:"required"`
+	Name              string             `url:"Name" validate:"required"`
+	Description       string             `url:"Description"`
+	NoReboot          *bool              `url:"NoReboot"`
+	TagSpecifications []TagSpecification `url:"TagSpecification"`
+}
+
+func (r CreateImageRequest) Action() Action { return ActionCreateImage }
+
+type CopyImageRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	SourceImageID     string             `url:"SourceImageId" validate:"required"`
+	SourceRegion      string             `url:"SourceRegion" validate:"required"`
+	Name              string             `url:"Name" validate:"required"`
+	Description       string             `url:"Description"`
+	CopyImageTags     bool               `url:"CopyImageTags"`
+	TagSpecifications []TagSpecification `url:"TagSpecification"`
+}
+
+func (r CopyImageRequest) Action() Action { return ActionCopyImage }
+
+type DescribeImagesRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	PaginableRequest
+	Filters  []Filter `url:"Filter"`
+	ImageIDs []string `url:"ImageId"`
+	Owners   []string `url:"Owner"`
+}
+
+func (r DescribeImagesRequest) Action() Action { return ActionDescribeImages }
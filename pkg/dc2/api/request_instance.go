@@ -4,18 +4,28 @@ import "github.com/fiam/dc2/pkg/dc2/types"
 
 type RunInstancesRequest struct {
 	CommonRequest
+	DryRunnableRequest
 	ImageID               string                                  `url:"ImageId" validate:"required_without=LaunchTemplate"`
 	InstanceType          string                                  `url:"InstanceType" validate:"required_without=LaunchTemplate"`
 	LaunchTemplate        *AutoScalingLaunchTemplateSpecification `url:"LaunchTemplate"`
 	InstanceMarketOptions *RunInstancesInstanceMarketOptions      `url:"InstanceMarketOptions"`
 	SubnetID              string                                  `url:"SubnetId"`
 	KeyName               string                                  `url:"KeyName"`
+	SecurityGroupIDs      []string                                `url:"SecurityGroupId"`
+	SecurityGroups        []string                                `url:"SecurityGroup"`
 	UserData              string                                  `url:"UserData"`
 	MinCount              int                                     `url:"MinCount" validate:"required,gt=0"`
 	MaxCount              int                                     `url:"MaxCount" validate:"required,gt=0"`
 	BlockDeviceMappings   []RunInstancesBlockDeviceMapping        `url:"BlockDeviceMapping"`
 	TagSpecifications     []TagSpecification                      `url:"TagSpecification"`
 	Placement             *Placement                              `url:"Placement"`
+	CreditSpecification   *CreditSpecificationRequest             `url:"CreditSpecification"`
+	IamInstanceProfile    *IamInstanceProfileSpecification        `url:"IamInstanceProfile"`
+	Monitoring            *RunInstancesMonitoring                 `url:"Monitoring"`
+	DisableAPITermination bool                                    `url:"DisableApiTermination"`
+	// Architecture is a dc2 extension that selects the image platform the
+	// instances run (e.g. x86_64 or arm64).
+	Architecture string `url:"Architecture"`
 }
 
 func (r RunInstancesRequest) Action() Action { return ActionRunInstances }
@@ -30,6 +40,21 @@ type RunInstancesSpotOptions struct {
 	InstanceInterruptionBehavior string `url:"InstanceInterruptionBehavior"`
 }
 
+type RunInstancesMonitoring struct {
+	Enabled bool `url:"Enabled"`
+}
+
+type CreditSpecificationRequest struct {
+	CPUCredits string `url:"CpuCredits"`
+}
+
+// IamInstanceProfileSpecification identifies an IAM instance profile by
+// either its ARN or its name.
+type IamInstanceProfileSpecification struct {
+	Arn  string `url:"Arn"`
+	Name string `url:"Name"`
+}
+
 type RunInstancesBlockDeviceMapping struct {
 	DeviceName string                      `url:"DeviceName"`
 	EBS        *RunInstancesEBSBlockDevice `url:"Ebs"`
@@ -54,10 +79,38 @@ type DescribeInstancesRequest struct {
 	CommonRequest
 	Filters     []Filter `url:"Filter"`
 	InstanceIDs []string `url:"InstanceId"`
+	PaginableRequest
 }
 
 func (r DescribeInstancesRequest) Action() Action { return ActionDescribeInstances }
 
+type DescribeInstanceCreditSpecificationsRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	Filters     []Filter `url:"Filter"`
+	InstanceIDs []string `url:"InstanceId"`
+	PaginableRequest
+}
+
+func (r DescribeInstanceCreditSpecificationsRequest) Action() Action {
+	return ActionDescribeInstanceCreditSpecifications
+}
+
+type ModifyInstanceCreditSpecificationRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	InstanceCreditSpecifications []InstanceCreditSpecificationRequest `url:"InstanceCreditSpecification"`
+}
+
+func (r ModifyInstanceCreditSpecificationRequest) Action() Action {
+	return ActionModifyInstanceCreditSpecification
+}
+
+type InstanceCreditSpecificationRequest struct {
+	InstanceID string `url:"InstanceId"`
+	CPUCredits string `url:"CpuCredits"`
+}
+
 type DescribeSpotInstanceRequestsRequest struct {
 	CommonRequest
 	DryRunnableRequest
@@ -70,6 +123,43 @@ func (r DescribeSpotInstanceRequestsRequest) Action() Action {
 	return ActionDescribeSpotInstanceRequests
 }
 
+type CancelSpotInstanceRequestsRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	SpotInstanceRequestIDs []string `url:"SpotInstanceRequestId" validate:"required,min=1"`
+}
+
+func (r CancelSpotInstanceRequestsRequest) Action() Action {
+	return ActionCancelSpotInstanceRequests
+}
+
+type RequestSpotInstancesRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	InstanceCount                *int                            `url:"InstanceCount"`
+	SpotPrice                    string                          `url:"SpotPrice"`
+	Type                         string                          `url:"Type"`
+	InstanceInterruptionBehavior string                          `url:"InstanceInterruptionBehavior"`
+	LaunchSpecification          *RequestSpotLaunchSpecification `url:"LaunchSpecification" validate:"required"`
+	TagSpecifications            []TagSpecification              `url:"TagSpecification"`
+}
+
+func (r RequestSpotInstancesRequest) Action() Action {
+	return ActionRequestSpotInstances
+}
+
+type RequestSpotLaunchSpecification struct {
+	ImageID             string                           `url:"ImageId"`
+	InstanceType        string                           `url:"InstanceType"`
+	KeyName             string                           `url:"KeyName"`
+	SecurityGroupIDs    []string                         `url:"SecurityGroupId"`
+	SecurityGroups      []string                         `url:"SecurityGroup"`
+	UserData            string                           `url:"UserData"`
+	SubnetID            string                           `url:"SubnetId"`
+	Placement           *Placement                       `url:"Placement"`
+	BlockDeviceMappings []RunInstancesBlockDeviceMapping `url:"BlockDeviceMapping"`
+}
+
 type DescribeInstanceStatusRequest struct {
 	CommonRequest
 	Filters             []Filter `url:"Filter"`
@@ -98,6 +188,14 @@ type StartInstancesRequest struct {
 
 func (r StartInstancesRequest) Action() Action { return ActionStartInstances }
 
+type RebootInstancesRequest struct {
+	CommonRequest
+	InstanceIDs []string `url:"InstanceId"`
+	DryRun      bool     `url:"DryRun"`
+}
+
+func (r RebootInstancesRequest) Action() Action { return ActionRebootInstances }
+
 type TerminateInstancesRequest struct {
 	CommonRequest
 	DryRunnableRequest
@@ -108,13 +206,99 @@ type TerminateInstancesRequest struct {
 
 func (r TerminateInstancesRequest) Action() Action { return ActionTerminateInstances }
 
+type GetConsoleOutputRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	InstanceID string `url:"InstanceId" validate:"required"`
+	Latest     *bool  `url:"Latest"`
+}
+
+func (r GetConsoleOutputRequest) Action() Action { return ActionGetConsoleOutput }
+
+type GetPasswordDataRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	InstanceID string `url:"InstanceId" validate:"required"`
+}
+
+func (r GetPasswordDataRequest) Action() Action { return ActionGetPasswordData }
+
 type ModifyInstanceMetadataOptionsRequest struct {
 	CommonRequest
 	DryRunnableRequest
-	InstanceID   string  `url:"InstanceId" validate:"required"`
-	HTTPEndpoint *string `url:"HttpEndpoint"`
+	InstanceID              string  `url:"InstanceId" validate:"required"`
+	HTTPEndpoint            *string `url:"HttpEndpoint"`
+	HTTPTokens              *string `url:"HttpTokens"`
+	HTTPPutResponseHopLimit *int    `url:"HttpPutResponseHopLimit"`
 }
 
 func (r ModifyInstanceMetadataOptionsRequest) Action() Action {
 	return ActionModifyInstanceMetadataOptions
 }
+
+type AttributeValue struct {
+	Value *string `url:"Value"`
+}
+
+// ModifyInstanceAttributeRequest accepts either the Attribute/Value pair or
+// one of the per-attribute parameters, like EC2 does.
+type ModifyInstanceAttributeRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	InstanceID            string          `url:"InstanceId" validate:"required"`
+	Attribute             *string         `url:"Attribute"`
+	Value                 *string         `url:"Value"`
+	InstanceType          *AttributeValue `url:"InstanceType"`
+	UserData              *AttributeValue `url:"UserData"`
+	DisableAPITermination *AttributeValue `url:"DisableApiTermination"`
+}
+
+func (r ModifyInstanceAttributeRequest) Action() Action {
+	return ActionModifyInstanceAttribute
+}
+
+type DescribeInstanceAttributeRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	InstanceID string `url:"InstanceId" validate:"required"`
+	Attribute  string `url:"Attribute" validate:"required"`
+}
+
+func (r DescribeInstanceAttributeRequest) Action() Action {
+	return ActionDescribeInstanceAttribute
+}
+
+type AssociateIamInstanceProfileRequest struct {
+	CommonRequest
+	IamInstanceProfile IamInstanceProfileSpecification `url:"IamInstanceProfile"`
+	InstanceID         string                          `url:"InstanceId" validate:"required"`
+}
+
+func (r AssociateIamInstanceProfileRequest) Action() Action {
+	return ActionAssociateIamInstanceProfile
+}
+
+type DisassociateIamInstanceProfileRequest struct {
+	CommonRequest
+	AssociationID string `url:"AssociationId" validate:"required"`
+}
+
+func (r DisassociateIamInstanceProfileRequest) Action() Action {
+	return ActionDisassociateIamInstanceProfile
+}
+
+type MonitorInstancesRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	InstanceIDs []string `url:"InstanceId" validate:"required"`
+}
+
+func (r MonitorInstancesRequest) Action() Action { return ActionMonitorInstances }
+
+type UnmonitorInstancesRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	InstanceIDs []string `url:"InstanceId" validate:"required"`
+}
+
+func (r UnmonitorInstancesRequest) Action() Action { return ActionUnmonitorInstances }
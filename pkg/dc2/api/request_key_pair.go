@@ -0,0 +1,42 @@
+package api
+
+type CreateKeyPairRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	KeyName           string             `url:"KeyName" validate:"required"`
+	KeyType           *string            `url:"KeyType"`
+	KeyFormat         *string            `url:"KeyFormat"`
+	TagSpecifications []TagSpecification `url:"TagSpecification"`
+}
+
+func (r CreateKeyPairRequest) Action() Action { return ActionCreateKeyPair }
+
+type ImportKeyPairRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	KeyName           string             `url:"KeyName" validate:"required"`
+	PublicKeyMaterial string             `url:"PublicKeyMaterial" validate:"required"`
+	TagSpecifications []TagSpecification `url:"TagSpecification"`
+}
+
+func (r ImportKeyPairRequest) Action() Action { return ActionImportKeyPair }
+
+type DescribeKeyPairsRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	KeyNames         []string `url:"KeyName"`
+	KeyPairIDs       []string `url:"KeyPairId"`
+	Filters          []Filter `url:"Filter"`
+	IncludePublicKey *bool    `url:"IncludePublicKey"`
+}
+
+func (r DescribeKeyPairsRequest) Action() Action { return ActionDescribeKeyPairs }
+
+type DeleteKeyPairRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	KeyName   *string `url:"KeyName"`
+	KeyPairID *string `url:"KeyPairId"`
+}
+
+func (r DeleteKeyPairRequest) Action() Action { return ActionDeleteKeyPair }
@@ -5,9 +5,19 @@ type LaunchTemplateData struct {
 	InstanceRequirements *InstanceRequirementsRequest     `url:"InstanceRequirements"`
 	InstanceType         string                           `url:"InstanceType"`
 	UserData             string                           `url:"UserData"`
+	KeyName              string                           `url:"KeyName"`
 	SecurityGroupIDs     []string                         `url:"SecurityGroupId"`
+	IamInstanceProfile   *IamInstanceProfileSpecification `url:"IamInstanceProfile"`
+	Placement            *LaunchTemplatePlacement         `url:"Placement"`
 	BlockDeviceMappings  []RunInstancesBlockDeviceMapping `url:"BlockDeviceMapping"`
 	TagSpecifications    []TagSpecification               `url:"TagSpecification"`
+	// Architecture is a dc2 extension, see RunInstancesRequest.
+	Architecture string `url:"Architecture"`
+}
+
+type LaunchTemplatePlacement struct {
+	AvailabilityZone string `url:"AvailabilityZone"`
+	GroupName        string `url:"GroupName"`
 }
 
 type CreateLaunchTemplateRequest struct {
@@ -59,6 +69,7 @@ type DescribeLaunchTemplateVersionsRequest struct {
 	MinVersion         *string  `url:"MinVersion"`
 	MaxVersion         *string  `url:"MaxVersion"`
 	Versions           []string `url:"LaunchTemplateVersion"`
+	Filters            []Filter `url:"Filter"`
 }
 
 func (r DescribeLaunchTemplateVersionsRequest) Action() Action {
@@ -71,6 +82,10 @@ type ModifyLaunchTemplateRequest struct {
 	LaunchTemplateID   *string `url:"LaunchTemplateId"`
 	LaunchTemplateName *string `url:"LaunchTemplateName"`
 	SetDefaultVersion  *string `url:"SetDefaultVersion"`
+	// VersionDescription and NewLaunchTemplateName are dc2 extensions, EC2
+	// doesn't allow changing them after creation.
+	VersionDescription    *string `url:"VersionDescription"`
+	NewLaunchTemplateName *string `url:"NewLaunchTemplateName"`
 }
 
 func (r ModifyLaunchTemplateRequest) Action() Action { return ActionModifyLaunchTemplate }
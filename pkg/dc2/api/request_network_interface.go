@@ -0,0 +1,29 @@
+package api
+
+type DescribeNetworkInterfacesRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	NetworkInterfaceIDs []string `url:"NetworkInterfaceId"`
+	Filters             []Filter `url:"Filter"`
+	PaginableRequest
+}
+
+func (r DescribeNetworkInterfacesRequest) Action() Action { return ActionDescribeNetworkInterfaces }
+
+type AssignPrivateIPAddressesRequest struct {
+	CommonRequest
+	NetworkInterfaceID             string   `url:"NetworkInterfaceId" validate:"required"`
+	PrivateIPAddresses             []string `url:"PrivateIpAddress"`
+	SecondaryPrivateIPAddressCount *int     `url:"SecondaryPrivateIpAddressCount"`
+	AllowReassignment              bool     `url:"AllowReassignment"`
+}
+
+func (r AssignPrivateIPAddressesRequest) Action() Action { return ActionAssignPrivateIPAddresses }
+
+type UnassignPrivateIPAddressesRequest struct {
+	CommonRequest
+	NetworkInterfaceID string   `url:"NetworkInterfaceId" validate:"required"`
+	PrivateIPAddresses []string `url:"PrivateIpAddress"`
+}
+
+func (r UnassignPrivateIPAddressesRequest) Action() Action { return ActionUnassignPrivateIPAddresses }
@@ -0,0 +1,31 @@
+package api
+
+type CreatePlacementGroupRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	GroupName         string             `url:"GroupName" validate:"required"`
+	Strategy          string             `url:"Strategy"`
+	PartitionCount    *int               `url:"PartitionCount"`
+	SpreadLevel       *string            `url:"SpreadLevel"`
+	TagSpecifications []TagSpecification `url:"TagSpecification"`
+}
+
+func (r CreatePlacementGroupRequest) Action() Action { return ActionCreatePlacementGroup }
+
+type DescribePlacementGroupsRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	GroupNames []string `url:"GroupName"`
+	GroupIDs   []string `url:"GroupId"`
+	Filters    []Filter `url:"Filter"`
+}
+
+func (r DescribePlacementGroupsRequest) Action() Action { return ActionDescribePlacementGroups }
+
+type DeletePlacementGroupRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	GroupName string `url:"GroupName" validate:"required"`
+}
+
+func (r DeletePlacementGroupRequest) Action() Action { return ActionDeletePlacementGroup }
@@ -31,38 +31,38 @@ type DeleteSecurityGroupRequest struct {
 func (r DeleteSecurityGroupRequest) Action() Action { return ActionDeleteSecurityGroup }
 
 type SecurityGroupIPPermission struct {
-	IPProtocol       *string                    `url:"IpProtocol"`
-	FromPort         *int                       `url:"FromPort"`
-	ToPort           *int                       `url:"ToPort"`
-	IPRanges         []SecurityGroupIPRange     `url:"IpRanges"`
-	IPv6Ranges       []SecurityGroupIPv6Range   `url:"Ipv6Ranges"`
-	PrefixListIDs    []SecurityGroupPrefixList  `url:"PrefixListIds"`
-	UserIDGroupPairs []SecurityGroupUserIDGroup `url:"UserIdGroupPairs"`
+	IPProtocol       *string                    `url:"IpProtocol" xml:"ipProtocol"`
+	FromPort         *int                       `url:"FromPort" xml:"fromPort"`
+	ToPort           *int                       `url:"ToPort" xml:"toPort"`
+	IPRanges         []SecurityGroupIPRange     `url:"IpRanges" xml:"ipRanges>item"`
+	IPv6Ranges       []SecurityGroupIPv6Range   `url:"Ipv6Ranges" xml:"ipv6Ranges>item"`
+	PrefixListIDs    []SecurityGroupPrefixList  `url:"PrefixListIds" xml:"prefixListIds>item"`
+	UserIDGroupPairs []SecurityGroupUserIDGroup `url:"UserIdGroupPairs" xml:"groups>item"`
 }
 
 type SecurityGroupIPRange struct {
-	CIDRIP      *string `url:"CidrIp"`
-	Description *string `url:"Description"`
+	CIDRIP      *string `url:"CidrIp" xml:"cidrIp"`
+	Description *string `url:"Description" xml:"description"`
 }
 
 type SecurityGroupIPv6Range struct {
-	CIDRIPv6    *string `url:"CidrIpv6"`
-	Description *string `url:"Description"`
+	CIDRIPv6    *string `url:"CidrIpv6" xml:"cidrIpv6"`
+	Description *string `url:"Description" xml:"description"`
 }
 
 type SecurityGroupPrefixList struct {
-	PrefixListID *string `url:"PrefixListId"`
-	Description  *string `url:"Description"`
+	PrefixListID *string `url:"PrefixListId" xml:"prefixListId"`
+	Description  *string `url:"Description" xml:"description"`
 }
 
 type SecurityGroupUserIDGroup struct {
-	Description            *string `url:"Description"`
-	GroupID                *string `url:"GroupId"`
-	GroupName              *string `url:"GroupName"`
-	PeeringStatus          *string `url:"PeeringStatus"`
-	UserID                 *string `url:"UserId"`
-	VPCID                  *string `url:"VpcId"`
-	VPCPeeringConnectionID *string `url:"VpcPeeringConnectionId"`
+	Description            *string `url:"Description" xml:"description"`
+	GroupID                *string `url:"GroupId" xml:"groupId"`
+	GroupName              *string `url:"GroupName" xml:"groupName"`
+	PeeringStatus          *string `url:"PeeringStatus" xml:"peeringStatus"`
+	UserID                 *string `url:"UserId" xml:"userId"`
+	VPCID                  *string `url:"VpcId" xml:"vpcId"`
+	VPCPeeringConnectionID *string `url:"VpcPeeringConnectionId" xml:"vpcPeeringConnectionId"`
 }
 
 type AuthorizeSecurityGroupIngressRequest struct {
@@ -96,3 +96,35 @@ type AuthorizeSecurityGroupEgressRequest struct {
 func (r AuthorizeSecurityGroupEgressRequest) Action() Action {
 	return ActionAuthorizeSecurityGroupEgress
 }
+
+type RevokeSecurityGroupIngressRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	GroupID       *string                     `url:"GroupId"`
+	GroupName     *string                     `url:"GroupName"`
+	CIDRIP        *string                     `url:"CidrIp"`
+	IPProtocol    *string                     `url:"IpProtocol"`
+	FromPort      *int                        `url:"FromPort"`
+	ToPort        *int                        `url:"ToPort"`
+	IPPermissions []SecurityGroupIPPermission `url:"IpPermissions"`
+}
+
+func (r RevokeSecurityGroupIngressRequest) Action() Action {
+	return ActionRevokeSecurityGroupIngress
+}
+
+type RevokeSecurityGroupEgressRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	GroupID       *string                     `url:"GroupId"`
+	GroupName     *string                     `url:"GroupName"`
+	CIDRIP        *string                     `url:"CidrIp"`
+	IPProtocol    *string                     `url:"IpProtocol"`
+	FromPort      *int                        `url:"FromPort"`
+	ToPort        *int                        `url:"ToPort"`
+	IPPermissions []SecurityGroupIPPermission `url:"IpPermissions"`
+}
+
+func (r RevokeSecurityGroupEgressRequest) Action() Action {
+	return ActionRevokeSecurityGroupEgress
+}
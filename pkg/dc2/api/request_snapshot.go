@@ -0,0 +1,42 @@
+package api
+
+type CreateSnapshotRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	Description       string             `url:"Description"`
+	VolumeID          string             `url:"VolumeId" validate:"required"`
+	TagSpecifications []TagSpecification `url:"TagSpecification"`
+}
+
+func (r CreateSnapshotRequest) Action() Action { return ActionCreateSnapshot }
+
+type CopySnapshotRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	SourceSnapshotID  string             `url:"SourceSnapshotId" validate:"required"`
+	SourceRegion      string             `url:"SourceRegion" validate:"required"`
+	DestinationRegion string             `url:"DestinationRegion"`
+	Description       string             `url:"Description"`
+	Encrypted         *bool              `url:"Encrypted"`
+	TagSpecifications []TagSpecification `url:"TagSpecification"`
+}
+
+func (r CopySnapshotRequest) Action() Action { return ActionCopySnapshot }
+
+type DeleteSnapshotRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	SnapshotID string `url:"SnapshotId" validate:"required"`
+}
+
+func (r DeleteSnapshotRequest) Action() Action { return ActionDeleteSnapshot }
+
+type DescribeSnapshotsRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	PaginableRequest
+	Filters     []Filter `url:"Filter"`
+	SnapshotIDs []string `url:"SnapshotId"`
+}
+
+func (r DescribeSnapshotsRequest) Action() Action { return ActionDescribeSnapshots }
@@ -9,3 +9,23 @@ type DescribeSubnetsRequest struct {
 }
 
 func (r DescribeSubnetsRequest) Action() Action { return ActionDescribeSubnets }
+
+type CreateSubnetRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	VPCID              string             `url:"VpcId" validate:"required"`
+	CIDRBlock          string             `url:"CidrBlock" validate:"required"`
+	AvailabilityZone   *string            `url:"AvailabilityZone"`
+	AvailabilityZoneID *string            `url:"AvailabilityZoneId"`
+	TagSpecifications  []TagSpecification `url:"TagSpecification"`
+}
+
+func (r CreateSubnetRequest) Action() Action { return ActionCreateSubnet }
+
+type DeleteSubnetRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	SubnetID string `url:"SubnetId" validate:"required"`
+}
+
+func (r DeleteSubnetRequest) Action() Action { return ActionDeleteSubnet }
@@ -11,7 +11,7 @@ type CreateVolumeRequest struct {
 	KmsKeyID           *string `url:"KmsKeyId"`
 	MultiAttachEnabled *bool
 	OutpostArn         *string
-	Size               *int               `url:"Size" validate:"required"`
+	Size               *int               `url:"Size" validate:"required_without=SnapshotID"`
 	SnapshotID         string             `url:"SnapshotId"`
 	TagSpecifications  []TagSpecification `url:"TagSpecification"`
 	Throughput         *int               `url:"Throughput"`
@@ -57,3 +57,27 @@ type DescribeVolumesRequest struct {
 }
 
 func (r DescribeVolumesRequest) Action() Action { return ActionDescribeVolumes }
+
+type ModifyVolumeRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	VolumeID   string           `url:"VolumeId" validate:"required"`
+	Size       *int             `url:"Size"`
+	VolumeType types.VolumeType `url:"VolumeType"`
+	Iops       *int             `url:"Iops"`
+	Throughput *int             `url:"Throughput"`
+}
+
+func (r ModifyVolumeRequest) Action() Action { return ActionModifyVolume }
+
+type DescribeVolumesModificationsRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	PaginableRequest
+	Filters   []Filter `url:"Filter"`
+	VolumeIDs []string `url:"VolumeId"`
+}
+
+func (r DescribeVolumesModificationsRequest) Action() Action {
+	return ActionDescribeVolumesModifications
+}
@@ -0,0 +1,29 @@
+package api
+
+type CreateVpcRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	CIDRBlock         string             `url:"CidrBlock" validate:"required"`
+	InstanceTenancy   *string            `url:"InstanceTenancy"`
+	TagSpecifications []TagSpecification `url:"TagSpecification"`
+}
+
+func (r CreateVpcRequest) Action() Action { return ActionCreateVpc }
+
+type DescribeVpcsRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	VPCIDs  []string `url:"VpcId"`
+	Filters []Filter `url:"Filter"`
+	PaginableRequest
+}
+
+func (r DescribeVpcsRequest) Action() Action { return ActionDescribeVpcs }
+
+type DeleteVpcRequest struct {
+	CommonRequest
+	DryRunnableRequest
+	VPCID string `url:"VpcId" validate:"required"`
+}
+
+func (r DeleteVpcRequest) Action() Action { return ActionDeleteVpc }
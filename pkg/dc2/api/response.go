@@ -1,5 +1,7 @@
 package api
 
+import "github.com/fiam/dc2/pkg/dc2/types"
+
 type Response any
 
 // Tag represents a key-value tag pair
@@ -13,3 +15,16 @@ type CreateTagsResponse struct {
 
 type DeleteTagsResponse struct {
 }
+
+type DescribeTagsResponse struct {
+	Tags      []TagDescription `xml:"tagSet>item"`
+	NextToken *string          `xml:"nextToken"`
+}
+
+// TagDescription describes a tag attached to a resource
+type TagDescription struct {
+	Key          string             `xml:"key"`
+	ResourceID   string             `xml:"resourceId"`
+	ResourceType types.ResourceType `xml:"resourceType"`
+	Value        string             `xml:"value"`
+}
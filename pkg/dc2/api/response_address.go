@@ -0,0 +1,39 @@
+package api
+
+type AllocateAddressResponse struct {
+	PublicIP           string `xml:"publicIp"`
+	AllocationID       string `xml:"allocationId"`
+	Domain             string `xml:"domain"`
+	PublicIPv4Pool     string `xml:"publicIpv4Pool"`
+	NetworkBorderGroup string `xml:"networkBorderGroup"`
+}
+
+type AssociateAddressResponse struct {
+	AssociationID string `xml:"associationId"`
+	Return        bool   `xml:"return"`
+}
+
+type DisassociateAddressResponse struct {
+	Return bool `xml:"return"`
+}
+
+type ReleaseAddressResponse struct {
+	Return bool `xml:"return"`
+}
+
+type DescribeAddressesResponse struct {
+	Addresses []Address `xml:"addressesSet>item"`
+}
+
+type Address struct {
+	PublicIP           string  `xml:"publicIp"`
+	AllocationID       string  `xml:"allocationId"`
+	Domain             string  `xml:"domain"`
+	InstanceID         *string `xml:"instanceId"`
+	AssociationID      *string `xml:"associationId"`
+	NetworkInterfaceID *string `xml:"networkInterfaceId"`
+	PrivateIPAddress   *string `xml:"privateIpAddress"`
+	PublicIPv4Pool     string  `xml:"publicIpv4Pool"`
+	NetworkBorderGroup string  `xml:"networkBorderGroup"`
+	Tags               []Tag   `xml:"tagSet>item"`
+}
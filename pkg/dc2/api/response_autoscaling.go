@@ -27,6 +27,24 @@ type DetachInstancesResponse struct {
 
 type DetachInstancesResult struct{}
 
+type AttachInstancesResponse struct{}
+
+type EnterStandbyResponse struct {
+	EnterStandbyResult EnterStandbyResult `xml:"EnterStandbyResult"`
+}
+
+type EnterStandbyResult struct {
+	Activities []AutoScalingActivity `xml:"Activities>member"`
+}
+
+type ExitStandbyResponse struct {
+	ExitStandbyResult ExitStandbyResult `xml:"ExitStandbyResult"`
+}
+
+type ExitStandbyResult struct {
+	Activities []AutoScalingActivity `xml:"Activities>member"`
+}
+
 type DeleteAutoScalingGroupResponse struct{}
 
 type PutWarmPoolResponse struct {
@@ -41,6 +59,58 @@ type DeleteWarmPoolResponse struct {
 
 type DeleteWarmPoolResult struct{}
 
+type PutLifecycleHookResponse struct {
+	PutLifecycleHookResult PutLifecycleHookResult `xml:"PutLifecycleHookResult"`
+}
+
+type PutLifecycleHookResult struct{}
+
+type DescribeLifecycleHookTypesResponse struct {
+	DescribeLifecycleHookTypesResult DescribeLifecycleHookTypesResult `xml:"DescribeLifecycleHookTypesResult"`
+}
+
+type DescribeLifecycleHookTypesResult struct {
+	LifecycleHookTypes []string `xml:"LifecycleHookTypes>member"`
+}
+
+type DeleteLifecycleHookResponse struct {
+	DeleteLifecycleHookResult DeleteLifecycleHookResult `xml:"DeleteLifecycleHookResult"`
+}
+
+type DeleteLifecycleHookResult struct{}
+
+type CompleteLifecycleActionResponse struct {
+	CompleteLifecycleActionResult CompleteLifecycleActionResult `xml:"CompleteLifecycleActionResult"`
+}
+
+type CompleteLifecycleActionResult struct{}
+
+type RecordLifecycleActionHeartbeatResponse struct {
+	RecordLifecycleActionHeartbeatResult RecordLifecycleActionHeartbeatResult `xml:"RecordLifecycleActionHeartbeatResult"`
+}
+
+type RecordLifecycleActionHeartbeatResult struct{}
+
+type DescribeLifecycleHooksResponse struct {
+	DescribeLifecycleHooksResult DescribeLifecycleHooksResult `xml:"DescribeLifecycleHooksResult"`
+}
+
+type DescribeLifecycleHooksResult struct {
+	LifecycleHooks []LifecycleHook `xml:"LifecycleHooks>member"`
+}
+
+type LifecycleHook struct {
+	AutoScalingGroupName  *string `xml:"AutoScalingGroupName"`
+	DefaultResult         *string `xml:"DefaultResult"`
+	GlobalTimeout         *int    `xml:"GlobalTimeout"`
+	HeartbeatTimeout      *int    `xml:"HeartbeatTimeout"`
+	LifecycleHookName     *string `xml:"LifecycleHookName"`
+	LifecycleTransition   *string `xml:"LifecycleTransition"`
+	NotificationMetadata  *string `xml:"NotificationMetadata"`
+	NotificationTargetARN *string `xml:"NotificationTargetARN"`
+	RoleARN               *string `xml:"RoleARN"`
+}
+
 type DescribeAutoScalingGroupsResponse struct {
 	DescribeAutoScalingGroupsResult DescribeAutoScalingGroupsResult `xml:"DescribeAutoScalingGroupsResult"`
 }
@@ -60,22 +130,48 @@ type DescribeWarmPoolResult struct {
 	WarmPoolConfiguration *WarmPoolConfiguration `xml:"WarmPoolConfiguration"`
 }
 
+type DescribeAutoScalingInstancesResponse struct {
+	DescribeAutoScalingInstancesResult DescribeAutoScalingInstancesResult `xml:"DescribeAutoScalingInstancesResult"`
+}
+
+type DescribeAutoScalingInstancesResult struct {
+	AutoScalingInstances []AutoScalingInstanceDetails `xml:"AutoScalingInstances>member"`
+	NextToken            *string                      `xml:"NextToken"`
+}
+
+// AutoScalingInstanceDetails is like AutoScalingInstance, but it also
+// includes the group the instance belongs to.
+type AutoScalingInstanceDetails struct {
+	AutoScalingGroupName *string                                 `xml:"AutoScalingGroupName"`
+	AvailabilityZone     *string                                 `xml:"AvailabilityZone"`
+	HealthStatus         *string                                 `xml:"HealthStatus"`
+	InstanceID           *string                                 `xml:"InstanceId"`
+	InstanceType         *string                                 `xml:"InstanceType"`
+	LaunchTemplate       *AutoScalingLaunchTemplateSpecification `xml:"LaunchTemplate"`
+	LifecycleState       *string                                 `xml:"LifecycleState"`
+	ProtectedFromScaleIn *bool                                   `xml:"ProtectedFromScaleIn"`
+}
+
 type AutoScalingGroup struct {
-	AutoScalingGroupName  *string                                 `xml:"AutoScalingGroupName"`
-	CreatedTime           *time.Time                              `xml:"CreatedTime"`
-	DefaultCooldown       *int                                    `xml:"DefaultCooldown"`
-	DesiredCapacity       *int                                    `xml:"DesiredCapacity"`
-	HealthCheckType       *string                                 `xml:"HealthCheckType"`
-	Instances             []AutoScalingInstance                   `xml:"Instances>member"`
-	LaunchTemplate        *AutoScalingLaunchTemplateSpecification `xml:"LaunchTemplate"`
-	MaxSize               *int                                    `xml:"MaxSize"`
-	MinSize               *int                                    `xml:"MinSize"`
-	MixedInstancesPolicy  *AutoScalingMixedInstancesPolicy        `xml:"MixedInstancesPolicy"`
-	Tags                  []AutoScalingTagDescription             `xml:"Tags>member"`
-	VPCZoneIdentifier     *string                                 `xml:"VPCZoneIdentifier"`
-	AvailabilityZones     []string                                `xml:"AvailabilityZones>member"`
-	WarmPoolConfiguration *WarmPoolConfiguration                  `xml:"WarmPoolConfiguration"`
-	WarmPoolSize          *int                                    `xml:"WarmPoolSize"`
+	AutoScalingGroupName             *string                                 `xml:"AutoScalingGroupName"`
+	CreatedTime                      *time.Time                              `xml:"CreatedTime"`
+	DefaultCooldown                  *int                                    `xml:"DefaultCooldown"`
+	DesiredCapacity                  *int                                    `xml:"DesiredCapacity"`
+	HealthCheckGracePeriod           *int                                    `xml:"HealthCheckGracePeriod"`
+	HealthCheckType                  *string                                 `xml:"HealthCheckType"`
+	Instances                        []AutoScalingInstance                   `xml:"Instances>member"`
+	LaunchTemplate                   *AutoScalingLaunchTemplateSpecification `xml:"LaunchTemplate"`
+	MaxSize                          *int                                    `xml:"MaxSize"`
+	MinSize                          *int                                    `xml:"MinSize"`
+	MixedInstancesPolicy             *AutoScalingMixedInstancesPolicy        `xml:"MixedInstancesPolicy"`
+	NewInstancesProtectedFromScaleIn *bool                                   `xml:"NewInstancesProtectedFromScaleIn"`
+	Status                           *string                                 `xml:"Status"`
+	Tags                             []AutoScalingTagDescription             `xml:"Tags>member"`
+	TargetGroupARNs                  []string                                `xml:"TargetGroupARNs>member"`
+	VPCZoneIdentifier                *string                                 `xml:"VPCZoneIdentifier"`
+	AvailabilityZones                []string                                `xml:"AvailabilityZones>member"`
+	WarmPoolConfiguration            *WarmPoolConfiguration                  `xml:"WarmPoolConfiguration"`
+	WarmPoolSize                     *int                                    `xml:"WarmPoolSize"`
 }
 
 type AutoScalingTagDescription struct {
@@ -122,3 +218,111 @@ type AutoScalingInstance struct {
 	LifecycleState       string                                  `xml:"LifecycleState"`
 	ProtectedFromScaleIn *bool                                   `xml:"ProtectedFromScaleIn"`
 }
+
+type PutScheduledUpdateGroupActionResponse struct {
+	PutScheduledUpdateGroupActionResult PutScheduledUpdateGroupActionResult `xml:"PutScheduledUpdateGroupActionResult"`
+}
+
+type PutScheduledUpdateGroupActionResult struct{}
+
+type DescribeScheduledActionsResponse struct {
+	DescribeScheduledActionsResult DescribeScheduledActionsResult `xml:"DescribeScheduledActionsResult"`
+}
+
+type DescribeScheduledActionsResult struct {
+	ScheduledUpdateGroupActions []ScheduledUpdateGroupAction `xml:"ScheduledUpdateGroupActions>member"`
+	NextToken                   *string                      `xml:"NextToken"`
+}
+
+type DescribeScalingActivitiesResponse struct {
+	DescribeScalingActivitiesResult DescribeScalingActivitiesResult `xml:"DescribeScalingActivitiesResult"`
+}
+
+type DescribeScalingActivitiesResult struct {
+	Activities []AutoScalingActivity `xml:"Activities>member"`
+	NextToken  *string               `xml:"NextToken"`
+}
+
+type ScheduledUpdateGroupAction struct {
+	AutoScalingGroupName *string    `xml:"AutoScalingGroupName"`
+	DesiredCapacity      *int       `xml:"DesiredCapacity"`
+	EndTime              *time.Time `xml:"EndTime"`
+	MaxSize              *int       `xml:"MaxSize"`
+	MinSize              *int       `xml:"MinSize"`
+	Recurrence           *string    `xml:"Recurrence"`
+	ScheduledActionName  *string    `xml:"ScheduledActionName"`
+	StartTime            *time.Time `xml:"StartTime"`
+	Time                 *time.Time `xml:"Time"`
+	TimeZone             *string    `xml:"TimeZone"`
+}
+
+type DeleteScheduledActionResponse struct {
+	DeleteScheduledActionResult DeleteScheduledActionResult `xml:"DeleteScheduledActionResult"`
+}
+
+type DeleteScheduledActionResult struct{}
+
+type PutScalingPolicyResponse struct {
+	PutScalingPolicyResult PutScalingPolicyResult `xml:"PutScalingPolicyResult"`
+}
+
+type PutScalingPolicyResult struct {
+	PolicyARN *string `xml:"PolicyARN"`
+}
+
+type DescribePoliciesResponse struct {
+	DescribePoliciesResult DescribePoliciesResult `xml:"DescribePoliciesResult"`
+}
+
+type DescribePoliciesResult struct {
+	ScalingPolicies []ScalingPolicy `xml:"ScalingPolicies>member"`
+	NextToken       *string         `xml:"NextToken"`
+}
+
+type ScalingPolicy struct {
+	AdjustmentType              *string                      `xml:"AdjustmentType"`
+	AutoScalingGroupName        *string                      `xml:"AutoScalingGroupName"`
+	Cooldown                    *int                         `xml:"Cooldown"`
+	Enabled                     *bool                        `xml:"Enabled"`
+	MinAdjustmentMagnitude      *int                         `xml:"MinAdjustmentMagnitude"`
+	PolicyARN                   *string                      `xml:"PolicyARN"`
+	PolicyName                  *string                      `xml:"PolicyName"`
+	PolicyType                  *string                      `xml:"PolicyType"`
+	ScalingAdjustment           *int                         `xml:"ScalingAdjustment"`
+	TargetTrackingConfiguration *TargetTrackingConfiguration `xml:"TargetTrackingConfiguration"`
+}
+
+type DeletePolicyResponse struct{}
+
+type ExecutePolicyResponse struct{}
+
+type SetInstanceHealthResponse struct {
+	SetInstanceHealthResult SetInstanceHealthResult `xml:"SetInstanceHealthResult"`
+}
+
+type SetInstanceHealthResult struct{}
+
+type SetInstanceProtectionResponse struct {
+	SetInstanceProtectionResult SetInstanceProtectionResult `xml:"SetInstanceProtectionResult"`
+}
+
+type SetInstanceProtectionResult struct{}
+
+type TerminateInstanceInAutoScalingGroupResponse struct {
+	TerminateInstanceInAutoScalingGroupResult TerminateInstanceInAutoScalingGroupResult `xml:"TerminateInstanceInAutoScalingGroupResult"`
+}
+
+type TerminateInstanceInAutoScalingGroupResult struct {
+	Activity *AutoScalingActivity `xml:"Activity"`
+}
+
+type AutoScalingActivity struct {
+	ActivityID           *string    `xml:"ActivityId"`
+	AutoScalingGroupName *string    `xml:"AutoScalingGroupName"`
+	Cause                *string    `xml:"Cause"`
+	Description          *string    `xml:"Description"`
+	EndTime              *time.Time `xml:"EndTime"`
+	Progress             *int       `xml:"Progress"`
+	StartTime            *time.Time `xml:"StartTime"`
+	StatusCode           *string    `xml:"StatusCode"`
+}
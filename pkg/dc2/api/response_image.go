@@ -0,0 +1,48 @@
+package api
+
+type CreateImageResponse struct {
+	ImageID string `xml:"imageId"`
+}
+
+type CopyImageResponse struct {
+	ImageID string `xml:"imageId"`
+}
+
+type DescribeImagesResponse struct {
+	NextToken *string
+	Images    []Image `xml:"imagesSet>item"`
+}
+
+type Image struct {
+	ImageID         string  `xml:"imageId"`
+	ImageLocation   string  `xml:"imageLocation"`
+	ImageState      string  `xml:"imageState"`
+	ImageOwnerID    string  `xml:"imageOwnerId"`
+	ImageOwnerAlias *string `xml:"imageOwnerAlias"`
+	CreationDate    string  `xml:"creationDate"`
+	IsPublic        bool    `xml:"isPublic"`
+	Architecture    string  `xml:"architecture"`
+	ImageType       string  `xml:"imageType"`
+	Platform        *string `xml:"platform"`
+	PlatformDetails string  `xml:"platformDetails"`
+	Name            string  `xml:"name"`
+	Description     *string `xml:"description"`
+	RootDeviceType  string  `xml:"rootDeviceType"`
+	RootDeviceName  string  `xml:"rootDeviceName"`
+	// BlockDeviceMappings reports the root volume, sized to fit the image
+	BlockDeviceMappings []ImageBlockDeviceMapping `xml:"blockDeviceMapping>item"`
+	VirtualizationType  string                    `xml:"virtualizationType"`
+	Hypervisor          string                    `xml:"hypervisor"`
+	Tags                []Tag                     `xml:"tagSet>item"`
+}
+
+type ImageBlockDeviceMapping struct {
+	DeviceName string    `xml:"deviceName"`
+	Ebs        *ImageEbs `xml:"ebs"`
+}
+
+type ImageEbs struct {
+	VolumeSize          int    `xml:"volumeSize"`
+	VolumeType          string `xml:"volumeType"`
+	DeleteOnTermination bool   `xml:"deleteOnTermination"`
+}
@@ -1,6 +1,10 @@
 package api
 
-import "time"
+import (
+	"time"
+
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
 
 var (
 	InstanceStatePending      = InstanceState{Code: "0", Name: "pending"}
@@ -13,6 +17,7 @@ var (
 
 type DescribeInstancesResponse struct {
 	ReservationSet []Reservation `xml:"reservationSet>item"`
+	NextToken      *string       `xml:"nextToken"`
 }
 
 type DescribeSpotInstanceRequestsResponse struct {
@@ -20,6 +25,19 @@ type DescribeSpotInstanceRequestsResponse struct {
 	NextToken            *string               `xml:"nextToken"`
 }
 
+type RequestSpotInstancesResponse struct {
+	SpotInstanceRequests []SpotInstanceRequest `xml:"spotInstanceRequestSet>item"`
+}
+
+type CancelSpotInstanceRequestsResponse struct {
+	CancelledSpotInstanceRequests []CancelledSpotInstanceRequest `xml:"spotInstanceRequestSet>item"`
+}
+
+type CancelledSpotInstanceRequest struct {
+	SpotInstanceRequestID string `xml:"spotInstanceRequestId"`
+	State                 string `xml:"state"`
+}
+
 type SpotInstanceRequest struct {
 	SpotInstanceRequestID        string                    `xml:"spotInstanceRequestId"`
 	State                        string                    `xml:"state"`
@@ -48,6 +66,35 @@ type DescribeInstanceStatusResponse struct {
 	NextToken         *string          `xml:"nextToken"`
 }
 
+type DescribeInstanceCreditSpecificationsResponse struct {
+	InstanceCreditSpecifications []InstanceCreditSpecification `xml:"instanceCreditSpecificationSet>item"`
+	NextToken                    *string                       `xml:"nextToken"`
+}
+
+type InstanceCreditSpecification struct {
+	InstanceID string `xml:"instanceId"`
+	CPUCredits string `xml:"cpuCredits"`
+}
+
+type ModifyInstanceCreditSpecificationResponse struct {
+	SuccessfulInstanceCreditSpecifications   []SuccessfulInstanceCreditSpecification   `xml:"successfulInstanceCreditSpecificationSet>item"`
+	UnsuccessfulInstanceCreditSpecifications []UnsuccessfulInstanceCreditSpecification `xml:"unsuccessfulInstanceCreditSpecificationSet>item"`
+}
+
+type SuccessfulInstanceCreditSpecification struct {
+	InstanceID string `xml:"instanceId"`
+}
+
+type UnsuccessfulInstanceCreditSpecification struct {
+	InstanceID string                                       `xml:"instanceId"`
+	Error      UnsuccessfulInstanceCreditSpecificationError `xml:"error"`
+}
+
+type UnsuccessfulInstanceCreditSpecificationError struct {
+	Code    string `xml:"code"`
+	Message string `xml:"message"`
+}
+
 type RunInstancesResponse struct {
 	ReservationID string     `xml:"reservationId"`
 	OwnerID       string     `xml:"ownerId"`
@@ -62,13 +109,33 @@ type StartInstancesResponse struct {
 	StartingInstances []InstanceStateChange `xml:"instancesSet>item"`
 }
 
+type RebootInstancesResponse struct {
+}
+
 type TerminateInstancesResponse struct {
 	TerminatingInstances []InstanceStateChange `xml:"instancesSet>item"`
 }
 
 type InstanceMetadataOptions struct {
-	HTTPEndpoint *string `xml:"httpEndpoint"`
-	State        *string `xml:"state"`
+	HTTPEndpoint            *string `xml:"httpEndpoint"`
+	HTTPTokens              *string `xml:"httpTokens"`
+	HTTPPutResponseHopLimit *int    `xml:"httpPutResponseHopLimit"`
+	State                   *string `xml:"state"`
+}
+
+type GetConsoleOutputResponse struct {
+	InstanceID string     `xml:"instanceId"`
+	Timestamp  *time.Time `xml:"timestamp"`
+	// Output is base64 encoded
+	Output string `xml:"output"`
+}
+
+type GetPasswordDataResponse struct {
+	InstanceID string     `xml:"instanceId"`
+	Timestamp  *time.Time `xml:"timestamp"`
+	// PasswordData is base64 encoded and empty until the password is
+	// available
+	PasswordData string `xml:"passwordData"`
 }
 
 type ModifyInstanceMetadataOptionsResponse struct {
@@ -76,6 +143,31 @@ type ModifyInstanceMetadataOptionsResponse struct {
 	InstanceMetadataOptions *InstanceMetadataOptions `xml:"instanceMetadataOptions"`
 }
 
+type ModifyInstanceAttributeResponse struct {
+	Return bool `xml:"return"`
+}
+
+// DescribeInstanceAttributeResponse only populates the field for the
+// requested attribute.
+type DescribeInstanceAttributeResponse struct {
+	InstanceID                        string                       `xml:"instanceId"`
+	InstanceType                      *AttributeValueResponse      `xml:"instanceType"`
+	UserData                          *AttributeValueResponse      `xml:"userData"`
+	RootDeviceName                    *AttributeValueResponse      `xml:"rootDeviceName"`
+	InstanceInitiatedShutdownBehavior *AttributeValueResponse      `xml:"instanceInitiatedShutdownBehavior"`
+	DisableAPITermination             *AttributeBooleanValue       `xml:"disableApiTermination"`
+	BlockDeviceMappings               []InstanceBlockDeviceMapping `xml:"blockDeviceMapping>item"`
+	Groups                            []Group                      `xml:"groupSet>item"`
+}
+
+type AttributeValueResponse struct {
+	Value *string `xml:"value"`
+}
+
+type AttributeBooleanValue struct {
+	Value bool `xml:"value"`
+}
+
 type InstanceStateChange struct {
 	InstanceID    string        `xml:"instanceId"`
 	CurrentState  InstanceState `xml:"currentState"`
@@ -89,31 +181,52 @@ type Reservation struct {
 }
 
 type Instance struct {
-	InstanceID            string                     `xml:"instanceId"`
-	ImageID               string                     `xml:"imageId"`
-	InstanceState         InstanceState              `xml:"instanceState"`
-	StateTransitionReason string                     `xml:"reason"`
-	StateReason           *StateReason               `xml:"stateReason"`
-	PrivateDNSName        string                     `xml:"privateDnsName"`
-	DNSName               string                     `xml:"dnsName"`
-	KeyName               string                     `xml:"keyName"`
-	AmiLaunchIndex        int                        `xml:"amiLaunchIndex"`
-	InstanceType          string                     `xml:"instanceType"`
-	InstanceLifecycle     *string                    `xml:"instanceLifecycle"`
-	LaunchTime            time.Time                  `xml:"launchTime"`
-	Placement             Placement                  `xml:"placement"`
-	Monitoring            Monitoring                 `xml:"monitoring"`
-	SubnetID              string                     `xml:"subnetId"`
-	VPCID                 string                     `xml:"vpcId"`
-	PrivateIPAddress      string                     `xml:"privateIpAddress"`
-	PublicIPAddress       string                     `xml:"ipAddress"`
-	NetworkInterfaces     []InstanceNetworkInterface `xml:"networkInterfaceSet>item"`
-	SecurityGroups        []Group                    `xml:"securityGroups>item"`
-	Architecture          string                     `xml:"architecture"`
-	RootDeviceType        string                     `xml:"rootDeviceType"`
-	RootDeviceName        string                     `xml:"rootDeviceName"`
-	MetadataOptions       *InstanceMetadataOptions   `xml:"metadataOptions"`
-	TagSet                []Tag                      `xml:"tagSet>item"`
+	InstanceID            string                       `xml:"instanceId"`
+	ImageID               string                       `xml:"imageId"`
+	InstanceState         InstanceState                `xml:"instanceState"`
+	StateTransitionReason string                       `xml:"reason"`
+	StateReason           *StateReason                 `xml:"stateReason"`
+	PrivateDNSName        string                       `xml:"privateDnsName"`
+	DNSName               string                       `xml:"dnsName"`
+	KeyName               string                       `xml:"keyName"`
+	ClientToken           *string                      `xml:"clientToken"`
+	AmiLaunchIndex        int                          `xml:"amiLaunchIndex"`
+	InstanceType          string                       `xml:"instanceType"`
+	InstanceLifecycle     *string                      `xml:"instanceLifecycle"`
+	LaunchTime            time.Time                    `xml:"launchTime"`
+	Placement             Placement                    `xml:"placement"`
+	Monitoring            Monitoring                   `xml:"monitoring"`
+	SubnetID              string                       `xml:"subnetId"`
+	VPCID                 string                       `xml:"vpcId"`
+	PrivateIPAddress      string                       `xml:"privateIpAddress"`
+	PublicIPAddress       string                       `xml:"ipAddress"`
+	NetworkInterfaces     []InstanceNetworkInterface   `xml:"networkInterfaceSet>item"`
+	SecurityGroups        []Group                      `xml:"groupSet>item"`
+	Architecture          string                       `xml:"architecture"`
+	Platform              *string                      `xml:"platform"`
+	PlatformDetails       string                       `xml:"platformDetails"`
+	RootDeviceType        string                       `xml:"rootDeviceType"`
+	RootDeviceName        string                       `xml:"rootDeviceName"`
+	BlockDeviceMappings   []InstanceBlockDeviceMapping `xml:"blockDeviceMapping>item"`
+	MetadataOptions       *InstanceMetadataOptions     `xml:"metadataOptions"`
+	IamInstanceProfile    *IamInstanceProfile          `xml:"iamInstanceProfile"`
+	TagSet                []Tag                        `xml:"tagSet>item"`
+	// ReservationID is reported by the enclosing Reservation rather than
+	// the instance itself.
+	ReservationID string `xml:"-"`
+}
+
+// InstanceBlockDeviceMapping describes a block device attached to an instance
+type InstanceBlockDeviceMapping struct {
+	DeviceName string                  `xml:"deviceName"`
+	EBS        *EBSInstanceBlockDevice `xml:"ebs"`
+}
+
+type EBSInstanceBlockDevice struct {
+	AttachTime          *time.Time                  `xml:"attachTime"`
+	DeleteOnTermination bool                        `xml:"deleteOnTermination"`
+	Status              types.VolumeAttachmentState `xml:"status"`
+	VolumeID            string                      `xml:"volumeId"`
 }
 
 type StateReason struct {
@@ -131,6 +244,7 @@ type InstanceNetworkInterface struct {
 	Association        *InstanceNetworkInterfaceAssociation  `xml:"association"`
 	Attachment         *InstanceNetworkInterfaceAttachment   `xml:"attachment"`
 	PrivateIPAddresses []InstancePrivateIPAddressAssociation `xml:"privateIpAddressesSet>item"`
+	Groups             []Group                               `xml:"groupSet>item"`
 }
 
 type InstanceNetworkInterfaceAssociation struct {
@@ -154,11 +268,21 @@ type InstancePrivateIPAddressAssociation struct {
 }
 
 type InstanceStatus struct {
-	AvailabilityZone string        `xml:"availabilityZone"`
-	InstanceID       string        `xml:"instanceId"`
-	InstanceState    InstanceState `xml:"instanceState"`
-	InstanceStatus   StatusSummary `xml:"instanceStatus"`
-	SystemStatus     StatusSummary `xml:"systemStatus"`
+	AvailabilityZone string                `xml:"availabilityZone"`
+	InstanceID       string                `xml:"instanceId"`
+	InstanceState    InstanceState         `xml:"instanceState"`
+	InstanceStatus   StatusSummary         `xml:"instanceStatus"`
+	SystemStatus     StatusSummary         `xml:"systemStatus"`
+	Events           []InstanceStatusEvent `xml:"eventsSet>item"`
+}
+
+// InstanceStatusEvent represents a scheduled event for an instance
+type InstanceStatusEvent struct {
+	InstanceEventID string     `xml:"instanceEventId"`
+	Code            string     `xml:"code"`
+	Description     string     `xml:"description"`
+	NotBefore       *time.Time `xml:"notBefore"`
+	NotAfter        *time.Time `xml:"notAfter"`
 }
 
 type StatusSummary struct {
@@ -180,6 +304,7 @@ type InstanceState struct {
 // Placement represents the placement details of an instance
 type Placement struct {
 	AvailabilityZone string `url:"AvailabilityZone" xml:"availabilityZone"`
+	GroupName        string `url:"GroupName" xml:"groupName,omitempty"`
 	Tenancy          string `xml:"tenancy"`
 }
 
@@ -193,3 +318,37 @@ type Group struct {
 	GroupID   string `xml:"groupId"`
 	GroupName string `xml:"groupName"`
 }
+
+type IamInstanceProfile struct {
+	Arn string `xml:"arn"`
+	ID  string `xml:"id"`
+}
+
+type IamInstanceProfileAssociation struct {
+	AssociationID      string              `xml:"associationId"`
+	InstanceID         string              `xml:"instanceId"`
+	IamInstanceProfile *IamInstanceProfile `xml:"iamInstanceProfile"`
+	State              string              `xml:"state"`
+	Timestamp          *time.Time          `xml:"timestamp"`
+}
+
+type AssociateIamInstanceProfileResponse struct {
+	IamInstanceProfileAssociation IamInstanceProfileAssociation `xml:"iamInstanceProfileAssociation"`
+}
+
+type DisassociateIamInstanceProfileResponse struct {
+	IamInstanceProfileAssociation IamInstanceProfileAssociation `xml:"iamInstanceProfileAssociation"`
+}
+
+type InstanceMonitoring struct {
+	InstanceID string     `xml:"instanceId"`
+	Monitoring Monitoring `xml:"monitoring"`
+}
+
+type MonitorInstancesResponse struct {
+	InstancesSet []InstanceMonitoring `xml:"instancesSet>item"`
+}
+
+type UnmonitorInstancesResponse struct {
+	InstancesSet []InstanceMonitoring `xml:"instancesSet>item"`
+}
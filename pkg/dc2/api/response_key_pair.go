@@ -0,0 +1,37 @@
+package api
+
+import "time"
+
+type CreateKeyPairResponse struct {
+	KeyPairID      string `xml:"keyPairId"`
+	KeyName        string `xml:"keyName"`
+	KeyFingerprint string `xml:"keyFingerprint"`
+	KeyMaterial    string `xml:"keyMaterial"`
+	Tags           []Tag  `xml:"tagSet>item"`
+}
+
+type ImportKeyPairResponse struct {
+	KeyPairID      string `xml:"keyPairId"`
+	KeyName        string `xml:"keyName"`
+	KeyFingerprint string `xml:"keyFingerprint"`
+	Tags           []Tag  `xml:"tagSet>item"`
+}
+
+type DescribeKeyPairsResponse struct {
+	KeyPairs []KeyPair `xml:"keySet>item"`
+}
+
+type KeyPair struct {
+	KeyPairID      string     `xml:"keyPairId"`
+	KeyName        string     `xml:"keyName"`
+	KeyFingerprint string     `xml:"keyFingerprint"`
+	KeyType        string     `xml:"keyType"`
+	PublicKey      *string    `xml:"publicKey"`
+	CreateTime     *time.Time `xml:"createTime"`
+	Tags           []Tag      `xml:"tagSet>item"`
+}
+
+type DeleteKeyPairResponse struct {
+	Return    bool    `xml:"return"`
+	KeyPairID *string `xml:"keyPairId"`
+}
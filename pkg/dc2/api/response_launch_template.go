@@ -34,8 +34,22 @@ type ResponseLaunchTemplateData struct {
 	InstanceRequirements *InstanceRequirementsRequest               `xml:"instanceRequirements"`
 	InstanceType         *string                                    `xml:"instanceType"`
 	UserData             *string                                    `xml:"userData"`
+	KeyName              *string                                    `xml:"keyName"`
 	SecurityGroupIDs     []string                                   `xml:"securityGroupIdSet>item"`
+	IamInstanceProfile   *ResponseLaunchTemplateIamInstanceProfile  `xml:"iamInstanceProfile"`
+	Placement            *ResponseLaunchTemplatePlacement           `xml:"placement"`
 	BlockDeviceMappings  []ResponseLaunchTemplateBlockDeviceMapping `xml:"blockDeviceMappingSet>item"`
+	Architecture         *string                                    `xml:"architecture"`
+}
+
+type ResponseLaunchTemplateIamInstanceProfile struct {
+	Arn  *string `xml:"arn"`
+	Name *string `xml:"name"`
+}
+
+type ResponseLaunchTemplatePlacement struct {
+	AvailabilityZone *string `xml:"availabilityZone"`
+	GroupName        *string `xml:"groupName"`
 }
 
 type ResponseLaunchTemplateBlockDeviceMapping struct {
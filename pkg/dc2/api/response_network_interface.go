@@ -0,0 +1,54 @@
+package api
+
+import "time"
+
+type DescribeNetworkInterfacesResponse struct {
+	NetworkInterfaces []NetworkInterface `xml:"networkInterfaceSet>item"`
+	NextToken         *string            `xml:"nextToken"`
+}
+
+type NetworkInterface struct {
+	NetworkInterfaceID string                                `xml:"networkInterfaceId"`
+	SubnetID           string                                `xml:"subnetId"`
+	VPCID              string                                `xml:"vpcId"`
+	AvailabilityZone   string                                `xml:"availabilityZone"`
+	Description        string                                `xml:"description"`
+	OwnerID            string                                `xml:"ownerId"`
+	RequesterManaged   bool                                  `xml:"requesterManaged"`
+	Status             string                                `xml:"status"`
+	MacAddress         string                                `xml:"macAddress"`
+	PrivateIPAddress   string                                `xml:"privateIpAddress"`
+	PrivateDNSName     string                                `xml:"privateDnsName"`
+	SourceDestCheck    bool                                  `xml:"sourceDestCheck"`
+	InterfaceType      string                                `xml:"interfaceType"`
+	Groups             []Group                               `xml:"groupSet>item"`
+	Attachment         *NetworkInterfaceAttachment           `xml:"attachment"`
+	Association        *InstanceNetworkInterfaceAssociation  `xml:"association"`
+	PrivateIPAddresses []InstancePrivateIPAddressAssociation `xml:"privateIpAddressesSet>item"`
+	Tags               []Tag                                 `xml:"tagSet>item"`
+}
+
+type NetworkInterfaceAttachment struct {
+	AttachmentID        string    `xml:"attachmentId"`
+	InstanceID          string    `xml:"instanceId"`
+	InstanceOwnerID     string    `xml:"instanceOwnerId"`
+	DeviceIndex         int       `xml:"deviceIndex"`
+	NetworkCardIndex    int       `xml:"networkCardIndex"`
+	Status              string    `xml:"status"`
+	AttachTime          time.Time `xml:"attachTime"`
+	DeleteOnTermination bool      `xml:"deleteOnTermination"`
+}
+
+type AssignPrivateIPAddressesResponse struct {
+	NetworkInterfaceID         string                     `xml:"networkInterfaceId"`
+	AssignedPrivateIPAddresses []AssignedPrivateIPAddress `xml:"assignedPrivateIpAddressesSet>item"`
+	Return                     bool                       `xml:"return"`
+}
+
+type AssignedPrivateIPAddress struct {
+	PrivateIPAddress string `xml:"privateIpAddress"`
+}
+
+type UnassignPrivateIPAddressesResponse struct {
+	Return bool `xml:"return"`
+}
@@ -0,0 +1,24 @@
+package api
+
+type CreatePlacementGroupResponse struct {
+	PlacementGroup PlacementGroup `xml:"placementGroup"`
+}
+
+type DescribePlacementGroupsResponse struct {
+	PlacementGroups []PlacementGroup `xml:"placementGroupSet>item"`
+}
+
+type PlacementGroup struct {
+	GroupID        string  `xml:"groupId"`
+	GroupName      string  `xml:"groupName"`
+	GroupARN       string  `xml:"groupArn"`
+	State          string  `xml:"state"`
+	Strategy       string  `xml:"strategy"`
+	PartitionCount *int    `xml:"partitionCount"`
+	SpreadLevel    *string `xml:"spreadLevel"`
+	Tags           []Tag   `xml:"tagSet>item"`
+}
+
+type DeletePlacementGroupResponse struct {
+	Return bool `xml:"return"`
+}
@@ -21,4 +21,7 @@ type SecurityGroup struct {
 	OwnerID          *string `xml:"ownerId"`
 	VPCID            *string `xml:"vpcId"`
 	Tags             []Tag   `xml:"tagSet>item"`
+
+	IPPermissions       []SecurityGroupIPPermission `xml:"ipPermissions>item"`
+	IPPermissionsEgress []SecurityGroupIPPermission `xml:"ipPermissionsEgress>item"`
 }
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"time"
+
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+type CreateSnapshotResponse struct {
+	Snapshot
+}
+
+type DeleteSnapshotResponse struct {
+}
+
+type CopySnapshotResponse struct {
+	SnapshotID string `xml:"snapshotId"`
+	Tags       []Tag  `xml:"tagSet>item"`
+}
+
+type DescribeSnapshotsResponse struct {
+	NextToken *string
+	Snapshots []Snapshot `xml:"snapshotSet>item"`
+}
+
+type Snapshot struct {
+	SnapshotID  string              `xml:"snapshotId"`
+	VolumeID    string              `xml:"volumeId"`
+	State       types.SnapshotState `xml:"status"`
+	StartTime   time.Time           `xml:"startTime"`
+	Progress    string              `xml:"progress"`
+	OwnerID     string              `xml:"ownerId"`
+	Description string              `xml:"description"`
+	// VolumeSize is the size of the source volume in GiB
+	VolumeSize int   `xml:"volumeSize"`
+	Encrypted  bool  `xml:"encrypted"`
+	Tags       []Tag `xml:"tagSet>item"`
+}
@@ -17,3 +17,11 @@ type Subnet struct {
 	MapPublicIPOnLaunch     *bool   `xml:"mapPublicIpOnLaunch"`
 	Tags                    []Tag   `xml:"tagSet>item"`
 }
+
+type CreateSubnetResponse struct {
+	Subnet Subnet `xml:"subnet"`
+}
+
+type DeleteSubnetResponse struct {
+	Return bool `xml:"return"`
+}
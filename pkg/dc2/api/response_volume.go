@@ -69,7 +69,7 @@ type Volume struct {
 	SnapshotID *string `xml:"SnapshotId"`
 
 	// The volume state.
-	State types.VolumeState `xml:"State"`
+	State types.VolumeState `xml:"status"`
 
 	// Any tags assigned to the volume.
 	Tags []Tag `xml:"tagSet>item"`
@@ -123,3 +123,29 @@ type DescribeVolumesResponse struct {
 	NextToken *string
 	Volumes   []Volume `xml:"volumeSet>item"`
 }
+
+type ModifyVolumeResponse struct {
+	VolumeModification VolumeModification `xml:"volumeModification"`
+}
+
+type DescribeVolumesModificationsResponse struct {
+	NextToken           *string
+	VolumeModifications []VolumeModification `xml:"volumeModificationSet>item"`
+}
+
+type VolumeModification struct {
+	VolumeID          string                        `xml:"volumeId"`
+	ModificationState types.VolumeModificationState `xml:"modificationState"`
+	// Progress is the modification progress, from 0 to 100
+	Progress           int              `xml:"progress"`
+	StartTime          time.Time        `xml:"startTime"`
+	EndTime            *time.Time       `xml:"endTime"`
+	OriginalSize       int              `xml:"originalSize"`
+	OriginalVolumeType types.VolumeType `xml:"originalVolumeType"`
+	OriginalIOPS       int              `xml:"originalIops"`
+	OriginalThroughput int              `xml:"originalThroughput"`
+	TargetSize         int              `xml:"targetSize"`
+	TargetVolumeType   types.VolumeType `xml:"targetVolumeType"`
+	TargetIOPS         int              `xml:"targetIops"`
+	TargetThroughput   int              `xml:"targetThroughput"`
+}
@@ -0,0 +1,25 @@
+package api
+
+type CreateVpcResponse struct {
+	Vpc Vpc `xml:"vpc"`
+}
+
+type DescribeVpcsResponse struct {
+	Vpcs      []Vpc   `xml:"vpcSet>item"`
+	NextToken *string `xml:"nextToken"`
+}
+
+type Vpc struct {
+	VPCID           string `xml:"vpcId"`
+	State           string `xml:"state"`
+	CIDRBlock       string `xml:"cidrBlock"`
+	DHCPOptionsID   string `xml:"dhcpOptionsId"`
+	InstanceTenancy string `xml:"instanceTenancy"`
+	IsDefault       bool   `xml:"isDefault"`
+	OwnerID         string `xml:"ownerId"`
+	Tags            []Tag  `xml:"tagSet>item"`
+}
+
+type DeleteVpcResponse struct {
+	Return bool `xml:"return"`
+}
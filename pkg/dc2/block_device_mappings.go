@@ -8,11 +8,16 @@ import (
 )
 
 func validateBlockDeviceMappings(mappings []api.RunInstancesBlockDeviceMapping, fieldPrefix string) error {
+	deviceNames := make(map[string]struct{}, len(mappings))
 	for i, mapping := range mappings {
 		prefix := fmt.Sprintf("%s.%d", fieldPrefix, i+1)
 		if mapping.DeviceName == "" {
 			return api.InvalidParameterValueError(prefix+".DeviceName", "<empty>")
 		}
+		if _, duplicate := deviceNames[mapping.DeviceName]; duplicate {
+			return api.InvalidParameterValueError(prefix+".DeviceName", mapping.DeviceName)
+		}
+		deviceNames[mapping.DeviceName] = struct{}{}
 		if mapping.EBS == nil {
 			return api.InvalidParameterValueError(prefix+".Ebs", "<empty>")
 		}
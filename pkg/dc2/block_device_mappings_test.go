@@ -71,6 +71,22 @@ func TestValidateBlockDeviceMappings(t *testing.T) {
 			},
 			wantErr: "BlockDeviceMapping.1.Ebs.VolumeSize",
 		},
+		{
+			name: "multiple devices",
+			mappings: []api.RunInstancesBlockDeviceMapping{
+				{DeviceName: "/dev/sdf", EBS: &api.RunInstancesEBSBlockDevice{VolumeSize: intPtr(1)}},
+				{DeviceName: "/dev/sdg", EBS: &api.RunInstancesEBSBlockDevice{VolumeSize: intPtr(1)}},
+				{DeviceName: "/dev/sdh", EBS: &api.RunInstancesEBSBlockDevice{VolumeSize: intPtr(1)}},
+			},
+		},
+		{
+			name: "duplicate device name",
+			mappings: []api.RunInstancesBlockDeviceMapping{
+				{DeviceName: "/dev/sdf", EBS: &api.RunInstancesEBSBlockDevice{VolumeSize: intPtr(1)}},
+				{DeviceName: "/dev/sdf", EBS: &api.RunInstancesEBSBlockDevice{VolumeSize: intPtr(2)}},
+			},
+			wantErr: "BlockDeviceMapping.2.DeviceName",
+		},
 	}
 
 	for _, tc := range testCases {
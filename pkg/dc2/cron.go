@@ -0,0 +1,151 @@
+package dc2
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSchedule is a parsed Unix cron expression with the five standard
+// fields: minute, hour, day of month, month and day of week.
+type cronSchedule struct {
+	minutes     uint64
+	hours       uint64
+	daysOfMonth uint64
+	months      uint64
+	daysOfWeek  uint64
+	// Like cron, when both day fields are restricted a time matches when
+	// either of them does.
+	anyDayOfMonth bool
+	anyDayOfWeek  bool
+}
+
+type cronField struct {
+	min   int
+	max   int
+	names map[string]int
+}
+
+var (
+	cronMinuteField     = cronField{min: 0, max: 59}
+	cronHourField       = cronField{min: 0, max: 23}
+	cronDayOfMonthField = cronField{min: 1, max: 31}
+	cronMonthField      = cronField{min: 1, max: 12, names: map[string]int{
+		"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+		"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+	}}
+	// Both 0 and 7 are Sunday.
+	cronDayOfWeekField = cronField{min: 0, max: 7, names: map[string]int{
+		"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+	}}
+)
+
+func parseCronSchedule(expr string) (*cronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expecting 5 fields, got %d", len(fields))
+	}
+	var s cronSchedule
+	var err error
+	if s.minutes, err = cronMinuteField.parse(fields[0]); err != nil {
+		return nil, fmt.Errorf("minute: %w", err)
+	}
+	if s.hours, err = cronHourField.parse(fields[1]); err != nil {
+		return nil, fmt.Errorf("hour: %w", err)
+	}
+	if s.daysOfMonth, err = cronDayOfMonthField.parse(fields[2]); err != nil {
+		return nil, fmt.Errorf("day of month: %w", err)
+	}
+	if s.months, err = cronMonthField.parse(fields[3]); err != nil {
+		return nil, fmt.Errorf("month: %w", err)
+	}
+	if s.daysOfWeek, err = cronDayOfWeekField.parse(fields[4]); err != nil {
+		return nil, fmt.Errorf("day of week: %w", err)
+	}
+	if s.daysOfWeek&(1<<7) != 0 {
+		s.daysOfWeek |= 1
+	}
+	s.anyDayOfMonth = strings.HasPrefix(fields[2], "*")
+	s.anyDayOfWeek = strings.HasPrefix(fields[4], "*")
+	return &s, nil
+}
+
+func (f cronField) parse(expr string) (uint64, error) {
+	var bits uint64
+	for part := range strings.SplitSeq(expr, ",") {
+		rangeExpr, stepExpr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepExpr)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepExpr)
+			}
+		}
+		var lo, hi int
+		switch {
+		case rangeExpr == "*":
+			lo, hi = f.min, f.max
+		case strings.Contains(rangeExpr, "-"):
+			loExpr, hiExpr, _ := strings.Cut(rangeExpr, "-")
+			var err error
+			if lo, err = f.value(loExpr); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(hiExpr); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range %q", rangeExpr)
+			}
+		default:
+			var err error
+			if lo, err = f.value(rangeExpr); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if hasStep {
+				hi = f.max
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+func (f cronField) value(expr string) (int, error) {
+	if v, ok := f.names[strings.ToUpper(expr)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(expr)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", expr)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("value %d out of range [%d, %d]", v, f.min, f.max)
+	}
+	return v, nil
+}
+
+// matches reports whether the schedule fires at the minute containing t, in
+// t's location.
+func (s *cronSchedule) matches(t time.Time) bool {
+	if s.minutes&(1<<t.Minute()) == 0 || s.hours&(1<<t.Hour()) == 0 || s.months&(1<<int(t.Month())) == 0 {
+		return false
+	}
+	dayOfMonth := s.daysOfMonth&(1<<t.Day()) != 0
+	dayOfWeek := s.daysOfWeek&(1<<int(t.Weekday())) != 0
+	switch {
+	case s.anyDayOfMonth && s.anyDayOfWeek:
+		return true
+	case s.anyDayOfMonth:
+		return dayOfWeek
+	case s.anyDayOfWeek:
+		return dayOfMonth
+	default:
+		return dayOfMonth || dayOfWeek
+	}
+}
@@ -0,0 +1,59 @@
+package dc2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCronScheduleMatches(t *testing.T) {
+	t.Parallel()
+
+	// 2026-10-16 is a Friday.
+	friday := time.Date(2026, 10, 16, 8, 30, 0, 0, time.UTC)
+	tests := []struct {
+		expr    string
+		t       time.Time
+		matches bool
+	}{
+		{expr: "* * * * *", t: friday, matches: true},
+		{expr: "30 8 * * *", t: friday, matches: true},
+		{expr: "31 8 * * *", t: friday, matches: false},
+		{expr: "*/15 * * * *", t: friday, matches: true},
+		{expr: "*/20 * * * *", t: friday, matches: false},
+		{expr: "0,30 8-17 * * MON-FRI", t: friday, matches: true},
+		{expr: "30 8 * * SAT,SUN", t: friday, matches: false},
+		{expr: "30 8 * OCT *", t: friday, matches: true},
+		{expr: "30 8 1 * *", t: friday, matches: false},
+		// Both day fields are restricted, so either one matching is enough.
+		{expr: "30 8 1 * 5", t: friday, matches: true},
+		{expr: "0 0 * * 7", t: time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC), matches: true},
+	}
+	for _, tc := range tests {
+		schedule, err := parseCronSchedule(tc.expr)
+		require.NoError(t, err, tc.expr)
+		assert.Equal(t, tc.matches, schedule.matches(tc.t), tc.expr)
+	}
+}
+
+func TestParseCronScheduleErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * FOO *",
+	} {
+		_, err := parseCronSchedule(expr)
+		assert.Error(t, err, expr)
+	}
+}
@@ -2,8 +2,10 @@ package dc2
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"slices"
@@ -14,6 +16,7 @@ import (
 
 	"github.com/moby/moby/api/types/events"
 	"github.com/moby/moby/client"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/fiam/dc2/pkg/dc2/api"
 	"github.com/fiam/dc2/pkg/dc2/docker"
@@ -35,6 +38,21 @@ const (
 	autoScalingReconcileInterval  = 250 * time.Millisecond
 )
 
+// describeTagsResourceTypes lists the EC2 resource types whose tags are
+// returned by DescribeTags.
+var describeTagsResourceTypes = []types.ResourceType{
+	types.ResourceTypeInstance,
+	types.ResourceTypeVolume,
+	types.ResourceTypeLaunchTemplate,
+	types.ResourceTypeSecurityGroup,
+	types.ResourceTypeSpotInstancesRequest,
+	types.ResourceTypeSnapshot,
+	types.ResourceTypeImage,
+	types.ResourceTypeAddress,
+	types.ResourceTypeVpc,
+	types.ResourceTypeSubnet,
+}
+
 type dispatcherInitHooks struct {
 	newExecutor             func(context.Context, docker.ExecutorOptions) (executor.Executor, error)
 	loadInstanceTypeCatalog func() (*instancetype.Catalog, error)
@@ -53,13 +71,57 @@ func (h dispatcherInitHooks) withDefaults() dispatcherInitHooks {
 }
 
 type DispatcherOptions struct {
-	Region            string
-	IMDSBackendPort   int
-	InstanceNetwork   string
-	TestProfileInput  string
-	SpotReclaimAfter  time.Duration
-	SpotReclaimNotice time.Duration
-	ExitResourceMode  ExitResourceMode
+	Region             string
+	IMDSBackendPort    int
+	InstanceNetwork    string
+	MainVolumeHostPath string
+	// MainImage is the image of the container managing EBS volume files.
+	// Empty uses the executor default.
+	MainImage string
+	// VolumeRoot is the path EBS volume files are stored at inside
+	// containers. Empty uses the executor default.
+	VolumeRoot string
+	// PublishPort is the container port instances publish on their public
+	// IP. Zero disables publishing.
+	PublishPort           int
+	StatePath             string
+	TestProfileInput      string
+	SpotReclaimAfter      time.Duration
+	SpotReclaimNotice     time.Duration
+	ExitResourceMode      ExitResourceMode
+	AsyncStateTransitions bool
+	// DisableResourceLimits launches instances without the CPU and memory
+	// limits of their instance type.
+	DisableResourceLimits bool
+	// MaxInstanceIDsPerRequest caps the instance IDs accepted by a single
+	// request. Zero means no limit.
+	MaxInstanceIDsPerRequest int
+	// InstanceStateChangeConcurrency is the number of instances started or
+	// stopped at the same time by the Docker executor. Zero uses the
+	// executor default.
+	InstanceStateChangeConcurrency int
+	// ScaleInDrainDelay stops instances removed by scale-in and keeps them
+	// in Terminating:Wait for the given duration before terminating them.
+	// Groups can override it with ScaleInDrainSeconds.
+	ScaleInDrainDelay time.Duration
+	// DefaultArchitecture is the architecture (x86_64, arm64 or i386)
+	// instances run when neither the request nor the launch template ask for
+	// one. Empty uses the image default.
+	DefaultArchitecture string
+	// PasswordDataDelay is how long instances must have been running before
+	// GetPasswordData returns their password.
+	PasswordDataDelay time.Duration
+	// Tracer, when set, creates a span per dispatched action and child
+	// spans around executor calls.
+	Tracer trace.Tracer
+	// Metrics enables the counters served by the /metrics endpoint.
+	Metrics bool
+	// EventStream enables the state change events served by the /events
+	// endpoint.
+	EventStream bool
+	// Executor, when set, runs instances and volumes instead of the Docker
+	// executor. The Docker specific options are ignored.
+	Executor executor.Executor
 }
 
 type warmPoolDeleteJob struct {
@@ -78,6 +140,15 @@ type Dispatcher struct {
 	testProfile         *testprofile.Profile
 	testProfileYAML     string
 	testProfileUpdateCh chan struct{}
+	// restoredState is true when resources were loaded from
+	// DispatcherOptions.StatePath.
+	restoredState bool
+	// metrics is nil unless DispatcherOptions.Metrics is set.
+	metrics *metrics
+	// stateChangeEvents is nil unless DispatcherOptions.EventStream is set.
+	stateChangeEvents *stateChangeEvents
+	// clock returns the current time. When nil, time.Now is used.
+	clock func() time.Time
 
 	dispatchMu sync.Mutex
 
@@ -93,7 +164,22 @@ type Dispatcher struct {
 	warmPoolDeleteMu   sync.Mutex
 	warmPoolDeleteSeq  uint64
 	warmPoolDeleteJobs map[string]warmPoolDeleteJob
-	launchInstances    map[string]launchInstancesRecord
+	// autoScalingGroupLocks holds a channel, closed on release, for each
+	// group locked by lockAutoScalingGroup.
+	autoScalingGroupLockMu sync.Mutex
+	autoScalingGroupLocks  map[string]chan struct{}
+	// scheduledActionCancel stops the loop started by
+	// startScheduledActionRunner, which closes scheduledActionDone on exit.
+	scheduledActionCancel context.CancelFunc
+	scheduledActionDone   chan struct{}
+	launchInstances       map[string]launchInstancesRecord
+	// autoScalingActivities holds the most recent scaling activities,
+	// oldest first.
+	autoScalingActivityMu sync.Mutex
+	autoScalingActivities []api.AutoScalingActivity
+	asyncStopMu           sync.Mutex
+	asyncStops            map[executor.InstanceID]*asyncStop
+	asyncStopWG           sync.WaitGroup
 }
 
 func NewDispatcher(ctx context.Context, opts DispatcherOptions, imds *imdsController) (*Dispatcher, error) {
@@ -112,13 +198,29 @@ func newDispatcherWithHooks(
 	if imds == nil {
 		return nil, errors.New("nil IMDS controller")
 	}
+	if err := validateArchitecture(opts.DefaultArchitecture, "DefaultArchitecture"); err != nil {
+		return nil, err
+	}
 	hooks = hooks.withDefaults()
-	exe, err := hooks.newExecutor(ctx, docker.ExecutorOptions{
-		IMDSBackendPort: opts.IMDSBackendPort,
-		InstanceNetwork: opts.InstanceNetwork,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("initializing executor: %w", err)
+	exe := opts.Executor
+	if exe == nil {
+		var err error
+		exe, err = hooks.newExecutor(ctx, docker.ExecutorOptions{
+			IMDSBackendPort:        opts.IMDSBackendPort,
+			InstanceNetwork:        opts.InstanceNetwork,
+			MainVolumeHostPath:     opts.MainVolumeHostPath,
+			MainImage:              opts.MainImage,
+			VolumeRoot:             opts.VolumeRoot,
+			PublishPort:            opts.PublishPort,
+			DisableResourceLimits:  opts.DisableResourceLimits,
+			StateChangeConcurrency: opts.InstanceStateChangeConcurrency,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("initializing executor: %w", err)
+		}
+	}
+	if opts.Tracer != nil {
+		exe = newTracingExecutor(exe, opts.Tracer)
 	}
 	shouldCloseExecutorOnError := true
 	defer func() {
@@ -131,22 +233,34 @@ func newDispatcherWithHooks(
 			slog.Warn("failed to close executor after dispatcher initialization error", "error", closeErr)
 		}
 	}()
+	store, restoredState, err := openStorage(opts.StatePath)
+	if err != nil {
+		return nil, err
+	}
 	d := &Dispatcher{
 		opts:                opts,
 		exe:                 exe,
 		imds:                imds,
-		storage:             storage.NewMemoryStorage(),
+		storage:             store,
+		restoredState:       restoredState,
 		securityGroups:      map[string]api.SecurityGroup{},
 		launchInstances:     map[string]launchInstancesRecord{},
 		spotReclaimCancels:  map[string]context.CancelFunc{},
 		warmPoolDeleteJobs:  map[string]warmPoolDeleteJob{},
 		testProfileUpdateCh: make(chan struct{}, 1),
 	}
+	if opts.Metrics {
+		d.metrics = newMetrics()
+	}
+	if opts.EventStream {
+		d.stateChangeEvents = newStateChangeEvents()
+	}
 	instanceTypeCatalog, err := hooks.loadInstanceTypeCatalog()
 	if err != nil {
 		return nil, fmt.Errorf("loading instance type catalog: %w", err)
 	}
 	d.instanceTypeCatalog = instanceTypeCatalog
+	imds.SetInstanceMetadataResolver(d.imdsInstanceMetadata)
 	if strings.TrimSpace(opts.TestProfileInput) != "" {
 		profile, profileYAML, err := loadStartupTestProfile(opts.TestProfileInput)
 		if err != nil {
@@ -154,7 +268,21 @@ func newDispatcherWithHooks(
 		}
 		d.setTestProfile(profile, profileYAML)
 	}
+	if d.restoredState {
+		if err := d.restoreState(ctx); err != nil {
+			return nil, fmt.Errorf("restoring state from %s: %w", opts.StatePath, err)
+		}
+	}
+	d.startScheduledActionRunner()
 
+	// Docker lifecycle events are only meaningful for the Docker executor,
+	// other executors rely on the periodic reconciliation alone.
+	if opts.Executor != nil {
+		d.pendingInstances = make(map[string]struct{})
+		d.startInstanceLifecycleEventWatcher()
+		shouldCloseExecutorOnError = false
+		return d, nil
+	}
 	eventCLI, err := client.New(client.FromEnv)
 	if err != nil {
 		slog.Warn("failed to initialize Docker events client for auto scaling reconciliation", "error", err)
@@ -203,13 +331,53 @@ func loadStartupTestProfile(input string) (*testprofile.Profile, string, error)
 	return nil, "", yamlErr
 }
 
+// writeMetrics serializes the dispatcher metrics in the Prometheus text
+// format.
+func (d *Dispatcher) writeMetrics(w io.Writer) error {
+	resourceCounts := make(map[string]int, len(metricsResourceTypes))
+	for _, rt := range metricsResourceTypes {
+		resources, err := d.storage.RegisteredResources(rt.resourceType)
+		if err != nil {
+			return fmt.Errorf("retrieving %s resources: %w", rt.label, err)
+		}
+		resourceCounts[rt.label] = len(resources)
+	}
+	return d.metrics.write(w, resourceCounts)
+}
+
+// Ready returns an error unless the dispatcher can serve requests, meaning
+// its storage is initialized and its executor is reachable.
+func (d *Dispatcher) Ready(ctx context.Context) error {
+	if d.storage == nil {
+		return errors.New("storage is not initialized")
+	}
+	if d.exe == nil {
+		return errors.New("executor is not initialized")
+	}
+	return d.exe.Ping(ctx)
+}
+
 func (d *Dispatcher) Close(ctx context.Context) error {
 	var closeErr error
 	d.cancelAllSpotReclaims()
 	d.cancelAllWarmPoolDeleteJobs()
+	if err := d.waitForAsyncStops(ctx); err != nil {
+		closeErr = errors.Join(closeErr, err)
+	}
+	d.stateChangeEvents.close()
 	if d.eventCancel != nil {
 		d.eventCancel()
 	}
+	if d.scheduledActionCancel != nil {
+		d.scheduledActionCancel()
+	}
+	if d.scheduledActionDone != nil {
+		select {
+		case <-d.scheduledActionDone:
+		case <-ctx.Done():
+			closeErr = errors.Join(closeErr, fmt.Errorf("waiting for auto scaling scheduled action runner: %w", ctx.Err()))
+		}
+	}
 	if d.eventDone != nil {
 		select {
 		case <-d.eventDone:
@@ -229,6 +397,12 @@ func (d *Dispatcher) Close(ctx context.Context) error {
 			closeErr = errors.Join(closeErr, fmt.Errorf("closing Docker events client: %w", err))
 		}
 	}
+	d.dispatchMu.Lock()
+	drainErr := d.cancelAutoScalingDrains(ctx)
+	d.dispatchMu.Unlock()
+	if drainErr != nil {
+		closeErr = errors.Join(closeErr, fmt.Errorf("canceling auto scaling instance drains: %w", drainErr))
+	}
 	switch d.opts.ExitResourceMode {
 	case ExitResourceModeCleanup:
 		slog.Info("running exit resource cleanup", slog.String("mode", string(d.opts.ExitResourceMode)))
@@ -257,10 +431,21 @@ func (d *Dispatcher) Close(ctx context.Context) error {
 	return closeErr
 }
 
-func (d *Dispatcher) Dispatch(ctx context.Context, req api.Request) (api.Response, error) {
+func (d *Dispatcher) Dispatch(ctx context.Context, req api.Request) (resp api.Response, err error) {
 	d.dispatchMu.Lock()
 	defer d.dispatchMu.Unlock()
 
+	// Actions like DescribeWarmPool describe the same instances several
+	// times, so container inspections are reused within the dispatch.
+	ctx = docker.WithInspectCache(ctx)
+	if d.opts.Tracer != nil {
+		var endSpan func(error)
+		ctx, endSpan = d.startDispatchSpan(ctx, req)
+		defer func() { endSpan(err) }()
+	}
+	if d.metrics != nil {
+		defer func() { d.metrics.recordAction(requestActionName(ctx, req), err) }()
+	}
 	dispatchers := []func(context.Context, api.Request) (api.Response, bool, error){
 		d.dispatchInstanceAPI,
 		d.dispatchStorageAPI,
@@ -289,9 +474,27 @@ func (d *Dispatcher) dispatchInstanceAPI(ctx context.Context, req api.Request) (
 	case api.ActionDescribeSpotInstanceRequests:
 		resp, err := d.dispatchDescribeSpotInstanceRequests(ctx, req.(*api.DescribeSpotInstanceRequestsRequest))
 		return resp, true, err
+	case api.ActionCancelSpotInstanceRequests:
+		resp, err := d.dispatchCancelSpotInstanceRequests(ctx, req.(*api.CancelSpotInstanceRequestsRequest))
+		return resp, true, err
+	case api.ActionRequestSpotInstances:
+		resp, err := d.dispatchRequestSpotInstances(ctx, req.(*api.RequestSpotInstancesRequest))
+		return resp, true, err
 	case api.ActionDescribeInstanceStatus:
 		resp, err := d.dispatchDescribeInstanceStatus(ctx, req.(*api.DescribeInstanceStatusRequest))
 		return resp, true, err
+	case api.ActionDescribeInstanceCreditSpecifications:
+		resp, err := d.dispatchDescribeInstanceCreditSpecifications(ctx, req.(*api.DescribeInstanceCreditSpecificationsRequest))
+		return resp, true, err
+	case api.ActionGetConsoleOutput:
+		resp, err := d.dispatchGetConsoleOutput(ctx, req.(*api.GetConsoleOutputRequest))
+		return resp, true, err
+	case api.ActionGetPasswordData:
+		resp, err := d.dispatchGetPasswordData(ctx, req.(*api.GetPasswordDataRequest))
+		return resp, true, err
+	case api.ActionModifyInstanceCreditSpecification:
+		resp, err := d.dispatchModifyInstanceCreditSpecification(ctx, req.(*api.ModifyInstanceCreditSpecificationRequest))
+		return resp, true, err
 	case api.ActionDescribeSecurityGroups:
 		resp, err := d.dispatchDescribeSecurityGroups(ctx, req.(*api.DescribeSecurityGroupsRequest))
 		return resp, true, err
@@ -307,21 +510,72 @@ func (d *Dispatcher) dispatchInstanceAPI(ctx context.Context, req api.Request) (
 	case api.ActionAuthorizeSecurityGroupEgress:
 		resp, err := d.dispatchAuthorizeSecurityGroupEgress(ctx, req.(*api.AuthorizeSecurityGroupEgressRequest))
 		return resp, true, err
+	case api.ActionRevokeSecurityGroupIngress:
+		resp, err := d.dispatchRevokeSecurityGroupIngress(ctx, req.(*api.RevokeSecurityGroupIngressRequest))
+		return resp, true, err
+	case api.ActionRevokeSecurityGroupEgress:
+		resp, err := d.dispatchRevokeSecurityGroupEgress(ctx, req.(*api.RevokeSecurityGroupEgressRequest))
+		return resp, true, err
 	case api.ActionDescribeSubnets:
 		resp, err := d.dispatchDescribeSubnets(ctx, req.(*api.DescribeSubnetsRequest))
 		return resp, true, err
+	case api.ActionCreateSubnet:
+		resp, err := d.dispatchCreateSubnet(ctx, req.(*api.CreateSubnetRequest))
+		return resp, true, err
+	case api.ActionDeleteSubnet:
+		resp, err := d.dispatchDeleteSubnet(ctx, req.(*api.DeleteSubnetRequest))
+		return resp, true, err
+	case api.ActionCreateVpc:
+		resp, err := d.dispatchCreateVpc(ctx, req.(*api.CreateVpcRequest))
+		return resp, true, err
+	case api.ActionDescribeVpcs:
+		resp, err := d.dispatchDescribeVpcs(ctx, req.(*api.DescribeVpcsRequest))
+		return resp, true, err
+	case api.ActionDeleteVpc:
+		resp, err := d.dispatchDeleteVpc(ctx, req.(*api.DeleteVpcRequest))
+		return resp, true, err
+	case api.ActionDescribeNetworkInterfaces:
+		resp, err := d.dispatchDescribeNetworkInterfaces(ctx, req.(*api.DescribeNetworkInterfacesRequest))
+		return resp, true, err
+	case api.ActionAssignPrivateIPAddresses:
+		resp, err := d.dispatchAssignPrivateIPAddresses(ctx, req.(*api.AssignPrivateIPAddressesRequest))
+		return resp, true, err
+	case api.ActionUnassignPrivateIPAddresses:
+		resp, err := d.dispatchUnassignPrivateIPAddresses(ctx, req.(*api.UnassignPrivateIPAddressesRequest))
+		return resp, true, err
 	case api.ActionStopInstances:
 		resp, err := d.dispatchStopInstances(ctx, req.(*api.StopInstancesRequest))
 		return resp, true, err
 	case api.ActionStartInstances:
 		resp, err := d.dispatchStartInstances(ctx, req.(*api.StartInstancesRequest))
 		return resp, true, err
+	case api.ActionRebootInstances:
+		resp, err := d.dispatchRebootInstances(ctx, req.(*api.RebootInstancesRequest))
+		return resp, true, err
 	case api.ActionTerminateInstances:
 		resp, err := d.dispatchTerminateInstances(ctx, req.(*api.TerminateInstancesRequest))
 		return resp, true, err
 	case api.ActionModifyInstanceMetadataOptions:
 		resp, err := d.dispatchModifyInstanceMetadataOptions(ctx, req.(*api.ModifyInstanceMetadataOptionsRequest))
 		return resp, true, err
+	case api.ActionModifyInstanceAttribute:
+		resp, err := d.dispatchModifyInstanceAttribute(ctx, req.(*api.ModifyInstanceAttributeRequest))
+		return resp, true, err
+	case api.ActionDescribeInstanceAttribute:
+		resp, err := d.dispatchDescribeInstanceAttribute(ctx, req.(*api.DescribeInstanceAttributeRequest))
+		return resp, true, err
+	case api.ActionAssociateIamInstanceProfile:
+		resp, err := d.dispatchAssociateIamInstanceProfile(ctx, req.(*api.AssociateIamInstanceProfileRequest))
+		return resp, true, err
+	case api.ActionDisassociateIamInstanceProfile:
+		resp, err := d.dispatchDisassociateIamInstanceProfile(ctx, req.(*api.DisassociateIamInstanceProfileRequest))
+		return resp, true, err
+	case api.ActionMonitorInstances:
+		resp, err := d.dispatchMonitorInstances(ctx, req.(*api.MonitorInstancesRequest))
+		return resp, true, err
+	case api.ActionUnmonitorInstances:
+		resp, err := d.dispatchUnmonitorInstances(ctx, req.(*api.UnmonitorInstancesRequest))
+		return resp, true, err
 	case api.ActionDescribeInstanceTypes:
 		resp, err := d.dispatchDescribeInstanceTypes(req.(*api.DescribeInstanceTypesRequest))
 		return resp, true, err
@@ -347,6 +601,9 @@ func (d *Dispatcher) dispatchStorageAPI(ctx context.Context, req api.Request) (a
 	case api.ActionDeleteTags:
 		resp, err := d.dispatchDeleteTags(ctx, req.(*api.DeleteTagsRequest))
 		return resp, true, err
+	case api.ActionDescribeTags:
+		resp, err := d.dispatchDescribeTags(ctx, req.(*api.DescribeTagsRequest))
+		return resp, true, err
 	case api.ActionCreateVolume:
 		resp, err := d.dispatchCreateVolume(ctx, req.(*api.CreateVolumeRequest))
 		return resp, true, err
@@ -362,6 +619,33 @@ func (d *Dispatcher) dispatchStorageAPI(ctx context.Context, req api.Request) (a
 	case api.ActionDescribeVolumes:
 		resp, err := d.dispatchDescribeVolumes(ctx, req.(*api.DescribeVolumesRequest))
 		return resp, true, err
+	case api.ActionModifyVolume:
+		resp, err := d.dispatchModifyVolume(ctx, req.(*api.ModifyVolumeRequest))
+		return resp, true, err
+	case api.ActionDescribeVolumesModifications:
+		resp, err := d.dispatchDescribeVolumesModifications(ctx, req.(*api.DescribeVolumesModificationsRequest))
+		return resp, true, err
+	case api.ActionCreateSnapshot:
+		resp, err := d.dispatchCreateSnapshot(ctx, req.(*api.CreateSnapshotRequest))
+		return resp, true, err
+	case api.ActionCopySnapshot:
+		resp, err := d.dispatchCopySnapshot(ctx, req.(*api.CopySnapshotRequest))
+		return resp, true, err
+	case api.ActionDeleteSnapshot:
+		resp, err := d.dispatchDeleteSnapshot(ctx, req.(*api.DeleteSnapshotRequest))
+		return resp, true, err
+	case api.ActionDescribeSnapshots:
+		resp, err := d.dispatchDescribeSnapshots(ctx, req.(*api.DescribeSnapshotsRequest))
+		return resp, true, err
+	case api.ActionCreateImage:
+		resp, err := d.dispatchCreateImage(ctx, req.(*api.CreateImageRequest))
+		return resp, true, err
+	case api.ActionCopyImage:
+		resp, err := d.dispatchCopyImage(ctx, req.(*api.CopyImageRequest))
+		return resp, true, err
+	case api.ActionDescribeImages:
+		resp, err := d.dispatchDescribeImages(ctx, req.(*api.DescribeImagesRequest))
+		return resp, true, err
 	case api.ActionCreateLaunchTemplate:
 		resp, err := d.dispatchCreateLaunchTemplate(ctx, req.(*api.CreateLaunchTemplateRequest))
 		return resp, true, err
@@ -380,6 +664,42 @@ func (d *Dispatcher) dispatchStorageAPI(ctx context.Context, req api.Request) (a
 	case api.ActionModifyLaunchTemplate:
 		resp, err := d.dispatchModifyLaunchTemplate(ctx, req.(*api.ModifyLaunchTemplateRequest))
 		return resp, true, err
+	case api.ActionCreateKeyPair:
+		resp, err := d.dispatchCreateKeyPair(ctx, req.(*api.CreateKeyPairRequest))
+		return resp, true, err
+	case api.ActionImportKeyPair:
+		resp, err := d.dispatchImportKeyPair(ctx, req.(*api.ImportKeyPairRequest))
+		return resp, true, err
+	case api.ActionDescribeKeyPairs:
+		resp, err := d.dispatchDescribeKeyPairs(ctx, req.(*api.DescribeKeyPairsRequest))
+		return resp, true, err
+	case api.ActionDeleteKeyPair:
+		resp, err := d.dispatchDeleteKeyPair(ctx, req.(*api.DeleteKeyPairRequest))
+		return resp, true, err
+	case api.ActionCreatePlacementGroup:
+		resp, err := d.dispatchCreatePlacementGroup(ctx, req.(*api.CreatePlacementGroupRequest))
+		return resp, true, err
+	case api.ActionDescribePlacementGroups:
+		resp, err := d.dispatchDescribePlacementGroups(ctx, req.(*api.DescribePlacementGroupsRequest))
+		return resp, true, err
+	case api.ActionDeletePlacementGroup:
+		resp, err := d.dispatchDeletePlacementGroup(ctx, req.(*api.DeletePlacementGroupRequest))
+		return resp, true, err
+	case api.ActionAllocateAddress:
+		resp, err := d.dispatchAllocateAddress(ctx, req.(*api.AllocateAddressRequest))
+		return resp, true, err
+	case api.ActionAssociateAddress:
+		resp, err := d.dispatchAssociateAddress(ctx, req.(*api.AssociateAddressRequest))
+		return resp, true, err
+	case api.ActionDisassociateAddress:
+		resp, err := d.dispatchDisassociateAddress(ctx, req.(*api.DisassociateAddressRequest))
+		return resp, true, err
+	case api.ActionReleaseAddress:
+		resp, err := d.dispatchReleaseAddress(ctx, req.(*api.ReleaseAddressRequest))
+		return resp, true, err
+	case api.ActionDescribeAddresses:
+		resp, err := d.dispatchDescribeAddresses(ctx, req.(*api.DescribeAddressesRequest))
+		return resp, true, err
 	default:
 		return nil, false, nil
 	}
@@ -408,6 +728,15 @@ func (d *Dispatcher) dispatchAutoScalingAPI(ctx context.Context, req api.Request
 	case api.ActionDetachInstances:
 		resp, err := d.dispatchDetachInstances(ctx, req.(*api.DetachInstancesRequest))
 		return resp, true, err
+	case api.ActionAttachInstances:
+		resp, err := d.dispatchAttachInstances(ctx, req.(*api.AttachInstancesRequest))
+		return resp, true, err
+	case api.ActionEnterStandby:
+		resp, err := d.dispatchEnterStandby(ctx, req.(*api.EnterStandbyRequest))
+		return resp, true, err
+	case api.ActionExitStandby:
+		resp, err := d.dispatchExitStandby(ctx, req.(*api.ExitStandbyRequest))
+		return resp, true, err
 	case api.ActionDeleteAutoScalingGroup:
 		resp, err := d.dispatchDeleteAutoScalingGroup(ctx, req.(*api.DeleteAutoScalingGroupRequest))
 		return resp, true, err
@@ -420,6 +749,60 @@ func (d *Dispatcher) dispatchAutoScalingAPI(ctx context.Context, req api.Request
 	case api.ActionDeleteWarmPool:
 		resp, err := d.dispatchDeleteWarmPool(ctx, req.(*api.DeleteWarmPoolRequest))
 		return resp, true, err
+	case api.ActionPutLifecycleHook:
+		resp, err := d.dispatchPutLifecycleHook(ctx, req.(*api.PutLifecycleHookRequest))
+		return resp, true, err
+	case api.ActionDescribeLifecycleHooks:
+		resp, err := d.dispatchDescribeLifecycleHooks(ctx, req.(*api.DescribeLifecycleHooksRequest))
+		return resp, true, err
+	case api.ActionDeleteLifecycleHook:
+		resp, err := d.dispatchDeleteLifecycleHook(ctx, req.(*api.DeleteLifecycleHookRequest))
+		return resp, true, err
+	case api.ActionDescribeLifecycleHookTypes:
+		resp, err := d.dispatchDescribeLifecycleHookTypes(ctx, req.(*api.DescribeLifecycleHookTypesRequest))
+		return resp, true, err
+	case api.ActionCompleteLifecycleAction:
+		resp, err := d.dispatchCompleteLifecycleAction(ctx, req.(*api.CompleteLifecycleActionRequest))
+		return resp, true, err
+	case api.ActionRecordLifecycleActionHeartbeat:
+		resp, err := d.dispatchRecordLifecycleActionHeartbeat(ctx, req.(*api.RecordLifecycleActionHeartbeatRequest))
+		return resp, true, err
+	case api.ActionPutScheduledUpdateGroupAction:
+		resp, err := d.dispatchPutScheduledUpdateGroupAction(ctx, req.(*api.PutScheduledUpdateGroupActionRequest))
+		return resp, true, err
+	case api.ActionDescribeScheduledActions:
+		resp, err := d.dispatchDescribeScheduledActions(ctx, req.(*api.DescribeScheduledActionsRequest))
+		return resp, true, err
+	case api.ActionDeleteScheduledAction:
+		resp, err := d.dispatchDeleteScheduledAction(ctx, req.(*api.DeleteScheduledActionRequest))
+		return resp, true, err
+	case api.ActionDescribeAutoScalingInstances:
+		resp, err := d.dispatchDescribeAutoScalingInstances(ctx, req.(*api.DescribeAutoScalingInstancesRequest))
+		return resp, true, err
+	case api.ActionDescribeScalingActivities:
+		resp, err := d.dispatchDescribeScalingActivities(ctx, req.(*api.DescribeScalingActivitiesRequest))
+		return resp, true, err
+	case api.ActionPutScalingPolicy:
+		resp, err := d.dispatchPutScalingPolicy(ctx, req.(*api.PutScalingPolicyRequest))
+		return resp, true, err
+	case api.ActionDescribePolicies:
+		resp, err := d.dispatchDescribePolicies(ctx, req.(*api.DescribePoliciesRequest))
+		return resp, true, err
+	case api.ActionDeletePolicy:
+		resp, err := d.dispatchDeletePolicy(ctx, req.(*api.DeletePolicyRequest))
+		return resp, true, err
+	case api.ActionExecutePolicy:
+		resp, err := d.dispatchExecutePolicy(ctx, req.(*api.ExecutePolicyRequest))
+		return resp, true, err
+	case api.ActionSetInstanceHealth:
+		resp, err := d.dispatchSetInstanceHealth(ctx, req.(*api.SetInstanceHealthRequest))
+		return resp, true, err
+	case api.ActionSetInstanceProtection:
+		resp, err := d.dispatchSetInstanceProtection(ctx, req.(*api.SetInstanceProtectionRequest))
+		return resp, true, err
+	case api.ActionTerminateInstanceInAutoScalingGroup:
+		resp, err := d.dispatchTerminateInstanceInAutoScalingGroup(ctx, req.(*api.TerminateInstanceInAutoScalingGroupRequest))
+		return resp, true, err
 	default:
 		return nil, false, nil
 	}
@@ -615,6 +998,97 @@ func (d *Dispatcher) dispatchDeleteTags(_ context.Context, req *api.DeleteTagsRe
 	return &api.DeleteTagsResponse{}, nil
 }
 
+func (d *Dispatcher) dispatchDescribeTags(_ context.Context, req *api.DescribeTagsRequest) (*api.DescribeTagsResponse, error) {
+	for _, f := range req.Filters {
+		if f.Name == nil {
+			return nil, api.InvalidParameterValueError("Filter.Name", "<missing>")
+		}
+		if !isSupportedTagFilter(*f.Name) {
+			return nil, api.InvalidParameterValueError("Filter.Name", *f.Name)
+		}
+	}
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
+
+	var tags []api.TagDescription
+	for _, rt := range describeTagsResourceTypes {
+		resources, err := d.storage.RegisteredResources(rt)
+		if err != nil {
+			return nil, fmt.Errorf("retrieving registered %s: %w", rt, err)
+		}
+		for _, r := range resources {
+			attrs, err := d.storage.ResourceAttributes(r.ID)
+			if err != nil {
+				return nil, fmt.Errorf("retrieving attributes for %s: %w", r.ID, err)
+			}
+			for _, attr := range attrs {
+				if !attr.IsTag() {
+					continue
+				}
+				tag := api.TagDescription{
+					Key:          attr.TagKey(),
+					ResourceID:   r.ID,
+					ResourceType: rt,
+					Value:        attr.Value,
+				}
+				if tagMatchesFilters(tag, attrs, req.Filters) {
+					tags = append(tags, tag)
+				}
+			}
+		}
+	}
+	slices.SortFunc(tags, func(a, b api.TagDescription) int {
+		if c := strings.Compare(a.ResourceID, b.ResourceID); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Key, b.Key)
+	})
+
+	tags, nextToken, err := applyNextToken(tags, req.NextToken, req.MaxResults)
+	if err != nil {
+		return nil, err
+	}
+	return &api.DescribeTagsResponse{
+		Tags:      tags,
+		NextToken: nextToken,
+	}, nil
+}
+
+func isSupportedTagFilter(name string) bool {
+	switch name {
+	case "key", "value", "resource-id", "resource-type":
+		return true
+	}
+	return strings.HasPrefix(name, "tag:")
+}
+
+func tagMatchesFilters(tag api.TagDescription, attrs storage.Attributes, filters []api.Filter) bool {
+	for _, f := range filters {
+		var value string
+		switch name := *f.Name; {
+		case name == "key":
+			value = tag.Key
+		case name == "value":
+			value = tag.Value
+		case name == "resource-id":
+			value = tag.ResourceID
+		case name == "resource-type":
+			value = string(tag.ResourceType)
+		case strings.HasPrefix(name, "tag:"):
+			tagValue, found := attrs.Key(storage.TagAttributeName(name[4:]))
+			if !found {
+				return false
+			}
+			value = tagValue
+		}
+		if !slices.Contains(f.Values, value) {
+			return false
+		}
+	}
+	return true
+}
+
 func (d *Dispatcher) syncIMDSTagsForResources(resourceIDs []string) error {
 	for _, resourceID := range resourceIDs {
 		if !strings.HasPrefix(resourceID, instanceIDPrefix) {
@@ -757,6 +1231,33 @@ func applyNextToken[E any](elems []E, nextToken *string, maxResults *int) ([]E,
 	return elems, nextNextToken, nil
 }
 
+// applyKeyNextToken paginates elems, which must be sorted by key, using an
+// opaque token that encodes the key of the last returned element. Unlike
+// applyNextToken, resuming from a token skips every element already seen,
+// so elements added or removed between pages don't shift the results.
+func applyKeyNextToken[E any](elems []E, key func(E) string, nextToken *string, maxResults *int) ([]E, *string, error) {
+	if nextToken != nil {
+		lastKey, err := base64.RawURLEncoding.DecodeString(*nextToken)
+		if err != nil || len(lastKey) == 0 {
+			return nil, nil, api.InvalidParameterValueError("NextToken", *nextToken)
+		}
+		start, found := slices.BinarySearchFunc(elems, string(lastKey), func(e E, k string) int {
+			return strings.Compare(key(e), k)
+		})
+		if found {
+			start++
+		}
+		elems = elems[start:]
+	}
+	var nextNextToken *string
+	if maxResults != nil && len(elems) > *maxResults {
+		elems = elems[:*maxResults]
+		t := base64.RawURLEncoding.EncodeToString([]byte(key(elems[len(elems)-1])))
+		nextNextToken = &t
+	}
+	return elems, nextNextToken, nil
+}
+
 func makeID(prefix string) (string, error) {
 	id, err := idgen.WithPrefix(prefix, idgen.AWSLikeHexIDLength)
 	if err != nil {
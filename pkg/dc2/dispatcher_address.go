@@ -0,0 +1,476 @@
+package dc2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/executor"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+const (
+	addressAllocationIDPrefix  = "eipalloc-"
+	addressAssociationIDPrefix = "eipassoc-"
+
+	addressDomainVPC  = "vpc"
+	addressPublicPool = "amazon"
+
+	attributeNameAddressPublicIP      = "AddressPublicIP"
+	attributeNameAddressInstanceID    = "AddressInstanceID"
+	attributeNameAddressAssociationID = "AddressAssociationID"
+
+	// attributeNameInstanceElasticIP stores the public IP of the address
+	// associated with an instance, which overrides the one assigned by the
+	// executor.
+	attributeNameInstanceElasticIP = "InstanceElasticIP"
+)
+
+// elasticIPRange is the pool Elastic IPs are allocated from. It's a
+// benchmarking range, so it never collides with real public addresses nor with
+// the private addresses assigned to containers.
+var elasticIPRange = netip.MustParsePrefix("198.18.0.0/15")
+
+type addressData struct {
+	AllocationID  string
+	PublicIP      string
+	InstanceID    string
+	AssociationID string
+	Tags          []api.Tag
+}
+
+func (d *Dispatcher) dispatchAllocateAddress(ctx context.Context, req *api.AllocateAddressRequest) (*api.AllocateAddressResponse, error) {
+	if err := validateTagSpecifications(req.TagSpecifications, types.ResourceTypeAddress); err != nil {
+		return nil, err
+	}
+	if domain := valueOrEmpty(req.Domain); domain != "" && domain != addressDomainVPC {
+		return nil, api.InvalidParameterValueError("Domain", domain)
+	}
+	addresses, err := d.listAddresses()
+	if err != nil {
+		return nil, err
+	}
+	publicIP, err := allocatePublicIP(addresses, valueOrEmpty(req.Address))
+	if err != nil {
+		return nil, err
+	}
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
+
+	allocationID, err := makeID(addressAllocationIDPrefix)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeAddress, ID: allocationID}); err != nil {
+		return nil, fmt.Errorf("registering address: %w", err)
+	}
+	attrs := []storage.Attribute{
+		{Key: attributeNameAddressPublicIP, Value: publicIP},
+	}
+	for _, tag := range tagSpecsToTags(req.TagSpecifications) {
+		attrs = append(attrs, storage.Attribute{Key: storage.TagAttributeName(tag.Key), Value: tag.Value})
+	}
+	if err := d.storage.SetResourceAttributes(allocationID, attrs); err != nil {
+		return nil, fmt.Errorf("saving address attributes: %w", err)
+	}
+	api.Logger(ctx).Info("allocated address", slog.String("allocation_id", allocationID), slog.String("public_ip", publicIP))
+	return &api.AllocateAddressResponse{
+		PublicIP:           publicIP,
+		AllocationID:       allocationID,
+		Domain:             addressDomainVPC,
+		PublicIPv4Pool:     addressPublicPool,
+		NetworkBorderGroup: d.opts.Region,
+	}, nil
+}
+
+func (d *Dispatcher) dispatchAssociateAddress(ctx context.Context, req *api.AssociateAddressRequest) (*api.AssociateAddressResponse, error) {
+	address, err := d.findAddress(req.AllocationID, req.PublicIP)
+	if err != nil {
+		return nil, err
+	}
+	instanceID := valueOrEmpty(req.InstanceID)
+	if instanceID == "" {
+		return nil, api.ErrWithCode("MissingParameter", fmt.Errorf("The request must contain the parameter InstanceId")) //nolint
+	}
+	if _, err := d.findResource(ctx, types.ResourceTypeInstance, instanceID); err != nil {
+		if errors.As(err, &storage.ErrResourceNotFound{}) {
+			return nil, api.ErrWithCode(api.ErrorCodeInstanceNotFound, fmt.Errorf("The instance ID '%s' does not exist", instanceID)) //nolint
+		}
+		return nil, err
+	}
+	instanceAttrs, err := d.storage.ResourceAttributes(instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving instance attributes: %w", err)
+	}
+	if terminatedAt, _ := instanceAttrs.Key(attributeNameInstanceTerminatedAt); terminatedAt != "" {
+		return nil, api.ErrWithCode("IncorrectInstanceState", fmt.Errorf("The instance '%s' is not in a valid state for this operation.", instanceID)) //nolint
+	}
+	if address.AssociationID != "" && address.InstanceID != instanceID && !req.AllowReassociation {
+		return nil, api.ErrWithCode(
+			"Resource.AlreadyAssociated",
+			fmt.Errorf("resource %s is already associated with associate-id %s", address.AllocationID, address.AssociationID),
+		)
+	}
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
+
+	// Both the address and the instance can only have a single association,
+	// so drop any previous ones before creating the new one.
+	if address.AssociationID != "" {
+		if err := d.disassociateAddress(ctx, *address); err != nil {
+			return nil, err
+		}
+	}
+	addresses, err := d.listAddresses()
+	if err != nil {
+		return nil, err
+	}
+	for _, previous := range addresses {
+		if previous.InstanceID == instanceID && previous.AssociationID != "" {
+			if err := d.disassociateAddress(ctx, previous); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	associationID, err := makeID(addressAssociationIDPrefix)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.storage.SetResourceAttributes(address.AllocationID, []storage.Attribute{
+		{Key: attributeNameAddressInstanceID, Value: instanceID},
+		{Key: attributeNameAddressAssociationID, Value: associationID},
+	}); err != nil {
+		return nil, fmt.Errorf("saving address association: %w", err)
+	}
+	if err := d.storage.SetResourceAttributes(instanceID, []storage.Attribute{
+		{Key: attributeNameInstanceElasticIP, Value: address.PublicIP},
+	}); err != nil {
+		return nil, fmt.Errorf("saving instance elastic IP: %w", err)
+	}
+	api.Logger(ctx).Info(
+		"associated address",
+		slog.String("allocation_id", address.AllocationID),
+		slog.String("association_id", associationID),
+		slog.String("instance_id", instanceID),
+	)
+	return &api.AssociateAddressResponse{AssociationID: associationID, Return: true}, nil
+}
+
+func (d *Dispatcher) dispatchDisassociateAddress(ctx context.Context, req *api.DisassociateAddressRequest) (*api.DisassociateAddressResponse, error) {
+	addresses, err := d.listAddresses()
+	if err != nil {
+		return nil, err
+	}
+	var address *addressData
+	switch {
+	case valueOrEmpty(req.AssociationID) != "":
+		associationID := valueOrEmpty(req.AssociationID)
+		idx := slices.IndexFunc(addresses, func(a addressData) bool { return a.AssociationID == associationID })
+		if idx < 0 {
+			return nil, api.ErrWithCode("InvalidAssociationID.NotFound", fmt.Errorf("The association ID '%s' does not exist", associationID)) //nolint
+		}
+		address = &addresses[idx]
+	case valueOrEmpty(req.PublicIP) != "":
+		address, err = d.findAddress(nil, req.PublicIP)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, api.ErrWithCode("MissingParameter", fmt.Errorf("Either public IP or association id must be specified")) //nolint
+	}
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
+	if address.AssociationID != "" {
+		if err := d.disassociateAddress(ctx, *address); err != nil {
+			return nil, err
+		}
+	}
+	return &api.DisassociateAddressResponse{Return: true}, nil
+}
+
+func (d *Dispatcher) dispatchReleaseAddress(ctx context.Context, req *api.ReleaseAddressRequest) (*api.ReleaseAddressResponse, error) {
+	address, err := d.findAddress(req.AllocationID, req.PublicIP)
+	if err != nil {
+		return nil, err
+	}
+	if address.AssociationID != "" {
+		return nil, api.ErrWithCode("InvalidIPAddress.InUse", fmt.Errorf("Address %s is in use.", address.PublicIP)) //nolint
+	}
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
+	if err := d.storage.RemoveResource(address.AllocationID); err != nil {
+		return nil, fmt.Errorf("releasing address: %w", err)
+	}
+	api.Logger(ctx).Info("released address", slog.String("allocation_id", address.AllocationID), slog.String("public_ip", address.PublicIP))
+	return &api.ReleaseAddressResponse{Return: true}, nil
+}
+
+func (d *Dispatcher) dispatchDescribeAddresses(ctx context.Context, req *api.DescribeAddressesRequest) (*api.DescribeAddressesResponse, error) {
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
+	addresses, err := d.listAddresses()
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range req.AllocationIDs {
+		if !slices.ContainsFunc(addresses, func(a addressData) bool { return a.AllocationID == id }) {
+			return nil, addressAllocationNotFoundError(id)
+		}
+	}
+	for _, ip := range req.PublicIPs {
+		if !slices.ContainsFunc(addresses, func(a addressData) bool { return a.PublicIP == ip }) {
+			return nil, addressPublicIPNotFoundError(ip)
+		}
+	}
+
+	out := make([]api.Address, 0, len(addresses))
+	for _, address := range addresses {
+		if len(req.AllocationIDs) > 0 && !slices.Contains(req.AllocationIDs, address.AllocationID) {
+			continue
+		}
+		if len(req.PublicIPs) > 0 && !slices.Contains(req.PublicIPs, address.PublicIP) {
+			continue
+		}
+		apiAddress, err := d.apiAddress(ctx, address)
+		if err != nil {
+			return nil, err
+		}
+		matches, err := addressMatchesFilters(apiAddress, req.Filters)
+		if err != nil {
+			return nil, err
+		}
+		if matches {
+			out = append(out, apiAddress)
+		}
+	}
+	slices.SortFunc(out, func(a, b api.Address) int {
+		return strings.Compare(a.AllocationID, b.AllocationID)
+	})
+	return &api.DescribeAddressesResponse{Addresses: out}, nil
+}
+
+// disassociateAddress removes the association of an address from both the
+// address and its instance.
+func (d *Dispatcher) disassociateAddress(ctx context.Context, address addressData) error {
+	if err := d.storage.RemoveResourceAttributes(address.AllocationID, []storage.Attribute{
+		{Key: attributeNameAddressInstanceID},
+		{Key: attributeNameAddressAssociationID},
+	}); err != nil {
+		return fmt.Errorf("removing address association: %w", err)
+	}
+	err := d.storage.RemoveResourceAttributes(address.InstanceID, []storage.Attribute{
+		{Key: attributeNameInstanceElasticIP, Value: address.PublicIP},
+	})
+	if err != nil && !errors.As(err, &storage.ErrResourceNotFound{}) {
+		return fmt.Errorf("removing instance elastic IP: %w", err)
+	}
+	api.Logger(ctx).Info(
+		"disassociated address",
+		slog.String("allocation_id", address.AllocationID),
+		slog.String("association_id", address.AssociationID),
+		slog.String("instance_id", address.InstanceID),
+	)
+	return nil
+}
+
+// listAddresses returns the allocated addresses. Associations with
+// instances that have been terminated are dropped, since EC2 releases them
+// on termination.
+func (d *Dispatcher) listAddresses() ([]addressData, error) {
+	resources, err := d.storage.RegisteredResources(types.ResourceTypeAddress)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving addresses: %w", err)
+	}
+	addresses := make([]addressData, 0, len(resources))
+	for _, r := range resources {
+		attrs, err := d.storage.ResourceAttributes(r.ID)
+		if err != nil {
+			return nil, fmt.Errorf("retrieving address attributes: %w", err)
+		}
+		address := addressData{AllocationID: r.ID, Tags: tagsFromAttributes(attrs)}
+		address.PublicIP, _ = attrs.Key(attributeNameAddressPublicIP)
+		address.InstanceID, _ = attrs.Key(attributeNameAddressInstanceID)
+		address.AssociationID, _ = attrs.Key(attributeNameAddressAssociationID)
+		if address.InstanceID != "" {
+			associated, err := d.instanceNotTerminated(address.InstanceID)
+			if err != nil {
+				return nil, err
+			}
+			if !associated {
+				address.InstanceID = ""
+				address.AssociationID = ""
+			}
+		}
+		addresses = append(addresses, address)
+	}
+	return addresses, nil
+}
+
+func (d *Dispatcher) instanceNotTerminated(instanceID string) (bool, error) {
+	attrs, err := d.storage.ResourceAttributes(instanceID)
+	if err != nil {
+		if errors.As(err, &storage.ErrResourceNotFound{}) {
+			return false, nil
+		}
+		return false, fmt.Errorf("retrieving instance attributes: %w", err)
+	}
+	terminatedAt, _ := attrs.Key(attributeNameInstanceTerminatedAt)
+	return terminatedAt == "", nil
+}
+
+// findAddress returns the address identified by either its allocation ID or
+// its public IP, preferring the former.
+func (d *Dispatcher) findAddress(allocationID *string, publicIP *string) (*addressData, error) {
+	if valueOrEmpty(allocationID) == "" && valueOrEmpty(publicIP) == "" {
+		return nil, api.ErrWithCode("MissingParameter", fmt.Errorf("The request must contain the parameter AllocationId")) //nolint
+	}
+	addresses, err := d.listAddresses()
+	if err != nil {
+		return nil, err
+	}
+	if id := valueOrEmpty(allocationID); id != "" {
+		idx := slices.IndexFunc(addresses, func(a addressData) bool { return a.AllocationID == id })
+		if idx < 0 {
+			return nil, addressAllocationNotFoundError(id)
+		}
+		return &addresses[idx], nil
+	}
+	ip := valueOrEmpty(publicIP)
+	idx := slices.IndexFunc(addresses, func(a addressData) bool { return a.PublicIP == ip })
+	if idx < 0 {
+		return nil, addressPublicIPNotFoundError(ip)
+	}
+	return &addresses[idx], nil
+}
+
+// instanceElasticIP returns the public IP of the Elastic IP associated with
+// an instance, or the empty string if there's none.
+func instanceElasticIP(attrs storage.Attributes) string {
+	if terminatedAt, _ := attrs.Key(attributeNameInstanceTerminatedAt); terminatedAt != "" {
+		return ""
+	}
+	publicIP, _ := attrs.Key(attributeNameInstanceElasticIP)
+	return publicIP
+}
+
+// allocatePublicIP returns the requested address if it's available, or the
+// first free one in elasticIPRange when none was requested.
+func allocatePublicIP(addresses []addressData, requested string) (string, error) {
+	allocated := make(map[string]bool, len(addresses))
+	for _, address := range addresses {
+		allocated[address.PublicIP] = true
+	}
+	if requested != "" {
+		addr, err := netip.ParseAddr(requested)
+		if err != nil || !addr.Is4() {
+			return "", api.InvalidParameterValueError("Address", requested)
+		}
+		if allocated[addr.String()] {
+			return "", api.ErrWithCode("InvalidAddress.InUse", fmt.Errorf("Address %s is already allocated.", requested)) //nolint
+		}
+		return addr.String(), nil
+	}
+	for addr := elasticIPRange.Addr().Next(); elasticIPRange.Contains(addr); addr = addr.Next() {
+		if !allocated[addr.String()] {
+			return addr.String(), nil
+		}
+	}
+	return "", api.ErrWithCode("AddressLimitExceeded", fmt.Errorf("The maximum number of addresses has been reached.")) //nolint
+}
+
+func (d *Dispatcher) apiAddress(ctx context.Context, address addressData) (api.Address, error) {
+	out := api.Address{
+		PublicIP:           address.PublicIP,
+		AllocationID:       address.AllocationID,
+		Domain:             addressDomainVPC,
+		PublicIPv4Pool:     addressPublicPool,
+		NetworkBorderGroup: d.opts.Region,
+		Tags:               address.Tags,
+	}
+	if address.AssociationID == "" {
+		return out, nil
+	}
+	out.InstanceID = &address.InstanceID
+	out.AssociationID = &address.AssociationID
+	descs, err := d.exe.DescribeInstances(ctx, executor.DescribeInstancesRequest{
+		InstanceIDs: []executor.InstanceID{executorInstanceID(address.InstanceID)},
+	})
+	if err != nil {
+		return api.Address{}, executorError(err)
+	}
+	if len(descs) > 0 {
+		instance, err := d.apiInstance(&descs[0])
+		if err != nil {
+			return api.Address{}, err
+		}
+		out.NetworkInterfaceID = &instance.NetworkInterfaces[0].NetworkInterfaceID
+		out.PrivateIPAddress = &instance.PrivateIPAddress
+	}
+	return out, nil
+}
+
+func addressMatchesFilters(address api.Address, filters []api.Filter) (bool, error) {
+	for _, filter := range filters {
+		if filter.Name == nil {
+			return false, api.InvalidParameterValueError("Filter.Name", "<missing>")
+		}
+		var value string
+		switch name := *filter.Name; {
+		case name == "allocation-id":
+			value = address.AllocationID
+		case name == "association-id":
+			value = valueOrEmpty(address.AssociationID)
+		case name == "domain":
+			value = address.Domain
+		case name == "instance-id":
+			value = valueOrEmpty(address.InstanceID)
+		case name == "network-border-group":
+			value = address.NetworkBorderGroup
+		case name == "network-interface-id":
+			value = valueOrEmpty(address.NetworkInterfaceID)
+		case name == "private-ip-address":
+			value = valueOrEmpty(address.PrivateIPAddress)
+		case name == "public-ip":
+			value = address.PublicIP
+		case name == "tag-key":
+			if !slices.ContainsFunc(address.Tags, func(tag api.Tag) bool { return slices.Contains(filter.Values, tag.Key) }) {
+				return false, nil
+			}
+			continue
+		case strings.HasPrefix(name, "tag:"):
+			tagKey := strings.TrimPrefix(name, "tag:")
+			if !slices.ContainsFunc(address.Tags, func(tag api.Tag) bool {
+				return tag.Key == tagKey && slices.Contains(filter.Values, tag.Value)
+			}) {
+				return false, nil
+			}
+			continue
+		default:
+			return false, api.InvalidParameterValueError("Filter.Name", name)
+		}
+		if !slices.Contains(filter.Values, value) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func addressAllocationNotFoundError(allocationID string) error {
+	return api.ErrWithCode("InvalidAllocationID.NotFound", fmt.Errorf("The allocation ID '%s' does not exist", allocationID)) //nolint
+}
+
+func addressPublicIPNotFoundError(publicIP string) error {
+	return api.ErrWithCode("InvalidAddress.NotFound", fmt.Errorf("Address '%s' not found.", publicIP)) //nolint
+}
@@ -0,0 +1,99 @@
+package dc2
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+)
+
+func TestElasticIPAddresses(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	d := &Dispatcher{
+		opts:    DispatcherOptions{Region: "us-east-1"},
+		exe:     newScalingExecutor(),
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+	runResp, err := d.dispatchRunInstances(ctx, &api.RunInstancesRequest{
+		ImageID:      "nginx",
+		InstanceType: "my-type",
+		MinCount:     2,
+		MaxCount:     2,
+	})
+	require.NoError(t, err)
+	require.Len(t, runResp.InstancesSet, 2)
+	instanceID := runResp.InstancesSet[0].InstanceID
+	otherInstanceID := runResp.InstancesSet[1].InstanceID
+
+	allocResp, err := d.dispatchAllocateAddress(ctx, &api.AllocateAddressRequest{Domain: new("vpc")})
+	require.NoError(t, err)
+	assert.Regexp(t, `^eipalloc-[0-9a-f]{17}$`, allocResp.AllocationID)
+	assert.Equal(t, "198.18.0.1", allocResp.PublicIP)
+	_, err = d.dispatchAllocateAddress(ctx, &api.AllocateAddressRequest{Address: new("198.18.0.1")})
+	var apiErr *api.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "InvalidAddress.InUse", apiErr.Code)
+
+	assocResp, err := d.dispatchAssociateAddress(ctx, &api.AssociateAddressRequest{
+		AllocationID: &allocResp.AllocationID,
+		InstanceID:   &instanceID,
+	})
+	require.NoError(t, err)
+	assert.Regexp(t, `^eipassoc-[0-9a-f]{17}$`, assocResp.AssociationID)
+
+	describeInstance := func(id string) api.Instance {
+		t.Helper()
+		resp, err := d.dispatchDescribeInstances(ctx, &api.DescribeInstancesRequest{InstanceIDs: []string{id}})
+		require.NoError(t, err)
+		require.Len(t, resp.ReservationSet, 1)
+		require.Len(t, resp.ReservationSet[0].InstancesSet, 1)
+		return resp.ReservationSet[0].InstancesSet[0]
+	}
+	instance := describeInstance(instanceID)
+	assert.Equal(t, allocResp.PublicIP, instance.PublicIPAddress)
+	assert.Equal(t, "ec2-198-18-0-1.us-east-1.compute.internal", instance.DNSName)
+	require.NotNil(t, instance.NetworkInterfaces[0].Association)
+	assert.Equal(t, allocResp.PublicIP, instance.NetworkInterfaces[0].Association.PublicIP)
+
+	describeResp, err := d.dispatchDescribeAddresses(ctx, &api.DescribeAddressesRequest{
+		Filters: []api.Filter{{Name: new("instance-id"), Values: []string{instanceID}}},
+	})
+	require.NoError(t, err)
+	require.Len(t, describeResp.Addresses, 1)
+	assert.Equal(t, allocResp.AllocationID, describeResp.Addresses[0].AllocationID)
+	assert.Equal(t, &assocResp.AssociationID, describeResp.Addresses[0].AssociationID)
+	describeResp, err = d.dispatchDescribeAddresses(ctx, &api.DescribeAddressesRequest{
+		Filters: []api.Filter{{Name: new("instance-id"), Values: []string{otherInstanceID}}},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, describeResp.Addresses)
+
+	// Moving the address requires AllowReassociation
+	_, err = d.dispatchAssociateAddress(ctx, &api.AssociateAddressRequest{
+		AllocationID: &allocResp.AllocationID,
+		InstanceID:   &otherInstanceID,
+	})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "Resource.AlreadyAssociated", apiErr.Code)
+
+	_, err = d.dispatchReleaseAddress(ctx, &api.ReleaseAddressRequest{AllocationID: &allocResp.AllocationID})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "InvalidIPAddress.InUse", apiErr.Code)
+
+	_, err = d.dispatchDisassociateAddress(ctx, &api.DisassociateAddressRequest{AssociationID: &assocResp.AssociationID})
+	require.NoError(t, err)
+	instance = describeInstance(instanceID)
+	assert.NotEqual(t, allocResp.PublicIP, instance.PublicIPAddress)
+
+	_, err = d.dispatchReleaseAddress(ctx, &api.ReleaseAddressRequest{AllocationID: &allocResp.AllocationID})
+	require.NoError(t, err)
+	_, err = d.dispatchDescribeAddresses(ctx, &api.DescribeAddressesRequest{AllocationIDs: []string{allocResp.AllocationID}})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "InvalidAllocationID.NotFound", apiErr.Code)
+}
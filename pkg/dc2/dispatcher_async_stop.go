@@ -0,0 +1,171 @@
+package dc2
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/executor"
+)
+
+// asyncStop is a background stop started by stopInstancesAsync.
+type asyncStop struct {
+	// started is set, with dispatchMu held, once the stop is about to call
+	// the executor. Until then, the stop can be dropped.
+	started bool
+	done    chan struct{}
+}
+
+// stopInstancesAsync reports instances as stopping right away and stops
+// them in the background. Instances that are not running are left alone and
+// reported with their current state, like synchronous stops do.
+func (d *Dispatcher) stopInstancesAsync(ctx context.Context, instanceIDs []executor.InstanceID, force bool) ([]executor.InstanceStateChange, error) {
+	descs, err := d.exe.DescribeInstances(ctx, executor.DescribeInstancesRequest{
+		InstanceIDs: instanceIDs,
+	})
+	if err != nil {
+		return nil, executorError(err)
+	}
+	descs = d.applyAsyncStopStates(descs)
+
+	changes := make([]executor.InstanceStateChange, 0, len(descs))
+	var stopping []executor.InstanceID
+	for _, desc := range descs {
+		change := executor.InstanceStateChange{
+			InstanceID:    desc.InstanceID,
+			PreviousState: desc.InstanceState,
+			CurrentState:  desc.InstanceState,
+		}
+		if desc.InstanceState == api.InstanceStatePending || desc.InstanceState == api.InstanceStateRunning {
+			change.CurrentState = api.InstanceStateStopping
+			stopping = append(stopping, desc.InstanceID)
+		}
+		changes = append(changes, change)
+	}
+	if len(stopping) == 0 {
+		return changes, nil
+	}
+	d.publishInstanceStateChanges(changes)
+
+	stop := &asyncStop{done: make(chan struct{})}
+	d.asyncStopMu.Lock()
+	if d.asyncStops == nil {
+		d.asyncStops = make(map[executor.InstanceID]*asyncStop)
+	}
+	for _, instanceID := range stopping {
+		d.asyncStops[instanceID] = stop
+	}
+	d.asyncStopMu.Unlock()
+
+	d.asyncStopWG.Add(1)
+	go func() {
+		defer d.asyncStopWG.Done()
+		defer close(stop.done)
+		// Instances started or terminated since the stop was requested
+		// are no longer pending, so check while holding the dispatch lock
+		d.dispatchMu.Lock()
+		pending := d.startAsyncStop(stop, stopping)
+		d.dispatchMu.Unlock()
+		defer d.finishAsyncStop(stop, pending)
+		if len(pending) == 0 {
+			return
+		}
+		stopCtx := context.WithoutCancel(ctx)
+		if _, err := d.stopInstancesWithProfileDelay(stopCtx, pending, force); err != nil {
+			api.Logger(stopCtx).Warn(
+				"failed to stop instances asynchronously",
+				slog.Any("instance_ids", apiInstanceIDs(pending)),
+				slog.Any("error", err),
+			)
+		}
+	}()
+	return changes, nil
+}
+
+// startAsyncStop marks the stop as started and returns the instances it's
+// still pending for. It must be called with dispatchMu held.
+func (d *Dispatcher) startAsyncStop(stop *asyncStop, instanceIDs []executor.InstanceID) []executor.InstanceID {
+	d.asyncStopMu.Lock()
+	defer d.asyncStopMu.Unlock()
+	stop.started = true
+	var pending []executor.InstanceID
+	for _, instanceID := range instanceIDs {
+		if d.asyncStops[instanceID] == stop {
+			pending = append(pending, instanceID)
+		}
+	}
+	return pending
+}
+
+func (d *Dispatcher) finishAsyncStop(stop *asyncStop, instanceIDs []executor.InstanceID) {
+	d.asyncStopMu.Lock()
+	defer d.asyncStopMu.Unlock()
+	for _, instanceID := range instanceIDs {
+		if d.asyncStops[instanceID] == stop {
+			delete(d.asyncStops, instanceID)
+		}
+	}
+}
+
+// dropAsyncStops drops the pending asynchronous stops of the given
+// instances, so they don't undo a start or termination requested after
+// them. Stops already calling the executor can't be dropped, so they're
+// waited for instead. It must be called with dispatchMu held.
+func (d *Dispatcher) dropAsyncStops(ctx context.Context, instanceIDs []executor.InstanceID) error {
+	d.asyncStopMu.Lock()
+	var started []*asyncStop
+	for _, instanceID := range instanceIDs {
+		stop, found := d.asyncStops[instanceID]
+		if !found {
+			continue
+		}
+		if stop.started {
+			started = append(started, stop)
+			continue
+		}
+		delete(d.asyncStops, instanceID)
+	}
+	d.asyncStopMu.Unlock()
+	for _, stop := range started {
+		select {
+		case <-stop.done:
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for asynchronous instance stop: %w", ctx.Err())
+		}
+	}
+	return nil
+}
+
+// applyAsyncStopStates reports instances with an in-flight asynchronous stop
+// as stopping until their container has actually stopped.
+func (d *Dispatcher) applyAsyncStopStates(descs []executor.InstanceDescription) []executor.InstanceDescription {
+	d.asyncStopMu.Lock()
+	defer d.asyncStopMu.Unlock()
+	if len(d.asyncStops) == 0 {
+		return descs
+	}
+	for i := range descs {
+		if _, found := d.asyncStops[descs[i].InstanceID]; !found {
+			continue
+		}
+		if descs[i].InstanceState == api.InstanceStatePending || descs[i].InstanceState == api.InstanceStateRunning {
+			descs[i].InstanceState = api.InstanceStateStopping
+		}
+	}
+	return descs
+}
+
+func (d *Dispatcher) waitForAsyncStops(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		d.asyncStopWG.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for asynchronous instance stops: %w", ctx.Err())
+	}
+}
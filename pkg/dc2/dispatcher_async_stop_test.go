@@ -0,0 +1,207 @@
+package dc2
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/executor"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+type asyncStopExecutor struct {
+	*exitCleanupExecutor
+	mu          sync.Mutex
+	state       api.InstanceState
+	releaseStop chan struct{}
+	// calls records the completed executor calls, in order
+	calls []string
+}
+
+func newAsyncStopExecutor() *asyncStopExecutor {
+	return &asyncStopExecutor{
+		exitCleanupExecutor: &exitCleanupExecutor{},
+		state:               api.InstanceStateRunning,
+		releaseStop:         make(chan struct{}),
+	}
+}
+
+func newAsyncStopDispatcher(t *testing.T, exe *asyncStopExecutor, instanceID string) *Dispatcher {
+	t.Helper()
+	dispatch := &Dispatcher{
+		opts: DispatcherOptions{
+			ExitResourceMode:      ExitResourceModeKeep,
+			AsyncStateTransitions: true,
+		},
+		exe:     exe,
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+	require.NoError(t, dispatch.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeInstance, ID: instanceID}))
+	return dispatch
+}
+
+func (e *asyncStopExecutor) recordedCalls() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return slices.Clone(e.calls)
+}
+
+func (e *asyncStopExecutor) setState(call string, instanceIDs []executor.InstanceID, state api.InstanceState) []executor.InstanceStateChange {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	changes := make([]executor.InstanceStateChange, len(instanceIDs))
+	for i, instanceID := range instanceIDs {
+		changes[i] = executor.InstanceStateChange{
+			InstanceID:    instanceID,
+			PreviousState: e.state,
+			CurrentState:  state,
+		}
+	}
+	e.state = state
+	e.calls = append(e.calls, call)
+	return changes
+}
+
+func (e *asyncStopExecutor) DescribeInstances(_ context.Context, req executor.DescribeInstancesRequest) ([]executor.InstanceDescription, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	descs := make([]executor.InstanceDescription, len(req.InstanceIDs))
+	for i, instanceID := range req.InstanceIDs {
+		descs[i] = executor.InstanceDescription{InstanceID: instanceID, InstanceState: e.state}
+	}
+	return descs, nil
+}
+
+func (e *asyncStopExecutor) StopInstances(ctx context.Context, req executor.StopInstancesRequest) ([]executor.InstanceStateChange, error) {
+	select {
+	case <-e.releaseStop:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return e.setState("stop", req.InstanceIDs, api.InstanceStateStopped), nil
+}
+
+func (e *asyncStopExecutor) StartInstances(_ context.Context, req executor.StartInstancesRequest) ([]executor.InstanceStateChange, error) {
+	return e.setState("start", req.InstanceIDs, api.InstanceStateRunning), nil
+}
+
+func (e *asyncStopExecutor) TerminateInstances(_ context.Context, req executor.TerminateInstancesRequest) ([]executor.InstanceStateChange, error) {
+	return e.setState("terminate", req.InstanceIDs, api.InstanceStateTerminated), nil
+}
+
+func TestStopInstancesAsyncStateTransitions(t *testing.T) {
+	t.Parallel()
+
+	exe := newAsyncStopExecutor()
+	const instanceID = "i-0123456789abcdef0"
+	dispatch := newAsyncStopDispatcher(t, exe, instanceID)
+
+	ctx := context.Background()
+	resp, err := dispatch.dispatchStopInstances(ctx, &api.StopInstancesRequest{InstanceIDs: []string{instanceID}})
+	require.NoError(t, err)
+	require.Len(t, resp.StoppingInstances, 1)
+	assert.Equal(t, api.InstanceStateRunning, resp.StoppingInstances[0].PreviousState)
+	assert.Equal(t, api.InstanceStateStopping, resp.StoppingInstances[0].CurrentState)
+
+	describeState := func() api.InstanceState {
+		descResp, err := dispatch.dispatchDescribeInstances(ctx, &api.DescribeInstancesRequest{InstanceIDs: []string{instanceID}})
+		require.NoError(t, err)
+		require.Len(t, descResp.ReservationSet, 1)
+		require.Len(t, descResp.ReservationSet[0].InstancesSet, 1)
+		return descResp.ReservationSet[0].InstancesSet[0].InstanceState
+	}
+	assert.Equal(t, api.InstanceStateStopping, describeState())
+
+	close(exe.releaseStop)
+	require.Eventually(t, func() bool {
+		return describeState() == api.InstanceStateStopped
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, dispatch.Close(ctx))
+}
+
+func TestAsyncStopDroppedByLaterRequest(t *testing.T) {
+	t.Parallel()
+
+	const instanceID = "i-0123456789abcdef0"
+	for _, tc := range []struct {
+		name     string
+		request  func(ctx context.Context, d *Dispatcher) error
+		expected []string
+	}{
+		{
+			name: "start",
+			request: func(ctx context.Context, d *Dispatcher) error {
+				_, err := d.dispatchStartInstances(ctx, &api.StartInstancesRequest{InstanceIDs: []string{instanceID}})
+				return err
+			},
+			expected: []string{"start"},
+		},
+		{
+			name: "terminate",
+			request: func(ctx context.Context, d *Dispatcher) error {
+				_, err := d.dispatchTerminateInstances(ctx, &api.TerminateInstancesRequest{InstanceIDs: []string{instanceID}})
+				return err
+			},
+			expected: []string{"terminate"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			exe := newAsyncStopExecutor()
+			close(exe.releaseStop)
+			dispatch := newAsyncStopDispatcher(t, exe, instanceID)
+			ctx := context.Background()
+
+			// Hold the dispatch lock like Dispatch does, so the request runs
+			// before the background stop checks whether it's still pending
+			dispatch.dispatchMu.Lock()
+			_, err := dispatch.dispatchStopInstances(ctx, &api.StopInstancesRequest{InstanceIDs: []string{instanceID}})
+			require.NoError(t, err)
+			require.NoError(t, tc.request(ctx, dispatch))
+			dispatch.dispatchMu.Unlock()
+
+			require.NoError(t, dispatch.Close(ctx))
+			assert.Equal(t, tc.expected, exe.recordedCalls())
+		})
+	}
+}
+
+func TestStartInstancesWaitsForStartedAsyncStop(t *testing.T) {
+	t.Parallel()
+
+	synctest.Test(t, func(t *testing.T) {
+		const instanceID = "i-0123456789abcdef0"
+		exe := newAsyncStopExecutor()
+		dispatch := newAsyncStopDispatcher(t, exe, instanceID)
+		ctx := t.Context()
+
+		_, err := dispatch.Dispatch(ctx, &api.StopInstancesRequest{InstanceIDs: []string{instanceID}})
+		require.NoError(t, err)
+		// The background stop is now blocked in the executor
+		synctest.Wait()
+
+		var wg sync.WaitGroup
+		wg.Go(func() {
+			_, err := dispatch.Dispatch(ctx, &api.StartInstancesRequest{InstanceIDs: []string{instanceID}})
+			assert.NoError(t, err)
+		})
+		synctest.Wait()
+		assert.Empty(t, exe.recordedCalls())
+
+		close(exe.releaseStop)
+		wg.Wait()
+		assert.Equal(t, []string{"stop", "start"}, exe.recordedCalls())
+		require.NoError(t, dispatch.Close(ctx))
+	})
+}
@@ -13,6 +13,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
+
 	"github.com/fiam/dc2/pkg/dc2/api"
 	"github.com/fiam/dc2/pkg/dc2/executor"
 	"github.com/fiam/dc2/pkg/dc2/storage"
@@ -29,34 +31,51 @@ const (
 	attributeNameAutoScalingGroupLaunchTemplateID                  = "AutoScalingGroupLaunchTemplateID"
 	attributeNameAutoScalingGroupLaunchTemplateName                = "AutoScalingGroupLaunchTemplateName"
 	attributeNameAutoScalingGroupLaunchTemplateVersion             = "AutoScalingGroupLaunchTemplateVersion"
+	attributeNameAutoScalingGroupLaunchTemplateVersionAlias        = "AutoScalingGroupLaunchTemplateVersionAlias"
 	attributeNameAutoScalingGroupLaunchTemplateImageID             = "AutoScalingGroupLaunchTemplateImageID"
 	attributeNameAutoScalingGroupLaunchTemplateType                = "AutoScalingGroupLaunchTemplateInstanceType"
 	attributeNameAutoScalingGroupLaunchTemplateUserData            = "AutoScalingGroupLaunchTemplateUserData"
 	attributeNameAutoScalingGroupLaunchTemplateBlockDeviceMappings = "AutoScalingGroupLaunchTemplateBlockDeviceMappings"
+	attributeNameAutoScalingGroupLaunchTemplateKeyName             = "AutoScalingGroupLaunchTemplateKeyName"
+	attributeNameAutoScalingGroupLaunchTemplateSecurityGroupIDs    = "AutoScalingGroupLaunchTemplateSecurityGroupIDs"
+	attributeNameAutoScalingGroupLaunchTemplateInstanceProfileArn  = "AutoScalingGroupLaunchTemplateInstanceProfileArn"
+	attributeNameAutoScalingGroupLaunchTemplateAvailabilityZone    = "AutoScalingGroupLaunchTemplateAvailabilityZone"
+	attributeNameAutoScalingGroupLaunchTemplateArchitecture        = "AutoScalingGroupLaunchTemplateArchitecture"
 	attributeNameAutoScalingGroupMixedInstancesPolicy              = "AutoScalingGroupMixedInstancesPolicy"
 	attributeNameAutoScalingGroupAvailabilityZones                 = "AutoScalingGroupAvailabilityZones"
 	attributeNameAutoScalingGroupVPCZoneIdentifier                 = "AutoScalingGroupVPCZoneIdentifier"
 	attributeNameAutoScalingGroupDefaultCooldown                   = "AutoScalingGroupDefaultCooldown"
 	attributeNameAutoScalingGroupHealthCheckType                   = "AutoScalingGroupHealthCheckType"
+	attributeNameAutoScalingGroupHealthCheckGracePeriod            = "AutoScalingGroupHealthCheckGracePeriod"
+	attributeNameAutoScalingGroupNewInstancesProtectedFromScaleIn  = "AutoScalingGroupNewInstancesProtectedFromScaleIn"
 	attributeNameAutoScalingGroupInstanceType                      = "AutoScalingGroupInstanceType"
 	attributeNameAutoScalingGroupWarmPoolEnabled                   = "AutoScalingGroupWarmPoolEnabled"
 	attributeNameAutoScalingGroupWarmPoolMinSize                   = "AutoScalingGroupWarmPoolMinSize"
 	attributeNameAutoScalingGroupWarmPoolMaxGroupPreparedCapacity  = "AutoScalingGroupWarmPoolMaxGroupPreparedCapacity"
 	attributeNameAutoScalingGroupWarmPoolState                     = "AutoScalingGroupWarmPoolState"
 	attributeNameAutoScalingGroupWarmPoolStatus                    = "AutoScalingGroupWarmPoolStatus"
+	attributeNameAutoScalingGroupStatus                            = "AutoScalingGroupStatus"
 	attributeNameAutoScalingGroupWarmPoolReuseOnScaleIn            = "AutoScalingGroupWarmPoolReuseOnScaleIn"
 	attributeNameAutoScalingInstanceWarmPool                       = "AutoScalingInstanceWarmPool"
 	attributeNameAutoScalingInstanceSynchronousProvisioning        = "AutoScalingInstanceSynchronousProvisioning"
+	attributeNameAutoScalingInstanceHealthStatus                   = "AutoScalingInstanceHealthStatus"
 	attributeNameAutoScalingTagPropagatePrefix                     = "AutoScalingTagPropagateAtLaunch:"
 	autoScalingTagResourceType                                     = "auto-scaling-group"
+	autoScalingGroupNameTagKey                                     = "aws:autoscaling:groupName"
+
+	autoScalingDefaultCooldown       = 300
+	autoScalingHealthStatus          = "Healthy"
+	autoScalingHealthStatusUnhealthy = "Unhealthy"
+	autoScalingHealthCheckType       = "EC2"
+	autoScalingLifecycleState        = "InService"
+	warmPoolStateStopped             = "Stopped"
+	warmPoolStateRunning             = "Running"
+	warmPoolStateHibernated          = "Hibernated"
 
-	autoScalingDefaultCooldown = 300
-	autoScalingHealthStatus    = "Healthy"
-	autoScalingHealthCheckType = "EC2"
-	autoScalingLifecycleState  = "InService"
-	warmPoolStateStopped       = "Stopped"
-	warmPoolStateRunning       = "Running"
-	warmPoolStateHibernated    = "Hibernated"
+	autoScalingGroupStatusDeleteInProgress = "Delete in progress"
+
+	autoScalingTerminationReasonAPI          = "api-termination"
+	autoScalingTerminationReasonSubnetUpdate = "subnet-update"
 
 	autoScalingWarmLifecycleStatePending    = "Warmed:Pending"
 	autoScalingWarmLifecycleStateStopped    = "Warmed:Stopped"
@@ -85,17 +104,41 @@ type autoScalingGroupData struct {
 	LaunchTemplateInstanceType        string
 	LaunchTemplateUserData            string
 	LaunchTemplateBlockDeviceMappings []api.RunInstancesBlockDeviceMapping
-	MixedInstancesPolicy              *api.AutoScalingMixedInstancesPolicy
-	AvailabilityZones                 []string
-	VPCZoneIdentifier                 *string
-	DefaultCooldown                   int
-	HealthCheckType                   string
-	WarmPoolEnabled                   bool
-	WarmPoolMinSize                   int
-	WarmPoolMaxGroupPreparedCapacity  *int
-	WarmPoolState                     string
-	WarmPoolStatus                    string
-	WarmPoolReuseOnScaleIn            *bool
+	LaunchTemplateKeyName             string
+	LaunchTemplateSecurityGroupIDs    []string
+	// LaunchTemplateInstanceProfileArn is the resolved ARN of the launch
+	// template IAM instance profile.
+	LaunchTemplateInstanceProfileArn string
+	// LaunchTemplateAvailabilityZone is the availability zone from the
+	// launch template placement, used instead of the region default.
+	LaunchTemplateAvailabilityZone string
+	// LaunchTemplateArchitecture is the architecture requested by the launch
+	// template, if any.
+	LaunchTemplateArchitecture string
+	// LaunchTemplateVersionAlias is $Default or $Latest when the group was
+	// configured with them. LaunchTemplateVersion then holds the version they
+	// resolved to, and is resolved again before launching instances.
+	LaunchTemplateVersionAlias       string
+	MixedInstancesPolicy             *api.AutoScalingMixedInstancesPolicy
+	AvailabilityZones                []string
+	VPCZoneIdentifier                *string
+	DefaultCooldown                  int
+	HealthCheckType                  string
+	TargetGroupARNs                  []string
+	HealthCheckGracePeriod           int
+	NewInstancesProtectedFromScaleIn bool
+	WarmPoolEnabled                  bool
+	WarmPoolMinSize                  int
+	WarmPoolMaxGroupPreparedCapacity *int
+	WarmPoolState                    string
+	WarmPoolStatus                   string
+	WarmPoolReuseOnScaleIn           *bool
+	// ScaleInDrainSeconds overrides DispatcherOptions.ScaleInDrainDelay
+	// for the group when set.
+	ScaleInDrainSeconds *int
+	// Status is only set while the group is being deleted in the
+	// background.
+	Status string
 }
 
 type launchInstancesRecord struct {
@@ -154,10 +197,10 @@ func (d *Dispatcher) dispatchCreateAutoScalingGroup(ctx context.Context, req *ap
 		return nil, api.InvalidParameterValueError("Tags", fmt.Sprintf("length %d exceeds limit %d", len(req.Tags), tagRequestCountLimit))
 	}
 	if req.MinSize == nil {
-		return nil, api.ErrWithCode("ValidationError", fmt.Errorf("MinSize is required"))
+		return nil, api.ValidationError("MinSize", "MinSize is required")
 	}
 	if req.MaxSize == nil {
-		return nil, api.ErrWithCode("ValidationError", fmt.Errorf("MaxSize is required"))
+		return nil, api.ValidationError("MaxSize", "MaxSize is required")
 	}
 
 	lt, mixedInstancesPolicy, err := d.resolveAutoScalingGroupLaunchTemplate(ctx, req.LaunchTemplate, req.MixedInstancesPolicy)
@@ -176,6 +219,28 @@ func (d *Dispatcher) dispatchCreateAutoScalingGroup(ctx context.Context, req *ap
 	if err := validateDesiredCapacity(desiredCapacity, minSize, maxSize); err != nil {
 		return nil, err
 	}
+	healthCheckGracePeriod := 0
+	if req.HealthCheckGracePeriod != nil {
+		if err := validateHealthCheckGracePeriod(*req.HealthCheckGracePeriod); err != nil {
+			return nil, err
+		}
+		healthCheckGracePeriod = *req.HealthCheckGracePeriod
+	}
+	healthCheckType := autoScalingHealthCheckType
+	if req.HealthCheckType != nil {
+		if err := validateHealthCheckType(*req.HealthCheckType); err != nil {
+			return nil, err
+		}
+		healthCheckType = *req.HealthCheckType
+	}
+	if err := validateTargetGroupARNs(req.TargetGroupARNs); err != nil {
+		return nil, err
+	}
+	if req.ScaleInDrainSeconds != nil {
+		if err := validateScaleInDrainSeconds(*req.ScaleInDrainSeconds); err != nil {
+			return nil, err
+		}
+	}
 	instanceType, err := d.resolveAutoScalingGroupInstanceType(lt, mixedInstancesPolicy)
 	if err != nil {
 		return nil, err
@@ -183,15 +248,24 @@ func (d *Dispatcher) dispatchCreateAutoScalingGroup(ctx context.Context, req *ap
 	if lt.ImageID == "" || instanceType == "" {
 		return nil, api.ErrWithCode("ValidationError", fmt.Errorf("launch template must define ImageId and a resolvable InstanceType"))
 	}
+	instanceProfileArn, err := launchTemplateInstanceProfileArn(lt)
+	if err != nil {
+		return nil, err
+	}
 	vpcZoneIdentifier := normalizeOptionalString(req.VPCZoneIdentifier)
 	availabilityZones, err := normalizeAutoScalingAvailabilityZones(req.AvailabilityZones)
 	if err != nil {
 		return nil, err
 	}
-	if err := validateAutoScalingPlacement(vpcZoneIdentifier, availabilityZones); err != nil {
+	if err := d.validateAutoScalingPlacement(vpcZoneIdentifier, availabilityZones); err != nil {
 		return nil, err
 	}
 
+	unlock, err := d.lockAutoScalingGroup(ctx, req.AutoScalingGroupName)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
 	if err := d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeAutoScalingGroup, ID: req.AutoScalingGroupName}); err != nil {
 		if errors.As(err, &storage.ErrDuplicatedResource{}) {
 			return nil, api.ErrWithCode("AlreadyExists", fmt.Errorf("auto scaling group %q already exists", req.AutoScalingGroupName))
@@ -208,16 +282,26 @@ func (d *Dispatcher) dispatchCreateAutoScalingGroup(ctx context.Context, req *ap
 		LaunchTemplateID:                  lt.ID,
 		LaunchTemplateName:                lt.Name,
 		LaunchTemplateVersion:             lt.Version,
+		LaunchTemplateVersionAlias:        autoScalingLaunchTemplateVersionAlias(req.LaunchTemplate, req.MixedInstancesPolicy),
 		LaunchTemplateImageID:             lt.ImageID,
 		LaunchTemplateInstanceType:        instanceType,
 		LaunchTemplateUserData:            lt.UserData,
 		LaunchTemplateBlockDeviceMappings: cloneBlockDeviceMappings(lt.BlockDeviceMappings),
+		LaunchTemplateKeyName:             lt.KeyName,
+		LaunchTemplateSecurityGroupIDs:    cloneStringSlice(lt.SecurityGroupIDs),
+		LaunchTemplateInstanceProfileArn:  instanceProfileArn,
+		LaunchTemplateAvailabilityZone:    launchTemplateAvailabilityZone(lt),
+		LaunchTemplateArchitecture:        lt.Architecture,
 		MixedInstancesPolicy:              mixedInstancesPolicy,
 		AvailabilityZones:                 availabilityZones,
 		VPCZoneIdentifier:                 vpcZoneIdentifier,
 		DefaultCooldown:                   autoScalingDefaultCooldown,
-		HealthCheckType:                   autoScalingHealthCheckType,
+		HealthCheckType:                   healthCheckType,
+		TargetGroupARNs:                   cloneStringSlice(req.TargetGroupARNs),
+		HealthCheckGracePeriod:            healthCheckGracePeriod,
+		NewInstancesProtectedFromScaleIn:  req.NewInstancesProtectedFromScaleIn != nil && *req.NewInstancesProtectedFromScaleIn,
 		WarmPoolState:                     warmPoolStateStopped,
+		ScaleInDrainSeconds:               req.ScaleInDrainSeconds,
 	}
 	if err := d.saveAutoScalingGroupData(&group); err != nil {
 		_ = d.storage.RemoveResource(req.AutoScalingGroupName)
@@ -345,27 +429,88 @@ func (d *Dispatcher) resolveAutoScalingGroupInstanceType(
 func (d *Dispatcher) resolveAutoScalingMixedInstancesPolicyInstanceType(
 	mixedInstancesPolicy *api.AutoScalingMixedInstancesPolicy,
 ) (string, bool, error) {
+	instanceTypes, err := d.resolveAutoScalingMixedInstancesPolicyInstanceTypes(mixedInstancesPolicy)
+	if err != nil || len(instanceTypes) == 0 {
+		return "", false, err
+	}
+	return instanceTypes[0], true, nil
+}
+
+// resolveAutoScalingMixedInstancesPolicyInstanceTypes returns the instance
+// types of the policy overrides in order, without duplicates.
+func (d *Dispatcher) resolveAutoScalingMixedInstancesPolicyInstanceTypes(
+	mixedInstancesPolicy *api.AutoScalingMixedInstancesPolicy,
+) ([]string, error) {
 	if mixedInstancesPolicy == nil || mixedInstancesPolicy.LaunchTemplate == nil {
-		return "", false, nil
+		return nil, nil
 	}
+	var instanceTypes []string
 	for _, override := range mixedInstancesPolicy.LaunchTemplate.Overrides {
+		instanceType := ""
 		if override.InstanceType != nil {
-			instanceType := strings.TrimSpace(*override.InstanceType)
-			if instanceType != "" {
-				return instanceType, true, nil
-			}
+			instanceType = strings.TrimSpace(*override.InstanceType)
 		}
-		if override.InstanceRequirements != nil {
-			instanceType, err := d.resolveAutoScalingInstanceTypeFromRequirements(override.InstanceRequirements)
+		if instanceType == "" && override.InstanceRequirements != nil {
+			var err error
+			instanceType, err = d.resolveAutoScalingInstanceTypeFromRequirements(override.InstanceRequirements)
 			if err != nil {
-				return "", false, err
+				return nil, err
+			}
+		}
+		if instanceType != "" && !slices.Contains(instanceTypes, instanceType) {
+			instanceTypes = append(instanceTypes, instanceType)
+		}
+	}
+	return instanceTypes, nil
+}
+
+// autoScalingLaunchInstanceTypes returns the instance type for each of the
+// count instances about to be launched into the group. With a mixed instances
+// policy, launches are spread round-robin across the override types, taking
+// the types of the instances already in the group into account.
+func (d *Dispatcher) autoScalingLaunchInstanceTypes(
+	ctx context.Context,
+	group *autoScalingGroupData,
+	count int,
+) ([]string, error) {
+	instanceTypes, err := d.resolveAutoScalingMixedInstancesPolicyInstanceTypes(group.MixedInstancesPolicy)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, 0, count)
+	if len(instanceTypes) <= 1 {
+		for range count {
+			out = append(out, group.LaunchTemplateInstanceType)
+		}
+		return out, nil
+	}
+	instanceIDs, err := d.autoScalingGroupInstanceIDsReadOnly(ctx, group.Name)
+	if err != nil {
+		return nil, err
+	}
+	running := make(map[string]int, len(instanceTypes))
+	for _, instanceID := range instanceIDs {
+		attrs, err := d.storage.ResourceAttributes(instanceID)
+		if err != nil {
+			if errors.As(err, &storage.ErrResourceNotFound{}) {
+				continue
 			}
-			if instanceType != "" {
-				return instanceType, true, nil
+			return nil, fmt.Errorf("retrieving instance attributes: %w", err)
+		}
+		instanceType, _ := attrs.Key(attributeNameAutoScalingGroupInstanceType)
+		running[instanceType]++
+	}
+	for range count {
+		next := instanceTypes[0]
+		for _, instanceType := range instanceTypes[1:] {
+			if running[instanceType] < running[next] {
+				next = instanceType
 			}
 		}
+		running[next]++
+		out = append(out, next)
 	}
-	return "", false, nil
+	return out, nil
 }
 
 func (d *Dispatcher) resolveAutoScalingInstanceTypeFromRequirements(
@@ -568,8 +713,13 @@ func (d *Dispatcher) dispatchDescribeAutoScalingGroups(ctx context.Context, req
 func (d *Dispatcher) dispatchLaunchInstances(ctx context.Context, req *api.LaunchInstancesRequest) (*api.LaunchInstancesResponse, error) {
 	clientToken := strings.TrimSpace(req.ClientToken)
 	if clientToken == "" {
-		return nil, api.ErrWithCode("ValidationError", fmt.Errorf("ClientToken is required"))
+		return nil, api.ValidationError("ClientToken", "ClientToken is required")
+	}
+	unlock, err := d.lockAutoScalingGroup(ctx, req.AutoScalingGroupName)
+	if err != nil {
+		return nil, err
 	}
+	defer unlock()
 	group, err := d.loadAutoScalingGroupData(ctx, req.AutoScalingGroupName)
 	if err != nil {
 		return nil, err
@@ -600,6 +750,10 @@ func (d *Dispatcher) dispatchLaunchInstances(ctx context.Context, req *api.Launc
 	if err != nil {
 		return nil, err
 	}
+	placement, err = d.alignLaunchInstancesPlacement(group, placement, len(req.SubnetIDs) > 0)
+	if err != nil {
+		return nil, err
+	}
 	createdIDs, err := d.createAutoScalingInstances(ctx, group, requestedCapacity, autoScalingInstanceLaunchOptions{
 		AvailabilityZone:        placement.AvailabilityZone,
 		SubnetID:                placement.SubnetID,
@@ -610,25 +764,39 @@ func (d *Dispatcher) dispatchLaunchInstances(ctx context.Context, req *api.Launc
 	}
 
 	groupName := group.Name
-	instanceType := group.LaunchTemplateInstanceType
 	availabilityZone := placement.AvailabilityZone
 	availabilityZoneID := placement.AvailabilityZoneID
 	subnetID := placement.SubnetID
 	marketType := launchInstancesMarketType(group)
+	// Instances launched with a mixed instances policy are reported in one
+	// collection per instance type
+	var collections []api.InstanceCollection
+	collectionIndexes := make(map[string]int)
+	for _, instanceID := range createdIDs {
+		attrs, err := d.storage.ResourceAttributes(instanceID)
+		if err != nil {
+			return nil, fmt.Errorf("retrieving instance attributes: %w", err)
+		}
+		instanceType, _ := attrs.Key(attributeNameAutoScalingGroupInstanceType)
+		idx, ok := collectionIndexes[instanceType]
+		if !ok {
+			idx = len(collections)
+			collectionIndexes[instanceType] = idx
+			collections = append(collections, api.InstanceCollection{
+				AvailabilityZone:   &availabilityZone,
+				AvailabilityZoneID: &availabilityZoneID,
+				InstanceType:       &instanceType,
+				MarketType:         &marketType,
+				SubnetID:           &subnetID,
+			})
+		}
+		collections[idx].InstanceIDs = append(collections[idx].InstanceIDs, instanceID)
+	}
 	response := &api.LaunchInstancesResponse{
 		LaunchInstancesResult: api.LaunchInstancesResult{
 			AutoScalingGroupName: &groupName,
 			ClientToken:          &clientToken,
-			Instances: []api.InstanceCollection{
-				{
-					AvailabilityZone:   &availabilityZone,
-					AvailabilityZoneID: &availabilityZoneID,
-					InstanceIDs:        slices.Clone(createdIDs),
-					InstanceType:       &instanceType,
-					MarketType:         &marketType,
-					SubnetID:           &subnetID,
-				},
-			},
+			Instances:            collections,
 		},
 	}
 	d.cacheLaunchInstancesResponse(group.Name, clientToken, response)
@@ -641,11 +809,7 @@ func (d *Dispatcher) reconcileAllAutoScalingGroups(ctx context.Context) error {
 		return fmt.Errorf("retrieving auto scaling groups for reconciliation: %w", err)
 	}
 	for _, resource := range resources {
-		group, err := d.loadAutoScalingGroupData(ctx, resource.ID)
-		if err != nil {
-			return err
-		}
-		if err := d.reconcileAutoScalingGroup(ctx, group); err != nil {
+		if err := d.reconcileAutoScalingGroupNamed(ctx, resource.ID); err != nil {
 			return err
 		}
 	}
@@ -687,27 +851,107 @@ func (d *Dispatcher) reconcilePendingAutoScalingEvents(ctx context.Context) erro
 	}
 
 	for groupName := range groupsToReconcile {
-		if _, err := d.findResource(ctx, types.ResourceTypeAutoScalingGroup, groupName); err != nil {
-			if errors.As(err, &storage.ErrResourceNotFound{}) {
-				continue
-			}
-			return err
-		}
-		group, err := d.loadAutoScalingGroupData(ctx, groupName)
-		if err != nil {
-			return err
-		}
 		api.Logger(ctx).Info(
 			"reconciling auto scaling group from lifecycle events",
 			slog.String("auto_scaling_group_name", groupName),
 		)
-		if err := d.reconcileAutoScalingGroup(ctx, group); err != nil {
+		if err := d.reconcileAutoScalingGroupNamed(ctx, groupName); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// reconcileAutoScalingGroupNamed reconciles the given group while holding
+// its scaling lock. Groups deleted in the meantime are skipped.
+func (d *Dispatcher) reconcileAutoScalingGroupNamed(ctx context.Context, groupName string) error {
+	unlock, err := d.lockAutoScalingGroup(ctx, groupName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if _, err := d.findResource(ctx, types.ResourceTypeAutoScalingGroup, groupName); err != nil {
+		if errors.As(err, &storage.ErrResourceNotFound{}) {
+			return nil
+		}
+		return err
+	}
+	group, err := d.loadAutoScalingGroupData(ctx, groupName)
+	if err != nil {
+		return err
+	}
+	return d.reconcileAutoScalingGroup(ctx, group)
+}
+
+// lockAutoScalingGroup serializes scaling, membership changes and warm pool
+// reconciliation for a group, since they all read the group instances and
+// capacity and launch or terminate based on them. It must be called with
+// dispatchMu held. Launches release dispatchMu while waiting on test profile
+// delays, so while another caller holds the group, dispatchMu is released
+// too, letting it finish. Callers must load the group data after acquiring
+// the lock.
+func (d *Dispatcher) lockAutoScalingGroup(ctx context.Context, groupName string) (func(), error) {
+	for {
+		d.autoScalingGroupLockMu.Lock()
+		if d.autoScalingGroupLocks == nil {
+			d.autoScalingGroupLocks = make(map[string]chan struct{})
+		}
+		released, locked := d.autoScalingGroupLocks[groupName]
+		if !locked {
+			released = make(chan struct{})
+			d.autoScalingGroupLocks[groupName] = released
+			d.autoScalingGroupLockMu.Unlock()
+			return func() {
+				d.autoScalingGroupLockMu.Lock()
+				delete(d.autoScalingGroupLocks, groupName)
+				d.autoScalingGroupLockMu.Unlock()
+				close(released)
+			}, nil
+		}
+		d.autoScalingGroupLockMu.Unlock()
+
+		d.dispatchMu.Unlock()
+		select {
+		case <-ctx.Done():
+			d.dispatchMu.Lock()
+			return nil, ctx.Err()
+		case <-released:
+		}
+		d.dispatchMu.Lock()
+	}
+}
+
+// lockAutoScalingInstanceGroup locks the group the instance belongs to and
+// returns the instance attributes, read after acquiring the lock. Instances
+// outside any group, or unknown ones, return a no-op unlock.
+func (d *Dispatcher) lockAutoScalingInstanceGroup(ctx context.Context, instanceID string) (storage.Attributes, func(), error) {
+	for {
+		attrs, err := d.storage.ResourceAttributes(instanceID)
+		if err != nil && !errors.As(err, &storage.ErrResourceNotFound{}) {
+			return nil, nil, fmt.Errorf("retrieving instance attributes: %w", err)
+		}
+		groupName, _ := attrs.Key(attributeNameAutoScalingGroupName)
+		if groupName == "" {
+			return attrs, func() {}, nil
+		}
+		unlock, err := d.lockAutoScalingGroup(ctx, groupName)
+		if err != nil {
+			return nil, nil, err
+		}
+		attrs, err = d.storage.ResourceAttributes(instanceID)
+		if err != nil && !errors.As(err, &storage.ErrResourceNotFound{}) {
+			unlock()
+			return nil, nil, fmt.Errorf("retrieving instance attributes: %w", err)
+		}
+		// The instance might have been detached or attached to another
+		// group while waiting for the lock
+		if current, _ := attrs.Key(attributeNameAutoScalingGroupName); current == groupName {
+			return attrs, unlock, nil
+		}
+		unlock()
+	}
+}
+
 func asGeneralFilters(filters []api.AutoScalingFilter) []api.Filter {
 	out := make([]api.Filter, 0, len(filters))
 	for _, filter := range filters {
@@ -763,6 +1007,11 @@ func autoScalingTagPropagateAtLaunchByKey(attrs storage.Attributes) map[string]b
 }
 
 func (d *Dispatcher) dispatchUpdateAutoScalingGroup(ctx context.Context, req *api.UpdateAutoScalingGroupRequest) (*api.UpdateAutoScalingGroupResponse, error) {
+	unlock, err := d.lockAutoScalingGroup(ctx, req.AutoScalingGroupName)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
 	group, err := d.loadAutoScalingGroupData(ctx, req.AutoScalingGroupName)
 	if err != nil {
 		return nil, err
@@ -787,6 +1036,27 @@ func (d *Dispatcher) dispatchUpdateAutoScalingGroup(ctx context.Context, req *ap
 	if err := validateDesiredCapacity(group.DesiredCapacity, group.MinSize, group.MaxSize); err != nil {
 		return nil, err
 	}
+	if req.HealthCheckGracePeriod != nil {
+		if err := validateHealthCheckGracePeriod(*req.HealthCheckGracePeriod); err != nil {
+			return nil, err
+		}
+		group.HealthCheckGracePeriod = *req.HealthCheckGracePeriod
+	}
+	if req.HealthCheckType != nil {
+		if err := validateHealthCheckType(*req.HealthCheckType); err != nil {
+			return nil, err
+		}
+		group.HealthCheckType = *req.HealthCheckType
+	}
+	if req.NewInstancesProtectedFromScaleIn != nil {
+		group.NewInstancesProtectedFromScaleIn = *req.NewInstancesProtectedFromScaleIn
+	}
+	if req.ScaleInDrainSeconds != nil {
+		if err := validateScaleInDrainSeconds(*req.ScaleInDrainSeconds); err != nil {
+			return nil, err
+		}
+		group.ScaleInDrainSeconds = req.ScaleInDrainSeconds
+	}
 
 	if req.LaunchTemplate != nil || req.MixedInstancesPolicy != nil {
 		lt, mixedInstancesPolicy, err := d.resolveAutoScalingGroupLaunchTemplate(ctx, req.LaunchTemplate, req.MixedInstancesPolicy)
@@ -800,18 +1070,19 @@ func (d *Dispatcher) dispatchUpdateAutoScalingGroup(ctx context.Context, req *ap
 		if lt.ImageID == "" || instanceType == "" {
 			return nil, api.ErrWithCode("ValidationError", fmt.Errorf("launch template must define ImageId and a resolvable InstanceType"))
 		}
+		instanceProfileArn, err := launchTemplateInstanceProfileArn(lt)
+		if err != nil {
+			return nil, err
+		}
 		launchTemplateChanged = autoScalingGroupLaunchTemplateChanged(group, lt, instanceType)
-		group.LaunchTemplateID = lt.ID
-		group.LaunchTemplateName = lt.Name
-		group.LaunchTemplateVersion = lt.Version
-		group.LaunchTemplateImageID = lt.ImageID
-		group.LaunchTemplateInstanceType = instanceType
-		group.LaunchTemplateUserData = lt.UserData
-		group.LaunchTemplateBlockDeviceMappings = cloneBlockDeviceMappings(lt.BlockDeviceMappings)
-		group.MixedInstancesPolicy = mixedInstancesPolicy
+		setAutoScalingGroupLaunchTemplate(group, lt, instanceType, instanceProfileArn, mixedInstancesPolicy)
+		group.LaunchTemplateVersionAlias = autoScalingLaunchTemplateVersionAlias(req.LaunchTemplate, req.MixedInstancesPolicy)
 	}
+	subnetsChanged := false
 	if req.VPCZoneIdentifier != nil {
+		previousSubnetIDs := autoScalingGroupSubnetIDs(group)
 		group.VPCZoneIdentifier = normalizeOptionalString(req.VPCZoneIdentifier)
+		subnetsChanged = !slices.Equal(previousSubnetIDs, autoScalingGroupSubnetIDs(group))
 	}
 	if req.AvailabilityZones != nil {
 		availabilityZones, err := normalizeAutoScalingAvailabilityZones(req.AvailabilityZones)
@@ -820,7 +1091,7 @@ func (d *Dispatcher) dispatchUpdateAutoScalingGroup(ctx context.Context, req *ap
 		}
 		group.AvailabilityZones = availabilityZones
 	}
-	if err := validateAutoScalingPlacement(group.VPCZoneIdentifier, group.AvailabilityZones); err != nil {
+	if err := d.validateAutoScalingPlacement(group.VPCZoneIdentifier, group.AvailabilityZones); err != nil {
 		return nil, err
 	}
 
@@ -832,12 +1103,181 @@ func (d *Dispatcher) dispatchUpdateAutoScalingGroup(ctx context.Context, req *ap
 			return nil, err
 		}
 	}
+	if subnetsChanged {
+		if err := d.replaceAutoScalingInstancesOutsideSubnets(ctx, group); err != nil {
+			return nil, err
+		}
+	}
 	if err := d.scaleAutoScalingGroupTo(ctx, group, group.DesiredCapacity); err != nil {
 		return nil, err
 	}
 	return &api.UpdateAutoScalingGroupResponse{}, nil
 }
 
+// replaceAutoScalingInstancesOutsideSubnets terminates the group instances
+// running in subnets that are no longer part of its VPCZoneIdentifier, so
+// scaling launches their replacements in the updated subnets.
+func (d *Dispatcher) replaceAutoScalingInstancesOutsideSubnets(ctx context.Context, group *autoScalingGroupData) error {
+	subnetIDs := autoScalingGroupSubnetIDs(group)
+	if len(subnetIDs) == 0 {
+		subnetIDs = []string{defaultSubnetID}
+	}
+	outside := func(instanceIDs []string) ([]string, error) {
+		var ids []string
+		for _, instanceID := range instanceIDs {
+			attrs, err := d.storage.ResourceAttributes(instanceID)
+			if err != nil {
+				return nil, fmt.Errorf("retrieving instance attributes: %w", err)
+			}
+			subnetID, _ := attrs.Key(attributeNameSubnetID)
+			if !slices.Contains(subnetIDs, subnetID) {
+				ids = append(ids, instanceID)
+			}
+		}
+		return ids, nil
+	}
+
+	instanceIDs, err := d.autoScalingGroupManagedInstanceIDs(ctx, group.Name)
+	if err != nil {
+		return err
+	}
+	movedInstanceIDs, err := outside(instanceIDs)
+	if err != nil {
+		return err
+	}
+	warmPoolInstanceIDs, err := d.autoScalingGroupWarmPoolInstanceIDs(ctx, group.Name)
+	if err != nil {
+		return err
+	}
+	movedWarmPoolInstanceIDs, err := outside(warmPoolInstanceIDs)
+	if err != nil {
+		return err
+	}
+	if len(movedInstanceIDs) == 0 && len(movedWarmPoolInstanceIDs) == 0 {
+		return nil
+	}
+	api.Logger(ctx).Info(
+		"replacing auto scaling instances after subnet update",
+		slog.String("auto_scaling_group_name", group.Name),
+		slog.Any("subnet_ids", subnetIDs),
+		slog.Any("instance_ids", movedInstanceIDs),
+		slog.Any("warm_pool_instance_ids", movedWarmPoolInstanceIDs),
+	)
+	if err := d.terminateAutoScalingInstancesWithLifecycleHooks(ctx, group.Name, movedInstanceIDs, autoScalingTerminationReasonSubnetUpdate); err != nil {
+		return err
+	}
+	return d.terminateAutoScalingInstancesWithReason(ctx, movedWarmPoolInstanceIDs, autoScalingTerminationReasonSubnetUpdate)
+}
+
+func setAutoScalingGroupLaunchTemplate(
+	group *autoScalingGroupData,
+	lt *launchTemplateData,
+	instanceType string,
+	instanceProfileArn string,
+	mixedInstancesPolicy *api.AutoScalingMixedInstancesPolicy,
+) {
+	group.LaunchTemplateID = lt.ID
+	group.LaunchTemplateName = lt.Name
+	group.LaunchTemplateVersion = lt.Version
+	group.LaunchTemplateImageID = lt.ImageID
+	group.LaunchTemplateInstanceType = instanceType
+	group.LaunchTemplateUserData = lt.UserData
+	group.LaunchTemplateBlockDeviceMappings = cloneBlockDeviceMappings(lt.BlockDeviceMappings)
+	group.LaunchTemplateKeyName = lt.KeyName
+	group.LaunchTemplateSecurityGroupIDs = cloneStringSlice(lt.SecurityGroupIDs)
+	group.LaunchTemplateInstanceProfileArn = instanceProfileArn
+	group.LaunchTemplateAvailabilityZone = launchTemplateAvailabilityZone(lt)
+	group.LaunchTemplateArchitecture = lt.Architecture
+	group.MixedInstancesPolicy = mixedInstancesPolicy
+}
+
+// autoScalingLaunchTemplateVersionAlias returns the $Default or $Latest
+// version alias requested for a group, or an empty string when the group
+// uses a numeric version or no version.
+func autoScalingLaunchTemplateVersionAlias(
+	launchTemplate *api.AutoScalingLaunchTemplateSpecification,
+	mixedInstancesPolicy *api.AutoScalingMixedInstancesPolicy,
+) string {
+	spec := launchTemplate
+	if mixedInstancesPolicy != nil && mixedInstancesPolicy.LaunchTemplate != nil {
+		spec = mixedInstancesPolicy.LaunchTemplate.LaunchTemplateSpecification
+	}
+	if spec == nil || spec.Version == nil {
+		return ""
+	}
+	switch version := *spec.Version; version {
+	case "$Default", "$Latest":
+		return version
+	default:
+		return ""
+	}
+}
+
+// refreshAutoScalingGroupLaunchTemplate resolves the launch template version
+// alias of the group again, so groups using $Default or $Latest launch
+// instances from the current default or latest version without an
+// UpdateAutoScalingGroup call. Existing instances are left alone.
+func (d *Dispatcher) refreshAutoScalingGroupLaunchTemplate(ctx context.Context, group *autoScalingGroupData) error {
+	if group.LaunchTemplateVersionAlias == "" {
+		return nil
+	}
+	spec := &api.AutoScalingLaunchTemplateSpecification{
+		LaunchTemplateID: new(group.LaunchTemplateID),
+		Version:          new(group.LaunchTemplateVersionAlias),
+	}
+	launchTemplate := spec
+	var mixedInstancesPolicy *api.AutoScalingMixedInstancesPolicy
+	if group.MixedInstancesPolicy != nil {
+		policy, err := cloneAutoScalingMixedInstancesPolicy(group.MixedInstancesPolicy)
+		if err != nil {
+			return err
+		}
+		if policy.LaunchTemplate == nil {
+			policy.LaunchTemplate = &api.AutoScalingMixedInstancesLaunchTemplate{}
+		}
+		policy.LaunchTemplate.LaunchTemplateSpecification = spec
+		launchTemplate = nil
+		mixedInstancesPolicy = policy
+	}
+	lt, mixedInstancesPolicy, err := d.resolveAutoScalingGroupLaunchTemplate(ctx, launchTemplate, mixedInstancesPolicy)
+	if err != nil {
+		return err
+	}
+	if lt.Version == group.LaunchTemplateVersion {
+		return nil
+	}
+	instanceType, err := d.resolveAutoScalingGroupInstanceType(lt, mixedInstancesPolicy)
+	if err != nil {
+		return err
+	}
+	if lt.ImageID == "" || instanceType == "" {
+		return api.ErrWithCode("ValidationError", fmt.Errorf("launch template must define ImageId and a resolvable InstanceType"))
+	}
+	instanceProfileArn, err := launchTemplateInstanceProfileArn(lt)
+	if err != nil {
+		return err
+	}
+	api.Logger(ctx).Info(
+		"resolved auto scaling group launch template version",
+		slog.String("auto_scaling_group", group.Name),
+		slog.String("version_alias", group.LaunchTemplateVersionAlias),
+		slog.String("previous_version", group.LaunchTemplateVersion),
+		slog.String("version", lt.Version),
+	)
+	setAutoScalingGroupLaunchTemplate(group, lt, instanceType, instanceProfileArn, mixedInstancesPolicy)
+	return d.saveAutoScalingGroupData(group)
+}
+
+// autoScalingInstanceLaunchTemplateVersion returns the launch template
+// version an auto scaling instance was launched from, falling back to the
+// current group version for instances without linkage tags.
+func autoScalingInstanceLaunchTemplateVersion(attrs storage.Attributes, group *autoScalingGroupData) string {
+	if version, found := attrs.Key(storage.TagAttributeName(launchTemplateTagKeyVersion)); found && version != "" {
+		return version
+	}
+	return group.LaunchTemplateVersion
+}
+
 func autoScalingGroupLaunchTemplateChanged(group *autoScalingGroupData, lt *launchTemplateData, resolvedInstanceType string) bool {
 	if group.LaunchTemplateID != lt.ID {
 		return true
@@ -857,9 +1297,49 @@ func autoScalingGroupLaunchTemplateChanged(group *autoScalingGroupData, lt *laun
 	if group.LaunchTemplateUserData != lt.UserData {
 		return true
 	}
+	if group.LaunchTemplateKeyName != lt.KeyName {
+		return true
+	}
+	if !slices.Equal(group.LaunchTemplateSecurityGroupIDs, lt.SecurityGroupIDs) {
+		return true
+	}
+	if instanceProfileArn, _ := launchTemplateInstanceProfileArn(lt); group.LaunchTemplateInstanceProfileArn != instanceProfileArn {
+		return true
+	}
+	if group.LaunchTemplateAvailabilityZone != launchTemplateAvailabilityZone(lt) {
+		return true
+	}
+	if group.LaunchTemplateArchitecture != lt.Architecture {
+		return true
+	}
 	return !reflect.DeepEqual(group.LaunchTemplateBlockDeviceMappings, lt.BlockDeviceMappings)
 }
 
+// launchTemplateInstanceProfileArn returns the ARN of the IAM instance
+// profile in lt, or an empty string if it has none.
+func launchTemplateInstanceProfileArn(lt *launchTemplateData) (string, error) {
+	if lt.IamInstanceProfile == nil {
+		return "", nil
+	}
+	return resolveInstanceProfileArn(*lt.IamInstanceProfile)
+}
+
+func launchTemplateAvailabilityZone(lt *launchTemplateData) string {
+	if lt.Placement == nil {
+		return ""
+	}
+	return lt.Placement.AvailabilityZone
+}
+
+// autoScalingGroupLaunchAvailabilityZone returns the availability zone for
+// instances launched by group without an explicit placement.
+func (d *Dispatcher) autoScalingGroupLaunchAvailabilityZone(group *autoScalingGroupData) string {
+	if group.LaunchTemplateAvailabilityZone != "" {
+		return group.LaunchTemplateAvailabilityZone
+	}
+	return defaultAvailabilityZone(d.opts.Region)
+}
+
 func (d *Dispatcher) recycleWarmPoolInstancesForLaunchTemplateUpdate(ctx context.Context, group *autoScalingGroupData) error {
 	if !group.WarmPoolEnabled {
 		return nil
@@ -882,8 +1362,13 @@ func (d *Dispatcher) recycleWarmPoolInstancesForLaunchTemplateUpdate(ctx context
 
 func (d *Dispatcher) dispatchSetDesiredCapacity(ctx context.Context, req *api.SetDesiredCapacityRequest) (*api.SetDesiredCapacityResponse, error) {
 	if req.DesiredCapacity == nil {
-		return nil, api.ErrWithCode("ValidationError", fmt.Errorf("DesiredCapacity is required"))
+		return nil, api.ValidationError("DesiredCapacity", "DesiredCapacity is required")
+	}
+	unlock, err := d.lockAutoScalingGroup(ctx, req.AutoScalingGroupName)
+	if err != nil {
+		return nil, err
 	}
+	defer unlock()
 	group, err := d.loadAutoScalingGroupData(ctx, req.AutoScalingGroupName)
 	if err != nil {
 		return nil, err
@@ -899,6 +1384,11 @@ func (d *Dispatcher) dispatchSetDesiredCapacity(ctx context.Context, req *api.Se
 }
 
 func (d *Dispatcher) dispatchDetachInstances(ctx context.Context, req *api.DetachInstancesRequest) (*api.DetachInstancesResponse, error) {
+	unlock, err := d.lockAutoScalingGroup(ctx, req.AutoScalingGroupName)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
 	group, err := d.loadAutoScalingGroupData(ctx, req.AutoScalingGroupName)
 	if err != nil {
 		return nil, err
@@ -908,69 +1398,314 @@ func (d *Dispatcher) dispatchDetachInstances(ctx context.Context, req *api.Detac
 	if err != nil {
 		return nil, err
 	}
-	currentInstancesSet := make(map[string]struct{}, len(currentInstanceIDs))
-	for _, instanceID := range currentInstanceIDs {
-		currentInstancesSet[instanceID] = struct{}{}
-	}
-
-	detachedInstanceIDs := make([]string, 0, len(req.InstanceIDs))
-	detachedInstanceSet := make(map[string]struct{}, len(req.InstanceIDs))
-	for _, instanceID := range req.InstanceIDs {
-		if _, seen := detachedInstanceSet[instanceID]; seen {
-			continue
-		}
-		detachedInstanceSet[instanceID] = struct{}{}
-
-		if _, found := currentInstancesSet[instanceID]; !found {
-			return nil, api.ErrWithCode(
-				"ValidationError",
-				fmt.Errorf("instance %q is not part of Auto Scaling group %q", instanceID, req.AutoScalingGroupName),
-			)
-		}
-		detachedInstanceIDs = append(detachedInstanceIDs, instanceID)
+	currentInstancesSet := make(map[string]struct{}, len(currentInstanceIDs))
+	for _, instanceID := range currentInstanceIDs {
+		currentInstancesSet[instanceID] = struct{}{}
+	}
+
+	detachedInstanceIDs := make([]string, 0, len(req.InstanceIDs))
+	detachedInstanceSet := make(map[string]struct{}, len(req.InstanceIDs))
+	for _, instanceID := range req.InstanceIDs {
+		if _, seen := detachedInstanceSet[instanceID]; seen {
+			continue
+		}
+		detachedInstanceSet[instanceID] = struct{}{}
+
+		if _, found := currentInstancesSet[instanceID]; !found {
+			return nil, api.ErrWithCode(
+				"ValidationError",
+				fmt.Errorf("instance %q is not part of Auto Scaling group %q", instanceID, req.AutoScalingGroupName),
+			)
+		}
+		detachedInstanceIDs = append(detachedInstanceIDs, instanceID)
+	}
+
+	targetDesiredCapacity := group.DesiredCapacity
+	decrementDesiredCapacity := req.ShouldDecrementDesiredCapacity != nil && *req.ShouldDecrementDesiredCapacity
+	if decrementDesiredCapacity {
+		targetDesiredCapacity -= len(detachedInstanceIDs)
+		if err := validateDesiredCapacity(targetDesiredCapacity, group.MinSize, group.MaxSize); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, instanceID := range detachedInstanceIDs {
+		if err := d.clearAutoScalingLifecycleActions(instanceID); err != nil {
+			return nil, err
+		}
+		if err := d.storage.RemoveResourceAttributes(instanceID, []storage.Attribute{
+			{Key: attributeNameAutoScalingGroupName},
+			{Key: attributeNameAutoScalingGroupInstanceType},
+			{Key: attributeNameAutoScalingInstanceSynchronousProvisioning},
+			{Key: attributeNameAutoScalingInstanceHealthStatus},
+			{Key: attributeNameAutoScalingInstanceDrainDeadline},
+			{Key: attributeNameAutoScalingInstanceStandby},
+			{Key: storage.TagAttributeName(autoScalingGroupNameTagKey)},
+		}); err != nil {
+			return nil, fmt.Errorf(
+				"removing auto scaling attributes for detached instance %s: %w",
+				instanceID,
+				err,
+			)
+		}
+		if err := d.syncIMDSTagsForResources([]string{instanceID}); err != nil {
+			return nil, err
+		}
+		api.Logger(ctx).Info(
+			"detached instance from auto scaling group",
+			slog.String("auto_scaling_group_name", req.AutoScalingGroupName),
+			slog.String("instance_id", instanceID),
+			slog.Bool("decrement_desired_capacity", decrementDesiredCapacity),
+			slog.Int("desired_capacity_before", group.DesiredCapacity),
+			slog.Int("desired_capacity_after", targetDesiredCapacity),
+		)
+	}
+
+	// Keep detach responsive even when replacement launches are delayed by
+	// test-profile RunInstances hooks. Reconciliation loop handles refill.
+	group.DesiredCapacity = targetDesiredCapacity
+	if err := d.saveAutoScalingGroupData(group); err != nil {
+		return nil, err
+	}
+	return &api.DetachInstancesResponse{}, nil
+}
+
+func (d *Dispatcher) dispatchAttachInstances(ctx context.Context, req *api.AttachInstancesRequest) (*api.AttachInstancesResponse, error) {
+	unlock, err := d.lockAutoScalingGroup(ctx, req.AutoScalingGroupName)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	group, err := d.loadAutoScalingGroupData(ctx, req.AutoScalingGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	attachedInstanceIDs := make([]string, 0, len(req.InstanceIDs))
+	for _, instanceID := range req.InstanceIDs {
+		if slices.Contains(attachedInstanceIDs, instanceID) {
+			continue
+		}
+		attrs, err := d.storage.ResourceAttributes(instanceID)
+		if err != nil {
+			if errors.As(err, &storage.ErrResourceNotFound{}) {
+				return nil, api.ErrWithCode("ValidationError", fmt.Errorf("instance %q was not found", instanceID))
+			}
+			return nil, fmt.Errorf("retrieving instance attributes: %w", err)
+		}
+		if groupName, _ := attrs.Key(attributeNameAutoScalingGroupName); groupName != "" {
+			return nil, api.ErrWithCode(
+				"ValidationError",
+				fmt.Errorf("instance %q is already part of Auto Scaling group %q", instanceID, groupName),
+			)
+		}
+		attachedInstanceIDs = append(attachedInstanceIDs, instanceID)
+	}
+
+	descs, err := d.exe.DescribeInstances(ctx, executor.DescribeInstancesRequest{
+		InstanceIDs: executorInstanceIDs(attachedInstanceIDs),
+	})
+	if err != nil {
+		return nil, executorError(err)
+	}
+	descsByID := make(map[string]executor.InstanceDescription, len(descs))
+	for _, desc := range descs {
+		descsByID[apiInstanceID(desc.InstanceID)] = desc
+	}
+	for _, instanceID := range attachedInstanceIDs {
+		desc, found := descsByID[instanceID]
+		if !found {
+			return nil, api.ErrWithCode("ValidationError", fmt.Errorf("instance %q was not found", instanceID))
+		}
+		if desc.InstanceState != api.InstanceStateRunning {
+			return nil, api.ErrWithCode(
+				"ValidationError",
+				fmt.Errorf("instance %q is not in the running state", instanceID),
+			)
+		}
+	}
+
+	targetDesiredCapacity := group.DesiredCapacity + len(attachedInstanceIDs)
+	if targetDesiredCapacity > group.MaxSize {
+		return nil, api.ErrWithCode(
+			"ValidationError",
+			fmt.Errorf(
+				"attaching %d instance(s) would raise DesiredCapacity to %d, above the group MaxSize %d",
+				len(attachedInstanceIDs),
+				targetDesiredCapacity,
+				group.MaxSize,
+			),
+		)
+	}
+
+	for _, instanceID := range attachedInstanceIDs {
+		if err := d.storage.SetResourceAttributes(instanceID, []storage.Attribute{
+			{Key: attributeNameAutoScalingGroupName, Value: group.Name},
+			{Key: attributeNameAutoScalingGroupInstanceType, Value: descsByID[instanceID].InstanceType},
+			{Key: storage.TagAttributeName(autoScalingGroupNameTagKey), Value: group.Name},
+		}); err != nil {
+			return nil, fmt.Errorf("setting auto scaling attributes for attached instance %s: %w", instanceID, err)
+		}
+		if err := d.syncIMDSTagsForResources([]string{instanceID}); err != nil {
+			return nil, err
+		}
+		api.Logger(ctx).Info(
+			"attached instance to auto scaling group",
+			slog.String("auto_scaling_group_name", group.Name),
+			slog.String("instance_id", instanceID),
+			slog.Int("desired_capacity_before", group.DesiredCapacity),
+			slog.Int("desired_capacity_after", targetDesiredCapacity),
+		)
+	}
+
+	group.DesiredCapacity = targetDesiredCapacity
+	if err := d.saveAutoScalingGroupData(group); err != nil {
+		return nil, err
+	}
+	return &api.AttachInstancesResponse{}, nil
+}
+
+func (d *Dispatcher) dispatchSetInstanceHealth(ctx context.Context, req *api.SetInstanceHealthRequest) (*api.SetInstanceHealthResponse, error) {
+	switch req.HealthStatus {
+	case autoScalingHealthStatus, autoScalingHealthStatusUnhealthy:
+	default:
+		return nil, api.ValidationError("HealthStatus", "HealthStatus must be %s or %s", autoScalingHealthStatus, autoScalingHealthStatusUnhealthy)
+	}
+	attrs, err := d.storage.ResourceAttributes(req.InstanceID)
+	if err != nil && !errors.As(err, &storage.ErrResourceNotFound{}) {
+		return nil, fmt.Errorf("retrieving instance attributes: %w", err)
+	}
+	groupName, _ := attrs.Key(attributeNameAutoScalingGroupName)
+	if groupName == "" || autoScalingInstanceIsWarm(attrs) {
+		return nil, api.ErrWithCode("ValidationError", fmt.Errorf("no managed instance found for instance ID %s", req.InstanceID))
+	}
+	group, err := d.loadAutoScalingGroupData(ctx, groupName)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.HealthStatus == autoScalingHealthStatus {
+		if err := d.storage.RemoveResourceAttributes(req.InstanceID, []storage.Attribute{
+			{Key: attributeNameAutoScalingInstanceHealthStatus},
+		}); err != nil {
+			return nil, fmt.Errorf("clearing instance health status: %w", err)
+		}
+		return &api.SetInstanceHealthResponse{}, nil
+	}
+
+	respectGracePeriod := req.ShouldRespectGracePeriod == nil || *req.ShouldRespectGracePeriod
+	if respectGracePeriod && group.HealthCheckGracePeriod > 0 {
+		descs, err := d.exe.DescribeInstances(ctx, executor.DescribeInstancesRequest{
+			InstanceIDs: executorInstanceIDs([]string{req.InstanceID}),
+		})
+		if err != nil {
+			return nil, executorError(err)
+		}
+		gracePeriod := time.Duration(group.HealthCheckGracePeriod) * time.Second
+		if len(descs) == 1 && d.now().Sub(descs[0].LaunchTime) < gracePeriod {
+			// Like AWS, health changes are ignored while the instance is
+			// within the group health check grace period.
+			api.Logger(ctx).Info(
+				"ignoring instance health change during health check grace period",
+				slog.String("auto_scaling_group_name", group.Name),
+				slog.String("instance_id", req.InstanceID),
+			)
+			return &api.SetInstanceHealthResponse{}, nil
+		}
+	}
+	if err := d.storage.SetResourceAttributes(req.InstanceID, []storage.Attribute{
+		{Key: attributeNameAutoScalingInstanceHealthStatus, Value: autoScalingHealthStatusUnhealthy},
+	}); err != nil {
+		return nil, fmt.Errorf("saving instance health status: %w", err)
+	}
+	// The reconciliation loop replaces the instance.
+	return &api.SetInstanceHealthResponse{}, nil
+}
+
+func (d *Dispatcher) dispatchTerminateInstanceInAutoScalingGroup(ctx context.Context, req *api.TerminateInstanceInAutoScalingGroupRequest) (*api.TerminateInstanceInAutoScalingGroupResponse, error) {
+	attrs, unlock, err := d.lockAutoScalingInstanceGroup(ctx, req.InstanceID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	groupName, _ := attrs.Key(attributeNameAutoScalingGroupName)
+	if groupName == "" || autoScalingInstanceIsWarm(attrs) {
+		return nil, api.ErrWithCode("ValidationError", fmt.Errorf("instance %q is not part of any Auto Scaling group", req.InstanceID))
+	}
+	group, err := d.loadAutoScalingGroupData(ctx, groupName)
+	if err != nil {
+		return nil, err
+	}
+	instanceIDs, err := d.autoScalingGroupInstanceIDsReadOnly(ctx, groupName)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(instanceIDs, req.InstanceID) {
+		return nil, api.ErrWithCode(
+			"ValidationError",
+			fmt.Errorf("instance %q is not part of Auto Scaling group %q", req.InstanceID, groupName),
+		)
 	}
 
-	targetDesiredCapacity := group.DesiredCapacity
-	decrementDesiredCapacity := req.ShouldDecrementDesiredCapacity != nil && *req.ShouldDecrementDesiredCapacity
+	desiredCapacityBefore := group.DesiredCapacity
+	decrementDesiredCapacity := *req.ShouldDecrementDesiredCapacity
 	if decrementDesiredCapacity {
-		targetDesiredCapacity -= len(detachedInstanceIDs)
-		if err := validateDesiredCapacity(targetDesiredCapacity, group.MinSize, group.MaxSize); err != nil {
+		group.DesiredCapacity--
+		if err := validateDesiredCapacity(group.DesiredCapacity, group.MinSize, group.MaxSize); err != nil {
+			return nil, err
+		}
+		// Save before terminating, so reconciliation doesn't launch a
+		// replacement for the terminated instance.
+		if err := d.saveAutoScalingGroupData(group); err != nil {
 			return nil, err
 		}
 	}
+	api.Logger(ctx).Info(
+		"terminating instance in auto scaling group",
+		slog.String("auto_scaling_group_name", groupName),
+		slog.String("instance_id", req.InstanceID),
+		slog.Bool("decrement_desired_capacity", decrementDesiredCapacity),
+		slog.Int("desired_capacity_before", desiredCapacityBefore),
+		slog.Int("desired_capacity_after", group.DesiredCapacity),
+	)
+	startTime := d.now().UTC()
+	if err := d.terminateAutoScalingInstancesWithLifecycleHooks(ctx, groupName, []string{req.InstanceID}, autoScalingTerminationReasonAPI); err != nil {
+		return nil, err
+	}
 
-	for _, instanceID := range detachedInstanceIDs {
-		if err := d.storage.RemoveResourceAttributes(instanceID, []storage.Attribute{
-			{Key: attributeNameAutoScalingGroupName},
-			{Key: attributeNameAutoScalingGroupInstanceType},
-			{Key: attributeNameAutoScalingInstanceSynchronousProvisioning},
-		}); err != nil {
-			return nil, fmt.Errorf(
-				"removing auto scaling attributes for detached instance %s: %w",
-				instanceID,
-				err,
-			)
-		}
-		api.Logger(ctx).Info(
-			"detached instance from auto scaling group",
-			slog.String("auto_scaling_group_name", req.AutoScalingGroupName),
-			slog.String("instance_id", instanceID),
-			slog.Bool("decrement_desired_capacity", decrementDesiredCapacity),
-			slog.Int("desired_capacity_before", group.DesiredCapacity),
-			slog.Int("desired_capacity_after", targetDesiredCapacity),
-		)
+	cause := fmt.Sprintf("At %s instance %s was taken out of service in response to a user request", startTime.Format(time.RFC3339), req.InstanceID)
+	if decrementDesiredCapacity {
+		cause += fmt.Sprintf(", shrinking the capacity from %d to %d", desiredCapacityBefore, group.DesiredCapacity)
 	}
+	activity := newAutoScalingActivity(groupName, "Terminating EC2 instance: "+req.InstanceID, cause+".", startTime)
+	d.recordAutoScalingActivity(activity)
+	return &api.TerminateInstanceInAutoScalingGroupResponse{
+		TerminateInstanceInAutoScalingGroupResult: api.TerminateInstanceInAutoScalingGroupResult{
+			Activity: &activity,
+		},
+	}, nil
+}
 
-	// Keep detach responsive even when replacement launches are delayed by
-	// test-profile RunInstances hooks. Reconciliation loop handles refill.
-	group.DesiredCapacity = targetDesiredCapacity
-	if err := d.saveAutoScalingGroupData(group); err != nil {
-		return nil, err
+// newAutoScalingActivity returns the in progress activity reported for an
+// operation on a group started at startTime.
+func newAutoScalingActivity(groupName string, description string, cause string, startTime time.Time) api.AutoScalingActivity {
+	return api.AutoScalingActivity{
+		ActivityID:           new(uuid.New().String()),
+		AutoScalingGroupName: &groupName,
+		Cause:                &cause,
+		Description:          &description,
+		Progress:             new(0),
+		StartTime:            &startTime,
+		StatusCode:           new("InProgress"),
 	}
-	return &api.DetachInstancesResponse{}, nil
 }
 
 func (d *Dispatcher) dispatchDeleteAutoScalingGroup(ctx context.Context, req *api.DeleteAutoScalingGroupRequest) (*api.DeleteAutoScalingGroupResponse, error) {
+	unlock, err := d.lockAutoScalingGroup(ctx, req.AutoScalingGroupName)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
 	if _, err := d.findResource(ctx, types.ResourceTypeAutoScalingGroup, req.AutoScalingGroupName); err != nil {
 		if errors.As(err, &storage.ErrResourceNotFound{}) {
 			return nil, api.ErrWithCode("ValidationError", fmt.Errorf("auto scaling group %q was not found", req.AutoScalingGroupName))
@@ -979,31 +1714,72 @@ func (d *Dispatcher) dispatchDeleteAutoScalingGroup(ctx context.Context, req *ap
 	}
 	d.cancelWarmPoolDeleteJob(req.AutoScalingGroupName)
 
-	instanceIDs, err := d.autoScalingGroupInstanceIDs(ctx, req.AutoScalingGroupName)
-	if err != nil {
-		return nil, err
-	}
-	warmPoolInstanceIDs, err := d.autoScalingGroupWarmPoolInstanceIDs(ctx, req.AutoScalingGroupName)
+	instanceIDs, err := d.autoScalingGroupAllInstanceIDs(ctx, req.AutoScalingGroupName)
 	if err != nil {
 		return nil, err
 	}
-	instanceIDs = append(instanceIDs, warmPoolInstanceIDs...)
 	forceDelete := req.ForceDelete != nil && *req.ForceDelete
 	if len(instanceIDs) > 0 && !forceDelete {
 		return nil, api.ErrWithCode("ResourceInUse", fmt.Errorf("auto scaling group %q still has instances", req.AutoScalingGroupName))
 	}
-	if len(instanceIDs) > 0 {
-		if err := d.terminateAutoScalingInstances(ctx, instanceIDs); err != nil {
+	if len(instanceIDs) > 0 && d.opts.AsyncStateTransitions {
+		// Like AWS, report the group as being deleted while the
+		// reconciliation loop terminates its instances.
+		group, err := d.loadAutoScalingGroupData(ctx, req.AutoScalingGroupName)
+		if err != nil {
+			return nil, err
+		}
+		group.Status = autoScalingGroupStatusDeleteInProgress
+		if err := d.saveAutoScalingGroupData(group); err != nil {
 			return nil, err
 		}
+		api.Logger(ctx).Info(
+			"deleting auto scaling group in the background",
+			slog.String("auto_scaling_group_name", group.Name),
+			slog.Int("instance_count", len(instanceIDs)),
+		)
+		return &api.DeleteAutoScalingGroupResponse{}, nil
 	}
-	if err := d.storage.RemoveResource(req.AutoScalingGroupName); err != nil {
-		return nil, fmt.Errorf("removing auto scaling group: %w", err)
+	if err := d.deleteAutoScalingGroup(ctx, req.AutoScalingGroupName); err != nil {
+		return nil, err
 	}
 	return &api.DeleteAutoScalingGroupResponse{}, nil
 }
 
+// deleteAutoScalingGroup terminates all the group instances, including warm
+// pool ones, and removes the group.
+func (d *Dispatcher) deleteAutoScalingGroup(ctx context.Context, autoScalingGroupName string) error {
+	instanceIDs, err := d.autoScalingGroupAllInstanceIDs(ctx, autoScalingGroupName)
+	if err != nil {
+		return err
+	}
+	if err := d.terminateAutoScalingInstances(ctx, instanceIDs); err != nil {
+		return err
+	}
+	if err := d.storage.RemoveResource(autoScalingGroupName); err != nil {
+		return fmt.Errorf("removing auto scaling group: %w", err)
+	}
+	return nil
+}
+
+func (d *Dispatcher) autoScalingGroupAllInstanceIDs(ctx context.Context, autoScalingGroupName string) ([]string, error) {
+	instanceIDs, err := d.autoScalingGroupInstanceIDs(ctx, autoScalingGroupName)
+	if err != nil {
+		return nil, err
+	}
+	warmPoolInstanceIDs, err := d.autoScalingGroupWarmPoolInstanceIDs(ctx, autoScalingGroupName)
+	if err != nil {
+		return nil, err
+	}
+	return append(instanceIDs, warmPoolInstanceIDs...), nil
+}
+
 func (d *Dispatcher) dispatchPutWarmPool(ctx context.Context, req *api.PutWarmPoolRequest) (*api.PutWarmPoolResponse, error) {
+	unlock, err := d.lockAutoScalingGroup(ctx, req.AutoScalingGroupName)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
 	group, err := d.loadAutoScalingGroupData(ctx, req.AutoScalingGroupName)
 	if err != nil {
 		return nil, err
@@ -1012,13 +1788,13 @@ func (d *Dispatcher) dispatchPutWarmPool(ctx context.Context, req *api.PutWarmPo
 	poolStateUpdated := false
 	if req.MinSize != nil {
 		if *req.MinSize < 0 {
-			return nil, api.ErrWithCode("ValidationError", fmt.Errorf("MinSize must be >= 0"))
+			return nil, api.ValidationError("MinSize", "MinSize must be >= 0")
 		}
 		group.WarmPoolMinSize = *req.MinSize
 	}
 	if req.MaxGroupPreparedCapacity != nil {
 		if *req.MaxGroupPreparedCapacity < -1 {
-			return nil, api.ErrWithCode("ValidationError", fmt.Errorf("MaxGroupPreparedCapacity must be >= -1"))
+			return nil, api.ValidationError("MaxGroupPreparedCapacity", "MaxGroupPreparedCapacity must be >= -1")
 		}
 		if *req.MaxGroupPreparedCapacity == -1 {
 			group.WarmPoolMaxGroupPreparedCapacity = nil
@@ -1087,8 +1863,6 @@ func (d *Dispatcher) dispatchDescribeWarmPool(ctx context.Context, req *api.Desc
 
 	launchTemplateID := group.LaunchTemplateID
 	launchTemplateName := group.LaunchTemplateName
-	launchTemplateVersion := group.LaunchTemplateVersion
-	protectedFromScaleIn := false
 	instances := make([]api.AutoScalingInstance, 0, len(warmPoolInstanceIDs))
 	for _, instanceID := range warmPoolInstanceIDs {
 		desc, ok := descriptionsByID[instanceID]
@@ -1096,10 +1870,14 @@ func (d *Dispatcher) dispatchDescribeWarmPool(ctx context.Context, req *api.Desc
 			continue
 		}
 		availabilityZone := defaultAvailabilityZone(d.opts.Region)
+		protectedFromScaleIn := false
+		instanceLaunchTemplateVersion := group.LaunchTemplateVersion
 		if attrs, attrErr := d.storage.ResourceAttributes(instanceID); attrErr == nil {
 			if v, ok := attrs.Key(attributeNameAvailabilityZone); ok && v != "" {
 				availabilityZone = v
 			}
+			protectedFromScaleIn = autoScalingInstanceIsProtectedFromScaleIn(attrs)
+			instanceLaunchTemplateVersion = autoScalingInstanceLaunchTemplateVersion(attrs, group)
 		}
 		instanceIDCopy := instanceID
 		instanceType := group.LaunchTemplateInstanceType
@@ -1110,7 +1888,6 @@ func (d *Dispatcher) dispatchDescribeWarmPool(ctx context.Context, req *api.Desc
 		lifecycleState := autoScalingWarmPoolLifecycleState(desc.InstanceState.Name, group.WarmPoolState)
 		instanceLaunchTemplateID := launchTemplateID
 		instanceLaunchTemplateName := launchTemplateName
-		instanceLaunchTemplateVersion := launchTemplateVersion
 
 		instances = append(instances, api.AutoScalingInstance{
 			AvailabilityZone: &availabilityZone,
@@ -1170,6 +1947,11 @@ func (d *Dispatcher) dispatchDescribeWarmPool(ctx context.Context, req *api.Desc
 }
 
 func (d *Dispatcher) dispatchDeleteWarmPool(ctx context.Context, req *api.DeleteWarmPoolRequest) (*api.DeleteWarmPoolResponse, error) {
+	unlock, err := d.lockAutoScalingGroup(ctx, req.AutoScalingGroupName)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
 	group, err := d.loadAutoScalingGroupData(ctx, req.AutoScalingGroupName)
 	if err != nil {
 		return nil, err
@@ -1216,16 +1998,26 @@ func (d *Dispatcher) scaleAutoScalingGroupTo(ctx context.Context, group *autoSca
 		return err
 	}
 	currentCapacity := len(instanceIDs)
+	scaledCapacity := currentCapacity
 
 	switch {
 	case currentCapacity < desiredCapacity:
 		addCount := desiredCapacity - currentCapacity
+		startTime := d.now().UTC()
 		promotedInstanceIDs, err := d.promoteWarmPoolInstances(ctx, group, addCount)
 		if err != nil {
 			return err
 		}
 		addCount -= len(promotedInstanceIDs)
+		scaledCapacity += len(promotedInstanceIDs)
 		if len(promotedInstanceIDs) > 0 {
+			d.recordAutoScalingInstanceActivities(
+				group.Name,
+				promotedInstanceIDs,
+				"Launching a new EC2 instance from warm pool",
+				autoScalingCapacityChangeCause(startTime, "an instance was moved out of the warm pool", currentCapacity, currentCapacity+len(promotedInstanceIDs)),
+				startTime,
+			)
 			api.Logger(ctx).Info(
 				"promoted warm pool instances into auto scaling group",
 				slog.String("auto_scaling_group_name", group.Name),
@@ -1243,33 +2035,66 @@ func (d *Dispatcher) scaleAutoScalingGroupTo(ctx context.Context, group *autoSca
 			slog.Int("target_capacity", desiredCapacity),
 			slog.Int("add_instances", addCount),
 		)
-		if err := d.scaleOutAutoScalingGroup(ctx, group, addCount); err != nil {
+		if err := d.scaleOutAutoScalingGroup(ctx, group, currentCapacity+len(promotedInstanceIDs), addCount); err != nil {
 			return err
 		}
+		scaledCapacity += addCount
 	case currentCapacity > desiredCapacity:
 		redundant := currentCapacity - desiredCapacity
-		slices.Sort(instanceIDs)
-		removedInstanceIDs := slices.Clone(instanceIDs[:redundant])
+		removedInstanceIDs, err := d.autoScalingScaleInInstanceIDs(instanceIDs, redundant)
+		if err != nil {
+			return err
+		}
+		if len(removedInstanceIDs) < redundant {
+			// Protected instances stay in service, leaving the group
+			// above its desired capacity until their protection is removed.
+			api.Logger(ctx).Debug(
+				"skipping auto scaling instances protected from scale in",
+				slog.String("auto_scaling_group_name", group.Name),
+				slog.Int("protected_instances", redundant-len(removedInstanceIDs)),
+			)
+		}
+		if len(removedInstanceIDs) == 0 {
+			break
+		}
+		scaledCapacity -= len(removedInstanceIDs)
 		reuseOnScaleIn := group.WarmPoolEnabled && group.WarmPoolReuseOnScaleIn != nil && *group.WarmPoolReuseOnScaleIn
+		startTime := d.now().UTC()
 		if reuseOnScaleIn {
 			if err := d.moveAutoScalingInstancesToWarmPool(ctx, group, removedInstanceIDs); err != nil {
 				return err
 			}
+			d.recordAutoScalingInstanceActivities(
+				group.Name,
+				removedInstanceIDs,
+				"Moving EC2 instance to warm pool",
+				autoScalingCapacityChangeCause(startTime, "an instance was moved to the warm pool", currentCapacity, currentCapacity-len(removedInstanceIDs)),
+				startTime,
+			)
 		} else {
 			api.Logger(ctx).Info(
 				"scaling down auto scaling group",
 				slog.String("auto_scaling_group_name", group.Name),
 				slog.Int("current_capacity", currentCapacity),
 				slog.Int("target_capacity", desiredCapacity),
-				slog.Int("remove_instances", redundant),
+				slog.Int("remove_instances", len(removedInstanceIDs)),
 				slog.Any("instance_ids", removedInstanceIDs),
 			)
-			if err := d.terminateAutoScalingInstancesWithReason(ctx, removedInstanceIDs, "scale-in"); err != nil {
+			if err := d.terminateAutoScalingInstancesWithLifecycleHooks(ctx, group.Name, removedInstanceIDs, autoScalingTerminationReasonScaleIn); err != nil {
 				return err
 			}
+			d.recordAutoScalingInstanceActivities(
+				group.Name,
+				removedInstanceIDs,
+				"Terminating EC2 instance",
+				autoScalingCapacityChangeCause(startTime, "an instance was taken out of service", currentCapacity, currentCapacity-len(removedInstanceIDs)),
+				startTime,
+			)
 		}
 	}
 
+	d.publishAutoScalingGroupCapacityChange(group.Name, currentCapacity, scaledCapacity)
+
 	group.DesiredCapacity = desiredCapacity
 	if err := d.saveAutoScalingGroupData(group); err != nil {
 		return err
@@ -1281,6 +2106,15 @@ func (d *Dispatcher) scaleAutoScalingGroupTo(ctx context.Context, group *autoSca
 }
 
 func (d *Dispatcher) reconcileAutoScalingGroup(ctx context.Context, group *autoScalingGroupData) error {
+	if group.Status == autoScalingGroupStatusDeleteInProgress {
+		return d.deleteAutoScalingGroup(ctx, group.Name)
+	}
+	if err := d.expireAutoScalingLifecycleActions(ctx, group.Name); err != nil {
+		return err
+	}
+	if err := d.terminateDrainedAutoScalingInstances(ctx, group.Name); err != nil {
+		return err
+	}
 	return d.scaleAutoScalingGroupTo(ctx, group, group.DesiredCapacity)
 }
 
@@ -1293,33 +2127,60 @@ func (d *Dispatcher) createAutoScalingInstances(
 	if count <= 0 {
 		return nil, nil
 	}
-
-	matchInput := d.runInstancesMatchInputForAutoScalingGroup(group.LaunchTemplateInstanceType, group.Name)
-	if err := d.applyRunInstancesDelayForMatchInputAllowConcurrentDispatch(
-		ctx,
-		testprofile.HookBefore,
-		testprofile.PhaseAllocate,
-		matchInput,
-	); err != nil {
+	if err := d.refreshAutoScalingGroupLaunchTemplate(ctx, group); err != nil {
 		return nil, err
 	}
-	created, err := d.exe.CreateInstances(ctx, executor.CreateInstancesRequest{
-		ImageID:      group.LaunchTemplateImageID,
-		InstanceType: group.LaunchTemplateInstanceType,
-		Count:        count,
-		UserData:     normalizeUserData(group.LaunchTemplateUserData),
-	})
+
+	launchInstanceTypes, err := d.autoScalingLaunchInstanceTypes(ctx, group, count)
 	if err != nil {
-		return nil, executorError(err)
-	}
-	if err := d.applyRunInstancesDelayForMatchInputAllowConcurrentDispatch(
-		ctx,
-		testprofile.HookAfter,
-		testprofile.PhaseAllocate,
-		matchInput,
-	); err != nil {
 		return nil, err
 	}
+	var batchInstanceTypes []string
+	batchCounts := make(map[string]int)
+	for _, instanceType := range launchInstanceTypes {
+		if batchCounts[instanceType] == 0 {
+			batchInstanceTypes = append(batchInstanceTypes, instanceType)
+		}
+		batchCounts[instanceType]++
+	}
+	var created []executor.InstanceID
+	instanceTypeByID := make(map[executor.InstanceID]string, count)
+	for _, instanceType := range batchInstanceTypes {
+		matchInput := d.runInstancesMatchInputForAutoScalingGroup(instanceType, group.Name)
+		if err := d.applyRunInstancesDelayForMatchInputAllowConcurrentDispatch(
+			ctx,
+			testprofile.HookBefore,
+			testprofile.PhaseAllocate,
+			matchInput,
+		); err != nil {
+			return nil, err
+		}
+		typeCreated, err := d.exe.CreateInstances(ctx, executor.CreateInstancesRequest{
+			ImageID:      group.LaunchTemplateImageID,
+			InstanceType: instanceType,
+			Count:        batchCounts[instanceType],
+			UserData:     normalizeUserData(group.LaunchTemplateUserData),
+			VCPUs:        matchInput.VCPU,
+			MemoryMiB:    matchInput.MemoryMiB,
+			Architecture: cmp.Or(group.LaunchTemplateArchitecture, d.opts.DefaultArchitecture),
+		})
+		if err != nil {
+			return nil, executorError(err)
+		}
+		if err := d.applyRunInstancesDelayForMatchInputAllowConcurrentDispatch(
+			ctx,
+			testprofile.HookAfter,
+			testprofile.PhaseAllocate,
+			matchInput,
+		); err != nil {
+			return nil, err
+		}
+		for _, instanceID := range typeCreated {
+			instanceTypeByID[instanceID] = instanceType
+		}
+		created = append(created, typeCreated...)
+	}
+	matchInput := d.runInstancesMatchInputForAutoScalingGroup(group.LaunchTemplateInstanceType, group.Name)
 
 	propagatedTagAttrs, propagatedTags, err := d.autoScalingGroupPropagatedInstanceTags(group.Name)
 	if err != nil {
@@ -1327,26 +2188,39 @@ func (d *Dispatcher) createAutoScalingInstances(
 	}
 	launchTemplateTagAttrs := launchTemplateLinkageTagAttributes(group.LaunchTemplateID, group.LaunchTemplateVersion)
 	propagatedTags = ensureLaunchTemplateLinkageTags(propagatedTags, group.LaunchTemplateID, group.LaunchTemplateVersion)
+	propagatedTags[autoScalingGroupNameTagKey] = group.Name
 	availabilityZone := strings.TrimSpace(opts.AvailabilityZone)
 	if availabilityZone == "" {
-		availabilityZone = defaultAvailabilityZone(d.opts.Region)
+		availabilityZone = d.autoScalingGroupLaunchAvailabilityZone(group)
 	}
 	subnetID := strings.TrimSpace(opts.SubnetID)
 	if subnetID == "" {
 		subnetID = autoScalingInstanceSubnetID(group)
 	}
-	vpcID := subnetVPCID(subnetID)
+	vpcID, _, err := d.subnetPlacement(subnetID)
+	if err != nil {
+		return nil, err
+	}
+	reservationID, err := makeID(reservationIDPrefix)
+	if err != nil {
+		return nil, err
+	}
+	securityGroupIDs, err := marshalStringSlice(group.LaunchTemplateSecurityGroupIDs)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling auto scaling instance security groups: %w", err)
+	}
 	for _, instanceID := range created {
 		id := apiInstanceID(instanceID)
 		if err := d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeInstance, ID: id}); err != nil {
 			return nil, fmt.Errorf("registering auto scaling instance %s: %w", id, err)
 		}
 		attrs := []storage.Attribute{
+			{Key: attributeNameInstanceReservationID, Value: reservationID},
 			{Key: attributeNameAvailabilityZone, Value: availabilityZone},
 			{Key: attributeNameSubnetID, Value: subnetID},
 			{Key: attributeNameVPCID, Value: vpcID},
 			{Key: attributeNameAutoScalingGroupName, Value: group.Name},
-			{Key: attributeNameAutoScalingGroupInstanceType, Value: group.LaunchTemplateInstanceType},
+			{Key: attributeNameAutoScalingGroupInstanceType, Value: instanceTypeByID[instanceID]},
 		}
 		if opts.WarmPool {
 			attrs = append(attrs, storage.Attribute{Key: attributeNameAutoScalingInstanceWarmPool, Value: "true"})
@@ -1354,17 +2228,40 @@ func (d *Dispatcher) createAutoScalingInstances(
 		if opts.SynchronousProvisioning {
 			attrs = append(attrs, storage.Attribute{Key: attributeNameAutoScalingInstanceSynchronousProvisioning, Value: "true"})
 		}
+		if group.NewInstancesProtectedFromScaleIn {
+			attrs = append(attrs, storage.Attribute{Key: attributeNameAutoScalingInstanceProtectedFromScaleIn, Value: "true"})
+		}
 		if group.LaunchTemplateUserData != "" {
 			attrs = append(attrs, storage.Attribute{
 				Key:   attributeNameInstanceUserData,
 				Value: normalizeUserData(group.LaunchTemplateUserData),
 			})
 		}
+		if group.LaunchTemplateKeyName != "" {
+			attrs = append(attrs, storage.Attribute{Key: attributeNameInstanceKeyName, Value: group.LaunchTemplateKeyName})
+		}
+		if securityGroupIDs != "" {
+			attrs = append(attrs, storage.Attribute{Key: attributeNameInstanceSecurityGroupIDs, Value: securityGroupIDs})
+		}
+		if group.LaunchTemplateInstanceProfileArn != "" {
+			profileAttrs, err := instanceProfileAttributes(group.LaunchTemplateInstanceProfileArn, d.now())
+			if err != nil {
+				return nil, err
+			}
+			attrs = append(attrs, profileAttrs...)
+		}
 		attrs = append(attrs, propagatedTagAttrs...)
 		attrs = append(attrs, launchTemplateTagAttrs...)
+		attrs = append(attrs, storage.Attribute{
+			Key:   storage.TagAttributeName(autoScalingGroupNameTagKey),
+			Value: group.Name,
+		})
 		if err := d.storage.SetResourceAttributes(id, attrs); err != nil {
 			return nil, fmt.Errorf("setting auto scaling instance attributes: %w", err)
 		}
+		if err := d.registerInstanceNetworkInterface(id); err != nil {
+			return nil, err
+		}
 		if err := d.imds.SetTags(string(instanceID), propagatedTags); err != nil {
 			return nil, fmt.Errorf("synchronizing IMDS tags for auto scaling instance %s: %w", id, err)
 		}
@@ -1378,9 +2275,11 @@ func (d *Dispatcher) createAutoScalingInstances(
 	); err != nil {
 		return nil, err
 	}
-	if _, err := d.exe.StartInstances(ctx, executor.StartInstancesRequest{InstanceIDs: created}); err != nil {
+	startChanges, err := d.exe.StartInstances(ctx, executor.StartInstancesRequest{InstanceIDs: created})
+	if err != nil {
 		return nil, executorError(err)
 	}
+	d.publishInstanceStateChanges(startChanges)
 	if err := d.applyRunInstancesDelayForMatchInputAllowConcurrentDispatch(
 		ctx,
 		testprofile.HookAfter,
@@ -1396,14 +2295,37 @@ func (d *Dispatcher) createAutoScalingInstances(
 	return apiInstanceIDs(created), nil
 }
 
-func (d *Dispatcher) scaleOutAutoScalingGroup(ctx context.Context, group *autoScalingGroupData, count int) error {
-	createdIDs, err := d.createAutoScalingInstances(ctx, group, count, autoScalingInstanceLaunchOptions{
-		AvailabilityZone: defaultAvailabilityZone(d.opts.Region),
-		SubnetID:         autoScalingInstanceSubnetID(group),
-	})
+func (d *Dispatcher) scaleOutAutoScalingGroup(ctx context.Context, group *autoScalingGroupData, currentCapacity int, count int) error {
+	startTime := d.now().UTC()
+	instanceIDs, err := d.autoScalingGroupManagedInstanceIDs(ctx, group.Name)
 	if err != nil {
 		return err
 	}
+	placements, err := d.autoScalingLaunchPlacements(group, count, instanceIDs)
+	if err != nil {
+		return err
+	}
+	var createdIDs []string
+	for _, placement := range placements {
+		placementIDs, err := d.createAutoScalingInstances(ctx, group, placement.Count, autoScalingInstanceLaunchOptions{
+			AvailabilityZone: placement.AvailabilityZone,
+			SubnetID:         placement.SubnetID,
+		})
+		if err != nil {
+			return err
+		}
+		createdIDs = append(createdIDs, placementIDs...)
+	}
+	if _, err := d.startAutoScalingLifecycleActions(ctx, group.Name, createdIDs, lifecycleTransitionInstanceLaunching); err != nil {
+		return err
+	}
+	d.recordAutoScalingInstanceActivities(
+		group.Name,
+		createdIDs,
+		"Launching a new EC2 instance",
+		autoScalingCapacityChangeCause(startTime, "an instance was started", currentCapacity, currentCapacity+len(createdIDs)),
+		startTime,
+	)
 	api.Logger(ctx).Info(
 		"scaled out auto scaling group",
 		slog.String("auto_scaling_group_name", group.Name),
@@ -1475,6 +2397,7 @@ func (d *Dispatcher) promoteWarmPoolInstances(ctx context.Context, group *autoSc
 			return nil, fmt.Errorf("promoting warm pool instance %s: %w", instanceID, err)
 		}
 	}
+	d.publishWarmPoolInstanceChanges(group.Name, promotedInstanceIDs, warmPoolLifecycleState(group), autoScalingLifecycleState)
 
 	return promotedInstanceIDs, nil
 }
@@ -1532,6 +2455,7 @@ func (d *Dispatcher) moveAutoScalingInstancesToWarmPool(ctx context.Context, gro
 			return fmt.Errorf("marking instance %s as warm pool: %w", instanceID, err)
 		}
 	}
+	d.publishWarmPoolInstanceChanges(group.Name, instanceIDs, autoScalingLifecycleState, warmPoolLifecycleState(group))
 	api.Logger(ctx).Info(
 		"moved auto scaling instances into warm pool",
 		slog.String("auto_scaling_group_name", group.Name),
@@ -1622,8 +2546,15 @@ func (d *Dispatcher) scheduleAsyncWarmPoolDeletion(autoScalingGroupName string)
 			shouldRetry := false
 			d.dispatchMu.Lock()
 			ctx := context.Background()
+			unlock, err := d.lockAutoScalingGroup(jobCtx, autoScalingGroupName)
+			if err != nil {
+				// Canceled while waiting for the lock
+				d.dispatchMu.Unlock()
+				return
+			}
 			group, err := d.loadAutoScalingGroupData(ctx, autoScalingGroupName)
 			if err != nil {
+				unlock()
 				d.dispatchMu.Unlock()
 				var apiErr *api.Error
 				if errors.As(err, &apiErr) && apiErr.Code == "ValidationError" {
@@ -1636,6 +2567,7 @@ func (d *Dispatcher) scheduleAsyncWarmPoolDeletion(autoScalingGroupName string)
 					slog.Any("error", err),
 				)
 			} else if !group.WarmPoolEnabled || group.WarmPoolStatus != warmPoolStatusPendingDelete {
+				unlock()
 				d.dispatchMu.Unlock()
 				return
 			} else {
@@ -1647,6 +2579,7 @@ func (d *Dispatcher) scheduleAsyncWarmPoolDeletion(autoScalingGroupName string)
 						slog.Any("error", err),
 					)
 				}
+				unlock()
 				d.dispatchMu.Unlock()
 			}
 
@@ -1709,6 +2642,10 @@ func (d *Dispatcher) reconcileWarmPool(ctx context.Context, group *autoScalingGr
 	if !group.WarmPoolEnabled {
 		return nil
 	}
+	if d.metrics != nil {
+		start := time.Now()
+		defer func() { d.metrics.observeWarmPoolReconcile(time.Since(start)) }()
+	}
 
 	warmPoolInstanceIDs, err := d.autoScalingGroupWarmPoolInstanceIDs(ctx, group.Name)
 	if err != nil {
@@ -1729,20 +2666,36 @@ func (d *Dispatcher) reconcileWarmPool(ctx context.Context, group *autoScalingGr
 	switch {
 	case currentCapacity < targetCapacity:
 		addCount := targetCapacity - currentCapacity
-		if err := d.scaleOutWarmPool(ctx, group, addCount); err != nil {
+		if err := d.scaleOutWarmPool(ctx, group, currentCapacity, addCount); err != nil {
 			return err
 		}
 	case currentCapacity > targetCapacity:
 		redundant := currentCapacity - targetCapacity
 		slices.Sort(warmPoolInstanceIDs)
 		terminatedInstanceIDs := slices.Clone(warmPoolInstanceIDs[:redundant])
+		startTime := d.now().UTC()
 		if err := d.terminateAutoScalingInstancesWithReason(ctx, terminatedInstanceIDs, "warm-pool-scale-in"); err != nil {
 			return err
 		}
+		d.publishWarmPoolInstanceChanges(group.Name, terminatedInstanceIDs, warmPoolLifecycleState(group), warmPoolTerminatedLifecycleState)
+		d.recordAutoScalingInstanceActivities(
+			group.Name,
+			terminatedInstanceIDs,
+			"Terminating EC2 instance from warm pool",
+			autoScalingWarmPoolCapacityChangeCause(startTime, "an instance was taken out of the warm pool", currentCapacity, targetCapacity),
+			startTime,
+		)
 	}
 	return nil
 }
 
+// autoScalingWarmPoolTargetCapacity returns the number of instances the warm
+// pool should hold. Like AWS, the pool is sized to fill the gap between
+// DesiredCapacity and MaxGroupPreparedCapacity (MaxSize when unset), but
+// never below the warm pool MinSize. When MinSize+DesiredCapacity exceeds
+// MaxGroupPreparedCapacity, MinSize takes precedence. Since the desired
+// capacity changes as the group scales, such configurations are accepted
+// rather than rejected.
 func autoScalingWarmPoolTargetCapacity(group *autoScalingGroupData) int {
 	maxPreparedCapacity := group.MaxSize
 	if group.WarmPoolMaxGroupPreparedCapacity != nil {
@@ -1752,18 +2705,31 @@ func autoScalingWarmPoolTargetCapacity(group *autoScalingGroupData) int {
 	return max(target, group.WarmPoolMinSize)
 }
 
-func (d *Dispatcher) scaleOutWarmPool(ctx context.Context, group *autoScalingGroupData, count int) error {
+func (d *Dispatcher) scaleOutWarmPool(ctx context.Context, group *autoScalingGroupData, currentCapacity int, count int) error {
 	if count <= 0 {
 		return nil
 	}
-	createdIDs, err := d.createAutoScalingInstances(ctx, group, count, autoScalingInstanceLaunchOptions{
-		AvailabilityZone: defaultAvailabilityZone(d.opts.Region),
-		SubnetID:         autoScalingInstanceSubnetID(group),
-		WarmPool:         true,
-	})
+	startTime := d.now().UTC()
+	warmPoolInstanceIDs, err := d.autoScalingGroupWarmPoolInstanceIDs(ctx, group.Name)
+	if err != nil {
+		return err
+	}
+	placements, err := d.autoScalingLaunchPlacements(group, count, warmPoolInstanceIDs)
 	if err != nil {
 		return err
 	}
+	var createdIDs []string
+	for _, placement := range placements {
+		placementIDs, err := d.createAutoScalingInstances(ctx, group, placement.Count, autoScalingInstanceLaunchOptions{
+			AvailabilityZone: placement.AvailabilityZone,
+			SubnetID:         placement.SubnetID,
+			WarmPool:         true,
+		})
+		if err != nil {
+			return err
+		}
+		createdIDs = append(createdIDs, placementIDs...)
+	}
 
 	switch group.WarmPoolState {
 	case "", warmPoolStateStopped, warmPoolStateHibernated:
@@ -1776,6 +2742,14 @@ func (d *Dispatcher) scaleOutWarmPool(ctx context.Context, group *autoScalingGro
 		return api.ErrWithCode("ValidationError", fmt.Errorf("unsupported PoolState %q", group.WarmPoolState))
 	}
 
+	d.publishWarmPoolInstanceChanges(group.Name, createdIDs, "", warmPoolLifecycleState(group))
+	d.recordAutoScalingInstanceActivities(
+		group.Name,
+		createdIDs,
+		"Launching a new EC2 instance into warm pool",
+		autoScalingWarmPoolCapacityChangeCause(startTime, "an instance was launched into the warm pool", currentCapacity, currentCapacity+len(createdIDs)),
+		startTime,
+	)
 	api.Logger(ctx).Info(
 		"scaled out warm pool",
 		slog.String("auto_scaling_group_name", group.Name),
@@ -1794,6 +2768,15 @@ func (d *Dispatcher) terminateAutoScalingInstancesWithReason(ctx context.Context
 	if len(instanceIDs) == 0 {
 		return nil
 	}
+	if reason == autoScalingTerminationReasonScaleIn {
+		drainDelay, err := d.autoScalingScaleInDrainDelay(instanceIDs)
+		if err != nil {
+			return err
+		}
+		if drainDelay > 0 {
+			return d.drainAutoScalingInstances(ctx, instanceIDs, drainDelay)
+		}
+	}
 	attrs := []any{
 		slog.Int("count", len(instanceIDs)),
 		slog.Any("instance_ids", instanceIDs),
@@ -1860,7 +2843,7 @@ func (d *Dispatcher) autoScalingGroupManagedInstanceIDsForMode(ctx context.Conte
 			}
 			return nil, fmt.Errorf("retrieving instance attributes: %w", err)
 		}
-		if autoScalingInstanceIsSynchronous(attrs) {
+		if autoScalingInstanceIsSynchronous(attrs) || autoScalingInstanceIsTerminating(attrs) || autoScalingInstanceIsStandby(attrs) {
 			continue
 		}
 		managedInstanceIDs = append(managedInstanceIDs, instanceID)
@@ -1874,6 +2857,9 @@ func (d *Dispatcher) autoScalingGroupInstanceIDsForMode(ctx context.Context, aut
 		return nil, fmt.Errorf("retrieving registered instances: %w", err)
 	}
 	instanceIDs := make([]string, 0, len(instances))
+	markedUnhealthy := make(map[string]bool)
+	standby := make(map[string]bool)
+	draining := make(map[string]bool)
 	for _, instance := range instances {
 		attrs, err := d.storage.ResourceAttributes(instance.ID)
 		if err != nil {
@@ -1882,6 +2868,9 @@ func (d *Dispatcher) autoScalingGroupInstanceIDsForMode(ctx context.Context, aut
 		groupName, _ := attrs.Key(attributeNameAutoScalingGroupName)
 		if groupName == autoScalingGroupName && !autoScalingInstanceIsWarm(attrs) {
 			instanceIDs = append(instanceIDs, instance.ID)
+			markedUnhealthy[instance.ID] = autoScalingInstanceHealthStatusOverride(attrs) == autoScalingHealthStatusUnhealthy
+			standby[instance.ID] = autoScalingInstanceIsStandby(attrs)
+			_, draining[instance.ID] = autoScalingInstanceDrainDeadline(attrs)
 		}
 	}
 	slices.Sort(instanceIDs)
@@ -1900,25 +2889,56 @@ func (d *Dispatcher) autoScalingGroupInstanceIDsForMode(ctx context.Context, aut
 		descriptionsByID[apiInstanceID(desc.InstanceID)] = desc
 	}
 
+	var healthCheck autoScalingGroupHealthCheck
+	if reconcile {
+		healthCheck, err = d.autoScalingGroupHealthCheckConfig(autoScalingGroupName)
+		if err != nil {
+			return nil, err
+		}
+	}
 	liveIDs := make([]string, 0, len(instanceIDs))
 	missingIDs := make([]string, 0)
 	replaceIDs := make([]string, 0)
 	replaceReasons := make([]string, 0)
+	failedELBHealthCheck := make(map[string]bool)
 	for _, instanceID := range instanceIDs {
 		desc, ok := descriptionsByID[instanceID]
 		if !ok {
 			missingIDs = append(missingIDs, instanceID)
 			continue
 		}
-		if autoScalingInstanceNeedsReplacement(desc) {
+		// Standby and draining instances might be stopped, but they're not
+		// replaced.
+		if !standby[instanceID] && !draining[instanceID] && (markedUnhealthy[instanceID] || autoScalingInstanceNeedsReplacement(desc)) {
 			if !reconcile {
 				liveIDs = append(liveIDs, instanceID)
 				continue
 			}
+			// Failing health checks are ignored while the instance boots,
+			// but stopped instances are replaced right away.
+			if !markedUnhealthy[instanceID] &&
+				desc.InstanceState.Name == api.InstanceStateRunning.Name &&
+				d.now().Sub(desc.LaunchTime) < healthCheck.GracePeriod {
+				liveIDs = append(liveIDs, instanceID)
+				continue
+			}
+			reason := autoScalingInstanceReplacementReason(desc)
+			if markedUnhealthy[instanceID] {
+				reason = "set-instance-health"
+			}
 			replaceIDs = append(replaceIDs, instanceID)
-			replaceReasons = append(replaceReasons, fmt.Sprintf("%s:%s", instanceID, autoScalingInstanceReplacementReason(desc)))
+			replaceReasons = append(replaceReasons, fmt.Sprintf("%s:%s", instanceID, reason))
 			continue
 		}
+		if reconcile && !standby[instanceID] && healthCheck.ELB() &&
+			d.now().Sub(desc.LaunchTime) >= healthCheck.GracePeriod {
+			if reason, failed := autoScalingInstanceFailsELBHealthCheck(ctx, desc.PrivateIP, healthCheck.TargetGroupARNs); failed {
+				failedELBHealthCheck[instanceID] = true
+				replaceIDs = append(replaceIDs, instanceID)
+				replaceReasons = append(replaceReasons, fmt.Sprintf("%s:elb-health-check(%s)", instanceID, reason))
+				continue
+			}
+		}
 		liveIDs = append(liveIDs, instanceID)
 	}
 	if !reconcile {
@@ -1931,9 +2951,21 @@ func (d *Dispatcher) autoScalingGroupInstanceIDsForMode(ctx context.Context, aut
 			slog.String("auto_scaling_group_name", autoScalingGroupName),
 			slog.Any("replacements", replaceReasons),
 		)
+		startTime := d.now().UTC()
 		if err := d.terminateAutoScalingInstancesWithReason(ctx, replaceIDs, "replacement:"+strings.Join(replaceReasons, ",")); err != nil {
 			return nil, err
 		}
+		for _, instanceID := range replaceIDs {
+			failedCheck := "an EC2 health check indicating it has been terminated or stopped"
+			switch {
+			case markedUnhealthy[instanceID]:
+				failedCheck = "a user health-check"
+			case failedELBHealthCheck[instanceID]:
+				failedCheck = "an ELB system health check failure"
+			}
+			cause := fmt.Sprintf("At %s an instance was taken out of service in response to %s.", startTime.Format(time.RFC3339), failedCheck)
+			d.recordAutoScalingActivity(newAutoScalingActivity(autoScalingGroupName, "Terminating EC2 instance: "+instanceID, cause, startTime))
+		}
 	}
 	if len(missingIDs) == 0 {
 		return liveIDs, nil
@@ -2036,6 +3068,13 @@ func autoScalingInstanceNeedsReplacement(desc executor.InstanceDescription) bool
 	return desc.HealthStatus == executor.InstanceHealthStatusUnhealthy
 }
 
+// autoScalingInstanceHealthStatusOverride returns the health status set with
+// SetInstanceHealth, if any.
+func autoScalingInstanceHealthStatusOverride(attrs storage.Attributes) string {
+	value, _ := attrs.Key(attributeNameAutoScalingInstanceHealthStatus)
+	return value
+}
+
 func autoScalingInstanceReplacementReason(desc executor.InstanceDescription) string {
 	if desc.InstanceState.Name != api.InstanceStateRunning.Name {
 		return desc.InstanceState.Name
@@ -2074,6 +3113,9 @@ func (d *Dispatcher) cleanupAutoScalingInstanceMetadata(ctx context.Context, ins
 	if err := d.imds.SetEnabled(containerID, true); err != nil {
 		api.Logger(ctx).Warn("failed to reset IMDS endpoint while reconciling auto scaling instance", "instance_id", instanceID, "error", err)
 	}
+	if err := d.imds.SetMetadataOptions(containerID, defaultIMDSMetadataOptions); err != nil {
+		api.Logger(ctx).Warn("failed to reset IMDS metadata options while reconciling auto scaling instance", "instance_id", instanceID, "error", err)
+	}
 	if err := d.imds.RevokeTokens(containerID); err != nil {
 		api.Logger(ctx).Warn("failed to revoke IMDS tokens while reconciling auto scaling instance", "instance_id", instanceID, "error", err)
 	}
@@ -2128,6 +3170,7 @@ func (d *Dispatcher) loadAutoScalingGroupData(ctx context.Context, autoScalingGr
 	if launchTemplateVersion == "" {
 		launchTemplateVersion = "1"
 	}
+	launchTemplateVersionAlias, _ := attrs.Key(attributeNameAutoScalingGroupLaunchTemplateVersionAlias)
 	launchTemplateImageID, _ := attrs.Key(attributeNameAutoScalingGroupLaunchTemplateImageID)
 	launchTemplateInstanceType, _ := attrs.Key(attributeNameAutoScalingGroupLaunchTemplateType)
 	launchTemplateUserData, _ := attrs.Key(attributeNameAutoScalingGroupLaunchTemplateUserData)
@@ -2136,6 +3179,15 @@ func (d *Dispatcher) loadAutoScalingGroupData(ctx context.Context, autoScalingGr
 	if err != nil {
 		return nil, fmt.Errorf("invalid auto scaling group launch template block device mappings: %w", err)
 	}
+	launchTemplateKeyName, _ := attrs.Key(attributeNameAutoScalingGroupLaunchTemplateKeyName)
+	launchTemplateSecurityGroupIDsRaw, _ := attrs.Key(attributeNameAutoScalingGroupLaunchTemplateSecurityGroupIDs)
+	launchTemplateSecurityGroupIDs, err := unmarshalStringSlice(launchTemplateSecurityGroupIDsRaw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auto scaling group launch template security group IDs: %w", err)
+	}
+	launchTemplateInstanceProfileArn, _ := attrs.Key(attributeNameAutoScalingGroupLaunchTemplateInstanceProfileArn)
+	launchTemplateAvailabilityZone, _ := attrs.Key(attributeNameAutoScalingGroupLaunchTemplateAvailabilityZone)
+	launchTemplateArchitecture, _ := attrs.Key(attributeNameAutoScalingGroupLaunchTemplateArchitecture)
 	mixedInstancesPolicyRaw, _ := attrs.Key(attributeNameAutoScalingGroupMixedInstancesPolicy)
 	mixedInstancesPolicy, err := unmarshalAutoScalingMixedInstancesPolicy(mixedInstancesPolicyRaw)
 	if err != nil {
@@ -2154,9 +3206,22 @@ func (d *Dispatcher) loadAutoScalingGroupData(ctx context.Context, autoScalingGr
 	if healthCheckType == "" {
 		healthCheckType = autoScalingHealthCheckType
 	}
+	healthCheckGracePeriod, err := strconv.Atoi(attrOrDefault(attrs, attributeNameAutoScalingGroupHealthCheckGracePeriod, "0"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid integer attribute %s: %w", attributeNameAutoScalingGroupHealthCheckGracePeriod, err)
+	}
+	targetGroupARNs, err := autoScalingGroupTargetGroupARNs(attrs)
+	if err != nil {
+		return nil, err
+	}
+
+	newInstancesProtectedFromScaleIn, err := parseOptionalBoolAttribute(attrs, attributeNameAutoScalingGroupNewInstancesProtectedFromScaleIn, false)
+	if err != nil {
+		return nil, err
+	}
 
 	var vpcZoneIdentifier *string
-	if v, ok := attrs.Key(attributeNameAutoScalingGroupVPCZoneIdentifier); ok {
+	if v, _ := attrs.Key(attributeNameAutoScalingGroupVPCZoneIdentifier); v != "" {
 		vpcZoneIdentifier = &v
 	}
 
@@ -2201,6 +3266,18 @@ func (d *Dispatcher) loadAutoScalingGroupData(ctx context.Context, autoScalingGr
 	if hasWarmPoolReuseOnScaleIn {
 		warmPoolReuseOnScaleIn = &warmPoolReuseOnScaleInValue
 	}
+	scaleInDrainSecondsValue, hasScaleInDrainSeconds, err := parseOptionalIntPtrAttribute(
+		attrs,
+		attributeNameAutoScalingGroupScaleInDrainSeconds,
+	)
+	if err != nil {
+		return nil, err
+	}
+	var scaleInDrainSeconds *int
+	if hasScaleInDrainSeconds {
+		scaleInDrainSeconds = &scaleInDrainSecondsValue
+	}
+	status, _ := attrs.Key(attributeNameAutoScalingGroupStatus)
 
 	return &autoScalingGroupData{
 		Name:                              autoScalingGroupName,
@@ -2211,21 +3288,32 @@ func (d *Dispatcher) loadAutoScalingGroupData(ctx context.Context, autoScalingGr
 		LaunchTemplateID:                  launchTemplateID,
 		LaunchTemplateName:                launchTemplateName,
 		LaunchTemplateVersion:             launchTemplateVersion,
+		LaunchTemplateVersionAlias:        launchTemplateVersionAlias,
 		LaunchTemplateImageID:             launchTemplateImageID,
 		LaunchTemplateInstanceType:        launchTemplateInstanceType,
 		LaunchTemplateUserData:            launchTemplateUserData,
 		LaunchTemplateBlockDeviceMappings: launchTemplateBlockDeviceMappings,
+		LaunchTemplateKeyName:             launchTemplateKeyName,
+		LaunchTemplateSecurityGroupIDs:    launchTemplateSecurityGroupIDs,
+		LaunchTemplateInstanceProfileArn:  launchTemplateInstanceProfileArn,
+		LaunchTemplateAvailabilityZone:    launchTemplateAvailabilityZone,
+		LaunchTemplateArchitecture:        launchTemplateArchitecture,
 		MixedInstancesPolicy:              mixedInstancesPolicy,
 		AvailabilityZones:                 availabilityZones,
 		VPCZoneIdentifier:                 vpcZoneIdentifier,
 		DefaultCooldown:                   defaultCooldown,
 		HealthCheckType:                   healthCheckType,
+		TargetGroupARNs:                   targetGroupARNs,
+		HealthCheckGracePeriod:            healthCheckGracePeriod,
+		NewInstancesProtectedFromScaleIn:  newInstancesProtectedFromScaleIn,
 		WarmPoolEnabled:                   warmPoolEnabled,
 		WarmPoolMinSize:                   warmPoolMinSize,
 		WarmPoolMaxGroupPreparedCapacity:  warmPoolMaxGroupPreparedCapacity,
 		WarmPoolState:                     warmPoolState,
 		WarmPoolStatus:                    warmPoolStatus,
 		WarmPoolReuseOnScaleIn:            warmPoolReuseOnScaleIn,
+		ScaleInDrainSeconds:               scaleInDrainSeconds,
+		Status:                            status,
 	}, nil
 }
 
@@ -2238,6 +3326,10 @@ func (d *Dispatcher) saveAutoScalingGroupData(group *autoScalingGroupData) error
 	if group.WarmPoolReuseOnScaleIn != nil {
 		warmPoolReuseOnScaleIn = strconv.FormatBool(*group.WarmPoolReuseOnScaleIn)
 	}
+	scaleInDrainSeconds := ""
+	if group.ScaleInDrainSeconds != nil {
+		scaleInDrainSeconds = strconv.Itoa(*group.ScaleInDrainSeconds)
+	}
 	mixedInstancesPolicyRaw := ""
 	if group.MixedInstancesPolicy != nil {
 		raw, err := marshalAutoScalingMixedInstancesPolicy(group.MixedInstancesPolicy)
@@ -2246,6 +3338,14 @@ func (d *Dispatcher) saveAutoScalingGroupData(group *autoScalingGroupData) error
 		}
 		mixedInstancesPolicyRaw = raw
 	}
+	launchTemplateSecurityGroupIDs, err := marshalStringSlice(group.LaunchTemplateSecurityGroupIDs)
+	if err != nil {
+		return fmt.Errorf("marshaling auto scaling launch template security group IDs: %w", err)
+	}
+	targetGroupARNs, err := marshalStringSlice(group.TargetGroupARNs)
+	if err != nil {
+		return fmt.Errorf("marshaling auto scaling target group ARNs: %w", err)
+	}
 	attrs := []storage.Attribute{
 		{Key: attributeNameAutoScalingGroupName, Value: group.Name},
 		{Key: attributeNameAutoScalingGroupMinSize, Value: strconv.Itoa(group.MinSize)},
@@ -2255,18 +3355,29 @@ func (d *Dispatcher) saveAutoScalingGroupData(group *autoScalingGroupData) error
 		{Key: attributeNameAutoScalingGroupLaunchTemplateID, Value: group.LaunchTemplateID},
 		{Key: attributeNameAutoScalingGroupLaunchTemplateName, Value: group.LaunchTemplateName},
 		{Key: attributeNameAutoScalingGroupLaunchTemplateVersion, Value: group.LaunchTemplateVersion},
+		{Key: attributeNameAutoScalingGroupLaunchTemplateVersionAlias, Value: group.LaunchTemplateVersionAlias},
 		{Key: attributeNameAutoScalingGroupLaunchTemplateImageID, Value: group.LaunchTemplateImageID},
 		{Key: attributeNameAutoScalingGroupLaunchTemplateType, Value: group.LaunchTemplateInstanceType},
 		{Key: attributeNameAutoScalingGroupLaunchTemplateUserData, Value: group.LaunchTemplateUserData},
+		{Key: attributeNameAutoScalingGroupLaunchTemplateKeyName, Value: group.LaunchTemplateKeyName},
+		{Key: attributeNameAutoScalingGroupLaunchTemplateSecurityGroupIDs, Value: launchTemplateSecurityGroupIDs},
+		{Key: attributeNameAutoScalingGroupLaunchTemplateInstanceProfileArn, Value: group.LaunchTemplateInstanceProfileArn},
+		{Key: attributeNameAutoScalingGroupLaunchTemplateAvailabilityZone, Value: group.LaunchTemplateAvailabilityZone},
+		{Key: attributeNameAutoScalingGroupLaunchTemplateArchitecture, Value: group.LaunchTemplateArchitecture},
 		{Key: attributeNameAutoScalingGroupMixedInstancesPolicy, Value: mixedInstancesPolicyRaw},
 		{Key: attributeNameAutoScalingGroupDefaultCooldown, Value: strconv.Itoa(group.DefaultCooldown)},
 		{Key: attributeNameAutoScalingGroupHealthCheckType, Value: group.HealthCheckType},
+		{Key: attributeNameAutoScalingGroupTargetGroupARNs, Value: targetGroupARNs},
+		{Key: attributeNameAutoScalingGroupHealthCheckGracePeriod, Value: strconv.Itoa(group.HealthCheckGracePeriod)},
+		{Key: attributeNameAutoScalingGroupNewInstancesProtectedFromScaleIn, Value: strconv.FormatBool(group.NewInstancesProtectedFromScaleIn)},
 		{Key: attributeNameAutoScalingGroupWarmPoolEnabled, Value: strconv.FormatBool(group.WarmPoolEnabled)},
 		{Key: attributeNameAutoScalingGroupWarmPoolMinSize, Value: strconv.Itoa(group.WarmPoolMinSize)},
 		{Key: attributeNameAutoScalingGroupWarmPoolState, Value: group.WarmPoolState},
 		{Key: attributeNameAutoScalingGroupWarmPoolStatus, Value: group.WarmPoolStatus},
+		{Key: attributeNameAutoScalingGroupStatus, Value: group.Status},
 		{Key: attributeNameAutoScalingGroupWarmPoolMaxGroupPreparedCapacity, Value: warmPoolMaxGroupPreparedCapacity},
 		{Key: attributeNameAutoScalingGroupWarmPoolReuseOnScaleIn, Value: warmPoolReuseOnScaleIn},
+		{Key: attributeNameAutoScalingGroupScaleInDrainSeconds, Value: scaleInDrainSeconds},
 	}
 	if len(group.LaunchTemplateBlockDeviceMappings) > 0 {
 		raw, err := marshalBlockDeviceMappings(group.LaunchTemplateBlockDeviceMappings)
@@ -2278,15 +3389,18 @@ func (d *Dispatcher) saveAutoScalingGroupData(group *autoScalingGroupData) error
 			Value: raw,
 		})
 	}
-	if len(group.AvailabilityZones) > 0 {
-		attrs = append(attrs, storage.Attribute{
-			Key:   attributeNameAutoScalingGroupAvailabilityZones,
-			Value: strings.Join(group.AvailabilityZones, ","),
-		})
-	}
+	// Placement attributes are always written, so updates can clear them.
+	vpcZoneIdentifier := ""
 	if group.VPCZoneIdentifier != nil {
-		attrs = append(attrs, storage.Attribute{Key: attributeNameAutoScalingGroupVPCZoneIdentifier, Value: *group.VPCZoneIdentifier})
+		vpcZoneIdentifier = *group.VPCZoneIdentifier
 	}
+	attrs = append(attrs,
+		storage.Attribute{
+			Key:   attributeNameAutoScalingGroupAvailabilityZones,
+			Value: strings.Join(group.AvailabilityZones, ","),
+		},
+		storage.Attribute{Key: attributeNameAutoScalingGroupVPCZoneIdentifier, Value: vpcZoneIdentifier},
+	)
 	if err := d.storage.SetResourceAttributes(group.Name, attrs); err != nil {
 		return fmt.Errorf("saving auto scaling group attributes: %w", err)
 	}
@@ -2298,32 +3412,44 @@ func (d *Dispatcher) apiAutoScalingGroup(ctx context.Context, group *autoScaling
 	defaultCooldown := group.DefaultCooldown
 	desiredCapacity := group.DesiredCapacity
 	healthCheckType := group.HealthCheckType
+	healthCheckGracePeriod := group.HealthCheckGracePeriod
 	maxSize := group.MaxSize
 	minSize := group.MinSize
 	launchTemplateID := group.LaunchTemplateID
 	launchTemplateName := group.LaunchTemplateName
-	launchTemplateVersion := group.LaunchTemplateVersion
+	// Like EC2 Auto Scaling, groups report the version alias they were
+	// configured with, while instances report the version they launched from.
+	launchTemplateVersion := cmp.Or(group.LaunchTemplateVersionAlias, group.LaunchTemplateVersion)
 	availabilityZones := slices.Clone(group.AvailabilityZones)
 	if len(availabilityZones) == 0 {
 		availabilityZones = []string{defaultAvailabilityZone(d.opts.Region)}
 	}
 
 	out := api.AutoScalingGroup{
-		AutoScalingGroupName: &name,
-		CreatedTime:          &group.CreatedTime,
-		DefaultCooldown:      &defaultCooldown,
-		DesiredCapacity:      &desiredCapacity,
-		HealthCheckType:      &healthCheckType,
-		MaxSize:              &maxSize,
-		MinSize:              &minSize,
-		VPCZoneIdentifier:    group.VPCZoneIdentifier,
-		AvailabilityZones:    availabilityZones,
+		AutoScalingGroupName:             &name,
+		CreatedTime:                      &group.CreatedTime,
+		DefaultCooldown:                  &defaultCooldown,
+		DesiredCapacity:                  &desiredCapacity,
+		HealthCheckGracePeriod:           &healthCheckGracePeriod,
+		HealthCheckType:                  &healthCheckType,
+		MaxSize:                          &maxSize,
+		MinSize:                          &minSize,
+		NewInstancesProtectedFromScaleIn: new(group.NewInstancesProtectedFromScaleIn),
+		TargetGroupARNs:                  cloneStringSlice(group.TargetGroupARNs),
+		VPCZoneIdentifier:                group.VPCZoneIdentifier,
+		AvailabilityZones:                availabilityZones,
+	}
+	if group.Status != "" {
+		out.Status = &group.Status
 	}
 	if group.MixedInstancesPolicy != nil {
 		mixedInstancesPolicy, err := cloneAutoScalingMixedInstancesPolicy(group.MixedInstancesPolicy)
 		if err != nil {
 			return api.AutoScalingGroup{}, err
 		}
+		if mixedInstancesPolicy.LaunchTemplate != nil && mixedInstancesPolicy.LaunchTemplate.LaunchTemplateSpecification != nil {
+			mixedInstancesPolicy.LaunchTemplate.LaunchTemplateSpecification.Version = &launchTemplateVersion
+		}
 		out.MixedInstancesPolicy = mixedInstancesPolicy
 	} else {
 		out.LaunchTemplate = &api.AutoScalingLaunchTemplateSpecification{
@@ -2406,16 +3532,16 @@ func (d *Dispatcher) apiAutoScalingGroup(ctx context.Context, group *autoScaling
 			}
 			availabilityZoneStr, _ := attrs.Key(attributeNameAvailabilityZone)
 			instanceTypeStr, _ := attrs.Key(attributeNameAutoScalingGroupInstanceType)
-			healthStatus := autoScalingHealthStatus
-			lifecycleState := autoScalingLifecycleState
-			protectedFromScaleIn := false
+			healthStatus := cmp.Or(autoScalingInstanceHealthStatusOverride(attrs), autoScalingHealthStatus)
+			lifecycleState := autoScalingInstanceLifecycleState(attrs)
+			protectedFromScaleIn := autoScalingInstanceIsProtectedFromScaleIn(attrs)
 
 			instanceIDCopy := instanceID
 			availabilityZone := availabilityZoneStr
 			instanceType := instanceTypeStr
 			instanceLaunchTemplateID := launchTemplateID
 			instanceLaunchTemplateName := launchTemplateName
-			instanceLaunchTemplateVersion := launchTemplateVersion
+			instanceLaunchTemplateVersion := autoScalingInstanceLaunchTemplateVersion(attrs, group)
 
 			instances = append(instances, api.AutoScalingInstance{
 				AvailabilityZone: &availabilityZone,
@@ -2542,10 +3668,6 @@ func normalizeAutoScalingAvailabilityZones(availabilityZones []string) ([]string
 	return normalized, nil
 }
 
-func validateAutoScalingPlacement(_ *string, _ []string) error {
-	return nil
-}
-
 func parseAutoScalingAvailabilityZones(raw string) ([]string, error) {
 	if strings.TrimSpace(raw) == "" {
 		return nil, nil
@@ -2604,7 +3726,7 @@ func validateLaunchInstancesRetryStrategy(retryStrategy *string) (string, error)
 
 func validateLaunchInstancesGroupSupport(group *autoScalingGroupData) error {
 	if group == nil {
-		return api.ErrWithCode("ValidationError", fmt.Errorf("AutoScalingGroupName is required"))
+		return api.ValidationError("AutoScalingGroupName", "AutoScalingGroupName is required")
 	}
 	if group.WarmPoolEnabled {
 		return api.ErrWithCode(
@@ -2781,20 +3903,34 @@ func launchInstancesMarketType(_ *autoScalingGroupData) string {
 
 func validateAutoScalingGroupSizes(minSize int, maxSize int) error {
 	if minSize < 0 {
-		return api.ErrWithCode("ValidationError", fmt.Errorf("MinSize must be >= 0"))
+		return api.ValidationError("MinSize", "MinSize must be >= 0")
 	}
 	if maxSize < 0 {
-		return api.ErrWithCode("ValidationError", fmt.Errorf("MaxSize must be >= 0"))
+		return api.ValidationError("MaxSize", "MaxSize must be >= 0")
 	}
 	if minSize > maxSize {
-		return api.ErrWithCode("ValidationError", fmt.Errorf("MinSize cannot be greater than MaxSize"))
+		return api.ValidationError("MinSize", "MinSize (%d) cannot be greater than MaxSize (%d)", minSize, maxSize)
+	}
+	return nil
+}
+
+func validateHealthCheckGracePeriod(healthCheckGracePeriod int) error {
+	if healthCheckGracePeriod < 0 {
+		return api.ValidationError("HealthCheckGracePeriod", "HealthCheckGracePeriod must be >= 0")
+	}
+	return nil
+}
+
+func validateScaleInDrainSeconds(scaleInDrainSeconds int) error {
+	if scaleInDrainSeconds < 0 {
+		return api.ValidationError("ScaleInDrainSeconds", "ScaleInDrainSeconds must be >= 0")
 	}
 	return nil
 }
 
 func validateDesiredCapacity(desiredCapacity int, minSize int, maxSize int) error {
 	if desiredCapacity < minSize || desiredCapacity > maxSize {
-		return api.ErrWithCode("ValidationError", fmt.Errorf("DesiredCapacity must be between MinSize and MaxSize"))
+		return api.ValidationError("DesiredCapacity", "DesiredCapacity (%d) must be between MinSize (%d) and MaxSize (%d)", desiredCapacity, minSize, maxSize)
 	}
 	return nil
 }
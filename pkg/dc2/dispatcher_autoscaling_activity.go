@@ -0,0 +1,100 @@
+package dc2
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+)
+
+const (
+	// autoScalingActivityLogSize is the maximum number of scaling activities
+	// kept across all groups. Older activities are discarded first.
+	autoScalingActivityLogSize = 1000
+
+	autoScalingActivityStatusSuccessful = "Successful"
+)
+
+// recordAutoScalingActivity marks activity as completed and adds it to the
+// activity log returned by DescribeScalingActivities.
+func (d *Dispatcher) recordAutoScalingActivity(activity api.AutoScalingActivity) {
+	endTime := d.now().UTC()
+	activity.EndTime = &endTime
+	activity.Progress = new(100)
+	activity.StatusCode = new(autoScalingActivityStatusSuccessful)
+
+	d.autoScalingActivityMu.Lock()
+	defer d.autoScalingActivityMu.Unlock()
+	d.autoScalingActivities = append(d.autoScalingActivities, activity)
+	if overflow := len(d.autoScalingActivities) - autoScalingActivityLogSize; overflow > 0 {
+		d.autoScalingActivities = slices.Delete(d.autoScalingActivities, 0, overflow)
+	}
+}
+
+// recordAutoScalingInstanceActivities records one activity per instance, using
+// the instance ID as the suffix of description.
+func (d *Dispatcher) recordAutoScalingInstanceActivities(groupName string, instanceIDs []string, description string, cause string, startTime time.Time) {
+	for _, instanceID := range instanceIDs {
+		d.recordAutoScalingActivity(newAutoScalingActivity(groupName, description+": "+instanceID, cause, startTime))
+	}
+}
+
+func (d *Dispatcher) dispatchDescribeScalingActivities(
+	_ context.Context,
+	req *api.DescribeScalingActivitiesRequest,
+) (*api.DescribeScalingActivitiesResponse, error) {
+	groupName := ""
+	if req.AutoScalingGroupName != nil {
+		groupName = *req.AutoScalingGroupName
+	}
+
+	d.autoScalingActivityMu.Lock()
+	activities := make([]api.AutoScalingActivity, 0, len(d.autoScalingActivities))
+	// Newest activities come first
+	for _, activity := range slices.Backward(d.autoScalingActivities) {
+		if groupName != "" && *activity.AutoScalingGroupName != groupName {
+			continue
+		}
+		if len(req.ActivityIDs) > 0 && !slices.Contains(req.ActivityIDs, *activity.ActivityID) {
+			continue
+		}
+		activities = append(activities, activity)
+	}
+	d.autoScalingActivityMu.Unlock()
+
+	activities, nextToken, err := applyNextToken(activities, req.NextToken, req.MaxRecords)
+	if err != nil {
+		return nil, err
+	}
+	return &api.DescribeScalingActivitiesResponse{
+		DescribeScalingActivitiesResult: api.DescribeScalingActivitiesResult{
+			Activities: activities,
+			NextToken:  nextToken,
+		},
+	}, nil
+}
+
+// autoScalingCapacityChangeCause returns the cause of an activity that moved
+// the group capacity from one value to another.
+func autoScalingCapacityChangeCause(startTime time.Time, what string, from int, to int) string {
+	return capacityChangeCause(startTime, what, "capacity", from, to)
+}
+
+// autoScalingWarmPoolCapacityChangeCause is like
+// autoScalingCapacityChangeCause, but for the warm pool capacity.
+func autoScalingWarmPoolCapacityChangeCause(startTime time.Time, what string, from int, to int) string {
+	return capacityChangeCause(startTime, what, "warm pool capacity", from, to)
+}
+
+func capacityChangeCause(startTime time.Time, what string, capacity string, from int, to int) string {
+	verb := "increasing"
+	if to < from {
+		verb = "shrinking"
+	}
+	return fmt.Sprintf(
+		"At %s %s in response to a difference between desired and actual %s, %s the %s from %d to %d.",
+		startTime.Format(time.RFC3339), what, capacity, verb, capacity, from, to,
+	)
+}
@@ -0,0 +1,86 @@
+package dc2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+func TestDescribeScalingActivities(t *testing.T) {
+	t.Parallel()
+
+	const groupName = "asg"
+	ctx := t.Context()
+	d := &Dispatcher{
+		exe:     newScalingExecutor(),
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+	require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeAutoScalingGroup, ID: groupName}))
+	require.NoError(t, d.saveAutoScalingGroupData(&autoScalingGroupData{
+		Name:                       groupName,
+		CreatedTime:                time.Now(),
+		MaxSize:                    2,
+		LaunchTemplateID:           "lt-1",
+		LaunchTemplateName:         "lt",
+		LaunchTemplateImageID:      "nginx",
+		LaunchTemplateInstanceType: "my-type",
+	}))
+
+	for _, desiredCapacity := range []int{1, 0} {
+		_, err := d.Dispatch(ctx, &api.SetDesiredCapacityRequest{
+			AutoScalingGroupName: groupName,
+			DesiredCapacity:      new(desiredCapacity),
+		})
+		require.NoError(t, err)
+	}
+
+	resp, err := d.dispatchDescribeScalingActivities(ctx, &api.DescribeScalingActivitiesRequest{
+		AutoScalingGroupName: new(groupName),
+	})
+	require.NoError(t, err)
+	activities := resp.DescribeScalingActivitiesResult.Activities
+	require.Len(t, activities, 2)
+	// Newest first
+	assert.Contains(t, *activities[0].Description, "Terminating EC2 instance: i-")
+	assert.Contains(t, *activities[0].Cause, "shrinking the capacity from 1 to 0")
+	assert.Contains(t, *activities[1].Description, "Launching a new EC2 instance: i-")
+	assert.Contains(t, *activities[1].Cause, "increasing the capacity from 0 to 1")
+	for _, activity := range activities {
+		assert.Equal(t, groupName, *activity.AutoScalingGroupName)
+		assert.Equal(t, "Successful", *activity.StatusCode)
+		assert.Equal(t, 100, *activity.Progress)
+		assert.NotEmpty(t, *activity.ActivityID)
+		require.NotNil(t, activity.EndTime)
+		assert.False(t, activity.EndTime.Before(*activity.StartTime))
+	}
+
+	page, err := d.dispatchDescribeScalingActivities(ctx, &api.DescribeScalingActivitiesRequest{
+		AutoScalingGroupName: new(groupName),
+		MaxRecords:           new(1),
+	})
+	require.NoError(t, err)
+	require.Len(t, page.DescribeScalingActivitiesResult.Activities, 1)
+	assert.Equal(t, *activities[0].ActivityID, *page.DescribeScalingActivitiesResult.Activities[0].ActivityID)
+	require.NotNil(t, page.DescribeScalingActivitiesResult.NextToken)
+	page, err = d.dispatchDescribeScalingActivities(ctx, &api.DescribeScalingActivitiesRequest{
+		AutoScalingGroupName: new(groupName),
+		MaxRecords:           new(1),
+		NextToken:            page.DescribeScalingActivitiesResult.NextToken,
+	})
+	require.NoError(t, err)
+	require.Len(t, page.DescribeScalingActivitiesResult.Activities, 1)
+	assert.Equal(t, *activities[1].ActivityID, *page.DescribeScalingActivitiesResult.Activities[0].ActivityID)
+
+	other, err := d.dispatchDescribeScalingActivities(ctx, &api.DescribeScalingActivitiesRequest{
+		AutoScalingGroupName: new("other"),
+	})
+	require.NoError(t, err)
+	assert.Empty(t, other.DescribeScalingActivitiesResult.Activities)
+}
@@ -0,0 +1,220 @@
+package dc2
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/executor"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/testprofile"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+// scalingExecutor keeps track of the instances it creates, so auto scaling
+// groups can scale out and in against it.
+type scalingExecutor struct {
+	*exitCleanupExecutor
+	mu        sync.Mutex
+	nextID    int
+	instances map[executor.InstanceID]api.InstanceState
+}
+
+func newScalingExecutor() *scalingExecutor {
+	return &scalingExecutor{
+		exitCleanupExecutor: &exitCleanupExecutor{},
+		instances:           make(map[executor.InstanceID]api.InstanceState),
+	}
+}
+
+func (e *scalingExecutor) CreateInstances(_ context.Context, req executor.CreateInstancesRequest) ([]executor.InstanceID, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	ids := make([]executor.InstanceID, req.Count)
+	for i := range ids {
+		e.nextID++
+		ids[i] = executor.InstanceID(fmt.Sprintf("%017x", e.nextID))
+		e.instances[ids[i]] = api.InstanceStatePending
+	}
+	return ids, nil
+}
+
+func (e *scalingExecutor) setState(instanceIDs []executor.InstanceID, state api.InstanceState) []executor.InstanceStateChange {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	changes := make([]executor.InstanceStateChange, 0, len(instanceIDs))
+	for _, instanceID := range instanceIDs {
+		previous, ok := e.instances[instanceID]
+		if !ok {
+			continue
+		}
+		e.instances[instanceID] = state
+		changes = append(changes, executor.InstanceStateChange{InstanceID: instanceID, PreviousState: previous, CurrentState: state})
+	}
+	return changes
+}
+
+func (e *scalingExecutor) StartInstances(_ context.Context, req executor.StartInstancesRequest) ([]executor.InstanceStateChange, error) {
+	return e.setState(req.InstanceIDs, api.InstanceStateRunning), nil
+}
+
+func (e *scalingExecutor) StopInstances(_ context.Context, req executor.StopInstancesRequest) ([]executor.InstanceStateChange, error) {
+	return e.setState(req.InstanceIDs, api.InstanceStateStopped), nil
+}
+
+func (e *scalingExecutor) TerminateInstances(_ context.Context, req executor.TerminateInstancesRequest) ([]executor.InstanceStateChange, error) {
+	changes := e.setState(req.InstanceIDs, api.InstanceStateTerminated)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, instanceID := range req.InstanceIDs {
+		delete(e.instances, instanceID)
+	}
+	return changes, nil
+}
+
+func (e *scalingExecutor) DescribeInstances(_ context.Context, req executor.DescribeInstancesRequest) ([]executor.InstanceDescription, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	descs := make([]executor.InstanceDescription, 0, len(req.InstanceIDs))
+	for _, instanceID := range req.InstanceIDs {
+		state, ok := e.instances[instanceID]
+		if !ok {
+			continue
+		}
+		descs = append(descs, executor.InstanceDescription{InstanceID: instanceID, InstanceState: state})
+	}
+	return descs, nil
+}
+
+// newSlowLaunchTestDispatcher returns a dispatcher with an empty group whose
+// launches release the dispatch lock while waiting on a delay, letting other
+// requests run in the meantime. It must be called inside a synctest bubble.
+func newSlowLaunchTestDispatcher(t *testing.T, groupName string) *Dispatcher {
+	t.Helper()
+	d := &Dispatcher{
+		exe:                 newScalingExecutor(),
+		imds:                &imdsController{},
+		storage:             storage.NewMemoryStorage(),
+		testProfileUpdateCh: make(chan struct{}, 1),
+		testProfile: &testprofile.Profile{
+			Version: testprofile.Version1,
+			Rules: []testprofile.Rule{
+				{
+					Name: "slow-allocate",
+					When: testprofile.RuleWhen{Action: testprofile.ActionRunInstances},
+					Delay: testprofile.DelaySpec{
+						Before: testprofile.DelayHooks{
+							Allocate: &testprofile.Duration{Duration: time.Second},
+						},
+					},
+				},
+			},
+		},
+	}
+	require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeAutoScalingGroup, ID: groupName}))
+	require.NoError(t, d.saveAutoScalingGroupData(&autoScalingGroupData{
+		Name:                       groupName,
+		CreatedTime:                time.Now(),
+		MaxSize:                    4,
+		LaunchTemplateID:           "lt-1",
+		LaunchTemplateName:         "lt",
+		LaunchTemplateImageID:      "nginx",
+		LaunchTemplateInstanceType: "my-type",
+	}))
+	return d
+}
+
+func TestPutWarmPoolConcurrentWithSetDesiredCapacity(t *testing.T) {
+	t.Parallel()
+
+	synctest.Test(t, func(t *testing.T) {
+		const groupName = "asg"
+		ctx := t.Context()
+		d := newSlowLaunchTestDispatcher(t, groupName)
+
+		// SetDesiredCapacity saves the group once its launch completes, which
+		// used to overwrite the warm pool configuration stored meanwhile.
+		var wg sync.WaitGroup
+		wg.Go(func() {
+			_, err := d.Dispatch(ctx, &api.SetDesiredCapacityRequest{
+				AutoScalingGroupName: groupName,
+				DesiredCapacity:      new(2),
+			})
+			assert.NoError(t, err)
+		})
+		synctest.Wait()
+		wg.Go(func() {
+			_, err := d.Dispatch(ctx, &api.PutWarmPoolRequest{AutoScalingGroupName: groupName})
+			assert.NoError(t, err)
+		})
+		wg.Wait()
+
+		d.dispatchMu.Lock()
+		defer d.dispatchMu.Unlock()
+		group, err := d.loadAutoScalingGroupData(ctx, groupName)
+		require.NoError(t, err)
+		assert.True(t, group.WarmPoolEnabled)
+		assert.Equal(t, 2, group.DesiredCapacity)
+		instanceIDs, err := d.autoScalingGroupManagedInstanceIDs(ctx, groupName)
+		require.NoError(t, err)
+		assert.Len(t, instanceIDs, 2)
+		// MaxSize - DesiredCapacity
+		warmPoolInstanceIDs, err := d.autoScalingGroupWarmPoolInstanceIDs(ctx, groupName)
+		require.NoError(t, err)
+		assert.Len(t, warmPoolInstanceIDs, 2)
+	})
+}
+
+func TestScheduledActionConcurrentWithSetDesiredCapacity(t *testing.T) {
+	t.Parallel()
+
+	synctest.Test(t, func(t *testing.T) {
+		const groupName = "asg"
+		ctx := t.Context()
+		d := newSlowLaunchTestDispatcher(t, groupName)
+		require.NoError(t, d.saveAutoScalingScheduledAction(groupName, autoScalingScheduledAction{
+			Name:            "scale-in",
+			StartTime:       new(d.now().Add(-time.Minute)),
+			MinSize:         new(1),
+			DesiredCapacity: new(1),
+		}))
+
+		// The scheduled action must wait for the SetDesiredCapacity launch,
+		// instead of scaling based on the instances seen before it completes.
+		var wg sync.WaitGroup
+		wg.Go(func() {
+			_, err := d.Dispatch(ctx, &api.SetDesiredCapacityRequest{
+				AutoScalingGroupName: groupName,
+				DesiredCapacity:      new(3),
+			})
+			assert.NoError(t, err)
+		})
+		synctest.Wait()
+		wg.Go(func() {
+			d.dispatchMu.Lock()
+			defer d.dispatchMu.Unlock()
+			assert.NoError(t, d.runAllDueAutoScalingScheduledActions(ctx))
+		})
+		wg.Wait()
+
+		d.dispatchMu.Lock()
+		defer d.dispatchMu.Unlock()
+		group, err := d.loadAutoScalingGroupData(ctx, groupName)
+		require.NoError(t, err)
+		assert.Equal(t, 1, group.MinSize)
+		assert.Equal(t, 1, group.DesiredCapacity)
+		instanceIDs, err := d.autoScalingGroupManagedInstanceIDs(ctx, groupName)
+		require.NoError(t, err)
+		assert.Len(t, instanceIDs, 1)
+		actions, err := d.autoScalingScheduledActions(groupName)
+		require.NoError(t, err)
+		assert.Empty(t, actions)
+	})
+}
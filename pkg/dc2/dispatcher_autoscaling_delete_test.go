@@ -0,0 +1,69 @@
+package dc2
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+func TestDeleteAutoScalingGroupAsyncReportsDeleteInProgress(t *testing.T) {
+	t.Parallel()
+
+	const (
+		groupName  = "asg"
+		instanceID = "i-00000000000000001"
+	)
+	ctx := context.Background()
+	exe := &runningInstancesExecutor{exitCleanupExecutor: &exitCleanupExecutor{}}
+	d := &Dispatcher{
+		opts:    DispatcherOptions{AsyncStateTransitions: true},
+		exe:     exe,
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+	require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeAutoScalingGroup, ID: groupName}))
+	require.NoError(t, d.saveAutoScalingGroupData(&autoScalingGroupData{
+		Name:               groupName,
+		CreatedTime:        time.Now(),
+		MaxSize:            1,
+		DesiredCapacity:    1,
+		LaunchTemplateID:   "lt-1",
+		LaunchTemplateName: "lt",
+	}))
+	require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeInstance, ID: instanceID}))
+	require.NoError(t, d.storage.SetResourceAttributes(instanceID, []storage.Attribute{
+		{Key: attributeNameAutoScalingGroupName, Value: groupName},
+	}))
+
+	_, err := d.dispatchDeleteAutoScalingGroup(ctx, &api.DeleteAutoScalingGroupRequest{
+		AutoScalingGroupName: groupName,
+		ForceDelete:          new(true),
+	})
+	require.NoError(t, err)
+	assert.Empty(t, exe.terminateReqs)
+
+	describeResp, err := d.dispatchDescribeAutoScalingGroups(ctx, &api.DescribeAutoScalingGroupsRequest{
+		AutoScalingGroupNames: []string{groupName},
+	})
+	require.NoError(t, err)
+	groups := describeResp.DescribeAutoScalingGroupsResult.AutoScalingGroups
+	require.Len(t, groups, 1)
+	require.NotNil(t, groups[0].Status)
+	assert.Equal(t, "Delete in progress", *groups[0].Status)
+	assert.Len(t, groups[0].Instances, 1)
+
+	require.NoError(t, d.reconcileAllAutoScalingGroups(ctx))
+	assert.Len(t, exe.terminateReqs, 1)
+	describeResp, err = d.dispatchDescribeAutoScalingGroups(ctx, &api.DescribeAutoScalingGroupsRequest{
+		AutoScalingGroupNames: []string{groupName},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, describeResp.DescribeAutoScalingGroupsResult.AutoScalingGroups)
+}
@@ -0,0 +1,199 @@
+package dc2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+const (
+	attributeNameAutoScalingInstanceDrainDeadline    = "AutoScalingInstanceDrainDeadline"
+	attributeNameAutoScalingGroupScaleInDrainSeconds = "AutoScalingGroupScaleInDrainSeconds"
+
+	autoScalingTerminationReasonScaleIn        = "scale-in"
+	autoScalingTerminationReasonScaleInDrained = "scale-in-drained"
+)
+
+// now returns the current time, from the injected clock when set.
+func (d *Dispatcher) now() time.Time {
+	if d.clock != nil {
+		return d.clock()
+	}
+	return time.Now()
+}
+
+// autoScalingScaleInDrainDelay returns how long the scaled in instances, which
+// all belong to the same group, drain before being terminated: the group
+// ScaleInDrainSeconds when set and DispatcherOptions.ScaleInDrainDelay
+// otherwise.
+func (d *Dispatcher) autoScalingScaleInDrainDelay(instanceIDs []string) (time.Duration, error) {
+	attrs, err := d.storage.ResourceAttributes(instanceIDs[0])
+	if err != nil {
+		if errors.As(err, &storage.ErrResourceNotFound{}) {
+			return d.opts.ScaleInDrainDelay, nil
+		}
+		return 0, fmt.Errorf("retrieving instance attributes: %w", err)
+	}
+	groupName, _ := attrs.Key(attributeNameAutoScalingGroupName)
+	if groupName == "" {
+		return d.opts.ScaleInDrainDelay, nil
+	}
+	groupAttrs, err := d.storage.ResourceAttributes(groupName)
+	if err != nil {
+		if errors.As(err, &storage.ErrResourceNotFound{}) {
+			return d.opts.ScaleInDrainDelay, nil
+		}
+		return 0, fmt.Errorf("retrieving auto scaling group attributes: %w", err)
+	}
+	seconds, found, err := parseOptionalIntPtrAttribute(groupAttrs, attributeNameAutoScalingGroupScaleInDrainSeconds)
+	if err != nil {
+		return 0, err
+	}
+	if !found {
+		return d.opts.ScaleInDrainDelay, nil
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// drainAutoScalingInstances stops scaled in instances and keeps them in
+// Terminating:Wait for drainDelay before terminating them, like load
+// balancer connection draining does. The deadline is stored with the
+// instance and terminateDrainedAutoScalingInstances enforces it during
+// reconciliation, while cancelAutoScalingDrains cuts pending drains short
+// on Close.
+func (d *Dispatcher) drainAutoScalingInstances(ctx context.Context, instanceIDs []string, drainDelay time.Duration) error {
+	deadline := d.now().Add(drainDelay)
+	stopIDs := make([]string, 0, len(instanceIDs))
+	for _, instanceID := range instanceIDs {
+		attrs, err := d.storage.ResourceAttributes(instanceID)
+		if err != nil {
+			if errors.As(err, &storage.ErrResourceNotFound{}) {
+				continue
+			}
+			return fmt.Errorf("retrieving instance attributes: %w", err)
+		}
+		if _, draining := autoScalingInstanceDrainDeadline(attrs); draining {
+			continue
+		}
+		if err := d.storage.SetResourceAttributes(instanceID, []storage.Attribute{
+			{Key: attributeNameAutoScalingInstanceDrainDeadline, Value: deadline.Format(time.RFC3339Nano)},
+		}); err != nil {
+			return fmt.Errorf("saving drain deadline for instance %s: %w", instanceID, err)
+		}
+		stopIDs = append(stopIDs, instanceID)
+	}
+	if len(stopIDs) > 0 {
+		if _, err := d.stopInstancesWithProfileDelay(ctx, executorInstanceIDs(stopIDs), false); err != nil {
+			return fmt.Errorf("stopping draining instances: %w", err)
+		}
+	}
+	api.Logger(ctx).Info(
+		"draining auto scaling instances before termination",
+		slog.Any("instance_ids", instanceIDs),
+		slog.Duration("drain_delay", drainDelay),
+	)
+	return nil
+}
+
+// terminateDrainedAutoScalingInstances terminates the group instances whose
+// drain delay has elapsed.
+func (d *Dispatcher) terminateDrainedAutoScalingInstances(ctx context.Context, groupName string) error {
+	deadlines, err := d.autoScalingDrainDeadlines(groupName)
+	if err != nil {
+		return err
+	}
+	now := d.now()
+	var drained []string
+	for instanceID, deadline := range deadlines {
+		if !now.Before(deadline) {
+			drained = append(drained, instanceID)
+		}
+	}
+	slices.Sort(drained)
+	return d.terminateAutoScalingInstancesWithReason(ctx, drained, autoScalingTerminationReasonScaleInDrained)
+}
+
+// cancelAutoScalingDrains terminates every draining instance right away,
+// without waiting for its drain delay. It must be called with dispatchMu
+// held, when shutting down, since nothing would enforce the deadlines
+// afterwards and the instances would linger stopped in Terminating:Wait.
+func (d *Dispatcher) cancelAutoScalingDrains(ctx context.Context) error {
+	groups, err := d.storage.RegisteredResources(types.ResourceTypeAutoScalingGroup)
+	if err != nil {
+		return fmt.Errorf("retrieving auto scaling groups: %w", err)
+	}
+	for _, group := range groups {
+		if err := d.cancelAutoScalingGroupDrains(ctx, group.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Dispatcher) cancelAutoScalingGroupDrains(ctx context.Context, groupName string) error {
+	unlock, err := d.lockAutoScalingGroup(ctx, groupName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	deadlines, err := d.autoScalingDrainDeadlines(groupName)
+	if err != nil {
+		return err
+	}
+	if len(deadlines) == 0 {
+		return nil
+	}
+	draining := slices.Sorted(maps.Keys(deadlines))
+	api.Logger(ctx).Info(
+		"canceling auto scaling instance drains on shutdown",
+		slog.String("auto_scaling_group_name", groupName),
+		slog.Any("instance_ids", draining),
+	)
+	return d.terminateAutoScalingInstancesWithReason(ctx, draining, autoScalingTerminationReasonScaleInDrained)
+}
+
+// autoScalingDrainDeadlines returns the drain deadline of every draining
+// instance in the group, by instance ID.
+func (d *Dispatcher) autoScalingDrainDeadlines(groupName string) (map[string]time.Time, error) {
+	instances, err := d.storage.RegisteredResources(types.ResourceTypeInstance)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving registered instances: %w", err)
+	}
+	deadlines := make(map[string]time.Time)
+	for _, instance := range instances {
+		attrs, err := d.storage.ResourceAttributes(instance.ID)
+		if err != nil {
+			if errors.As(err, &storage.ErrResourceNotFound{}) {
+				continue
+			}
+			return nil, fmt.Errorf("retrieving instance attributes: %w", err)
+		}
+		if name, _ := attrs.Key(attributeNameAutoScalingGroupName); name != groupName {
+			continue
+		}
+		if deadline, draining := autoScalingInstanceDrainDeadline(attrs); draining {
+			deadlines[instance.ID] = deadline
+		}
+	}
+	return deadlines, nil
+}
+
+func autoScalingInstanceDrainDeadline(attrs storage.Attributes) (time.Time, bool) {
+	value, ok := attrs.Key(attributeNameAutoScalingInstanceDrainDeadline)
+	if !ok || value == "" {
+		return time.Time{}, false
+	}
+	deadline, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return deadline, true
+}
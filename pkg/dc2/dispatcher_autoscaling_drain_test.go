@@ -0,0 +1,165 @@
+package dc2
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/executor"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+// drainRecordingExecutor records the order of the stop and terminate calls.
+type drainRecordingExecutor struct {
+	exitCleanupExecutor
+	calls []string
+}
+
+func (e *drainRecordingExecutor) StopInstances(_ context.Context, req executor.StopInstancesRequest) ([]executor.InstanceStateChange, error) {
+	for _, instanceID := range req.InstanceIDs {
+		e.calls = append(e.calls, "stop:"+apiInstanceID(instanceID))
+	}
+	return nil, nil
+}
+
+func (e *drainRecordingExecutor) TerminateInstances(ctx context.Context, req executor.TerminateInstancesRequest) ([]executor.InstanceStateChange, error) {
+	for _, instanceID := range req.InstanceIDs {
+		e.calls = append(e.calls, "terminate:"+apiInstanceID(instanceID))
+	}
+	return e.exitCleanupExecutor.TerminateInstances(ctx, req)
+}
+
+func TestAutoScalingScaleInDrainsInstancesBeforeTermination(t *testing.T) {
+	t.Parallel()
+
+	const (
+		groupName  = "asg"
+		instanceID = "i-00000000000000001"
+	)
+	ctx := context.Background()
+	now := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	exe := &exitCleanupExecutor{}
+	d := &Dispatcher{
+		opts:    DispatcherOptions{ScaleInDrainDelay: 30 * time.Second},
+		exe:     exe,
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+		clock:   func() time.Time { return now },
+	}
+	require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeInstance, ID: instanceID}))
+	require.NoError(t, d.storage.SetResourceAttributes(instanceID, []storage.Attribute{
+		{Key: attributeNameAutoScalingGroupName, Value: groupName},
+	}))
+
+	require.NoError(t, d.terminateAutoScalingInstancesWithReason(ctx, []string{instanceID}, autoScalingTerminationReasonScaleIn))
+	attrs, err := d.storage.ResourceAttributes(instanceID)
+	require.NoError(t, err)
+	assert.Equal(t, autoScalingLifecycleStateTerminatingWait, autoScalingInstanceLifecycleState(attrs))
+	assert.True(t, autoScalingInstanceIsTerminating(attrs))
+	assert.Empty(t, exe.terminateReqs)
+
+	now = now.Add(29 * time.Second)
+	require.NoError(t, d.terminateDrainedAutoScalingInstances(ctx, groupName))
+	assert.Empty(t, exe.terminateReqs)
+
+	now = now.Add(time.Second)
+	require.NoError(t, d.terminateDrainedAutoScalingInstances(ctx, groupName))
+	require.Len(t, exe.terminateReqs, 1)
+	_, err = d.storage.ResourceAttributes(instanceID)
+	require.ErrorAs(t, err, &storage.ErrResourceNotFound{})
+}
+
+func TestAutoScalingGroupScaleInDrainSeconds(t *testing.T) {
+	t.Parallel()
+
+	const (
+		groupName  = "asg"
+		instanceID = "i-00000000000000001"
+	)
+	ctx := context.Background()
+	now := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	exe := &drainRecordingExecutor{}
+	d := &Dispatcher{
+		exe:     exe,
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+		clock:   func() time.Time { return now },
+	}
+	require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeAutoScalingGroup, ID: groupName}))
+	require.NoError(t, d.storage.SetResourceAttributes(groupName, []storage.Attribute{
+		{Key: attributeNameAutoScalingGroupScaleInDrainSeconds, Value: "5"},
+	}))
+	require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeInstance, ID: instanceID}))
+	require.NoError(t, d.storage.SetResourceAttributes(instanceID, []storage.Attribute{
+		{Key: attributeNameAutoScalingGroupName, Value: groupName},
+	}))
+
+	// The group setting applies even without a global drain delay, and the
+	// instance is stopped while it drains
+	require.NoError(t, d.terminateAutoScalingInstancesWithReason(ctx, []string{instanceID}, autoScalingTerminationReasonScaleIn))
+	attrs, err := d.storage.ResourceAttributes(instanceID)
+	require.NoError(t, err)
+	assert.Equal(t, autoScalingLifecycleStateTerminatingWait, autoScalingInstanceLifecycleState(attrs))
+	assert.Equal(t, []string{"stop:" + instanceID}, exe.calls)
+
+	now = now.Add(4 * time.Second)
+	require.NoError(t, d.terminateDrainedAutoScalingInstances(ctx, groupName))
+	assert.Equal(t, []string{"stop:" + instanceID}, exe.calls)
+
+	now = now.Add(time.Second)
+	require.NoError(t, d.terminateDrainedAutoScalingInstances(ctx, groupName))
+	assert.Equal(t, []string{"stop:" + instanceID, "terminate:" + instanceID}, exe.calls)
+	_, err = d.storage.ResourceAttributes(instanceID)
+	require.ErrorAs(t, err, &storage.ErrResourceNotFound{})
+
+	// A zero group setting disables the global drain delay
+	const otherInstanceID = "i-00000000000000002"
+	d.opts.ScaleInDrainDelay = time.Minute
+	require.NoError(t, d.storage.SetResourceAttributes(groupName, []storage.Attribute{
+		{Key: attributeNameAutoScalingGroupScaleInDrainSeconds, Value: "0"},
+	}))
+	require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeInstance, ID: otherInstanceID}))
+	require.NoError(t, d.storage.SetResourceAttributes(otherInstanceID, []storage.Attribute{
+		{Key: attributeNameAutoScalingGroupName, Value: groupName},
+	}))
+	require.NoError(t, d.terminateAutoScalingInstancesWithReason(ctx, []string{otherInstanceID}, autoScalingTerminationReasonScaleIn))
+	assert.Equal(t, "terminate:"+otherInstanceID, exe.calls[len(exe.calls)-1])
+}
+
+func TestDispatcherCloseCancelsAutoScalingDrains(t *testing.T) {
+	t.Parallel()
+
+	const (
+		groupName  = "asg"
+		instanceID = "i-00000000000000001"
+	)
+	ctx := context.Background()
+	exe := &drainRecordingExecutor{}
+	d := &Dispatcher{
+		opts: DispatcherOptions{
+			ExitResourceMode:  ExitResourceModeKeep,
+			ScaleInDrainDelay: time.Hour,
+		},
+		exe:     exe,
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+	require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeAutoScalingGroup, ID: groupName}))
+	require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeInstance, ID: instanceID}))
+	require.NoError(t, d.storage.SetResourceAttributes(instanceID, []storage.Attribute{
+		{Key: attributeNameAutoScalingGroupName, Value: groupName},
+	}))
+	require.NoError(t, d.terminateAutoScalingInstancesWithReason(ctx, []string{instanceID}, autoScalingTerminationReasonScaleIn))
+	assert.Equal(t, []string{"stop:" + instanceID}, exe.calls)
+
+	// The instance is terminated even with the drain delay pending and
+	// resources kept on exit
+	require.NoError(t, d.Close(ctx))
+	assert.Equal(t, []string{"stop:" + instanceID, "terminate:" + instanceID}, exe.calls)
+	_, err := d.storage.ResourceAttributes(instanceID)
+	require.ErrorAs(t, err, &storage.ErrResourceNotFound{})
+}
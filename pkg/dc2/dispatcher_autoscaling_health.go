@@ -0,0 +1,149 @@
+package dc2
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+)
+
+const (
+	attributeNameAutoScalingGroupTargetGroupARNs = "AutoScalingGroupTargetGroupARNs"
+
+	autoScalingHealthCheckTypeELB = "ELB"
+
+	// autoScalingELBHealthCheckTimeout bounds each request made by ELB
+	// health checks, since they run while reconciling the group.
+	autoScalingELBHealthCheckTimeout = 2 * time.Second
+)
+
+var autoScalingELBHealthCheckClient = &http.Client{
+	Timeout: autoScalingELBHealthCheckTimeout,
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// autoScalingGroupHealthCheck is the subset of the group configuration used
+// to decide whether its instances must be replaced.
+type autoScalingGroupHealthCheck struct {
+	Type            string
+	GracePeriod     time.Duration
+	TargetGroupARNs []string
+}
+
+// ELB reports whether instances must also pass the target group health
+// checks.
+func (h autoScalingGroupHealthCheck) ELB() bool {
+	return h.Type == autoScalingHealthCheckTypeELB && len(h.TargetGroupARNs) > 0
+}
+
+// autoScalingGroupHealthCheckConfig returns the group health check
+// configuration without loading the whole group.
+func (d *Dispatcher) autoScalingGroupHealthCheckConfig(autoScalingGroupName string) (autoScalingGroupHealthCheck, error) {
+	attrs, err := d.storage.ResourceAttributes(autoScalingGroupName)
+	if err != nil {
+		return autoScalingGroupHealthCheck{}, fmt.Errorf("retrieving auto scaling group attributes: %w", err)
+	}
+	seconds, err := strconv.Atoi(attrOrDefault(attrs, attributeNameAutoScalingGroupHealthCheckGracePeriod, "0"))
+	if err != nil {
+		return autoScalingGroupHealthCheck{}, fmt.Errorf("invalid integer attribute %s: %w", attributeNameAutoScalingGroupHealthCheckGracePeriod, err)
+	}
+	targetGroupARNs, err := autoScalingGroupTargetGroupARNs(attrs)
+	if err != nil {
+		return autoScalingGroupHealthCheck{}, err
+	}
+	return autoScalingGroupHealthCheck{
+		Type:            attrOrDefault(attrs, attributeNameAutoScalingGroupHealthCheckType, autoScalingHealthCheckType),
+		GracePeriod:     time.Duration(seconds) * time.Second,
+		TargetGroupARNs: targetGroupARNs,
+	}, nil
+}
+
+func autoScalingGroupTargetGroupARNs(attrs storage.Attributes) ([]string, error) {
+	raw, _ := attrs.Key(attributeNameAutoScalingGroupTargetGroupARNs)
+	targetGroupARNs, err := unmarshalStringSlice(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auto scaling group target group ARNs: %w", err)
+	}
+	return targetGroupARNs, nil
+}
+
+func validateHealthCheckType(healthCheckType string) error {
+	switch healthCheckType {
+	case autoScalingHealthCheckType, autoScalingHealthCheckTypeELB:
+		return nil
+	}
+	return api.ValidationError("HealthCheckType", "HealthCheckType must be one of %s, %s", autoScalingHealthCheckType, autoScalingHealthCheckTypeELB)
+}
+
+// validateTargetGroupARNs checks the target groups of a group. Since dc2 has
+// no load balancers, each target group is given as the URL of the health
+// check endpoint with an empty host (e.g. http://:8080/healthz), which is
+// requested against the private IP of every instance.
+func validateTargetGroupARNs(targetGroupARNs []string) error {
+	for i, targetGroupARN := range targetGroupARNs {
+		if _, err := parseAutoScalingHealthCheckEndpoint(targetGroupARN); err != nil {
+			return api.ValidationError(fmt.Sprintf("TargetGroupARNs.member.%d", i+1), "invalid target group %q: %v", targetGroupARN, err)
+		}
+	}
+	return nil
+}
+
+func parseAutoScalingHealthCheckEndpoint(targetGroupARN string) (*url.URL, error) {
+	u, err := url.Parse(targetGroupARN)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" {
+		return nil, fmt.Errorf("health check endpoint must be an http URL")
+	}
+	if u.Hostname() != "" {
+		return nil, fmt.Errorf("health check endpoint must not include a host")
+	}
+	if port := u.Port(); port != "" {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return nil, fmt.Errorf("invalid health check port %q", port)
+		}
+	}
+	return u, nil
+}
+
+// autoScalingInstanceFailsELBHealthCheck requests every health check endpoint
+// against privateIP, returning a description of the first failure. Like the
+// default target group matcher, only 200 responses are healthy.
+func autoScalingInstanceFailsELBHealthCheck(ctx context.Context, privateIP string, targetGroupARNs []string) (string, bool) {
+	if privateIP == "" {
+		return "no private IP", true
+	}
+	for _, targetGroupARN := range targetGroupARNs {
+		endpoint, err := parseAutoScalingHealthCheckEndpoint(targetGroupARN)
+		if err != nil {
+			return err.Error(), true
+		}
+		target := *endpoint
+		target.Host = privateIP
+		if port := endpoint.Port(); port != "" {
+			target.Host = net.JoinHostPort(privateIP, port)
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+		if err != nil {
+			return err.Error(), true
+		}
+		resp, err := autoScalingELBHealthCheckClient.Do(req)
+		if err != nil {
+			return err.Error(), true
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Sprintf("%s returned %d", target.String(), resp.StatusCode), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,202 @@
+package dc2
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/executor"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+// healthCheckExecutor records launch times from clock and reports every
+// running instance with privateIP, optionally failing its health check.
+type healthCheckExecutor struct {
+	*scalingExecutor
+	clock       func() time.Time
+	launchTimes map[executor.InstanceID]time.Time
+	unhealthy   bool
+	privateIP   string
+}
+
+func newHealthCheckExecutor(clock func() time.Time) *healthCheckExecutor {
+	return &healthCheckExecutor{
+		scalingExecutor: newScalingExecutor(),
+		clock:           clock,
+		launchTimes:     make(map[executor.InstanceID]time.Time),
+	}
+}
+
+func (e *healthCheckExecutor) CreateInstances(ctx context.Context, req executor.CreateInstancesRequest) ([]executor.InstanceID, error) {
+	ids, err := e.scalingExecutor.CreateInstances(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, id := range ids {
+		e.launchTimes[id] = e.clock()
+	}
+	return ids, nil
+}
+
+func (e *healthCheckExecutor) DescribeInstances(ctx context.Context, req executor.DescribeInstancesRequest) ([]executor.InstanceDescription, error) {
+	descs, err := e.scalingExecutor.DescribeInstances(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i := range descs {
+		descs[i].LaunchTime = e.launchTimes[descs[i].InstanceID]
+		descs[i].PrivateIP = e.privateIP
+		if e.unhealthy && descs[i].InstanceState == api.InstanceStateRunning {
+			descs[i].HealthStatus = executor.InstanceHealthStatusUnhealthy
+		}
+	}
+	return descs, nil
+}
+
+func TestAutoScalingGroupHonorsHealthCheckGracePeriod(t *testing.T) {
+	t.Parallel()
+
+	const groupName = "asg"
+	ctx := t.Context()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	exe := newHealthCheckExecutor(clock)
+	exe.unhealthy = true
+	d := &Dispatcher{
+		exe:     exe,
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+		clock:   clock,
+	}
+	require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeAutoScalingGroup, ID: groupName}))
+	require.NoError(t, d.saveAutoScalingGroupData(&autoScalingGroupData{
+		Name:                       groupName,
+		CreatedTime:                now,
+		MaxSize:                    1,
+		HealthCheckGracePeriod:     10,
+		LaunchTemplateID:           "lt-1",
+		LaunchTemplateName:         "lt",
+		LaunchTemplateVersion:      "1",
+		LaunchTemplateImageID:      "nginx",
+		LaunchTemplateInstanceType: "my-type",
+	}))
+
+	_, err := d.Dispatch(ctx, &api.SetDesiredCapacityRequest{AutoScalingGroupName: groupName, DesiredCapacity: new(1)})
+	require.NoError(t, err)
+	instanceIDs, err := d.autoScalingGroupManagedInstanceIDs(ctx, groupName)
+	require.NoError(t, err)
+	require.Len(t, instanceIDs, 1)
+	launchedID := instanceIDs[0]
+
+	reconcile := func() []string {
+		t.Helper()
+		group, err := d.loadAutoScalingGroupData(ctx, groupName)
+		require.NoError(t, err)
+		require.NoError(t, d.reconcileAutoScalingGroup(ctx, group))
+		instanceIDs, err := d.autoScalingGroupManagedInstanceIDs(ctx, groupName)
+		require.NoError(t, err)
+		return instanceIDs
+	}
+
+	// Within the grace period the failing instance is kept
+	now = now.Add(5 * time.Second)
+	assert.Equal(t, []string{launchedID}, reconcile())
+
+	// Once the grace period passes, it gets replaced
+	now = now.Add(10 * time.Second)
+	instanceIDs = reconcile()
+	require.Len(t, instanceIDs, 1)
+	assert.NotEqual(t, launchedID, instanceIDs[0])
+}
+
+func TestAutoScalingGroupELBHealthCheckReplacesFailingInstances(t *testing.T) {
+	t.Parallel()
+
+	const groupName = "asg"
+	ctx := t.Context()
+	var healthy atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" || !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+	srvURL, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	exe := newHealthCheckExecutor(time.Now)
+	exe.privateIP = srvURL.Hostname()
+	d := &Dispatcher{
+		exe:     exe,
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+
+	targetGroupARN := "http://:" + srvURL.Port() + "/healthz"
+	require.NoError(t, validateTargetGroupARNs([]string{targetGroupARN}))
+	require.Error(t, validateTargetGroupARNs([]string{"arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/tg/0123456789abcdef"}))
+	require.Error(t, validateHealthCheckType("HTTP"))
+
+	require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeAutoScalingGroup, ID: groupName}))
+	require.NoError(t, d.saveAutoScalingGroupData(&autoScalingGroupData{
+		Name:                       groupName,
+		CreatedTime:                time.Now(),
+		MaxSize:                    1,
+		HealthCheckType:            autoScalingHealthCheckTypeELB,
+		TargetGroupARNs:            []string{targetGroupARN},
+		LaunchTemplateID:           "lt-1",
+		LaunchTemplateName:         "lt",
+		LaunchTemplateVersion:      "1",
+		LaunchTemplateImageID:      "nginx",
+		LaunchTemplateInstanceType: "my-type",
+	}))
+	healthy.Store(true)
+	_, err = d.Dispatch(ctx, &api.SetDesiredCapacityRequest{AutoScalingGroupName: groupName, DesiredCapacity: new(1)})
+	require.NoError(t, err)
+	launchedIDs, err := d.autoScalingGroupManagedInstanceIDs(ctx, groupName)
+	require.NoError(t, err)
+	require.Len(t, launchedIDs, 1)
+
+	reconcile := func() []string {
+		t.Helper()
+		group, err := d.loadAutoScalingGroupData(ctx, groupName)
+		require.NoError(t, err)
+		require.NoError(t, d.reconcileAutoScalingGroup(ctx, group))
+		instanceIDs, err := d.autoScalingGroupInstanceIDsReadOnly(ctx, groupName)
+		require.NoError(t, err)
+		return instanceIDs
+	}
+
+	assert.Equal(t, launchedIDs, reconcile())
+
+	// The endpoint starts returning 500, so the instance is replaced
+	healthy.Store(false)
+	instanceIDs := reconcile()
+	require.Len(t, instanceIDs, 1)
+	assert.NotEqual(t, launchedIDs[0], instanceIDs[0])
+
+	activitiesResp, err := d.dispatchDescribeScalingActivities(ctx, &api.DescribeScalingActivitiesRequest{
+		AutoScalingGroupName: new(groupName),
+	})
+	require.NoError(t, err)
+	assert.True(t, slices.ContainsFunc(activitiesResp.DescribeScalingActivitiesResult.Activities, func(activity api.AutoScalingActivity) bool {
+		return strings.Contains(*activity.Cause, "ELB")
+	}))
+}
@@ -0,0 +1,119 @@
+package dc2
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/executor"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+func (d *Dispatcher) dispatchDescribeAutoScalingInstances(
+	ctx context.Context,
+	req *api.DescribeAutoScalingInstancesRequest,
+) (*api.DescribeAutoScalingInstancesResponse, error) {
+	resources, err := d.storage.RegisteredResources(types.ResourceTypeInstance)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving registered instances: %w", err)
+	}
+	attrsByID := make(map[string]storage.Attributes)
+	instanceIDs := make([]string, 0, len(resources))
+	for _, r := range resources {
+		if len(req.InstanceIDs) > 0 && !slices.Contains(req.InstanceIDs, r.ID) {
+			continue
+		}
+		attrs, err := d.storage.ResourceAttributes(r.ID)
+		if err != nil {
+			return nil, fmt.Errorf("retrieving instance attributes: %w", err)
+		}
+		if groupName, _ := attrs.Key(attributeNameAutoScalingGroupName); groupName == "" {
+			continue
+		}
+		attrsByID[r.ID] = attrs
+		instanceIDs = append(instanceIDs, r.ID)
+	}
+	slices.Sort(instanceIDs)
+
+	descriptionsByID := make(map[string]executor.InstanceDescription, len(instanceIDs))
+	if len(instanceIDs) > 0 {
+		descriptions, err := d.exe.DescribeInstances(ctx, executor.DescribeInstancesRequest{
+			InstanceIDs: executorInstanceIDs(instanceIDs),
+		})
+		if err != nil {
+			return nil, executorError(err)
+		}
+		for _, desc := range descriptions {
+			descriptionsByID[apiInstanceID(desc.InstanceID)] = desc
+		}
+	}
+
+	groups := make(map[string]*autoScalingGroupData)
+	instances := make([]api.AutoScalingInstanceDetails, 0, len(instanceIDs))
+	for _, instanceID := range instanceIDs {
+		desc, ok := descriptionsByID[instanceID]
+		if !ok || desc.InstanceState == api.InstanceStateTerminated {
+			continue
+		}
+		attrs := attrsByID[instanceID]
+		groupName, _ := attrs.Key(attributeNameAutoScalingGroupName)
+		group, ok := groups[groupName]
+		if !ok {
+			group, err = d.loadAutoScalingGroupData(ctx, groupName)
+			if err != nil {
+				return nil, err
+			}
+			groups[groupName] = group
+		}
+		instances = append(instances, d.apiAutoScalingInstanceDetails(group, instanceID, attrs, desc))
+	}
+
+	instances, nextToken, err := applyNextToken(instances, req.NextToken, req.MaxRecords)
+	if err != nil {
+		return nil, err
+	}
+	return &api.DescribeAutoScalingInstancesResponse{
+		DescribeAutoScalingInstancesResult: api.DescribeAutoScalingInstancesResult{
+			AutoScalingInstances: instances,
+			NextToken:            nextToken,
+		},
+	}, nil
+}
+
+func (d *Dispatcher) apiAutoScalingInstanceDetails(
+	group *autoScalingGroupData,
+	instanceID string,
+	attrs storage.Attributes,
+	desc executor.InstanceDescription,
+) api.AutoScalingInstanceDetails {
+	availabilityZone, _ := attrs.Key(attributeNameAvailabilityZone)
+	if availabilityZone == "" {
+		availabilityZone = defaultAvailabilityZone(d.opts.Region)
+	}
+	instanceType, _ := attrs.Key(attributeNameAutoScalingGroupInstanceType)
+	instanceType = cmp.Or(instanceType, desc.InstanceType, group.LaunchTemplateInstanceType)
+	// Unlike DescribeAutoScalingGroups, this API reports health in uppercase
+	healthStatus := strings.ToUpper(cmp.Or(autoScalingInstanceHealthStatusOverride(attrs), autoScalingHealthStatus))
+	lifecycleState := autoScalingInstanceLifecycleState(attrs)
+	if autoScalingInstanceIsWarm(attrs) {
+		lifecycleState = autoScalingWarmPoolLifecycleState(desc.InstanceState.Name, group.WarmPoolState)
+	}
+	return api.AutoScalingInstanceDetails{
+		AutoScalingGroupName: new(group.Name),
+		AvailabilityZone:     &availabilityZone,
+		HealthStatus:         &healthStatus,
+		InstanceID:           new(instanceID),
+		InstanceType:         &instanceType,
+		LaunchTemplate: &api.AutoScalingLaunchTemplateSpecification{
+			LaunchTemplateID:   new(group.LaunchTemplateID),
+			LaunchTemplateName: new(group.LaunchTemplateName),
+			Version:            new(autoScalingInstanceLaunchTemplateVersion(attrs, group)),
+		},
+		LifecycleState:       &lifecycleState,
+		ProtectedFromScaleIn: new(autoScalingInstanceIsProtectedFromScaleIn(attrs)),
+	}
+}
@@ -0,0 +1,78 @@
+package dc2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+func TestDescribeAutoScalingInstances(t *testing.T) {
+	t.Parallel()
+
+	const groupName = "asg"
+	ctx := t.Context()
+	d := &Dispatcher{
+		exe:     newScalingExecutor(),
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+	require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeAutoScalingGroup, ID: groupName}))
+	require.NoError(t, d.saveAutoScalingGroupData(&autoScalingGroupData{
+		Name:                       groupName,
+		CreatedTime:                time.Now(),
+		MaxSize:                    3,
+		LaunchTemplateID:           "lt-1",
+		LaunchTemplateName:         "lt",
+		LaunchTemplateVersion:      "1",
+		LaunchTemplateImageID:      "nginx",
+		LaunchTemplateInstanceType: "my-type",
+	}))
+
+	_, err := d.Dispatch(ctx, &api.SetDesiredCapacityRequest{AutoScalingGroupName: groupName, DesiredCapacity: new(2)})
+	require.NoError(t, err)
+	_, err = d.Dispatch(ctx, &api.PutWarmPoolRequest{AutoScalingGroupName: groupName})
+	require.NoError(t, err)
+
+	resp, err := d.dispatchDescribeAutoScalingInstances(ctx, &api.DescribeAutoScalingInstancesRequest{})
+	require.NoError(t, err)
+	instances := resp.DescribeAutoScalingInstancesResult.AutoScalingInstances
+	require.Len(t, instances, 3)
+	lifecycleStates := make(map[string]int)
+	for _, instance := range instances {
+		assert.Equal(t, groupName, *instance.AutoScalingGroupName)
+		assert.Equal(t, "HEALTHY", *instance.HealthStatus)
+		assert.Equal(t, "my-type", *instance.InstanceType)
+		assert.Equal(t, "lt-1", *instance.LaunchTemplate.LaunchTemplateID)
+		lifecycleStates[*instance.LifecycleState]++
+	}
+	assert.Equal(t, map[string]int{
+		autoScalingLifecycleState:            2,
+		autoScalingWarmLifecycleStateStopped: 1,
+	}, lifecycleStates)
+
+	// Filtering by instance ID and pagination
+	byID, err := d.dispatchDescribeAutoScalingInstances(ctx, &api.DescribeAutoScalingInstancesRequest{
+		InstanceIDs: []string{*instances[1].InstanceID, "i-00000000000000000"},
+	})
+	require.NoError(t, err)
+	require.Len(t, byID.DescribeAutoScalingInstancesResult.AutoScalingInstances, 1)
+	assert.Equal(t, *instances[1].InstanceID, *byID.DescribeAutoScalingInstancesResult.AutoScalingInstances[0].InstanceID)
+
+	page, err := d.dispatchDescribeAutoScalingInstances(ctx, &api.DescribeAutoScalingInstancesRequest{MaxRecords: new(2)})
+	require.NoError(t, err)
+	require.Len(t, page.DescribeAutoScalingInstancesResult.AutoScalingInstances, 2)
+	require.NotNil(t, page.DescribeAutoScalingInstancesResult.NextToken)
+	page, err = d.dispatchDescribeAutoScalingInstances(ctx, &api.DescribeAutoScalingInstancesRequest{
+		MaxRecords: new(2),
+		NextToken:  page.DescribeAutoScalingInstancesResult.NextToken,
+	})
+	require.NoError(t, err)
+	require.Len(t, page.DescribeAutoScalingInstancesResult.AutoScalingInstances, 1)
+	assert.Nil(t, page.DescribeAutoScalingInstancesResult.NextToken)
+}
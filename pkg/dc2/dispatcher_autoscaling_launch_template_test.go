@@ -0,0 +1,173 @@
+package dc2
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+)
+
+func TestAutoScalingGroupInstancesCarryLaunchTemplateData(t *testing.T) {
+	t.Parallel()
+
+	const groupName = "asg"
+	ctx := t.Context()
+	d := &Dispatcher{
+		exe:     newScalingExecutor(),
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+		opts:    DispatcherOptions{Region: "us-east-1"},
+	}
+
+	createResp, err := d.dispatchCreateLaunchTemplate(ctx, &api.CreateLaunchTemplateRequest{
+		LaunchTemplateName: "lt",
+		LaunchTemplateData: api.LaunchTemplateData{
+			ImageID:            "nginx",
+			InstanceType:       "t3.micro",
+			KeyName:            "my-key",
+			SecurityGroupIDs:   []string{"sg-0123456789abcdef0"},
+			IamInstanceProfile: &api.IamInstanceProfileSpecification{Name: "my-profile"},
+			Placement:          &api.LaunchTemplatePlacement{AvailabilityZone: "us-east-1b"},
+		},
+	})
+	require.NoError(t, err)
+	launchTemplateID := createResp.LaunchTemplate.LaunchTemplateID
+
+	versionsResp, err := d.dispatchDescribeLaunchTemplateVersions(ctx, &api.DescribeLaunchTemplateVersionsRequest{
+		LaunchTemplateID: launchTemplateID,
+	})
+	require.NoError(t, err)
+	require.Len(t, versionsResp.LaunchTemplateVersions, 1)
+	data := versionsResp.LaunchTemplateVersions[0].LaunchTemplateData
+	require.NotNil(t, data)
+	assert.Equal(t, "my-key", *data.KeyName)
+	assert.Equal(t, []string{"sg-0123456789abcdef0"}, data.SecurityGroupIDs)
+	assert.Equal(t, "my-profile", *data.IamInstanceProfile.Name)
+	assert.Equal(t, "us-east-1b", *data.Placement.AvailabilityZone)
+
+	_, err = d.Dispatch(ctx, &api.CreateAutoScalingGroupRequest{
+		AutoScalingGroupName: groupName,
+		MinSize:              new(2),
+		MaxSize:              new(2),
+		LaunchTemplate:       &api.AutoScalingLaunchTemplateSpecification{LaunchTemplateID: launchTemplateID},
+	})
+	require.NoError(t, err)
+
+	instanceIDs, err := d.autoScalingGroupManagedInstanceIDs(ctx, groupName)
+	require.NoError(t, err)
+	require.Len(t, instanceIDs, 2)
+	describeResp, err := d.dispatchDescribeInstances(ctx, &api.DescribeInstancesRequest{InstanceIDs: instanceIDs})
+	require.NoError(t, err)
+	require.Len(t, describeResp.ReservationSet, 1)
+	require.Len(t, describeResp.ReservationSet[0].InstancesSet, 2)
+	for _, instance := range describeResp.ReservationSet[0].InstancesSet {
+		assert.Equal(t, "my-key", instance.KeyName)
+		require.Len(t, instance.SecurityGroups, 1)
+		assert.Equal(t, "sg-0123456789abcdef0", instance.SecurityGroups[0].GroupID)
+		require.NotNil(t, instance.IamInstanceProfile)
+		assert.Equal(t, "arn:aws:iam::"+defaultSecurityGroupOwnerID+":instance-profile/my-profile", instance.IamInstanceProfile.Arn)
+		assert.Equal(t, "us-east-1b", instance.Placement.AvailabilityZone)
+	}
+}
+
+func TestAutoScalingGroupLaunchTemplateVersionAlias(t *testing.T) {
+	t.Parallel()
+
+	const groupName = "asg"
+	ctx := t.Context()
+	d := &Dispatcher{
+		exe:     newScalingExecutor(),
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+		opts:    DispatcherOptions{Region: "us-east-1"},
+	}
+	createResp, err := d.dispatchCreateLaunchTemplate(ctx, &api.CreateLaunchTemplateRequest{
+		LaunchTemplateName: "lt",
+		LaunchTemplateData: api.LaunchTemplateData{ImageID: "nginx", InstanceType: "t3.micro"},
+	})
+	require.NoError(t, err)
+	launchTemplateID := createResp.LaunchTemplate.LaunchTemplateID
+
+	_, err = d.Dispatch(ctx, &api.CreateAutoScalingGroupRequest{
+		AutoScalingGroupName: groupName,
+		MinSize:              new(0),
+		MaxSize:              new(3),
+		DesiredCapacity:      new(1),
+		LaunchTemplate: &api.AutoScalingLaunchTemplateSpecification{
+			LaunchTemplateID: launchTemplateID,
+			Version:          new("$Latest"),
+		},
+	})
+	require.NoError(t, err)
+	initialIDs, err := d.autoScalingGroupManagedInstanceIDs(ctx, groupName)
+	require.NoError(t, err)
+	require.Len(t, initialIDs, 1)
+
+	_, err = d.dispatchCreateLaunchTemplateVersion(ctx, &api.CreateLaunchTemplateVersionRequest{
+		LaunchTemplateID:   launchTemplateID,
+		LaunchTemplateData: api.LaunchTemplateData{ImageID: "nginx", InstanceType: "t3.small"},
+	})
+	require.NoError(t, err)
+	_, err = d.Dispatch(ctx, &api.SetDesiredCapacityRequest{AutoScalingGroupName: groupName, DesiredCapacity: new(2)})
+	require.NoError(t, err)
+
+	instanceIDs, err := d.autoScalingGroupManagedInstanceIDs(ctx, groupName)
+	require.NoError(t, err)
+	require.Len(t, instanceIDs, 2)
+	describeResp, err := d.dispatchDescribeInstances(ctx, &api.DescribeInstancesRequest{InstanceIDs: instanceIDs})
+	require.NoError(t, err)
+	versions := map[string]string{}
+	for _, reservation := range describeResp.ReservationSet {
+		for _, instance := range reservation.InstancesSet {
+			for _, tag := range instance.TagSet {
+				if tag.Key == launchTemplateTagKeyVersion {
+					versions[instance.InstanceID] = tag.Value
+				}
+			}
+		}
+	}
+	newID := instanceIDs[0]
+	if newID == initialIDs[0] {
+		newID = instanceIDs[1]
+	}
+	assert.Equal(t, map[string]string{initialIDs[0]: "1", newID: "2"}, versions)
+
+	groupsResp, err := d.Dispatch(ctx, &api.DescribeAutoScalingGroupsRequest{AutoScalingGroupNames: []string{groupName}})
+	require.NoError(t, err)
+	groups := groupsResp.(*api.DescribeAutoScalingGroupsResponse).DescribeAutoScalingGroupsResult.AutoScalingGroups
+	require.Len(t, groups, 1)
+	assert.Equal(t, "$Latest", *groups[0].LaunchTemplate.Version)
+	instanceVersions := map[string]string{}
+	instanceTypes := map[string]string{}
+	for _, instance := range groups[0].Instances {
+		instanceVersions[*instance.InstanceID] = *instance.LaunchTemplate.Version
+		instanceTypes[*instance.InstanceID] = *instance.InstanceType
+	}
+	assert.Equal(t, versions, instanceVersions)
+	assert.Equal(t, map[string]string{initialIDs[0]: "t3.micro", newID: "t3.small"}, instanceTypes)
+
+	// Numeric versions are kept after the template changes
+	_, err = d.Dispatch(ctx, &api.UpdateAutoScalingGroupRequest{
+		AutoScalingGroupName: groupName,
+		LaunchTemplate: &api.AutoScalingLaunchTemplateSpecification{
+			LaunchTemplateID: launchTemplateID,
+			Version:          new("2"),
+		},
+	})
+	require.NoError(t, err)
+	_, err = d.dispatchCreateLaunchTemplateVersion(ctx, &api.CreateLaunchTemplateVersionRequest{
+		LaunchTemplateID:   launchTemplateID,
+		LaunchTemplateData: api.LaunchTemplateData{ImageID: "nginx", InstanceType: "t3.large"},
+	})
+	require.NoError(t, err)
+	_, err = d.Dispatch(ctx, &api.SetDesiredCapacityRequest{AutoScalingGroupName: groupName, DesiredCapacity: new(3)})
+	require.NoError(t, err)
+	group, err := d.loadAutoScalingGroupData(ctx, groupName)
+	require.NoError(t, err)
+	assert.Equal(t, "2", group.LaunchTemplateVersion)
+	assert.Empty(t, group.LaunchTemplateVersionAlias)
+	assert.Equal(t, "t3.small", group.LaunchTemplateInstanceType)
+}
@@ -0,0 +1,564 @@
+package dc2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+const (
+	attributeNameAutoScalingLifecycleHookPrefix           = "AutoScalingLifecycleHook:"
+	attributeNameAutoScalingInstanceLifecycleActionPrefix = "AutoScalingInstanceLifecycleAction:"
+
+	lifecycleTransitionInstanceLaunching     = "autoscaling:EC2_INSTANCE_LAUNCHING"
+	lifecycleTransitionInstanceTerminating   = "autoscaling:EC2_INSTANCE_TERMINATING"
+	lifecycleActionResultContinue            = "CONTINUE"
+	lifecycleActionResultAbandon             = "ABANDON"
+	lifecycleHookDefaultHeartbeatTimeout     = 3600
+	lifecycleHookMinHeartbeatTimeout         = 30
+	lifecycleHookMaxHeartbeatTimeout         = 7200
+	lifecycleHookMaxGlobalTimeout            = 172800
+	lifecycleHookGlobalTimeoutMultiplier     = 100
+	autoScalingLifecycleStatePendingWait     = "Pending:Wait"
+	autoScalingLifecycleStateTerminatingWait = "Terminating:Wait"
+)
+
+// autoScalingLifecycleHook is a lifecycle hook definition, stored as JSON in
+// an auto scaling group attribute keyed by the hook name.
+type autoScalingLifecycleHook struct {
+	Name                  string  `json:"-"`
+	LifecycleTransition   string  `json:"lifecycleTransition"`
+	DefaultResult         string  `json:"defaultResult"`
+	HeartbeatTimeout      int     `json:"heartbeatTimeout"`
+	NotificationMetadata  *string `json:"notificationMetadata,omitempty"`
+	NotificationTargetARN *string `json:"notificationTargetARN,omitempty"`
+	RoleARN               *string `json:"roleARN,omitempty"`
+}
+
+// autoScalingLifecycleAction is a lifecycle action waiting for completion,
+// stored as JSON in an instance attribute keyed by the hook name.
+type autoScalingLifecycleAction struct {
+	HookName            string    `json:"-"`
+	Token               string    `json:"token"`
+	LifecycleTransition string    `json:"lifecycleTransition"`
+	DefaultResult       string    `json:"defaultResult"`
+	HeartbeatTimeout    int       `json:"heartbeatTimeout"`
+	Deadline            time.Time `json:"deadline"`
+	GlobalDeadline      time.Time `json:"globalDeadline"`
+}
+
+func (d *Dispatcher) dispatchPutLifecycleHook(ctx context.Context, req *api.PutLifecycleHookRequest) (*api.PutLifecycleHookResponse, error) {
+	group, err := d.loadAutoScalingGroupData(ctx, req.AutoScalingGroupName)
+	if err != nil {
+		return nil, err
+	}
+	hooks, err := d.autoScalingLifecycleHooks(group.Name)
+	if err != nil {
+		return nil, err
+	}
+	hook := autoScalingLifecycleHook{
+		Name:             req.LifecycleHookName,
+		DefaultResult:    lifecycleActionResultAbandon,
+		HeartbeatTimeout: lifecycleHookDefaultHeartbeatTimeout,
+	}
+	if idx := slices.IndexFunc(hooks, func(h autoScalingLifecycleHook) bool { return h.Name == req.LifecycleHookName }); idx >= 0 {
+		hook = hooks[idx]
+	}
+	if req.LifecycleTransition != nil {
+		hook.LifecycleTransition = *req.LifecycleTransition
+	}
+	switch hook.LifecycleTransition {
+	case lifecycleTransitionInstanceLaunching, lifecycleTransitionInstanceTerminating:
+	case "":
+		return nil, api.ValidationError("LifecycleTransition", "LifecycleTransition is required when creating a lifecycle hook")
+	default:
+		return nil, api.InvalidParameterValueError("LifecycleTransition", hook.LifecycleTransition)
+	}
+	if req.DefaultResult != nil {
+		result, err := parseLifecycleActionResult("DefaultResult", *req.DefaultResult)
+		if err != nil {
+			return nil, err
+		}
+		hook.DefaultResult = result
+	}
+	if req.HeartbeatTimeout != nil {
+		if *req.HeartbeatTimeout < lifecycleHookMinHeartbeatTimeout || *req.HeartbeatTimeout > lifecycleHookMaxHeartbeatTimeout {
+			return nil, api.ValidationError(
+				"HeartbeatTimeout",
+				"HeartbeatTimeout must be between %d and %d",
+				lifecycleHookMinHeartbeatTimeout,
+				lifecycleHookMaxHeartbeatTimeout,
+			)
+		}
+		hook.HeartbeatTimeout = *req.HeartbeatTimeout
+	}
+	if req.NotificationMetadata != nil {
+		hook.NotificationMetadata = req.NotificationMetadata
+	}
+	if req.NotificationTargetARN != nil {
+		hook.NotificationTargetARN = req.NotificationTargetARN
+	}
+	if req.RoleARN != nil {
+		hook.RoleARN = req.RoleARN
+	}
+
+	raw, err := json.Marshal(hook)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling lifecycle hook: %w", err)
+	}
+	if err := d.storage.SetResourceAttributes(group.Name, []storage.Attribute{
+		{Key: attributeNameAutoScalingLifecycleHookPrefix + hook.Name, Value: string(raw)},
+	}); err != nil {
+		return nil, fmt.Errorf("saving lifecycle hook: %w", err)
+	}
+	return &api.PutLifecycleHookResponse{}, nil
+}
+
+func (d *Dispatcher) dispatchDescribeLifecycleHooks(ctx context.Context, req *api.DescribeLifecycleHooksRequest) (*api.DescribeLifecycleHooksResponse, error) {
+	group, err := d.loadAutoScalingGroupData(ctx, req.AutoScalingGroupName)
+	if err != nil {
+		return nil, err
+	}
+	hooks, err := d.autoScalingLifecycleHooks(group.Name)
+	if err != nil {
+		return nil, err
+	}
+	lifecycleHooks := make([]api.LifecycleHook, 0, len(hooks))
+	for _, hook := range hooks {
+		if len(req.LifecycleHookNames) > 0 && !slices.Contains(req.LifecycleHookNames, hook.Name) {
+			continue
+		}
+		groupName := group.Name
+		hookName := hook.Name
+		transition := hook.LifecycleTransition
+		defaultResult := hook.DefaultResult
+		heartbeatTimeout := hook.HeartbeatTimeout
+		globalTimeout := lifecycleHookGlobalTimeout(hook.HeartbeatTimeout)
+		lifecycleHooks = append(lifecycleHooks, api.LifecycleHook{
+			AutoScalingGroupName:  &groupName,
+			DefaultResult:         &defaultResult,
+			GlobalTimeout:         &globalTimeout,
+			HeartbeatTimeout:      &heartbeatTimeout,
+			LifecycleHookName:     &hookName,
+			LifecycleTransition:   &transition,
+			NotificationMetadata:  hook.NotificationMetadata,
+			NotificationTargetARN: hook.NotificationTargetARN,
+			RoleARN:               hook.RoleARN,
+		})
+	}
+	return &api.DescribeLifecycleHooksResponse{
+		DescribeLifecycleHooksResult: api.DescribeLifecycleHooksResult{
+			LifecycleHooks: lifecycleHooks,
+		},
+	}, nil
+}
+
+func (d *Dispatcher) dispatchDescribeLifecycleHookTypes(_ context.Context, _ *api.DescribeLifecycleHookTypesRequest) (*api.DescribeLifecycleHookTypesResponse, error) {
+	return &api.DescribeLifecycleHookTypesResponse{
+		DescribeLifecycleHookTypesResult: api.DescribeLifecycleHookTypesResult{
+			LifecycleHookTypes: []string{
+				lifecycleTransitionInstanceLaunching,
+				lifecycleTransitionInstanceTerminating,
+			},
+		},
+	}, nil
+}
+
+func (d *Dispatcher) dispatchDeleteLifecycleHook(ctx context.Context, req *api.DeleteLifecycleHookRequest) (*api.DeleteLifecycleHookResponse, error) {
+	group, err := d.loadAutoScalingGroupData(ctx, req.AutoScalingGroupName)
+	if err != nil {
+		return nil, err
+	}
+	hooks, err := d.autoScalingLifecycleHooks(group.Name)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.ContainsFunc(hooks, func(h autoScalingLifecycleHook) bool { return h.Name == req.LifecycleHookName }) {
+		return nil, api.ErrWithCode("ValidationError", fmt.Errorf(
+			"no lifecycle hook found with name %q for group %q",
+			req.LifecycleHookName,
+			group.Name,
+		))
+	}
+
+	// Like AWS, outstanding actions for the hook are completed first:
+	// launching instances are abandoned and terminating ones continue.
+	actionsByInstance, err := d.autoScalingGroupLifecycleActions(group.Name)
+	if err != nil {
+		return nil, err
+	}
+	for _, instanceID := range slices.Sorted(maps.Keys(actionsByInstance)) {
+		for _, action := range actionsByInstance[instanceID] {
+			if action.HookName != req.LifecycleHookName {
+				continue
+			}
+			result := lifecycleActionResultContinue
+			if action.LifecycleTransition == lifecycleTransitionInstanceLaunching {
+				result = lifecycleActionResultAbandon
+			}
+			if err := d.completeAutoScalingLifecycleAction(ctx, instanceID, action, result); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := d.storage.RemoveResourceAttributes(group.Name, []storage.Attribute{
+		{Key: attributeNameAutoScalingLifecycleHookPrefix + req.LifecycleHookName},
+	}); err != nil {
+		return nil, fmt.Errorf("removing lifecycle hook: %w", err)
+	}
+	return &api.DeleteLifecycleHookResponse{}, nil
+}
+
+func (d *Dispatcher) dispatchCompleteLifecycleAction(ctx context.Context, req *api.CompleteLifecycleActionRequest) (*api.CompleteLifecycleActionResponse, error) {
+	result, err := parseLifecycleActionResult("LifecycleActionResult", req.LifecycleActionResult)
+	if err != nil {
+		return nil, err
+	}
+	// Completing an action can launch or terminate group instances
+	unlock, err := d.lockAutoScalingGroup(ctx, req.AutoScalingGroupName)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	instanceID, action, err := d.findAutoScalingLifecycleAction(ctx, req.AutoScalingGroupName, req.LifecycleHookName, req.InstanceID, req.LifecycleActionToken)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.completeAutoScalingLifecycleAction(ctx, instanceID, action, result); err != nil {
+		return nil, err
+	}
+	return &api.CompleteLifecycleActionResponse{}, nil
+}
+
+func (d *Dispatcher) dispatchRecordLifecycleActionHeartbeat(ctx context.Context, req *api.RecordLifecycleActionHeartbeatRequest) (*api.RecordLifecycleActionHeartbeatResponse, error) {
+	instanceID, action, err := d.findAutoScalingLifecycleAction(ctx, req.AutoScalingGroupName, req.LifecycleHookName, req.InstanceID, req.LifecycleActionToken)
+	if err != nil {
+		return nil, err
+	}
+	deadline := d.now().Add(time.Duration(action.HeartbeatTimeout) * time.Second)
+	if deadline.After(action.GlobalDeadline) {
+		deadline = action.GlobalDeadline
+	}
+	action.Deadline = deadline
+	if err := d.saveAutoScalingLifecycleAction(instanceID, action); err != nil {
+		return nil, err
+	}
+	return &api.RecordLifecycleActionHeartbeatResponse{}, nil
+}
+
+// startAutoScalingLifecycleActions puts the given instances on hold for every
+// hook in the group matching transition, replacing any actions they were
+// already waiting on. It reports whether any hook matched.
+func (d *Dispatcher) startAutoScalingLifecycleActions(ctx context.Context, groupName string, instanceIDs []string, transition string) (bool, error) {
+	if len(instanceIDs) == 0 {
+		return false, nil
+	}
+	hooks, err := d.autoScalingLifecycleHooks(groupName)
+	if err != nil {
+		return false, err
+	}
+	hooks = slices.DeleteFunc(hooks, func(h autoScalingLifecycleHook) bool { return h.LifecycleTransition != transition })
+	if len(hooks) == 0 {
+		return false, nil
+	}
+	now := d.now()
+	for _, instanceID := range instanceIDs {
+		if err := d.clearAutoScalingLifecycleActions(instanceID); err != nil {
+			return false, err
+		}
+		for _, hook := range hooks {
+			action := autoScalingLifecycleAction{
+				HookName:            hook.Name,
+				Token:               uuid.New().String(),
+				LifecycleTransition: transition,
+				DefaultResult:       hook.DefaultResult,
+				HeartbeatTimeout:    hook.HeartbeatTimeout,
+				Deadline:            now.Add(time.Duration(hook.HeartbeatTimeout) * time.Second),
+				GlobalDeadline:      now.Add(time.Duration(lifecycleHookGlobalTimeout(hook.HeartbeatTimeout)) * time.Second),
+			}
+			if err := d.saveAutoScalingLifecycleAction(instanceID, action); err != nil {
+				return false, err
+			}
+		}
+	}
+	api.Logger(ctx).Info(
+		"auto scaling instances waiting on lifecycle hooks",
+		slog.String("auto_scaling_group_name", groupName),
+		slog.String("lifecycle_transition", transition),
+		slog.Any("instance_ids", instanceIDs),
+	)
+	return true, nil
+}
+
+// terminateAutoScalingInstancesWithLifecycleHooks terminates the given
+// instances, unless the group has terminating hooks. In that case, the
+// instances wait in Terminating:Wait and are terminated once their actions
+// complete.
+func (d *Dispatcher) terminateAutoScalingInstancesWithLifecycleHooks(ctx context.Context, groupName string, instanceIDs []string, reason string) error {
+	waiting, err := d.startAutoScalingLifecycleActions(ctx, groupName, instanceIDs, lifecycleTransitionInstanceTerminating)
+	if err != nil {
+		return err
+	}
+	if waiting {
+		return nil
+	}
+	return d.terminateAutoScalingInstancesWithReason(ctx, instanceIDs, reason)
+}
+
+// completeAutoScalingLifecycleAction finishes a pending action. Abandoning a
+// launch terminates the instance, and terminating instances are terminated
+// once their last action completes.
+func (d *Dispatcher) completeAutoScalingLifecycleAction(ctx context.Context, instanceID string, action autoScalingLifecycleAction, result string) error {
+	if err := d.storage.RemoveResourceAttributes(instanceID, []storage.Attribute{
+		{Key: attributeNameAutoScalingInstanceLifecycleActionPrefix + action.HookName},
+	}); err != nil {
+		return fmt.Errorf("removing lifecycle action for instance %s: %w", instanceID, err)
+	}
+	api.Logger(ctx).Info(
+		"completed auto scaling lifecycle action",
+		slog.String("instance_id", instanceID),
+		slog.String("lifecycle_hook_name", action.HookName),
+		slog.String("lifecycle_transition", action.LifecycleTransition),
+		slog.String("result", result),
+	)
+	if action.LifecycleTransition == lifecycleTransitionInstanceLaunching {
+		if result != lifecycleActionResultAbandon {
+			return nil
+		}
+		return d.terminateAutoScalingInstancesWithReason(ctx, []string{instanceID}, "lifecycle-action-abandoned")
+	}
+	attrs, err := d.storage.ResourceAttributes(instanceID)
+	if err != nil {
+		return fmt.Errorf("retrieving instance attributes: %w", err)
+	}
+	if len(autoScalingInstanceLifecycleActions(attrs)) > 0 {
+		return nil
+	}
+	return d.terminateAutoScalingInstancesWithReason(ctx, []string{instanceID}, autoScalingTerminationReasonScaleIn)
+}
+
+// expireAutoScalingLifecycleActions applies the default result to actions in
+// the group whose heartbeat timeout has elapsed.
+func (d *Dispatcher) expireAutoScalingLifecycleActions(ctx context.Context, groupName string) error {
+	actionsByInstance, err := d.autoScalingGroupLifecycleActions(groupName)
+	if err != nil {
+		return err
+	}
+	now := d.now()
+	for _, instanceID := range slices.Sorted(maps.Keys(actionsByInstance)) {
+		for _, action := range actionsByInstance[instanceID] {
+			if now.Before(action.Deadline) {
+				continue
+			}
+			api.Logger(ctx).Info(
+				"auto scaling lifecycle action timed out",
+				slog.String("instance_id", instanceID),
+				slog.String("lifecycle_hook_name", action.HookName),
+			)
+			if err := d.completeAutoScalingLifecycleAction(ctx, instanceID, action, action.DefaultResult); err != nil {
+				return err
+			}
+			if action.LifecycleTransition == lifecycleTransitionInstanceLaunching && action.DefaultResult == lifecycleActionResultAbandon {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func (d *Dispatcher) findAutoScalingLifecycleAction(
+	ctx context.Context,
+	groupName string,
+	hookName string,
+	instanceID *string,
+	token *string,
+) (string, autoScalingLifecycleAction, error) {
+	if _, err := d.loadAutoScalingGroupData(ctx, groupName); err != nil {
+		return "", autoScalingLifecycleAction{}, err
+	}
+	if instanceID == nil && token == nil {
+		return "", autoScalingLifecycleAction{}, api.ErrWithCode("ValidationError", fmt.Errorf("either InstanceId or LifecycleActionToken is required"))
+	}
+	hooks, err := d.autoScalingLifecycleHooks(groupName)
+	if err != nil {
+		return "", autoScalingLifecycleAction{}, err
+	}
+	if !slices.ContainsFunc(hooks, func(h autoScalingLifecycleHook) bool { return h.Name == hookName }) {
+		return "", autoScalingLifecycleAction{}, api.ErrWithCode("ValidationError", fmt.Errorf("no lifecycle hook found with name %s", hookName))
+	}
+	actionsByInstance, err := d.autoScalingGroupLifecycleActions(groupName)
+	if err != nil {
+		return "", autoScalingLifecycleAction{}, err
+	}
+	for id, actions := range actionsByInstance {
+		if instanceID != nil && *instanceID != id {
+			continue
+		}
+		for _, action := range actions {
+			if action.HookName != hookName {
+				continue
+			}
+			if token != nil && *token != action.Token {
+				continue
+			}
+			return id, action, nil
+		}
+	}
+	if instanceID != nil {
+		return "", autoScalingLifecycleAction{}, api.ErrWithCode("ValidationError", fmt.Errorf("no active lifecycle action found with instance ID %s", *instanceID))
+	}
+	return "", autoScalingLifecycleAction{}, api.ErrWithCode("ValidationError", fmt.Errorf("no active lifecycle action found with token %s", *token))
+}
+
+func (d *Dispatcher) autoScalingLifecycleHooks(groupName string) ([]autoScalingLifecycleHook, error) {
+	attrs, err := d.storage.ResourceAttributes(groupName)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving auto scaling group attributes: %w", err)
+	}
+	var hooks []autoScalingLifecycleHook
+	for _, attr := range attrs {
+		name, ok := strings.CutPrefix(attr.Key, attributeNameAutoScalingLifecycleHookPrefix)
+		if !ok {
+			continue
+		}
+		var hook autoScalingLifecycleHook
+		if err := json.Unmarshal([]byte(attr.Value), &hook); err != nil {
+			return nil, fmt.Errorf("invalid lifecycle hook %q: %w", name, err)
+		}
+		hook.Name = name
+		hooks = append(hooks, hook)
+	}
+	slices.SortFunc(hooks, func(a, b autoScalingLifecycleHook) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return hooks, nil
+}
+
+// autoScalingGroupLifecycleActions returns the pending lifecycle actions for
+// the instances in the group, keyed by instance ID.
+func (d *Dispatcher) autoScalingGroupLifecycleActions(groupName string) (map[string][]autoScalingLifecycleAction, error) {
+	instances, err := d.storage.RegisteredResources(types.ResourceTypeInstance)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving registered instances: %w", err)
+	}
+	actionsByInstance := make(map[string][]autoScalingLifecycleAction)
+	for _, instance := range instances {
+		attrs, err := d.storage.ResourceAttributes(instance.ID)
+		if err != nil {
+			if errors.As(err, &storage.ErrResourceNotFound{}) {
+				continue
+			}
+			return nil, fmt.Errorf("retrieving instance attributes: %w", err)
+		}
+		if name, _ := attrs.Key(attributeNameAutoScalingGroupName); name != groupName {
+			continue
+		}
+		if actions := autoScalingInstanceLifecycleActions(attrs); len(actions) > 0 {
+			actionsByInstance[instance.ID] = actions
+		}
+	}
+	return actionsByInstance, nil
+}
+
+func (d *Dispatcher) saveAutoScalingLifecycleAction(instanceID string, action autoScalingLifecycleAction) error {
+	raw, err := json.Marshal(action)
+	if err != nil {
+		return fmt.Errorf("marshaling lifecycle action: %w", err)
+	}
+	if err := d.storage.SetResourceAttributes(instanceID, []storage.Attribute{
+		{Key: attributeNameAutoScalingInstanceLifecycleActionPrefix + action.HookName, Value: string(raw)},
+	}); err != nil {
+		return fmt.Errorf("saving lifecycle action for instance %s: %w", instanceID, err)
+	}
+	return nil
+}
+
+func (d *Dispatcher) clearAutoScalingLifecycleActions(instanceID string) error {
+	attrs, err := d.storage.ResourceAttributes(instanceID)
+	if err != nil {
+		return fmt.Errorf("retrieving instance attributes: %w", err)
+	}
+	var remove []storage.Attribute
+	for _, attr := range attrs {
+		if strings.HasPrefix(attr.Key, attributeNameAutoScalingInstanceLifecycleActionPrefix) {
+			remove = append(remove, storage.Attribute{Key: attr.Key})
+		}
+	}
+	if len(remove) == 0 {
+		return nil
+	}
+	if err := d.storage.RemoveResourceAttributes(instanceID, remove); err != nil {
+		return fmt.Errorf("removing lifecycle actions for instance %s: %w", instanceID, err)
+	}
+	return nil
+}
+
+// autoScalingInstanceLifecycleActions returns the pending lifecycle actions
+// stored in the instance attributes, ignoring malformed entries.
+func autoScalingInstanceLifecycleActions(attrs storage.Attributes) []autoScalingLifecycleAction {
+	var actions []autoScalingLifecycleAction
+	for _, attr := range attrs {
+		hookName, ok := strings.CutPrefix(attr.Key, attributeNameAutoScalingInstanceLifecycleActionPrefix)
+		if !ok {
+			continue
+		}
+		var action autoScalingLifecycleAction
+		if err := json.Unmarshal([]byte(attr.Value), &action); err != nil {
+			continue
+		}
+		action.HookName = hookName
+		actions = append(actions, action)
+	}
+	slices.SortFunc(actions, func(a, b autoScalingLifecycleAction) int {
+		return strings.Compare(a.HookName, b.HookName)
+	})
+	return actions
+}
+
+// autoScalingInstanceLifecycleState returns the lifecycle state reported by
+// DescribeAutoScalingGroups for an in-group instance.
+func autoScalingInstanceLifecycleState(attrs storage.Attributes) string {
+	if autoScalingInstanceIsStandby(attrs) {
+		return autoScalingLifecycleStateStandby
+	}
+	if _, draining := autoScalingInstanceDrainDeadline(attrs); draining {
+		return autoScalingLifecycleStateTerminatingWait
+	}
+	for _, action := range autoScalingInstanceLifecycleActions(attrs) {
+		if action.LifecycleTransition == lifecycleTransitionInstanceTerminating {
+			return autoScalingLifecycleStateTerminatingWait
+		}
+		return autoScalingLifecycleStatePendingWait
+	}
+	return autoScalingLifecycleState
+}
+
+func autoScalingInstanceIsTerminating(attrs storage.Attributes) bool {
+	return autoScalingInstanceLifecycleState(attrs) == autoScalingLifecycleStateTerminatingWait
+}
+
+func parseLifecycleActionResult(param string, result string) (string, error) {
+	switch result {
+	case lifecycleActionResultContinue, lifecycleActionResultAbandon:
+		return result, nil
+	default:
+		return "", api.InvalidParameterValueError(param, result)
+	}
+}
+
+func lifecycleHookGlobalTimeout(heartbeatTimeout int) int {
+	return min(heartbeatTimeout*lifecycleHookGlobalTimeoutMultiplier, lifecycleHookMaxGlobalTimeout)
+}
@@ -0,0 +1,169 @@
+package dc2
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+func TestAutoScalingLifecycleActionsExpireWithDefaultResult(t *testing.T) {
+	t.Parallel()
+
+	const (
+		groupName  = "asg"
+		instanceID = "i-00000000000000001"
+	)
+	ctx := context.Background()
+	d := &Dispatcher{
+		exe:     &exitCleanupExecutor{},
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+	require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeAutoScalingGroup, ID: groupName}))
+	require.NoError(t, d.saveAutoScalingGroupData(&autoScalingGroupData{
+		Name:               groupName,
+		CreatedTime:        time.Now(),
+		LaunchTemplateID:   "lt-1",
+		LaunchTemplateName: "lt",
+	}))
+	require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeInstance, ID: instanceID}))
+	require.NoError(t, d.storage.SetResourceAttributes(instanceID, []storage.Attribute{
+		{Key: attributeNameAutoScalingGroupName, Value: groupName},
+	}))
+
+	_, err := d.dispatchPutLifecycleHook(ctx, &api.PutLifecycleHookRequest{
+		AutoScalingGroupName: groupName,
+		LifecycleHookName:    "launching",
+		LifecycleTransition:  new(lifecycleTransitionInstanceLaunching),
+		DefaultResult:        new(lifecycleActionResultContinue),
+		HeartbeatTimeout:     new(lifecycleHookMinHeartbeatTimeout),
+	})
+	require.NoError(t, err)
+	_, err = d.dispatchPutLifecycleHook(ctx, &api.PutLifecycleHookRequest{
+		AutoScalingGroupName: groupName,
+		LifecycleHookName:    "launching",
+		LifecycleTransition:  new("autoscaling:EC2_INSTANCE_REBOOTING"),
+	})
+	require.Error(t, err)
+
+	waiting, err := d.startAutoScalingLifecycleActions(ctx, groupName, []string{instanceID}, lifecycleTransitionInstanceLaunching)
+	require.NoError(t, err)
+	require.True(t, waiting)
+	attrs, err := d.storage.ResourceAttributes(instanceID)
+	require.NoError(t, err)
+	assert.Equal(t, autoScalingLifecycleStatePendingWait, autoScalingInstanceLifecycleState(attrs))
+
+	// Not expired yet, so the instance keeps waiting.
+	require.NoError(t, d.expireAutoScalingLifecycleActions(ctx, groupName))
+	attrs, err = d.storage.ResourceAttributes(instanceID)
+	require.NoError(t, err)
+	assert.Equal(t, autoScalingLifecycleStatePendingWait, autoScalingInstanceLifecycleState(attrs))
+
+	actions := autoScalingInstanceLifecycleActions(attrs)
+	require.Len(t, actions, 1)
+	expired := actions[0]
+	expired.Deadline = time.Now().Add(-time.Second)
+	require.NoError(t, d.saveAutoScalingLifecycleAction(instanceID, expired))
+	require.NoError(t, d.expireAutoScalingLifecycleActions(ctx, groupName))
+	attrs, err = d.storage.ResourceAttributes(instanceID)
+	require.NoError(t, err)
+	assert.Equal(t, autoScalingLifecycleState, autoScalingInstanceLifecycleState(attrs))
+
+	_, err = d.dispatchCompleteLifecycleAction(ctx, &api.CompleteLifecycleActionRequest{
+		AutoScalingGroupName:  groupName,
+		LifecycleHookName:     "launching",
+		LifecycleActionResult: lifecycleActionResultContinue,
+		LifecycleActionToken:  &expired.Token,
+	})
+	var apiErr *api.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "ValidationError", apiErr.Code)
+}
+
+func TestRecordLifecycleActionHeartbeatExtendsTimeout(t *testing.T) {
+	t.Parallel()
+
+	const (
+		groupName  = "asg"
+		hookName   = "launching"
+		instanceID = "i-00000000000000001"
+	)
+	ctx := context.Background()
+	now := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	d := &Dispatcher{
+		exe:     &exitCleanupExecutor{},
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+		clock:   func() time.Time { return now },
+	}
+	require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeAutoScalingGroup, ID: groupName}))
+	require.NoError(t, d.saveAutoScalingGroupData(&autoScalingGroupData{
+		Name:               groupName,
+		CreatedTime:        now,
+		LaunchTemplateID:   "lt-1",
+		LaunchTemplateName: "lt",
+	}))
+	require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeInstance, ID: instanceID}))
+	require.NoError(t, d.storage.SetResourceAttributes(instanceID, []storage.Attribute{
+		{Key: attributeNameAutoScalingGroupName, Value: groupName},
+	}))
+	_, err := d.dispatchPutLifecycleHook(ctx, &api.PutLifecycleHookRequest{
+		AutoScalingGroupName: groupName,
+		LifecycleHookName:    hookName,
+		LifecycleTransition:  new(lifecycleTransitionInstanceLaunching),
+		DefaultResult:        new(lifecycleActionResultAbandon),
+		HeartbeatTimeout:     new(60),
+	})
+	require.NoError(t, err)
+	waiting, err := d.startAutoScalingLifecycleActions(ctx, groupName, []string{instanceID}, lifecycleTransitionInstanceLaunching)
+	require.NoError(t, err)
+	require.True(t, waiting)
+	attrs, err := d.storage.ResourceAttributes(instanceID)
+	require.NoError(t, err)
+	actions := autoScalingInstanceLifecycleActions(attrs)
+	require.Len(t, actions, 1)
+	token := actions[0].Token
+
+	_, err = d.dispatchRecordLifecycleActionHeartbeat(ctx, &api.RecordLifecycleActionHeartbeatRequest{
+		AutoScalingGroupName: groupName,
+		LifecycleHookName:    "missing",
+		InstanceID:           new(instanceID),
+	})
+	var apiErr *api.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "ValidationError", apiErr.Code)
+	_, err = d.dispatchRecordLifecycleActionHeartbeat(ctx, &api.RecordLifecycleActionHeartbeatRequest{
+		AutoScalingGroupName: groupName,
+		LifecycleHookName:    hookName,
+		LifecycleActionToken: new("not-a-token"),
+	})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "ValidationError", apiErr.Code)
+
+	// A heartbeat 50s in restarts the 60s timeout, so the action is still
+	// pending past the original deadline.
+	now = now.Add(50 * time.Second)
+	_, err = d.dispatchRecordLifecycleActionHeartbeat(ctx, &api.RecordLifecycleActionHeartbeatRequest{
+		AutoScalingGroupName: groupName,
+		LifecycleHookName:    hookName,
+		LifecycleActionToken: &token,
+	})
+	require.NoError(t, err)
+	now = now.Add(30 * time.Second)
+	require.NoError(t, d.expireAutoScalingLifecycleActions(ctx, groupName))
+	attrs, err = d.storage.ResourceAttributes(instanceID)
+	require.NoError(t, err)
+	assert.Equal(t, autoScalingLifecycleStatePendingWait, autoScalingInstanceLifecycleState(attrs))
+
+	now = now.Add(30 * time.Second)
+	require.NoError(t, d.expireAutoScalingLifecycleActions(ctx, groupName))
+	_, err = d.storage.ResourceAttributes(instanceID)
+	require.ErrorAs(t, err, &storage.ErrResourceNotFound{})
+}
@@ -0,0 +1,107 @@
+package dc2
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/executor"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+func TestAutoScalingGroupVPCZoneIdentifierUpdateReplacesInstances(t *testing.T) {
+	t.Parallel()
+
+	const groupName = "asg"
+	ctx := context.Background()
+	exe := &runningInstancesExecutor{exitCleanupExecutor: &exitCleanupExecutor{}}
+	d := &Dispatcher{
+		exe:     exe,
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+	require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeAutoScalingGroup, ID: groupName}))
+	group := &autoScalingGroupData{
+		Name:               groupName,
+		CreatedTime:        time.Now(),
+		MaxSize:            2,
+		DesiredCapacity:    2,
+		LaunchTemplateID:   "lt-1",
+		LaunchTemplateName: "lt",
+		VPCZoneIdentifier:  new("subnet-a,subnet-b"),
+	}
+	require.NoError(t, d.saveAutoScalingGroupData(group))
+	instanceSubnets := map[string]string{
+		"i-00000000000000001": "subnet-a",
+		"i-00000000000000002": "subnet-b",
+	}
+	for instanceID, subnetID := range instanceSubnets {
+		require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeInstance, ID: instanceID}))
+		require.NoError(t, d.storage.SetResourceAttributes(instanceID, []storage.Attribute{
+			{Key: attributeNameAutoScalingGroupName, Value: groupName},
+			{Key: attributeNameSubnetID, Value: subnetID},
+		}))
+	}
+
+	group.VPCZoneIdentifier = new("subnet-b")
+	require.NoError(t, d.saveAutoScalingGroupData(group))
+	require.NoError(t, d.replaceAutoScalingInstancesOutsideSubnets(ctx, group))
+	require.Len(t, exe.terminateReqs, 1)
+	assert.Equal(t, []executor.InstanceID{executorInstanceID("i-00000000000000001")}, exe.terminateReqs[0].InstanceIDs)
+
+	// Clearing the identifier must be persisted too.
+	group.VPCZoneIdentifier = nil
+	require.NoError(t, d.saveAutoScalingGroupData(group))
+	loaded, err := d.loadAutoScalingGroupData(ctx, groupName)
+	require.NoError(t, err)
+	assert.Nil(t, loaded.VPCZoneIdentifier)
+}
+
+func TestAutoScalingLaunchInstanceTypesRoundRobin(t *testing.T) {
+	t.Parallel()
+
+	const groupName = "asg"
+	ctx := context.Background()
+	d := &Dispatcher{
+		exe:     &runningInstancesExecutor{exitCleanupExecutor: &exitCleanupExecutor{}},
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+	group := &autoScalingGroupData{
+		Name:                       groupName,
+		LaunchTemplateInstanceType: "t3.micro",
+		MixedInstancesPolicy: &api.AutoScalingMixedInstancesPolicy{
+			LaunchTemplate: &api.AutoScalingMixedInstancesLaunchTemplate{
+				Overrides: []api.AutoScalingMixedInstancesLaunchTemplateOverrides{
+					{InstanceType: new("t3.micro")},
+					{InstanceType: new("t3.small")},
+				},
+			},
+		},
+	}
+
+	instanceTypes, err := d.autoScalingLaunchInstanceTypes(ctx, group, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"t3.micro", "t3.small", "t3.micro"}, instanceTypes)
+
+	// Existing instances are taken into account
+	const instanceID = "i-00000000000000001"
+	require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeInstance, ID: instanceID}))
+	require.NoError(t, d.storage.SetResourceAttributes(instanceID, []storage.Attribute{
+		{Key: attributeNameAutoScalingGroupName, Value: groupName},
+		{Key: attributeNameAutoScalingGroupInstanceType, Value: "t3.micro"},
+	}))
+	instanceTypes, err = d.autoScalingLaunchInstanceTypes(ctx, group, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"t3.small", "t3.micro"}, instanceTypes)
+
+	group.MixedInstancesPolicy = nil
+	instanceTypes, err = d.autoScalingLaunchInstanceTypes(ctx, group, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"t3.micro", "t3.micro"}, instanceTypes)
+}
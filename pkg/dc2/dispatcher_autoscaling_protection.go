@@ -0,0 +1,80 @@
+package dc2
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+)
+
+const (
+	attributeNameAutoScalingInstanceProtectedFromScaleIn = "AutoScalingInstanceProtectedFromScaleIn"
+)
+
+func (d *Dispatcher) dispatchSetInstanceProtection(ctx context.Context, req *api.SetInstanceProtectionRequest) (*api.SetInstanceProtectionResponse, error) {
+	group, err := d.loadAutoScalingGroupData(ctx, req.AutoScalingGroupName)
+	if err != nil {
+		return nil, err
+	}
+	groupInstanceIDs, err := d.autoScalingGroupInstanceIDsReadOnly(ctx, group.Name)
+	if err != nil {
+		return nil, err
+	}
+	for _, instanceID := range req.InstanceIDs {
+		if !slices.Contains(groupInstanceIDs, instanceID) {
+			return nil, api.ErrWithCode(
+				"ValidationError",
+				fmt.Errorf("instance %q is not part of Auto Scaling group %q", instanceID, group.Name),
+			)
+		}
+	}
+	protected := *req.ProtectedFromScaleIn
+	for _, instanceID := range req.InstanceIDs {
+		if err := d.storage.SetResourceAttributes(instanceID, []storage.Attribute{
+			{Key: attributeNameAutoScalingInstanceProtectedFromScaleIn, Value: strconv.FormatBool(protected)},
+		}); err != nil {
+			return nil, fmt.Errorf("setting instance %s scale in protection: %w", instanceID, err)
+		}
+	}
+	api.Logger(ctx).Info(
+		"set auto scaling instance protection",
+		slog.String("auto_scaling_group_name", group.Name),
+		slog.Any("instance_ids", req.InstanceIDs),
+		slog.Bool("protected_from_scale_in", protected),
+	)
+	return &api.SetInstanceProtectionResponse{}, nil
+}
+
+func autoScalingInstanceIsProtectedFromScaleIn(attrs storage.Attributes) bool {
+	value, _ := attrs.Key(attributeNameAutoScalingInstanceProtectedFromScaleIn)
+	protected, err := strconv.ParseBool(value)
+	if err != nil {
+		return false
+	}
+	return protected
+}
+
+// autoScalingScaleInInstanceIDs picks up to count instances to remove from
+// instanceIDs when scaling in, skipping the ones protected from scale in.
+func (d *Dispatcher) autoScalingScaleInInstanceIDs(instanceIDs []string, count int) ([]string, error) {
+	candidates := slices.Sorted(slices.Values(instanceIDs))
+	removable := make([]string, 0, count)
+	for _, instanceID := range candidates {
+		if len(removable) == count {
+			break
+		}
+		attrs, err := d.storage.ResourceAttributes(instanceID)
+		if err != nil {
+			return nil, fmt.Errorf("retrieving instance attributes: %w", err)
+		}
+		if autoScalingInstanceIsProtectedFromScaleIn(attrs) {
+			continue
+		}
+		removable = append(removable, instanceID)
+	}
+	return removable, nil
+}
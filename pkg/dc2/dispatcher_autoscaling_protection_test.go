@@ -0,0 +1,91 @@
+package dc2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+func TestSetInstanceProtectionSkipsProtectedInstancesOnScaleIn(t *testing.T) {
+	t.Parallel()
+
+	const groupName = "asg"
+	ctx := t.Context()
+	d := &Dispatcher{
+		exe:     newScalingExecutor(),
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+	require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeAutoScalingGroup, ID: groupName}))
+	require.NoError(t, d.saveAutoScalingGroupData(&autoScalingGroupData{
+		Name:                       groupName,
+		CreatedTime:                time.Now(),
+		MaxSize:                    3,
+		LaunchTemplateID:           "lt-1",
+		LaunchTemplateName:         "lt",
+		LaunchTemplateVersion:      "1",
+		LaunchTemplateImageID:      "nginx",
+		LaunchTemplateInstanceType: "my-type",
+	}))
+
+	_, err := d.Dispatch(ctx, &api.SetDesiredCapacityRequest{AutoScalingGroupName: groupName, DesiredCapacity: new(2)})
+	require.NoError(t, err)
+	instanceIDs, err := d.autoScalingGroupManagedInstanceIDs(ctx, groupName)
+	require.NoError(t, err)
+	require.Len(t, instanceIDs, 2)
+	// Protect the instance that would be removed first
+	protectedID := min(instanceIDs[0], instanceIDs[1])
+
+	_, err = d.Dispatch(ctx, &api.SetInstanceProtectionRequest{
+		AutoScalingGroupName: groupName,
+		InstanceIDs:          []string{"i-00000000000000000"},
+		ProtectedFromScaleIn: new(true),
+	})
+	require.Error(t, err)
+
+	_, err = d.Dispatch(ctx, &api.SetInstanceProtectionRequest{
+		AutoScalingGroupName: groupName,
+		InstanceIDs:          []string{protectedID},
+		ProtectedFromScaleIn: new(true),
+	})
+	require.NoError(t, err)
+
+	details, err := d.dispatchDescribeAutoScalingInstances(ctx, &api.DescribeAutoScalingInstancesRequest{
+		InstanceIDs: []string{protectedID},
+	})
+	require.NoError(t, err)
+	require.Len(t, details.DescribeAutoScalingInstancesResult.AutoScalingInstances, 1)
+	assert.True(t, *details.DescribeAutoScalingInstancesResult.AutoScalingInstances[0].ProtectedFromScaleIn)
+
+	_, err = d.Dispatch(ctx, &api.SetDesiredCapacityRequest{AutoScalingGroupName: groupName, DesiredCapacity: new(1)})
+	require.NoError(t, err)
+	instanceIDs, err = d.autoScalingGroupManagedInstanceIDs(ctx, groupName)
+	require.NoError(t, err)
+	assert.Equal(t, []string{protectedID}, instanceIDs)
+
+	// With every instance protected, the group stays above its desired capacity
+	_, err = d.Dispatch(ctx, &api.SetDesiredCapacityRequest{AutoScalingGroupName: groupName, DesiredCapacity: new(0)})
+	require.NoError(t, err)
+	instanceIDs, err = d.autoScalingGroupManagedInstanceIDs(ctx, groupName)
+	require.NoError(t, err)
+	assert.Equal(t, []string{protectedID}, instanceIDs)
+
+	_, err = d.Dispatch(ctx, &api.SetInstanceProtectionRequest{
+		AutoScalingGroupName: groupName,
+		InstanceIDs:          []string{protectedID},
+		ProtectedFromScaleIn: new(false),
+	})
+	require.NoError(t, err)
+	group, err := d.loadAutoScalingGroupData(ctx, groupName)
+	require.NoError(t, err)
+	require.NoError(t, d.reconcileAutoScalingGroup(ctx, group))
+	instanceIDs, err = d.autoScalingGroupManagedInstanceIDs(ctx, groupName)
+	require.NoError(t, err)
+	assert.Empty(t, instanceIDs)
+}
@@ -0,0 +1,440 @@
+package dc2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+const (
+	attributeNameAutoScalingScalingPolicyPrefix = "AutoScalingScalingPolicy:"
+	attributeNameAutoScalingGroupCooldownEnd    = "AutoScalingGroupCooldownEnd"
+
+	scalingPolicyTypeSimpleScaling         = "SimpleScaling"
+	scalingPolicyTypeTargetTrackingScaling = "TargetTrackingScaling"
+
+	adjustmentTypeChangeInCapacity        = "ChangeInCapacity"
+	adjustmentTypeExactCapacity           = "ExactCapacity"
+	adjustmentTypePercentChangeInCapacity = "PercentChangeInCapacity"
+
+	errorCodeScalingActivityInProgress = "ScalingActivityInProgress"
+)
+
+// autoScalingScalingPolicy is a scaling policy, stored as JSON in an auto
+// scaling group attribute keyed by the policy name.
+type autoScalingScalingPolicy struct {
+	Name                        string                           `json:"-"`
+	ARN                         string                           `json:"arn"`
+	PolicyType                  string                           `json:"policyType"`
+	AdjustmentType              *string                          `json:"adjustmentType,omitempty"`
+	ScalingAdjustment           *int                             `json:"scalingAdjustment,omitempty"`
+	MinAdjustmentMagnitude      *int                             `json:"minAdjustmentMagnitude,omitempty"`
+	Cooldown                    *int                             `json:"cooldown,omitempty"`
+	TargetTrackingConfiguration *api.TargetTrackingConfiguration `json:"targetTrackingConfiguration,omitempty"`
+	Enabled                     bool                             `json:"enabled"`
+}
+
+func (d *Dispatcher) dispatchPutScalingPolicy(ctx context.Context, req *api.PutScalingPolicyRequest) (*api.PutScalingPolicyResponse, error) {
+	group, err := d.loadAutoScalingGroupData(ctx, req.AutoScalingGroupName)
+	if err != nil {
+		return nil, err
+	}
+	policy := autoScalingScalingPolicy{
+		Name:                        req.PolicyName,
+		PolicyType:                  scalingPolicyTypeSimpleScaling,
+		AdjustmentType:              req.AdjustmentType,
+		ScalingAdjustment:           req.ScalingAdjustment,
+		MinAdjustmentMagnitude:      req.MinAdjustmentMagnitude,
+		Cooldown:                    req.Cooldown,
+		TargetTrackingConfiguration: req.TargetTrackingConfiguration,
+		Enabled:                     req.Enabled == nil || *req.Enabled,
+	}
+	if req.PolicyType != nil && *req.PolicyType != "" {
+		policy.PolicyType = *req.PolicyType
+	}
+	if err := validateAutoScalingScalingPolicy(policy); err != nil {
+		return nil, err
+	}
+
+	existing, err := d.autoScalingScalingPolicies(group.Name)
+	if err != nil {
+		return nil, err
+	}
+	if idx := slices.IndexFunc(existing, func(p autoScalingScalingPolicy) bool { return p.Name == policy.Name }); idx >= 0 {
+		// Updating a policy keeps its ARN
+		policy.ARN = existing[idx].ARN
+	} else {
+		policy.ARN = fmt.Sprintf(
+			"arn:aws:autoscaling:%s:%s:scalingPolicy:%s:autoScalingGroupName/%s:policyName/%s",
+			d.opts.Region, defaultSecurityGroupOwnerID, uuid.New().String(), group.Name, policy.Name,
+		)
+	}
+	if err := d.saveAutoScalingScalingPolicy(group.Name, policy); err != nil {
+		return nil, err
+	}
+	return &api.PutScalingPolicyResponse{
+		PutScalingPolicyResult: api.PutScalingPolicyResult{
+			PolicyARN: new(policy.ARN),
+		},
+	}, nil
+}
+
+func validateAutoScalingScalingPolicy(policy autoScalingScalingPolicy) error {
+	switch policy.PolicyType {
+	case scalingPolicyTypeSimpleScaling:
+		if policy.TargetTrackingConfiguration != nil {
+			return api.ValidationError("TargetTrackingConfiguration", "TargetTrackingConfiguration is not supported for %s policies", policy.PolicyType)
+		}
+		if policy.AdjustmentType == nil {
+			return api.ValidationError("AdjustmentType", "AdjustmentType is required for %s policies", policy.PolicyType)
+		}
+		switch *policy.AdjustmentType {
+		case adjustmentTypeChangeInCapacity, adjustmentTypeExactCapacity, adjustmentTypePercentChangeInCapacity:
+		default:
+			return api.ValidationError("AdjustmentType", "invalid AdjustmentType %q", *policy.AdjustmentType)
+		}
+		if policy.ScalingAdjustment == nil {
+			return api.ValidationError("ScalingAdjustment", "ScalingAdjustment is required for %s policies", policy.PolicyType)
+		}
+		if *policy.AdjustmentType == adjustmentTypeExactCapacity && *policy.ScalingAdjustment < 0 {
+			return api.ValidationError("ScalingAdjustment", "ScalingAdjustment must be non-negative for %s adjustments", adjustmentTypeExactCapacity)
+		}
+		if policy.MinAdjustmentMagnitude != nil {
+			if *policy.AdjustmentType != adjustmentTypePercentChangeInCapacity {
+				return api.ValidationError("MinAdjustmentMagnitude", "MinAdjustmentMagnitude is only supported with %s adjustments", adjustmentTypePercentChangeInCapacity)
+			}
+			if *policy.MinAdjustmentMagnitude < 1 {
+				return api.ValidationError("MinAdjustmentMagnitude", "MinAdjustmentMagnitude must be at least 1")
+			}
+		}
+		if policy.Cooldown != nil && *policy.Cooldown < 0 {
+			return api.ValidationError("Cooldown", "Cooldown must be non-negative")
+		}
+	case scalingPolicyTypeTargetTrackingScaling:
+		if policy.AdjustmentType != nil || policy.ScalingAdjustment != nil || policy.MinAdjustmentMagnitude != nil || policy.Cooldown != nil {
+			return api.ErrWithCode("ValidationError", fmt.Errorf(
+				"AdjustmentType, ScalingAdjustment, MinAdjustmentMagnitude and Cooldown are not supported for %s policies",
+				policy.PolicyType,
+			))
+		}
+		config := policy.TargetTrackingConfiguration
+		if config == nil {
+			return api.ValidationError("TargetTrackingConfiguration", "TargetTrackingConfiguration is required for %s policies", policy.PolicyType)
+		}
+		if config.PredefinedMetricSpecification == nil || config.PredefinedMetricSpecification.PredefinedMetricType == "" {
+			return api.ValidationError("TargetTrackingConfiguration.PredefinedMetricSpecification", "PredefinedMetricSpecification is required")
+		}
+		if config.TargetValue == nil || *config.TargetValue <= 0 {
+			return api.ValidationError("TargetTrackingConfiguration.TargetValue", "TargetValue must be greater than 0")
+		}
+	default:
+		return api.ValidationError("PolicyType", "unsupported PolicyType %q", policy.PolicyType)
+	}
+	return nil
+}
+
+func (d *Dispatcher) dispatchDescribePolicies(
+	ctx context.Context,
+	req *api.DescribePoliciesRequest,
+) (*api.DescribePoliciesResponse, error) {
+	var groupNames []string
+	if req.AutoScalingGroupName != nil && *req.AutoScalingGroupName != "" {
+		group, err := d.loadAutoScalingGroupData(ctx, *req.AutoScalingGroupName)
+		if err != nil {
+			return nil, err
+		}
+		groupNames = []string{group.Name}
+	} else {
+		resources, err := d.storage.RegisteredResources(types.ResourceTypeAutoScalingGroup)
+		if err != nil {
+			return nil, fmt.Errorf("retrieving auto scaling groups: %w", err)
+		}
+		for _, r := range resources {
+			groupNames = append(groupNames, r.ID)
+		}
+		slices.Sort(groupNames)
+	}
+
+	var scalingPolicies []api.ScalingPolicy
+	for _, groupName := range groupNames {
+		policies, err := d.autoScalingScalingPolicies(groupName)
+		if err != nil {
+			return nil, err
+		}
+		for _, policy := range policies {
+			if len(req.PolicyNames) > 0 && !slices.Contains(req.PolicyNames, policy.Name) && !slices.Contains(req.PolicyNames, policy.ARN) {
+				continue
+			}
+			if len(req.PolicyTypes) > 0 && !slices.Contains(req.PolicyTypes, policy.PolicyType) {
+				continue
+			}
+			scalingPolicies = append(scalingPolicies, api.ScalingPolicy{
+				AdjustmentType:              policy.AdjustmentType,
+				AutoScalingGroupName:        new(groupName),
+				Cooldown:                    policy.Cooldown,
+				Enabled:                     new(policy.Enabled),
+				MinAdjustmentMagnitude:      policy.MinAdjustmentMagnitude,
+				PolicyARN:                   new(policy.ARN),
+				PolicyName:                  new(policy.Name),
+				PolicyType:                  new(policy.PolicyType),
+				ScalingAdjustment:           policy.ScalingAdjustment,
+				TargetTrackingConfiguration: policy.TargetTrackingConfiguration,
+			})
+		}
+	}
+	scalingPolicies, nextToken, err := applyNextToken(scalingPolicies, req.NextToken, req.MaxRecords)
+	if err != nil {
+		return nil, err
+	}
+	return &api.DescribePoliciesResponse{
+		DescribePoliciesResult: api.DescribePoliciesResult{
+			ScalingPolicies: scalingPolicies,
+			NextToken:       nextToken,
+		},
+	}, nil
+}
+
+func (d *Dispatcher) dispatchDeletePolicy(ctx context.Context, req *api.DeletePolicyRequest) (*api.DeletePolicyResponse, error) {
+	group, policy, err := d.findAutoScalingScalingPolicy(ctx, req.AutoScalingGroupName, req.PolicyName)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.storage.RemoveResourceAttributes(group.Name, []storage.Attribute{
+		{Key: attributeNameAutoScalingScalingPolicyPrefix + policy.Name},
+	}); err != nil {
+		return nil, fmt.Errorf("removing scaling policy: %w", err)
+	}
+	return &api.DeletePolicyResponse{}, nil
+}
+
+// dispatchExecutePolicy runs a scaling policy once. Since dc2 has no metrics,
+// target tracking policies require MetricValue, which is used as the current
+// value of the tracked metric.
+func (d *Dispatcher) dispatchExecutePolicy(ctx context.Context, req *api.ExecutePolicyRequest) (*api.ExecutePolicyResponse, error) {
+	group, policy, err := d.findAutoScalingScalingPolicy(ctx, req.AutoScalingGroupName, req.PolicyName)
+	if err != nil {
+		return nil, err
+	}
+	if !policy.Enabled {
+		return nil, api.ValidationError("PolicyName", "scaling policy %q is disabled", policy.Name)
+	}
+	unlock, err := d.lockAutoScalingGroup(ctx, group.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	// Reload after locking, so a concurrent scaling operation isn't undone
+	group, err = d.loadAutoScalingGroupData(ctx, group.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	now := d.now().UTC()
+	honorCooldown := req.HonorCooldown != nil && *req.HonorCooldown
+	if policy.PolicyType == scalingPolicyTypeSimpleScaling && honorCooldown {
+		cooldownEnd, err := d.autoScalingGroupCooldownEnd(group.Name)
+		if err != nil {
+			return nil, err
+		}
+		if now.Before(cooldownEnd) {
+			return nil, api.ErrWithCode(errorCodeScalingActivityInProgress, fmt.Errorf(
+				"auto scaling group %q is in its cooldown period until %s",
+				group.Name,
+				cooldownEnd.Format(time.RFC3339),
+			))
+		}
+	}
+
+	var desiredCapacity int
+	switch policy.PolicyType {
+	case scalingPolicyTypeTargetTrackingScaling:
+		if req.MetricValue == nil {
+			return nil, api.ValidationError("MetricValue", "MetricValue is required to execute %s policies", policy.PolicyType)
+		}
+		desiredCapacity = targetTrackingDesiredCapacity(group.DesiredCapacity, *req.MetricValue, policy.TargetTrackingConfiguration)
+	default:
+		desiredCapacity = simpleScalingDesiredCapacity(group.DesiredCapacity, policy)
+	}
+	desiredCapacity = min(max(desiredCapacity, group.MinSize), group.MaxSize)
+	api.Logger(ctx).Info(
+		"executing auto scaling policy",
+		slog.String("auto_scaling_group_name", group.Name),
+		slog.String("policy_name", policy.Name),
+		slog.Int("desired_capacity_before", group.DesiredCapacity),
+		slog.Int("desired_capacity_after", desiredCapacity),
+	)
+	if desiredCapacity == group.DesiredCapacity {
+		return &api.ExecutePolicyResponse{}, nil
+	}
+	if err := d.scaleAutoScalingGroupTo(ctx, group, desiredCapacity); err != nil {
+		return nil, err
+	}
+	if policy.PolicyType == scalingPolicyTypeSimpleScaling {
+		cooldown := group.DefaultCooldown
+		if policy.Cooldown != nil {
+			cooldown = *policy.Cooldown
+		}
+		cooldownEnd := now.Add(time.Duration(cooldown) * time.Second)
+		if err := d.storage.SetResourceAttributes(group.Name, []storage.Attribute{
+			{Key: attributeNameAutoScalingGroupCooldownEnd, Value: cooldownEnd.Format(time.RFC3339Nano)},
+		}); err != nil {
+			return nil, fmt.Errorf("saving auto scaling group cooldown: %w", err)
+		}
+	}
+	return &api.ExecutePolicyResponse{}, nil
+}
+
+// simpleScalingDesiredCapacity returns the desired capacity after applying a
+// simple scaling policy, before clamping it to the group size limits.
+func simpleScalingDesiredCapacity(currentCapacity int, policy autoScalingScalingPolicy) int {
+	adjustment := *policy.ScalingAdjustment
+	switch *policy.AdjustmentType {
+	case adjustmentTypeExactCapacity:
+		return adjustment
+	case adjustmentTypePercentChangeInCapacity:
+		// Like AWS, changes between -1 and 1 round away from zero, while
+		// larger ones round towards zero.
+		change := float64(currentCapacity) * float64(adjustment) / 100
+		delta := int(change)
+		switch {
+		case change > 0 && change < 1:
+			delta = 1
+		case change < 0 && change > -1:
+			delta = -1
+		}
+		if policy.MinAdjustmentMagnitude != nil && abs(delta) < *policy.MinAdjustmentMagnitude {
+			delta = *policy.MinAdjustmentMagnitude
+			if change < 0 {
+				delta = -delta
+			}
+		}
+		return currentCapacity + delta
+	default:
+		return currentCapacity + adjustment
+	}
+}
+
+// targetTrackingDesiredCapacity returns the capacity needed to move
+// metricValue towards the configured target value, assuming the metric scales
+// linearly with the number of instances.
+func targetTrackingDesiredCapacity(currentCapacity int, metricValue float64, config *api.TargetTrackingConfiguration) int {
+	desiredCapacity := int(math.Ceil(float64(max(currentCapacity, 1)) * metricValue / *config.TargetValue))
+	if desiredCapacity < currentCapacity && config.DisableScaleIn != nil && *config.DisableScaleIn {
+		return currentCapacity
+	}
+	return desiredCapacity
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// findAutoScalingScalingPolicy returns the policy identified by policyName,
+// which can be either a name within groupName or a policy ARN.
+func (d *Dispatcher) findAutoScalingScalingPolicy(
+	ctx context.Context,
+	groupName *string,
+	policyName string,
+) (*autoScalingGroupData, autoScalingScalingPolicy, error) {
+	name := ""
+	if groupName != nil {
+		name = *groupName
+	}
+	if strings.HasPrefix(policyName, "arn:") {
+		_, rest, _ := strings.Cut(policyName, ":autoScalingGroupName/")
+		arnGroupName, _, ok := strings.Cut(rest, ":policyName/")
+		if !ok {
+			return nil, autoScalingScalingPolicy{}, api.ValidationError("PolicyName", "invalid policy ARN %q", policyName)
+		}
+		name = arnGroupName
+	}
+	if name == "" {
+		return nil, autoScalingScalingPolicy{}, api.ValidationError("AutoScalingGroupName", "AutoScalingGroupName is required when PolicyName is not an ARN")
+	}
+	group, err := d.loadAutoScalingGroupData(ctx, name)
+	if err != nil {
+		return nil, autoScalingScalingPolicy{}, err
+	}
+	policies, err := d.autoScalingScalingPolicies(group.Name)
+	if err != nil {
+		return nil, autoScalingScalingPolicy{}, err
+	}
+	idx := slices.IndexFunc(policies, func(p autoScalingScalingPolicy) bool {
+		return p.Name == policyName || p.ARN == policyName
+	})
+	if idx < 0 {
+		return nil, autoScalingScalingPolicy{}, api.ErrWithCode("ValidationError", fmt.Errorf(
+			"no scaling policy found with name %q for group %q",
+			policyName,
+			group.Name,
+		))
+	}
+	return group, policies[idx], nil
+}
+
+func (d *Dispatcher) autoScalingGroupCooldownEnd(groupName string) (time.Time, error) {
+	attrs, err := d.storage.ResourceAttributes(groupName)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("retrieving auto scaling group attributes: %w", err)
+	}
+	raw, ok := attrs.Key(attributeNameAutoScalingGroupCooldownEnd)
+	if !ok || raw == "" {
+		return time.Time{}, nil
+	}
+	cooldownEnd, err := time.Parse(time.RFC3339Nano, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid auto scaling group cooldown %q: %w", raw, err)
+	}
+	return cooldownEnd, nil
+}
+
+func (d *Dispatcher) autoScalingScalingPolicies(groupName string) ([]autoScalingScalingPolicy, error) {
+	attrs, err := d.storage.ResourceAttributes(groupName)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving auto scaling group attributes: %w", err)
+	}
+	var policies []autoScalingScalingPolicy
+	for _, attr := range attrs {
+		name, ok := strings.CutPrefix(attr.Key, attributeNameAutoScalingScalingPolicyPrefix)
+		if !ok {
+			continue
+		}
+		var policy autoScalingScalingPolicy
+		if err := json.Unmarshal([]byte(attr.Value), &policy); err != nil {
+			return nil, fmt.Errorf("invalid scaling policy %q: %w", name, err)
+		}
+		policy.Name = name
+		policies = append(policies, policy)
+	}
+	slices.SortFunc(policies, func(a, b autoScalingScalingPolicy) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return policies, nil
+}
+
+func (d *Dispatcher) saveAutoScalingScalingPolicy(groupName string, policy autoScalingScalingPolicy) error {
+	raw, err := json.Marshal(policy)
+	if err != nil {
+		return fmt.Errorf("marshaling scaling policy: %w", err)
+	}
+	if err := d.storage.SetResourceAttributes(groupName, []storage.Attribute{
+		{Key: attributeNameAutoScalingScalingPolicyPrefix + policy.Name, Value: string(raw)},
+	}); err != nil {
+		return fmt.Errorf("saving scaling policy: %w", err)
+	}
+	return nil
+}
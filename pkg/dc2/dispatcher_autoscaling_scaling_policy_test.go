@@ -0,0 +1,186 @@
+package dc2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+func newScalingPolicyTestDispatcher(t *testing.T, groupName string, now *time.Time) *Dispatcher {
+	t.Helper()
+	d := &Dispatcher{
+		exe:     newScalingExecutor(),
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+		clock:   func() time.Time { return *now },
+	}
+	require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeAutoScalingGroup, ID: groupName}))
+	require.NoError(t, d.saveAutoScalingGroupData(&autoScalingGroupData{
+		Name:                       groupName,
+		CreatedTime:                *now,
+		MaxSize:                    4,
+		DefaultCooldown:            300,
+		LaunchTemplateID:           "lt-1",
+		LaunchTemplateName:         "lt",
+		LaunchTemplateImageID:      "nginx",
+		LaunchTemplateInstanceType: "my-type",
+	}))
+	return d
+}
+
+func TestExecuteSimpleScalingPolicyHonorsCooldown(t *testing.T) {
+	t.Parallel()
+
+	const groupName = "asg"
+	ctx := t.Context()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	d := newScalingPolicyTestDispatcher(t, groupName, &now)
+
+	putResp, err := d.Dispatch(ctx, &api.PutScalingPolicyRequest{
+		AutoScalingGroupName: groupName,
+		PolicyName:           "scale-out",
+		AdjustmentType:       new(adjustmentTypeChangeInCapacity),
+		ScalingAdjustment:    new(1),
+	})
+	require.NoError(t, err)
+	policyARN := *putResp.(*api.PutScalingPolicyResponse).PutScalingPolicyResult.PolicyARN
+	assert.Contains(t, policyARN, ":autoScalingGroupName/asg:policyName/scale-out")
+
+	desiredCapacity := func() int {
+		t.Helper()
+		group, err := d.loadAutoScalingGroupData(ctx, groupName)
+		require.NoError(t, err)
+		return group.DesiredCapacity
+	}
+	execute := func() error {
+		_, err := d.Dispatch(ctx, &api.ExecutePolicyRequest{
+			AutoScalingGroupName: new(groupName),
+			PolicyName:           "scale-out",
+			HonorCooldown:        new(true),
+		})
+		return err
+	}
+
+	require.NoError(t, execute())
+	assert.Equal(t, 1, desiredCapacity())
+
+	// Within the DefaultCooldown window the group doesn't scale again
+	now = now.Add(299 * time.Second)
+	err = execute()
+	var apiErr *api.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "ScalingActivityInProgress", apiErr.Code)
+	assert.Equal(t, 1, desiredCapacity())
+
+	now = now.Add(time.Second)
+	require.NoError(t, execute())
+	assert.Equal(t, 2, desiredCapacity())
+
+	// Without HonorCooldown, the policy runs right away. The policy can be
+	// referenced by its ARN.
+	_, err = d.Dispatch(ctx, &api.ExecutePolicyRequest{PolicyName: policyARN})
+	require.NoError(t, err)
+	assert.Equal(t, 3, desiredCapacity())
+
+	instanceIDs, err := d.autoScalingGroupManagedInstanceIDs(ctx, groupName)
+	require.NoError(t, err)
+	assert.Len(t, instanceIDs, 3)
+
+	_, err = d.Dispatch(ctx, &api.DeletePolicyRequest{AutoScalingGroupName: new(groupName), PolicyName: "scale-out"})
+	require.NoError(t, err)
+	require.ErrorAs(t, execute(), &apiErr)
+	assert.Equal(t, "ValidationError", apiErr.Code)
+}
+
+func TestExecuteTargetTrackingScalingPolicy(t *testing.T) {
+	t.Parallel()
+
+	const groupName = "asg"
+	ctx := t.Context()
+	now := time.Now()
+	d := newScalingPolicyTestDispatcher(t, groupName, &now)
+
+	_, err := d.Dispatch(ctx, &api.SetDesiredCapacityRequest{AutoScalingGroupName: groupName, DesiredCapacity: new(1)})
+	require.NoError(t, err)
+	_, err = d.Dispatch(ctx, &api.PutScalingPolicyRequest{
+		AutoScalingGroupName: groupName,
+		PolicyName:           "cpu",
+		PolicyType:           new(scalingPolicyTypeTargetTrackingScaling),
+		TargetTrackingConfiguration: &api.TargetTrackingConfiguration{
+			PredefinedMetricSpecification: &api.PredefinedMetricSpecification{PredefinedMetricType: "ASGAverageCPUUtilization"},
+			TargetValue:                   new(50.0),
+		},
+	})
+	require.NoError(t, err)
+
+	describeResp, err := d.Dispatch(ctx, &api.DescribePoliciesRequest{AutoScalingGroupName: new(groupName)})
+	require.NoError(t, err)
+	policies := describeResp.(*api.DescribePoliciesResponse).DescribePoliciesResult.ScalingPolicies
+	require.Len(t, policies, 1)
+	assert.Equal(t, scalingPolicyTypeTargetTrackingScaling, *policies[0].PolicyType)
+	assert.InDelta(t, 50.0, *policies[0].TargetTrackingConfiguration.TargetValue, 0)
+
+	_, err = d.Dispatch(ctx, &api.ExecutePolicyRequest{AutoScalingGroupName: new(groupName), PolicyName: "cpu"})
+	var apiErr *api.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "ValidationError", apiErr.Code)
+
+	tests := []struct {
+		metricValue float64
+		want        int
+	}{
+		// 1 instance at 120% of the target needs 3 instances
+		{120, 3},
+		// 3 instances at 20% need 2 instances
+		{20, 2},
+		// Capped at MaxSize
+		{500, 4},
+	}
+	for _, tc := range tests {
+		_, err = d.Dispatch(ctx, &api.ExecutePolicyRequest{
+			AutoScalingGroupName: new(groupName),
+			PolicyName:           "cpu",
+			MetricValue:          new(tc.metricValue),
+		})
+		require.NoError(t, err)
+		group, err := d.loadAutoScalingGroupData(ctx, groupName)
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, group.DesiredCapacity, "metric value %v", tc.metricValue)
+	}
+}
+
+func TestSimpleScalingDesiredCapacity(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name                   string
+		adjustmentType         string
+		adjustment             int
+		minAdjustmentMagnitude *int
+		current                int
+		want                   int
+	}{
+		{"change in capacity", adjustmentTypeChangeInCapacity, -2, nil, 5, 3},
+		{"exact capacity", adjustmentTypeExactCapacity, 7, nil, 5, 7},
+		{"percent rounds towards zero", adjustmentTypePercentChangeInCapacity, 50, nil, 5, 7},
+		{"small percent rounds away from zero", adjustmentTypePercentChangeInCapacity, 10, nil, 5, 6},
+		{"negative percent", adjustmentTypePercentChangeInCapacity, -10, nil, 5, 4},
+		{"min adjustment magnitude", adjustmentTypePercentChangeInCapacity, 10, new(3), 5, 8},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := simpleScalingDesiredCapacity(tc.current, autoScalingScalingPolicy{
+				AdjustmentType:         new(tc.adjustmentType),
+				ScalingAdjustment:      new(tc.adjustment),
+				MinAdjustmentMagnitude: tc.minAdjustmentMagnitude,
+			})
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
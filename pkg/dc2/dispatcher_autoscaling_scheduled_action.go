@@ -0,0 +1,390 @@
+package dc2
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+const (
+	attributeNameAutoScalingScheduledActionPrefix = "AutoScalingScheduledAction:"
+
+	autoScalingScheduledActionInterval = time.Second
+)
+
+// autoScalingScheduledAction is a scheduled scaling action, stored as JSON in
+// an auto scaling group attribute keyed by the action name.
+type autoScalingScheduledAction struct {
+	Name            string     `json:"-"`
+	StartTime       *time.Time `json:"startTime,omitempty"`
+	EndTime         *time.Time `json:"endTime,omitempty"`
+	Recurrence      *string    `json:"recurrence,omitempty"`
+	TimeZone        *string    `json:"timeZone,omitempty"`
+	MinSize         *int       `json:"minSize,omitempty"`
+	MaxSize         *int       `json:"maxSize,omitempty"`
+	DesiredCapacity *int       `json:"desiredCapacity,omitempty"`
+	// LastRun is the minute a recurring action last fired at, so it fires
+	// at most once per matching minute.
+	LastRun *time.Time `json:"lastRun,omitempty"`
+}
+
+func (d *Dispatcher) dispatchPutScheduledUpdateGroupAction(
+	ctx context.Context,
+	req *api.PutScheduledUpdateGroupActionRequest,
+) (*api.PutScheduledUpdateGroupActionResponse, error) {
+	group, err := d.loadAutoScalingGroupData(ctx, req.AutoScalingGroupName)
+	if err != nil {
+		return nil, err
+	}
+	action := autoScalingScheduledAction{
+		Name:            req.ScheduledActionName,
+		StartTime:       cmp.Or(req.StartTime, req.Time),
+		EndTime:         req.EndTime,
+		Recurrence:      req.Recurrence,
+		TimeZone:        req.TimeZone,
+		MinSize:         req.MinSize,
+		MaxSize:         req.MaxSize,
+		DesiredCapacity: req.DesiredCapacity,
+	}
+	if action.MinSize == nil && action.MaxSize == nil && action.DesiredCapacity == nil {
+		return nil, api.ErrWithCode("ValidationError", errors.New("at least one of MinSize, MaxSize or DesiredCapacity must be specified"))
+	}
+	if action.StartTime == nil && action.Recurrence == nil {
+		return nil, api.ErrWithCode("ValidationError", errors.New("at least one of StartTime or Recurrence must be specified"))
+	}
+	if action.StartTime != nil && action.EndTime != nil && !action.EndTime.After(*action.StartTime) {
+		return nil, api.ValidationError("EndTime", "EndTime must be after StartTime")
+	}
+	if action.Recurrence != nil {
+		if _, err := parseCronSchedule(*action.Recurrence); err != nil {
+			return nil, api.ValidationError("Recurrence", "invalid Recurrence %q: %w", *action.Recurrence, err)
+		}
+	} else if action.EndTime != nil {
+		return nil, api.ValidationError("EndTime", "EndTime requires Recurrence")
+	}
+	if _, err := action.location(); err != nil {
+		return nil, api.ValidationError("TimeZone", "invalid TimeZone %q: %w", *action.TimeZone, err)
+	}
+
+	existing, err := d.autoScalingScheduledActions(group.Name)
+	if err != nil {
+		return nil, err
+	}
+	if idx := slices.IndexFunc(existing, func(a autoScalingScheduledAction) bool { return a.Name == action.Name }); idx >= 0 {
+		// Keep the last run, so updating an action doesn't fire it again
+		// within the same minute.
+		action.LastRun = existing[idx].LastRun
+	}
+	if err := d.saveAutoScalingScheduledAction(group.Name, action); err != nil {
+		return nil, err
+	}
+	// Actions whose start time already passed fire right away, like the
+	// ones whose schedule matches the current minute.
+	if err := d.runDueAutoScalingScheduledActions(ctx, group.Name, d.now()); err != nil {
+		return nil, err
+	}
+	return &api.PutScheduledUpdateGroupActionResponse{}, nil
+}
+
+func (d *Dispatcher) dispatchDescribeScheduledActions(
+	ctx context.Context,
+	req *api.DescribeScheduledActionsRequest,
+) (*api.DescribeScheduledActionsResponse, error) {
+	var groupNames []string
+	if req.AutoScalingGroupName != nil && *req.AutoScalingGroupName != "" {
+		group, err := d.loadAutoScalingGroupData(ctx, *req.AutoScalingGroupName)
+		if err != nil {
+			return nil, err
+		}
+		groupNames = []string{group.Name}
+	} else {
+		resources, err := d.storage.RegisteredResources(types.ResourceTypeAutoScalingGroup)
+		if err != nil {
+			return nil, fmt.Errorf("retrieving auto scaling groups: %w", err)
+		}
+		for _, r := range resources {
+			groupNames = append(groupNames, r.ID)
+		}
+		slices.Sort(groupNames)
+	}
+
+	var scheduledActions []api.ScheduledUpdateGroupAction
+	for _, groupName := range groupNames {
+		actions, err := d.autoScalingScheduledActions(groupName)
+		if err != nil {
+			return nil, err
+		}
+		for _, action := range actions {
+			if len(req.ScheduledActionNames) > 0 && !slices.Contains(req.ScheduledActionNames, action.Name) {
+				continue
+			}
+			if action.StartTime != nil {
+				if req.StartTime != nil && action.StartTime.Before(*req.StartTime) {
+					continue
+				}
+				if req.EndTime != nil && action.StartTime.After(*req.EndTime) {
+					continue
+				}
+			}
+			scheduledActions = append(scheduledActions, api.ScheduledUpdateGroupAction{
+				AutoScalingGroupName: new(groupName),
+				DesiredCapacity:      action.DesiredCapacity,
+				EndTime:              action.EndTime,
+				MaxSize:              action.MaxSize,
+				MinSize:              action.MinSize,
+				Recurrence:           action.Recurrence,
+				ScheduledActionName:  new(action.Name),
+				StartTime:            action.StartTime,
+				Time:                 action.StartTime,
+				TimeZone:             action.TimeZone,
+			})
+		}
+	}
+	scheduledActions, nextToken, err := applyNextToken(scheduledActions, req.NextToken, req.MaxRecords)
+	if err != nil {
+		return nil, err
+	}
+	return &api.DescribeScheduledActionsResponse{
+		DescribeScheduledActionsResult: api.DescribeScheduledActionsResult{
+			ScheduledUpdateGroupActions: scheduledActions,
+			NextToken:                   nextToken,
+		},
+	}, nil
+}
+
+func (d *Dispatcher) dispatchDeleteScheduledAction(
+	ctx context.Context,
+	req *api.DeleteScheduledActionRequest,
+) (*api.DeleteScheduledActionResponse, error) {
+	group, err := d.loadAutoScalingGroupData(ctx, req.AutoScalingGroupName)
+	if err != nil {
+		return nil, err
+	}
+	actions, err := d.autoScalingScheduledActions(group.Name)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.ContainsFunc(actions, func(a autoScalingScheduledAction) bool { return a.Name == req.ScheduledActionName }) {
+		return nil, api.ErrWithCode("ValidationError", fmt.Errorf(
+			"no scheduled action found with name %q for group %q",
+			req.ScheduledActionName,
+			group.Name,
+		))
+	}
+	if err := d.removeAutoScalingScheduledAction(group.Name, req.ScheduledActionName); err != nil {
+		return nil, err
+	}
+	return &api.DeleteScheduledActionResponse{}, nil
+}
+
+// startScheduledActionRunner starts the background loop firing due scheduled
+// actions. It's stopped by Close.
+func (d *Dispatcher) startScheduledActionRunner() {
+	runCtx, cancel := context.WithCancel(context.Background())
+	d.scheduledActionCancel = cancel
+	d.scheduledActionDone = make(chan struct{})
+
+	go func() {
+		defer close(d.scheduledActionDone)
+		ticker := time.NewTicker(autoScalingScheduledActionInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-runCtx.Done():
+				return
+			case <-ticker.C:
+				d.dispatchMu.Lock()
+				if runCtx.Err() != nil {
+					d.dispatchMu.Unlock()
+					return
+				}
+				if err := d.runAllDueAutoScalingScheduledActions(context.Background()); err != nil {
+					slog.Warn("failed to run auto scaling scheduled actions", "error", err)
+				}
+				d.dispatchMu.Unlock()
+			}
+		}
+	}()
+}
+
+// runAllDueAutoScalingScheduledActions fires the actions of every group that
+// are due at the dispatcher's current time.
+func (d *Dispatcher) runAllDueAutoScalingScheduledActions(ctx context.Context) error {
+	now := d.now()
+	resources, err := d.storage.RegisteredResources(types.ResourceTypeAutoScalingGroup)
+	if err != nil {
+		return fmt.Errorf("retrieving auto scaling groups for scheduled actions: %w", err)
+	}
+	for _, resource := range resources {
+		if err := d.runDueAutoScalingScheduledActions(ctx, resource.ID, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runDueAutoScalingScheduledActions fires the group actions that are due at
+// now, holding the group scaling lock. One-off actions are removed after
+// firing, while recurring ones record the minute they fired at. Groups
+// deleted while waiting for the lock are skipped.
+func (d *Dispatcher) runDueAutoScalingScheduledActions(ctx context.Context, groupName string, now time.Time) error {
+	unlock, err := d.lockAutoScalingGroup(ctx, groupName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if _, err := d.findResource(ctx, types.ResourceTypeAutoScalingGroup, groupName); err != nil {
+		if errors.As(err, &storage.ErrResourceNotFound{}) {
+			return nil
+		}
+		return err
+	}
+	actions, err := d.autoScalingScheduledActions(groupName)
+	if err != nil {
+		return err
+	}
+	for _, action := range actions {
+		if !action.due(now) {
+			continue
+		}
+		// Load for every action, since the previous one might have
+		// changed the group
+		group, err := d.loadAutoScalingGroupData(ctx, groupName)
+		if err != nil {
+			return err
+		}
+		api.Logger(ctx).Info(
+			"running auto scaling scheduled action",
+			slog.String("auto_scaling_group_name", groupName),
+			slog.String("scheduled_action_name", action.Name),
+		)
+		// A failing action is not retried, since it would most likely
+		// fail again on every tick.
+		if err := d.applyAutoScalingScheduledAction(ctx, group, action); err != nil {
+			api.Logger(ctx).Warn(
+				"failed to run auto scaling scheduled action",
+				slog.String("auto_scaling_group_name", groupName),
+				slog.String("scheduled_action_name", action.Name),
+				slog.Any("error", err),
+			)
+		}
+		if action.Recurrence == nil {
+			if err := d.removeAutoScalingScheduledAction(groupName, action.Name); err != nil {
+				return err
+			}
+			continue
+		}
+		action.LastRun = new(now.Truncate(time.Minute))
+		if err := d.saveAutoScalingScheduledAction(groupName, action); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Dispatcher) applyAutoScalingScheduledAction(ctx context.Context, group *autoScalingGroupData, action autoScalingScheduledAction) error {
+	if action.MinSize != nil {
+		group.MinSize = *action.MinSize
+	}
+	if action.MaxSize != nil {
+		group.MaxSize = *action.MaxSize
+	}
+	if err := validateAutoScalingGroupSizes(group.MinSize, group.MaxSize); err != nil {
+		return err
+	}
+	desiredCapacity := group.DesiredCapacity
+	if action.DesiredCapacity != nil {
+		desiredCapacity = *action.DesiredCapacity
+	}
+	desiredCapacity = min(max(desiredCapacity, group.MinSize), group.MaxSize)
+	return d.scaleAutoScalingGroupTo(ctx, group, desiredCapacity)
+}
+
+// due reports whether the action should fire at now.
+func (a autoScalingScheduledAction) due(now time.Time) bool {
+	if a.StartTime != nil && now.Before(*a.StartTime) {
+		return false
+	}
+	if a.Recurrence == nil {
+		return a.StartTime != nil
+	}
+	if a.EndTime != nil && now.After(*a.EndTime) {
+		return false
+	}
+	minute := now.Truncate(time.Minute)
+	if a.LastRun != nil && !a.LastRun.Before(minute) {
+		return false
+	}
+	schedule, err := parseCronSchedule(*a.Recurrence)
+	if err != nil {
+		return false
+	}
+	loc, err := a.location()
+	if err != nil {
+		return false
+	}
+	return schedule.matches(minute.In(loc))
+}
+
+func (a autoScalingScheduledAction) location() (*time.Location, error) {
+	if a.TimeZone == nil || *a.TimeZone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(*a.TimeZone)
+}
+
+func (d *Dispatcher) autoScalingScheduledActions(groupName string) ([]autoScalingScheduledAction, error) {
+	attrs, err := d.storage.ResourceAttributes(groupName)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving auto scaling group attributes: %w", err)
+	}
+	var actions []autoScalingScheduledAction
+	for _, attr := range attrs {
+		name, ok := strings.CutPrefix(attr.Key, attributeNameAutoScalingScheduledActionPrefix)
+		if !ok {
+			continue
+		}
+		var action autoScalingScheduledAction
+		if err := json.Unmarshal([]byte(attr.Value), &action); err != nil {
+			return nil, fmt.Errorf("invalid scheduled action %q: %w", name, err)
+		}
+		action.Name = name
+		actions = append(actions, action)
+	}
+	slices.SortFunc(actions, func(a, b autoScalingScheduledAction) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return actions, nil
+}
+
+func (d *Dispatcher) saveAutoScalingScheduledAction(groupName string, action autoScalingScheduledAction) error {
+	raw, err := json.Marshal(action)
+	if err != nil {
+		return fmt.Errorf("marshaling scheduled action: %w", err)
+	}
+	if err := d.storage.SetResourceAttributes(groupName, []storage.Attribute{
+		{Key: attributeNameAutoScalingScheduledActionPrefix + action.Name, Value: string(raw)},
+	}); err != nil {
+		return fmt.Errorf("saving scheduled action: %w", err)
+	}
+	return nil
+}
+
+func (d *Dispatcher) removeAutoScalingScheduledAction(groupName string, name string) error {
+	if err := d.storage.RemoveResourceAttributes(groupName, []storage.Attribute{
+		{Key: attributeNameAutoScalingScheduledActionPrefix + name},
+	}); err != nil {
+		return fmt.Errorf("removing scheduled action: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package dc2
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+// newScheduledActionTestDispatcher returns a dispatcher whose clock reads
+// *now.
+func newScheduledActionTestDispatcher(t *testing.T, groupName string, now *time.Time) *Dispatcher {
+	t.Helper()
+	d := &Dispatcher{
+		exe:     &exitCleanupExecutor{},
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+		clock:   func() time.Time { return *now },
+	}
+	require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeAutoScalingGroup, ID: groupName}))
+	require.NoError(t, d.saveAutoScalingGroupData(&autoScalingGroupData{
+		Name:               groupName,
+		CreatedTime:        *now,
+		MaxSize:            5,
+		LaunchTemplateID:   "lt-1",
+		LaunchTemplateName: "lt",
+	}))
+	return d
+}
+
+func TestAutoScalingScheduledActionFiresAtStartTime(t *testing.T) {
+	t.Parallel()
+
+	const groupName = "asg"
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	d := newScheduledActionTestDispatcher(t, groupName, &now)
+
+	startTime := now.Add(2 * time.Second)
+	_, err := d.dispatchPutScheduledUpdateGroupAction(ctx, &api.PutScheduledUpdateGroupActionRequest{
+		AutoScalingGroupName: groupName,
+		ScheduledActionName:  "scale-out",
+		StartTime:            &startTime,
+		MinSize:              new(2),
+		DesiredCapacity:      new(3),
+	})
+	require.NoError(t, err)
+	group, err := d.loadAutoScalingGroupData(ctx, groupName)
+	require.NoError(t, err)
+	assert.Equal(t, 0, group.DesiredCapacity)
+
+	describeResp, err := d.dispatchDescribeScheduledActions(ctx, &api.DescribeScheduledActionsRequest{
+		AutoScalingGroupName: new(groupName),
+	})
+	require.NoError(t, err)
+	require.Len(t, describeResp.DescribeScheduledActionsResult.ScheduledUpdateGroupActions, 1)
+
+	// Not due yet
+	now = startTime.Add(-time.Second)
+	require.NoError(t, d.runAllDueAutoScalingScheduledActions(ctx))
+	group, err = d.loadAutoScalingGroupData(ctx, groupName)
+	require.NoError(t, err)
+	assert.Equal(t, 0, group.DesiredCapacity)
+
+	now = startTime
+	require.NoError(t, d.runAllDueAutoScalingScheduledActions(ctx))
+	group, err = d.loadAutoScalingGroupData(ctx, groupName)
+	require.NoError(t, err)
+	assert.Equal(t, 2, group.MinSize)
+	assert.Equal(t, 3, group.DesiredCapacity)
+
+	// One-off actions are removed once they fire.
+	describeResp, err = d.dispatchDescribeScheduledActions(ctx, &api.DescribeScheduledActionsRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, describeResp.DescribeScheduledActionsResult.ScheduledUpdateGroupActions)
+}
+
+func TestAutoScalingScheduledActionInThePastFiresImmediately(t *testing.T) {
+	t.Parallel()
+
+	const groupName = "asg"
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	d := newScheduledActionTestDispatcher(t, groupName, &now)
+
+	_, err := d.dispatchPutScheduledUpdateGroupAction(ctx, &api.PutScheduledUpdateGroupActionRequest{
+		AutoScalingGroupName: groupName,
+		ScheduledActionName:  "scale-out",
+		StartTime:            new(now.Add(-time.Hour)),
+		DesiredCapacity:      new(1),
+	})
+	require.NoError(t, err)
+	group, err := d.loadAutoScalingGroupData(ctx, groupName)
+	require.NoError(t, err)
+	assert.Equal(t, 1, group.DesiredCapacity)
+	actions, err := d.autoScalingScheduledActions(groupName)
+	require.NoError(t, err)
+	assert.Empty(t, actions)
+}
+
+func TestAutoScalingRecurringScheduledActionFiresOncePerMinute(t *testing.T) {
+	t.Parallel()
+
+	const groupName = "asg"
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 12, 0, 30, 0, time.UTC)
+	d := newScheduledActionTestDispatcher(t, groupName, &now)
+
+	// Matches every minute, so it fires when created.
+	_, err := d.dispatchPutScheduledUpdateGroupAction(ctx, &api.PutScheduledUpdateGroupActionRequest{
+		AutoScalingGroupName: groupName,
+		ScheduledActionName:  "every-minute",
+		Recurrence:           new("* * * * *"),
+		DesiredCapacity:      new(1),
+	})
+	require.NoError(t, err)
+	group, err := d.loadAutoScalingGroupData(ctx, groupName)
+	require.NoError(t, err)
+	assert.Equal(t, 1, group.DesiredCapacity)
+
+	group.DesiredCapacity = 0
+	require.NoError(t, d.saveAutoScalingGroupData(group))
+	actions, err := d.autoScalingScheduledActions(groupName)
+	require.NoError(t, err)
+	require.Len(t, actions, 1)
+	lastRun := *actions[0].LastRun
+	assert.Equal(t, now.Truncate(time.Minute), lastRun)
+
+	now = lastRun.Add(59 * time.Second)
+	require.NoError(t, d.runAllDueAutoScalingScheduledActions(ctx))
+	group, err = d.loadAutoScalingGroupData(ctx, groupName)
+	require.NoError(t, err)
+	assert.Equal(t, 0, group.DesiredCapacity)
+
+	now = lastRun.Add(time.Minute)
+	require.NoError(t, d.runAllDueAutoScalingScheduledActions(ctx))
+	group, err = d.loadAutoScalingGroupData(ctx, groupName)
+	require.NoError(t, err)
+	assert.Equal(t, 1, group.DesiredCapacity)
+
+	_, err = d.dispatchDeleteScheduledAction(ctx, &api.DeleteScheduledActionRequest{
+		AutoScalingGroupName: groupName,
+		ScheduledActionName:  "every-minute",
+	})
+	require.NoError(t, err)
+	_, err = d.dispatchDeleteScheduledAction(ctx, &api.DeleteScheduledActionRequest{
+		AutoScalingGroupName: groupName,
+		ScheduledActionName:  "every-minute",
+	})
+	var apiErr *api.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "ValidationError", apiErr.Code)
+}
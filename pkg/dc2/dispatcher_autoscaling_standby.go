@@ -0,0 +1,212 @@
+package dc2
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/executor"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+)
+
+const (
+	attributeNameAutoScalingInstanceStandby = "AutoScalingInstanceStandby"
+
+	autoScalingLifecycleStateStandby = "Standby"
+)
+
+func (d *Dispatcher) dispatchEnterStandby(ctx context.Context, req *api.EnterStandbyRequest) (*api.EnterStandbyResponse, error) {
+	unlock, err := d.lockAutoScalingGroup(ctx, req.AutoScalingGroupName)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	group, err := d.loadAutoScalingGroupData(ctx, req.AutoScalingGroupName)
+	if err != nil {
+		return nil, err
+	}
+	instanceIDs, err := d.autoScalingStandbyRequestInstanceIDs(ctx, group.Name, req.InstanceIDs, false)
+	if err != nil {
+		return nil, err
+	}
+
+	desiredCapacityBefore := group.DesiredCapacity
+	decrementDesiredCapacity := *req.ShouldDecrementDesiredCapacity
+	if decrementDesiredCapacity {
+		group.DesiredCapacity -= len(instanceIDs)
+		if err := validateDesiredCapacity(group.DesiredCapacity, group.MinSize, group.MaxSize); err != nil {
+			return nil, err
+		}
+	}
+	for _, instanceID := range instanceIDs {
+		if err := d.storage.SetResourceAttributes(instanceID, []storage.Attribute{
+			{Key: attributeNameAutoScalingInstanceStandby, Value: "true"},
+		}); err != nil {
+			return nil, fmt.Errorf("marking instance %s as standby: %w", instanceID, err)
+		}
+	}
+	if decrementDesiredCapacity {
+		if err := d.saveAutoScalingGroupData(group); err != nil {
+			return nil, err
+		}
+		// No replacements are launched, so the instances don't need to
+		// keep running while in standby.
+		if _, err := d.stopInstancesWithProfileDelay(ctx, executorInstanceIDs(instanceIDs), false); err != nil {
+			return nil, err
+		}
+	}
+	// Otherwise, the reconciliation loop launches replacements.
+	api.Logger(ctx).Info(
+		"moved auto scaling instances to standby",
+		slog.String("auto_scaling_group_name", group.Name),
+		slog.Any("instance_ids", instanceIDs),
+		slog.Bool("decrement_desired_capacity", decrementDesiredCapacity),
+		slog.Int("desired_capacity_before", desiredCapacityBefore),
+		slog.Int("desired_capacity_after", group.DesiredCapacity),
+	)
+
+	startTime := d.now().UTC()
+	activities := make([]api.AutoScalingActivity, 0, len(instanceIDs))
+	for _, instanceID := range instanceIDs {
+		cause := fmt.Sprintf("At %s instance %s was moved to standby in response to a user request", startTime.Format(time.RFC3339), instanceID)
+		if decrementDesiredCapacity {
+			cause += fmt.Sprintf(", shrinking the capacity from %d to %d", desiredCapacityBefore, group.DesiredCapacity)
+		}
+		activities = append(activities, newAutoScalingActivity(group.Name, "Moving EC2 instance to Standby: "+instanceID, cause+".", startTime))
+	}
+	return &api.EnterStandbyResponse{
+		EnterStandbyResult: api.EnterStandbyResult{Activities: activities},
+	}, nil
+}
+
+func (d *Dispatcher) dispatchExitStandby(ctx context.Context, req *api.ExitStandbyRequest) (*api.ExitStandbyResponse, error) {
+	unlock, err := d.lockAutoScalingGroup(ctx, req.AutoScalingGroupName)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	group, err := d.loadAutoScalingGroupData(ctx, req.AutoScalingGroupName)
+	if err != nil {
+		return nil, err
+	}
+	instanceIDs, err := d.autoScalingStandbyRequestInstanceIDs(ctx, group.Name, req.InstanceIDs, true)
+	if err != nil {
+		return nil, err
+	}
+
+	desiredCapacityBefore := group.DesiredCapacity
+	group.DesiredCapacity += len(instanceIDs)
+	if group.DesiredCapacity > group.MaxSize {
+		return nil, api.ErrWithCode(
+			"ValidationError",
+			fmt.Errorf(
+				"exiting standby would raise DesiredCapacity to %d, above the group MaxSize %d",
+				group.DesiredCapacity,
+				group.MaxSize,
+			),
+		)
+	}
+
+	descs, err := d.exe.DescribeInstances(ctx, executor.DescribeInstancesRequest{
+		InstanceIDs: executorInstanceIDs(instanceIDs),
+	})
+	if err != nil {
+		return nil, executorError(err)
+	}
+	var stopped []executor.InstanceID
+	for _, desc := range descs {
+		if desc.InstanceState.Name == api.InstanceStateStopped.Name {
+			stopped = append(stopped, desc.InstanceID)
+		}
+	}
+	if len(stopped) > 0 {
+		if _, err := d.startInstancesWithProfileDelay(ctx, stopped); err != nil {
+			return nil, err
+		}
+	}
+	for _, instanceID := range instanceIDs {
+		if err := d.storage.RemoveResourceAttributes(instanceID, []storage.Attribute{
+			{Key: attributeNameAutoScalingInstanceStandby},
+		}); err != nil {
+			return nil, fmt.Errorf("removing standby attribute for instance %s: %w", instanceID, err)
+		}
+	}
+	if err := d.saveAutoScalingGroupData(group); err != nil {
+		return nil, err
+	}
+	api.Logger(ctx).Info(
+		"moved auto scaling instances out of standby",
+		slog.String("auto_scaling_group_name", group.Name),
+		slog.Any("instance_ids", instanceIDs),
+		slog.Int("desired_capacity_before", desiredCapacityBefore),
+		slog.Int("desired_capacity_after", group.DesiredCapacity),
+	)
+
+	startTime := d.now().UTC()
+	activities := make([]api.AutoScalingActivity, 0, len(instanceIDs))
+	for _, instanceID := range instanceIDs {
+		cause := fmt.Sprintf(
+			"At %s instance %s was moved out of standby in response to a user request, increasing the capacity from %d to %d.",
+			startTime.Format(time.RFC3339),
+			instanceID,
+			desiredCapacityBefore,
+			group.DesiredCapacity,
+		)
+		activities = append(activities, newAutoScalingActivity(group.Name, "Moving EC2 instance out of Standby: "+instanceID, cause, startTime))
+	}
+	return &api.ExitStandbyResponse{
+		ExitStandbyResult: api.ExitStandbyResult{Activities: activities},
+	}, nil
+}
+
+// autoScalingStandbyRequestInstanceIDs validates the instances in an
+// EnterStandby or ExitStandby request, returning them without duplicates.
+// Instances must belong to the group and be in standby (when standby is true)
+// or in service (otherwise).
+func (d *Dispatcher) autoScalingStandbyRequestInstanceIDs(ctx context.Context, groupName string, requested []string, standby bool) ([]string, error) {
+	groupInstanceIDs, err := d.autoScalingGroupInstanceIDsReadOnly(ctx, groupName)
+	if err != nil {
+		return nil, err
+	}
+	expectedState := autoScalingLifecycleState
+	if standby {
+		expectedState = autoScalingLifecycleStateStandby
+	}
+	instanceIDs := make([]string, 0, len(requested))
+	for _, instanceID := range requested {
+		if slices.Contains(instanceIDs, instanceID) {
+			continue
+		}
+		if !slices.Contains(groupInstanceIDs, instanceID) {
+			return nil, api.ErrWithCode(
+				"ValidationError",
+				fmt.Errorf("instance %q is not part of Auto Scaling group %q", instanceID, groupName),
+			)
+		}
+		attrs, err := d.storage.ResourceAttributes(instanceID)
+		if err != nil {
+			return nil, fmt.Errorf("retrieving instance attributes: %w", err)
+		}
+		if state := autoScalingInstanceLifecycleState(attrs); state != expectedState {
+			return nil, api.ErrWithCode(
+				"ValidationError",
+				fmt.Errorf("instance %q is in %s state, expecting %s", instanceID, state, expectedState),
+			)
+		}
+		instanceIDs = append(instanceIDs, instanceID)
+	}
+	return instanceIDs, nil
+}
+
+func autoScalingInstanceIsStandby(attrs storage.Attributes) bool {
+	value, _ := attrs.Key(attributeNameAutoScalingInstanceStandby)
+	isStandby, err := strconv.ParseBool(value)
+	if err != nil {
+		return false
+	}
+	return isStandby
+}
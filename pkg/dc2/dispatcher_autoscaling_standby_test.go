@@ -0,0 +1,95 @@
+package dc2
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+func TestEnterAndExitStandby(t *testing.T) {
+	t.Parallel()
+
+	const groupName = "asg"
+	instanceIDs := []string{"i-00000000000000001", "i-00000000000000002"}
+	ctx := context.Background()
+	exe := &runningInstancesExecutor{exitCleanupExecutor: &exitCleanupExecutor{}}
+	d := &Dispatcher{
+		exe:     exe,
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+	require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeAutoScalingGroup, ID: groupName}))
+	require.NoError(t, d.saveAutoScalingGroupData(&autoScalingGroupData{
+		Name:               groupName,
+		CreatedTime:        time.Now(),
+		MinSize:            0,
+		MaxSize:            2,
+		DesiredCapacity:    2,
+		LaunchTemplateID:   "lt-1",
+		LaunchTemplateName: "lt",
+	}))
+	for _, instanceID := range instanceIDs {
+		require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeInstance, ID: instanceID}))
+		require.NoError(t, d.storage.SetResourceAttributes(instanceID, []storage.Attribute{
+			{Key: attributeNameAutoScalingGroupName, Value: groupName},
+		}))
+	}
+
+	desiredCapacity := func() int {
+		group, err := d.loadAutoScalingGroupData(ctx, groupName)
+		require.NoError(t, err)
+		return group.DesiredCapacity
+	}
+	lifecycleState := func(instanceID string) string {
+		attrs, err := d.storage.ResourceAttributes(instanceID)
+		require.NoError(t, err)
+		return autoScalingInstanceLifecycleState(attrs)
+	}
+
+	resp, err := d.dispatchEnterStandby(ctx, &api.EnterStandbyRequest{
+		AutoScalingGroupName:           groupName,
+		InstanceIDs:                    []string{instanceIDs[0]},
+		ShouldDecrementDesiredCapacity: new(true),
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.EnterStandbyResult.Activities, 1)
+	assert.Equal(t, "Moving EC2 instance to Standby: "+instanceIDs[0], *resp.EnterStandbyResult.Activities[0].Description)
+	assert.Equal(t, 1, desiredCapacity())
+	assert.Equal(t, autoScalingLifecycleStateStandby, lifecycleState(instanceIDs[0]))
+
+	// The standby instance no longer counts towards the desired capacity,
+	// so the group doesn't need a replacement.
+	managedInstanceIDs, err := d.autoScalingGroupManagedInstanceIDs(ctx, groupName)
+	require.NoError(t, err)
+	assert.Equal(t, []string{instanceIDs[1]}, managedInstanceIDs)
+
+	// Entering standby twice is rejected.
+	_, err = d.dispatchEnterStandby(ctx, &api.EnterStandbyRequest{
+		AutoScalingGroupName:           groupName,
+		InstanceIDs:                    []string{instanceIDs[0]},
+		ShouldDecrementDesiredCapacity: new(true),
+	})
+	var apiErr *api.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "ValidationError", apiErr.Code)
+
+	_, err = d.dispatchExitStandby(ctx, &api.ExitStandbyRequest{
+		AutoScalingGroupName: groupName,
+		InstanceIDs:          []string{instanceIDs[0]},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, desiredCapacity())
+	assert.Equal(t, autoScalingLifecycleState, lifecycleState(instanceIDs[0]))
+
+	managedInstanceIDs, err = d.autoScalingGroupManagedInstanceIDs(ctx, groupName)
+	require.NoError(t, err)
+	assert.Equal(t, instanceIDs, managedInstanceIDs)
+	assert.Empty(t, exe.terminateReqs)
+}
@@ -0,0 +1,106 @@
+package dc2
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+)
+
+// validateAutoScalingPlacement checks that every subnet in a group
+// VPCZoneIdentifier exists.
+func (d *Dispatcher) validateAutoScalingPlacement(vpcZoneIdentifier *string, _ []string) error {
+	group := &autoScalingGroupData{VPCZoneIdentifier: vpcZoneIdentifier}
+	for _, subnetID := range autoScalingGroupSubnetIDs(group) {
+		subnet, err := d.findSubnet(subnetID)
+		if err != nil {
+			return err
+		}
+		if subnet == nil {
+			return api.ValidationError("VPCZoneIdentifier", "The subnet ID '%s' does not exist", subnetID)
+		}
+	}
+	return nil
+}
+
+// autoScalingLaunchPlacement is the number of instances to launch into a
+// subnet.
+type autoScalingLaunchPlacement struct {
+	AvailabilityZone string
+	SubnetID         string
+	Count            int
+}
+
+// autoScalingLaunchPlacements spreads count new instances across the group
+// subnets, filling the subnets with the fewest of instanceIDs first, and
+// resolves the availability zone of each subnet.
+func (d *Dispatcher) autoScalingLaunchPlacements(group *autoScalingGroupData, count int, instanceIDs []string) ([]autoScalingLaunchPlacement, error) {
+	subnetIDs := autoScalingGroupSubnetIDs(group)
+	if len(subnetIDs) == 0 {
+		subnetIDs = []string{autoScalingInstanceSubnetID(group)}
+	}
+	subnetCounts := make(map[string]int, len(subnetIDs))
+	for _, instanceID := range instanceIDs {
+		attrs, err := d.storage.ResourceAttributes(instanceID)
+		if err != nil {
+			return nil, fmt.Errorf("retrieving instance attributes: %w", err)
+		}
+		subnetID, _ := attrs.Key(attributeNameSubnetID)
+		subnetCounts[subnetID]++
+	}
+	launchCounts := make(map[string]int, len(subnetIDs))
+	for range count {
+		// Ties go to the subnet listed first in VPCZoneIdentifier
+		target := slices.MinFunc(subnetIDs, func(a, b string) int {
+			return (subnetCounts[a] + launchCounts[a]) - (subnetCounts[b] + launchCounts[b])
+		})
+		launchCounts[target]++
+	}
+
+	var placements []autoScalingLaunchPlacement
+	for _, subnetID := range subnetIDs {
+		if launchCounts[subnetID] == 0 {
+			continue
+		}
+		_, availabilityZone, err := d.subnetPlacement(subnetID)
+		if err != nil {
+			return nil, err
+		}
+		if availabilityZone == "" {
+			availabilityZone = d.autoScalingGroupLaunchAvailabilityZone(group)
+		}
+		placements = append(placements, autoScalingLaunchPlacement{
+			AvailabilityZone: availabilityZone,
+			SubnetID:         subnetID,
+			Count:            launchCounts[subnetID],
+		})
+	}
+	return placements, nil
+}
+
+// alignLaunchInstancesPlacement makes the zone and the subnet of a
+// LaunchInstances placement agree. A requested subnet determines the zone,
+// while a requested zone selects the group subnet in it.
+func (d *Dispatcher) alignLaunchInstancesPlacement(group *autoScalingGroupData, placement launchInstancesPlacement, subnetRequested bool) (launchInstancesPlacement, error) {
+	if !subnetRequested {
+		for _, subnetID := range autoScalingGroupSubnetIDs(group) {
+			_, availabilityZone, err := d.subnetPlacement(subnetID)
+			if err != nil {
+				return launchInstancesPlacement{}, err
+			}
+			if availabilityZone == placement.AvailabilityZone {
+				placement.SubnetID = subnetID
+				return placement, nil
+			}
+		}
+	}
+	_, availabilityZone, err := d.subnetPlacement(placement.SubnetID)
+	if err != nil {
+		return launchInstancesPlacement{}, err
+	}
+	if availabilityZone != "" {
+		placement.AvailabilityZone = availabilityZone
+		placement.AvailabilityZoneID = availabilityZoneIDFromName(availabilityZone, d.opts.Region)
+	}
+	return placement, nil
+}
@@ -0,0 +1,90 @@
+package dc2
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+func TestTerminateInstanceInAutoScalingGroup(t *testing.T) {
+	t.Parallel()
+
+	const groupName = "asg"
+	instanceIDs := []string{"i-00000000000000001", "i-00000000000000002", "i-00000000000000003"}
+	ctx := context.Background()
+	exe := &runningInstancesExecutor{exitCleanupExecutor: &exitCleanupExecutor{}}
+	d := &Dispatcher{
+		exe:     exe,
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+	require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeAutoScalingGroup, ID: groupName}))
+	require.NoError(t, d.saveAutoScalingGroupData(&autoScalingGroupData{
+		Name:               groupName,
+		CreatedTime:        time.Now(),
+		MinSize:            2,
+		MaxSize:            5,
+		DesiredCapacity:    3,
+		LaunchTemplateID:   "lt-1",
+		LaunchTemplateName: "lt",
+	}))
+	for _, instanceID := range instanceIDs {
+		require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeInstance, ID: instanceID}))
+		require.NoError(t, d.storage.SetResourceAttributes(instanceID, []storage.Attribute{
+			{Key: attributeNameAutoScalingGroupName, Value: groupName},
+		}))
+	}
+
+	desiredCapacity := func() int {
+		group, err := d.loadAutoScalingGroupData(ctx, groupName)
+		require.NoError(t, err)
+		return group.DesiredCapacity
+	}
+
+	// Without decrementing, the desired capacity is kept so reconciliation
+	// launches a replacement.
+	resp, err := d.dispatchTerminateInstanceInAutoScalingGroup(ctx, &api.TerminateInstanceInAutoScalingGroupRequest{
+		InstanceID:                     instanceIDs[0],
+		ShouldDecrementDesiredCapacity: new(false),
+	})
+	require.NoError(t, err)
+	activity := resp.TerminateInstanceInAutoScalingGroupResult.Activity
+	require.NotNil(t, activity)
+	assert.Equal(t, groupName, *activity.AutoScalingGroupName)
+	assert.Equal(t, "Terminating EC2 instance: "+instanceIDs[0], *activity.Description)
+	assert.Len(t, exe.terminateReqs, 1)
+	assert.Equal(t, 3, desiredCapacity())
+
+	resp, err = d.dispatchTerminateInstanceInAutoScalingGroup(ctx, &api.TerminateInstanceInAutoScalingGroupRequest{
+		InstanceID:                     instanceIDs[1],
+		ShouldDecrementDesiredCapacity: new(true),
+	})
+	require.NoError(t, err)
+	assert.Contains(t, *resp.TerminateInstanceInAutoScalingGroupResult.Activity.Cause, "shrinking the capacity from 3 to 2")
+	assert.Len(t, exe.terminateReqs, 2)
+	assert.Equal(t, 2, desiredCapacity())
+
+	// Decrementing below MinSize is rejected and the instance is kept.
+	_, err = d.dispatchTerminateInstanceInAutoScalingGroup(ctx, &api.TerminateInstanceInAutoScalingGroupRequest{
+		InstanceID:                     instanceIDs[2],
+		ShouldDecrementDesiredCapacity: new(true),
+	})
+	require.Error(t, err)
+	assert.Len(t, exe.terminateReqs, 2)
+	assert.Equal(t, 2, desiredCapacity())
+
+	_, err = d.dispatchTerminateInstanceInAutoScalingGroup(ctx, &api.TerminateInstanceInAutoScalingGroupRequest{
+		InstanceID:                     "i-00000000000000004",
+		ShouldDecrementDesiredCapacity: new(false),
+	})
+	var apiErr *api.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "ValidationError", apiErr.Code)
+}
@@ -0,0 +1,41 @@
+package dc2
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+)
+
+func TestAutoScalingValidationErrorsNameParameter(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		err       error
+		parameter string
+	}{
+		{"negative min size", validateAutoScalingGroupSizes(-1, 1), "MinSize"},
+		{"negative max size", validateAutoScalingGroupSizes(0, -1), "MaxSize"},
+		{"min size above max size", validateAutoScalingGroupSizes(3, 2), "MinSize"},
+		{"negative grace period", validateHealthCheckGracePeriod(-1), "HealthCheckGracePeriod"},
+		{"desired capacity below min size", validateDesiredCapacity(0, 1, 3), "DesiredCapacity"},
+		{"desired capacity above max size", validateDesiredCapacity(4, 1, 3), "DesiredCapacity"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var apiErr *api.Error
+			require.ErrorAs(t, tc.err, &apiErr)
+			assert.Equal(t, api.ErrorCodeValidationError, apiErr.Code)
+			assert.Equal(t, tc.parameter, apiErr.Parameter)
+			assert.Contains(t, apiErr.Error(), tc.parameter)
+		})
+	}
+
+	require.NoError(t, validateAutoScalingGroupSizes(1, 3))
+	require.NoError(t, validateHealthCheckGracePeriod(0))
+	require.NoError(t, validateDesiredCapacity(2, 1, 3))
+}
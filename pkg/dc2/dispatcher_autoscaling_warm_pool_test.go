@@ -0,0 +1,71 @@
+package dc2
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutoScalingWarmPoolTargetCapacity(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name                     string
+		maxSize                  int
+		desiredCapacity          int
+		minSize                  int
+		maxGroupPreparedCapacity *int
+		want                     int
+	}{
+		{
+			name:            "defaults to max size",
+			maxSize:         5,
+			desiredCapacity: 2,
+			want:            3,
+		},
+		{
+			name:                     "max group prepared capacity",
+			maxSize:                  5,
+			desiredCapacity:          2,
+			maxGroupPreparedCapacity: new(4),
+			want:                     2,
+		},
+		{
+			name:                     "min size when above prepared capacity gap",
+			maxSize:                  5,
+			desiredCapacity:          1,
+			minSize:                  3,
+			maxGroupPreparedCapacity: new(3),
+			want:                     3,
+		},
+		{
+			// MinSize+DesiredCapacity exceeds MaxGroupPreparedCapacity, MinSize
+			// wins and the group holds 4 prepared instances.
+			name:                     "min size takes precedence over max group prepared capacity",
+			maxSize:                  5,
+			desiredCapacity:          2,
+			minSize:                  2,
+			maxGroupPreparedCapacity: new(2),
+			want:                     2,
+		},
+		{
+			name:                     "desired capacity above max group prepared capacity",
+			maxSize:                  5,
+			desiredCapacity:          4,
+			maxGroupPreparedCapacity: new(2),
+			want:                     0,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			group := &autoScalingGroupData{
+				MaxSize:                          tc.maxSize,
+				DesiredCapacity:                  tc.desiredCapacity,
+				WarmPoolMinSize:                  tc.minSize,
+				WarmPoolMaxGroupPreparedCapacity: tc.maxGroupPreparedCapacity,
+			}
+			assert.Equal(t, tc.want, autoScalingWarmPoolTargetCapacity(group))
+		})
+	}
+}
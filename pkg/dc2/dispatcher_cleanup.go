@@ -0,0 +1,132 @@
+package dc2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+// CleanupResult lists the resources removed by CleanupTagged.
+type CleanupResult struct {
+	AutoScalingGroupNames []string `json:"autoScalingGroupNames"`
+	InstanceIDs           []string `json:"instanceIds"`
+	LaunchTemplateIDs     []string `json:"launchTemplateIds"`
+}
+
+// CleanupTagged deletes the auto scaling groups, terminates the instances and
+// deletes the launch templates that have all the given tags. An empty tag
+// value matches any value. Groups are deleted first, so their instances are
+// terminated without being replaced.
+func (d *Dispatcher) CleanupTagged(ctx context.Context, tags map[string]string) (*CleanupResult, error) {
+	if len(tags) == 0 {
+		return nil, errors.New("at least one tag is required")
+	}
+	filters := make([]api.Filter, 0, len(tags))
+	for key, value := range tags {
+		if value == "" {
+			filters = append(filters, api.Filter{Name: new("tag-key"), Values: []string{key}})
+			continue
+		}
+		filters = append(filters, api.Filter{Name: new("tag:" + key), Values: []string{value}})
+	}
+
+	d.dispatchMu.Lock()
+	defer d.dispatchMu.Unlock()
+
+	result := &CleanupResult{}
+	groupNames, err := d.applyFilters(types.ResourceTypeAutoScalingGroup, nil, filters)
+	if err != nil {
+		return nil, err
+	}
+	for _, groupName := range groupNames {
+		deleted, err := d.cleanupAutoScalingGroup(ctx, groupName)
+		if err != nil {
+			return nil, fmt.Errorf("deleting auto scaling group %s: %w", groupName, err)
+		}
+		if deleted {
+			result.AutoScalingGroupNames = append(result.AutoScalingGroupNames, groupName)
+		}
+	}
+
+	instanceIDs, err := d.applyFilters(types.ResourceTypeInstance, nil, filters)
+	if err != nil {
+		return nil, err
+	}
+	// Terminated instances stay registered for a while, skip them
+	instanceIDs, err = d.withoutTerminatedInstances(instanceIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(instanceIDs) > 0 {
+		if _, err := d.dispatchTerminateInstances(ctx, &api.TerminateInstancesRequest{InstanceIDs: instanceIDs}); err != nil {
+			return nil, fmt.Errorf("terminating instances: %w", err)
+		}
+		result.InstanceIDs = instanceIDs
+	}
+
+	launchTemplateIDs, err := d.applyFilters(types.ResourceTypeLaunchTemplate, nil, filters)
+	if err != nil {
+		return nil, err
+	}
+	for _, launchTemplateID := range launchTemplateIDs {
+		if _, err := d.dispatchDeleteLaunchTemplate(ctx, &api.DeleteLaunchTemplateRequest{LaunchTemplateID: &launchTemplateID}); err != nil {
+			return nil, fmt.Errorf("deleting launch template %s: %w", launchTemplateID, err)
+		}
+		result.LaunchTemplateIDs = append(result.LaunchTemplateIDs, launchTemplateID)
+	}
+
+	api.Logger(ctx).Info(
+		"cleaned up tagged resources",
+		slog.Any("auto_scaling_group_names", result.AutoScalingGroupNames),
+		slog.Any("instance_ids", result.InstanceIDs),
+		slog.Any("launch_template_ids", result.LaunchTemplateIDs),
+	)
+	return result, nil
+}
+
+// cleanupAutoScalingGroup deletes the group while holding its scaling lock.
+// It reports false if the group was deleted while waiting for the lock.
+func (d *Dispatcher) cleanupAutoScalingGroup(ctx context.Context, groupName string) (bool, error) {
+	d.cancelWarmPoolDeleteJob(groupName)
+	unlock, err := d.lockAutoScalingGroup(ctx, groupName)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+	if _, err := d.findResource(ctx, types.ResourceTypeAutoScalingGroup, groupName); err != nil {
+		if errors.As(err, &storage.ErrResourceNotFound{}) {
+			return false, nil
+		}
+		return false, err
+	}
+	if err := d.deleteAutoScalingGroup(ctx, groupName); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (d *Dispatcher) withoutTerminatedInstances(instanceIDs []string) ([]string, error) {
+	var terminated []string
+	for _, instanceID := range instanceIDs {
+		attrs, err := d.storage.ResourceAttributes(instanceID)
+		if err != nil {
+			if errors.As(err, &storage.ErrResourceNotFound{}) {
+				terminated = append(terminated, instanceID)
+				continue
+			}
+			return nil, fmt.Errorf("retrieving instance attributes: %w", err)
+		}
+		if terminatedAt, _ := attrs.Key(attributeNameInstanceTerminatedAt); terminatedAt != "" {
+			terminated = append(terminated, instanceID)
+		}
+	}
+	return slices.DeleteFunc(instanceIDs, func(id string) bool {
+		return slices.Contains(terminated, id)
+	}), nil
+}
@@ -0,0 +1,101 @@
+package dc2
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/executor"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+func TestCleanupTagged(t *testing.T) {
+	t.Parallel()
+
+	const (
+		groupName          = "asg"
+		groupInstanceID    = "i-00000000000000001"
+		taggedInstanceID   = "i-00000000000000002"
+		untaggedInstanceID = "i-00000000000000003"
+		// Terminated instances stay registered for a while
+		terminatedInstanceID = "i-00000000000000004"
+	)
+	ctx := context.Background()
+	exe := &runningInstancesExecutor{exitCleanupExecutor: &exitCleanupExecutor{}}
+	d := &Dispatcher{
+		exe:     exe,
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+	ciTag := storage.Attribute{Key: storage.TagAttributeName("suite"), Value: "ci"}
+	require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeAutoScalingGroup, ID: groupName}))
+	require.NoError(t, d.saveAutoScalingGroupData(&autoScalingGroupData{
+		Name:               groupName,
+		CreatedTime:        time.Now(),
+		MaxSize:            1,
+		DesiredCapacity:    1,
+		LaunchTemplateID:   "lt-1",
+		LaunchTemplateName: "lt",
+	}))
+	require.NoError(t, d.storage.SetResourceAttributes(groupName, []storage.Attribute{ciTag}))
+	for _, instanceID := range []string{groupInstanceID, taggedInstanceID, untaggedInstanceID, terminatedInstanceID} {
+		require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeInstance, ID: instanceID}))
+	}
+	require.NoError(t, d.storage.SetResourceAttributes(groupInstanceID, []storage.Attribute{
+		{Key: attributeNameAutoScalingGroupName, Value: groupName},
+		ciTag,
+	}))
+	require.NoError(t, d.storage.SetResourceAttributes(taggedInstanceID, []storage.Attribute{ciTag}))
+	require.NoError(t, d.storage.SetResourceAttributes(terminatedInstanceID, []storage.Attribute{
+		{Key: attributeNameInstanceTerminatedAt, Value: time.Now().UTC().Format(time.RFC3339Nano)},
+		ciTag,
+	}))
+	launchTemplate, err := d.dispatchCreateLaunchTemplate(ctx, &api.CreateLaunchTemplateRequest{
+		LaunchTemplateName: "lt",
+		LaunchTemplateData: api.LaunchTemplateData{ImageID: "nginx", InstanceType: "t3.micro"},
+	})
+	require.NoError(t, err)
+	launchTemplateID := *launchTemplate.LaunchTemplate.LaunchTemplateID
+	require.NoError(t, d.storage.SetResourceAttributes(launchTemplateID, []storage.Attribute{ciTag}))
+
+	_, err = d.CleanupTagged(ctx, nil)
+	require.Error(t, err)
+
+	result, err := d.CleanupTagged(ctx, map[string]string{"suite": "nightly"})
+	require.NoError(t, err)
+	assert.Equal(t, &CleanupResult{}, result)
+
+	// Resources must have all the tags, and an empty value matches any
+	// value
+	result, err = d.CleanupTagged(ctx, map[string]string{"suite": "", "owner": ""})
+	require.NoError(t, err)
+	assert.Equal(t, &CleanupResult{}, result)
+
+	result, err = d.CleanupTagged(ctx, map[string]string{"suite": "ci"})
+	require.NoError(t, err)
+	assert.Equal(t, &CleanupResult{
+		AutoScalingGroupNames: []string{groupName},
+		InstanceIDs:           []string{taggedInstanceID},
+		LaunchTemplateIDs:     []string{launchTemplateID},
+	}, result)
+
+	var terminatedIDs []executor.InstanceID
+	for _, req := range exe.terminateReqs {
+		terminatedIDs = append(terminatedIDs, req.InstanceIDs...)
+	}
+	assert.ElementsMatch(t, []executor.InstanceID{
+		executorInstanceID(groupInstanceID),
+		executorInstanceID(taggedInstanceID),
+	}, terminatedIDs)
+	groups, err := d.storage.RegisteredResources(types.ResourceTypeAutoScalingGroup)
+	require.NoError(t, err)
+	assert.Empty(t, groups)
+	launchTemplates, err := d.storage.RegisteredResources(types.ResourceTypeLaunchTemplate)
+	require.NoError(t, err)
+	assert.Empty(t, launchTemplates)
+}
@@ -17,6 +17,7 @@ type ownedResourceLeakReport struct {
 	instances         []string
 	launchTemplates   []string
 	spotRequests      []string
+	keyPairs          []string
 	ownedContainers   []string
 	volumes           []string
 }
@@ -26,6 +27,7 @@ func (r ownedResourceLeakReport) empty() bool {
 		len(r.instances) == 0 &&
 		len(r.launchTemplates) == 0 &&
 		len(r.spotRequests) == 0 &&
+		len(r.keyPairs) == 0 &&
 		len(r.ownedContainers) == 0 &&
 		len(r.volumes) == 0
 }
@@ -47,6 +49,9 @@ func (r ownedResourceLeakReport) String() string {
 	if len(r.spotRequests) > 0 {
 		parts = append(parts, fmt.Sprintf("spot-instance-requests=[%s]", strings.Join(r.spotRequests, ",")))
 	}
+	if len(r.keyPairs) > 0 {
+		parts = append(parts, fmt.Sprintf("key-pairs=[%s]", strings.Join(r.keyPairs, ",")))
+	}
 	if len(r.volumes) > 0 {
 		parts = append(parts, fmt.Sprintf("volumes=[%s]", strings.Join(r.volumes, ",")))
 	}
@@ -75,12 +80,39 @@ func (d *Dispatcher) cleanupOwnedResources(ctx context.Context) error {
 	if err := d.removeAllResourcesOfType(ctx, types.ResourceTypeVolume); err != nil {
 		cleanupErr = errors.Join(cleanupErr, err)
 	}
+	if err := d.removeAllResourcesOfType(ctx, types.ResourceTypeSnapshot); err != nil {
+		cleanupErr = errors.Join(cleanupErr, err)
+	}
+	if err := d.deleteCreatedImages(ctx); err != nil {
+		cleanupErr = errors.Join(cleanupErr, err)
+	}
+	if err := d.removeAllResourcesOfType(ctx, types.ResourceTypeImage); err != nil {
+		cleanupErr = errors.Join(cleanupErr, err)
+	}
 	if err := d.removeAllResourcesOfType(ctx, types.ResourceTypeLaunchTemplate); err != nil {
 		cleanupErr = errors.Join(cleanupErr, err)
 	}
 	if err := d.removeAllResourcesOfType(ctx, types.ResourceTypeSpotInstancesRequest); err != nil {
 		cleanupErr = errors.Join(cleanupErr, err)
 	}
+	if err := d.removeAllResourcesOfType(ctx, types.ResourceTypeKeyPair); err != nil {
+		cleanupErr = errors.Join(cleanupErr, err)
+	}
+	if err := d.removeAllResourcesOfType(ctx, types.ResourceTypePlacementGroup); err != nil {
+		cleanupErr = errors.Join(cleanupErr, err)
+	}
+	if err := d.removeAllResourcesOfType(ctx, types.ResourceTypeAddress); err != nil {
+		cleanupErr = errors.Join(cleanupErr, err)
+	}
+	if err := d.removeAllResourcesOfType(ctx, types.ResourceTypeNetworkInterface); err != nil {
+		cleanupErr = errors.Join(cleanupErr, err)
+	}
+	if err := d.removeAllResourcesOfType(ctx, types.ResourceTypeSubnet); err != nil {
+		cleanupErr = errors.Join(cleanupErr, err)
+	}
+	if err := d.removeAllResourcesOfType(ctx, types.ResourceTypeVpc); err != nil {
+		cleanupErr = errors.Join(cleanupErr, err)
+	}
 	if err := d.assertNoOwnedResources(ctx); err != nil {
 		cleanupErr = errors.Join(cleanupErr, err)
 	}
@@ -230,6 +262,14 @@ func (d *Dispatcher) ownedResourceLeakReport(ctx context.Context) (ownedResource
 		report.spotRequests = append(report.spotRequests, resource.ID)
 	}
 
+	keyPairs, err := d.storage.RegisteredResources(types.ResourceTypeKeyPair)
+	if err != nil {
+		return report, fmt.Errorf("listing key pairs for exit verification: %w", err)
+	}
+	for _, resource := range keyPairs {
+		report.keyPairs = append(report.keyPairs, resource.ID)
+	}
+
 	volumes, err := d.storage.RegisteredResources(types.ResourceTypeVolume)
 	if err != nil {
 		return report, fmt.Errorf("listing volumes for exit verification: %w", err)
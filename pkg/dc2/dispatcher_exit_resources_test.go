@@ -25,6 +25,10 @@ func (e *exitCleanupExecutor) Disconnect() error {
 	return nil
 }
 
+func (e *exitCleanupExecutor) Ping(context.Context) error {
+	return nil
+}
+
 func (e *exitCleanupExecutor) ListOwnedInstances(context.Context) ([]executor.InstanceID, error) {
 	return append([]executor.InstanceID(nil), e.owned...), nil
 }
@@ -45,6 +49,14 @@ func (e *exitCleanupExecutor) StopInstances(context.Context, executor.StopInstan
 	return nil, nil
 }
 
+func (e *exitCleanupExecutor) RebootInstances(context.Context, executor.RebootInstancesRequest) error {
+	return nil
+}
+
+func (e *exitCleanupExecutor) ModifyInstanceAttribute(context.Context, executor.ModifyInstanceAttributeRequest) error {
+	return nil
+}
+
 func (e *exitCleanupExecutor) TerminateInstances(
 	_ context.Context,
 	req executor.TerminateInstancesRequest,
@@ -58,6 +70,10 @@ func (e *exitCleanupExecutor) TerminateInstances(
 	return nil, nil
 }
 
+func (e *exitCleanupExecutor) InstanceConsoleOutput(context.Context, executor.InstanceID) (executor.InstanceConsoleOutput, error) {
+	return executor.InstanceConsoleOutput{}, nil
+}
+
 func (e *exitCleanupExecutor) CreateVolume(context.Context, executor.CreateVolumeRequest) (executor.VolumeID, error) {
 	return "", nil
 }
@@ -78,6 +94,42 @@ func (e *exitCleanupExecutor) DetachVolume(context.Context, executor.DetachVolum
 	return nil, assert.AnError
 }
 
+func (e *exitCleanupExecutor) ResizeVolume(context.Context, executor.ResizeVolumeRequest) error {
+	return nil
+}
+
+func (e *exitCleanupExecutor) CreateSnapshot(context.Context, executor.CreateSnapshotRequest) (executor.SnapshotID, error) {
+	return "", nil
+}
+
+func (e *exitCleanupExecutor) DeleteSnapshot(context.Context, executor.DeleteSnapshotRequest) error {
+	return nil
+}
+
+func (e *exitCleanupExecutor) CopySnapshot(context.Context, executor.CopySnapshotRequest) (executor.SnapshotID, error) {
+	return "", nil
+}
+
+func (e *exitCleanupExecutor) DescribeSnapshots(context.Context, executor.DescribeSnapshotsRequest) ([]executor.SnapshotDescription, error) {
+	return nil, nil
+}
+
+func (e *exitCleanupExecutor) CreateImage(context.Context, executor.CreateImageRequest) error {
+	return nil
+}
+
+func (e *exitCleanupExecutor) DeleteImage(context.Context, executor.DeleteImageRequest) error {
+	return nil
+}
+
+func (e *exitCleanupExecutor) CopyImage(context.Context, executor.CopyImageRequest) error {
+	return nil
+}
+
+func (e *exitCleanupExecutor) ListImages(context.Context) ([]executor.ImageDescription, error) {
+	return nil, nil
+}
+
 func TestCleanupOwnedInstanceContainersUsesForceTerminate(t *testing.T) {
 	t.Parallel()
 
@@ -75,7 +75,7 @@ func validateCreateFleetType(rawType string) error {
 
 func validateCreateFleetTargetCapacity(spec *api.TargetCapacitySpecificationRequest) (int, error) {
 	if spec == nil || spec.TotalTargetCapacity == nil {
-		return 0, api.ErrWithCode("ValidationError", fmt.Errorf("TargetCapacitySpecification.TotalTargetCapacity is required"))
+		return 0, api.ValidationError("TargetCapacitySpecification.TotalTargetCapacity", "TargetCapacitySpecification.TotalTargetCapacity is required")
 	}
 	if *spec.TotalTargetCapacity <= 0 {
 		return 0, api.InvalidParameterValueError(
@@ -117,7 +117,7 @@ func (d *Dispatcher) createFleetRunInstancesRequest(
 ) (*api.RunInstancesRequest, *api.LaunchTemplateAndOverridesResponse, error) {
 	config := req.LaunchTemplateConfigs[0]
 	if config.LaunchTemplateSpecification == nil {
-		return nil, nil, api.ErrWithCode("ValidationError", fmt.Errorf("LaunchTemplateConfigs.1.LaunchTemplateSpecification is required"))
+		return nil, nil, api.ValidationError("LaunchTemplateConfigs.1.LaunchTemplateSpecification", "LaunchTemplateConfigs.1.LaunchTemplateSpecification is required")
 	}
 	if len(config.Overrides) > 1 {
 		return nil, nil, api.InvalidParameterValueError(
@@ -0,0 +1,498 @@
+package dc2
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/executor"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+const (
+	attributeNameImageName             = "ImageName"
+	attributeNameImageDescription      = "ImageDescription"
+	attributeNameImageSourceInstanceID = "ImageSourceInstanceID"
+	attributeNameImageArchitecture     = "ImageArchitecture"
+	attributeNameImagePlatform         = "ImagePlatform"
+	attributeNameImageCreationDate     = "ImageCreationDate"
+
+	imageIDPrefix = "ami-"
+
+	imageStateAvailable     = "available"
+	imageTypeMachine        = "machine"
+	imageRootDeviceTypeEBS  = "ebs"
+	imageVirtualizationType = "hvm"
+	imageHypervisor         = "xen"
+
+	imageOwnerSelf = "self"
+	// registryImageOwnerAlias is the owner alias of the images present in
+	// the Docker host, which can be launched by any account.
+	registryImageOwnerAlias = "amazon"
+	registryImageOwnerID    = "137112412989"
+
+	imageCreationDateLayout = "2006-01-02T15:04:05.000Z"
+)
+
+// imageNamePattern matches the names accepted by CreateImage.
+var imageNamePattern = regexp.MustCompile(`^[a-zA-Z0-9()\[\] ./\-'@_]{3,128}$`)
+
+func (d *Dispatcher) dispatchCreateImage(ctx context.Context, req *api.CreateImageRequest) (*api.CreateImageResponse, error) {
+	if err := validateTagSpecifications(req.TagSpecifications, types.ResourceTypeImage); err != nil {
+		return nil, err
+	}
+	if _, err := d.findInstance(ctx, req.InstanceID); err != nil {
+		return nil, err
+	}
+	running, err := d.withoutTerminatedInstances([]string{req.InstanceID})
+	if err != nil {
+		return nil, err
+	}
+	if len(running) == 0 {
+		return nil, api.ErrWithCode(api.ErrorCodeIncorrectInstanceState, fmt.Errorf("instance %s is terminated", req.InstanceID))
+	}
+	if err := d.validateNewImageName(req.Name); err != nil {
+		return nil, err
+	}
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
+
+	descriptions, err := d.exe.DescribeInstances(ctx, executor.DescribeInstancesRequest{
+		InstanceIDs: []executor.InstanceID{executorInstanceID(req.InstanceID)},
+	})
+	if err != nil {
+		return nil, executorError(err)
+	}
+	if len(descriptions) == 0 {
+		return nil, api.InvalidParameterValueError("InstanceId", req.InstanceID)
+	}
+	id, err := makeID(imageIDPrefix)
+	if err != nil {
+		return nil, err
+	}
+	// Committing the instance is synchronous, so the image is available
+	// right away.
+	if err := d.exe.CreateImage(ctx, executor.CreateImageRequest{
+		InstanceID: executorInstanceID(req.InstanceID),
+		ImageID:    id,
+	}); err != nil {
+		return nil, executorError(err)
+	}
+
+	attrs := []storage.Attribute{
+		{Key: attributeNameImageName, Value: req.Name},
+		{Key: attributeNameImageSourceInstanceID, Value: req.InstanceID},
+		{Key: attributeNameImageArchitecture, Value: descriptions[0].Architecture},
+		{Key: attributeNameImagePlatform, Value: descriptions[0].Platform},
+		{Key: attributeNameImageCreationDate, Value: time.Now().UTC().Format(time.RFC3339Nano)},
+	}
+	if req.Description != "" {
+		attrs = append(attrs, storage.Attribute{Key: attributeNameImageDescription, Value: req.Description})
+	}
+	for _, spec := range req.TagSpecifications {
+		for _, tag := range spec.Tags {
+			attrs = append(attrs, storage.Attribute{Key: storage.TagAttributeName(tag.Key), Value: tag.Value})
+		}
+	}
+	if err := d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeImage, ID: id}); err != nil {
+		return nil, fmt.Errorf("registering image %s: %w", id, err)
+	}
+	if err := d.storage.SetResourceAttributes(id, attrs); err != nil {
+		return nil, fmt.Errorf("storing image attributes: %w", err)
+	}
+	api.Logger(ctx).Info(
+		"created image",
+		slog.String("image_id", id),
+		slog.String("instance_id", req.InstanceID),
+		slog.String("name", req.Name),
+	)
+	return &api.CreateImageResponse{ImageID: id}, nil
+}
+
+func (d *Dispatcher) dispatchCopyImage(ctx context.Context, req *api.CopyImageRequest) (*api.CopyImageResponse, error) {
+	if err := validateTagSpecifications(req.TagSpecifications, types.ResourceTypeImage); err != nil {
+		return nil, err
+	}
+	if req.SourceRegion != d.opts.Region {
+		return nil, api.InvalidParameterValueError("SourceRegion", req.SourceRegion)
+	}
+	source, err := d.imageCopySource(ctx, req.SourceImageID)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.validateNewImageName(req.Name); err != nil {
+		return nil, err
+	}
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
+
+	id, err := makeID(imageIDPrefix)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.exe.CopyImage(ctx, executor.CopyImageRequest{
+		SourceImageID: req.SourceImageID,
+		ImageID:       id,
+	}); err != nil {
+		return nil, executorError(err)
+	}
+
+	attrs := []storage.Attribute{
+		{Key: attributeNameImageName, Value: req.Name},
+		{Key: attributeNameImageArchitecture, Value: source.architecture},
+		{Key: attributeNameImagePlatform, Value: source.platform},
+		{Key: attributeNameImageCreationDate, Value: time.Now().UTC().Format(time.RFC3339Nano)},
+	}
+	if source.instanceID != "" {
+		attrs = append(attrs, storage.Attribute{Key: attributeNameImageSourceInstanceID, Value: source.instanceID})
+	}
+	if description := cmp.Or(req.Description, source.description); description != "" {
+		attrs = append(attrs, storage.Attribute{Key: attributeNameImageDescription, Value: description})
+	}
+	if req.CopyImageTags {
+		for _, tag := range source.tags {
+			attrs = append(attrs, storage.Attribute{Key: storage.TagAttributeName(tag.Key), Value: tag.Value})
+		}
+	}
+	for _, spec := range req.TagSpecifications {
+		for _, tag := range spec.Tags {
+			attrs = append(attrs, storage.Attribute{Key: storage.TagAttributeName(tag.Key), Value: tag.Value})
+		}
+	}
+	if err := d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeImage, ID: id}); err != nil {
+		return nil, fmt.Errorf("registering image %s: %w", id, err)
+	}
+	if err := d.storage.SetResourceAttributes(id, attrs); err != nil {
+		return nil, fmt.Errorf("storing image attributes: %w", err)
+	}
+	api.Logger(ctx).Info(
+		"copied image",
+		slog.String("image_id", id),
+		slog.String("source_image_id", req.SourceImageID),
+		slog.String("name", req.Name),
+	)
+	return &api.CopyImageResponse{ImageID: id}, nil
+}
+
+// imageCopySource holds the properties of an image that CopyImage
+// preserves.
+type imageCopySource struct {
+	architecture string
+	platform     string
+	description  string
+	instanceID   string
+	tags         []api.Tag
+}
+
+// imageCopySource returns the properties of the image with the given ID,
+// which can be an AMI created by dc2 or an image present in the Docker host.
+func (d *Dispatcher) imageCopySource(ctx context.Context, imageID string) (imageCopySource, error) {
+	if _, err := d.findResource(ctx, types.ResourceTypeImage, imageID); err == nil {
+		attrs, err := d.storage.ResourceAttributes(imageID)
+		if err != nil {
+			return imageCopySource{}, fmt.Errorf("retrieving image attributes: %w", err)
+		}
+		var source imageCopySource
+		source.architecture, _ = attrs.Key(attributeNameImageArchitecture)
+		source.platform, _ = attrs.Key(attributeNameImagePlatform)
+		source.description, _ = attrs.Key(attributeNameImageDescription)
+		source.instanceID, _ = attrs.Key(attributeNameImageSourceInstanceID)
+		for _, attr := range attrs {
+			if attr.IsTag() {
+				source.tags = append(source.tags, api.Tag{Key: attr.TagKey(), Value: attr.Value})
+			}
+		}
+		return source, nil
+	} else if !errors.As(err, &storage.ErrResourceNotFound{}) {
+		return imageCopySource{}, err
+	}
+	localImages, err := d.exe.ListImages(ctx)
+	if err != nil {
+		return imageCopySource{}, executorError(err)
+	}
+	for _, image := range localImages {
+		if image.ImageID == imageID {
+			return imageCopySource{architecture: image.Architecture, platform: image.Platform}, nil
+		}
+	}
+	return imageCopySource{}, api.ErrWithCode("InvalidAMIID.NotFound", fmt.Errorf("The image id '[%s]' does not exist", imageID)) //nolint
+}
+
+// validateNewImageName returns an error if name can't be used for a new
+// AMI, either because it's malformed or already in use.
+func (d *Dispatcher) validateNewImageName(name string) error {
+	if !imageNamePattern.MatchString(name) {
+		return api.ErrWithCode("InvalidAMIName.Malformed", fmt.Errorf("AMI name %q is invalid", name))
+	}
+	images, err := d.storage.RegisteredResources(types.ResourceTypeImage)
+	if err != nil {
+		return fmt.Errorf("retrieving images: %w", err)
+	}
+	for _, image := range images {
+		attrs, err := d.storage.ResourceAttributes(image.ID)
+		if err != nil {
+			return fmt.Errorf("retrieving image attributes: %w", err)
+		}
+		if imageName, _ := attrs.Key(attributeNameImageName); imageName == name {
+			return api.ErrWithCode("InvalidAMIName.Duplicate", fmt.Errorf("AMI name %s is already in use by AMI %s", name, image.ID))
+		}
+	}
+	return nil
+}
+
+func (d *Dispatcher) dispatchDescribeImages(ctx context.Context, req *api.DescribeImagesRequest) (*api.DescribeImagesResponse, error) {
+	tagFilters, imageFilters, err := splitImageFilters(req.Filters)
+	if err != nil {
+		return nil, err
+	}
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
+	localImages, err := d.exe.ListImages(ctx)
+	if err != nil {
+		return nil, executorError(err)
+	}
+	localImagesByID := make(map[string]executor.ImageDescription, len(localImages))
+	for _, image := range localImages {
+		localImagesByID[image.ImageID] = image
+	}
+
+	createdImageIDs, err := d.applyFilters(types.ResourceTypeImage, nil, tagFilters)
+	if err != nil {
+		return nil, err
+	}
+	createdImages, err := d.storage.RegisteredResources(types.ResourceTypeImage)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving images: %w", err)
+	}
+
+	images := make([]api.Image, 0, len(createdImageIDs)+len(localImages))
+	for _, imageID := range createdImageIDs {
+		image, err := d.describeCreatedImage(imageID, localImagesByID)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, image)
+	}
+	// Images present in the Docker host have no tags, so they never match
+	// tag filters. Created AMIs are committed as local images too, but
+	// they're already reported above.
+	if len(tagFilters) == 0 {
+		for _, image := range localImages {
+			if slices.ContainsFunc(createdImages, func(r storage.Resource) bool { return r.ID == image.ImageID }) {
+				continue
+			}
+			images = append(images, registryImage(image))
+		}
+	}
+
+	var filtered []api.Image
+	for _, image := range images {
+		if len(req.ImageIDs) > 0 && !slices.Contains(req.ImageIDs, image.ImageID) {
+			continue
+		}
+		if !imageMatchesOwners(image, req.Owners) || !imageMatchesFilters(image, imageFilters) {
+			continue
+		}
+		filtered = append(filtered, image)
+	}
+	for _, imageID := range req.ImageIDs {
+		_, local := localImagesByID[imageID]
+		if !local && !slices.ContainsFunc(createdImages, func(r storage.Resource) bool { return r.ID == imageID }) {
+			return nil, api.ErrWithCode("InvalidAMIID.NotFound", fmt.Errorf("The image id '[%s]' does not exist", imageID)) //nolint
+		}
+	}
+	slices.SortFunc(filtered, func(a, b api.Image) int {
+		return strings.Compare(a.ImageID, b.ImageID)
+	})
+
+	filtered, nextToken, err := applyNextToken(filtered, req.NextToken, req.MaxResults)
+	if err != nil {
+		return nil, err
+	}
+	return &api.DescribeImagesResponse{
+		Images:    filtered,
+		NextToken: nextToken,
+	}, nil
+}
+
+func (d *Dispatcher) describeCreatedImage(imageID string, localImages map[string]executor.ImageDescription) (api.Image, error) {
+	attrs, err := d.storage.ResourceAttributes(imageID)
+	if err != nil {
+		return api.Image{}, fmt.Errorf("retrieving image attributes: %w", err)
+	}
+	creationDate, err := parseAttr(attrs, attributeNameImageCreationDate, parseTime)
+	if err != nil {
+		return api.Image{}, fmt.Errorf("invalid image creation date: %w", err)
+	}
+	name, _ := attrs.Key(attributeNameImageName)
+	// Prefer the architecture and platform recorded from the source instance,
+	// falling back to the ones of the committed image.
+	local := localImages[imageID]
+	architecture, _ := attrs.Key(attributeNameImageArchitecture)
+	if architecture == "" {
+		architecture = local.Architecture
+	}
+	platform, _ := attrs.Key(attributeNameImagePlatform)
+	if platform == "" {
+		platform = local.Platform
+	}
+	image := apiImage(executor.ImageDescription{
+		ImageID:      imageID,
+		Architecture: architecture,
+		Platform:     platform,
+		Size:         local.Size,
+		CreationDate: creationDate,
+	})
+	image.Name = name
+	image.ImageLocation = defaultSecurityGroupOwnerID + "/" + name
+	image.ImageOwnerID = defaultSecurityGroupOwnerID
+	if description, _ := attrs.Key(attributeNameImageDescription); description != "" {
+		image.Description = &description
+	}
+	for _, attr := range attrs {
+		if attr.IsTag() {
+			image.Tags = append(image.Tags, api.Tag{Key: attr.TagKey(), Value: attr.Value})
+		}
+	}
+	return image, nil
+}
+
+// registryImage describes an image present in the Docker host, which can
+// be passed as is to RunInstances.
+func registryImage(desc executor.ImageDescription) api.Image {
+	image := apiImage(desc)
+	image.Name = desc.ImageID
+	image.ImageLocation = desc.ImageID
+	image.ImageOwnerID = registryImageOwnerID
+	image.ImageOwnerAlias = new(registryImageOwnerAlias)
+	image.IsPublic = true
+	return image
+}
+
+func apiImage(desc executor.ImageDescription) api.Image {
+	platform, platformDetails := instancePlatform(desc.Platform, "")
+	volumeSize := int((desc.Size + bytesPerGigaByte - 1) / bytesPerGigaByte)
+	return api.Image{
+		ImageID:         desc.ImageID,
+		ImageState:      imageStateAvailable,
+		CreationDate:    desc.CreationDate.UTC().Format(imageCreationDateLayout),
+		Architecture:    desc.Architecture,
+		ImageType:       imageTypeMachine,
+		Platform:        platform,
+		PlatformDetails: platformDetails,
+		RootDeviceType:  imageRootDeviceTypeEBS,
+		RootDeviceName:  defaultRootDeviceName,
+		BlockDeviceMappings: []api.ImageBlockDeviceMapping{
+			{
+				DeviceName: defaultRootDeviceName,
+				Ebs: &api.ImageEbs{
+					VolumeSize:          max(volumeSize, 1),
+					VolumeType:          string(types.VolumeTypeGp3),
+					DeleteOnTermination: true,
+				},
+			},
+		},
+		VirtualizationType: imageVirtualizationType,
+		Hypervisor:         imageHypervisor,
+	}
+}
+
+// imageMatchesOwners returns whether the image belongs to any of the given
+// owners, which can be account IDs, owner aliases or self.
+func imageMatchesOwners(image api.Image, owners []string) bool {
+	if len(owners) == 0 {
+		return true
+	}
+	for _, owner := range owners {
+		switch {
+		case owner == imageOwnerSelf && image.ImageOwnerID == defaultSecurityGroupOwnerID:
+			return true
+		case owner == image.ImageOwnerID:
+			return true
+		case image.ImageOwnerAlias != nil && owner == *image.ImageOwnerAlias:
+			return true
+		}
+	}
+	return false
+}
+
+func splitImageFilters(filters []api.Filter) ([]api.Filter, []api.Filter, error) {
+	var tagFilters, imageFilters []api.Filter
+	for _, filter := range filters {
+		if filter.Name == nil {
+			return nil, nil, api.InvalidParameterValueError("Filter.Name", "<missing>")
+		}
+		switch name := *filter.Name; {
+		case strings.HasPrefix(name, "tag:"), name == "tag-key":
+			tagFilters = append(tagFilters, filter)
+		case name == "image-id", name == "name", name == "owner-alias", name == "owner-id",
+			name == "architecture", name == "state", name == "image-type", name == "root-device-type":
+			imageFilters = append(imageFilters, filter)
+		default:
+			return nil, nil, api.InvalidParameterValueError("Filter.Name", name)
+		}
+	}
+	return tagFilters, imageFilters, nil
+}
+
+func imageMatchesFilters(image api.Image, filters []api.Filter) bool {
+	for _, filter := range filters {
+		var value string
+		switch *filter.Name {
+		case "image-id":
+			value = image.ImageID
+		case "name":
+			value = image.Name
+		case "owner-alias":
+			if image.ImageOwnerAlias == nil {
+				return false
+			}
+			value = *image.ImageOwnerAlias
+		case "owner-id":
+			value = image.ImageOwnerID
+		case "architecture":
+			value = image.Architecture
+		case "state":
+			value = image.ImageState
+		case "image-type":
+			value = image.ImageType
+		case "root-device-type":
+			value = image.RootDeviceType
+		}
+		if !slices.ContainsFunc(filter.Values, func(pattern string) bool {
+			matched, err := path.Match(pattern, value)
+			return err == nil && matched
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
+// deleteCreatedImages removes the images committed by CreateImage from the
+// Docker host.
+func (d *Dispatcher) deleteCreatedImages(ctx context.Context) error {
+	images, err := d.storage.RegisteredResources(types.ResourceTypeImage)
+	if err != nil {
+		return fmt.Errorf("listing images for exit cleanup: %w", err)
+	}
+	var cleanupErr error
+	for _, image := range images {
+		if err := d.exe.DeleteImage(ctx, executor.DeleteImageRequest{ImageID: image.ID}); err != nil {
+			cleanupErr = errors.Join(cleanupErr, fmt.Errorf("deleting image %s: %w", image.ID, err))
+		}
+	}
+	return cleanupErr
+}
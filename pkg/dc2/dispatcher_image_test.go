@@ -0,0 +1,243 @@
+package dc2
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/executor"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+type imagesExecutor struct {
+	*runningInstancesExecutor
+	images []executor.ImageDescription
+}
+
+func (e *imagesExecutor) CreateImage(_ context.Context, req executor.CreateImageRequest) error {
+	e.images = append(e.images, executor.ImageDescription{
+		ImageID:      req.ImageID,
+		Architecture: "x86_64",
+		Platform:     "linux",
+		Size:         3 * bytesPerGigaByte / 2,
+		CreationDate: time.Now(),
+	})
+	return nil
+}
+
+func (e *imagesExecutor) CopyImage(_ context.Context, req executor.CopyImageRequest) error {
+	for _, image := range e.images {
+		if image.ImageID == req.SourceImageID {
+			image.ImageID = req.ImageID
+			e.images = append(e.images, image)
+			return nil
+		}
+	}
+	return fmt.Errorf("image %s not found", req.SourceImageID)
+}
+
+func (e *imagesExecutor) ListImages(context.Context) ([]executor.ImageDescription, error) {
+	return e.images, nil
+}
+
+func TestDescribeImages(t *testing.T) {
+	t.Parallel()
+
+	const instanceID = "i-00000000000000001"
+	ctx := context.Background()
+	exe := &imagesExecutor{
+		runningInstancesExecutor: &runningInstancesExecutor{exitCleanupExecutor: &exitCleanupExecutor{}},
+		images: []executor.ImageDescription{
+			{ImageID: "nginx", Architecture: "x86_64", Platform: "linux", Size: 1000, CreationDate: time.Now()},
+			{ImageID: "windows:ltsc", Architecture: "x86_64", Platform: "windows", Size: 1000, CreationDate: time.Now()},
+		},
+	}
+	dispatch := &Dispatcher{
+		exe:     exe,
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+	require.NoError(t, dispatch.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeInstance, ID: instanceID}))
+
+	created, err := dispatch.dispatchCreateImage(ctx, &api.CreateImageRequest{
+		InstanceID:  instanceID,
+		Name:        "my-image",
+		Description: "my description",
+		TagSpecifications: []api.TagSpecification{
+			{ResourceType: types.ResourceTypeImage, Tags: []api.Tag{{Key: "Team", Value: "dc2"}}},
+		},
+	})
+	require.NoError(t, err)
+	assert.Regexp(t, `^ami-[0-9a-f]{17}$`, created.ImageID)
+
+	_, err = dispatch.dispatchCreateImage(ctx, &api.CreateImageRequest{InstanceID: instanceID, Name: "my-image"})
+	var apiErr *api.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "InvalidAMIName.Duplicate", apiErr.Code)
+
+	describe := func(req *api.DescribeImagesRequest) []api.Image {
+		t.Helper()
+		resp, err := dispatch.dispatchDescribeImages(ctx, req)
+		require.NoError(t, err)
+		return resp.Images
+	}
+	imageIDs := func(images []api.Image) []string {
+		ids := make([]string, 0, len(images))
+		for _, image := range images {
+			ids = append(ids, image.ImageID)
+		}
+		return ids
+	}
+
+	// The committed AMI is only reported once
+	assert.Equal(t, []string{created.ImageID, "nginx", "windows:ltsc"}, imageIDs(describe(&api.DescribeImagesRequest{})))
+
+	self := describe(&api.DescribeImagesRequest{Owners: []string{"self"}})
+	require.Len(t, self, 1)
+	assert.Equal(t, created.ImageID, self[0].ImageID)
+	assert.Equal(t, "my-image", self[0].Name)
+	require.NotNil(t, self[0].Description)
+	assert.Equal(t, "my description", *self[0].Description)
+	assert.Equal(t, "x86_64", self[0].Architecture)
+	assert.Equal(t, "available", self[0].ImageState)
+	assert.Equal(t, "ebs", self[0].RootDeviceType)
+	assert.Nil(t, self[0].ImageOwnerAlias)
+	assert.Equal(t, []api.Tag{{Key: "Team", Value: "dc2"}}, self[0].Tags)
+	require.Len(t, self[0].BlockDeviceMappings, 1)
+	assert.Equal(t, 2, self[0].BlockDeviceMappings[0].Ebs.VolumeSize)
+
+	registry := describe(&api.DescribeImagesRequest{Owners: []string{"amazon"}})
+	assert.Equal(t, []string{"nginx", "windows:ltsc"}, imageIDs(registry))
+	assert.Equal(t, "nginx", registry[0].Name)
+	assert.Nil(t, registry[0].Platform)
+	require.NotNil(t, registry[1].Platform)
+	assert.Equal(t, "windows", *registry[1].Platform)
+
+	filterTests := []struct {
+		name   string
+		filter api.Filter
+		want   []string
+	}{
+		{"image-id", api.Filter{Name: new("image-id"), Values: []string{"nginx"}}, []string{"nginx"}},
+		{"name wildcard", api.Filter{Name: new("name"), Values: []string{"my-*"}}, []string{created.ImageID}},
+		{"owner-alias", api.Filter{Name: new("owner-alias"), Values: []string{"amazon"}}, []string{"nginx", "windows:ltsc"}},
+		{"tag", api.Filter{Name: new("tag:Team"), Values: []string{"dc2"}}, []string{created.ImageID}},
+	}
+	for _, tc := range filterTests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, imageIDs(describe(&api.DescribeImagesRequest{Filters: []api.Filter{tc.filter}})))
+		})
+	}
+
+	_, err = dispatch.dispatchDescribeImages(ctx, &api.DescribeImagesRequest{ImageIDs: []string{"ami-00000000000000000"}})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "InvalidAMIID.NotFound", apiErr.Code)
+
+	_, err = dispatch.dispatchDescribeImages(ctx, &api.DescribeImagesRequest{
+		Filters: []api.Filter{{Name: new("unknown"), Values: []string{"x"}}},
+	})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, api.ErrorCodeInvalidParameterValue, apiErr.Code)
+}
+
+func TestCopyImage(t *testing.T) {
+	t.Parallel()
+
+	const instanceID = "i-00000000000000001"
+	ctx := t.Context()
+	exe := &imagesExecutor{
+		runningInstancesExecutor: &runningInstancesExecutor{exitCleanupExecutor: &exitCleanupExecutor{}},
+		images: []executor.ImageDescription{
+			{ImageID: "nginx", Architecture: "arm64", Platform: "linux", Size: 1000, CreationDate: time.Now()},
+		},
+	}
+	dispatch := &Dispatcher{
+		opts:    DispatcherOptions{Region: "us-east-1"},
+		exe:     exe,
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+	require.NoError(t, dispatch.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeInstance, ID: instanceID}))
+	created, err := dispatch.dispatchCreateImage(ctx, &api.CreateImageRequest{
+		InstanceID:  instanceID,
+		Name:        "my-image",
+		Description: "my description",
+		TagSpecifications: []api.TagSpecification{
+			{ResourceType: types.ResourceTypeImage, Tags: []api.Tag{{Key: "Team", Value: "dc2"}}},
+		},
+	})
+	require.NoError(t, err)
+
+	describeImage := func(imageID string) api.Image {
+		t.Helper()
+		resp, err := dispatch.dispatchDescribeImages(ctx, &api.DescribeImagesRequest{ImageIDs: []string{imageID}})
+		require.NoError(t, err)
+		require.Len(t, resp.Images, 1)
+		return resp.Images[0]
+	}
+
+	copied, err := dispatch.dispatchCopyImage(ctx, &api.CopyImageRequest{
+		SourceImageID: created.ImageID,
+		SourceRegion:  "us-east-1",
+		Name:          "my-image-copy",
+		CopyImageTags: true,
+		TagSpecifications: []api.TagSpecification{
+			{ResourceType: types.ResourceTypeImage, Tags: []api.Tag{{Key: "Copy", Value: "true"}}},
+		},
+	})
+	require.NoError(t, err)
+	assert.Regexp(t, `^ami-[0-9a-f]{17}$`, copied.ImageID)
+	assert.NotEqual(t, created.ImageID, copied.ImageID)
+	source := describeImage(created.ImageID)
+	image := describeImage(copied.ImageID)
+	assert.Equal(t, "my-image-copy", image.Name)
+	require.NotNil(t, image.Description)
+	assert.Equal(t, "my description", *image.Description)
+	assert.Equal(t, source.Architecture, image.Architecture)
+	assert.Equal(t, source.BlockDeviceMappings, image.BlockDeviceMappings)
+	assert.ElementsMatch(t, []api.Tag{{Key: "Team", Value: "dc2"}, {Key: "Copy", Value: "true"}}, image.Tags)
+
+	// Images in the Docker host can be copied too
+	copied, err = dispatch.dispatchCopyImage(ctx, &api.CopyImageRequest{
+		SourceImageID: "nginx",
+		SourceRegion:  "us-east-1",
+		Name:          "nginx-copy",
+		Description:   "copy of nginx",
+	})
+	require.NoError(t, err)
+	image = describeImage(copied.ImageID)
+	assert.Equal(t, "nginx-copy", image.Name)
+	assert.Equal(t, "arm64", image.Architecture)
+	require.NotNil(t, image.Description)
+	assert.Equal(t, "copy of nginx", *image.Description)
+	assert.Empty(t, image.Tags)
+
+	var apiErr *api.Error
+	_, err = dispatch.dispatchCopyImage(ctx, &api.CopyImageRequest{
+		SourceImageID: "ami-00000000000000000",
+		SourceRegion:  "us-east-1",
+		Name:          "missing-copy",
+	})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "InvalidAMIID.NotFound", apiErr.Code)
+	_, err = dispatch.dispatchCopyImage(ctx, &api.CopyImageRequest{
+		SourceImageID: created.ImageID,
+		SourceRegion:  "us-east-1",
+		Name:          "my-image",
+	})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "InvalidAMIName.Duplicate", apiErr.Code)
+	_, err = dispatch.dispatchCopyImage(ctx, &api.CopyImageRequest{
+		SourceImageID: created.ImageID,
+		SourceRegion:  "eu-west-1",
+		Name:          "other-region",
+	})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, api.ErrorCodeInvalidParameterValue, apiErr.Code)
+}
@@ -74,3 +74,28 @@ func TestNewDispatcherClosesExecutorOnProfileLoadError(t *testing.T) {
 	assert.Contains(t, err.Error(), "loading test profile")
 	assert.Equal(t, 1, exe.closeCalls)
 }
+
+func TestNewDispatcherUsesConfiguredExecutor(t *testing.T) {
+	t.Parallel()
+
+	exe := &initCleanupExecutor{
+		exitCleanupExecutor: &exitCleanupExecutor{},
+	}
+	dispatch, err := newDispatcherWithHooks(
+		context.Background(),
+		DispatcherOptions{Executor: exe},
+		&imdsController{},
+		dispatcherInitHooks{
+			newExecutor: func(context.Context, docker.ExecutorOptions) (executor.Executor, error) {
+				return nil, errors.New("the Docker executor should not be created")
+			},
+			loadInstanceTypeCatalog: func() (*instancetype.Catalog, error) {
+				return &instancetype.Catalog{}, nil
+			},
+		},
+	)
+	require.NoError(t, err)
+	assert.Same(t, exe, dispatch.exe)
+	require.NoError(t, dispatch.Close(context.Background()))
+	assert.Equal(t, 1, exe.closeCalls)
+}
@@ -1,6 +1,7 @@
 package dc2
 
 import (
+	"cmp"
 	"context"
 	"crypto/sha1"
 	"encoding/base64"
@@ -9,6 +10,7 @@ import (
 	"log/slog"
 	"net/netip"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -34,9 +36,19 @@ const (
 	terminatedInstanceTTL     = 3 * time.Second
 	stateReasonUserInitiated  = "Client.UserInitiatedShutdown"
 	stateMessageUserInitiated = "Client.UserInitiatedShutdown: User initiated shutdown"
+
+	platformWindows               = "windows"
+	platformDetailsLinux          = "Linux/UNIX"
+	platformDetailsWindows        = "Windows"
+	platformDetailsOverrideTagKey = "dc2:platform-details"
 )
 
 func (d *Dispatcher) dispatchRunInstances(ctx context.Context, req *api.RunInstancesRequest) (*api.RunInstancesResponse, error) {
+	clientToken := strings.TrimSpace(req.ClientToken)
+	if previous, found, err := d.runInstancesForClientToken(ctx, clientToken); err != nil || (found && !req.DryRun) {
+		return previous, err
+	}
+
 	spotOptions, err := resolveRunInstancesSpotOptions(req)
 	if err != nil {
 		return nil, err
@@ -46,6 +58,17 @@ func (d *Dispatcher) dispatchRunInstances(ctx context.Context, req *api.RunInsta
 	if err != nil {
 		return nil, err
 	}
+	cpuCredits, err := d.resolveRunInstancesCPUCredits(req, launchParams.instanceType)
+	if err != nil {
+		return nil, err
+	}
+	var instanceProfileArn string
+	if launchParams.iamInstanceProfile != nil {
+		instanceProfileArn, err = resolveInstanceProfileArn(*launchParams.iamInstanceProfile)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	matchInput := d.runInstancesMatchInputForInstanceType(launchParams.instanceType)
 	matchInput.MarketType = spotOptions.MarketType
@@ -57,12 +80,22 @@ func (d *Dispatcher) dispatchRunInstances(ctx context.Context, req *api.RunInsta
 		return nil, err
 	}
 	reclaimPlan := d.resolveSpotReclaimPlanForMatchInput(matchInput, spotOptions.MarketType)
-	availabilityZone, err := d.runInstancesAvailabilityZone(req)
+	subnetID := runInstancesSubnetID(req)
+	vpcID, subnetAvailabilityZone, err := d.subnetPlacement(subnetID)
 	if err != nil {
 		return nil, err
 	}
-	subnetID := runInstancesSubnetID(req)
-	vpcID := subnetVPCID(subnetID)
+	availabilityZone, err := d.runInstancesAvailabilityZone(req, subnetAvailabilityZone)
+	if err != nil {
+		return nil, err
+	}
+	placementGroupName, err := d.resolveRunInstancesPlacementGroup(req, availabilityZone)
+	if err != nil {
+		return nil, err
+	}
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
 	if err := d.applyRunInstancesDelayForMatchInput(ctx, testprofile.HookBefore, testprofile.PhaseAllocate, matchInput); err != nil {
 		return nil, err
 	}
@@ -71,6 +104,9 @@ func (d *Dispatcher) dispatchRunInstances(ctx context.Context, req *api.RunInsta
 		InstanceType: launchParams.instanceType,
 		Count:        req.MaxCount,
 		UserData:     normalizeUserData(launchParams.userData),
+		VCPUs:        matchInput.VCPU,
+		MemoryMiB:    matchInput.MemoryMiB,
+		Architecture: cmp.Or(launchParams.architecture, d.opts.DefaultArchitecture),
 	})
 	if err != nil {
 		return nil, executorError(err)
@@ -80,7 +116,15 @@ func (d *Dispatcher) dispatchRunInstances(ctx context.Context, req *api.RunInsta
 		return nil, err
 	}
 
+	reservationID, err := makeID(reservationIDPrefix)
+	if err != nil {
+		d.cleanupFailedRunInstancesLaunch(ctx, ids)
+		return nil, err
+	}
 	attrs := []storage.Attribute{
+		{
+			Key: attributeNameInstanceReservationID, Value: reservationID,
+		},
 		{
 			Key: attributeNameAvailabilityZone, Value: availabilityZone,
 		},
@@ -91,12 +135,35 @@ func (d *Dispatcher) dispatchRunInstances(ctx context.Context, req *api.RunInsta
 			Key: attributeNameVPCID, Value: vpcID,
 		},
 	}
-	if req.KeyName != "" {
-		attrs = append(attrs, storage.Attribute{Key: attributeNameInstanceKeyName, Value: req.KeyName})
+	if clientToken != "" {
+		attrs = append(attrs, storage.Attribute{Key: attributeNameInstanceClientToken, Value: clientToken})
+	}
+	if launchParams.keyName != "" {
+		attrs = append(attrs, storage.Attribute{Key: attributeNameInstanceKeyName, Value: launchParams.keyName})
+	}
+	if req.Monitoring != nil && req.Monitoring.Enabled {
+		attrs = append(attrs, storage.Attribute{Key: attributeNameInstanceMonitoring, Value: monitoringStateEnabled})
+	}
+	if placementGroupName != "" {
+		attrs = append(attrs, storage.Attribute{Key: attributeNameInstancePlacementGroupName, Value: placementGroupName})
+	}
+	if len(launchParams.securityGroupIDs) > 0 {
+		securityGroupIDs, err := marshalStringSlice(launchParams.securityGroupIDs)
+		if err != nil {
+			d.cleanupFailedRunInstancesLaunch(ctx, ids)
+			return nil, fmt.Errorf("marshaling instance security groups: %w", err)
+		}
+		attrs = append(attrs, storage.Attribute{Key: attributeNameInstanceSecurityGroupIDs, Value: securityGroupIDs})
 	}
 	if launchParams.userData != "" {
 		attrs = append(attrs, storage.Attribute{Key: attributeNameInstanceUserData, Value: normalizeUserData(launchParams.userData)})
 	}
+	if req.DisableAPITermination {
+		attrs = append(attrs, storage.Attribute{Key: attributeNameInstanceDisableAPITermination, Value: "true"})
+	}
+	if cpuCredits != "" {
+		attrs = append(attrs, storage.Attribute{Key: attributeNameInstanceCPUCredits, Value: cpuCredits})
+	}
 	if spotOptions.MarketType == instanceMarketTypeSpot {
 		attrs = append(attrs, storage.Attribute{Key: attributeNameInstanceMarketType, Value: spotOptions.MarketType})
 		attrs = append(attrs, storage.Attribute{Key: attributeNameSpotInterruptMode, Value: spotOptions.InterruptionBehavior})
@@ -122,6 +189,14 @@ func (d *Dispatcher) dispatchRunInstances(ctx context.Context, req *api.RunInsta
 			}
 			instanceAttrs = append(instanceAttrs, storage.Attribute{Key: attributeNameSpotRequestID, Value: spotRequestID})
 		}
+		if instanceProfileArn != "" {
+			profileAttrs, err := instanceProfileAttributes(instanceProfileArn, time.Now())
+			if err != nil {
+				d.cleanupFailedRunInstancesLaunch(ctx, ids)
+				return nil, err
+			}
+			instanceAttrs = append(instanceAttrs, profileAttrs...)
+		}
 		r := storage.Resource{Type: types.ResourceTypeInstance, ID: id}
 		if err := d.storage.RegisterResource(r); err != nil {
 			d.cleanupFailedRunInstancesLaunch(ctx, ids)
@@ -133,6 +208,10 @@ func (d *Dispatcher) dispatchRunInstances(ctx context.Context, req *api.RunInsta
 				return nil, fmt.Errorf("storing instance attributes: %w", err)
 			}
 		}
+		if err := d.registerInstanceNetworkInterface(id); err != nil {
+			d.cleanupFailedRunInstancesLaunch(ctx, ids)
+			return nil, err
+		}
 		if err := d.imds.SetTags(string(executorID), instanceTags); err != nil {
 			d.cleanupFailedRunInstancesLaunch(ctx, ids)
 			return nil, fmt.Errorf("synchronizing IMDS tags for instance %s: %w", id, err)
@@ -143,12 +222,14 @@ func (d *Dispatcher) dispatchRunInstances(ctx context.Context, req *api.RunInsta
 		d.cleanupFailedRunInstancesLaunch(ctx, ids)
 		return nil, err
 	}
-	if _, err := d.exe.StartInstances(ctx, executor.StartInstancesRequest{
+	startChanges, err := d.exe.StartInstances(ctx, executor.StartInstancesRequest{
 		InstanceIDs: ids,
-	}); err != nil {
+	})
+	if err != nil {
 		d.cleanupFailedRunInstancesLaunch(ctx, ids)
 		return nil, executorError(err)
 	}
+	d.publishInstanceStateChanges(startChanges)
 	if err := d.applyRunInstancesDelayForMatchInput(ctx, testprofile.HookAfter, testprofile.PhaseStart, matchInput); err != nil {
 		d.cleanupFailedRunInstancesLaunch(ctx, ids)
 		return nil, err
@@ -179,6 +260,7 @@ func (d *Dispatcher) dispatchRunInstances(ctx context.Context, req *api.RunInsta
 		"created instances",
 		slog.Int("count", len(createdInstanceIDs)),
 		slog.Any("instance_ids", createdInstanceIDs),
+		slog.String("reservation_id", reservationID),
 		slog.String("image_id", launchParams.imageID),
 		slog.String("instance_type", launchParams.instanceType),
 		slog.String("market_type", spotOptions.MarketType),
@@ -193,7 +275,8 @@ func (d *Dispatcher) dispatchRunInstances(ctx context.Context, req *api.RunInsta
 		}
 	}
 	return &api.RunInstancesResponse{
-		InstancesSet: instances,
+		ReservationID: reservationID,
+		InstancesSet:  instances,
 	}, nil
 }
 
@@ -202,8 +285,12 @@ type runInstancesLaunchParameters struct {
 	instanceType          string
 	userData              string
 	blockDeviceMappings   []api.RunInstancesBlockDeviceMapping
+	keyName               string
+	securityGroupIDs      []string
+	iamInstanceProfile    *api.IamInstanceProfileSpecification
 	launchTemplateID      string
 	launchTemplateVersion string
+	architecture          string
 }
 
 func (d *Dispatcher) resolveRunInstancesLaunchParameters(
@@ -215,7 +302,14 @@ func (d *Dispatcher) resolveRunInstancesLaunchParameters(
 		instanceType:        strings.TrimSpace(req.InstanceType),
 		userData:            req.UserData,
 		blockDeviceMappings: cloneBlockDeviceMappings(req.BlockDeviceMappings),
+		keyName:             req.KeyName,
+		iamInstanceProfile:  req.IamInstanceProfile,
+		architecture:        req.Architecture,
 	}
+	if err := validateArchitecture(out.architecture, "Architecture"); err != nil {
+		return runInstancesLaunchParameters{}, err
+	}
+	securityGroupIDs, securityGroupNames := req.SecurityGroupIDs, req.SecurityGroups
 
 	if req.LaunchTemplate != nil {
 		lt, err := d.findLaunchTemplate(ctx, req.LaunchTemplate)
@@ -236,7 +330,24 @@ func (d *Dispatcher) resolveRunInstancesLaunchParameters(
 		if len(out.blockDeviceMappings) == 0 {
 			out.blockDeviceMappings = cloneBlockDeviceMappings(lt.BlockDeviceMappings)
 		}
+		if len(securityGroupIDs) == 0 && len(securityGroupNames) == 0 {
+			securityGroupIDs = lt.SecurityGroupIDs
+		}
+		if out.keyName == "" {
+			out.keyName = lt.KeyName
+		}
+		if out.iamInstanceProfile == nil {
+			out.iamInstanceProfile = lt.IamInstanceProfile
+		}
+		if out.architecture == "" {
+			out.architecture = lt.Architecture
+		}
+	}
+	resolvedSecurityGroupIDs, err := d.resolveRunInstancesSecurityGroupIDs(securityGroupIDs, securityGroupNames)
+	if err != nil {
+		return runInstancesLaunchParameters{}, err
 	}
+	out.securityGroupIDs = resolvedSecurityGroupIDs
 
 	if out.imageID == "" {
 		return runInstancesLaunchParameters{}, api.InvalidParameterValueError("ImageId", "<empty>")
@@ -274,6 +385,10 @@ func (d *Dispatcher) cleanupFailedRunInstancesLaunch(ctx context.Context, ids []
 		if err := d.storage.RemoveResource(apiID); err != nil && !errors.As(err, &storage.ErrResourceNotFound{}) {
 			api.Logger(ctx).Warn("failed to remove instance resource during rollback", slog.String("instance_id", apiID), slog.Any("error", err))
 		}
+		networkInterfaceID := instanceNetworkInterfaceID(apiID)
+		if err := d.storage.RemoveResource(networkInterfaceID); err != nil && !errors.As(err, &storage.ErrResourceNotFound{}) {
+			api.Logger(ctx).Warn("failed to remove network interface resource during rollback", slog.String("network_interface_id", networkInterfaceID), slog.Any("error", err))
+		}
 
 		containerID := string(id)
 		if err := d.imds.ClearSpotInstanceAction(containerID); err != nil {
@@ -282,6 +397,9 @@ func (d *Dispatcher) cleanupFailedRunInstancesLaunch(ctx context.Context, ids []
 		if err := d.imds.SetEnabled(containerID, true); err != nil {
 			api.Logger(ctx).Warn("failed to reset IMDS endpoint during rollback", slog.String("container_id", containerID), slog.Any("error", err))
 		}
+		if err := d.imds.SetMetadataOptions(containerID, defaultIMDSMetadataOptions); err != nil {
+			api.Logger(ctx).Warn("failed to reset IMDS metadata options during rollback", slog.String("container_id", containerID), slog.Any("error", err))
+		}
 		if err := d.imds.RevokeTokens(containerID); err != nil {
 			api.Logger(ctx).Warn("failed to revoke IMDS tokens during rollback", slog.String("container_id", containerID), slog.Any("error", err))
 		}
@@ -485,6 +603,7 @@ func (d *Dispatcher) stopInstancesWithProfileDelay(
 	if err != nil {
 		return nil, executorError(err)
 	}
+	d.publishInstanceStateChanges(changes)
 	if err := d.applyTestProfileDelayForMatchInputs(ctx, testprofile.HookAfter, testprofile.PhaseStop, matchInputs); err != nil {
 		return nil, err
 	}
@@ -495,6 +614,9 @@ func (d *Dispatcher) startInstancesWithProfileDelay(
 	ctx context.Context,
 	instanceIDs []executor.InstanceID,
 ) ([]executor.InstanceStateChange, error) {
+	if err := d.dropAsyncStops(ctx, instanceIDs); err != nil {
+		return nil, err
+	}
 	matchInputs, err := d.lifecycleMatchInputs(ctx, testprofile.ActionStartInstances, apiInstanceIDs(instanceIDs))
 	if err != nil {
 		return nil, err
@@ -502,12 +624,18 @@ func (d *Dispatcher) startInstancesWithProfileDelay(
 	if err := d.applyTestProfileDelayForMatchInputs(ctx, testprofile.HookBefore, testprofile.PhaseStart, matchInputs); err != nil {
 		return nil, err
 	}
+	resetIDs, err := d.instanceStoreInstanceIDs(instanceIDs)
+	if err != nil {
+		return nil, err
+	}
 	changes, err := d.exe.StartInstances(ctx, executor.StartInstancesRequest{
-		InstanceIDs: instanceIDs,
+		InstanceIDs:      instanceIDs,
+		ResetInstanceIDs: resetIDs,
 	})
 	if err != nil {
 		return nil, executorError(err)
 	}
+	d.publishInstanceStateChanges(changes)
 	if err := d.applyTestProfileDelayForMatchInputs(ctx, testprofile.HookAfter, testprofile.PhaseStart, matchInputs); err != nil {
 		return nil, err
 	}
@@ -519,6 +647,9 @@ func (d *Dispatcher) terminateInstancesWithProfileDelay(
 	instanceIDs []executor.InstanceID,
 	force bool,
 ) ([]executor.InstanceStateChange, error) {
+	if err := d.dropAsyncStops(ctx, instanceIDs); err != nil {
+		return nil, err
+	}
 	matchInputs, err := d.lifecycleMatchInputs(ctx, testprofile.ActionTerminateInstances, apiInstanceIDs(instanceIDs))
 	if err != nil {
 		return nil, err
@@ -533,6 +664,7 @@ func (d *Dispatcher) terminateInstancesWithProfileDelay(
 	if err != nil {
 		return nil, executorError(err)
 	}
+	d.publishInstanceStateChanges(changes)
 	if err := d.applyTestProfileDelayForMatchInputs(ctx, testprofile.HookAfter, testprofile.PhaseTerminate, matchInputs); err != nil {
 		return nil, err
 	}
@@ -575,6 +707,9 @@ func (d *Dispatcher) runInstancesMatchInputForAutoScalingGroup(instanceType stri
 }
 
 func (d *Dispatcher) dispatchDescribeInstances(ctx context.Context, req *api.DescribeInstancesRequest) (*api.DescribeInstancesResponse, error) {
+	if err := d.validateInstanceIDCount(req.InstanceIDs); err != nil {
+		return nil, err
+	}
 	tagFilters, instanceFilters, err := splitInstanceFilters(req.Filters)
 	if err != nil {
 		return nil, err
@@ -590,6 +725,7 @@ func (d *Dispatcher) dispatchDescribeInstances(ctx context.Context, req *api.Des
 	if err != nil {
 		return nil, executorError(err)
 	}
+	descriptions = d.applyAsyncStopStates(descriptions)
 
 	instances := make([]api.Instance, 0, len(instanceIDs))
 	describedInstances := make(map[string]struct{}, len(descriptions))
@@ -617,17 +753,43 @@ func (d *Dispatcher) dispatchDescribeInstances(ctx context.Context, req *api.Des
 	if err != nil {
 		return nil, err
 	}
-
-	var reservations []api.Reservation
+	// Paginate the filtered set, so pages are full and a token is only
+	// returned while matching instances remain. Tokens resume after the last
+	// returned instance ID, so instances launched or terminated between pages
+	// neither repeat nor skip the remaining ones.
+	slices.SortFunc(instances, func(a, b api.Instance) int {
+		return strings.Compare(a.InstanceID, b.InstanceID)
+	})
+	instances, nextToken, err := applyKeyNextToken(instances, func(i api.Instance) string {
+		return i.InstanceID
+	}, req.NextToken, req.MaxResults)
+	if err != nil {
+		return nil, err
+	}
 	if len(instances) > 0 {
-		reservations = append(reservations, api.Reservation{InstancesSet: instances})
+		instanceIDs := make([]string, len(instances))
+		for i, instance := range instances {
+			instanceIDs[i] = instance.InstanceID
+		}
+		blockDeviceMappings, err := d.instanceBlockDeviceMappings(ctx, instanceIDs)
+		if err != nil {
+			return nil, err
+		}
+		for i := range instances {
+			instances[i].BlockDeviceMappings = blockDeviceMappings[instances[i].InstanceID]
+		}
 	}
+
 	return &api.DescribeInstancesResponse{
-		ReservationSet: reservations,
+		ReservationSet: instanceReservations(instances),
+		NextToken:      nextToken,
 	}, nil
 }
 
 func (d *Dispatcher) dispatchDescribeInstanceStatus(ctx context.Context, req *api.DescribeInstanceStatusRequest) (*api.DescribeInstanceStatusResponse, error) {
+	if err := d.validateInstanceIDCount(req.InstanceIDs); err != nil {
+		return nil, err
+	}
 	tagFilters, instanceFilters, err := splitInstanceFilters(req.Filters)
 	if err != nil {
 		return nil, err
@@ -643,6 +805,7 @@ func (d *Dispatcher) dispatchDescribeInstanceStatus(ctx context.Context, req *ap
 	if err != nil {
 		return nil, executorError(err)
 	}
+	descriptions = d.applyAsyncStopStates(descriptions)
 
 	includeAllInstances := req.IncludeAllInstances != nil && *req.IncludeAllInstances
 	statuses := make([]api.InstanceStatus, 0, len(descriptions))
@@ -668,6 +831,10 @@ func (d *Dispatcher) dispatchDescribeInstanceStatus(ctx context.Context, req *ap
 			return nil, fmt.Errorf("retrieving instance attributes: %w", err)
 		}
 		availabilityZone, _ := attrs.Key(attributeNameAvailabilityZone)
+		events, err := instanceScheduledEvents(attrs)
+		if err != nil {
+			return nil, err
+		}
 		summary := statusSummaryForInstanceState(desc.InstanceState)
 		statuses = append(statuses, api.InstanceStatus{
 			AvailabilityZone: availabilityZone,
@@ -675,6 +842,7 @@ func (d *Dispatcher) dispatchDescribeInstanceStatus(ctx context.Context, req *ap
 			InstanceState:    desc.InstanceState,
 			InstanceStatus:   summary,
 			SystemStatus:     summary,
+			Events:           apiInstanceStatusEvents(events),
 		})
 	}
 
@@ -747,7 +915,16 @@ func isSupportedInstanceFilter(filterName string) bool {
 		"private-ip-address",
 		"ip-address",
 		"private-dns-name",
-		"dns-name":
+		"dns-name",
+		"group-id",
+		"group-name",
+		"instance.group-id",
+		"instance.group-name",
+		"reservation-id",
+		"client-token",
+		"placement-group-name",
+		"launch-template-id",
+		"launch-template-version":
 		return true
 	default:
 		return false
@@ -814,17 +991,56 @@ func instanceMatchesFilter(instance api.Instance, filter api.Filter) (bool, erro
 		return slices.Contains(filter.Values, instance.PrivateDNSName), nil
 	case "dns-name":
 		return slices.Contains(filter.Values, instance.DNSName), nil
+	case "reservation-id":
+		return slices.Contains(filter.Values, instance.ReservationID), nil
+	case "client-token":
+		return instance.ClientToken != nil && slices.Contains(filter.Values, *instance.ClientToken), nil
+	case "placement-group-name":
+		return slices.Contains(filter.Values, instance.Placement.GroupName), nil
+	case "launch-template-id":
+		return instanceLaunchTemplateLinkageMatches(instance, launchTemplateTagKeyID, filter.Values), nil
+	case "launch-template-version":
+		return instanceLaunchTemplateLinkageMatches(instance, launchTemplateTagKeyVersion, filter.Values), nil
+	case "group-id", "instance.group-id":
+		return slices.ContainsFunc(instance.SecurityGroups, func(group api.Group) bool {
+			return slices.Contains(filter.Values, group.GroupID)
+		}), nil
+	case "group-name", "instance.group-name":
+		return slices.ContainsFunc(instance.SecurityGroups, func(group api.Group) bool {
+			return slices.Contains(filter.Values, group.GroupName)
+		}), nil
 	default:
 		return false, api.InvalidParameterValueError("Filter.Name", *filter.Name)
 	}
 }
 
+// validateInstanceIDCount rejects requests naming more instance IDs than the
+// configured limit, like EC2 does.
+func (d *Dispatcher) validateInstanceIDCount(instanceIDs []string) error {
+	limit := d.opts.MaxInstanceIDsPerRequest
+	if limit <= 0 || len(instanceIDs) <= limit {
+		return nil
+	}
+	//nolint
+	err := fmt.Errorf("The request can include up to %d instance IDs, but %d were provided.", limit, len(instanceIDs))
+	return api.ErrWithCode(api.ErrorCodeInvalidParameterValue, err)
+}
+
 func (d *Dispatcher) dispatchStopInstances(ctx context.Context, req *api.StopInstancesRequest) (*api.StopInstancesResponse, error) {
+	if err := d.validateInstanceIDCount(req.InstanceIDs); err != nil {
+		return nil, err
+	}
 	if req.DryRun {
 		return nil, api.DryRunError()
 	}
 	ids := executorInstanceIDs(req.InstanceIDs)
-	changes, err := d.stopInstancesWithProfileDelay(ctx, ids, req.Force)
+	var changes []executor.InstanceStateChange
+	var err error
+	if d.opts.AsyncStateTransitions {
+		changes, err = d.stopInstancesAsync(ctx, ids, req.Force)
+	} else {
+		changes, err = d.stopInstancesWithProfileDelay(ctx, ids, req.Force)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -850,6 +1066,9 @@ func (d *Dispatcher) dispatchStopInstances(ctx context.Context, req *api.StopIns
 }
 
 func (d *Dispatcher) dispatchStartInstances(ctx context.Context, req *api.StartInstancesRequest) (*api.StartInstancesResponse, error) {
+	if err := d.validateInstanceIDCount(req.InstanceIDs); err != nil {
+		return nil, err
+	}
 	if req.DryRun {
 		return nil, api.DryRunError()
 	}
@@ -874,10 +1093,34 @@ func (d *Dispatcher) dispatchStartInstances(ctx context.Context, req *api.StartI
 	}, nil
 }
 
+func (d *Dispatcher) dispatchRebootInstances(ctx context.Context, req *api.RebootInstancesRequest) (*api.RebootInstancesResponse, error) {
+	if err := d.validateInstanceIDCount(req.InstanceIDs); err != nil {
+		return nil, err
+	}
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
+	// A reboot keeps the instance running, so the recorded state transition
+	// metadata is left untouched.
+	if err := d.exe.RebootInstances(ctx, executor.RebootInstancesRequest{
+		InstanceIDs: executorInstanceIDs(req.InstanceIDs),
+	}); err != nil {
+		return nil, executorError(err)
+	}
+	api.Logger(ctx).Info("rebooted instances", slog.Any("instance_ids", req.InstanceIDs))
+	return &api.RebootInstancesResponse{}, nil
+}
+
 func (d *Dispatcher) dispatchTerminateInstances(ctx context.Context, req *api.TerminateInstancesRequest) (*api.TerminateInstancesResponse, error) {
+	if err := d.validateInstanceIDCount(req.InstanceIDs); err != nil {
+		return nil, err
+	}
 	if req.DryRun {
 		return nil, api.DryRunError()
 	}
+	if err := d.checkInstancesTerminationProtection(req.InstanceIDs); err != nil {
+		return nil, err
+	}
 	changes, err := d.terminateInstancesWithStateReason(
 		ctx,
 		req.InstanceIDs,
@@ -938,6 +1181,9 @@ func (d *Dispatcher) terminateInstancesWithStateReason(
 		if err := d.imds.SetEnabled(containerID, true); err != nil {
 			return nil, fmt.Errorf("resetting IMDS endpoint for instance %s: %w", instanceID, err)
 		}
+		if err := d.imds.SetMetadataOptions(containerID, defaultIMDSMetadataOptions); err != nil {
+			return nil, fmt.Errorf("resetting IMDS metadata options for instance %s: %w", instanceID, err)
+		}
 		if err := d.imds.RevokeTokens(containerID); err != nil {
 			return nil, fmt.Errorf("revoking IMDS tokens for instance %s: %w", instanceID, err)
 		}
@@ -967,6 +1213,25 @@ func (d *Dispatcher) terminateInstancesWithStateReason(
 	return apiInstanceChanges(changes), nil
 }
 
+func (d *Dispatcher) dispatchGetConsoleOutput(ctx context.Context, req *api.GetConsoleOutputRequest) (*api.GetConsoleOutputResponse, error) {
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
+	consoleOutput, err := d.exe.InstanceConsoleOutput(ctx, executorInstanceID(req.InstanceID))
+	if err != nil {
+		return nil, executorError(err)
+	}
+	resp := &api.GetConsoleOutputResponse{
+		InstanceID: req.InstanceID,
+		Output:     base64.StdEncoding.EncodeToString(consoleOutput.Output),
+	}
+	if !consoleOutput.Timestamp.IsZero() {
+		timestamp := consoleOutput.Timestamp.UTC()
+		resp.Timestamp = &timestamp
+	}
+	return resp, nil
+}
+
 func (d *Dispatcher) dispatchModifyInstanceMetadataOptions(ctx context.Context, req *api.ModifyInstanceMetadataOptionsRequest) (*api.ModifyInstanceMetadataOptionsResponse, error) {
 	if req.DryRun {
 		return nil, api.DryRunError()
@@ -974,9 +1239,10 @@ func (d *Dispatcher) dispatchModifyInstanceMetadataOptions(ctx context.Context,
 	if _, err := d.findInstance(ctx, req.InstanceID); err != nil {
 		return nil, err
 	}
+	containerID := string(executorInstanceID(req.InstanceID))
 
 	httpEndpoint := imdsEndpointEnabled
-	if !d.imds.Enabled(string(executorInstanceID(req.InstanceID))) {
+	if !d.imds.Enabled(containerID) {
 		httpEndpoint = imdsEndpointDisabled
 	}
 	if req.HTTPEndpoint != nil {
@@ -989,17 +1255,200 @@ func (d *Dispatcher) dispatchModifyInstanceMetadataOptions(ctx context.Context,
 			return nil, api.InvalidParameterValueError("HttpEndpoint", *req.HTTPEndpoint)
 		}
 	}
+	options := d.imds.MetadataOptions(containerID)
+	if req.HTTPTokens != nil {
+		switch strings.ToLower(*req.HTTPTokens) {
+		case imdsHTTPTokensOptional:
+			options.HTTPTokens = imdsHTTPTokensOptional
+		case imdsHTTPTokensRequired:
+			options.HTTPTokens = imdsHTTPTokensRequired
+		default:
+			return nil, api.InvalidParameterValueError("HttpTokens", *req.HTTPTokens)
+		}
+	}
+	if req.HTTPPutResponseHopLimit != nil {
+		hopLimit := *req.HTTPPutResponseHopLimit
+		if hopLimit < imdsMinPutResponseHopLimit || hopLimit > imdsMaxPutResponseHopLimit {
+			return nil, api.InvalidParameterValueError("HttpPutResponseHopLimit", strconv.Itoa(hopLimit))
+		}
+		options.HTTPPutResponseHopLimit = hopLimit
+	}
 
-	if err := d.imds.SetEnabled(string(executorInstanceID(req.InstanceID)), httpEndpoint == imdsEndpointEnabled); err != nil {
+	if err := d.imds.SetEnabled(containerID, httpEndpoint == imdsEndpointEnabled); err != nil {
 		return nil, fmt.Errorf("setting IMDS endpoint state for instance %s: %w", req.InstanceID, err)
 	}
+	if err := d.imds.SetMetadataOptions(containerID, options); err != nil {
+		return nil, fmt.Errorf("setting IMDS metadata options for instance %s: %w", req.InstanceID, err)
+	}
 	instanceID := req.InstanceID
 	return &api.ModifyInstanceMetadataOptionsResponse{
 		InstanceID:              &instanceID,
-		InstanceMetadataOptions: instanceMetadataOptions(httpEndpoint == imdsEndpointEnabled),
+		InstanceMetadataOptions: d.describeInstanceMetadataOptions(executor.InstanceID(containerID)),
 	}, nil
 }
 
+func (d *Dispatcher) dispatchModifyInstanceAttribute(ctx context.Context, req *api.ModifyInstanceAttributeRequest) (*api.ModifyInstanceAttributeResponse, error) {
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
+	if _, err := d.findInstance(ctx, req.InstanceID); err != nil {
+		return nil, err
+	}
+	disableAPITermination, err := modifyInstanceAttributeDisableAPITermination(req)
+	if err != nil {
+		return nil, err
+	}
+	if disableAPITermination != nil {
+		// Unlike the other attributes, termination protection can be
+		// changed while the instance is running.
+		if err := d.setInstanceDisableAPITermination(ctx, req.InstanceID, *disableAPITermination); err != nil {
+			return nil, err
+		}
+		return &api.ModifyInstanceAttributeResponse{Return: true}, nil
+	}
+	modifyReq, err := modifyInstanceAttributeRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if modifyReq.InstanceType != nil {
+		matchInput := d.runInstancesMatchInputForInstanceType(*modifyReq.InstanceType)
+		modifyReq.VCPUs = matchInput.VCPU
+		modifyReq.MemoryMiB = matchInput.MemoryMiB
+	}
+
+	descs, err := d.exe.DescribeInstances(ctx, executor.DescribeInstancesRequest{
+		InstanceIDs: []executor.InstanceID{modifyReq.InstanceID},
+	})
+	if err != nil {
+		return nil, executorError(err)
+	}
+	descs = d.applyAsyncStopStates(descs)
+	if len(descs) == 0 {
+		return nil, api.ErrWithCode(api.ErrorCodeInstanceNotFound, fmt.Errorf("instance %s doesn't exist", req.InstanceID))
+	}
+	if descs[0].InstanceState.Name != api.InstanceStateStopped.Name {
+		//nolint
+		err := fmt.Errorf("The instance '%s' is not in the 'stopped' state.", req.InstanceID)
+		return nil, api.ErrWithCode(api.ErrorCodeIncorrectInstanceState, err)
+	}
+
+	if err := d.exe.ModifyInstanceAttribute(ctx, modifyReq); err != nil {
+		return nil, executorError(err)
+	}
+	if modifyReq.UserData != nil {
+		if *modifyReq.UserData == "" {
+			err = d.storage.RemoveResourceAttributes(req.InstanceID, []storage.Attribute{{Key: attributeNameInstanceUserData}})
+		} else {
+			err = d.storage.SetResourceAttributes(req.InstanceID, []storage.Attribute{{Key: attributeNameInstanceUserData, Value: *modifyReq.UserData}})
+		}
+		if err != nil {
+			return nil, fmt.Errorf("storing user data for instance %s: %w", req.InstanceID, err)
+		}
+	}
+	api.Logger(ctx).Info("modified instance attribute", slog.String("instance_id", req.InstanceID))
+	return &api.ModifyInstanceAttributeResponse{Return: true}, nil
+}
+
+// modifyInstanceAttributeRequest resolves the attribute being modified, which
+// can be given either as Attribute/Value or as a per-attribute parameter.
+func modifyInstanceAttributeRequest(req *api.ModifyInstanceAttributeRequest) (executor.ModifyInstanceAttributeRequest, error) {
+	out := executor.ModifyInstanceAttributeRequest{
+		InstanceID: executorInstanceID(req.InstanceID),
+	}
+	instanceType := req.InstanceType
+	userData := req.UserData
+	if req.Attribute != nil {
+		value := &api.AttributeValue{Value: req.Value}
+		switch *req.Attribute {
+		case "instanceType":
+			instanceType = value
+		case "userData":
+			userData = value
+		default:
+			return out, api.InvalidParameterValueError("Attribute", *req.Attribute)
+		}
+	}
+	if instanceType != nil {
+		if instanceType.Value == nil || strings.TrimSpace(*instanceType.Value) == "" {
+			return out, api.InvalidParameterValueError("InstanceType", "<empty>")
+		}
+		value := strings.TrimSpace(*instanceType.Value)
+		out.InstanceType = &value
+	}
+	if userData != nil {
+		value := ""
+		if userData.Value != nil {
+			value = normalizeUserData(*userData.Value)
+		}
+		out.UserData = &value
+	}
+	if out.InstanceType == nil && out.UserData == nil {
+		return out, api.InvalidParameterValueError("Attribute", "<missing>")
+	}
+	return out, nil
+}
+
+func (d *Dispatcher) dispatchDescribeInstanceAttribute(ctx context.Context, req *api.DescribeInstanceAttributeRequest) (*api.DescribeInstanceAttributeResponse, error) {
+	if _, err := d.findInstance(ctx, req.InstanceID); err != nil {
+		return nil, err
+	}
+	switch req.Attribute {
+	case "instanceType", "userData", "rootDeviceName", "instanceInitiatedShutdownBehavior",
+		"disableApiTermination", "blockDeviceMapping", "groupSet":
+	default:
+		return nil, api.InvalidParameterValueError("Attribute", req.Attribute)
+	}
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
+	attrs, err := d.storage.ResourceAttributes(req.InstanceID)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving instance attributes: %w", err)
+	}
+
+	resp := &api.DescribeInstanceAttributeResponse{InstanceID: req.InstanceID}
+	switch req.Attribute {
+	case "instanceType":
+		descs, err := d.exe.DescribeInstances(ctx, executor.DescribeInstancesRequest{
+			InstanceIDs: []executor.InstanceID{executorInstanceID(req.InstanceID)},
+		})
+		if err != nil {
+			return nil, executorError(err)
+		}
+		if len(descs) == 0 {
+			return nil, api.ErrWithCode(api.ErrorCodeInstanceNotFound, fmt.Errorf("instance %s doesn't exist", req.InstanceID))
+		}
+		resp.InstanceType = &api.AttributeValueResponse{Value: &descs[0].InstanceType}
+	case "userData":
+		resp.UserData = &api.AttributeValueResponse{}
+		if userData, _ := attrs.Key(attributeNameInstanceUserData); userData != "" {
+			resp.UserData.Value = new(base64.StdEncoding.EncodeToString([]byte(userData)))
+		}
+	case "rootDeviceName":
+		rootDeviceName, _ := attrs.Key(attributeNameInstanceRootDeviceName)
+		if rootDeviceName == "" {
+			rootDeviceName = defaultRootDeviceName
+		}
+		resp.RootDeviceName = &api.AttributeValueResponse{Value: &rootDeviceName}
+	case "instanceInitiatedShutdownBehavior":
+		resp.InstanceInitiatedShutdownBehavior = &api.AttributeValueResponse{Value: new("stop")}
+	case "disableApiTermination":
+		resp.DisableAPITermination = &api.AttributeBooleanValue{Value: instanceDisableAPITermination(attrs)}
+	case "blockDeviceMapping":
+		blockDeviceMappings, err := d.instanceBlockDeviceMappings(ctx, []string{req.InstanceID})
+		if err != nil {
+			return nil, err
+		}
+		resp.BlockDeviceMappings = blockDeviceMappings[req.InstanceID]
+	case "groupSet":
+		resp.Groups, err = d.instanceSecurityGroups(attrs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
 func (d *Dispatcher) findInstance(ctx context.Context, instanceID string) (*storage.Resource, error) {
 	instance, err := d.findResource(ctx, types.ResourceTypeInstance, instanceID)
 	if err != nil {
@@ -1024,7 +1473,10 @@ func (d *Dispatcher) apiInstance(desc *executor.InstanceDescription) (api.Instan
 			tags = append(tags, api.Tag{Key: attr.TagKey(), Value: attr.Value})
 		}
 	}
+	platformDetailsOverride, _ := attrs.Key(storage.TagAttributeName(platformDetailsOverrideTagKey))
+	platform, platformDetails := instancePlatform(desc.Platform, platformDetailsOverride)
 	availabilityZone, _ := attrs.Key(attributeNameAvailabilityZone)
+	placementGroupName, _ := attrs.Key(attributeNameInstancePlacementGroupName)
 	subnetID, _ := attrs.Key(attributeNameSubnetID)
 	if subnetID == "" {
 		subnetID = defaultSubnetID
@@ -1040,15 +1492,38 @@ func (d *Dispatcher) apiInstance(desc *executor.InstanceDescription) (api.Instan
 		lifecycle := instanceMarketTypeSpot
 		instanceLifecycle = &lifecycle
 	}
+	securityGroups, err := d.instanceSecurityGroups(attrs)
+	if err != nil {
+		return api.Instance{}, err
+	}
+	rootDeviceName, _ := attrs.Key(attributeNameInstanceRootDeviceName)
+	if rootDeviceName == "" {
+		rootDeviceName = defaultRootDeviceName
+	}
+	publicIP := desc.PublicIP
+	if elasticIP := instanceElasticIP(attrs); elasticIP != "" {
+		publicIP = elasticIP
+	}
 	privateDNSName := privateDNSNameFromIP(desc.PrivateIP, d.opts.Region, desc.PrivateDNSName)
-	publicDNSName := publicDNSNameFromIP(desc.PublicIP, d.opts.Region, desc.PrivateDNSName)
+	publicDNSName := publicDNSNameFromIP(publicIP, d.opts.Region, desc.PrivateDNSName)
 	networkInterface := primaryNetworkInterface(
 		instanceID,
 		desc.PrivateIP,
-		desc.PublicIP,
+		publicIP,
 		privateDNSName,
 		publicDNSName,
+		securityGroups,
 	)
+	secondaryPrivateIPs, err := d.instanceSecondaryPrivateIPs(instanceID)
+	if err != nil {
+		return api.Instance{}, err
+	}
+	for _, ip := range secondaryPrivateIPs {
+		networkInterface.PrivateIPAddresses = append(networkInterface.PrivateIPAddresses, api.InstancePrivateIPAddressAssociation{
+			PrivateDNSName: privateDNSNameFromIP(ip, d.opts.Region, ""),
+			PrivateIP:      ip,
+		})
+	}
 	return api.Instance{
 		InstanceID:            instanceID,
 		ImageID:               desc.ImageID,
@@ -1058,33 +1533,90 @@ func (d *Dispatcher) apiInstance(desc *executor.InstanceDescription) (api.Instan
 		PrivateDNSName:        privateDNSName,
 		DNSName:               publicDNSName,
 		KeyName:               keyName,
+		ClientToken:           instanceClientToken(attrs),
 		InstanceType:          desc.InstanceType,
 		InstanceLifecycle:     instanceLifecycle,
 		LaunchTime:            desc.LaunchTime,
+		Monitoring:            api.Monitoring{State: instanceMonitoringState(attrs)},
 		Architecture:          desc.Architecture,
+		Platform:              platform,
+		PlatformDetails:       platformDetails,
+		RootDeviceType:        instanceRootDeviceType(attrs),
+		RootDeviceName:        rootDeviceName,
 		SubnetID:              subnetID,
 		VPCID:                 vpcID,
 		PrivateIPAddress:      desc.PrivateIP,
-		PublicIPAddress:       desc.PublicIP,
+		PublicIPAddress:       publicIP,
 		NetworkInterfaces: []api.InstanceNetworkInterface{
 			networkInterface,
 		},
-		MetadataOptions: instanceMetadataOptions(d.imds.Enabled(string(desc.InstanceID))),
-		TagSet:          tags,
+		SecurityGroups:     securityGroups,
+		MetadataOptions:    d.describeInstanceMetadataOptions(desc.InstanceID),
+		IamInstanceProfile: apiInstanceProfile(attrs),
+		TagSet:             tags,
 		Placement: api.Placement{
 			AvailabilityZone: availabilityZone,
+			GroupName:        placementGroupName,
 		},
+		ReservationID: instanceReservationID(attrs),
 	}, nil
 }
 
-func instanceMetadataOptions(enabled bool) *api.InstanceMetadataOptions {
+// imdsInstanceMetadata resolves the placement and network details served by
+// IMDS for the instance running in containerID, from the same data used by
+// DescribeInstances.
+func (d *Dispatcher) imdsInstanceMetadata(ctx context.Context, containerID string) (imdsInstanceMetadata, error) {
+	d.dispatchMu.Lock()
+	defer d.dispatchMu.Unlock()
+
+	descriptions, err := d.exe.DescribeInstances(ctx, executor.DescribeInstancesRequest{
+		InstanceIDs: []executor.InstanceID{executor.InstanceID(containerID)},
+	})
+	if err != nil {
+		return imdsInstanceMetadata{}, fmt.Errorf("describing instance %s: %w", containerID, err)
+	}
+	if len(descriptions) == 0 {
+		return imdsInstanceMetadata{}, errIMDSInstanceNotFound
+	}
+	instance, err := d.apiInstance(&descriptions[0])
+	if err != nil {
+		return imdsInstanceMetadata{}, err
+	}
+	availabilityZone := instance.Placement.AvailabilityZone
+	if availabilityZone == "" {
+		availabilityZone = defaultAvailabilityZone(d.opts.Region)
+	}
+	metadata := imdsInstanceMetadata{
+		AvailabilityZone: availabilityZone,
+		Region:           d.opts.Region,
+		LocalIPv4:        instance.PrivateIPAddress,
+		PublicIPv4:       instance.PublicIPAddress,
+		LocalHostname:    instance.PrivateDNSName,
+		PublicHostname:   instance.DNSName,
+	}
+	if len(instance.NetworkInterfaces) > 0 {
+		metadata.MAC = instance.NetworkInterfaces[0].MacAddress
+	}
+	if instance.IamInstanceProfile != nil {
+		metadata.InstanceProfileArn = instance.IamInstanceProfile.Arn
+		metadata.InstanceProfileID = instance.IamInstanceProfile.ID
+	}
+	return metadata, nil
+}
+
+// describeInstanceMetadataOptions returns the IMDS settings of an instance,
+// as reported by DescribeInstances and ModifyInstanceMetadataOptions.
+func (d *Dispatcher) describeInstanceMetadataOptions(instanceID executor.InstanceID) *api.InstanceMetadataOptions {
 	httpEndpoint := imdsEndpointDisabled
-	if enabled {
+	if d.imds.Enabled(string(instanceID)) {
 		httpEndpoint = imdsEndpointEnabled
 	}
+	options := d.imds.MetadataOptions(string(instanceID))
 	return &api.InstanceMetadataOptions{
-		HTTPEndpoint: &httpEndpoint,
-		State:        new(imdsStateApplied),
+		HTTPEndpoint:            &httpEndpoint,
+		HTTPTokens:              &options.HTTPTokens,
+		HTTPPutResponseHopLimit: &options.HTTPPutResponseHopLimit,
+		State:                   new(imdsStateApplied),
 	}
 }
 
@@ -1122,6 +1654,7 @@ func (d *Dispatcher) terminatedInstanceFromStorage(instanceID string) (api.Insta
 		return api.Instance{}, false, nil
 	}
 	availabilityZone, _ := attrs.Key(attributeNameAvailabilityZone)
+	placementGroupName, _ := attrs.Key(attributeNameInstancePlacementGroupName)
 	subnetID, _ := attrs.Key(attributeNameSubnetID)
 	if subnetID == "" {
 		subnetID = defaultSubnetID
@@ -1143,9 +1676,11 @@ func (d *Dispatcher) terminatedInstanceFromStorage(instanceID string) (api.Insta
 		StateReason:           stateReasonFromAttributes(attrs),
 		InstanceLifecycle:     instanceLifecycle,
 		LaunchTime:            terminatedAt,
-		Placement:             api.Placement{AvailabilityZone: availabilityZone},
+		Placement:             api.Placement{AvailabilityZone: availabilityZone, GroupName: placementGroupName},
 		SubnetID:              subnetID,
 		VPCID:                 vpcID,
+		ClientToken:           instanceClientToken(attrs),
+		ReservationID:         instanceReservationID(attrs),
 	}, true, nil
 }
 
@@ -1180,13 +1715,32 @@ func dashedIPv4ForDNS(ip string) (string, bool) {
 	return strings.ReplaceAll(addr.String(), ".", "-"), true
 }
 
-func primaryNetworkInterface(instanceID string, privateIP string, publicIP string, privateDNSName string, publicDNSName string) api.InstanceNetworkInterface {
-	eniSuffix := strings.TrimPrefix(instanceID, instanceIDPrefix)
-	if len(eniSuffix) > 17 {
-		eniSuffix = eniSuffix[:17]
+// instancePlatform returns the Platform and PlatformDetails reported for an
+// instance. Instances are Linux/UNIX unless their image targets Windows. The
+// dc2:platform-details tag overrides the detected details, for callers that
+// need to classify instances like a particular AMI would (e.g. "Red Hat
+// Enterprise Linux" or "Windows with SQL Server Standard").
+func instancePlatform(imagePlatform string, detailsOverride string) (*string, string) {
+	details := platformDetailsLinux
+	if strings.EqualFold(imagePlatform, platformWindows) {
+		details = platformDetailsWindows
+	}
+	if override := strings.TrimSpace(detailsOverride); override != "" {
+		details = override
 	}
-	networkInterfaceID := "eni-" + eniSuffix
-	attachmentID := "eni-attach-" + eniSuffix
+	if !strings.HasPrefix(strings.ToLower(details), platformWindows) {
+		return nil, details
+	}
+	platform := platformWindows
+	return &platform, details
+}
+
+// primaryNetworkInterface synthesizes the primary network interface of an
+// instance. The interface reports the same security groups as the instance,
+// since dc2 doesn't model per-interface groups.
+func primaryNetworkInterface(instanceID string, privateIP string, publicIP string, privateDNSName string, publicDNSName string, groups []api.Group) api.InstanceNetworkInterface {
+	networkInterfaceID := instanceNetworkInterfaceID(instanceID)
+	attachmentID := networkInterfaceAttachmentIDPrefix + instanceNetworkInterfaceSuffix(instanceID)
 	macAddress := syntheticMACAddress(instanceID)
 	association := &api.InstanceNetworkInterfaceAssociation{
 		PublicDNSName: publicDNSName,
@@ -1219,6 +1773,7 @@ func primaryNetworkInterface(instanceID string, privateIP string, publicIP strin
 				Association:    association,
 			},
 		},
+		Groups: groups,
 	}
 }
 
@@ -1269,7 +1824,15 @@ func defaultAvailabilityZone(region string) string {
 	return region + "a"
 }
 
-func (d *Dispatcher) runInstancesAvailabilityZone(req *api.RunInstancesRequest) (string, error) {
+// runInstancesAvailabilityZone returns the zone to launch instances in, which
+// is the zone of their subnet when it has one.
+func (d *Dispatcher) runInstancesAvailabilityZone(req *api.RunInstancesRequest, subnetAvailabilityZone string) (string, error) {
+	if subnetAvailabilityZone != "" {
+		if req.Placement != nil && req.Placement.AvailabilityZone != "" && req.Placement.AvailabilityZone != subnetAvailabilityZone {
+			return "", api.InvalidParameterValueError("Placement.AvailabilityZone", req.Placement.AvailabilityZone)
+		}
+		return subnetAvailabilityZone, nil
+	}
 	if req.Placement == nil || req.Placement.AvailabilityZone == "" {
 		return defaultAvailabilityZone(d.opts.Region), nil
 	}
@@ -1299,6 +1862,17 @@ func subnetVPCID(subnetID string) string {
 	return "vpc-" + fmt.Sprintf("%x", hash)[:17]
 }
 
+// validateArchitecture returns an InvalidParameterValue error for param if
+// arch is not empty and not an architecture dc2 can run images for.
+func validateArchitecture(arch string, param string) error {
+	switch arch {
+	case "", "i386", "x86_64", "arm64":
+		return nil
+	default:
+		return api.InvalidParameterValueError(param, arch)
+	}
+}
+
 func normalizeUserData(raw string) string {
 	if decoded, err := base64.StdEncoding.DecodeString(raw); err == nil {
 		return string(decoded)
@@ -0,0 +1,185 @@
+package dc2
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/executor"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+)
+
+// architectureExecutor reports the architecture requested when launching
+// each instance, defaulting to x86_64 like an amd64 Docker host.
+type architectureExecutor struct {
+	*scalingExecutor
+	architectures map[executor.InstanceID]string
+}
+
+func (e *architectureExecutor) CreateInstances(ctx context.Context, req executor.CreateInstancesRequest) ([]executor.InstanceID, error) {
+	ids, err := e.scalingExecutor.CreateInstances(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, id := range ids {
+		e.architectures[id] = req.Architecture
+	}
+	return ids, nil
+}
+
+func (e *architectureExecutor) DescribeInstances(ctx context.Context, req executor.DescribeInstancesRequest) ([]executor.InstanceDescription, error) {
+	descs, err := e.scalingExecutor.DescribeInstances(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i := range descs {
+		descs[i].Architecture = e.architectures[descs[i].InstanceID]
+		if descs[i].Architecture == "" {
+			descs[i].Architecture = "x86_64"
+		}
+	}
+	return descs, nil
+}
+
+func TestInstanceArchitecture(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	d := &Dispatcher{
+		exe: &architectureExecutor{
+			scalingExecutor: newScalingExecutor(),
+			architectures:   make(map[executor.InstanceID]string),
+		},
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+		opts:    DispatcherOptions{Region: "us-east-1"},
+	}
+	runInstance := func(req *api.RunInstancesRequest) string {
+		t.Helper()
+		req.MinCount = 1
+		req.MaxCount = 1
+		resp, err := d.dispatchRunInstances(ctx, req)
+		require.NoError(t, err)
+		require.Len(t, resp.InstancesSet, 1)
+		return resp.InstancesSet[0].InstanceID
+	}
+	architecture := func(instanceID string) string {
+		t.Helper()
+		resp, err := d.dispatchDescribeInstances(ctx, &api.DescribeInstancesRequest{InstanceIDs: []string{instanceID}})
+		require.NoError(t, err)
+		require.Len(t, resp.ReservationSet, 1)
+		require.Len(t, resp.ReservationSet[0].InstancesSet, 1)
+		return resp.ReservationSet[0].InstancesSet[0].Architecture
+	}
+
+	imageDefault := runInstance(&api.RunInstancesRequest{ImageID: "nginx", InstanceType: "t3.micro"})
+	assert.Equal(t, "x86_64", architecture(imageDefault))
+	requested := runInstance(&api.RunInstancesRequest{ImageID: "nginx", InstanceType: "t4g.micro", Architecture: "arm64"})
+	assert.Equal(t, "arm64", architecture(requested))
+
+	var apiErr *api.Error
+	_, err := d.dispatchRunInstances(ctx, &api.RunInstancesRequest{
+		ImageID:      "nginx",
+		InstanceType: "t3.micro",
+		MinCount:     1,
+		MaxCount:     1,
+		Architecture: "sparc",
+	})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, api.ErrorCodeInvalidParameterValue, apiErr.Code)
+
+	// Launch templates
+	createResp, err := d.dispatchCreateLaunchTemplate(ctx, &api.CreateLaunchTemplateRequest{
+		LaunchTemplateName: "lt",
+		LaunchTemplateData: api.LaunchTemplateData{
+			ImageID:      "nginx",
+			InstanceType: "t4g.micro",
+			Architecture: "arm64",
+		},
+	})
+	require.NoError(t, err)
+	launchTemplateID := createResp.LaunchTemplate.LaunchTemplateID
+	versionsResp, err := d.dispatchDescribeLaunchTemplateVersions(ctx, &api.DescribeLaunchTemplateVersionsRequest{
+		LaunchTemplateID: launchTemplateID,
+	})
+	require.NoError(t, err)
+	require.Len(t, versionsResp.LaunchTemplateVersions, 1)
+	data := versionsResp.LaunchTemplateVersions[0].LaunchTemplateData
+	require.NotNil(t, data)
+	require.NotNil(t, data.Architecture)
+	assert.Equal(t, "arm64", *data.Architecture)
+
+	launchTemplate := &api.AutoScalingLaunchTemplateSpecification{LaunchTemplateID: launchTemplateID}
+	fromTemplate := runInstance(&api.RunInstancesRequest{LaunchTemplate: launchTemplate})
+	assert.Equal(t, "arm64", architecture(fromTemplate))
+	overridden := runInstance(&api.RunInstancesRequest{LaunchTemplate: launchTemplate, Architecture: "x86_64"})
+	assert.Equal(t, "x86_64", architecture(overridden))
+
+	_, err = d.dispatchCreateLaunchTemplateVersion(ctx, &api.CreateLaunchTemplateVersionRequest{
+		LaunchTemplateID:   launchTemplateID,
+		LaunchTemplateData: api.LaunchTemplateData{Architecture: "sparc"},
+	})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, api.ErrorCodeInvalidParameterValue, apiErr.Code)
+
+	// Default architecture
+	d.opts.DefaultArchitecture = "arm64"
+	defaulted := runInstance(&api.RunInstancesRequest{ImageID: "nginx", InstanceType: "t4g.micro"})
+	assert.Equal(t, "arm64", architecture(defaulted))
+	explicit := runInstance(&api.RunInstancesRequest{ImageID: "nginx", InstanceType: "t3.micro", Architecture: "x86_64"})
+	assert.Equal(t, "x86_64", architecture(explicit))
+}
+
+func TestAutoScalingGroupLaunchTemplateArchitecture(t *testing.T) {
+	t.Parallel()
+
+	const groupName = "asg"
+	ctx := t.Context()
+	exe := &architectureExecutor{
+		scalingExecutor: newScalingExecutor(),
+		architectures:   make(map[executor.InstanceID]string),
+	}
+	d := &Dispatcher{
+		exe:     exe,
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+		opts:    DispatcherOptions{Region: "us-east-1"},
+	}
+
+	createResp, err := d.dispatchCreateLaunchTemplate(ctx, &api.CreateLaunchTemplateRequest{
+		LaunchTemplateName: "lt",
+		LaunchTemplateData: api.LaunchTemplateData{
+			ImageID:      "nginx",
+			InstanceType: "t4g.micro",
+			Architecture: "arm64",
+		},
+	})
+	require.NoError(t, err)
+	_, err = d.Dispatch(ctx, &api.CreateAutoScalingGroupRequest{
+		AutoScalingGroupName: groupName,
+		MinSize:              new(2),
+		MaxSize:              new(2),
+		LaunchTemplate: &api.AutoScalingLaunchTemplateSpecification{
+			LaunchTemplateID: createResp.LaunchTemplate.LaunchTemplateID,
+		},
+	})
+	require.NoError(t, err)
+
+	instanceIDs, err := d.autoScalingGroupManagedInstanceIDs(ctx, groupName)
+	require.NoError(t, err)
+	require.Len(t, instanceIDs, 2)
+	describeResp, err := d.dispatchDescribeInstances(ctx, &api.DescribeInstancesRequest{InstanceIDs: instanceIDs})
+	require.NoError(t, err)
+	require.Len(t, describeResp.ReservationSet, 1)
+	require.Len(t, describeResp.ReservationSet[0].InstancesSet, 2)
+	for _, instance := range describeResp.ReservationSet[0].InstancesSet {
+		assert.Equal(t, "arm64", instance.Architecture)
+	}
+}
@@ -0,0 +1,242 @@
+package dc2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/executor"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+const (
+	attributeNameInstanceCPUCredits = "CPUCredits"
+
+	cpuCreditsStandard  = "standard"
+	cpuCreditsUnlimited = "unlimited"
+)
+
+// resolveRunInstancesCPUCredits validates the CreditSpecification in a
+// RunInstances request, returning the empty string when none was given.
+func (d *Dispatcher) resolveRunInstancesCPUCredits(req *api.RunInstancesRequest, instanceType string) (string, error) {
+	if req.CreditSpecification == nil {
+		return "", nil
+	}
+	cpuCredits, err := parseCPUCredits("CreditSpecification.CpuCredits", req.CreditSpecification.CPUCredits)
+	if err != nil {
+		return "", err
+	}
+	if !d.instanceTypeIsBurstable(instanceType) {
+		return "", api.ErrWithCode(
+			"InvalidParameterCombination",
+			fmt.Errorf("CreditSpecification is not supported for instance type %s", instanceType),
+		)
+	}
+	return cpuCredits, nil
+}
+
+func parseCPUCredits(param string, value string) (string, error) {
+	cpuCredits := strings.ToLower(strings.TrimSpace(value))
+	switch cpuCredits {
+	case cpuCreditsStandard, cpuCreditsUnlimited:
+		return cpuCredits, nil
+	}
+	return "", api.InvalidParameterValueError(param, value)
+}
+
+// instanceTypeIsBurstable reports whether the instance type supports CPU
+// credits. Types missing from the catalog are considered burstable when they
+// belong to the T family.
+func (d *Dispatcher) instanceTypeIsBurstable(instanceType string) bool {
+	if d.instanceTypeCatalog != nil {
+		if data, ok := d.instanceTypeCatalog.InstanceTypes[instanceType]; ok {
+			return boolAt(data, "BurstablePerformanceSupported")
+		}
+	}
+	family, _, _ := strings.Cut(instanceType, ".")
+	switch family {
+	case "t2", "t3", "t3a", "t4g":
+		return true
+	}
+	return false
+}
+
+// defaultCPUCredits returns the credit option AWS uses for burstable
+// instances launched without a CreditSpecification.
+func defaultCPUCredits(instanceType string) string {
+	if strings.HasPrefix(instanceType, "t2.") {
+		return cpuCreditsStandard
+	}
+	return cpuCreditsUnlimited
+}
+
+func (d *Dispatcher) dispatchDescribeInstanceCreditSpecifications(
+	ctx context.Context,
+	req *api.DescribeInstanceCreditSpecificationsRequest,
+) (*api.DescribeInstanceCreditSpecificationsResponse, error) {
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
+	if err := d.validateInstanceIDCount(req.InstanceIDs); err != nil {
+		return nil, err
+	}
+	for _, f := range req.Filters {
+		if f.Name == nil {
+			return nil, api.InvalidParameterValueError("Filter.Name", "<missing>")
+		}
+		if *f.Name != "instance-id" {
+			return nil, api.InvalidParameterValueError("Filter.Name", *f.Name)
+		}
+	}
+	instanceIDs, err := d.applyFilters(types.ResourceTypeInstance, req.InstanceIDs, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range req.Filters {
+		instanceIDs = slices.DeleteFunc(instanceIDs, func(id string) bool {
+			return !slices.Contains(f.Values, id)
+		})
+	}
+	descriptions, err := d.exe.DescribeInstances(ctx, executor.DescribeInstancesRequest{
+		InstanceIDs: executorInstanceIDs(instanceIDs),
+	})
+	if err != nil {
+		return nil, executorError(err)
+	}
+
+	specs := make([]api.InstanceCreditSpecification, 0, len(descriptions))
+	for _, desc := range descriptions {
+		if !d.instanceTypeIsBurstable(desc.InstanceType) {
+			continue
+		}
+		instanceID := apiInstanceID(desc.InstanceID)
+		attrs, err := d.storage.ResourceAttributes(instanceID)
+		if err != nil {
+			return nil, fmt.Errorf("retrieving instance attributes: %w", err)
+		}
+		cpuCredits, _ := attrs.Key(attributeNameInstanceCPUCredits)
+		if cpuCredits == "" {
+			cpuCredits = defaultCPUCredits(desc.InstanceType)
+		}
+		specs = append(specs, api.InstanceCreditSpecification{
+			InstanceID: instanceID,
+			CPUCredits: cpuCredits,
+		})
+	}
+
+	specs, nextToken, err := applyNextToken(specs, req.NextToken, req.MaxResults)
+	if err != nil {
+		return nil, err
+	}
+	return &api.DescribeInstanceCreditSpecificationsResponse{
+		InstanceCreditSpecifications: specs,
+		NextToken:                    nextToken,
+	}, nil
+}
+
+func (d *Dispatcher) dispatchModifyInstanceCreditSpecification(
+	ctx context.Context,
+	req *api.ModifyInstanceCreditSpecificationRequest,
+) (*api.ModifyInstanceCreditSpecificationResponse, error) {
+	if len(req.InstanceCreditSpecifications) == 0 {
+		return nil, api.InvalidParameterValueError("InstanceCreditSpecification", "<empty>")
+	}
+	cpuCredits := make([]string, len(req.InstanceCreditSpecifications))
+	instanceIDs := make([]string, len(req.InstanceCreditSpecifications))
+	for i, spec := range req.InstanceCreditSpecifications {
+		instanceID := strings.TrimSpace(spec.InstanceID)
+		if instanceID == "" {
+			return nil, api.InvalidParameterValueError(fmt.Sprintf("InstanceCreditSpecification.%d.InstanceId", i+1), "<empty>")
+		}
+		value, err := parseCPUCredits(fmt.Sprintf("InstanceCreditSpecification.%d.CpuCredits", i+1), spec.CPUCredits)
+		if err != nil {
+			return nil, err
+		}
+		instanceIDs[i] = instanceID
+		cpuCredits[i] = value
+	}
+	if err := d.validateInstanceIDCount(instanceIDs); err != nil {
+		return nil, err
+	}
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
+
+	// Only describe instances that are still registered and not terminated,
+	// the rest are reported as unsuccessful.
+	var describable []string
+	failures := make(map[string]api.UnsuccessfulInstanceCreditSpecificationError)
+	for _, instanceID := range instanceIDs {
+		attrs, err := d.storage.ResourceAttributes(instanceID)
+		if err != nil {
+			if errors.As(err, &storage.ErrResourceNotFound{}) {
+				failures[instanceID] = api.UnsuccessfulInstanceCreditSpecificationError{
+					Code:    api.ErrorCodeInstanceNotFound,
+					Message: fmt.Sprintf("The instance ID '%s' does not exist", instanceID),
+				}
+				continue
+			}
+			return nil, fmt.Errorf("retrieving instance attributes: %w", err)
+		}
+		if terminatedAt, _ := attrs.Key(attributeNameInstanceTerminatedAt); terminatedAt != "" {
+			failures[instanceID] = api.UnsuccessfulInstanceCreditSpecificationError{
+				Code:    api.ErrorCodeIncorrectInstanceState,
+				Message: fmt.Sprintf("The instance '%s' is not in a state from which it can be modified", instanceID),
+			}
+			continue
+		}
+		describable = append(describable, instanceID)
+	}
+	instanceTypes := make(map[string]string, len(describable))
+	if len(describable) > 0 {
+		descriptions, err := d.exe.DescribeInstances(ctx, executor.DescribeInstancesRequest{
+			InstanceIDs: executorInstanceIDs(describable),
+		})
+		if err != nil {
+			return nil, executorError(err)
+		}
+		for _, desc := range descriptions {
+			instanceTypes[apiInstanceID(desc.InstanceID)] = desc.InstanceType
+		}
+	}
+
+	resp := &api.ModifyInstanceCreditSpecificationResponse{}
+	for i, instanceID := range instanceIDs {
+		failure, failed := failures[instanceID]
+		if !failed {
+			instanceType, found := instanceTypes[instanceID]
+			switch {
+			case !found:
+				failure, failed = api.UnsuccessfulInstanceCreditSpecificationError{
+					Code:    api.ErrorCodeInstanceNotFound,
+					Message: fmt.Sprintf("The instance ID '%s' does not exist", instanceID),
+				}, true
+			case !d.instanceTypeIsBurstable(instanceType):
+				failure, failed = api.UnsuccessfulInstanceCreditSpecificationError{
+					Code:    "InstanceCreditSpecification.NotSupported",
+					Message: fmt.Sprintf("The instance type %s does not support CPU credits", instanceType),
+				}, true
+			}
+		}
+		if failed {
+			resp.UnsuccessfulInstanceCreditSpecifications = append(resp.UnsuccessfulInstanceCreditSpecifications, api.UnsuccessfulInstanceCreditSpecification{
+				InstanceID: instanceID,
+				Error:      failure,
+			})
+			continue
+		}
+		if err := d.storage.SetResourceAttributes(instanceID, []storage.Attribute{
+			{Key: attributeNameInstanceCPUCredits, Value: cpuCredits[i]},
+		}); err != nil {
+			return nil, fmt.Errorf("storing CPU credits for instance %s: %w", instanceID, err)
+		}
+		resp.SuccessfulInstanceCreditSpecifications = append(resp.SuccessfulInstanceCreditSpecifications, api.SuccessfulInstanceCreditSpecification{
+			InstanceID: instanceID,
+		})
+	}
+	return resp, nil
+}
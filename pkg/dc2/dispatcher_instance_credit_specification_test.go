@@ -0,0 +1,226 @@
+package dc2
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/executor"
+	"github.com/fiam/dc2/pkg/dc2/instancetype"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+func TestResolveRunInstancesCPUCredits(t *testing.T) {
+	t.Parallel()
+
+	d := &Dispatcher{
+		instanceTypeCatalog: &instancetype.Catalog{
+			InstanceTypes: map[string]map[string]any{
+				"t3.micro": {
+					"InstanceType":                  "t3.micro",
+					"BurstablePerformanceSupported": true,
+				},
+				"m5.large": {
+					"InstanceType":                  "m5.large",
+					"BurstablePerformanceSupported": false,
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name         string
+		instanceType string
+		spec         *api.CreditSpecificationRequest
+		expected     string
+		errorCode    string
+	}{
+		{
+			name:         "no credit specification",
+			instanceType: "m5.large",
+		},
+		{
+			name:         "unlimited burstable type",
+			instanceType: "t3.micro",
+			spec:         &api.CreditSpecificationRequest{CPUCredits: "unlimited"},
+			expected:     cpuCreditsUnlimited,
+		},
+		{
+			name:         "burstable family missing from the catalog",
+			instanceType: "t2.nano",
+			spec:         &api.CreditSpecificationRequest{CPUCredits: "Standard"},
+			expected:     cpuCreditsStandard,
+		},
+		{
+			name:         "non-burstable type",
+			instanceType: "m5.large",
+			spec:         &api.CreditSpecificationRequest{CPUCredits: "unlimited"},
+			errorCode:    "InvalidParameterCombination",
+		},
+		{
+			name:         "invalid value",
+			instanceType: "t3.micro",
+			spec:         &api.CreditSpecificationRequest{CPUCredits: "infinite"},
+			errorCode:    api.ErrorCodeInvalidParameterValue,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cpuCredits, err := d.resolveRunInstancesCPUCredits(&api.RunInstancesRequest{CreditSpecification: tc.spec}, tc.instanceType)
+			if tc.errorCode != "" {
+				var apiErr *api.Error
+				require.ErrorAs(t, err, &apiErr)
+				assert.Equal(t, tc.errorCode, apiErr.Code)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, cpuCredits)
+		})
+	}
+}
+
+type instanceTypesExecutor struct {
+	*exitCleanupExecutor
+	instanceTypes map[executor.InstanceID]string
+}
+
+func (e *instanceTypesExecutor) DescribeInstances(_ context.Context, req executor.DescribeInstancesRequest) ([]executor.InstanceDescription, error) {
+	descs := make([]executor.InstanceDescription, len(req.InstanceIDs))
+	for i, instanceID := range req.InstanceIDs {
+		descs[i] = executor.InstanceDescription{
+			InstanceID:    instanceID,
+			InstanceType:  e.instanceTypes[instanceID],
+			InstanceState: api.InstanceStateRunning,
+		}
+	}
+	return descs, nil
+}
+
+func TestDescribeInstanceCreditSpecifications(t *testing.T) {
+	t.Parallel()
+
+	const (
+		standardID     = "i-00000000000000001"
+		nonBurstableID = "i-00000000000000002"
+		unlimitedID    = "i-00000000000000003"
+		configuredID   = "i-00000000000000004"
+	)
+	ctx := context.Background()
+	d := &Dispatcher{
+		exe: &instanceTypesExecutor{
+			exitCleanupExecutor: &exitCleanupExecutor{},
+			instanceTypes: map[executor.InstanceID]string{
+				executorInstanceID(standardID):     "t2.micro",
+				executorInstanceID(nonBurstableID): "m5.large",
+				executorInstanceID(unlimitedID):    "t3.micro",
+				executorInstanceID(configuredID):   "t4g.small",
+			},
+		},
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+	for _, instanceID := range []string{standardID, nonBurstableID, unlimitedID, configuredID} {
+		require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeInstance, ID: instanceID}))
+	}
+	require.NoError(t, d.storage.SetResourceAttributes(configuredID, []storage.Attribute{
+		{Key: attributeNameInstanceCPUCredits, Value: cpuCreditsStandard},
+	}))
+
+	// Non-burstable instances are omitted, the rest report their setting or
+	// the default of their family
+	resp, err := d.dispatchDescribeInstanceCreditSpecifications(ctx, &api.DescribeInstanceCreditSpecificationsRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []api.InstanceCreditSpecification{
+		{InstanceID: standardID, CPUCredits: cpuCreditsStandard},
+		{InstanceID: unlimitedID, CPUCredits: cpuCreditsUnlimited},
+		{InstanceID: configuredID, CPUCredits: cpuCreditsStandard},
+	}, resp.InstanceCreditSpecifications)
+	assert.Nil(t, resp.NextToken)
+
+	resp, err = d.dispatchDescribeInstanceCreditSpecifications(ctx, &api.DescribeInstanceCreditSpecificationsRequest{
+		InstanceIDs: []string{nonBurstableID, unlimitedID},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []api.InstanceCreditSpecification{{InstanceID: unlimitedID, CPUCredits: cpuCreditsUnlimited}}, resp.InstanceCreditSpecifications)
+
+	var pages [][]api.InstanceCreditSpecification
+	req := &api.DescribeInstanceCreditSpecificationsRequest{
+		PaginableRequest: api.PaginableRequest{MaxResults: new(2)},
+	}
+	for {
+		resp, err := d.dispatchDescribeInstanceCreditSpecifications(ctx, req)
+		require.NoError(t, err)
+		pages = append(pages, resp.InstanceCreditSpecifications)
+		if resp.NextToken == nil {
+			break
+		}
+		req.NextToken = resp.NextToken
+	}
+	require.Len(t, pages, 2)
+	assert.Len(t, pages[0], 2)
+	assert.Equal(t, []api.InstanceCreditSpecification{{InstanceID: configuredID, CPUCredits: cpuCreditsStandard}}, pages[1])
+}
+
+func TestModifyInstanceCreditSpecification(t *testing.T) {
+	t.Parallel()
+
+	const (
+		burstableID    = "i-00000000000000001"
+		nonBurstableID = "i-00000000000000002"
+		missingID      = "i-00000000000000003"
+	)
+	ctx := context.Background()
+	d := &Dispatcher{
+		exe: &instanceTypesExecutor{
+			exitCleanupExecutor: &exitCleanupExecutor{},
+			instanceTypes: map[executor.InstanceID]string{
+				executorInstanceID(burstableID):    "t2.micro",
+				executorInstanceID(nonBurstableID): "m5.large",
+			},
+		},
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+	for _, instanceID := range []string{burstableID, nonBurstableID} {
+		require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeInstance, ID: instanceID}))
+	}
+
+	describeCPUCredits := func() []api.InstanceCreditSpecification {
+		resp, err := d.dispatchDescribeInstanceCreditSpecifications(ctx, &api.DescribeInstanceCreditSpecificationsRequest{})
+		require.NoError(t, err)
+		return resp.InstanceCreditSpecifications
+	}
+	assert.Equal(t, []api.InstanceCreditSpecification{{InstanceID: burstableID, CPUCredits: cpuCreditsStandard}}, describeCPUCredits())
+
+	resp, err := d.dispatchModifyInstanceCreditSpecification(ctx, &api.ModifyInstanceCreditSpecificationRequest{
+		InstanceCreditSpecifications: []api.InstanceCreditSpecificationRequest{
+			{InstanceID: burstableID, CPUCredits: "unlimited"},
+			{InstanceID: nonBurstableID, CPUCredits: "unlimited"},
+			{InstanceID: missingID, CPUCredits: "standard"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []api.SuccessfulInstanceCreditSpecification{{InstanceID: burstableID}}, resp.SuccessfulInstanceCreditSpecifications)
+	require.Len(t, resp.UnsuccessfulInstanceCreditSpecifications, 2)
+	assert.Equal(t, nonBurstableID, resp.UnsuccessfulInstanceCreditSpecifications[0].InstanceID)
+	assert.Equal(t, "InstanceCreditSpecification.NotSupported", resp.UnsuccessfulInstanceCreditSpecifications[0].Error.Code)
+	assert.Equal(t, missingID, resp.UnsuccessfulInstanceCreditSpecifications[1].InstanceID)
+	assert.Equal(t, api.ErrorCodeInstanceNotFound, resp.UnsuccessfulInstanceCreditSpecifications[1].Error.Code)
+	assert.Equal(t, []api.InstanceCreditSpecification{{InstanceID: burstableID, CPUCredits: cpuCreditsUnlimited}}, describeCPUCredits())
+
+	_, err = d.dispatchModifyInstanceCreditSpecification(ctx, &api.ModifyInstanceCreditSpecificationRequest{
+		InstanceCreditSpecifications: []api.InstanceCreditSpecificationRequest{
+			{InstanceID: burstableID, CPUCredits: "infinite"},
+		},
+	})
+	var apiErr *api.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, api.ErrorCodeInvalidParameterValue, apiErr.Code)
+}
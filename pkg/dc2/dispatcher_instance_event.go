@@ -0,0 +1,142 @@
+package dc2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+)
+
+const (
+	instanceEventIDPrefix = "instance-event-"
+
+	attributeNameInstanceScheduledEvents = "InstanceScheduledEvents"
+)
+
+// instanceEventDescriptions maps the scheduled event codes reported by EC2
+// to the description used when none is given.
+var instanceEventDescriptions = map[string]string{
+	"instance-reboot":     "The instance is scheduled for a reboot",
+	"instance-retirement": "The instance is scheduled for retirement",
+	"instance-stop":       "The instance is scheduled to be stopped",
+	"system-maintenance":  "The instance is scheduled for system maintenance",
+	"system-reboot":       "The instance is scheduled for a system reboot",
+}
+
+// InstanceEvent is a scheduled event reported by DescribeInstanceStatus.
+// Events are informational only: dc2 never acts on them.
+type InstanceEvent struct {
+	ID          string    `json:"instanceEventId"`
+	Code        string    `json:"code"`
+	Description string    `json:"description"`
+	NotBefore   time.Time `json:"notBefore"`
+	NotAfter    time.Time `json:"notAfter,omitzero"`
+}
+
+// ScheduleInstanceEvent adds a scheduled event to the given instance. The
+// event code must be one EC2 reports, the description defaults to the EC2
+// one for the code and NotBefore defaults to the current time. It returns
+// the event with its assigned ID.
+func (d *Dispatcher) ScheduleInstanceEvent(ctx context.Context, instanceID string, event InstanceEvent) (InstanceEvent, error) {
+	defaultDescription, found := instanceEventDescriptions[event.Code]
+	if !found {
+		return InstanceEvent{}, api.InvalidParameterValueError("Code", event.Code)
+	}
+	if event.Description == "" {
+		event.Description = defaultDescription
+	}
+	if event.NotBefore.IsZero() {
+		event.NotBefore = time.Now()
+	}
+	event.NotBefore = event.NotBefore.UTC()
+	if !event.NotAfter.IsZero() {
+		if event.NotAfter.Before(event.NotBefore) {
+			return InstanceEvent{}, api.InvalidParameterValueError("NotAfter", event.NotAfter.Format(time.RFC3339))
+		}
+		event.NotAfter = event.NotAfter.UTC()
+	}
+
+	d.dispatchMu.Lock()
+	defer d.dispatchMu.Unlock()
+
+	if _, err := d.findInstance(ctx, instanceID); err != nil {
+		return InstanceEvent{}, err
+	}
+	running, err := d.withoutTerminatedInstances([]string{instanceID})
+	if err != nil {
+		return InstanceEvent{}, err
+	}
+	if len(running) == 0 {
+		return InstanceEvent{}, api.ErrWithCode(api.ErrorCodeIncorrectInstanceState, fmt.Errorf("instance %s is terminated", instanceID))
+	}
+	attrs, err := d.storage.ResourceAttributes(instanceID)
+	if err != nil {
+		return InstanceEvent{}, fmt.Errorf("retrieving instance attributes: %w", err)
+	}
+	events, err := instanceScheduledEvents(attrs)
+	if err != nil {
+		return InstanceEvent{}, err
+	}
+	event.ID, err = makeID(instanceEventIDPrefix)
+	if err != nil {
+		return InstanceEvent{}, err
+	}
+	events = append(events, event)
+	data, err := json.Marshal(events)
+	if err != nil {
+		return InstanceEvent{}, fmt.Errorf("serializing instance events: %w", err)
+	}
+	if err := d.storage.SetResourceAttributes(instanceID, []storage.Attribute{
+		{Key: attributeNameInstanceScheduledEvents, Value: string(data)},
+	}); err != nil {
+		return InstanceEvent{}, fmt.Errorf("saving instance events: %w", err)
+	}
+	api.Logger(ctx).Info(
+		"scheduled instance event",
+		slog.String("instance_id", instanceID),
+		slog.String("instance_event_id", event.ID),
+		slog.String("code", event.Code),
+		slog.Time("not_before", event.NotBefore),
+	)
+	return event, nil
+}
+
+func instanceScheduledEvents(attrs storage.Attributes) ([]InstanceEvent, error) {
+	raw, found := attrs.Key(attributeNameInstanceScheduledEvents)
+	if !found || raw == "" {
+		return nil, nil
+	}
+	var events []InstanceEvent
+	if err := json.Unmarshal([]byte(raw), &events); err != nil {
+		return nil, fmt.Errorf("parsing instance events: %w", err)
+	}
+	return events, nil
+}
+
+func apiInstanceStatusEvents(events []InstanceEvent) []api.InstanceStatusEvent {
+	if len(events) == 0 {
+		return nil
+	}
+	out := make([]api.InstanceStatusEvent, 0, len(events))
+	for _, event := range events {
+		statusEvent := api.InstanceStatusEvent{
+			InstanceEventID: event.ID,
+			Code:            event.Code,
+			Description:     event.Description,
+			NotBefore:       &event.NotBefore,
+		}
+		if !event.NotAfter.IsZero() {
+			statusEvent.NotAfter = &event.NotAfter
+		}
+		out = append(out, statusEvent)
+	}
+	slices.SortStableFunc(out, func(a, b api.InstanceStatusEvent) int {
+		return a.NotBefore.Compare(*b.NotBefore)
+	})
+	return out
+}
@@ -0,0 +1,72 @@
+package dc2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+)
+
+func TestScheduleInstanceEvent(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	d := &Dispatcher{
+		opts:    DispatcherOptions{Region: "us-east-1"},
+		exe:     newScalingExecutor(),
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+	runResp, err := d.dispatchRunInstances(ctx, &api.RunInstancesRequest{
+		ImageID:      "nginx",
+		InstanceType: "my-type",
+		MinCount:     1,
+		MaxCount:     1,
+	})
+	require.NoError(t, err)
+	instanceID := runResp.InstancesSet[0].InstanceID
+
+	var apiErr *api.Error
+	_, err = d.ScheduleInstanceEvent(ctx, instanceID, InstanceEvent{Code: "instance-explode"})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, api.ErrorCodeInvalidParameterValue, apiErr.Code)
+	_, err = d.ScheduleInstanceEvent(ctx, "i-0123456789abcdef0", InstanceEvent{Code: "instance-retirement"})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, api.ErrorCodeInvalidParameterValue, apiErr.Code)
+	notBefore := time.Now().Add(7 * 24 * time.Hour).UTC().Truncate(time.Second)
+	_, err = d.ScheduleInstanceEvent(ctx, instanceID, InstanceEvent{
+		Code:      "instance-retirement",
+		NotBefore: notBefore,
+		NotAfter:  notBefore.Add(-time.Hour),
+	})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, api.ErrorCodeInvalidParameterValue, apiErr.Code)
+
+	event, err := d.ScheduleInstanceEvent(ctx, instanceID, InstanceEvent{
+		Code:      "instance-retirement",
+		NotBefore: notBefore,
+		NotAfter:  notBefore.Add(48 * time.Hour),
+	})
+	require.NoError(t, err)
+	assert.Regexp(t, `^instance-event-[0-9a-f]{17}$`, event.ID)
+
+	statusResp, err := d.dispatchDescribeInstanceStatus(ctx, &api.DescribeInstanceStatusRequest{
+		InstanceIDs:         []string{instanceID},
+		IncludeAllInstances: new(true),
+	})
+	require.NoError(t, err)
+	require.Len(t, statusResp.InstanceStatusSet, 1)
+	events := statusResp.InstanceStatusSet[0].Events
+	require.Len(t, events, 1)
+	assert.Equal(t, event.ID, events[0].InstanceEventID)
+	assert.Equal(t, "instance-retirement", events[0].Code)
+	assert.Equal(t, "The instance is scheduled for retirement", events[0].Description)
+	require.NotNil(t, events[0].NotBefore)
+	assert.True(t, notBefore.Equal(*events[0].NotBefore))
+	require.NotNil(t, events[0].NotAfter)
+	assert.True(t, notBefore.Add(48*time.Hour).Equal(*events[0].NotAfter))
+}
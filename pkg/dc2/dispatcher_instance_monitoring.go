@@ -0,0 +1,80 @@
+package dc2
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+)
+
+const (
+	attributeNameInstanceMonitoring = "Monitoring"
+
+	monitoringStateDisabled  = "disabled"
+	monitoringStateDisabling = "disabling"
+	monitoringStateEnabled   = "enabled"
+	monitoringStatePending   = "pending"
+)
+
+// instanceMonitoringState returns the detailed monitoring state of an
+// instance. Monitoring is metadata only, so toggling it completes right away.
+func instanceMonitoringState(attrs storage.Attributes) string {
+	if state, _ := attrs.Key(attributeNameInstanceMonitoring); state != "" {
+		return state
+	}
+	return monitoringStateDisabled
+}
+
+func (d *Dispatcher) dispatchMonitorInstances(ctx context.Context, req *api.MonitorInstancesRequest) (*api.MonitorInstancesResponse, error) {
+	instances, err := d.setInstancesMonitoring(ctx, req.InstanceIDs, req.DryRun, monitoringStateEnabled, monitoringStatePending)
+	if err != nil {
+		return nil, err
+	}
+	return &api.MonitorInstancesResponse{InstancesSet: instances}, nil
+}
+
+func (d *Dispatcher) dispatchUnmonitorInstances(ctx context.Context, req *api.UnmonitorInstancesRequest) (*api.UnmonitorInstancesResponse, error) {
+	instances, err := d.setInstancesMonitoring(ctx, req.InstanceIDs, req.DryRun, monitoringStateDisabled, monitoringStateDisabling)
+	if err != nil {
+		return nil, err
+	}
+	return &api.UnmonitorInstancesResponse{InstancesSet: instances}, nil
+}
+
+// setInstancesMonitoring stores the monitoring state for the given instances,
+// returning the transitional state reported by EC2 for each of them.
+func (d *Dispatcher) setInstancesMonitoring(
+	ctx context.Context,
+	instanceIDs []string,
+	dryRun bool,
+	state string,
+	transitionState string,
+) ([]api.InstanceMonitoring, error) {
+	if err := d.validateInstanceIDCount(instanceIDs); err != nil {
+		return nil, err
+	}
+	for _, instanceID := range instanceIDs {
+		if _, err := d.findInstance(ctx, instanceID); err != nil {
+			return nil, err
+		}
+	}
+	if dryRun {
+		return nil, api.DryRunError()
+	}
+	instances := make([]api.InstanceMonitoring, 0, len(instanceIDs))
+	for _, instanceID := range instanceIDs {
+		if err := d.storage.SetResourceAttributes(instanceID, []storage.Attribute{
+			{Key: attributeNameInstanceMonitoring, Value: state},
+		}); err != nil {
+			return nil, fmt.Errorf("storing monitoring state for instance %s: %w", instanceID, err)
+		}
+		instances = append(instances, api.InstanceMonitoring{
+			InstanceID: instanceID,
+			Monitoring: api.Monitoring{State: transitionState},
+		})
+	}
+	api.Logger(ctx).Debug("set instances monitoring", slog.Any("instance_ids", instanceIDs), slog.String("state", state))
+	return instances, nil
+}
@@ -0,0 +1,69 @@
+package dc2
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+func TestMonitorInstances(t *testing.T) {
+	t.Parallel()
+
+	const (
+		instanceID = "i-00000000000000001"
+		missingID  = "i-00000000000000002"
+	)
+	ctx := context.Background()
+	d := &Dispatcher{
+		exe:     &exitCleanupExecutor{},
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+	require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeInstance, ID: instanceID}))
+
+	monitoringState := func() string {
+		attrs, err := d.storage.ResourceAttributes(instanceID)
+		require.NoError(t, err)
+		return instanceMonitoringState(attrs)
+	}
+	assert.Equal(t, monitoringStateDisabled, monitoringState())
+
+	_, err := d.dispatchMonitorInstances(ctx, &api.MonitorInstancesRequest{
+		DryRunnableRequest: api.DryRunnableRequest{DryRun: true},
+		InstanceIDs:        []string{instanceID},
+	})
+	var apiErr *api.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, api.ErrorCodeDryRunOperation, apiErr.Code)
+	assert.Equal(t, monitoringStateDisabled, monitoringState())
+
+	_, err = d.dispatchMonitorInstances(ctx, &api.MonitorInstancesRequest{
+		InstanceIDs: []string{instanceID, missingID},
+	})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, monitoringStateDisabled, monitoringState())
+
+	monitorResp, err := d.dispatchMonitorInstances(ctx, &api.MonitorInstancesRequest{
+		InstanceIDs: []string{instanceID},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []api.InstanceMonitoring{
+		{InstanceID: instanceID, Monitoring: api.Monitoring{State: monitoringStatePending}},
+	}, monitorResp.InstancesSet)
+	assert.Equal(t, monitoringStateEnabled, monitoringState())
+
+	unmonitorResp, err := d.dispatchUnmonitorInstances(ctx, &api.UnmonitorInstancesRequest{
+		InstanceIDs: []string{instanceID},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []api.InstanceMonitoring{
+		{InstanceID: instanceID, Monitoring: api.Monitoring{State: monitoringStateDisabling}},
+	}, unmonitorResp.InstancesSet)
+	assert.Equal(t, monitoringStateDisabled, monitoringState())
+}
@@ -0,0 +1,92 @@
+package dc2
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/executor"
+)
+
+func (d *Dispatcher) dispatchGetPasswordData(ctx context.Context, req *api.GetPasswordDataRequest) (*api.GetPasswordDataResponse, error) {
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
+	if _, err := d.findInstance(ctx, req.InstanceID); err != nil {
+		return nil, err
+	}
+	descriptions, err := d.exe.DescribeInstances(ctx, executor.DescribeInstancesRequest{
+		InstanceIDs: []executor.InstanceID{executorInstanceID(req.InstanceID)},
+	})
+	if err != nil {
+		return nil, executorError(err)
+	}
+	descriptions = d.applyAsyncStopStates(descriptions)
+	if len(descriptions) != 1 {
+		return nil, api.InvalidParameterValueError("InstanceId", req.InstanceID)
+	}
+	desc := descriptions[0]
+
+	now := d.now().UTC()
+	resp := &api.GetPasswordDataResponse{
+		InstanceID: req.InstanceID,
+		Timestamp:  &now,
+	}
+	// Like in EC2, the password data stays empty until the instance has
+	// been running for a while
+	availableAt := desc.LaunchTime.Add(d.opts.PasswordDataDelay).UTC()
+	if desc.InstanceState.Name != api.InstanceStateRunning.Name || now.Before(availableAt) {
+		return resp, nil
+	}
+	resp.Timestamp = &availableAt
+
+	attrs, err := d.storage.ResourceAttributes(req.InstanceID)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving instance attributes: %w", err)
+	}
+	keyName, _ := attrs.Key(attributeNameInstanceKeyName)
+	if keyName == "" {
+		return resp, nil
+	}
+	keyPair, err := d.findKeyPairByName(keyName)
+	if err != nil {
+		return nil, err
+	}
+	// Passwords can only be encrypted with RSA keys
+	if keyPair == nil || keyPair.Type != keyPairTypeRSA {
+		return resp, nil
+	}
+	publicKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(keyPair.PublicKey))
+	if err != nil {
+		return nil, fmt.Errorf("parsing key pair %s public key: %w", keyPair.ID, err)
+	}
+	cryptoKey, ok := publicKey.(ssh.CryptoPublicKey)
+	if !ok {
+		return resp, nil
+	}
+	rsaKey, ok := cryptoKey.CryptoPublicKey().(*rsa.PublicKey)
+	if !ok {
+		return resp, nil
+	}
+	encrypted, err := rsa.EncryptPKCS1v15(rand.Reader, rsaKey, []byte(instancePassword(req.InstanceID)))
+	if err != nil {
+		return nil, fmt.Errorf("encrypting password: %w", err)
+	}
+	resp.PasswordData = base64.StdEncoding.EncodeToString(encrypted)
+	return resp, nil
+}
+
+// instancePassword returns the administrator password reported for the
+// given instance. It's derived from the instance ID, so it stays the same
+// across calls and restarts.
+func instancePassword(instanceID string) string {
+	sum := sha256.Sum256([]byte("dc2-password:" + instanceID))
+	return "Dc2-" + hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,101 @@
+package dc2
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+)
+
+func TestGetPasswordData(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	d := &Dispatcher{
+		exe:     newHealthCheckExecutor(clock),
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+		clock:   clock,
+		opts: DispatcherOptions{
+			Region:            "us-east-1",
+			PasswordDataDelay: 4 * time.Minute,
+		},
+	}
+
+	keyPair, err := d.dispatchCreateKeyPair(ctx, &api.CreateKeyPairRequest{KeyName: "windows"})
+	require.NoError(t, err)
+	block, _ := pem.Decode([]byte(keyPair.KeyMaterial))
+	require.NotNil(t, block)
+	privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	require.NoError(t, err)
+
+	runInstance := func(keyName string) string {
+		t.Helper()
+		resp, err := d.dispatchRunInstances(ctx, &api.RunInstancesRequest{
+			ImageID:      "nginx",
+			InstanceType: "t3.micro",
+			KeyName:      keyName,
+			MinCount:     1,
+			MaxCount:     1,
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.InstancesSet, 1)
+		return resp.InstancesSet[0].InstanceID
+	}
+	instanceID := runInstance("windows")
+	withoutKey := runInstance("")
+
+	getPasswordData := func(instanceID string) *api.GetPasswordDataResponse {
+		t.Helper()
+		resp, err := d.dispatchGetPasswordData(ctx, &api.GetPasswordDataRequest{InstanceID: instanceID})
+		require.NoError(t, err)
+		assert.Equal(t, instanceID, resp.InstanceID)
+		require.NotNil(t, resp.Timestamp)
+		return resp
+	}
+
+	// Before the delay, the password data is empty
+	now = now.Add(time.Minute)
+	resp := getPasswordData(instanceID)
+	assert.Empty(t, resp.PasswordData)
+	assert.Equal(t, now, *resp.Timestamp)
+
+	// Once the delay passes, it decrypts with the key pair
+	now = now.Add(3 * time.Minute)
+	resp = getPasswordData(instanceID)
+	require.NotEmpty(t, resp.PasswordData)
+	assert.Equal(t, now, *resp.Timestamp)
+	encrypted, err := base64.StdEncoding.DecodeString(resp.PasswordData)
+	require.NoError(t, err)
+	password, err := rsa.DecryptPKCS1v15(nil, privateKey, encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, instancePassword(instanceID), string(password))
+
+	// The password is the same in every call
+	now = now.Add(time.Minute)
+	resp = getPasswordData(instanceID)
+	encrypted, err = base64.StdEncoding.DecodeString(resp.PasswordData)
+	require.NoError(t, err)
+	password, err = rsa.DecryptPKCS1v15(nil, privateKey, encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, instancePassword(instanceID), string(password))
+	assert.Equal(t, now.Add(-time.Minute), *resp.Timestamp)
+
+	// Instances without a key pair have no password data
+	assert.Empty(t, getPasswordData(withoutKey).PasswordData)
+
+	var apiErr *api.Error
+	_, err = d.dispatchGetPasswordData(ctx, &api.GetPasswordDataRequest{InstanceID: "i-0123456789abcdef0"})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, api.ErrorCodeInvalidParameterValue, apiErr.Code)
+}
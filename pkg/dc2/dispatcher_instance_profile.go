@@ -0,0 +1,199 @@
+package dc2
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+const (
+	attributeNameInstanceProfileArn           = "IamInstanceProfileArn"
+	attributeNameInstanceProfileAssociationID = "IamInstanceProfileAssociationId"
+	attributeNameInstanceProfileAssociatedAt  = "IamInstanceProfileAssociatedAt"
+
+	instanceProfileAssociationIDPrefix = "iip-assoc-"
+	instanceProfileIDPrefix            = "AIPA"
+
+	instanceProfileAssociationStateAssociated    = "associated"
+	instanceProfileAssociationStateDisassociated = "disassociated"
+)
+
+// resolveInstanceProfileArn returns the ARN of the instance profile in spec,
+// building it from the profile name when no ARN is given. dc2 doesn't model
+// IAM, so any profile is accepted.
+func resolveInstanceProfileArn(spec api.IamInstanceProfileSpecification) (string, error) {
+	arn := strings.TrimSpace(spec.Arn)
+	name := strings.TrimSpace(spec.Name)
+	switch {
+	case arn != "" && name != "":
+		return "", api.ErrWithCode(
+			"InvalidParameterCombination",
+			errors.New("IamInstanceProfile.Arn and IamInstanceProfile.Name cannot be used together"),
+		)
+	case arn != "":
+		if !strings.HasPrefix(arn, "arn:aws:iam::") || !strings.Contains(arn, ":instance-profile/") || instanceProfileName(arn) == "" {
+			return "", api.InvalidParameterValueError("IamInstanceProfile.Arn", arn)
+		}
+		return arn, nil
+	case name != "":
+		if strings.Contains(name, "/") {
+			return "", api.InvalidParameterValueError("IamInstanceProfile.Name", name)
+		}
+		return fmt.Sprintf("arn:aws:iam::%s:instance-profile/%s", defaultSecurityGroupOwnerID, name), nil
+	}
+	return "", api.ErrWithCode("MissingParameter", errors.New("IamInstanceProfile.Arn or IamInstanceProfile.Name is required"))
+}
+
+// instanceProfileName returns the name of an instance profile from its ARN,
+// which is the last component of its path.
+func instanceProfileName(arn string) string {
+	_, path, _ := strings.Cut(arn, ":instance-profile/")
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		path = path[i+1:]
+	}
+	return path
+}
+
+// instanceProfileID derives a stable, AWS-like unique ID for an instance
+// profile from its ARN.
+func instanceProfileID(arn string) string {
+	sum := sha1.Sum([]byte(arn))
+	return instanceProfileIDPrefix + strings.ToUpper(hex.EncodeToString(sum[:]))[:17]
+}
+
+// instanceProfileAttributes returns the attributes recording an association
+// between an instance and the instance profile with the given ARN.
+func instanceProfileAttributes(arn string, now time.Time) ([]storage.Attribute, error) {
+	associationID, err := makeID(instanceProfileAssociationIDPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return []storage.Attribute{
+		{Key: attributeNameInstanceProfileArn, Value: arn},
+		{Key: attributeNameInstanceProfileAssociationID, Value: associationID},
+		{Key: attributeNameInstanceProfileAssociatedAt, Value: now.UTC().Format(time.RFC3339Nano)},
+	}, nil
+}
+
+func apiInstanceProfile(attrs storage.Attributes) *api.IamInstanceProfile {
+	arn, _ := attrs.Key(attributeNameInstanceProfileArn)
+	if arn == "" {
+		return nil
+	}
+	return &api.IamInstanceProfile{
+		Arn: arn,
+		ID:  instanceProfileID(arn),
+	}
+}
+
+func apiInstanceProfileAssociation(instanceID string, attrs storage.Attributes, state string) api.IamInstanceProfileAssociation {
+	associationID, _ := attrs.Key(attributeNameInstanceProfileAssociationID)
+	association := api.IamInstanceProfileAssociation{
+		AssociationID:      associationID,
+		InstanceID:         instanceID,
+		IamInstanceProfile: apiInstanceProfile(attrs),
+		State:              state,
+	}
+	if raw, _ := attrs.Key(attributeNameInstanceProfileAssociatedAt); raw != "" {
+		if associatedAt, err := time.Parse(time.RFC3339Nano, raw); err == nil {
+			association.Timestamp = &associatedAt
+		}
+	}
+	return association
+}
+
+func (d *Dispatcher) dispatchAssociateIamInstanceProfile(
+	ctx context.Context,
+	req *api.AssociateIamInstanceProfileRequest,
+) (*api.AssociateIamInstanceProfileResponse, error) {
+	arn, err := resolveInstanceProfileArn(req.IamInstanceProfile)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := d.findInstance(ctx, req.InstanceID); err != nil {
+		return nil, err
+	}
+	running, err := d.withoutTerminatedInstances([]string{req.InstanceID})
+	if err != nil {
+		return nil, err
+	}
+	if len(running) == 0 {
+		return nil, api.ErrWithCode(api.ErrorCodeIncorrectInstanceState, fmt.Errorf("instance %s is terminated", req.InstanceID))
+	}
+	attrs, err := d.storage.ResourceAttributes(req.InstanceID)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving instance attributes: %w", err)
+	}
+	if associationID, _ := attrs.Key(attributeNameInstanceProfileAssociationID); associationID != "" {
+		return nil, api.ErrWithCode(
+			"IncorrectState",
+			fmt.Errorf("there is an existing association for instance %s", req.InstanceID),
+		)
+	}
+	profileAttrs, err := instanceProfileAttributes(arn, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := d.storage.SetResourceAttributes(req.InstanceID, profileAttrs); err != nil {
+		return nil, fmt.Errorf("storing instance profile association: %w", err)
+	}
+	return &api.AssociateIamInstanceProfileResponse{
+		IamInstanceProfileAssociation: apiInstanceProfileAssociation(
+			req.InstanceID,
+			profileAttrs,
+			instanceProfileAssociationStateAssociated,
+		),
+	}, nil
+}
+
+func (d *Dispatcher) dispatchDisassociateIamInstanceProfile(
+	ctx context.Context,
+	req *api.DisassociateIamInstanceProfileRequest,
+) (*api.DisassociateIamInstanceProfileResponse, error) {
+	instances, err := d.storage.RegisteredResources(types.ResourceTypeInstance)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving instances: %w", err)
+	}
+	for _, instance := range instances {
+		attrs, err := d.storage.ResourceAttributes(instance.ID)
+		if err != nil {
+			return nil, fmt.Errorf("retrieving instance attributes: %w", err)
+		}
+		if associationID, _ := attrs.Key(attributeNameInstanceProfileAssociationID); associationID != req.AssociationID {
+			continue
+		}
+		profileAttrs := []storage.Attribute{
+			{Key: attributeNameInstanceProfileArn},
+			{Key: attributeNameInstanceProfileAssociationID},
+			{Key: attributeNameInstanceProfileAssociatedAt},
+		}
+		if err := d.storage.RemoveResourceAttributes(instance.ID, profileAttrs); err != nil {
+			return nil, fmt.Errorf("removing instance profile association: %w", err)
+		}
+		api.Logger(ctx).Debug(
+			"disassociated instance profile",
+			slog.String("instance_id", instance.ID),
+			slog.String("association_id", req.AssociationID),
+		)
+		return &api.DisassociateIamInstanceProfileResponse{
+			IamInstanceProfileAssociation: apiInstanceProfileAssociation(
+				instance.ID,
+				attrs,
+				instanceProfileAssociationStateDisassociated,
+			),
+		}, nil
+	}
+	return nil, api.ErrWithCode(
+		"InvalidAssociationID.NotFound",
+		fmt.Errorf("association ID '%s' does not exist", req.AssociationID),
+	)
+}
@@ -0,0 +1,96 @@
+package dc2
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+func TestResolveInstanceProfileArn(t *testing.T) {
+	t.Parallel()
+
+	arn, err := resolveInstanceProfileArn(api.IamInstanceProfileSpecification{Name: "web"})
+	require.NoError(t, err)
+	assert.Equal(t, "arn:aws:iam::"+defaultSecurityGroupOwnerID+":instance-profile/web", arn)
+	assert.Equal(t, "web", instanceProfileName(arn))
+
+	const pathArn = "arn:aws:iam::123456789012:instance-profile/team/web"
+	arn, err = resolveInstanceProfileArn(api.IamInstanceProfileSpecification{Arn: pathArn})
+	require.NoError(t, err)
+	assert.Equal(t, pathArn, arn)
+	assert.Equal(t, "web", instanceProfileName(arn))
+
+	for _, spec := range []api.IamInstanceProfileSpecification{
+		{},
+		{Arn: pathArn, Name: "web"},
+		{Arn: "arn:aws:iam::123456789012:role/web"},
+		{Name: "team/web"},
+	} {
+		_, err := resolveInstanceProfileArn(spec)
+		var apiErr *api.Error
+		require.ErrorAs(t, err, &apiErr, "spec %+v", spec)
+	}
+}
+
+func TestAssociateIamInstanceProfile(t *testing.T) {
+	t.Parallel()
+
+	const instanceID = "i-00000000000000001"
+	ctx := context.Background()
+	d := &Dispatcher{
+		exe:     &exitCleanupExecutor{},
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+	require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeInstance, ID: instanceID}))
+
+	associateResp, err := d.dispatchAssociateIamInstanceProfile(ctx, &api.AssociateIamInstanceProfileRequest{
+		InstanceID:         instanceID,
+		IamInstanceProfile: api.IamInstanceProfileSpecification{Name: "web"},
+	})
+	require.NoError(t, err)
+	association := associateResp.IamInstanceProfileAssociation
+	assert.Equal(t, instanceID, association.InstanceID)
+	assert.Equal(t, instanceProfileAssociationStateAssociated, association.State)
+	assert.Regexp(t, `^iip-assoc-[0-9a-f]{17}$`, association.AssociationID)
+	require.NotNil(t, association.IamInstanceProfile)
+	assert.Equal(t, "web", instanceProfileName(association.IamInstanceProfile.Arn))
+	assert.Equal(t, instanceProfileID(association.IamInstanceProfile.Arn), association.IamInstanceProfile.ID)
+
+	_, err = d.dispatchAssociateIamInstanceProfile(ctx, &api.AssociateIamInstanceProfileRequest{
+		InstanceID:         instanceID,
+		IamInstanceProfile: api.IamInstanceProfileSpecification{Name: "other"},
+	})
+	var apiErr *api.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "IncorrectState", apiErr.Code)
+
+	disassociateResp, err := d.dispatchDisassociateIamInstanceProfile(ctx, &api.DisassociateIamInstanceProfileRequest{
+		AssociationID: association.AssociationID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, instanceProfileAssociationStateDisassociated, disassociateResp.IamInstanceProfileAssociation.State)
+	assert.Equal(t, association.AssociationID, disassociateResp.IamInstanceProfileAssociation.AssociationID)
+
+	attrs, err := d.storage.ResourceAttributes(instanceID)
+	require.NoError(t, err)
+	assert.Nil(t, apiInstanceProfile(attrs))
+
+	_, err = d.dispatchDisassociateIamInstanceProfile(ctx, &api.DisassociateIamInstanceProfileRequest{
+		AssociationID: association.AssociationID,
+	})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "InvalidAssociationID.NotFound", apiErr.Code)
+
+	_, err = d.dispatchAssociateIamInstanceProfile(ctx, &api.AssociateIamInstanceProfileRequest{
+		InstanceID:         instanceID,
+		IamInstanceProfile: api.IamInstanceProfileSpecification{Name: "other"},
+	})
+	require.NoError(t, err)
+}
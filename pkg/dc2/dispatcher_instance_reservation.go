@@ -0,0 +1,118 @@
+package dc2
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/executor"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+const (
+	reservationIDPrefix = "r-"
+
+	attributeNameInstanceReservationID = "ReservationID"
+	attributeNameInstanceClientToken   = "ClientToken"
+)
+
+// instanceReservationID returns the reservation the instance was launched
+// in, or the empty string for instances registered before reservations were
+// tracked. Those keep sharing a single reservation without an ID.
+func instanceReservationID(attrs storage.Attributes) string {
+	reservationID, _ := attrs.Key(attributeNameInstanceReservationID)
+	return reservationID
+}
+
+// instanceClientToken returns the client token used to launch the instance,
+// or nil if none was given.
+func instanceClientToken(attrs storage.Attributes) *string {
+	clientToken, _ := attrs.Key(attributeNameInstanceClientToken)
+	if clientToken == "" {
+		return nil
+	}
+	return &clientToken
+}
+
+// runInstancesForClientToken returns the response for a RunInstances call
+// that already launched instances with the given client token, so retries
+// resolve to the original reservation instead of launching new instances.
+func (d *Dispatcher) runInstancesForClientToken(ctx context.Context, clientToken string) (*api.RunInstancesResponse, bool, error) {
+	if clientToken == "" {
+		return nil, false, nil
+	}
+	rs, err := d.storage.RegisteredResources(types.ResourceTypeInstance)
+	if err != nil {
+		return nil, false, fmt.Errorf("retrieving registered instances: %w", err)
+	}
+	var reservationID string
+	var instanceIDs []string
+	for _, r := range rs {
+		attrs, err := d.storage.ResourceAttributes(r.ID)
+		if err != nil {
+			return nil, false, fmt.Errorf("retrieving instance attributes: %w", err)
+		}
+		if token, _ := attrs.Key(attributeNameInstanceClientToken); token != clientToken {
+			continue
+		}
+		reservationID = instanceReservationID(attrs)
+		instanceIDs = append(instanceIDs, r.ID)
+	}
+	if len(instanceIDs) == 0 {
+		return nil, false, nil
+	}
+
+	descriptions, err := d.exe.DescribeInstances(ctx, executor.DescribeInstancesRequest{
+		InstanceIDs: executorInstanceIDs(instanceIDs),
+	})
+	if err != nil {
+		return nil, false, executorError(err)
+	}
+	instances := make([]api.Instance, 0, len(instanceIDs))
+	for _, desc := range d.applyAsyncStopStates(descriptions) {
+		instance, err := d.apiInstance(&desc)
+		if err != nil {
+			return nil, false, err
+		}
+		instances = append(instances, instance)
+	}
+	for _, instanceID := range instanceIDs {
+		if slices.ContainsFunc(instances, func(instance api.Instance) bool { return instance.InstanceID == instanceID }) {
+			continue
+		}
+		terminatedInstance, include, err := d.terminatedInstanceFromStorage(instanceID)
+		if err != nil {
+			return nil, false, err
+		}
+		if include {
+			instances = append(instances, terminatedInstance)
+		}
+	}
+	slices.SortFunc(instances, func(a, b api.Instance) int {
+		return strings.Compare(a.InstanceID, b.InstanceID)
+	})
+	return &api.RunInstancesResponse{
+		ReservationID: reservationID,
+		InstancesSet:  instances,
+	}, true, nil
+}
+
+// instanceReservations groups instances by the reservation they were
+// launched in, keeping the order in which reservations first appear.
+func instanceReservations(instances []api.Instance) []api.Reservation {
+	var reservations []api.Reservation
+	indexes := make(map[string]int)
+	for _, instance := range instances {
+		i, found := indexes[instance.ReservationID]
+		if !found {
+			i = len(reservations)
+			indexes[instance.ReservationID] = i
+			reservations = append(reservations, api.Reservation{ReservationID: instance.ReservationID})
+		}
+		reservations[i].InstancesSet = append(reservations[i].InstancesSet, instance)
+	}
+	return reservations
+}
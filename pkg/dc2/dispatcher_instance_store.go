@@ -0,0 +1,54 @@
+package dc2
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/fiam/dc2/pkg/dc2/executor"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+)
+
+const (
+	// instanceVolumeTypeTagKey selects how the root filesystem of an
+	// instance behaves across a stop. Instances tagged with
+	// instanceVolumeTypeInstanceStore start from a clean copy of their image
+	// every time, while the rest keep their filesystem like EBS-backed
+	// instances do.
+	instanceVolumeTypeTagKey        = "dc2:volume-type"
+	instanceVolumeTypeInstanceStore = "instance-store"
+
+	rootDeviceTypeInstanceStore = "instance-store"
+)
+
+// instanceUsesInstanceStore reports whether an instance is tagged to lose its
+// root filesystem when stopped.
+func instanceUsesInstanceStore(attrs storage.Attributes) bool {
+	volumeType, _ := attrs.Key(storage.TagAttributeName(instanceVolumeTypeTagKey))
+	return volumeType == instanceVolumeTypeInstanceStore
+}
+
+func instanceRootDeviceType(attrs storage.Attributes) string {
+	if instanceUsesInstanceStore(attrs) {
+		return rootDeviceTypeInstanceStore
+	}
+	return rootDeviceTypeEBS
+}
+
+// instanceStoreInstanceIDs returns the instances that must be reset to their
+// image when started.
+func (d *Dispatcher) instanceStoreInstanceIDs(instanceIDs []executor.InstanceID) ([]executor.InstanceID, error) {
+	var resetIDs []executor.InstanceID
+	for _, instanceID := range instanceIDs {
+		attrs, err := d.storage.ResourceAttributes(apiInstanceID(instanceID))
+		if err != nil {
+			if errors.As(err, &storage.ErrResourceNotFound{}) {
+				continue
+			}
+			return nil, fmt.Errorf("retrieving instance attributes: %w", err)
+		}
+		if instanceUsesInstanceStore(attrs) {
+			resetIDs = append(resetIDs, instanceID)
+		}
+	}
+	return resetIDs, nil
+}
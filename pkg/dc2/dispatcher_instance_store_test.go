@@ -0,0 +1,76 @@
+package dc2
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/executor"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+)
+
+// resetRecordingExecutor records the instances each StartInstances call asks
+// to reset.
+type resetRecordingExecutor struct {
+	*scalingExecutor
+	resetIDs []executor.InstanceID
+}
+
+func (e *resetRecordingExecutor) StartInstances(ctx context.Context, req executor.StartInstancesRequest) ([]executor.InstanceStateChange, error) {
+	e.resetIDs = append(e.resetIDs, req.ResetInstanceIDs...)
+	return e.scalingExecutor.StartInstances(ctx, req)
+}
+
+func TestInstanceStoreInstancesResetOnStart(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	exe := &resetRecordingExecutor{scalingExecutor: newScalingExecutor()}
+	d := &Dispatcher{
+		exe:     exe,
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+	runInstance := func(tags ...api.Tag) string {
+		t.Helper()
+		resp, err := d.dispatchRunInstances(ctx, &api.RunInstancesRequest{
+			ImageID:      "nginx",
+			InstanceType: "my-type",
+			MinCount:     1,
+			MaxCount:     1,
+			TagSpecifications: []api.TagSpecification{
+				{ResourceType: "instance", Tags: tags},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.InstancesSet, 1)
+		return resp.InstancesSet[0].InstanceID
+	}
+	ebsID := runInstance()
+	instanceStoreID := runInstance(api.Tag{Key: instanceVolumeTypeTagKey, Value: instanceVolumeTypeInstanceStore})
+	// Launching never resets, since the container is already fresh
+	assert.Empty(t, exe.resetIDs)
+
+	instanceIDs := []string{ebsID, instanceStoreID}
+	_, err := d.dispatchStopInstances(ctx, &api.StopInstancesRequest{InstanceIDs: instanceIDs})
+	require.NoError(t, err)
+	_, err = d.dispatchStartInstances(ctx, &api.StartInstancesRequest{InstanceIDs: instanceIDs})
+	require.NoError(t, err)
+	assert.Equal(t, executorInstanceIDs([]string{instanceStoreID}), exe.resetIDs)
+
+	resp, err := d.dispatchDescribeInstances(ctx, &api.DescribeInstancesRequest{InstanceIDs: instanceIDs})
+	require.NoError(t, err)
+	rootDeviceTypes := make(map[string]string)
+	for _, reservation := range resp.ReservationSet {
+		for _, instance := range reservation.InstancesSet {
+			rootDeviceTypes[instance.InstanceID] = instance.RootDeviceType
+		}
+	}
+	assert.Equal(t, map[string]string{
+		ebsID:           rootDeviceTypeEBS,
+		instanceStoreID: rootDeviceTypeInstanceStore,
+	}, rootDeviceTypes)
+}
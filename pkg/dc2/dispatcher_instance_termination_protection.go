@@ -0,0 +1,81 @@
+package dc2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+)
+
+const (
+	attributeNameInstanceDisableAPITermination = "DisableAPITermination"
+)
+
+// instanceDisableAPITermination reports whether termination protection is
+// enabled for an instance.
+func instanceDisableAPITermination(attrs storage.Attributes) bool {
+	value, _ := attrs.Key(attributeNameInstanceDisableAPITermination)
+	return value == "true"
+}
+
+// modifyInstanceAttributeDisableAPITermination returns the termination
+// protection requested by ModifyInstanceAttribute, given either as
+// Attribute/Value or as DisableApiTermination.Value. It returns nil when the
+// request modifies another attribute.
+func modifyInstanceAttributeDisableAPITermination(req *api.ModifyInstanceAttributeRequest) (*bool, error) {
+	value := req.DisableAPITermination
+	if req.Attribute != nil && *req.Attribute == "disableApiTermination" {
+		value = &api.AttributeValue{Value: req.Value}
+	}
+	if value == nil {
+		return nil, nil //nolint:nilnil
+	}
+	if value.Value == nil {
+		return nil, api.InvalidParameterValueError("DisableApiTermination", "<missing>")
+	}
+	enabled, err := strconv.ParseBool(strings.TrimSpace(*value.Value))
+	if err != nil {
+		return nil, api.InvalidParameterValueError("DisableApiTermination", *value.Value)
+	}
+	return &enabled, nil
+}
+
+func (d *Dispatcher) setInstanceDisableAPITermination(ctx context.Context, instanceID string, enabled bool) error {
+	if err := d.storage.SetResourceAttributes(instanceID, []storage.Attribute{
+		{Key: attributeNameInstanceDisableAPITermination, Value: strconv.FormatBool(enabled)},
+	}); err != nil {
+		return fmt.Errorf("storing termination protection for instance %s: %w", instanceID, err)
+	}
+	api.Logger(ctx).Info(
+		"modified instance termination protection",
+		slog.String("instance_id", instanceID),
+		slog.Bool("disable_api_termination", enabled),
+	)
+	return nil
+}
+
+// checkInstancesTerminationProtection returns OperationNotPermitted if any of
+// the instances has termination protection enabled. Like EC2, this only
+// applies to TerminateInstances; Auto Scaling terminations ignore it.
+func (d *Dispatcher) checkInstancesTerminationProtection(instanceIDs []string) error {
+	for _, instanceID := range instanceIDs {
+		attrs, err := d.storage.ResourceAttributes(instanceID)
+		if err != nil {
+			if errors.As(err, &storage.ErrResourceNotFound{}) {
+				continue
+			}
+			return fmt.Errorf("retrieving instance attributes: %w", err)
+		}
+		if instanceDisableAPITermination(attrs) {
+			//nolint
+			err := fmt.Errorf("The instance '%s' may not be terminated. Modify its 'disableApiTermination' instance attribute and try again.", instanceID)
+			return api.ErrWithCode(api.ErrorCodeOperationNotPermitted, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package dc2
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+)
+
+func TestInstanceTerminationProtection(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	d := &Dispatcher{
+		exe:     newScalingExecutor(),
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+	runResp, err := d.dispatchRunInstances(ctx, &api.RunInstancesRequest{
+		ImageID:               "nginx",
+		InstanceType:          "my-type",
+		MinCount:              1,
+		MaxCount:              1,
+		DisableAPITermination: true,
+	})
+	require.NoError(t, err)
+	require.Len(t, runResp.InstancesSet, 1)
+	instanceID := runResp.InstancesSet[0].InstanceID
+
+	disableAPITermination := func() bool {
+		t.Helper()
+		resp, err := d.dispatchDescribeInstanceAttribute(ctx, &api.DescribeInstanceAttributeRequest{
+			InstanceID: instanceID,
+			Attribute:  "disableApiTermination",
+		})
+		require.NoError(t, err)
+		require.NotNil(t, resp.DisableAPITermination)
+		return resp.DisableAPITermination.Value
+	}
+	assert.True(t, disableAPITermination())
+
+	_, err = d.dispatchTerminateInstances(ctx, &api.TerminateInstancesRequest{InstanceIDs: []string{instanceID}})
+	var apiErr *api.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, api.ErrorCodeOperationNotPermitted, apiErr.Code)
+
+	// The running instance accepts the change, using either parameter style
+	_, err = d.dispatchModifyInstanceAttribute(ctx, &api.ModifyInstanceAttributeRequest{
+		InstanceID:            instanceID,
+		DisableAPITermination: &api.AttributeValue{Value: new("false")},
+	})
+	require.NoError(t, err)
+	assert.False(t, disableAPITermination())
+	_, err = d.dispatchModifyInstanceAttribute(ctx, &api.ModifyInstanceAttributeRequest{
+		InstanceID: instanceID,
+		Attribute:  new("disableApiTermination"),
+		Value:      new("true"),
+	})
+	require.NoError(t, err)
+	assert.True(t, disableAPITermination())
+	_, err = d.dispatchModifyInstanceAttribute(ctx, &api.ModifyInstanceAttributeRequest{
+		InstanceID:            instanceID,
+		DisableAPITermination: &api.AttributeValue{Value: new("maybe")},
+	})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, api.ErrorCodeInvalidParameterValue, apiErr.Code)
+
+	_, err = d.dispatchModifyInstanceAttribute(ctx, &api.ModifyInstanceAttributeRequest{
+		InstanceID:            instanceID,
+		DisableAPITermination: &api.AttributeValue{Value: new("false")},
+	})
+	require.NoError(t, err)
+	_, err = d.dispatchTerminateInstances(ctx, &api.TerminateInstancesRequest{InstanceIDs: []string{instanceID}})
+	require.NoError(t, err)
+}
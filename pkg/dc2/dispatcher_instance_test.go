@@ -1,12 +1,22 @@
 package dc2
 
 import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/executor"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
 )
 
 func TestRunInstancesSubnetID(t *testing.T) {
@@ -35,3 +45,759 @@ func TestSubnetVPCID(t *testing.T) {
 	assert.Len(t, first, len("vpc-")+17)
 	assert.NotEqual(t, first, subnetVPCID("subnet-other"))
 }
+
+func TestInstanceAPIsRejectTooManyInstanceIDs(t *testing.T) {
+	t.Parallel()
+
+	dispatch := &Dispatcher{
+		opts: DispatcherOptions{MaxInstanceIDsPerRequest: 2},
+	}
+	instanceIDs := make([]string, 3)
+	for i := range instanceIDs {
+		instanceIDs[i] = fmt.Sprintf("i-%017d", i)
+	}
+
+	requests := []api.Request{
+		&api.DescribeInstancesRequest{InstanceIDs: instanceIDs},
+		&api.DescribeInstanceStatusRequest{InstanceIDs: instanceIDs},
+		&api.StartInstancesRequest{InstanceIDs: instanceIDs},
+		&api.StopInstancesRequest{InstanceIDs: instanceIDs},
+		&api.RebootInstancesRequest{InstanceIDs: instanceIDs},
+		&api.TerminateInstancesRequest{InstanceIDs: instanceIDs},
+	}
+	for _, req := range requests {
+		_, err := dispatch.Dispatch(context.Background(), req)
+		require.Error(t, err, "action %d", req.Action())
+		var apiErr *api.Error
+		require.True(t, errors.As(err, &apiErr), "action %d", req.Action())
+		assert.Equal(t, api.ErrorCodeInvalidParameterValue, apiErr.Code)
+		assert.Contains(t, apiErr.Error(), "up to 2 instance IDs")
+	}
+}
+
+func TestInstanceActionsHonorDryRun(t *testing.T) {
+	t.Parallel()
+
+	const instanceID = "i-00000000000000001"
+	ctx := t.Context()
+	exe := newScalingExecutor()
+	d := &Dispatcher{
+		exe:     exe,
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+	require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeInstance, ID: instanceID}))
+	require.NoError(t, d.storage.SetResourceAttributes(instanceID, []storage.Attribute{
+		{Key: storage.TagAttributeName("existing"), Value: "value"},
+	}))
+	dryRun := api.DryRunnableRequest{DryRun: true}
+
+	requests := []api.Request{
+		&api.RunInstancesRequest{DryRunnableRequest: dryRun, ImageID: "nginx", InstanceType: "my-type", MinCount: 1, MaxCount: 1},
+		&api.StartInstancesRequest{DryRun: true, InstanceIDs: []string{instanceID}},
+		&api.RebootInstancesRequest{DryRun: true, InstanceIDs: []string{instanceID}},
+		&api.CreateTagsRequest{DryRunnableRequest: dryRun, ResourceIDs: []string{instanceID}, Tags: []api.Tag{{Key: "created", Value: "value"}}},
+		&api.DeleteTagsRequest{DryRunnableRequest: dryRun, ResourceIDs: []string{instanceID}, Tags: []api.Tag{{Key: "existing", Value: "value"}}},
+	}
+	for _, req := range requests {
+		_, err := d.Dispatch(ctx, req)
+		var apiErr *api.Error
+		require.ErrorAs(t, err, &apiErr, "action %d", req.Action())
+		assert.Equal(t, api.ErrorCodeDryRunOperation, apiErr.Code, "action %d", req.Action())
+	}
+
+	assert.Empty(t, exe.instances)
+	instances, err := d.storage.RegisteredResources(types.ResourceTypeInstance)
+	require.NoError(t, err)
+	assert.Len(t, instances, 1)
+	attrs, err := d.storage.ResourceAttributes(instanceID)
+	require.NoError(t, err)
+	existing, ok := attrs.Key(storage.TagAttributeName("existing"))
+	assert.True(t, ok)
+	assert.Equal(t, "value", existing)
+	_, ok = attrs.Key(storage.TagAttributeName("created"))
+	assert.False(t, ok)
+}
+
+type runningInstancesExecutor struct {
+	*exitCleanupExecutor
+}
+
+func (e *runningInstancesExecutor) DescribeInstances(_ context.Context, req executor.DescribeInstancesRequest) ([]executor.InstanceDescription, error) {
+	descs := make([]executor.InstanceDescription, len(req.InstanceIDs))
+	for i, instanceID := range req.InstanceIDs {
+		descs[i] = executor.InstanceDescription{InstanceID: instanceID, InstanceState: api.InstanceStateRunning}
+	}
+	return descs, nil
+}
+
+func TestDescribeInstancesWithoutArgumentsReturnsAllInstances(t *testing.T) {
+	t.Parallel()
+
+	const (
+		standaloneInstanceID = "i-00000000000000001"
+		groupInstanceID      = "i-00000000000000002"
+		warmPoolInstanceID   = "i-00000000000000003"
+		terminatedInstanceID = "i-00000000000000004"
+		expiredInstanceID    = "i-00000000000000005"
+	)
+	ctx := context.Background()
+	dispatch := &Dispatcher{
+		exe: &existingInstancesExecutor{
+			exitCleanupExecutor: &exitCleanupExecutor{},
+			instanceIDs: executorInstanceIDs([]string{
+				standaloneInstanceID,
+				groupInstanceID,
+				warmPoolInstanceID,
+			}),
+		},
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+	// Register in reverse order, the result must not depend on it
+	for _, instanceID := range []string{expiredInstanceID, terminatedInstanceID, warmPoolInstanceID, groupInstanceID, standaloneInstanceID} {
+		require.NoError(t, dispatch.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeInstance, ID: instanceID}))
+	}
+	require.NoError(t, dispatch.storage.SetResourceAttributes(groupInstanceID, []storage.Attribute{
+		{Key: attributeNameAutoScalingGroupName, Value: "asg"},
+	}))
+	require.NoError(t, dispatch.storage.SetResourceAttributes(warmPoolInstanceID, []storage.Attribute{
+		{Key: attributeNameAutoScalingGroupName, Value: "asg"},
+		{Key: attributeNameAutoScalingInstanceWarmPool, Value: "true"},
+	}))
+	require.NoError(t, dispatch.storage.SetResourceAttributes(terminatedInstanceID, []storage.Attribute{
+		{Key: attributeNameInstanceTerminatedAt, Value: time.Now().UTC().Format(time.RFC3339Nano)},
+	}))
+	require.NoError(t, dispatch.storage.SetResourceAttributes(expiredInstanceID, []storage.Attribute{
+		{Key: attributeNameInstanceTerminatedAt, Value: time.Now().Add(-2 * terminatedInstanceTTL).UTC().Format(time.RFC3339Nano)},
+	}))
+
+	describe := func(req *api.DescribeInstancesRequest) ([]string, *string) {
+		resp, err := dispatch.dispatchDescribeInstances(ctx, req)
+		require.NoError(t, err)
+		var instanceIDs []string
+		for _, reservation := range resp.ReservationSet {
+			for _, instance := range reservation.InstancesSet {
+				instanceIDs = append(instanceIDs, instance.InstanceID)
+			}
+		}
+		return instanceIDs, resp.NextToken
+	}
+
+	want := []string{standaloneInstanceID, groupInstanceID, warmPoolInstanceID, terminatedInstanceID}
+	for range 3 {
+		instanceIDs, nextToken := describe(&api.DescribeInstancesRequest{})
+		assert.Equal(t, want, instanceIDs)
+		assert.Nil(t, nextToken)
+	}
+
+	var paged []string
+	var nextToken *string
+	for {
+		instanceIDs, token := describe(&api.DescribeInstancesRequest{
+			PaginableRequest: api.PaginableRequest{MaxResults: new(3), NextToken: nextToken},
+		})
+		assert.LessOrEqual(t, len(instanceIDs), 3)
+		paged = append(paged, instanceIDs...)
+		if token == nil {
+			break
+		}
+		nextToken = token
+	}
+	assert.Equal(t, want, paged)
+
+	// Instances terminated outside the retention window are forgotten
+	_, err := dispatch.storage.ResourceAttributes(expiredInstanceID)
+	assert.ErrorAs(t, err, &storage.ErrResourceNotFound{})
+}
+
+func TestDescribeInstancesPaginatesFilteredInstances(t *testing.T) {
+	t.Parallel()
+
+	dispatch := &Dispatcher{
+		exe:     &runningInstancesExecutor{exitCleanupExecutor: &exitCleanupExecutor{}},
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+	var webInstanceIDs []string
+	for i := range 12 {
+		instanceID := fmt.Sprintf("i-%017d", i)
+		require.NoError(t, dispatch.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeInstance, ID: instanceID}))
+		role := "worker"
+		if i%2 == 0 {
+			role = "web"
+			webInstanceIDs = append(webInstanceIDs, instanceID)
+		}
+		require.NoError(t, dispatch.storage.SetResourceAttributes(instanceID, []storage.Attribute{
+			{Key: storage.TagAttributeName("Role"), Value: role},
+		}))
+	}
+
+	describeAll := func(maxResults int) ([]string, int) {
+		var instanceIDs []string
+		var nextToken *string
+		pages := 0
+		for {
+			resp, err := dispatch.dispatchDescribeInstances(context.Background(), &api.DescribeInstancesRequest{
+				Filters: []api.Filter{{Name: new("tag:Role"), Values: []string{"web"}}},
+				PaginableRequest: api.PaginableRequest{
+					NextToken:  nextToken,
+					MaxResults: &maxResults,
+				},
+			})
+			require.NoError(t, err)
+			pages++
+			require.Len(t, resp.ReservationSet, 1)
+			instances := resp.ReservationSet[0].InstancesSet
+			if resp.NextToken != nil {
+				require.Len(t, instances, maxResults)
+			}
+			for _, instance := range instances {
+				instanceIDs = append(instanceIDs, instance.InstanceID)
+			}
+			if resp.NextToken == nil {
+				return instanceIDs, pages
+			}
+			nextToken = resp.NextToken
+		}
+	}
+
+	instanceIDs, pages := describeAll(4)
+	assert.Equal(t, webInstanceIDs, instanceIDs)
+	assert.Equal(t, 2, pages)
+
+	// Filtered results ending exactly at a page boundary must not return a
+	// token pointing at an empty page.
+	instanceIDs, pages = describeAll(3)
+	assert.Equal(t, webInstanceIDs, instanceIDs)
+	assert.Equal(t, 2, pages)
+}
+
+func TestDescribeInstancesNextTokenSkipsSeenInstances(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dispatch := &Dispatcher{
+		exe:     &runningInstancesExecutor{exitCleanupExecutor: &exitCleanupExecutor{}},
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+	register := func(n int) string {
+		instanceID := fmt.Sprintf("i-%017d", n)
+		require.NoError(t, dispatch.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeInstance, ID: instanceID}))
+		return instanceID
+	}
+	var want []string
+	for _, n := range []int{10, 20, 30, 40, 50} {
+		want = append(want, register(n))
+	}
+
+	describePage := func(nextToken *string) ([]string, *string) {
+		resp, err := dispatch.dispatchDescribeInstances(ctx, &api.DescribeInstancesRequest{
+			PaginableRequest: api.PaginableRequest{MaxResults: new(2), NextToken: nextToken},
+		})
+		require.NoError(t, err)
+		var instanceIDs []string
+		for _, reservation := range resp.ReservationSet {
+			for _, instance := range reservation.InstancesSet {
+				instanceIDs = append(instanceIDs, instance.InstanceID)
+			}
+		}
+		return instanceIDs, resp.NextToken
+	}
+
+	instanceIDs, nextToken := describePage(nil)
+	require.Equal(t, want[:2], instanceIDs)
+	require.NotNil(t, nextToken)
+	assert.NotContains(t, *nextToken, want[1], "token must be opaque")
+
+	// Instances launched before the cursor must not shift the next pages
+	register(5)
+	register(15)
+	paged := instanceIDs
+	for nextToken != nil {
+		instanceIDs, nextToken = describePage(nextToken)
+		paged = append(paged, instanceIDs...)
+	}
+	assert.Equal(t, want, paged)
+
+	_, err := dispatch.dispatchDescribeInstances(ctx, &api.DescribeInstancesRequest{
+		PaginableRequest: api.PaginableRequest{NextToken: new("not a token!")},
+	})
+	var apiErr *api.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, api.ErrorCodeInvalidParameterValue, apiErr.Code)
+}
+
+func TestDescribeInstancesReportsSecurityGroupsOnInstanceAndInterface(t *testing.T) {
+	t.Parallel()
+
+	dispatch := &Dispatcher{
+		exe:     &runningInstancesExecutor{exitCleanupExecutor: &exitCleanupExecutor{}},
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+	ctx := context.Background()
+	createResp, err := dispatch.dispatchCreateSecurityGroup(ctx, &api.CreateSecurityGroupRequest{
+		GroupName:   "web",
+		Description: "web servers",
+	})
+	require.NoError(t, err)
+
+	const (
+		defaultGroupInstanceID = "i-00000000000000001"
+		customGroupInstanceID  = "i-00000000000000002"
+	)
+	for _, instanceID := range []string{defaultGroupInstanceID, customGroupInstanceID} {
+		require.NoError(t, dispatch.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeInstance, ID: instanceID}))
+	}
+	groupIDs, err := marshalStringSlice([]string{*createResp.GroupID, defaultSecurityGroupID})
+	require.NoError(t, err)
+	require.NoError(t, dispatch.storage.SetResourceAttributes(customGroupInstanceID, []storage.Attribute{
+		{Key: attributeNameInstanceSecurityGroupIDs, Value: groupIDs},
+	}))
+
+	resp, err := dispatch.dispatchDescribeInstances(ctx, &api.DescribeInstancesRequest{})
+	require.NoError(t, err)
+	require.Len(t, resp.ReservationSet, 1)
+	instances := resp.ReservationSet[0].InstancesSet
+	require.Len(t, instances, 2)
+
+	expected := map[string][]api.Group{
+		defaultGroupInstanceID: {
+			{GroupID: defaultSecurityGroupID, GroupName: defaultSecurityGroupName},
+		},
+		customGroupInstanceID: {
+			{GroupID: *createResp.GroupID, GroupName: "web"},
+			{GroupID: defaultSecurityGroupID, GroupName: defaultSecurityGroupName},
+		},
+	}
+	for _, instance := range instances {
+		assert.Equal(t, expected[instance.InstanceID], instance.SecurityGroups, instance.InstanceID)
+		require.Len(t, instance.NetworkInterfaces, 1)
+		assert.Equal(t, instance.SecurityGroups, instance.NetworkInterfaces[0].Groups, instance.InstanceID)
+	}
+}
+
+func TestInstancePlatform(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		imagePlatform string
+		override      string
+		wantPlatform  *string
+		wantDetails   string
+	}{
+		{name: "linux image", imagePlatform: "linux", wantDetails: "Linux/UNIX"},
+		{name: "unknown image", wantDetails: "Linux/UNIX"},
+		{name: "windows image", imagePlatform: "windows", wantPlatform: new("windows"), wantDetails: "Windows"},
+		{name: "linux override", imagePlatform: "linux", override: "Red Hat Enterprise Linux", wantDetails: "Red Hat Enterprise Linux"},
+		{name: "windows override", imagePlatform: "linux", override: "Windows with SQL Server Standard", wantPlatform: new("windows"), wantDetails: "Windows with SQL Server Standard"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			platform, details := instancePlatform(tc.imagePlatform, tc.override)
+			assert.Equal(t, tc.wantPlatform, platform)
+			assert.Equal(t, tc.wantDetails, details)
+		})
+	}
+}
+
+func TestDescribeInstancesPlatformDetailsOverride(t *testing.T) {
+	t.Parallel()
+
+	dispatch := &Dispatcher{
+		exe:     &runningInstancesExecutor{exitCleanupExecutor: &exitCleanupExecutor{}},
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+	const (
+		linuxInstanceID   = "i-00000000000000001"
+		windowsInstanceID = "i-00000000000000002"
+	)
+	for _, instanceID := range []string{linuxInstanceID, windowsInstanceID} {
+		require.NoError(t, dispatch.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeInstance, ID: instanceID}))
+	}
+	require.NoError(t, dispatch.storage.SetResourceAttributes(windowsInstanceID, []storage.Attribute{
+		{Key: storage.TagAttributeName(platformDetailsOverrideTagKey), Value: "Windows"},
+	}))
+
+	resp, err := dispatch.dispatchDescribeInstances(context.Background(), &api.DescribeInstancesRequest{})
+	require.NoError(t, err)
+	require.Len(t, resp.ReservationSet, 1)
+	instances := resp.ReservationSet[0].InstancesSet
+	require.Len(t, instances, 2)
+	assert.Nil(t, instances[0].Platform)
+	assert.Equal(t, "Linux/UNIX", instances[0].PlatformDetails)
+	assert.Equal(t, new("windows"), instances[1].Platform)
+	assert.Equal(t, "Windows", instances[1].PlatformDetails)
+}
+
+func TestModifyInstanceAttributeRequiresStoppedInstance(t *testing.T) {
+	t.Parallel()
+
+	dispatch := &Dispatcher{
+		exe:     &runningInstancesExecutor{exitCleanupExecutor: &exitCleanupExecutor{}},
+		storage: storage.NewMemoryStorage(),
+	}
+	const instanceID = "i-0123456789abcdef0"
+	require.NoError(t, dispatch.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeInstance, ID: instanceID}))
+
+	_, err := dispatch.dispatchModifyInstanceAttribute(context.Background(), &api.ModifyInstanceAttributeRequest{
+		InstanceID:   instanceID,
+		InstanceType: &api.AttributeValue{Value: new("t3.large")},
+	})
+	var apiErr *api.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, api.ErrorCodeIncorrectInstanceState, apiErr.Code)
+}
+
+// userDataExecutor records the user data of the instances it modifies, like
+// the labels the Docker executor recreates their containers with.
+type userDataExecutor struct {
+	*scalingExecutor
+	userData map[executor.InstanceID]string
+}
+
+func (e *userDataExecutor) ModifyInstanceAttribute(_ context.Context, req executor.ModifyInstanceAttributeRequest) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.instances[req.InstanceID] != api.InstanceStateStopped {
+		return api.ErrWithCode(api.ErrorCodeIncorrectInstanceState, fmt.Errorf("instance %s is not stopped", req.InstanceID))
+	}
+	if req.UserData != nil {
+		e.userData[req.InstanceID] = *req.UserData
+	}
+	return nil
+}
+
+func TestModifyInstanceAttributeUserData(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	exe := &userDataExecutor{
+		scalingExecutor: newScalingExecutor(),
+		userData:        make(map[executor.InstanceID]string),
+	}
+	d := &Dispatcher{
+		exe:     exe,
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+		opts:    DispatcherOptions{Region: "us-east-1"},
+	}
+	runResp, err := d.dispatchRunInstances(ctx, &api.RunInstancesRequest{
+		ImageID:      "nginx",
+		InstanceType: "t3.micro",
+		UserData:     base64.StdEncoding.EncodeToString([]byte("#!/bin/sh\necho old\n")),
+		MinCount:     1,
+		MaxCount:     1,
+	})
+	require.NoError(t, err)
+	require.Len(t, runResp.InstancesSet, 1)
+	instanceID := runResp.InstancesSet[0].InstanceID
+
+	userData := func() string {
+		t.Helper()
+		resp, err := d.dispatchDescribeInstanceAttribute(ctx, &api.DescribeInstanceAttributeRequest{
+			InstanceID: instanceID,
+			Attribute:  "userData",
+		})
+		require.NoError(t, err)
+		require.NotNil(t, resp.UserData)
+		if resp.UserData.Value == nil {
+			return ""
+		}
+		decoded, err := base64.StdEncoding.DecodeString(*resp.UserData.Value)
+		require.NoError(t, err)
+		return string(decoded)
+	}
+	modifyUserData := func(value string) error {
+		_, err := d.dispatchModifyInstanceAttribute(ctx, &api.ModifyInstanceAttributeRequest{
+			InstanceID: instanceID,
+			UserData:   &api.AttributeValue{Value: new(base64.StdEncoding.EncodeToString([]byte(value)))},
+		})
+		return err
+	}
+
+	// Running instances can't change their user data
+	err = modifyUserData("#!/bin/sh\necho new\n")
+	var apiErr *api.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, api.ErrorCodeIncorrectInstanceState, apiErr.Code)
+	assert.Equal(t, "#!/bin/sh\necho old\n", userData())
+
+	_, err = d.dispatchStopInstances(ctx, &api.StopInstancesRequest{InstanceIDs: []string{instanceID}})
+	require.NoError(t, err)
+	require.NoError(t, modifyUserData("#!/bin/sh\necho new\n"))
+	assert.Equal(t, "#!/bin/sh\necho new\n", userData())
+	assert.Equal(t, "#!/bin/sh\necho new\n", exe.userData[executorInstanceID(instanceID)])
+
+	_, err = d.dispatchStartInstances(ctx, &api.StartInstancesRequest{InstanceIDs: []string{instanceID}})
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/sh\necho new\n", userData())
+}
+
+func TestModifyInstanceAttributeRequest(t *testing.T) {
+	t.Parallel()
+
+	const instanceID = "i-0123456789abcdef0"
+	req, err := modifyInstanceAttributeRequest(&api.ModifyInstanceAttributeRequest{
+		InstanceID: instanceID,
+		Attribute:  new("instanceType"),
+		Value:      new("t3.large"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, executorInstanceID(instanceID), req.InstanceID)
+	assert.Equal(t, new("t3.large"), req.InstanceType)
+	assert.Nil(t, req.UserData)
+
+	req, err = modifyInstanceAttributeRequest(&api.ModifyInstanceAttributeRequest{
+		InstanceID: instanceID,
+		UserData:   &api.AttributeValue{Value: new("ZWNobyBoaQ==")},
+	})
+	require.NoError(t, err)
+	assert.Nil(t, req.InstanceType)
+	assert.Equal(t, new("echo hi"), req.UserData)
+
+	for _, bad := range []*api.ModifyInstanceAttributeRequest{
+		{InstanceID: instanceID},
+		{InstanceID: instanceID, Attribute: new("kernel"), Value: new("aki-123")},
+		{InstanceID: instanceID, InstanceType: &api.AttributeValue{Value: new(" ")}},
+	} {
+		_, err := modifyInstanceAttributeRequest(bad)
+		var apiErr *api.Error
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, api.ErrorCodeInvalidParameterValue, apiErr.Code)
+	}
+}
+
+type attachedVolumesExecutor struct {
+	*runningInstancesExecutor
+	volumes []executor.VolumeDescription
+}
+
+func (e *attachedVolumesExecutor) DescribeVolumes(_ context.Context, req executor.DescribeVolumesRequest) ([]executor.VolumeDescription, error) {
+	var descs []executor.VolumeDescription
+	for _, id := range req.VolumeIDs {
+		idx := slices.IndexFunc(e.volumes, func(desc executor.VolumeDescription) bool { return desc.VolumeID == id })
+		if idx < 0 {
+			return nil, fmt.Errorf("volume %s not found", id)
+		}
+		descs = append(descs, e.volumes[idx])
+	}
+	return descs, nil
+}
+
+func TestDescribeInstanceAttribute(t *testing.T) {
+	t.Parallel()
+
+	const (
+		instanceID      = "i-0123456789abcdef0"
+		volumeID        = "vol-0123456789abcdef0"
+		otherVolumeID   = "vol-0123456789abcdef1"
+		missingVolumeID = "vol-0123456789abcdef2"
+	)
+	ctx := context.Background()
+	attachTime := time.Now().UTC()
+	dispatch := &Dispatcher{
+		exe: &attachedVolumesExecutor{
+			runningInstancesExecutor: &runningInstancesExecutor{exitCleanupExecutor: &exitCleanupExecutor{}},
+			volumes: []executor.VolumeDescription{{
+				VolumeID: executorVolumeID(volumeID),
+				Attachments: []executor.VolumeAttachment{{
+					Device:     "/dev/sdf",
+					InstanceID: executorInstanceID(instanceID),
+					AttachTime: attachTime,
+				}},
+			}, {
+				VolumeID: executorVolumeID(otherVolumeID),
+				Attachments: []executor.VolumeAttachment{{
+					Device:     "/dev/sdg",
+					InstanceID: executorInstanceID("i-0123456789abcdef1"),
+					AttachTime: attachTime,
+				}},
+			}},
+		},
+		storage: storage.NewMemoryStorage(),
+	}
+	createResp, err := dispatch.dispatchCreateSecurityGroup(ctx, &api.CreateSecurityGroupRequest{
+		GroupName:   "web",
+		Description: "web servers",
+	})
+	require.NoError(t, err)
+	securityGroupIDs, err := marshalStringSlice([]string{*createResp.GroupID})
+	require.NoError(t, err)
+	require.NoError(t, dispatch.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeInstance, ID: instanceID}))
+	require.NoError(t, dispatch.storage.SetResourceAttributes(instanceID, []storage.Attribute{
+		{Key: attributeNameInstanceSecurityGroupIDs, Value: securityGroupIDs},
+	}))
+	// Only the volumes stored as attached to the instance are described, and
+	// the ones that can't be described are left out
+	for _, id := range []string{volumeID, otherVolumeID, missingVolumeID} {
+		require.NoError(t, dispatch.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeVolume, ID: id}))
+	}
+	require.NoError(t, dispatch.storage.SetResourceAttributes(volumeID, []storage.Attribute{
+		{Key: attributeNameVolumeAttachedInstanceID, Value: instanceID},
+	}))
+	require.NoError(t, dispatch.storage.SetResourceAttributes(missingVolumeID, []storage.Attribute{
+		{Key: attributeNameVolumeAttachedInstanceID, Value: instanceID},
+	}))
+
+	resp, err := dispatch.dispatchDescribeInstanceAttribute(ctx, &api.DescribeInstanceAttributeRequest{
+		InstanceID: instanceID,
+		Attribute:  "blockDeviceMapping",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []api.InstanceBlockDeviceMapping{{
+		DeviceName: "/dev/sdf",
+		EBS: &api.EBSInstanceBlockDevice{
+			AttachTime: &attachTime,
+			Status:     types.VolumeAttachmentStateAttached,
+			VolumeID:   volumeID,
+		},
+	}}, resp.BlockDeviceMappings)
+	assert.Nil(t, resp.Groups)
+
+	resp, err = dispatch.dispatchDescribeInstanceAttribute(ctx, &api.DescribeInstanceAttributeRequest{
+		InstanceID: instanceID,
+		Attribute:  "groupSet",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []api.Group{{GroupID: *createResp.GroupID, GroupName: "web"}}, resp.Groups)
+	assert.Nil(t, resp.BlockDeviceMappings)
+
+	resp, err = dispatch.dispatchDescribeInstanceAttribute(ctx, &api.DescribeInstanceAttributeRequest{
+		InstanceID: instanceID,
+		Attribute:  "userData",
+	})
+	require.NoError(t, err)
+	require.NotNil(t, resp.UserData)
+	assert.Nil(t, resp.UserData.Value)
+	require.NoError(t, dispatch.storage.SetResourceAttributes(instanceID, []storage.Attribute{
+		{Key: attributeNameInstanceUserData, Value: "#!/bin/sh\necho hello\n"},
+	}))
+	resp, err = dispatch.dispatchDescribeInstanceAttribute(ctx, &api.DescribeInstanceAttributeRequest{
+		InstanceID: instanceID,
+		Attribute:  "userData",
+	})
+	require.NoError(t, err)
+	require.NotNil(t, resp.UserData.Value)
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("#!/bin/sh\necho hello\n")), *resp.UserData.Value)
+
+	_, err = dispatch.dispatchDescribeInstanceAttribute(ctx, &api.DescribeInstanceAttributeRequest{
+		InstanceID: instanceID,
+		Attribute:  "kernel",
+	})
+	var apiErr *api.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, api.ErrorCodeInvalidParameterValue, apiErr.Code)
+}
+
+func TestInstanceReservations(t *testing.T) {
+	t.Parallel()
+
+	reservations := instanceReservations([]api.Instance{
+		{InstanceID: "i-1", ReservationID: "r-1"},
+		{InstanceID: "i-2", ReservationID: "r-2"},
+		{InstanceID: "i-3", ReservationID: "r-1"},
+		{InstanceID: "i-4"},
+	})
+	require.Len(t, reservations, 3)
+	assert.Equal(t, "r-1", reservations[0].ReservationID)
+	assert.Equal(t, []string{"i-1", "i-3"}, []string{reservations[0].InstancesSet[0].InstanceID, reservations[0].InstancesSet[1].InstanceID})
+	assert.Equal(t, "r-2", reservations[1].ReservationID)
+	assert.Len(t, reservations[1].InstancesSet, 1)
+	assert.Empty(t, reservations[2].ReservationID)
+	assert.Len(t, reservations[2].InstancesSet, 1)
+	assert.Empty(t, instanceReservations(nil))
+}
+
+func TestRunInstancesClientToken(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	d := &Dispatcher{
+		opts:    DispatcherOptions{Region: "us-east-1"},
+		exe:     newScalingExecutor(),
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+	req := &api.RunInstancesRequest{
+		CommonRequest: api.CommonRequest{ClientToken: "token-1"},
+		ImageID:       "nginx",
+		InstanceType:  "my-type",
+		MinCount:      2,
+		MaxCount:      2,
+	}
+	first, err := d.dispatchRunInstances(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, first.InstancesSet, 2)
+	second, err := d.dispatchRunInstances(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, first.ReservationID, second.ReservationID)
+	require.Len(t, second.InstancesSet, 2)
+	assert.ElementsMatch(t,
+		[]string{first.InstancesSet[0].InstanceID, first.InstancesSet[1].InstanceID},
+		[]string{second.InstancesSet[0].InstanceID, second.InstancesSet[1].InstanceID},
+	)
+
+	instances, err := d.storage.RegisteredResources(types.ResourceTypeInstance)
+	require.NoError(t, err)
+	assert.Len(t, instances, 2)
+}
+
+func TestDescribeInstancesLaunchTemplateFilters(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	d := &Dispatcher{
+		opts:    DispatcherOptions{Region: "us-east-1"},
+		exe:     newScalingExecutor(),
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+	createResp, err := d.dispatchCreateLaunchTemplate(ctx, &api.CreateLaunchTemplateRequest{
+		LaunchTemplateName: "lt",
+		LaunchTemplateData: api.LaunchTemplateData{ImageID: "nginx", InstanceType: "my-type"},
+	})
+	require.NoError(t, err)
+	launchTemplateID := *createResp.LaunchTemplate.LaunchTemplateID
+
+	fromTemplate, err := d.dispatchRunInstances(ctx, &api.RunInstancesRequest{
+		LaunchTemplate: &api.AutoScalingLaunchTemplateSpecification{LaunchTemplateID: &launchTemplateID},
+		MinCount:       1,
+		MaxCount:       1,
+	})
+	require.NoError(t, err)
+	require.Len(t, fromTemplate.InstancesSet, 1)
+	_, err = d.dispatchRunInstances(ctx, &api.RunInstancesRequest{
+		ImageID:      "nginx",
+		InstanceType: "my-type",
+		MinCount:     1,
+		MaxCount:     1,
+	})
+	require.NoError(t, err)
+
+	describe := func(filters ...api.Filter) []string {
+		t.Helper()
+		resp, err := d.dispatchDescribeInstances(ctx, &api.DescribeInstancesRequest{Filters: filters})
+		require.NoError(t, err)
+		var instanceIDs []string
+		for _, reservation := range resp.ReservationSet {
+			for _, instance := range reservation.InstancesSet {
+				instanceIDs = append(instanceIDs, instance.InstanceID)
+			}
+		}
+		return instanceIDs
+	}
+	expected := []string{fromTemplate.InstancesSet[0].InstanceID}
+	assert.Equal(t, expected, describe(api.Filter{Name: new("launch-template-id"), Values: []string{launchTemplateID}}))
+	assert.Equal(t, expected, describe(
+		api.Filter{Name: new("launch-template-id"), Values: []string{launchTemplateID}},
+		api.Filter{Name: new("launch-template-version"), Values: []string{"1"}},
+	))
+	assert.Equal(t, expected, describe(api.Filter{Name: new("tag:aws:ec2launchtemplate:id"), Values: []string{launchTemplateID}}))
+	assert.Empty(t, describe(api.Filter{Name: new("launch-template-version"), Values: []string{"2"}}))
+}
@@ -0,0 +1,445 @@
+package dc2
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/md5" //nolint:gosec // AWS fingerprints imported RSA keys with MD5
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1" //nolint:gosec // AWS fingerprints created RSA keys with SHA-1
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+const (
+	keyPairIDPrefix = "key-"
+
+	keyPairTypeRSA     = "rsa"
+	keyPairTypeED25519 = "ed25519"
+
+	keyPairFormatPEM = "pem"
+
+	rsaKeyPairBits = 2048
+
+	attributeNameKeyPairName        = "KeyPairName"
+	attributeNameKeyPairFingerprint = "KeyPairFingerprint"
+	attributeNameKeyPairType        = "KeyPairType"
+	attributeNameKeyPairPublicKey   = "KeyPairPublicKey"
+	attributeNameKeyPairCreateTime  = "KeyPairCreateTime"
+)
+
+type keyPairData struct {
+	ID          string
+	Name        string
+	Fingerprint string
+	Type        string
+	PublicKey   string
+	CreateTime  *time.Time
+	Tags        []api.Tag
+}
+
+func (d *Dispatcher) dispatchCreateKeyPair(ctx context.Context, req *api.CreateKeyPairRequest) (*api.CreateKeyPairResponse, error) {
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
+	if err := validateTagSpecifications(req.TagSpecifications, types.ResourceTypeKeyPair); err != nil {
+		return nil, err
+	}
+	keyType := keyPairTypeRSA
+	if req.KeyType != nil && *req.KeyType != "" {
+		keyType = *req.KeyType
+	}
+	if req.KeyFormat != nil && *req.KeyFormat != "" && *req.KeyFormat != keyPairFormatPEM {
+		return nil, api.InvalidParameterValueError("KeyFormat", *req.KeyFormat)
+	}
+	if err := d.ensureKeyPairNameAvailable(req.KeyName); err != nil {
+		return nil, err
+	}
+
+	var (
+		material    []byte
+		fingerprint string
+		publicKey   ssh.PublicKey
+	)
+	switch keyType {
+	case keyPairTypeRSA:
+		privateKey, err := rsa.GenerateKey(rand.Reader, rsaKeyPairBits)
+		if err != nil {
+			return nil, fmt.Errorf("generating RSA key: %w", err)
+		}
+		material = pem.EncodeToMemory(&pem.Block{
+			Type:  "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+		})
+		der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+		if err != nil {
+			return nil, fmt.Errorf("encoding RSA key: %w", err)
+		}
+		sum := sha1.Sum(der) //nolint:gosec // see import
+		fingerprint = colonHex(sum[:])
+		publicKey, err = ssh.NewPublicKey(&privateKey.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("encoding RSA public key: %w", err)
+		}
+	case keyPairTypeED25519:
+		pub, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("generating ED25519 key: %w", err)
+		}
+		block, err := ssh.MarshalPrivateKey(privateKey, "")
+		if err != nil {
+			return nil, fmt.Errorf("encoding ED25519 key: %w", err)
+		}
+		material = pem.EncodeToMemory(block)
+		publicKey, err = ssh.NewPublicKey(pub)
+		if err != nil {
+			return nil, fmt.Errorf("encoding ED25519 public key: %w", err)
+		}
+		fingerprint = sha256Fingerprint(publicKey)
+	default:
+		return nil, api.InvalidParameterValueError("KeyType", keyType)
+	}
+
+	data, err := d.registerKeyPair(ctx, req.KeyName, keyType, fingerprint, publicKey, tagSpecsToTags(req.TagSpecifications))
+	if err != nil {
+		return nil, err
+	}
+	return &api.CreateKeyPairResponse{
+		KeyPairID:      data.ID,
+		KeyName:        data.Name,
+		KeyFingerprint: data.Fingerprint,
+		KeyMaterial:    strings.TrimSpace(string(material)),
+		Tags:           data.Tags,
+	}, nil
+}
+
+func (d *Dispatcher) dispatchImportKeyPair(ctx context.Context, req *api.ImportKeyPairRequest) (*api.ImportKeyPairResponse, error) {
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
+	if err := validateTagSpecifications(req.TagSpecifications, types.ResourceTypeKeyPair); err != nil {
+		return nil, err
+	}
+	publicKey, err := parseImportedPublicKey(req.PublicKeyMaterial)
+	if err != nil {
+		return nil, api.ErrWithCode("InvalidKey.Format", fmt.Errorf("Key is not in valid OpenSSH public key format")) //nolint
+	}
+	var keyType, fingerprint string
+	switch publicKey.Type() {
+	case ssh.KeyAlgoRSA:
+		keyType = keyPairTypeRSA
+		cryptoKey, ok := publicKey.(ssh.CryptoPublicKey)
+		if !ok {
+			return nil, api.ErrWithCode("InvalidKey.Format", fmt.Errorf("Key is not in valid OpenSSH public key format")) //nolint
+		}
+		der, err := x509.MarshalPKIXPublicKey(cryptoKey.CryptoPublicKey())
+		if err != nil {
+			return nil, fmt.Errorf("encoding imported public key: %w", err)
+		}
+		sum := md5.Sum(der) //nolint:gosec // see import
+		fingerprint = colonHex(sum[:])
+	case ssh.KeyAlgoED25519:
+		keyType = keyPairTypeED25519
+		fingerprint = sha256Fingerprint(publicKey)
+	default:
+		return nil, api.ErrWithCode("InvalidKey.Format", fmt.Errorf("Key type %s is not supported", publicKey.Type())) //nolint
+	}
+	if err := d.ensureKeyPairNameAvailable(req.KeyName); err != nil {
+		return nil, err
+	}
+
+	data, err := d.registerKeyPair(ctx, req.KeyName, keyType, fingerprint, publicKey, tagSpecsToTags(req.TagSpecifications))
+	if err != nil {
+		return nil, err
+	}
+	return &api.ImportKeyPairResponse{
+		KeyPairID:      data.ID,
+		KeyName:        data.Name,
+		KeyFingerprint: data.Fingerprint,
+		Tags:           data.Tags,
+	}, nil
+}
+
+func (d *Dispatcher) dispatchDescribeKeyPairs(_ context.Context, req *api.DescribeKeyPairsRequest) (*api.DescribeKeyPairsResponse, error) {
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
+	keyPairs, err := d.listKeyPairs()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range req.KeyNames {
+		if !slices.ContainsFunc(keyPairs, func(kp keyPairData) bool { return kp.Name == name }) {
+			return nil, keyPairNotFoundError(name)
+		}
+	}
+	for _, id := range req.KeyPairIDs {
+		if !slices.ContainsFunc(keyPairs, func(kp keyPairData) bool { return kp.ID == id }) {
+			return nil, keyPairNotFoundError(id)
+		}
+	}
+
+	includePublicKey := req.IncludePublicKey != nil && *req.IncludePublicKey
+	out := make([]api.KeyPair, 0, len(keyPairs))
+	for _, kp := range keyPairs {
+		if len(req.KeyNames) > 0 && !slices.Contains(req.KeyNames, kp.Name) {
+			continue
+		}
+		if len(req.KeyPairIDs) > 0 && !slices.Contains(req.KeyPairIDs, kp.ID) {
+			continue
+		}
+		matches, err := keyPairMatchesFilters(kp, req.Filters)
+		if err != nil {
+			return nil, err
+		}
+		if !matches {
+			continue
+		}
+		keyPair := api.KeyPair{
+			KeyPairID:      kp.ID,
+			KeyName:        kp.Name,
+			KeyFingerprint: kp.Fingerprint,
+			KeyType:        kp.Type,
+			CreateTime:     kp.CreateTime,
+			Tags:           kp.Tags,
+		}
+		if includePublicKey {
+			publicKey := kp.PublicKey
+			keyPair.PublicKey = &publicKey
+		}
+		out = append(out, keyPair)
+	}
+	return &api.DescribeKeyPairsResponse{
+		KeyPairs: out,
+	}, nil
+}
+
+func (d *Dispatcher) dispatchDeleteKeyPair(ctx context.Context, req *api.DeleteKeyPairRequest) (*api.DeleteKeyPairResponse, error) {
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
+	var keyPairID string
+	switch {
+	case req.KeyPairID != nil && *req.KeyPairID != "":
+		if _, err := d.findResource(ctx, types.ResourceTypeKeyPair, *req.KeyPairID); err != nil {
+			if errors.As(err, &storage.ErrResourceNotFound{}) {
+				return nil, keyPairNotFoundError(*req.KeyPairID)
+			}
+			return nil, err
+		}
+		keyPairID = *req.KeyPairID
+	case req.KeyName != nil && *req.KeyName != "":
+		kp, err := d.findKeyPairByName(*req.KeyName)
+		if err != nil {
+			return nil, err
+		}
+		if kp == nil {
+			// Like AWS, deleting a key pair that doesn't exist by name succeeds.
+			return &api.DeleteKeyPairResponse{Return: true}, nil
+		}
+		keyPairID = kp.ID
+	default:
+		return nil, api.ErrWithCode("MissingParameter", fmt.Errorf("The request must contain the parameter KeyName or KeyPairId")) //nolint
+	}
+
+	if err := d.storage.RemoveResource(keyPairID); err != nil {
+		return nil, fmt.Errorf("deleting key pair: %w", err)
+	}
+	api.Logger(ctx).Info("deleted key pair", slog.String("key_pair_id", keyPairID))
+	return &api.DeleteKeyPairResponse{
+		Return:    true,
+		KeyPairID: &keyPairID,
+	}, nil
+}
+
+func (d *Dispatcher) ensureKeyPairNameAvailable(name string) error {
+	kp, err := d.findKeyPairByName(name)
+	if err != nil {
+		return err
+	}
+	if kp != nil {
+		return api.ErrWithCode("InvalidKeyPair.Duplicate", fmt.Errorf("The keypair '%s' already exists.", name)) //nolint
+	}
+	return nil
+}
+
+func (d *Dispatcher) registerKeyPair(ctx context.Context, name string, keyType string, fingerprint string, publicKey ssh.PublicKey, tags []api.Tag) (*keyPairData, error) {
+	keyPairID, err := makeID(keyPairIDPrefix)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.storage.RegisterResource(storage.Resource{
+		Type: types.ResourceTypeKeyPair,
+		ID:   keyPairID,
+	}); err != nil {
+		return nil, fmt.Errorf("registering key pair: %w", err)
+	}
+
+	now := time.Now().UTC()
+	authorizedKey := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(publicKey))) + " " + name
+	attrs := []storage.Attribute{
+		{Key: attributeNameKeyPairName, Value: name},
+		{Key: attributeNameKeyPairFingerprint, Value: fingerprint},
+		{Key: attributeNameKeyPairType, Value: keyType},
+		{Key: attributeNameKeyPairPublicKey, Value: authorizedKey},
+		{Key: attributeNameKeyPairCreateTime, Value: now.Format(time.RFC3339Nano)},
+	}
+	for _, tag := range tags {
+		attrs = append(attrs, storage.Attribute{Key: storage.TagAttributeName(tag.Key), Value: tag.Value})
+	}
+	if err := d.storage.SetResourceAttributes(keyPairID, attrs); err != nil {
+		return nil, fmt.Errorf("saving key pair attributes: %w", err)
+	}
+
+	api.Logger(ctx).Info(
+		"created key pair",
+		slog.String("key_pair_id", keyPairID),
+		slog.String("key_name", name),
+		slog.String("key_type", keyType),
+	)
+	return &keyPairData{
+		ID:          keyPairID,
+		Name:        name,
+		Fingerprint: fingerprint,
+		Type:        keyType,
+		PublicKey:   authorizedKey,
+		CreateTime:  &now,
+		Tags:        tags,
+	}, nil
+}
+
+func (d *Dispatcher) listKeyPairs() ([]keyPairData, error) {
+	resources, err := d.storage.RegisteredResources(types.ResourceTypeKeyPair)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving key pairs: %w", err)
+	}
+	keyPairs := make([]keyPairData, 0, len(resources))
+	for _, r := range resources {
+		attrs, err := d.storage.ResourceAttributes(r.ID)
+		if err != nil {
+			return nil, fmt.Errorf("retrieving key pair attributes: %w", err)
+		}
+		kp := keyPairData{ID: r.ID, Tags: tagsFromAttributes(attrs)}
+		kp.Name, _ = attrs.Key(attributeNameKeyPairName)
+		kp.Fingerprint, _ = attrs.Key(attributeNameKeyPairFingerprint)
+		kp.Type, _ = attrs.Key(attributeNameKeyPairType)
+		kp.PublicKey, _ = attrs.Key(attributeNameKeyPairPublicKey)
+		if createTime, ok := attrs.Key(attributeNameKeyPairCreateTime); ok {
+			t, err := parseTime(createTime)
+			if err != nil {
+				return nil, fmt.Errorf("parsing key pair create time: %w", err)
+			}
+			kp.CreateTime = &t
+		}
+		keyPairs = append(keyPairs, kp)
+	}
+	return keyPairs, nil
+}
+
+// findKeyPairByName returns the key pair with the given name, or nil if
+// there's none.
+func (d *Dispatcher) findKeyPairByName(name string) (*keyPairData, error) {
+	keyPairs, err := d.listKeyPairs()
+	if err != nil {
+		return nil, err
+	}
+	for _, kp := range keyPairs {
+		if kp.Name == name {
+			return &kp, nil
+		}
+	}
+	return nil, nil
+}
+
+func keyPairMatchesFilters(kp keyPairData, filters []api.Filter) (bool, error) {
+	for _, filter := range filters {
+		if filter.Name == nil {
+			return false, api.InvalidParameterValueError("Filter.Name", "<missing>")
+		}
+		if filter.Values == nil {
+			return false, api.InvalidParameterValueError("Filter.Values", "<missing>")
+		}
+		filterName := strings.TrimSpace(strings.ToLower(*filter.Name))
+		if filterName == "" {
+			return false, api.InvalidParameterValueError("Filter.Name", "<empty>")
+		}
+
+		switch {
+		case filterName == "key-pair-id":
+			if !slices.Contains(filter.Values, kp.ID) {
+				return false, nil
+			}
+		case filterName == "key-name":
+			if !slices.Contains(filter.Values, kp.Name) {
+				return false, nil
+			}
+		case filterName == "fingerprint":
+			if !slices.Contains(filter.Values, kp.Fingerprint) {
+				return false, nil
+			}
+		case filterName == "key-type":
+			if !slices.Contains(filter.Values, kp.Type) {
+				return false, nil
+			}
+		case filterName == "tag-key":
+			if !slices.ContainsFunc(kp.Tags, func(tag api.Tag) bool { return slices.Contains(filter.Values, tag.Key) }) {
+				return false, nil
+			}
+		case strings.HasPrefix(filterName, "tag:"):
+			tagKey := (*filter.Name)[len("tag:"):]
+			if !slices.ContainsFunc(kp.Tags, func(tag api.Tag) bool {
+				return tag.Key == tagKey && slices.Contains(filter.Values, tag.Value)
+			}) {
+				return false, nil
+			}
+		default:
+			return false, api.InvalidParameterValueError("Filter.Name", *filter.Name)
+		}
+	}
+	return true, nil
+}
+
+// parseImportedPublicKey parses an OpenSSH public key, accepting it either
+// verbatim or base64 encoded, since the SDKs encode blobs before sending them.
+func parseImportedPublicKey(material string) (ssh.PublicKey, error) {
+	raw := []byte(strings.TrimSpace(material))
+	if decoded, err := base64.StdEncoding.DecodeString(string(raw)); err == nil {
+		raw = decoded
+	}
+	publicKey, _, _, _, err := ssh.ParseAuthorizedKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parsing public key: %w", err)
+	}
+	return publicKey, nil
+}
+
+func sha256Fingerprint(publicKey ssh.PublicKey) string {
+	sum := sha256.Sum256(publicKey.Marshal())
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func colonHex(b []byte) string {
+	parts := make([]string, len(b))
+	for i, c := range b {
+		parts[i] = fmt.Sprintf("%02x", c)
+	}
+	return strings.Join(parts, ":")
+}
+
+func keyPairNotFoundError(nameOrID string) error {
+	return api.ErrWithCode("InvalidKeyPair.NotFound", fmt.Errorf("The key pair '%s' does not exist", nameOrID)) //nolint
+}
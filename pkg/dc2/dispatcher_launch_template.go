@@ -19,10 +19,15 @@ import (
 const (
 	launchTemplateIDPrefix = "lt-"
 
+	minLaunchTemplateNameLength               = 3
+	maxLaunchTemplateNameLength               = 128
+	maxLaunchTemplateVersionDescriptionLength = 255
+
 	attributeNameLaunchTemplateName           = "LaunchTemplateName"
 	attributeNameLaunchTemplateCreateTime     = "LaunchTemplateCreateTime"
 	attributeNameLaunchTemplateDefaultVersion = "LaunchTemplateDefaultVersion"
 	attributeNameLaunchTemplateLatestVersion  = "LaunchTemplateLatestVersion"
+	attributeNameLaunchTemplateClientToken    = "LaunchTemplateClientToken"
 
 	// Legacy attributes kept in sync with the current default version.
 	attributeNameLaunchTemplateImageID              = "LaunchTemplateDataImageID"
@@ -41,8 +46,12 @@ type launchTemplateData struct {
 	InstanceRequirements *api.InstanceRequirementsRequest
 	InstanceType         string
 	UserData             string
+	KeyName              string
 	SecurityGroupIDs     []string
+	IamInstanceProfile   *api.IamInstanceProfileSpecification
+	Placement            *api.LaunchTemplatePlacement
 	BlockDeviceMappings  []api.RunInstancesBlockDeviceMapping
+	Architecture         string
 }
 
 type launchTemplateMetadata struct {
@@ -59,17 +68,39 @@ type launchTemplateVersionData struct {
 	InstanceRequirements *api.InstanceRequirementsRequest
 	InstanceType         string
 	UserData             string
+	KeyName              string
 	SecurityGroupIDs     []string
+	IamInstanceProfile   *api.IamInstanceProfileSpecification
+	Placement            *api.LaunchTemplatePlacement
 	BlockDeviceMappings  []api.RunInstancesBlockDeviceMapping
+	Architecture         string
 	VersionDescription   *string
 	CreateTime           *time.Time
 }
 
 func (d *Dispatcher) dispatchCreateLaunchTemplate(ctx context.Context, req *api.CreateLaunchTemplateRequest) (*api.CreateLaunchTemplateResponse, error) {
+	clientToken := strings.TrimSpace(req.ClientToken)
+	previous, err := d.launchTemplateForClientToken(clientToken)
+	if err != nil {
+		return nil, err
+	}
+	if previous != nil {
+		if previous.Name != req.LaunchTemplateName {
+			return nil, api.ErrWithCode(
+				"IdempotentParameterMismatch",
+				fmt.Errorf("client token %q was already used to create launch template %q", clientToken, previous.ID),
+			)
+		}
+		launchTemplate := apiLaunchTemplate(*previous)
+		return &api.CreateLaunchTemplateResponse{
+			LaunchTemplate: &launchTemplate,
+		}, nil
+	}
+
 	if launchTemplateDataIsEmpty(req.LaunchTemplateData) {
 		return nil, api.InvalidParameterValueError("LaunchTemplateData", "<empty>")
 	}
-	if err := validateLaunchTemplateData(req.LaunchTemplateData); err != nil {
+	if err := validateLaunchTemplateData(req.LaunchTemplateData, d.opts.Region); err != nil {
 		return nil, err
 	}
 	if _, err := d.findLaunchTemplateByName(ctx, req.LaunchTemplateName); err == nil {
@@ -101,8 +132,12 @@ func (d *Dispatcher) dispatchCreateLaunchTemplate(ctx context.Context, req *api.
 		InstanceRequirements: instanceRequirements,
 		InstanceType:         req.LaunchTemplateData.InstanceType,
 		UserData:             req.LaunchTemplateData.UserData,
+		KeyName:              req.LaunchTemplateData.KeyName,
 		SecurityGroupIDs:     cloneStringSlice(req.LaunchTemplateData.SecurityGroupIDs),
+		IamInstanceProfile:   cloneIamInstanceProfileSpecification(req.LaunchTemplateData.IamInstanceProfile),
+		Placement:            cloneLaunchTemplatePlacement(req.LaunchTemplateData.Placement),
 		BlockDeviceMappings:  cloneBlockDeviceMappings(req.LaunchTemplateData.BlockDeviceMappings),
+		Architecture:         req.LaunchTemplateData.Architecture,
 		CreateTime:           &now,
 	}
 	attrs := []storage.Attribute{
@@ -111,6 +146,9 @@ func (d *Dispatcher) dispatchCreateLaunchTemplate(ctx context.Context, req *api.
 		{Key: attributeNameLaunchTemplateDefaultVersion, Value: "1"},
 		{Key: attributeNameLaunchTemplateLatestVersion, Value: "1"},
 	}
+	if clientToken != "" {
+		attrs = append(attrs, storage.Attribute{Key: attributeNameLaunchTemplateClientToken, Value: clientToken})
+	}
 	attrs = append(attrs, launchTemplateVersionAttributes(versionData)...)
 	attrs = append(attrs, legacyLaunchTemplateAttributes(versionData)...)
 	if err := d.storage.SetResourceAttributes(launchTemplateID, attrs); err != nil {
@@ -252,7 +290,7 @@ func (d *Dispatcher) dispatchCreateLaunchTemplateVersion(ctx context.Context, re
 	if req.DryRun {
 		return nil, api.DryRunError()
 	}
-	if err := validateLaunchTemplateData(req.LaunchTemplateData); err != nil {
+	if err := validateLaunchTemplateData(req.LaunchTemplateData, d.opts.Region); err != nil {
 		return nil, err
 	}
 
@@ -282,8 +320,12 @@ func (d *Dispatcher) dispatchCreateLaunchTemplateVersion(ctx context.Context, re
 		}
 		data.InstanceType = sourceData.InstanceType
 		data.UserData = sourceData.UserData
+		data.KeyName = sourceData.KeyName
 		data.SecurityGroupIDs = cloneStringSlice(sourceData.SecurityGroupIDs)
+		data.IamInstanceProfile = cloneIamInstanceProfileSpecification(sourceData.IamInstanceProfile)
+		data.Placement = cloneLaunchTemplatePlacement(sourceData.Placement)
 		data.BlockDeviceMappings = cloneBlockDeviceMappings(sourceData.BlockDeviceMappings)
+		data.Architecture = sourceData.Architecture
 	}
 	if req.LaunchTemplateData.ImageID != "" {
 		data.ImageID = req.LaunchTemplateData.ImageID
@@ -300,21 +342,26 @@ func (d *Dispatcher) dispatchCreateLaunchTemplateVersion(ctx context.Context, re
 	if req.LaunchTemplateData.UserData != "" {
 		data.UserData = req.LaunchTemplateData.UserData
 	}
+	if req.LaunchTemplateData.KeyName != "" {
+		data.KeyName = req.LaunchTemplateData.KeyName
+	}
 	if len(req.LaunchTemplateData.SecurityGroupIDs) > 0 {
 		data.SecurityGroupIDs = cloneStringSlice(req.LaunchTemplateData.SecurityGroupIDs)
 	}
+	if req.LaunchTemplateData.IamInstanceProfile != nil {
+		data.IamInstanceProfile = cloneIamInstanceProfileSpecification(req.LaunchTemplateData.IamInstanceProfile)
+	}
+	if req.LaunchTemplateData.Placement != nil {
+		data.Placement = cloneLaunchTemplatePlacement(req.LaunchTemplateData.Placement)
+	}
 	if len(req.LaunchTemplateData.BlockDeviceMappings) > 0 {
 		data.BlockDeviceMappings = cloneBlockDeviceMappings(req.LaunchTemplateData.BlockDeviceMappings)
 	}
+	if req.LaunchTemplateData.Architecture != "" {
+		data.Architecture = req.LaunchTemplateData.Architecture
+	}
 
-	if req.SourceVersion == nil &&
-		req.LaunchTemplateData.ImageID == "" &&
-		req.LaunchTemplateData.InstanceRequirements == nil &&
-		req.LaunchTemplateData.InstanceType == "" &&
-		req.LaunchTemplateData.UserData == "" &&
-		len(req.LaunchTemplateData.SecurityGroupIDs) == 0 &&
-		len(req.LaunchTemplateData.BlockDeviceMappings) == 0 &&
-		len(req.LaunchTemplateData.TagSpecifications) == 0 {
+	if req.SourceVersion == nil && launchTemplateDataIsEmpty(req.LaunchTemplateData) {
 		return nil, api.InvalidParameterValueError("LaunchTemplateData", "<empty>")
 	}
 
@@ -413,7 +460,17 @@ func (d *Dispatcher) dispatchDescribeLaunchTemplateVersions(ctx context.Context,
 			return nil, err
 		}
 		defaultVersion := v == meta.DefaultVersion
-		versions = append(versions, d.apiLaunchTemplateVersion(*meta, *data, defaultVersion))
+		version := d.apiLaunchTemplateVersion(*meta, *data, defaultVersion)
+		if len(req.Filters) > 0 {
+			matches, err := launchTemplateVersionMatchesFilters(version, req.Filters)
+			if err != nil {
+				return nil, err
+			}
+			if !matches {
+				continue
+			}
+		}
+		versions = append(versions, version)
 	}
 
 	versions, nextToken, err := applyNextToken(versions, req.NextToken, req.MaxResults)
@@ -427,13 +484,81 @@ func (d *Dispatcher) dispatchDescribeLaunchTemplateVersions(ctx context.Context,
 	}, nil
 }
 
-func (d *Dispatcher) dispatchModifyLaunchTemplate(ctx context.Context, req *api.ModifyLaunchTemplateRequest) (*api.ModifyLaunchTemplateResponse, error) {
-	if req.DryRun {
-		return nil, api.DryRunError()
+func launchTemplateVersionMatchesFilters(version api.LaunchTemplateVersion, filters []api.Filter) (bool, error) {
+	var data api.ResponseLaunchTemplateData
+	if version.LaunchTemplateData != nil {
+		data = *version.LaunchTemplateData
 	}
-	if req.SetDefaultVersion == nil || *req.SetDefaultVersion == "" {
+	for _, filter := range filters {
+		if filter.Name == nil {
+			return false, api.InvalidParameterValueError("Filter.Name", "<missing>")
+		}
+		if filter.Values == nil {
+			return false, api.InvalidParameterValueError("Filter.Values", "<missing>")
+		}
+		filterName := strings.TrimSpace(strings.ToLower(*filter.Name))
+		if filterName == "" {
+			return false, api.InvalidParameterValueError("Filter.Name", "<empty>")
+		}
+
+		switch filterName {
+		case "create-time":
+			matches, err := launchTemplateCreateTimeMatches(version.CreateTime, filter.Values)
+			if err != nil || !matches {
+				return false, err
+			}
+		case "is-default-version":
+			isDefault := strconv.FormatBool(version.DefaultVersion != nil && *version.DefaultVersion)
+			if !slices.ContainsFunc(filter.Values, func(value string) bool { return strings.EqualFold(value, isDefault) }) {
+				return false, nil
+			}
+		case "image-id":
+			if !slices.Contains(filter.Values, valueOrEmpty(data.ImageID)) {
+				return false, nil
+			}
+		case "instance-type":
+			if !slices.Contains(filter.Values, valueOrEmpty(data.InstanceType)) {
+				return false, nil
+			}
+		default:
+			// Preserve compatibility for callers that send additional AWS filters.
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// launchTemplateCreateTimeMatches reports whether createTime matches any of
+// the given RFC 3339 timestamps. Since EC2 reports times with millisecond
+// precision, timestamps without fractional seconds match any time within
+// that second.
+func launchTemplateCreateTimeMatches(createTime *time.Time, values []string) (bool, error) {
+	if createTime == nil {
+		return false, nil
+	}
+	for _, value := range values {
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return false, api.InvalidParameterValueError("create-time", value)
+		}
+		precision := time.Millisecond
+		if t.Nanosecond() == 0 {
+			precision = time.Second
+		}
+		if createTime.Truncate(precision).Equal(t) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (d *Dispatcher) dispatchModifyLaunchTemplate(ctx context.Context, req *api.ModifyLaunchTemplateRequest) (*api.ModifyLaunchTemplateResponse, error) {
+	if valueOrEmpty(req.SetDefaultVersion) == "" && req.VersionDescription == nil && req.NewLaunchTemplateName == nil {
 		return nil, api.InvalidParameterValueError("SetDefaultVersion", "<empty>")
 	}
+	if req.VersionDescription != nil && len(*req.VersionDescription) > maxLaunchTemplateVersionDescriptionLength {
+		return nil, api.InvalidParameterValueError("VersionDescription", *req.VersionDescription)
+	}
 
 	launchTemplateID, err := d.resolveLaunchTemplateReference(ctx, req.LaunchTemplateID, req.LaunchTemplateName)
 	if err != nil {
@@ -443,14 +568,34 @@ func (d *Dispatcher) dispatchModifyLaunchTemplate(ctx context.Context, req *api.
 	if err != nil {
 		return nil, err
 	}
-	defaultVersion, err := resolveLaunchTemplateVersionSelector(*req.SetDefaultVersion, meta.DefaultVersion, meta.LatestVersion, "SetDefaultVersion")
-	if err != nil {
-		return nil, err
+	defaultVersion := meta.DefaultVersion
+	if selector := valueOrEmpty(req.SetDefaultVersion); selector != "" {
+		defaultVersion, err = resolveLaunchTemplateVersionSelector(selector, meta.DefaultVersion, meta.LatestVersion, "SetDefaultVersion")
+		if err != nil {
+			return nil, err
+		}
 	}
 	defaultVersionData, err := d.loadLaunchTemplateVersionData(launchTemplateID, defaultVersion)
 	if err != nil {
 		return nil, err
 	}
+	name := meta.Name
+	if req.NewLaunchTemplateName != nil {
+		name = strings.TrimSpace(*req.NewLaunchTemplateName)
+		if !validLaunchTemplateName(name) {
+			return nil, api.InvalidParameterValueError("NewLaunchTemplateName", *req.NewLaunchTemplateName)
+		}
+		if name != meta.Name {
+			if _, err := d.findLaunchTemplateByName(ctx, name); err == nil {
+				return nil, api.ErrWithCode("AlreadyExists", fmt.Errorf("launch template %q already exists", name))
+			} else if !errors.As(err, &storage.ErrResourceNotFound{}) {
+				return nil, err
+			}
+		}
+	}
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
 
 	if err := d.storage.RemoveResourceAttributes(launchTemplateID, []storage.Attribute{
 		{Key: attributeNameLaunchTemplateImageID},
@@ -463,13 +608,30 @@ func (d *Dispatcher) dispatchModifyLaunchTemplate(ctx context.Context, req *api.
 		return nil, fmt.Errorf("removing legacy launch template attributes: %w", err)
 	}
 	attrs := []storage.Attribute{
+		{Key: attributeNameLaunchTemplateName, Value: name},
 		{Key: attributeNameLaunchTemplateDefaultVersion, Value: strconv.FormatInt(defaultVersion, 10)},
 	}
 	attrs = append(attrs, legacyLaunchTemplateAttributes(*defaultVersionData)...)
+	if req.VersionDescription != nil {
+		// The description applies to the default version, after changing it
+		attrs = append(attrs, storage.Attribute{
+			Key:   launchTemplateVersionDescriptionAttributeName(defaultVersion),
+			Value: *req.VersionDescription,
+		})
+	}
 	if err := d.storage.SetResourceAttributes(launchTemplateID, attrs); err != nil {
 		return nil, fmt.Errorf("updating launch template attributes: %w", err)
 	}
+	if name != meta.Name {
+		api.Logger(ctx).Info(
+			"renamed launch template",
+			slog.String("launch_template_id", launchTemplateID),
+			slog.String("old_name", meta.Name),
+			slog.String("new_name", name),
+		)
+	}
 
+	meta.Name = name
 	meta.DefaultVersion = defaultVersion
 	launchTemplate := apiLaunchTemplate(*meta)
 	return &api.ModifyLaunchTemplateResponse{
@@ -477,7 +639,24 @@ func (d *Dispatcher) dispatchModifyLaunchTemplate(ctx context.Context, req *api.
 	}, nil
 }
 
-func validateLaunchTemplateData(data api.LaunchTemplateData) error {
+// validLaunchTemplateName reports whether name satisfies the length and
+// character constraints EC2 places on launch template names.
+func validLaunchTemplateName(name string) bool {
+	if len(name) < minLaunchTemplateNameLength || len(name) > maxLaunchTemplateNameLength {
+		return false
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case strings.ContainsRune("().-/_", r):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func validateLaunchTemplateData(data api.LaunchTemplateData, region string) error {
 	if err := validateLaunchTemplateTagSpecifications(data.TagSpecifications); err != nil {
 		return err
 	}
@@ -487,6 +666,19 @@ func validateLaunchTemplateData(data api.LaunchTemplateData) error {
 	if data.InstanceType != "" && data.InstanceRequirements != nil {
 		return api.ErrWithCode("InvalidParameterCombination", fmt.Errorf("InstanceType and InstanceRequirements cannot be specified together"))
 	}
+	if data.IamInstanceProfile != nil {
+		if _, err := resolveInstanceProfileArn(*data.IamInstanceProfile); err != nil {
+			return err
+		}
+	}
+	if data.Placement != nil && data.Placement.AvailabilityZone != "" {
+		if err := validateAvailabilityZone(data.Placement.AvailabilityZone, region); err != nil {
+			return err
+		}
+	}
+	if err := validateArchitecture(data.Architecture, "LaunchTemplateData.Architecture"); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -495,9 +687,13 @@ func launchTemplateDataIsEmpty(data api.LaunchTemplateData) bool {
 		data.InstanceRequirements == nil &&
 		data.InstanceType == "" &&
 		data.UserData == "" &&
+		data.KeyName == "" &&
 		len(data.SecurityGroupIDs) == 0 &&
+		data.IamInstanceProfile == nil &&
+		data.Placement == nil &&
 		len(data.BlockDeviceMappings) == 0 &&
-		len(data.TagSpecifications) == 0
+		len(data.TagSpecifications) == 0 &&
+		data.Architecture == ""
 }
 
 func validateLaunchTemplateTagSpecifications(specs []api.TagSpecification) error {
@@ -517,7 +713,7 @@ func validateLaunchTemplateTagSpecifications(specs []api.TagSpecification) error
 
 func (d *Dispatcher) findLaunchTemplate(ctx context.Context, spec *api.AutoScalingLaunchTemplateSpecification) (*launchTemplateData, error) {
 	if spec == nil {
-		return nil, api.ErrWithCode("ValidationError", fmt.Errorf("LaunchTemplate is required"))
+		return nil, api.ValidationError("LaunchTemplate", "LaunchTemplate is required")
 	}
 
 	var (
@@ -575,6 +771,30 @@ func (d *Dispatcher) findLaunchTemplateByName(_ context.Context, launchTemplateN
 	return nil, storage.ErrResourceNotFound{ID: launchTemplateName}
 }
 
+// launchTemplateForClientToken returns the launch template created with
+// clientToken, or nil if there is none.
+//
+//nolint:nilnil
+func (d *Dispatcher) launchTemplateForClientToken(clientToken string) (*launchTemplateMetadata, error) {
+	if clientToken == "" {
+		return nil, nil
+	}
+	templates, err := d.storage.RegisteredResources(types.ResourceTypeLaunchTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving launch templates: %w", err)
+	}
+	for _, r := range templates {
+		attrs, err := d.storage.ResourceAttributes(r.ID)
+		if err != nil {
+			return nil, fmt.Errorf("retrieving launch template attributes: %w", err)
+		}
+		if token, _ := attrs.Key(attributeNameLaunchTemplateClientToken); token == clientToken {
+			return d.loadLaunchTemplateMetadata(r.ID)
+		}
+	}
+	return nil, nil
+}
+
 func (d *Dispatcher) loadLaunchTemplateData(launchTemplateID string, versionSelector string) (*launchTemplateData, error) {
 	meta, err := d.loadLaunchTemplateMetadata(launchTemplateID)
 	if err != nil {
@@ -596,8 +816,12 @@ func (d *Dispatcher) loadLaunchTemplateData(launchTemplateID string, versionSele
 		InstanceRequirements: versionData.InstanceRequirements,
 		InstanceType:         versionData.InstanceType,
 		UserData:             versionData.UserData,
+		KeyName:              versionData.KeyName,
 		SecurityGroupIDs:     cloneStringSlice(versionData.SecurityGroupIDs),
+		IamInstanceProfile:   cloneIamInstanceProfileSpecification(versionData.IamInstanceProfile),
+		Placement:            cloneLaunchTemplatePlacement(versionData.Placement),
 		BlockDeviceMappings:  cloneBlockDeviceMappings(versionData.BlockDeviceMappings),
+		Architecture:         versionData.Architecture,
 	}, nil
 }
 
@@ -660,8 +884,12 @@ func (d *Dispatcher) loadLaunchTemplateVersionData(launchTemplateID string, vers
 	instanceRequirementsRaw, _ := attrs.Key(launchTemplateVersionInstanceRequirementsAttributeName(version))
 	instanceType, _ := attrs.Key(launchTemplateVersionInstanceTypeAttributeName(version))
 	userData, _ := attrs.Key(launchTemplateVersionUserDataAttributeName(version))
+	keyName, _ := attrs.Key(launchTemplateVersionKeyNameAttributeName(version))
 	securityGroupIDsRaw, _ := attrs.Key(launchTemplateVersionSecurityGroupIDsAttributeName(version))
+	iamInstanceProfileRaw, _ := attrs.Key(launchTemplateVersionIamInstanceProfileAttributeName(version))
+	placementRaw, _ := attrs.Key(launchTemplateVersionPlacementAttributeName(version))
 	blockDeviceMappingsRaw, _ := attrs.Key(launchTemplateVersionBlockDeviceMappingsAttributeName(version))
+	architecture, _ := attrs.Key(launchTemplateVersionArchitectureAttributeName(version))
 	if version == 1 {
 		if imageID == "" {
 			imageID, _ = attrs.Key(attributeNameLaunchTemplateImageID)
@@ -690,6 +918,20 @@ func (d *Dispatcher) loadLaunchTemplateVersionData(launchTemplateID string, vers
 	if err != nil {
 		return nil, fmt.Errorf("invalid launch template version instance requirements: %w", err)
 	}
+	var iamInstanceProfile *api.IamInstanceProfileSpecification
+	if iamInstanceProfileRaw != "" {
+		iamInstanceProfile = &api.IamInstanceProfileSpecification{}
+		if err := json.Unmarshal([]byte(iamInstanceProfileRaw), iamInstanceProfile); err != nil {
+			return nil, fmt.Errorf("invalid launch template version IAM instance profile: %w", err)
+		}
+	}
+	var placement *api.LaunchTemplatePlacement
+	if placementRaw != "" {
+		placement = &api.LaunchTemplatePlacement{}
+		if err := json.Unmarshal([]byte(placementRaw), placement); err != nil {
+			return nil, fmt.Errorf("invalid launch template version placement: %w", err)
+		}
+	}
 	blockDeviceMappings, err := unmarshalBlockDeviceMappings(blockDeviceMappingsRaw)
 	if err != nil {
 		return nil, fmt.Errorf("invalid launch template version block device mappings: %w", err)
@@ -715,8 +957,12 @@ func (d *Dispatcher) loadLaunchTemplateVersionData(launchTemplateID string, vers
 		InstanceRequirements: instanceRequirements,
 		InstanceType:         instanceType,
 		UserData:             userData,
+		KeyName:              keyName,
 		SecurityGroupIDs:     securityGroupIDs,
+		IamInstanceProfile:   iamInstanceProfile,
+		Placement:            placement,
 		BlockDeviceMappings:  blockDeviceMappings,
+		Architecture:         architecture,
 		VersionDescription:   versionDescriptionPtr,
 		CreateTime:           createTime,
 	}, nil
@@ -801,6 +1047,18 @@ func launchTemplateVersionAttributes(data launchTemplateVersionData) []storage.A
 			Value: data.UserData,
 		})
 	}
+	if data.KeyName != "" {
+		attrs = append(attrs, storage.Attribute{
+			Key:   launchTemplateVersionKeyNameAttributeName(data.Version),
+			Value: data.KeyName,
+		})
+	}
+	if data.Architecture != "" {
+		attrs = append(attrs, storage.Attribute{
+			Key:   launchTemplateVersionArchitectureAttributeName(data.Version),
+			Value: data.Architecture,
+		})
+	}
 	if len(data.SecurityGroupIDs) > 0 {
 		if raw, err := marshalStringSlice(data.SecurityGroupIDs); err == nil && raw != "" {
 			attrs = append(attrs, storage.Attribute{
@@ -809,6 +1067,22 @@ func launchTemplateVersionAttributes(data launchTemplateVersionData) []storage.A
 			})
 		}
 	}
+	if data.IamInstanceProfile != nil {
+		if raw, err := json.Marshal(data.IamInstanceProfile); err == nil {
+			attrs = append(attrs, storage.Attribute{
+				Key:   launchTemplateVersionIamInstanceProfileAttributeName(data.Version),
+				Value: string(raw),
+			})
+		}
+	}
+	if data.Placement != nil {
+		if raw, err := json.Marshal(data.Placement); err == nil {
+			attrs = append(attrs, storage.Attribute{
+				Key:   launchTemplateVersionPlacementAttributeName(data.Version),
+				Value: string(raw),
+			})
+		}
+	}
 	if len(data.BlockDeviceMappings) > 0 {
 		if raw, err := marshalBlockDeviceMappings(data.BlockDeviceMappings); err == nil && raw != "" {
 			attrs = append(attrs, storage.Attribute{
@@ -877,10 +1151,26 @@ func launchTemplateVersionUserDataAttributeName(version int64) string {
 	return fmt.Sprintf("LaunchTemplateVersion.%d.UserData", version)
 }
 
+func launchTemplateVersionKeyNameAttributeName(version int64) string {
+	return fmt.Sprintf("LaunchTemplateVersion.%d.KeyName", version)
+}
+
+func launchTemplateVersionArchitectureAttributeName(version int64) string {
+	return fmt.Sprintf("LaunchTemplateVersion.%d.Architecture", version)
+}
+
 func launchTemplateVersionSecurityGroupIDsAttributeName(version int64) string {
 	return fmt.Sprintf("LaunchTemplateVersion.%d.SecurityGroupIDs", version)
 }
 
+func launchTemplateVersionIamInstanceProfileAttributeName(version int64) string {
+	return fmt.Sprintf("LaunchTemplateVersion.%d.IamInstanceProfile", version)
+}
+
+func launchTemplateVersionPlacementAttributeName(version int64) string {
+	return fmt.Sprintf("LaunchTemplateVersion.%d.Placement", version)
+}
+
 func launchTemplateVersionBlockDeviceMappingsAttributeName(version int64) string {
 	return fmt.Sprintf("LaunchTemplateVersion.%d.BlockDeviceMappings", version)
 }
@@ -925,18 +1215,46 @@ func (d *Dispatcher) apiLaunchTemplateVersion(meta launchTemplateMetadata, data
 	if data.UserData != "" {
 		userData = new(data.UserData)
 	}
+	var keyName *string
+	if data.KeyName != "" {
+		keyName = new(data.KeyName)
+	}
 	securityGroupIDs := cloneStringSlice(data.SecurityGroupIDs)
+	var iamInstanceProfile *api.ResponseLaunchTemplateIamInstanceProfile
+	if data.IamInstanceProfile != nil {
+		iamInstanceProfile = &api.ResponseLaunchTemplateIamInstanceProfile{
+			Arn:  optionalString(data.IamInstanceProfile.Arn),
+			Name: optionalString(data.IamInstanceProfile.Name),
+		}
+	}
+	var placement *api.ResponseLaunchTemplatePlacement
+	if data.Placement != nil {
+		placement = &api.ResponseLaunchTemplatePlacement{
+			AvailabilityZone: optionalString(data.Placement.AvailabilityZone),
+			GroupName:        optionalString(data.Placement.GroupName),
+		}
+	}
 	responseBlockDeviceMappings := apiLaunchTemplateBlockDeviceMappings(data.BlockDeviceMappings)
+	var architecture *string
+	if data.Architecture != "" {
+		architecture = new(data.Architecture)
+	}
 
 	var launchTemplateData *api.ResponseLaunchTemplateData
-	if imageID != nil || instanceRequirements != nil || instanceType != nil || userData != nil || len(securityGroupIDs) > 0 || len(responseBlockDeviceMappings) > 0 {
+	if imageID != nil || instanceRequirements != nil || instanceType != nil || userData != nil || keyName != nil ||
+		len(securityGroupIDs) > 0 || iamInstanceProfile != nil || placement != nil || len(responseBlockDeviceMappings) > 0 ||
+		architecture != nil {
 		launchTemplateData = &api.ResponseLaunchTemplateData{
 			ImageID:              imageID,
 			InstanceRequirements: instanceRequirements,
 			InstanceType:         instanceType,
 			UserData:             userData,
+			KeyName:              keyName,
 			SecurityGroupIDs:     securityGroupIDs,
+			IamInstanceProfile:   iamInstanceProfile,
+			Placement:            placement,
 			BlockDeviceMappings:  responseBlockDeviceMappings,
+			Architecture:         architecture,
 		}
 	}
 
@@ -1000,6 +1318,22 @@ func cloneStringSlice(values []string) []string {
 	return cloned
 }
 
+func cloneIamInstanceProfileSpecification(spec *api.IamInstanceProfileSpecification) *api.IamInstanceProfileSpecification {
+	if spec == nil {
+		return nil
+	}
+	cloned := *spec
+	return &cloned
+}
+
+func cloneLaunchTemplatePlacement(placement *api.LaunchTemplatePlacement) *api.LaunchTemplatePlacement {
+	if placement == nil {
+		return nil
+	}
+	cloned := *placement
+	return &cloned
+}
+
 func marshalStringSlice(values []string) (string, error) {
 	if len(values) == 0 {
 		return "", nil
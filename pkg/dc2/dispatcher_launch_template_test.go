@@ -0,0 +1,246 @@
+package dc2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+)
+
+func TestCreateLaunchTemplateVersionInheritsBlockDeviceMappings(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	d := &Dispatcher{
+		exe:     &exitCleanupExecutor{},
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+
+	createResp, err := d.dispatchCreateLaunchTemplate(ctx, &api.CreateLaunchTemplateRequest{
+		LaunchTemplateName: "lt",
+		LaunchTemplateData: api.LaunchTemplateData{
+			ImageID:      "nginx",
+			InstanceType: "t3.micro",
+			BlockDeviceMappings: []api.RunInstancesBlockDeviceMapping{
+				{
+					DeviceName: "/dev/sdf",
+					EBS: &api.RunInstancesEBSBlockDevice{
+						DeleteOnTermination: true,
+						VolumeSize:          new(20),
+						VolumeType:          "gp3",
+					},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	launchTemplateID := createResp.LaunchTemplate.LaunchTemplateID
+
+	_, err = d.dispatchCreateLaunchTemplateVersion(ctx, &api.CreateLaunchTemplateVersionRequest{
+		LaunchTemplateID:   launchTemplateID,
+		SourceVersion:      new("$Latest"),
+		LaunchTemplateData: api.LaunchTemplateData{ImageID: "httpd"},
+	})
+	require.NoError(t, err)
+
+	versionsResp, err := d.dispatchDescribeLaunchTemplateVersions(ctx, &api.DescribeLaunchTemplateVersionsRequest{
+		LaunchTemplateID: launchTemplateID,
+		Versions:         []string{"2"},
+	})
+	require.NoError(t, err)
+	require.Len(t, versionsResp.LaunchTemplateVersions, 1)
+	data := versionsResp.LaunchTemplateVersions[0].LaunchTemplateData
+	require.NotNil(t, data)
+	assert.Equal(t, "httpd", *data.ImageID)
+	assert.Equal(t, "t3.micro", *data.InstanceType)
+	require.Len(t, data.BlockDeviceMappings, 1)
+	mapping := data.BlockDeviceMappings[0]
+	assert.Equal(t, "/dev/sdf", *mapping.DeviceName)
+	require.NotNil(t, mapping.EBS)
+	assert.True(t, *mapping.EBS.DeleteOnTermination)
+	assert.Equal(t, 20, *mapping.EBS.VolumeSize)
+	assert.Equal(t, "gp3", *mapping.EBS.VolumeType)
+}
+
+func TestModifyLaunchTemplateRenameAndDescription(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	d := &Dispatcher{
+		exe:     &exitCleanupExecutor{},
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+	createLaunchTemplate := func(name string) string {
+		t.Helper()
+		resp, err := d.dispatchCreateLaunchTemplate(ctx, &api.CreateLaunchTemplateRequest{
+			LaunchTemplateName: name,
+			LaunchTemplateData: api.LaunchTemplateData{ImageID: "nginx", InstanceType: "t3.micro"},
+		})
+		require.NoError(t, err)
+		return *resp.LaunchTemplate.LaunchTemplateID
+	}
+	launchTemplateID := createLaunchTemplate("lt-a")
+	createLaunchTemplate("lt-b")
+
+	var apiErr *api.Error
+	_, err := d.dispatchModifyLaunchTemplate(ctx, &api.ModifyLaunchTemplateRequest{
+		LaunchTemplateID:      &launchTemplateID,
+		NewLaunchTemplateName: new("lt-b"),
+	})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "AlreadyExists", apiErr.Code)
+	_, err = d.dispatchModifyLaunchTemplate(ctx, &api.ModifyLaunchTemplateRequest{
+		LaunchTemplateID:      &launchTemplateID,
+		NewLaunchTemplateName: new("not a valid name"),
+	})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, api.ErrorCodeInvalidParameterValue, apiErr.Code)
+
+	modifyResp, err := d.dispatchModifyLaunchTemplate(ctx, &api.ModifyLaunchTemplateRequest{
+		LaunchTemplateName:    new("lt-a"),
+		NewLaunchTemplateName: new("lt-renamed"),
+		VersionDescription:    new("web servers"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "lt-renamed", *modifyResp.LaunchTemplate.LaunchTemplateName)
+	assert.Equal(t, int64(1), *modifyResp.LaunchTemplate.DefaultVersionNumber)
+
+	describeResp, err := d.dispatchDescribeLaunchTemplates(ctx, &api.DescribeLaunchTemplatesRequest{
+		LaunchTemplateNames: []string{"lt-renamed"},
+	})
+	require.NoError(t, err)
+	require.Len(t, describeResp.LaunchTemplates, 1)
+	assert.Equal(t, launchTemplateID, *describeResp.LaunchTemplates[0].LaunchTemplateID)
+	_, err = d.dispatchModifyLaunchTemplate(ctx, &api.ModifyLaunchTemplateRequest{
+		LaunchTemplateName: new("lt-a"),
+		VersionDescription: new("gone"),
+	})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "ValidationError", apiErr.Code)
+
+	versionsResp, err := d.dispatchDescribeLaunchTemplateVersions(ctx, &api.DescribeLaunchTemplateVersionsRequest{
+		LaunchTemplateID: &launchTemplateID,
+		Versions:         []string{"$Default"},
+	})
+	require.NoError(t, err)
+	require.Len(t, versionsResp.LaunchTemplateVersions, 1)
+	assert.Equal(t, "lt-renamed", *versionsResp.LaunchTemplateVersions[0].LaunchTemplateName)
+	assert.Equal(t, "web servers", *versionsResp.LaunchTemplateVersions[0].VersionDescription)
+
+	// The old name is free again
+	createLaunchTemplate("lt-a")
+}
+
+func TestCreateLaunchTemplateClientToken(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	d := &Dispatcher{
+		exe:     &exitCleanupExecutor{},
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+	req := &api.CreateLaunchTemplateRequest{
+		CommonRequest:      api.CommonRequest{ClientToken: "token-1"},
+		LaunchTemplateName: "lt",
+		LaunchTemplateData: api.LaunchTemplateData{ImageID: "nginx", InstanceType: "t3.micro"},
+	}
+	first, err := d.dispatchCreateLaunchTemplate(ctx, req)
+	require.NoError(t, err)
+	second, err := d.dispatchCreateLaunchTemplate(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, first.LaunchTemplate.LaunchTemplateID, second.LaunchTemplate.LaunchTemplateID)
+
+	describeResp, err := d.dispatchDescribeLaunchTemplates(ctx, &api.DescribeLaunchTemplatesRequest{})
+	require.NoError(t, err)
+	assert.Len(t, describeResp.LaunchTemplates, 1)
+
+	var apiErr *api.Error
+	_, err = d.dispatchCreateLaunchTemplate(ctx, &api.CreateLaunchTemplateRequest{
+		CommonRequest:      api.CommonRequest{ClientToken: "token-1"},
+		LaunchTemplateName: "other",
+		LaunchTemplateData: api.LaunchTemplateData{ImageID: "nginx"},
+	})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "IdempotentParameterMismatch", apiErr.Code)
+
+	req.ClientToken = "token-2"
+	_, err = d.dispatchCreateLaunchTemplate(ctx, req)
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "AlreadyExists", apiErr.Code)
+}
+
+func TestDescribeLaunchTemplateVersionsFilters(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	d := &Dispatcher{
+		exe:     &exitCleanupExecutor{},
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+	createResp, err := d.dispatchCreateLaunchTemplate(ctx, &api.CreateLaunchTemplateRequest{
+		LaunchTemplateName: "lt-filters",
+		LaunchTemplateData: api.LaunchTemplateData{ImageID: "nginx", InstanceType: "t3.micro"},
+	})
+	require.NoError(t, err)
+	launchTemplateID := createResp.LaunchTemplate.LaunchTemplateID
+	for _, instanceType := range []string{"t3.small", "t3.large"} {
+		_, err := d.dispatchCreateLaunchTemplateVersion(ctx, &api.CreateLaunchTemplateVersionRequest{
+			LaunchTemplateID:   launchTemplateID,
+			LaunchTemplateData: api.LaunchTemplateData{ImageID: "nginx", InstanceType: instanceType},
+		})
+		require.NoError(t, err)
+	}
+	_, err = d.dispatchModifyLaunchTemplate(ctx, &api.ModifyLaunchTemplateRequest{
+		LaunchTemplateID:  launchTemplateID,
+		SetDefaultVersion: new("2"),
+	})
+	require.NoError(t, err)
+
+	describeVersions := func(filters ...api.Filter) []int64 {
+		t.Helper()
+		resp, err := d.dispatchDescribeLaunchTemplateVersions(ctx, &api.DescribeLaunchTemplateVersionsRequest{
+			LaunchTemplateID: launchTemplateID,
+			Filters:          filters,
+		})
+		require.NoError(t, err)
+		versions := make([]int64, 0, len(resp.LaunchTemplateVersions))
+		for _, version := range resp.LaunchTemplateVersions {
+			versions = append(versions, *version.VersionNumber)
+		}
+		return versions
+	}
+	assert.Equal(t, []int64{2}, describeVersions(api.Filter{Name: new("is-default-version"), Values: []string{"true"}}))
+	assert.Equal(t, []int64{1, 3}, describeVersions(api.Filter{Name: new("is-default-version"), Values: []string{"false"}}))
+	assert.Equal(t, []int64{1, 3}, describeVersions(api.Filter{Name: new("instance-type"), Values: []string{"t3.micro", "t3.large"}}))
+	assert.Equal(t, []int64{1, 2, 3}, describeVersions(api.Filter{Name: new("image-id"), Values: []string{"nginx"}}))
+	assert.Empty(t, describeVersions(
+		api.Filter{Name: new("image-id"), Values: []string{"nginx"}},
+		api.Filter{Name: new("instance-type"), Values: []string{"t3.small"}},
+		api.Filter{Name: new("is-default-version"), Values: []string{"false"}},
+	))
+
+	resp, err := d.dispatchDescribeLaunchTemplateVersions(ctx, &api.DescribeLaunchTemplateVersionsRequest{
+		LaunchTemplateID: launchTemplateID,
+		Versions:         []string{"1"},
+	})
+	require.NoError(t, err)
+	createTime := resp.LaunchTemplateVersions[0].CreateTime.UTC()
+	assert.Contains(t, describeVersions(api.Filter{Name: new("create-time"), Values: []string{createTime.Truncate(time.Second).Format(time.RFC3339)}}), int64(1))
+	assert.Empty(t, describeVersions(api.Filter{Name: new("create-time"), Values: []string{createTime.Add(-time.Hour).Format(time.RFC3339)}}))
+
+	var apiErr *api.Error
+	_, err = d.dispatchDescribeLaunchTemplateVersions(ctx, &api.DescribeLaunchTemplateVersionsRequest{
+		LaunchTemplateID: launchTemplateID,
+		Filters:          []api.Filter{{Name: new("create-time"), Values: []string{"yesterday"}}},
+	})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, api.ErrorCodeInvalidParameterValue, apiErr.Code)
+}
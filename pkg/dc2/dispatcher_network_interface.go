@@ -0,0 +1,468 @@
+package dc2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/executor"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+const (
+	networkInterfaceIDPrefix           = "eni-"
+	networkInterfaceAttachmentIDPrefix = "eni-attach-"
+	networkInterfaceTypeInterface      = "interface"
+
+	// defaultIPv4AddressesPerInterface is the private IP limit per interface
+	// for instance types missing from the catalog.
+	defaultIPv4AddressesPerInterface = 10
+
+	attributeNameNetworkInterfaceInstanceID          = "NetworkInterfaceInstanceID"
+	attributeNameNetworkInterfaceSecondaryPrivateIPs = "NetworkInterfaceSecondaryPrivateIPs"
+)
+
+type networkInterfaceData struct {
+	ID                  string
+	InstanceID          string
+	SecondaryPrivateIPs []string
+}
+
+func (d *Dispatcher) dispatchDescribeNetworkInterfaces(ctx context.Context, req *api.DescribeNetworkInterfacesRequest) (*api.DescribeNetworkInterfacesResponse, error) {
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
+	networkInterfaces, err := d.listNetworkInterfaces()
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range req.NetworkInterfaceIDs {
+		if !slices.ContainsFunc(networkInterfaces, func(ni networkInterfaceData) bool { return ni.ID == id }) {
+			return nil, networkInterfaceNotFoundError(id)
+		}
+	}
+
+	instanceIDs := make([]string, 0, len(networkInterfaces))
+	for _, ni := range networkInterfaces {
+		if len(req.NetworkInterfaceIDs) > 0 && !slices.Contains(req.NetworkInterfaceIDs, ni.ID) {
+			continue
+		}
+		instanceIDs = append(instanceIDs, ni.InstanceID)
+	}
+	descs, err := d.exe.DescribeInstances(ctx, executor.DescribeInstancesRequest{
+		InstanceIDs: executorInstanceIDs(instanceIDs),
+	})
+	if err != nil {
+		return nil, executorError(err)
+	}
+	out := make([]api.NetworkInterface, 0, len(descs))
+	for _, desc := range descs {
+		instance, err := d.apiInstance(&desc)
+		if err != nil {
+			return nil, err
+		}
+		networkInterface := apiNetworkInterface(instance)
+		if networkInterface.AvailabilityZone == "" {
+			networkInterface.AvailabilityZone = defaultAvailabilityZone(d.opts.Region)
+		}
+		matches, err := networkInterfaceMatchesFilters(networkInterface, req.Filters)
+		if err != nil {
+			return nil, err
+		}
+		if matches {
+			out = append(out, networkInterface)
+		}
+	}
+	slices.SortFunc(out, func(a, b api.NetworkInterface) int {
+		return strings.Compare(a.NetworkInterfaceID, b.NetworkInterfaceID)
+	})
+	paged, nextToken, err := applyNextToken(out, req.NextToken, req.MaxResults)
+	if err != nil {
+		return nil, api.InvalidParameterValueError("NextToken", stringValue(req.NextToken))
+	}
+	return &api.DescribeNetworkInterfacesResponse{NetworkInterfaces: paged, NextToken: nextToken}, nil
+}
+
+func (d *Dispatcher) dispatchAssignPrivateIPAddresses(ctx context.Context, req *api.AssignPrivateIPAddressesRequest) (*api.AssignPrivateIPAddressesResponse, error) {
+	count := 0
+	if req.SecondaryPrivateIPAddressCount != nil {
+		count = *req.SecondaryPrivateIPAddressCount
+	}
+	if count < 0 {
+		return nil, api.InvalidParameterValueError("SecondaryPrivateIpAddressCount", strconv.Itoa(count))
+	}
+	if len(req.PrivateIPAddresses) > 0 && count > 0 {
+		return nil, api.ErrWithCode(
+			"InvalidParameterCombination",
+			fmt.Errorf("PrivateIpAddress and SecondaryPrivateIpAddressCount cannot be used together"),
+		)
+	}
+	if len(req.PrivateIPAddresses) == 0 && count == 0 {
+		return nil, api.ErrWithCode(
+			"MissingParameter",
+			fmt.Errorf("Either PrivateIpAddress or SecondaryPrivateIpAddressCount must be specified"), //nolint
+		)
+	}
+	networkInterfaces, err := d.listNetworkInterfaces()
+	if err != nil {
+		return nil, err
+	}
+	idx := slices.IndexFunc(networkInterfaces, func(ni networkInterfaceData) bool { return ni.ID == req.NetworkInterfaceID })
+	if idx < 0 {
+		return nil, networkInterfaceNotFoundError(req.NetworkInterfaceID)
+	}
+	networkInterface := networkInterfaces[idx]
+	descs, err := d.exe.DescribeInstances(ctx, executor.DescribeInstancesRequest{
+		InstanceIDs: []executor.InstanceID{executorInstanceID(networkInterface.InstanceID)},
+	})
+	if err != nil {
+		return nil, executorError(err)
+	}
+	if len(descs) == 0 {
+		return nil, networkInterfaceNotFoundError(req.NetworkInterfaceID)
+	}
+	subnet, err := d.networkInterfaceSubnet(networkInterface.InstanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Addresses in use by any interface, mapped to its ID
+	inUse := map[string]string{descs[0].PrivateIP: networkInterface.ID}
+	for _, ni := range networkInterfaces {
+		for _, ip := range ni.SecondaryPrivateIPs {
+			inUse[ip] = ni.ID
+		}
+	}
+	var assigned []string
+	for _, rawIP := range req.PrivateIPAddresses {
+		addr, err := netip.ParseAddr(strings.TrimSpace(rawIP))
+		if err != nil || !addr.Is4() || !subnet.CIDRBlock.Contains(addr) || slices.Contains(assigned, addr.String()) {
+			return nil, api.InvalidParameterValueError("PrivateIpAddress", rawIP)
+		}
+		ip := addr.String()
+		if owner, ok := inUse[ip]; ok && (owner == networkInterface.ID || !req.AllowReassignment) {
+			return nil, api.ErrWithCode("PrivateIpAddressInUse", fmt.Errorf("Address %s is in use.", ip)) //nolint
+		}
+		assigned = append(assigned, ip)
+	}
+	if count > 0 {
+		assigned, err = allocateSecondaryPrivateIPs(subnet.CIDRBlock, inUse, count)
+		if err != nil {
+			return nil, err
+		}
+	}
+	limit := d.ipv4AddressesPerInterface(descs[0].InstanceType)
+	if 1+len(networkInterface.SecondaryPrivateIPs)+len(assigned) > limit {
+		return nil, api.ErrWithCode(
+			"PrivateIpAddressLimitExceeded",
+			fmt.Errorf("Number of private addresses will exceed limit of %d for interface %s.", limit, networkInterface.ID), //nolint
+		)
+	}
+
+	for _, ni := range networkInterfaces {
+		if ni.ID == networkInterface.ID {
+			continue
+		}
+		remaining := slices.DeleteFunc(slices.Clone(ni.SecondaryPrivateIPs), func(ip string) bool { return slices.Contains(assigned, ip) })
+		if len(remaining) != len(ni.SecondaryPrivateIPs) {
+			if err := d.saveSecondaryPrivateIPs(ni.ID, remaining); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := d.saveSecondaryPrivateIPs(networkInterface.ID, append(networkInterface.SecondaryPrivateIPs, assigned...)); err != nil {
+		return nil, err
+	}
+	api.Logger(ctx).Info(
+		"assigned private IP addresses",
+		slog.String("network_interface_id", networkInterface.ID),
+		slog.Any("private_ip_addresses", assigned),
+	)
+	resp := &api.AssignPrivateIPAddressesResponse{NetworkInterfaceID: networkInterface.ID, Return: true}
+	for _, ip := range assigned {
+		resp.AssignedPrivateIPAddresses = append(resp.AssignedPrivateIPAddresses, api.AssignedPrivateIPAddress{PrivateIPAddress: ip})
+	}
+	return resp, nil
+}
+
+func (d *Dispatcher) dispatchUnassignPrivateIPAddresses(ctx context.Context, req *api.UnassignPrivateIPAddressesRequest) (*api.UnassignPrivateIPAddressesResponse, error) {
+	if len(req.PrivateIPAddresses) == 0 {
+		return nil, api.ErrWithCode("MissingParameter", fmt.Errorf("The request must contain the parameter PrivateIpAddress")) //nolint
+	}
+	networkInterfaces, err := d.listNetworkInterfaces()
+	if err != nil {
+		return nil, err
+	}
+	idx := slices.IndexFunc(networkInterfaces, func(ni networkInterfaceData) bool { return ni.ID == req.NetworkInterfaceID })
+	if idx < 0 {
+		return nil, networkInterfaceNotFoundError(req.NetworkInterfaceID)
+	}
+	networkInterface := networkInterfaces[idx]
+	for _, ip := range req.PrivateIPAddresses {
+		if !slices.Contains(networkInterface.SecondaryPrivateIPs, ip) {
+			return nil, api.ErrWithCode(
+				api.ErrorCodeInvalidParameterValue,
+				fmt.Errorf("Some of the specified addresses are not assigned to interface %s", networkInterface.ID), //nolint
+			)
+		}
+	}
+	remaining := slices.DeleteFunc(slices.Clone(networkInterface.SecondaryPrivateIPs), func(ip string) bool {
+		return slices.Contains(req.PrivateIPAddresses, ip)
+	})
+	if err := d.saveSecondaryPrivateIPs(networkInterface.ID, remaining); err != nil {
+		return nil, err
+	}
+	api.Logger(ctx).Info(
+		"unassigned private IP addresses",
+		slog.String("network_interface_id", networkInterface.ID),
+		slog.Any("private_ip_addresses", req.PrivateIPAddresses),
+	)
+	return &api.UnassignPrivateIPAddressesResponse{Return: true}, nil
+}
+
+// registerInstanceNetworkInterface records the primary network interface of
+// a newly launched instance.
+func (d *Dispatcher) registerInstanceNetworkInterface(instanceID string) error {
+	id := instanceNetworkInterfaceID(instanceID)
+	if err := d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeNetworkInterface, ID: id}); err != nil {
+		return fmt.Errorf("registering network interface %s: %w", id, err)
+	}
+	if err := d.storage.SetResourceAttributes(id, []storage.Attribute{
+		{Key: attributeNameNetworkInterfaceInstanceID, Value: instanceID},
+	}); err != nil {
+		return fmt.Errorf("saving network interface attributes: %w", err)
+	}
+	return nil
+}
+
+// listNetworkInterfaces returns the registered network interfaces. Primary
+// interfaces are deleted on termination, so the records of interfaces whose
+// instance is gone are removed.
+func (d *Dispatcher) listNetworkInterfaces() ([]networkInterfaceData, error) {
+	resources, err := d.storage.RegisteredResources(types.ResourceTypeNetworkInterface)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving network interfaces: %w", err)
+	}
+	networkInterfaces := make([]networkInterfaceData, 0, len(resources))
+	for _, r := range resources {
+		attrs, err := d.storage.ResourceAttributes(r.ID)
+		if err != nil {
+			return nil, fmt.Errorf("retrieving network interface attributes: %w", err)
+		}
+		instanceID, _ := attrs.Key(attributeNameNetworkInterfaceInstanceID)
+		rawSecondaryPrivateIPs, _ := attrs.Key(attributeNameNetworkInterfaceSecondaryPrivateIPs)
+		secondaryPrivateIPs, err := unmarshalStringSlice(rawSecondaryPrivateIPs)
+		if err != nil {
+			return nil, err
+		}
+		running, err := d.instanceNotTerminated(instanceID)
+		if err != nil {
+			return nil, err
+		}
+		if !running {
+			if err := d.storage.RemoveResource(r.ID); err != nil && !errors.As(err, &storage.ErrResourceNotFound{}) {
+				return nil, fmt.Errorf("removing network interface %s: %w", r.ID, err)
+			}
+			continue
+		}
+		networkInterfaces = append(networkInterfaces, networkInterfaceData{
+			ID:                  r.ID,
+			InstanceID:          instanceID,
+			SecondaryPrivateIPs: secondaryPrivateIPs,
+		})
+	}
+	return networkInterfaces, nil
+}
+
+func (d *Dispatcher) saveSecondaryPrivateIPs(networkInterfaceID string, ips []string) error {
+	if len(ips) == 0 {
+		if err := d.storage.RemoveResourceAttributes(networkInterfaceID, []storage.Attribute{
+			{Key: attributeNameNetworkInterfaceSecondaryPrivateIPs},
+		}); err != nil {
+			return fmt.Errorf("removing secondary private IPs: %w", err)
+		}
+		return nil
+	}
+	raw, err := marshalStringSlice(ips)
+	if err != nil {
+		return err
+	}
+	if err := d.storage.SetResourceAttributes(networkInterfaceID, []storage.Attribute{
+		{Key: attributeNameNetworkInterfaceSecondaryPrivateIPs, Value: raw},
+	}); err != nil {
+		return fmt.Errorf("saving secondary private IPs: %w", err)
+	}
+	return nil
+}
+
+// instanceSecondaryPrivateIPs returns the secondary private IPs assigned to
+// the primary network interface of an instance.
+func (d *Dispatcher) instanceSecondaryPrivateIPs(instanceID string) ([]string, error) {
+	attrs, err := d.storage.ResourceAttributes(instanceNetworkInterfaceID(instanceID))
+	if err != nil {
+		if errors.As(err, &storage.ErrResourceNotFound{}) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("retrieving network interface attributes: %w", err)
+	}
+	raw, _ := attrs.Key(attributeNameNetworkInterfaceSecondaryPrivateIPs)
+	return unmarshalStringSlice(raw)
+}
+
+// networkInterfaceSubnet returns the subnet secondary IPs of an instance
+// interface are assigned from. Instances in subnets that were never created
+// use the default one.
+func (d *Dispatcher) networkInterfaceSubnet(instanceID string) (*subnetData, error) {
+	attrs, err := d.storage.ResourceAttributes(instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving instance attributes: %w", err)
+	}
+	subnetID, _ := attrs.Key(attributeNameSubnetID)
+	if subnetID != "" {
+		subnet, err := d.findSubnet(subnetID)
+		if err != nil || subnet != nil {
+			return subnet, err
+		}
+	}
+	return d.findSubnet(defaultSubnetID)
+}
+
+// ipv4AddressesPerInterface returns how many private IPs, including the
+// primary one, an interface of the given instance type can have.
+func (d *Dispatcher) ipv4AddressesPerInterface(instanceType string) int {
+	if d.instanceTypeCatalog != nil {
+		if data, ok := d.instanceTypeCatalog.InstanceTypes[instanceType]; ok {
+			if limit, ok := int64At(data, "NetworkInfo", "Ipv4AddressesPerInterface"); ok {
+				return int(limit)
+			}
+		}
+	}
+	return defaultIPv4AddressesPerInterface
+}
+
+// allocateSecondaryPrivateIPs picks count free addresses from a subnet,
+// skipping the first four and the last one, which AWS reserves.
+func allocateSecondaryPrivateIPs(cidrBlock netip.Prefix, inUse map[string]string, count int) ([]string, error) {
+	var ips []string
+	addr := cidrBlock.Addr()
+	for range 4 {
+		addr = addr.Next()
+	}
+	for ; cidrBlock.Contains(addr.Next()) && len(ips) < count; addr = addr.Next() {
+		if _, ok := inUse[addr.String()]; !ok {
+			ips = append(ips, addr.String())
+		}
+	}
+	if len(ips) < count {
+		return nil, api.ErrWithCode(
+			"InsufficientFreeAddressesInSubnet",
+			fmt.Errorf("The specified subnet does not have enough free addresses to satisfy the request."), //nolint
+		)
+	}
+	return ips, nil
+}
+
+// instanceNetworkInterfaceID returns the ID of the primary network interface
+// of an instance, which is derived from the instance ID.
+func instanceNetworkInterfaceID(instanceID string) string {
+	return networkInterfaceIDPrefix + instanceNetworkInterfaceSuffix(instanceID)
+}
+
+func instanceNetworkInterfaceSuffix(instanceID string) string {
+	suffix := strings.TrimPrefix(instanceID, instanceIDPrefix)
+	if len(suffix) > 17 {
+		suffix = suffix[:17]
+	}
+	return suffix
+}
+
+func apiNetworkInterface(instance api.Instance) api.NetworkInterface {
+	primary := instance.NetworkInterfaces[0]
+	return api.NetworkInterface{
+		NetworkInterfaceID: primary.NetworkInterfaceID,
+		SubnetID:           instance.SubnetID,
+		VPCID:              instance.VPCID,
+		AvailabilityZone:   instance.Placement.AvailabilityZone,
+		OwnerID:            defaultSecurityGroupOwnerID,
+		Status:             primary.Status,
+		MacAddress:         primary.MacAddress,
+		PrivateIPAddress:   primary.PrivateIPAddress,
+		PrivateDNSName:     primary.PrivateDNSName,
+		SourceDestCheck:    primary.SourceDestCheck,
+		InterfaceType:      networkInterfaceTypeInterface,
+		Groups:             primary.Groups,
+		Attachment: &api.NetworkInterfaceAttachment{
+			AttachmentID:        primary.Attachment.AttachmentID,
+			InstanceID:          instance.InstanceID,
+			InstanceOwnerID:     defaultSecurityGroupOwnerID,
+			DeviceIndex:         primary.Attachment.DeviceIndex,
+			Status:              primary.Attachment.Status,
+			AttachTime:          instance.LaunchTime,
+			DeleteOnTermination: primary.Attachment.DeleteOnTermination,
+		},
+		Association:        primary.Association,
+		PrivateIPAddresses: primary.PrivateIPAddresses,
+	}
+}
+
+func networkInterfaceMatchesFilters(networkInterface api.NetworkInterface, filters []api.Filter) (bool, error) {
+	for _, filter := range filters {
+		if filter.Name == nil {
+			return false, api.InvalidParameterValueError("Filter.Name", "<missing>")
+		}
+		var value string
+		switch name := *filter.Name; name {
+		case "network-interface-id":
+			value = networkInterface.NetworkInterfaceID
+		case "attachment.instance-id":
+			value = networkInterface.Attachment.InstanceID
+		case "attachment.attachment-id":
+			value = networkInterface.Attachment.AttachmentID
+		case "attachment.status":
+			value = networkInterface.Attachment.Status
+		case "availability-zone":
+			value = networkInterface.AvailabilityZone
+		case "mac-address":
+			value = networkInterface.MacAddress
+		case "private-ip-address":
+			value = networkInterface.PrivateIPAddress
+		case "addresses.private-ip-address":
+			if !slices.ContainsFunc(networkInterface.PrivateIPAddresses, func(a api.InstancePrivateIPAddressAssociation) bool {
+				return slices.Contains(filter.Values, a.PrivateIP)
+			}) {
+				return false, nil
+			}
+			continue
+		case "private-dns-name":
+			value = networkInterface.PrivateDNSName
+		case "status":
+			value = networkInterface.Status
+		case "subnet-id":
+			value = networkInterface.SubnetID
+		case "vpc-id":
+			value = networkInterface.VPCID
+		case "interface-type":
+			value = networkInterface.InterfaceType
+		default:
+			return false, api.InvalidParameterValueError("Filter.Name", name)
+		}
+		if !slices.Contains(filter.Values, value) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func networkInterfaceNotFoundError(networkInterfaceID string) error {
+	return api.ErrWithCode(
+		"InvalidNetworkInterfaceID.NotFound",
+		fmt.Errorf("The networkInterface ID '%s' does not exist", networkInterfaceID), //nolint
+	)
+}
@@ -0,0 +1,165 @@
+package dc2
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+)
+
+func TestDescribeNetworkInterfaces(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	d := &Dispatcher{
+		opts:    DispatcherOptions{Region: "us-east-1"},
+		exe:     newScalingExecutor(),
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+	runResp, err := d.dispatchRunInstances(ctx, &api.RunInstancesRequest{
+		ImageID:      "nginx",
+		InstanceType: "my-type",
+		MinCount:     2,
+		MaxCount:     2,
+	})
+	require.NoError(t, err)
+	require.Len(t, runResp.InstancesSet, 2)
+	instance := runResp.InstancesSet[0]
+
+	resp, err := d.dispatchDescribeNetworkInterfaces(ctx, &api.DescribeNetworkInterfacesRequest{})
+	require.NoError(t, err)
+	assert.Len(t, resp.NetworkInterfaces, 2)
+
+	resp, err = d.dispatchDescribeNetworkInterfaces(ctx, &api.DescribeNetworkInterfacesRequest{
+		Filters: []api.Filter{{Name: new("attachment.instance-id"), Values: []string{instance.InstanceID}}},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.NetworkInterfaces, 1)
+	networkInterface := resp.NetworkInterfaces[0]
+	assert.Equal(t, instance.NetworkInterfaces[0].NetworkInterfaceID, networkInterface.NetworkInterfaceID)
+	assert.Regexp(t, `^eni-[0-9a-f]{17}$`, networkInterface.NetworkInterfaceID)
+	assert.Equal(t, instance.InstanceID, networkInterface.Attachment.InstanceID)
+	assert.Equal(t, instance.PrivateIPAddress, networkInterface.PrivateIPAddress)
+	assert.Equal(t, instance.NetworkInterfaces[0].MacAddress, networkInterface.MacAddress)
+	assert.Equal(t, defaultSubnetID, networkInterface.SubnetID)
+	assert.Equal(t, "us-east-1a", networkInterface.AvailabilityZone)
+
+	resp, err = d.dispatchDescribeNetworkInterfaces(ctx, &api.DescribeNetworkInterfacesRequest{
+		Filters: []api.Filter{{Name: new("network-interface-id"), Values: []string{networkInterface.NetworkInterfaceID}}},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.NetworkInterfaces, 1)
+	assert.Equal(t, instance.InstanceID, resp.NetworkInterfaces[0].Attachment.InstanceID)
+
+	// Primary interfaces are deleted with their instance
+	_, err = d.dispatchTerminateInstances(ctx, &api.TerminateInstancesRequest{InstanceIDs: []string{instance.InstanceID}})
+	require.NoError(t, err)
+	_, err = d.dispatchDescribeNetworkInterfaces(ctx, &api.DescribeNetworkInterfacesRequest{
+		NetworkInterfaceIDs: []string{networkInterface.NetworkInterfaceID},
+	})
+	var apiErr *api.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "InvalidNetworkInterfaceID.NotFound", apiErr.Code)
+	resp, err = d.dispatchDescribeNetworkInterfaces(ctx, &api.DescribeNetworkInterfacesRequest{})
+	require.NoError(t, err)
+	assert.Len(t, resp.NetworkInterfaces, 1)
+}
+
+func TestAssignPrivateIPAddresses(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	d := &Dispatcher{
+		opts:    DispatcherOptions{Region: "us-east-1"},
+		exe:     newScalingExecutor(),
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+	runResp, err := d.dispatchRunInstances(ctx, &api.RunInstancesRequest{
+		ImageID:      "nginx",
+		InstanceType: "my-type",
+		MinCount:     2,
+		MaxCount:     2,
+	})
+	require.NoError(t, err)
+	require.Len(t, runResp.InstancesSet, 2)
+	instanceID := runResp.InstancesSet[0].InstanceID
+	networkInterfaceID := runResp.InstancesSet[0].NetworkInterfaces[0].NetworkInterfaceID
+	otherNetworkInterfaceID := runResp.InstancesSet[1].NetworkInterfaces[0].NetworkInterfaceID
+
+	privateIPs := func() map[string]bool {
+		t.Helper()
+		resp, err := d.dispatchDescribeInstances(ctx, &api.DescribeInstancesRequest{InstanceIDs: []string{instanceID}})
+		require.NoError(t, err)
+		require.Len(t, resp.ReservationSet, 1)
+		require.Len(t, resp.ReservationSet[0].InstancesSet, 1)
+		ips := map[string]bool{}
+		for _, addr := range resp.ReservationSet[0].InstancesSet[0].NetworkInterfaces[0].PrivateIPAddresses {
+			ips[addr.PrivateIP] = addr.Primary
+		}
+		return ips
+	}
+
+	assignResp, err := d.dispatchAssignPrivateIPAddresses(ctx, &api.AssignPrivateIPAddressesRequest{
+		NetworkInterfaceID: networkInterfaceID,
+		PrivateIPAddresses: []string{"10.0.0.10", "10.0.0.11"},
+	})
+	require.NoError(t, err)
+	assert.Len(t, assignResp.AssignedPrivateIPAddresses, 2)
+	ips := privateIPs()
+	assert.Len(t, ips, 3)
+	for _, ip := range []string{"10.0.0.10", "10.0.0.11"} {
+		primary, ok := ips[ip]
+		assert.True(t, ok, ip)
+		assert.False(t, primary, ip)
+	}
+
+	var apiErr *api.Error
+	// Addresses can't be assigned twice, nor outside the subnet
+	_, err = d.dispatchAssignPrivateIPAddresses(ctx, &api.AssignPrivateIPAddressesRequest{
+		NetworkInterfaceID: otherNetworkInterfaceID,
+		PrivateIPAddresses: []string{"10.0.0.10"},
+	})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "PrivateIpAddressInUse", apiErr.Code)
+	_, err = d.dispatchAssignPrivateIPAddresses(ctx, &api.AssignPrivateIPAddressesRequest{
+		NetworkInterfaceID: otherNetworkInterfaceID,
+		PrivateIPAddresses: []string{"10.0.1.10"},
+	})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, api.ErrorCodeInvalidParameterValue, apiErr.Code)
+
+	// Automatic assignment skips the addresses in use
+	assignResp, err = d.dispatchAssignPrivateIPAddresses(ctx, &api.AssignPrivateIPAddressesRequest{
+		NetworkInterfaceID:             otherNetworkInterfaceID,
+		SecondaryPrivateIPAddressCount: new(1),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []api.AssignedPrivateIPAddress{{PrivateIPAddress: "10.0.0.4"}}, assignResp.AssignedPrivateIPAddresses)
+	_, err = d.dispatchAssignPrivateIPAddresses(ctx, &api.AssignPrivateIPAddressesRequest{
+		NetworkInterfaceID:             networkInterfaceID,
+		SecondaryPrivateIPAddressCount: new(defaultIPv4AddressesPerInterface),
+	})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "PrivateIpAddressLimitExceeded", apiErr.Code)
+
+	_, err = d.dispatchUnassignPrivateIPAddresses(ctx, &api.UnassignPrivateIPAddressesRequest{
+		NetworkInterfaceID: networkInterfaceID,
+		PrivateIPAddresses: []string{"10.0.0.10"},
+	})
+	require.NoError(t, err)
+	ips = privateIPs()
+	assert.Len(t, ips, 2)
+	assert.NotContains(t, ips, "10.0.0.10")
+	assert.Contains(t, ips, "10.0.0.11")
+	_, err = d.dispatchUnassignPrivateIPAddresses(ctx, &api.UnassignPrivateIPAddressesRequest{
+		NetworkInterfaceID: networkInterfaceID,
+		PrivateIPAddresses: []string{"10.0.0.10"},
+	})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, api.ErrorCodeInvalidParameterValue, apiErr.Code)
+}
@@ -0,0 +1,367 @@
+package dc2
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+const (
+	placementGroupIDPrefix = "pg-"
+
+	placementGroupStrategyCluster   = "cluster"
+	placementGroupStrategySpread    = "spread"
+	placementGroupStrategyPartition = "partition"
+
+	placementGroupStateAvailable = "available"
+
+	placementGroupSpreadLevelRack = "rack"
+	placementGroupSpreadLevelHost = "host"
+
+	// AWS allows up to 7 running instances per availability zone in a
+	// spread placement group, and up to 7 partitions per group.
+	maxSpreadPlacementGroupInstances    = 7
+	maxPlacementGroupPartitions         = 7
+	defaultPlacementGroupPartitionCount = 2
+
+	attributeNamePlacementGroupName           = "PlacementGroupName"
+	attributeNamePlacementGroupStrategy       = "PlacementGroupStrategy"
+	attributeNamePlacementGroupPartitionCount = "PlacementGroupPartitionCount"
+	attributeNamePlacementGroupSpreadLevel    = "PlacementGroupSpreadLevel"
+
+	attributeNameInstancePlacementGroupName = "InstancePlacementGroupName"
+)
+
+type placementGroupData struct {
+	ID             string
+	Name           string
+	Strategy       string
+	PartitionCount *int
+	SpreadLevel    *string
+	Tags           []api.Tag
+}
+
+func (d *Dispatcher) dispatchCreatePlacementGroup(ctx context.Context, req *api.CreatePlacementGroupRequest) (*api.CreatePlacementGroupResponse, error) {
+	if err := validateTagSpecifications(req.TagSpecifications, types.ResourceTypePlacementGroup); err != nil {
+		return nil, err
+	}
+	strategy := strings.ToLower(strings.TrimSpace(req.Strategy))
+	if strategy == "" {
+		return nil, api.ErrWithCode("MissingParameter", fmt.Errorf("The request must contain the parameter Strategy")) //nolint
+	}
+	group := placementGroupData{
+		Name:     req.GroupName,
+		Strategy: strategy,
+		Tags:     tagSpecsToTags(req.TagSpecifications),
+	}
+	switch strategy {
+	case placementGroupStrategyCluster:
+	case placementGroupStrategySpread:
+		spreadLevel := placementGroupSpreadLevelRack
+		if req.SpreadLevel != nil && *req.SpreadLevel != "" {
+			spreadLevel = *req.SpreadLevel
+		}
+		if spreadLevel != placementGroupSpreadLevelRack && spreadLevel != placementGroupSpreadLevelHost {
+			return nil, api.InvalidParameterValueError("SpreadLevel", spreadLevel)
+		}
+		group.SpreadLevel = &spreadLevel
+	case placementGroupStrategyPartition:
+		partitionCount := defaultPlacementGroupPartitionCount
+		if req.PartitionCount != nil {
+			partitionCount = *req.PartitionCount
+		}
+		if partitionCount < 1 || partitionCount > maxPlacementGroupPartitions {
+			return nil, api.InvalidParameterValueError("PartitionCount", strconv.Itoa(partitionCount))
+		}
+		group.PartitionCount = &partitionCount
+	default:
+		return nil, api.InvalidParameterValueError("Strategy", req.Strategy)
+	}
+	if req.PartitionCount != nil && strategy != placementGroupStrategyPartition {
+		return nil, api.ErrWithCode(
+			"InvalidParameterCombination",
+			fmt.Errorf("PartitionCount is only supported by the partition strategy"),
+		)
+	}
+	existing, err := d.findPlacementGroupByName(req.GroupName)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, api.ErrWithCode(
+			"InvalidPlacementGroup.Duplicate",
+			fmt.Errorf("The Placement Group '%s' already exists.", req.GroupName), //nolint
+		)
+	}
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
+
+	group.ID, err = makeID(placementGroupIDPrefix)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypePlacementGroup, ID: group.ID}); err != nil {
+		return nil, fmt.Errorf("registering placement group: %w", err)
+	}
+	attrs := []storage.Attribute{
+		{Key: attributeNamePlacementGroupName, Value: group.Name},
+		{Key: attributeNamePlacementGroupStrategy, Value: group.Strategy},
+	}
+	if group.PartitionCount != nil {
+		attrs = append(attrs, storage.Attribute{Key: attributeNamePlacementGroupPartitionCount, Value: strconv.Itoa(*group.PartitionCount)})
+	}
+	if group.SpreadLevel != nil {
+		attrs = append(attrs, storage.Attribute{Key: attributeNamePlacementGroupSpreadLevel, Value: *group.SpreadLevel})
+	}
+	for _, tag := range group.Tags {
+		attrs = append(attrs, storage.Attribute{Key: storage.TagAttributeName(tag.Key), Value: tag.Value})
+	}
+	if err := d.storage.SetResourceAttributes(group.ID, attrs); err != nil {
+		return nil, fmt.Errorf("saving placement group attributes: %w", err)
+	}
+	api.Logger(ctx).Info(
+		"created placement group",
+		slog.String("group_id", group.ID),
+		slog.String("group_name", group.Name),
+		slog.String("strategy", group.Strategy),
+	)
+	return &api.CreatePlacementGroupResponse{PlacementGroup: d.apiPlacementGroup(group)}, nil
+}
+
+func (d *Dispatcher) dispatchDescribePlacementGroups(_ context.Context, req *api.DescribePlacementGroupsRequest) (*api.DescribePlacementGroupsResponse, error) {
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
+	groups, err := d.listPlacementGroups()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range req.GroupNames {
+		if !slices.ContainsFunc(groups, func(g placementGroupData) bool { return g.Name == name }) {
+			return nil, placementGroupUnknownError(name)
+		}
+	}
+	for _, id := range req.GroupIDs {
+		if !slices.ContainsFunc(groups, func(g placementGroupData) bool { return g.ID == id }) {
+			return nil, placementGroupUnknownError(id)
+		}
+	}
+
+	out := make([]api.PlacementGroup, 0, len(groups))
+	for _, group := range groups {
+		if len(req.GroupNames) > 0 && !slices.Contains(req.GroupNames, group.Name) {
+			continue
+		}
+		if len(req.GroupIDs) > 0 && !slices.Contains(req.GroupIDs, group.ID) {
+			continue
+		}
+		placementGroup := d.apiPlacementGroup(group)
+		matches, err := placementGroupMatchesFilters(placementGroup, req.Filters)
+		if err != nil {
+			return nil, err
+		}
+		if matches {
+			out = append(out, placementGroup)
+		}
+	}
+	slices.SortFunc(out, func(a, b api.PlacementGroup) int {
+		return strings.Compare(a.GroupName, b.GroupName)
+	})
+	return &api.DescribePlacementGroupsResponse{PlacementGroups: out}, nil
+}
+
+func (d *Dispatcher) dispatchDeletePlacementGroup(ctx context.Context, req *api.DeletePlacementGroupRequest) (*api.DeletePlacementGroupResponse, error) {
+	group, err := d.findPlacementGroupByName(req.GroupName)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return nil, placementGroupUnknownError(req.GroupName)
+	}
+	instanceIDs, err := d.placementGroupInstanceIDs(group.Name, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(instanceIDs) > 0 {
+		return nil, api.ErrWithCode(
+			"InvalidPlacementGroup.InUse",
+			fmt.Errorf("The placement group '%s' is in use and may not be deleted.", group.Name), //nolint
+		)
+	}
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
+	if err := d.storage.RemoveResource(group.ID); err != nil {
+		return nil, fmt.Errorf("deleting placement group: %w", err)
+	}
+	api.Logger(ctx).Info("deleted placement group", slog.String("group_id", group.ID), slog.String("group_name", group.Name))
+	return &api.DeletePlacementGroupResponse{Return: true}, nil
+}
+
+// resolveRunInstancesPlacementGroup validates the placement group requested
+// by RunInstances, returning the empty string when none was given.
+func (d *Dispatcher) resolveRunInstancesPlacementGroup(req *api.RunInstancesRequest, availabilityZone string) (string, error) {
+	if req.Placement == nil || strings.TrimSpace(req.Placement.GroupName) == "" {
+		return "", nil
+	}
+	groupName := strings.TrimSpace(req.Placement.GroupName)
+	group, err := d.findPlacementGroupByName(groupName)
+	if err != nil {
+		return "", err
+	}
+	if group == nil {
+		return "", placementGroupUnknownError(groupName)
+	}
+	if group.Strategy == placementGroupStrategySpread {
+		instanceIDs, err := d.placementGroupInstanceIDs(group.Name, availabilityZone)
+		if err != nil {
+			return "", err
+		}
+		if len(instanceIDs)+req.MaxCount > maxSpreadPlacementGroupInstances {
+			return "", api.ErrWithCode(
+				"InsufficientInstanceCapacity",
+				fmt.Errorf(
+					"Spread placement group '%s' can't have more than %d running instances in availability zone %s.", //nolint
+					group.Name, maxSpreadPlacementGroupInstances, availabilityZone,
+				),
+			)
+		}
+	}
+	return group.Name, nil
+}
+
+// placementGroupInstanceIDs returns the non terminated instances launched in
+// the placement group, optionally restricted to an availability zone.
+func (d *Dispatcher) placementGroupInstanceIDs(groupName string, availabilityZone string) ([]string, error) {
+	resources, err := d.storage.RegisteredResources(types.ResourceTypeInstance)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving registered instances: %w", err)
+	}
+	var instanceIDs []string
+	for _, r := range resources {
+		attrs, err := d.storage.ResourceAttributes(r.ID)
+		if err != nil {
+			return nil, fmt.Errorf("retrieving instance attributes: %w", err)
+		}
+		if name, _ := attrs.Key(attributeNameInstancePlacementGroupName); name != groupName {
+			continue
+		}
+		if terminatedAt, _ := attrs.Key(attributeNameInstanceTerminatedAt); terminatedAt != "" {
+			continue
+		}
+		if zone, _ := attrs.Key(attributeNameAvailabilityZone); availabilityZone != "" && zone != availabilityZone {
+			continue
+		}
+		instanceIDs = append(instanceIDs, r.ID)
+	}
+	return instanceIDs, nil
+}
+
+func (d *Dispatcher) listPlacementGroups() ([]placementGroupData, error) {
+	resources, err := d.storage.RegisteredResources(types.ResourceTypePlacementGroup)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving placement groups: %w", err)
+	}
+	groups := make([]placementGroupData, 0, len(resources))
+	for _, r := range resources {
+		attrs, err := d.storage.ResourceAttributes(r.ID)
+		if err != nil {
+			return nil, fmt.Errorf("retrieving placement group attributes: %w", err)
+		}
+		group := placementGroupData{ID: r.ID, Tags: tagsFromAttributes(attrs)}
+		group.Name, _ = attrs.Key(attributeNamePlacementGroupName)
+		group.Strategy, _ = attrs.Key(attributeNamePlacementGroupStrategy)
+		if raw, ok := attrs.Key(attributeNamePlacementGroupPartitionCount); ok {
+			partitionCount, err := strconv.Atoi(raw)
+			if err != nil {
+				return nil, fmt.Errorf("parsing placement group partition count: %w", err)
+			}
+			group.PartitionCount = &partitionCount
+		}
+		if spreadLevel, ok := attrs.Key(attributeNamePlacementGroupSpreadLevel); ok {
+			group.SpreadLevel = &spreadLevel
+		}
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+// findPlacementGroupByName returns the placement group with the given name,
+// or nil if there's none.
+func (d *Dispatcher) findPlacementGroupByName(name string) (*placementGroupData, error) {
+	groups, err := d.listPlacementGroups()
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groups {
+		if group.Name == name {
+			return &group, nil
+		}
+	}
+	return nil, nil
+}
+
+func (d *Dispatcher) apiPlacementGroup(group placementGroupData) api.PlacementGroup {
+	return api.PlacementGroup{
+		GroupID:        group.ID,
+		GroupName:      group.Name,
+		GroupARN:       fmt.Sprintf("arn:aws:ec2:%s:%s:placement-group/%s", d.opts.Region, defaultSecurityGroupOwnerID, group.Name),
+		State:          placementGroupStateAvailable,
+		Strategy:       group.Strategy,
+		PartitionCount: group.PartitionCount,
+		SpreadLevel:    group.SpreadLevel,
+		Tags:           group.Tags,
+	}
+}
+
+func placementGroupMatchesFilters(group api.PlacementGroup, filters []api.Filter) (bool, error) {
+	for _, filter := range filters {
+		if filter.Name == nil {
+			return false, api.InvalidParameterValueError("Filter.Name", "<missing>")
+		}
+		var value string
+		switch name := *filter.Name; {
+		case name == "group-name":
+			value = group.GroupName
+		case name == "group-arn":
+			value = group.GroupARN
+		case name == "state":
+			value = group.State
+		case name == "strategy":
+			value = group.Strategy
+		case name == "spread-level":
+			value = stringValue(group.SpreadLevel)
+		case name == "tag-key":
+			if !slices.ContainsFunc(group.Tags, func(tag api.Tag) bool { return slices.Contains(filter.Values, tag.Key) }) {
+				return false, nil
+			}
+			continue
+		case strings.HasPrefix(name, "tag:"):
+			tagKey := strings.TrimPrefix(name, "tag:")
+			if !slices.ContainsFunc(group.Tags, func(tag api.Tag) bool {
+				return tag.Key == tagKey && slices.Contains(filter.Values, tag.Value)
+			}) {
+				return false, nil
+			}
+			continue
+		default:
+			return false, api.InvalidParameterValueError("Filter.Name", name)
+		}
+		if !slices.Contains(filter.Values, value) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func placementGroupUnknownError(nameOrID string) error {
+	return api.ErrWithCode("InvalidPlacementGroup.Unknown", fmt.Errorf("The Placement Group '%s' is unknown.", nameOrID)) //nolint
+}
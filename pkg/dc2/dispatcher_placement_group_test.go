@@ -0,0 +1,115 @@
+package dc2
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+func TestPlacementGroups(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	d := &Dispatcher{
+		opts:    DispatcherOptions{Region: "us-east-1"},
+		exe:     &exitCleanupExecutor{},
+		storage: storage.NewMemoryStorage(),
+	}
+	clusterResp, err := d.dispatchCreatePlacementGroup(ctx, &api.CreatePlacementGroupRequest{
+		GroupName: "cluster",
+		Strategy:  "cluster",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "arn:aws:ec2:us-east-1:000000000000:placement-group/cluster", clusterResp.PlacementGroup.GroupARN)
+	_, err = d.dispatchCreatePlacementGroup(ctx, &api.CreatePlacementGroupRequest{
+		GroupName: "spread",
+		Strategy:  "spread",
+	})
+	require.NoError(t, err)
+	partitionResp, err := d.dispatchCreatePlacementGroup(ctx, &api.CreatePlacementGroupRequest{
+		GroupName: "partition",
+		Strategy:  "partition",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, new(2), partitionResp.PlacementGroup.PartitionCount)
+
+	for _, req := range []*api.CreatePlacementGroupRequest{
+		{GroupName: "cluster", Strategy: "cluster"},
+		{GroupName: "invalid", Strategy: "invalid"},
+		{GroupName: "too-many-partitions", Strategy: "partition", PartitionCount: new(8)},
+		{GroupName: "cluster-partitions", Strategy: "cluster", PartitionCount: new(2)},
+	} {
+		_, err := d.dispatchCreatePlacementGroup(ctx, req)
+		require.Error(t, err, req.GroupName)
+	}
+
+	describeResp, err := d.dispatchDescribePlacementGroups(ctx, &api.DescribePlacementGroupsRequest{})
+	require.NoError(t, err)
+	var names []string
+	for _, group := range describeResp.PlacementGroups {
+		names = append(names, group.GroupName)
+	}
+	assert.Equal(t, []string{"cluster", "partition", "spread"}, names)
+
+	describeResp, err = d.dispatchDescribePlacementGroups(ctx, &api.DescribePlacementGroupsRequest{
+		Filters: []api.Filter{{Name: new("strategy"), Values: []string{"spread"}}},
+	})
+	require.NoError(t, err)
+	require.Len(t, describeResp.PlacementGroups, 1)
+	assert.Equal(t, new("rack"), describeResp.PlacementGroups[0].SpreadLevel)
+
+	_, err = d.dispatchDescribePlacementGroups(ctx, &api.DescribePlacementGroupsRequest{GroupNames: []string{"missing"}})
+	var apiErr *api.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "InvalidPlacementGroup.Unknown", apiErr.Code)
+
+	_, err = d.resolveRunInstancesPlacementGroup(&api.RunInstancesRequest{
+		MaxCount:  1,
+		Placement: &api.Placement{GroupName: "missing"},
+	}, "us-east-1a")
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "InvalidPlacementGroup.Unknown", apiErr.Code)
+
+	// Spread groups hold at most 7 instances per availability zone
+	for i := range 6 {
+		instanceID := fmt.Sprintf("i-0000000000000000%d", i)
+		require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeInstance, ID: instanceID}))
+		require.NoError(t, d.storage.SetResourceAttributes(instanceID, []storage.Attribute{
+			{Key: attributeNameInstancePlacementGroupName, Value: "spread"},
+			{Key: attributeNameAvailabilityZone, Value: "us-east-1a"},
+		}))
+	}
+	groupName, err := d.resolveRunInstancesPlacementGroup(&api.RunInstancesRequest{
+		MaxCount:  1,
+		Placement: &api.Placement{GroupName: "spread"},
+	}, "us-east-1a")
+	require.NoError(t, err)
+	assert.Equal(t, "spread", groupName)
+	_, err = d.resolveRunInstancesPlacementGroup(&api.RunInstancesRequest{
+		MaxCount:  2,
+		Placement: &api.Placement{GroupName: "spread"},
+	}, "us-east-1a")
+	require.Error(t, err)
+	_, err = d.resolveRunInstancesPlacementGroup(&api.RunInstancesRequest{
+		MaxCount:  2,
+		Placement: &api.Placement{GroupName: "spread"},
+	}, "us-east-1b")
+	require.NoError(t, err)
+
+	_, err = d.dispatchDeletePlacementGroup(ctx, &api.DeletePlacementGroupRequest{GroupName: "spread"})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "InvalidPlacementGroup.InUse", apiErr.Code)
+
+	_, err = d.dispatchDeletePlacementGroup(ctx, &api.DeletePlacementGroupRequest{GroupName: "cluster"})
+	require.NoError(t, err)
+	_, err = d.dispatchDeletePlacementGroup(ctx, &api.DeletePlacementGroupRequest{GroupName: "cluster"})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "InvalidPlacementGroup.Unknown", apiErr.Code)
+}
@@ -13,6 +13,13 @@ import (
 )
 
 const (
+	attributeNameInstanceSecurityGroupIDs = "InstanceSecurityGroupIDs"
+
+	attributeNameSecurityGroupName        = "SecurityGroupName"
+	attributeNameSecurityGroupDescription = "SecurityGroupDescription"
+	attributeNameSecurityGroupOwnerID     = "SecurityGroupOwnerID"
+	attributeNameSecurityGroupVPCID       = "SecurityGroupVPCID"
+
 	defaultSecurityGroupID          = "sg-00000000000000000"
 	defaultSecurityGroupName        = "default"
 	defaultSecurityGroupDescription = "default VPC security group"
@@ -92,14 +99,17 @@ func (d *Dispatcher) dispatchCreateSecurityGroup(
 	if err := d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeSecurityGroup, ID: groupID}); err != nil {
 		return nil, fmt.Errorf("registering resource %s: %w", groupID, err)
 	}
-	if len(group.Tags) > 0 {
-		attrs := make([]storage.Attribute, len(group.Tags))
-		for i, tag := range group.Tags {
-			attrs[i] = storage.Attribute{Key: storage.TagAttributeName(tag.Key), Value: tag.Value}
-		}
-		if err := d.storage.SetResourceAttributes(groupID, attrs); err != nil {
-			return nil, fmt.Errorf("setting resource attributes for %s: %w", groupID, err)
-		}
+	attrs := []storage.Attribute{
+		{Key: attributeNameSecurityGroupName, Value: groupName},
+		{Key: attributeNameSecurityGroupDescription, Value: description},
+		{Key: attributeNameSecurityGroupOwnerID, Value: ownerID},
+		{Key: attributeNameSecurityGroupVPCID, Value: groupVPCID},
+	}
+	for _, tag := range group.Tags {
+		attrs = append(attrs, storage.Attribute{Key: storage.TagAttributeName(tag.Key), Value: tag.Value})
+	}
+	if err := d.storage.SetResourceAttributes(groupID, attrs); err != nil {
+		return nil, fmt.Errorf("setting resource attributes for %s: %w", groupID, err)
 	}
 	d.ensureSecurityGroupMap()
 	d.securityGroups[groupID] = group
@@ -119,13 +129,7 @@ func (d *Dispatcher) dispatchDeleteSecurityGroup(
 
 	groupID, ok := d.resolveSecurityGroupID(req.GroupID, req.GroupName)
 	if !ok {
-		msg := "The security group does not exist."
-		if req.GroupID != nil && strings.TrimSpace(*req.GroupID) != "" {
-			msg = fmt.Sprintf("The security group '%s' does not exist.", strings.TrimSpace(*req.GroupID))
-		} else if req.GroupName != nil && strings.TrimSpace(*req.GroupName) != "" {
-			msg = fmt.Sprintf("The security group '%s' does not exist.", strings.TrimSpace(*req.GroupName))
-		}
-		return nil, api.ErrWithCode("InvalidGroup.NotFound", fmt.Errorf("%s", msg))
+		return nil, securityGroupNotFoundError(req.GroupID, req.GroupName)
 	}
 
 	if groupID == defaultSecurityGroupID {
@@ -143,46 +147,8 @@ func (d *Dispatcher) dispatchDeleteSecurityGroup(
 	return &api.DeleteSecurityGroupResponse{}, nil
 }
 
-func (d *Dispatcher) dispatchAuthorizeSecurityGroupIngress(
-	_ context.Context,
-	req *api.AuthorizeSecurityGroupIngressRequest,
-) (*api.SecurityGroupRuleMutationResponse, error) {
-	if req.DryRun {
-		return nil, api.DryRunError()
-	}
-	if _, ok := d.resolveSecurityGroupID(req.GroupID, req.GroupName); !ok {
-		msg := "The security group does not exist."
-		if req.GroupID != nil && strings.TrimSpace(*req.GroupID) != "" {
-			msg = fmt.Sprintf("The security group '%s' does not exist.", strings.TrimSpace(*req.GroupID))
-		} else if req.GroupName != nil && strings.TrimSpace(*req.GroupName) != "" {
-			msg = fmt.Sprintf("The security group '%s' does not exist.", strings.TrimSpace(*req.GroupName))
-		}
-		return nil, api.ErrWithCode("InvalidGroup.NotFound", fmt.Errorf("%s", msg))
-	}
-	return &api.SecurityGroupRuleMutationResponse{Return: true}, nil
-}
-
-func (d *Dispatcher) dispatchAuthorizeSecurityGroupEgress(
-	_ context.Context,
-	req *api.AuthorizeSecurityGroupEgressRequest,
-) (*api.SecurityGroupRuleMutationResponse, error) {
-	if req.DryRun {
-		return nil, api.DryRunError()
-	}
-	if _, ok := d.resolveSecurityGroupID(req.GroupID, req.GroupName); !ok {
-		msg := "The security group does not exist."
-		if req.GroupID != nil && strings.TrimSpace(*req.GroupID) != "" {
-			msg = fmt.Sprintf("The security group '%s' does not exist.", strings.TrimSpace(*req.GroupID))
-		} else if req.GroupName != nil && strings.TrimSpace(*req.GroupName) != "" {
-			msg = fmt.Sprintf("The security group '%s' does not exist.", strings.TrimSpace(*req.GroupName))
-		}
-		return nil, api.ErrWithCode("InvalidGroup.NotFound", fmt.Errorf("%s", msg))
-	}
-	return &api.SecurityGroupRuleMutationResponse{Return: true}, nil
-}
-
 func (d *Dispatcher) listSecurityGroups() []api.SecurityGroup {
-	groups := []api.SecurityGroup{d.securityGroupWithStoredTags(defaultSecurityGroup())}
+	groups := []api.SecurityGroup{d.securityGroupWithStoredAttributes(defaultSecurityGroup())}
 	if len(d.securityGroups) == 0 {
 		return groups
 	}
@@ -193,34 +159,39 @@ func (d *Dispatcher) listSecurityGroups() []api.SecurityGroup {
 	}
 	slices.Sort(ids)
 	for _, id := range ids {
-		groups = append(groups, d.securityGroupWithStoredTags(d.securityGroups[id]))
+		groups = append(groups, d.securityGroupWithStoredAttributes(d.securityGroups[id]))
 	}
 	return groups
 }
 
-func (d *Dispatcher) securityGroupWithStoredTags(group api.SecurityGroup) api.SecurityGroup {
+func (d *Dispatcher) securityGroupWithStoredAttributes(group api.SecurityGroup) api.SecurityGroup {
 	groupID := securityGroupStringValue(group.GroupID)
 	if groupID == "" {
 		return group
 	}
+	// The default group is only registered once its rules change, use the
+	// default rules until then.
 	attrs, err := d.storage.ResourceAttributes(groupID)
-	if err != nil {
-		return group
-	}
-	tags := make([]api.Tag, 0, len(attrs))
-	for _, attr := range attrs {
-		if !attr.IsTag() {
-			continue
+	if err == nil {
+		tags := make([]api.Tag, 0, len(attrs))
+		for _, attr := range attrs {
+			if !attr.IsTag() {
+				continue
+			}
+			tags = append(tags, api.Tag{Key: attr.TagKey(), Value: attr.Value})
 		}
-		tags = append(tags, api.Tag{Key: attr.TagKey(), Value: attr.Value})
+		slices.SortFunc(tags, func(a, b api.Tag) int {
+			if a.Key != b.Key {
+				return strings.Compare(a.Key, b.Key)
+			}
+			return strings.Compare(a.Value, b.Value)
+		})
+		group.Tags = tags
+	}
+	if ingress, egress, err := securityGroupRules(groupID, attrs); err == nil {
+		group.IPPermissions = securityGroupPermissions(ingress)
+		group.IPPermissionsEgress = securityGroupPermissions(egress)
 	}
-	slices.SortFunc(tags, func(a, b api.Tag) int {
-		if a.Key != b.Key {
-			return strings.Compare(a.Key, b.Key)
-		}
-		return strings.Compare(a.Value, b.Value)
-	})
-	group.Tags = tags
 	return group
 }
 
@@ -259,6 +230,56 @@ func (d *Dispatcher) resolveSecurityGroupID(groupID *string, groupName *string)
 	return "", false
 }
 
+// resolveRunInstancesSecurityGroupIDs validates the security groups requested
+// by ID or name and returns their IDs, preserving request order.
+func (d *Dispatcher) resolveRunInstancesSecurityGroupIDs(groupIDs []string, groupNames []string) ([]string, error) {
+	resolved := make([]string, 0, len(groupIDs)+len(groupNames))
+	for _, groupID := range groupIDs {
+		id, ok := d.resolveSecurityGroupID(&groupID, nil)
+		if !ok {
+			msg := fmt.Sprintf("The security group '%s' does not exist.", strings.TrimSpace(groupID))
+			return nil, api.ErrWithCode("InvalidGroup.NotFound", fmt.Errorf("%s", msg))
+		}
+		if !slices.Contains(resolved, id) {
+			resolved = append(resolved, id)
+		}
+	}
+	for _, groupName := range groupNames {
+		id, ok := d.resolveSecurityGroupID(nil, &groupName)
+		if !ok {
+			msg := fmt.Sprintf("The security group '%s' does not exist in default VPC '%s'", strings.TrimSpace(groupName), defaultSecurityGroupVPCID)
+			return nil, api.ErrWithCode("InvalidGroup.NotFound", fmt.Errorf("%s", msg))
+		}
+		if !slices.Contains(resolved, id) {
+			resolved = append(resolved, id)
+		}
+	}
+	return resolved, nil
+}
+
+// instanceSecurityGroups returns the security groups associated with an
+// instance, falling back to the default security group.
+func (d *Dispatcher) instanceSecurityGroups(attrs storage.Attributes) ([]api.Group, error) {
+	raw, _ := attrs.Key(attributeNameInstanceSecurityGroupIDs)
+	groupIDs, err := unmarshalStringSlice(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parsing instance security groups: %w", err)
+	}
+	if len(groupIDs) == 0 {
+		groupIDs = []string{defaultSecurityGroupID}
+	}
+	groups := make([]api.Group, len(groupIDs))
+	for i, groupID := range groupIDs {
+		groups[i] = api.Group{GroupID: groupID}
+		if groupID == defaultSecurityGroupID {
+			groups[i].GroupName = defaultSecurityGroupName
+		} else if group, ok := d.securityGroups[groupID]; ok {
+			groups[i].GroupName = securityGroupStringValue(group.GroupName)
+		}
+	}
+	return groups, nil
+}
+
 func defaultSecurityGroup() api.SecurityGroup {
 	groupID := defaultSecurityGroupID
 	groupName := defaultSecurityGroupName
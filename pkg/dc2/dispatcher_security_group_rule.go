@@ -0,0 +1,421 @@
+package dc2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+const (
+	attributeNameSecurityGroupIngressRules = "SecurityGroupIngressRules"
+	attributeNameSecurityGroupEgressRules  = "SecurityGroupEgressRules"
+
+	securityGroupProtocolAll = "-1"
+	securityGroupAnyIPv4     = "0.0.0.0/0"
+)
+
+// securityGroupRule is a single security group rule with exactly one source
+// (or destination, for egress rules). Permissions with several ranges are
+// split into one rule per range, which is how AWS matches them on revoke.
+type securityGroupRule struct {
+	IPProtocol   string `json:"ipProtocol"`
+	FromPort     *int   `json:"fromPort,omitempty"`
+	ToPort       *int   `json:"toPort,omitempty"`
+	CIDRIP       string `json:"cidrIp,omitempty"`
+	CIDRIPv6     string `json:"cidrIpv6,omitempty"`
+	PrefixListID string `json:"prefixListId,omitempty"`
+	GroupID      string `json:"groupId,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+// sameRule reports whether both rules match the same traffic, ignoring
+// their descriptions.
+func (r securityGroupRule) sameRule(other securityGroupRule) bool {
+	return r.IPProtocol == other.IPProtocol &&
+		optionalIntEqual(r.FromPort, other.FromPort) &&
+		optionalIntEqual(r.ToPort, other.ToPort) &&
+		r.CIDRIP == other.CIDRIP &&
+		r.CIDRIPv6 == other.CIDRIPv6 &&
+		r.PrefixListID == other.PrefixListID &&
+		r.GroupID == other.GroupID
+}
+
+func (r securityGroupRule) String() string {
+	peer := r.CIDRIP
+	switch {
+	case r.CIDRIPv6 != "":
+		peer = r.CIDRIPv6
+	case r.PrefixListID != "":
+		peer = r.PrefixListID
+	case r.GroupID != "":
+		peer = r.GroupID
+	}
+	s := fmt.Sprintf("peer: %s, %s", peer, strings.ToUpper(r.IPProtocol))
+	if r.IPProtocol == securityGroupProtocolAll {
+		s = fmt.Sprintf("peer: %s, ALL", peer)
+	}
+	if r.FromPort != nil {
+		s += fmt.Sprintf(", from port: %d", *r.FromPort)
+	}
+	if r.ToPort != nil {
+		s += fmt.Sprintf(", to port: %d", *r.ToPort)
+	}
+	return s + ", ALLOW"
+}
+
+type securityGroupRuleChange struct {
+	GroupID     *string
+	GroupName   *string
+	Permissions []api.SecurityGroupIPPermission
+	Egress      bool
+	Revoke      bool
+	DryRun      bool
+}
+
+func (d *Dispatcher) dispatchAuthorizeSecurityGroupIngress(
+	ctx context.Context,
+	req *api.AuthorizeSecurityGroupIngressRequest,
+) (*api.SecurityGroupRuleMutationResponse, error) {
+	return d.changeSecurityGroupRules(ctx, securityGroupRuleChange{
+		GroupID:     req.GroupID,
+		GroupName:   req.GroupName,
+		Permissions: securityGroupRequestPermissions(req.CIDRIP, req.IPProtocol, req.FromPort, req.ToPort, req.IPPermissions),
+		DryRun:      req.DryRun,
+	})
+}
+
+func (d *Dispatcher) dispatchAuthorizeSecurityGroupEgress(
+	ctx context.Context,
+	req *api.AuthorizeSecurityGroupEgressRequest,
+) (*api.SecurityGroupRuleMutationResponse, error) {
+	return d.changeSecurityGroupRules(ctx, securityGroupRuleChange{
+		GroupID:     req.GroupID,
+		GroupName:   req.GroupName,
+		Permissions: securityGroupRequestPermissions(req.CIDRIP, req.IPProtocol, req.FromPort, req.ToPort, req.IPPermissions),
+		Egress:      true,
+		DryRun:      req.DryRun,
+	})
+}
+
+func (d *Dispatcher) dispatchRevokeSecurityGroupIngress(
+	ctx context.Context,
+	req *api.RevokeSecurityGroupIngressRequest,
+) (*api.SecurityGroupRuleMutationResponse, error) {
+	return d.changeSecurityGroupRules(ctx, securityGroupRuleChange{
+		GroupID:     req.GroupID,
+		GroupName:   req.GroupName,
+		Permissions: securityGroupRequestPermissions(req.CIDRIP, req.IPProtocol, req.FromPort, req.ToPort, req.IPPermissions),
+		Revoke:      true,
+		DryRun:      req.DryRun,
+	})
+}
+
+func (d *Dispatcher) dispatchRevokeSecurityGroupEgress(
+	ctx context.Context,
+	req *api.RevokeSecurityGroupEgressRequest,
+) (*api.SecurityGroupRuleMutationResponse, error) {
+	return d.changeSecurityGroupRules(ctx, securityGroupRuleChange{
+		GroupID:     req.GroupID,
+		GroupName:   req.GroupName,
+		Permissions: securityGroupRequestPermissions(req.CIDRIP, req.IPProtocol, req.FromPort, req.ToPort, req.IPPermissions),
+		Egress:      true,
+		Revoke:      true,
+		DryRun:      req.DryRun,
+	})
+}
+
+// securityGroupRequestPermissions merges the legacy top level rule fields
+// into the IpPermissions list.
+func securityGroupRequestPermissions(
+	cidrIP *string,
+	ipProtocol *string,
+	fromPort *int,
+	toPort *int,
+	permissions []api.SecurityGroupIPPermission,
+) []api.SecurityGroupIPPermission {
+	if cidrIP == nil && ipProtocol == nil {
+		return permissions
+	}
+	legacy := api.SecurityGroupIPPermission{
+		IPProtocol: ipProtocol,
+		FromPort:   fromPort,
+		ToPort:     toPort,
+	}
+	if cidrIP != nil {
+		legacy.IPRanges = []api.SecurityGroupIPRange{{CIDRIP: cidrIP}}
+	}
+	return append([]api.SecurityGroupIPPermission{legacy}, permissions...)
+}
+
+func (d *Dispatcher) changeSecurityGroupRules(ctx context.Context, change securityGroupRuleChange) (*api.SecurityGroupRuleMutationResponse, error) {
+	groupID, ok := d.resolveSecurityGroupID(change.GroupID, change.GroupName)
+	if !ok {
+		return nil, securityGroupNotFoundError(change.GroupID, change.GroupName)
+	}
+	if len(change.Permissions) == 0 {
+		return nil, api.ErrWithCode("MissingParameter", errors.New("the request must contain the parameter ipPermissions"))
+	}
+	changed, err := d.securityGroupRulesFromPermissions(change.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	attrs, err := d.securityGroupAttributes(groupID)
+	if err != nil {
+		return nil, err
+	}
+	ingress, egress, err := securityGroupRules(groupID, attrs)
+	if err != nil {
+		return nil, err
+	}
+	rules, attributeName := ingress, attributeNameSecurityGroupIngressRules
+	if change.Egress {
+		rules, attributeName = egress, attributeNameSecurityGroupEgressRules
+	}
+
+	for _, rule := range changed {
+		i := slices.IndexFunc(rules, rule.sameRule)
+		switch {
+		case change.Revoke && i < 0:
+			return nil, api.ErrWithCode(
+				"InvalidPermission.NotFound",
+				fmt.Errorf("The specified rule %q does not exist in this security group.", rule.String()), //nolint
+			)
+		case change.Revoke:
+			rules = slices.Delete(rules, i, i+1)
+		case i >= 0:
+			return nil, api.ErrWithCode(
+				"InvalidPermission.Duplicate",
+				fmt.Errorf("the specified rule %q already exists", rule.String()),
+			)
+		default:
+			rules = append(rules, rule)
+		}
+	}
+	if change.DryRun {
+		return nil, api.DryRunError()
+	}
+
+	raw, err := json.Marshal(rules)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling security group rules: %w", err)
+	}
+	if attrs == nil {
+		// The default security group is synthesized, register it the first
+		// time its rules change so they can be stored.
+		if err := d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeSecurityGroup, ID: groupID}); err != nil {
+			return nil, fmt.Errorf("registering resource %s: %w", groupID, err)
+		}
+	}
+	if err := d.storage.SetResourceAttributes(groupID, []storage.Attribute{
+		{Key: attributeName, Value: string(raw)},
+	}); err != nil {
+		return nil, fmt.Errorf("storing security group rules: %w", err)
+	}
+	api.Logger(ctx).Info(
+		"changed security group rules",
+		slog.String("group_id", groupID),
+		slog.Bool("egress", change.Egress),
+		slog.Bool("revoke", change.Revoke),
+		slog.Int("rules", len(rules)),
+	)
+	return &api.SecurityGroupRuleMutationResponse{Return: true}, nil
+}
+
+// securityGroupAttributes returns the stored attributes for the group, or
+// nil if the group hasn't been registered in storage.
+func (d *Dispatcher) securityGroupAttributes(groupID string) (storage.Attributes, error) {
+	attrs, err := d.storage.ResourceAttributes(groupID)
+	if err != nil {
+		if errors.As(err, &storage.ErrResourceNotFound{}) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("retrieving security group attributes: %w", err)
+	}
+	return attrs, nil
+}
+
+func (d *Dispatcher) securityGroupRulesFromPermissions(permissions []api.SecurityGroupIPPermission) ([]securityGroupRule, error) {
+	var rules []securityGroupRule
+	for i, permission := range permissions {
+		param := fmt.Sprintf("IpPermissions.%d", i+1)
+		protocol, err := parseSecurityGroupProtocol(param+".IpProtocol", permission.IPProtocol)
+		if err != nil {
+			return nil, err
+		}
+		base := securityGroupRule{IPProtocol: protocol}
+		// Rules for all protocols apply to all ports
+		if protocol != securityGroupProtocolAll {
+			base.FromPort = permission.FromPort
+			base.ToPort = permission.ToPort
+		}
+		start := len(rules)
+		for _, r := range permission.IPRanges {
+			rule := base
+			rule.CIDRIP = strings.TrimSpace(securityGroupStringValue(r.CIDRIP))
+			rule.Description = securityGroupStringValue(r.Description)
+			if rule.CIDRIP == "" {
+				return nil, api.InvalidParameterValueError(param+".IpRanges.CidrIp", "<empty>")
+			}
+			rules = append(rules, rule)
+		}
+		for _, r := range permission.IPv6Ranges {
+			rule := base
+			rule.CIDRIPv6 = strings.TrimSpace(securityGroupStringValue(r.CIDRIPv6))
+			rule.Description = securityGroupStringValue(r.Description)
+			if rule.CIDRIPv6 == "" {
+				return nil, api.InvalidParameterValueError(param+".Ipv6Ranges.CidrIpv6", "<empty>")
+			}
+			rules = append(rules, rule)
+		}
+		for _, p := range permission.PrefixListIDs {
+			rule := base
+			rule.PrefixListID = strings.TrimSpace(securityGroupStringValue(p.PrefixListID))
+			rule.Description = securityGroupStringValue(p.Description)
+			if rule.PrefixListID == "" {
+				return nil, api.InvalidParameterValueError(param+".PrefixListIds.PrefixListId", "<empty>")
+			}
+			rules = append(rules, rule)
+		}
+		for _, pair := range permission.UserIDGroupPairs {
+			groupID, ok := d.resolveSecurityGroupID(pair.GroupID, pair.GroupName)
+			if !ok {
+				return nil, securityGroupNotFoundError(pair.GroupID, pair.GroupName)
+			}
+			rule := base
+			rule.GroupID = groupID
+			rule.Description = securityGroupStringValue(pair.Description)
+			rules = append(rules, rule)
+		}
+		if len(rules) == start {
+			return nil, api.ErrWithCode(
+				"MissingParameter",
+				fmt.Errorf("%s must specify at least one IP range, prefix list or security group", param),
+			)
+		}
+	}
+	return rules, nil
+}
+
+func parseSecurityGroupProtocol(param string, value *string) (string, error) {
+	protocol := strings.ToLower(strings.TrimSpace(securityGroupStringValue(value)))
+	switch protocol {
+	case "":
+		return "", api.ErrWithCode("MissingParameter", fmt.Errorf("the request must contain the parameter %s", param))
+	case "tcp", "udp", "icmp", "icmpv6", securityGroupProtocolAll:
+		return protocol, nil
+	}
+	if n, err := strconv.Atoi(protocol); err == nil && n >= 0 && n <= 255 {
+		return protocol, nil
+	}
+	return "", api.InvalidParameterValueError(param, securityGroupStringValue(value))
+}
+
+// securityGroupRules returns the ingress and egress rules stored in the
+// group attributes. Groups that never had their rules changed use the AWS
+// defaults: all outbound traffic is allowed and, for the default group,
+// all inbound traffic from members of the group too.
+func securityGroupRules(groupID string, attrs storage.Attributes) ([]securityGroupRule, []securityGroupRule, error) {
+	var ingress []securityGroupRule
+	if groupID == defaultSecurityGroupID {
+		ingress = []securityGroupRule{{IPProtocol: securityGroupProtocolAll, GroupID: defaultSecurityGroupID}}
+	}
+	egress := []securityGroupRule{{IPProtocol: securityGroupProtocolAll, CIDRIP: securityGroupAnyIPv4}}
+	for _, target := range []struct {
+		key   string
+		rules *[]securityGroupRule
+	}{
+		{key: attributeNameSecurityGroupIngressRules, rules: &ingress},
+		{key: attributeNameSecurityGroupEgressRules, rules: &egress},
+	} {
+		raw, found := attrs.Key(target.key)
+		if !found {
+			continue
+		}
+		var rules []securityGroupRule
+		if err := json.Unmarshal([]byte(raw), &rules); err != nil {
+			return nil, nil, fmt.Errorf("parsing security group %s %s: %w", groupID, target.key, err)
+		}
+		*target.rules = rules
+	}
+	return ingress, egress, nil
+}
+
+// securityGroupPermissions groups rules sharing protocol and ports into the
+// IpPermissions returned by DescribeSecurityGroups, keeping the order in
+// which they were added.
+func securityGroupPermissions(rules []securityGroupRule) []api.SecurityGroupIPPermission {
+	var permissions []api.SecurityGroupIPPermission
+	var keys []securityGroupRule
+	for _, rule := range rules {
+		key := securityGroupRule{IPProtocol: rule.IPProtocol, FromPort: rule.FromPort, ToPort: rule.ToPort}
+		i := slices.IndexFunc(keys, key.sameRule)
+		if i < 0 {
+			i = len(permissions)
+			keys = append(keys, key)
+			permissions = append(permissions, api.SecurityGroupIPPermission{
+				IPProtocol: new(rule.IPProtocol),
+				FromPort:   rule.FromPort,
+				ToPort:     rule.ToPort,
+			})
+		}
+		permission := &permissions[i]
+		description := optionalString(rule.Description)
+		switch {
+		case rule.CIDRIP != "":
+			permission.IPRanges = append(permission.IPRanges, api.SecurityGroupIPRange{
+				CIDRIP:      new(rule.CIDRIP),
+				Description: description,
+			})
+		case rule.CIDRIPv6 != "":
+			permission.IPv6Ranges = append(permission.IPv6Ranges, api.SecurityGroupIPv6Range{
+				CIDRIPv6:    new(rule.CIDRIPv6),
+				Description: description,
+			})
+		case rule.PrefixListID != "":
+			permission.PrefixListIDs = append(permission.PrefixListIDs, api.SecurityGroupPrefixList{
+				PrefixListID: new(rule.PrefixListID),
+				Description:  description,
+			})
+		case rule.GroupID != "":
+			permission.UserIDGroupPairs = append(permission.UserIDGroupPairs, api.SecurityGroupUserIDGroup{
+				GroupID:     new(rule.GroupID),
+				UserID:      new(defaultSecurityGroupOwnerID),
+				Description: description,
+			})
+		}
+	}
+	return permissions
+}
+
+func securityGroupNotFoundError(groupID *string, groupName *string) error {
+	msg := "The security group does not exist."
+	if groupID != nil && strings.TrimSpace(*groupID) != "" {
+		msg = fmt.Sprintf("The security group '%s' does not exist.", strings.TrimSpace(*groupID))
+	} else if groupName != nil && strings.TrimSpace(*groupName) != "" {
+		msg = fmt.Sprintf("The security group '%s' does not exist.", strings.TrimSpace(*groupName))
+	}
+	return api.ErrWithCode("InvalidGroup.NotFound", fmt.Errorf("%s", msg))
+}
+
+func optionalIntEqual(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
@@ -0,0 +1,192 @@
+package dc2
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+)
+
+func TestSecurityGroupRules(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	d := &Dispatcher{
+		exe:     &exitCleanupExecutor{},
+		storage: storage.NewMemoryStorage(),
+	}
+	createResp, err := d.dispatchCreateSecurityGroup(ctx, &api.CreateSecurityGroupRequest{
+		GroupName:   "web",
+		Description: "web servers",
+	})
+	require.NoError(t, err)
+	groupID := createResp.GroupID
+	describeGroup := func() api.SecurityGroup {
+		resp, err := d.dispatchDescribeSecurityGroups(ctx, &api.DescribeSecurityGroupsRequest{
+			GroupIDs: []string{*groupID},
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.SecurityGroups, 1)
+		return resp.SecurityGroups[0]
+	}
+
+	group := describeGroup()
+	assert.Empty(t, group.IPPermissions)
+	assert.Equal(t, []api.SecurityGroupIPPermission{{
+		IPProtocol: new("-1"),
+		IPRanges:   []api.SecurityGroupIPRange{{CIDRIP: new("0.0.0.0/0")}},
+	}}, group.IPPermissionsEgress)
+
+	sshPermission := api.SecurityGroupIPPermission{
+		IPProtocol: new("tcp"),
+		FromPort:   new(22),
+		ToPort:     new(22),
+		IPRanges: []api.SecurityGroupIPRange{
+			{CIDRIP: new("10.0.0.0/8"), Description: new("internal")},
+			{CIDRIP: new("192.168.0.0/16")},
+		},
+	}
+	_, err = d.dispatchAuthorizeSecurityGroupIngress(ctx, &api.AuthorizeSecurityGroupIngressRequest{
+		GroupID:       groupID,
+		IPPermissions: []api.SecurityGroupIPPermission{sshPermission},
+	})
+	require.NoError(t, err)
+	// Legacy top level fields are merged with the ones for the same ports
+	_, err = d.dispatchAuthorizeSecurityGroupIngress(ctx, &api.AuthorizeSecurityGroupIngressRequest{
+		GroupName:  new("web"),
+		IPProtocol: new("TCP"),
+		FromPort:   new(22),
+		ToPort:     new(22),
+		CIDRIP:     new("172.16.0.0/12"),
+	})
+	require.NoError(t, err)
+	_, err = d.dispatchAuthorizeSecurityGroupIngress(ctx, &api.AuthorizeSecurityGroupIngressRequest{
+		GroupID: groupID,
+		IPPermissions: []api.SecurityGroupIPPermission{{
+			IPProtocol:       new("-1"),
+			UserIDGroupPairs: []api.SecurityGroupUserIDGroup{{GroupID: groupID}},
+		}},
+	})
+	require.NoError(t, err)
+
+	group = describeGroup()
+	assert.Equal(t, []api.SecurityGroupIPPermission{
+		{
+			IPProtocol: new("tcp"),
+			FromPort:   new(22),
+			ToPort:     new(22),
+			IPRanges: []api.SecurityGroupIPRange{
+				{CIDRIP: new("10.0.0.0/8"), Description: new("internal")},
+				{CIDRIP: new("192.168.0.0/16")},
+				{CIDRIP: new("172.16.0.0/12")},
+			},
+		},
+		{
+			IPProtocol: new("-1"),
+			UserIDGroupPairs: []api.SecurityGroupUserIDGroup{
+				{GroupID: groupID, UserID: new(defaultSecurityGroupOwnerID)},
+			},
+		},
+	}, group.IPPermissions)
+
+	_, err = d.dispatchAuthorizeSecurityGroupIngress(ctx, &api.AuthorizeSecurityGroupIngressRequest{
+		GroupID:       groupID,
+		IPPermissions: []api.SecurityGroupIPPermission{sshPermission},
+	})
+	var apiErr *api.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "InvalidPermission.Duplicate", apiErr.Code)
+
+	_, err = d.dispatchRevokeSecurityGroupIngress(ctx, &api.RevokeSecurityGroupIngressRequest{
+		GroupID:       groupID,
+		IPPermissions: []api.SecurityGroupIPPermission{sshPermission},
+	})
+	require.NoError(t, err)
+	group = describeGroup()
+	require.Len(t, group.IPPermissions, 2)
+	assert.Equal(t, []api.SecurityGroupIPRange{{CIDRIP: new("172.16.0.0/12")}}, group.IPPermissions[0].IPRanges)
+
+	_, err = d.dispatchRevokeSecurityGroupIngress(ctx, &api.RevokeSecurityGroupIngressRequest{
+		GroupID:       groupID,
+		IPPermissions: []api.SecurityGroupIPPermission{sshPermission},
+	})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "InvalidPermission.NotFound", apiErr.Code)
+
+	_, err = d.dispatchRevokeSecurityGroupEgress(ctx, &api.RevokeSecurityGroupEgressRequest{
+		GroupID: groupID,
+		IPPermissions: []api.SecurityGroupIPPermission{{
+			IPProtocol: new("-1"),
+			IPRanges:   []api.SecurityGroupIPRange{{CIDRIP: new("0.0.0.0/0")}},
+		}},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, describeGroup().IPPermissionsEgress)
+
+	for _, permission := range []api.SecurityGroupIPPermission{
+		{IPRanges: []api.SecurityGroupIPRange{{CIDRIP: new("10.0.0.0/8")}}},
+		{IPProtocol: new("sctp"), IPRanges: []api.SecurityGroupIPRange{{CIDRIP: new("10.0.0.0/8")}}},
+		{IPProtocol: new("tcp"), FromPort: new(80), ToPort: new(80)},
+		{IPProtocol: new("tcp"), UserIDGroupPairs: []api.SecurityGroupUserIDGroup{{GroupID: new("sg-missing")}}},
+	} {
+		_, err = d.dispatchAuthorizeSecurityGroupEgress(ctx, &api.AuthorizeSecurityGroupEgressRequest{
+			GroupID:       groupID,
+			IPPermissions: []api.SecurityGroupIPPermission{permission},
+		})
+		require.Error(t, err)
+	}
+}
+
+func TestDefaultSecurityGroupRulesAreRestored(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	statePath := filepath.Join(t.TempDir(), "state.json")
+
+	store, _, err := openStorage(statePath)
+	require.NoError(t, err)
+	first := &Dispatcher{
+		exe:     &exitCleanupExecutor{},
+		imds:    &imdsController{},
+		storage: store,
+	}
+	resp, err := first.dispatchDescribeSecurityGroups(ctx, &api.DescribeSecurityGroupsRequest{})
+	require.NoError(t, err)
+	require.Len(t, resp.SecurityGroups, 1)
+	assert.Equal(t, []api.SecurityGroupIPPermission{{
+		IPProtocol: new("-1"),
+		UserIDGroupPairs: []api.SecurityGroupUserIDGroup{
+			{GroupID: new(defaultSecurityGroupID), UserID: new(defaultSecurityGroupOwnerID)},
+		},
+	}}, resp.SecurityGroups[0].IPPermissions)
+
+	_, err = first.dispatchAuthorizeSecurityGroupIngress(ctx, &api.AuthorizeSecurityGroupIngressRequest{
+		GroupName:  new(defaultSecurityGroupName),
+		IPProtocol: new("tcp"),
+		FromPort:   new(443),
+		ToPort:     new(443),
+		CIDRIP:     new("0.0.0.0/0"),
+	})
+	require.NoError(t, err)
+
+	store, _, err = openStorage(statePath)
+	require.NoError(t, err)
+	second := &Dispatcher{
+		exe:     &exitCleanupExecutor{},
+		imds:    &imdsController{},
+		storage: store,
+	}
+	require.NoError(t, second.restoreState(ctx))
+	resp, err = second.dispatchDescribeSecurityGroups(ctx, &api.DescribeSecurityGroupsRequest{})
+	require.NoError(t, err)
+	require.Len(t, resp.SecurityGroups, 1)
+	permissions := resp.SecurityGroups[0].IPPermissions
+	require.Len(t, permissions, 2)
+	assert.Equal(t, new(443), permissions[1].FromPort)
+	assert.Equal(t, []api.SecurityGroupIPRange{{CIDRIP: new("0.0.0.0/0")}}, permissions[1].IPRanges)
+}
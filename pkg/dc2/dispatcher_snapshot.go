@@ -0,0 +1,300 @@
+package dc2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/executor"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+const (
+	attributeNameSnapshotVolumeID    = "SnapshotVolumeID"
+	attributeNameSnapshotVolumeSize  = "SnapshotVolumeSize"
+	attributeNameSnapshotDescription = "SnapshotDescription"
+	attributeNameSnapshotStartTime   = "SnapshotStartTime"
+
+	snapshotIDPrefix = "snap-"
+	// copiedSnapshotVolumeID is the volume ID EC2 reports for snapshots
+	// created by CopySnapshot.
+	copiedSnapshotVolumeID = "vol-ffffffff"
+
+	snapshotProgressCompleted = "100%"
+)
+
+func (d *Dispatcher) dispatchCreateSnapshot(ctx context.Context, req *api.CreateSnapshotRequest) (*api.CreateSnapshotResponse, error) {
+	if err := validateTagSpecifications(req.TagSpecifications, types.ResourceTypeSnapshot); err != nil {
+		return nil, err
+	}
+	volume, err := d.describeVolume(ctx, req.VolumeID)
+	if err != nil {
+		return nil, err
+	}
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
+
+	startTime := time.Now().UTC()
+	snapID, err := d.exe.CreateSnapshot(ctx, executor.CreateSnapshotRequest{VolumeID: executorVolumeID(req.VolumeID)})
+	if err != nil {
+		return nil, executorError(err)
+	}
+	id := snapshotIDPrefix + string(snapID)
+
+	attrs := []storage.Attribute{
+		{Key: attributeNameSnapshotVolumeID, Value: req.VolumeID},
+		{Key: attributeNameSnapshotVolumeSize, Value: strconv.Itoa(*volume.Size)},
+		{Key: attributeNameSnapshotStartTime, Value: startTime.Format(time.RFC3339Nano)},
+		{Key: attributeNameEncrypted, Value: strconv.FormatBool(*volume.Encrypted)},
+	}
+	if req.Description != "" {
+		attrs = append(attrs, storage.Attribute{Key: attributeNameSnapshotDescription, Value: req.Description})
+	}
+	for _, spec := range req.TagSpecifications {
+		for _, tag := range spec.Tags {
+			attrs = append(attrs, storage.Attribute{Key: storage.TagAttributeName(tag.Key), Value: tag.Value})
+		}
+	}
+	if err := d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeSnapshot, ID: id}); err != nil {
+		return nil, fmt.Errorf("registering snapshot %s: %w", id, err)
+	}
+	if err := d.storage.SetResourceAttributes(id, attrs); err != nil {
+		return nil, fmt.Errorf("storing snapshot attributes: %w", err)
+	}
+	api.Logger(ctx).Info(
+		"created snapshot",
+		slog.String("snapshot_id", id),
+		slog.String("volume_id", req.VolumeID),
+		slog.Int("volume_size_gib", *volume.Size),
+	)
+	snapshot, err := d.describeSnapshot(id)
+	if err != nil {
+		return nil, err
+	}
+	return &api.CreateSnapshotResponse{Snapshot: snapshot}, nil
+}
+
+func (d *Dispatcher) dispatchCopySnapshot(ctx context.Context, req *api.CopySnapshotRequest) (*api.CopySnapshotResponse, error) {
+	if err := validateTagSpecifications(req.TagSpecifications, types.ResourceTypeSnapshot); err != nil {
+		return nil, err
+	}
+	if req.SourceRegion != d.opts.Region {
+		return nil, api.InvalidParameterValueError("SourceRegion", req.SourceRegion)
+	}
+	if req.DestinationRegion != "" && req.DestinationRegion != d.opts.Region {
+		return nil, api.InvalidParameterValueError("DestinationRegion", req.DestinationRegion)
+	}
+	source, err := d.findSnapshot(ctx, req.SourceSnapshotID)
+	if err != nil {
+		return nil, err
+	}
+	sourceSnapshot, err := d.describeSnapshot(source.ID)
+	if err != nil {
+		return nil, err
+	}
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
+
+	startTime := time.Now().UTC()
+	snapID, err := d.exe.CopySnapshot(ctx, executor.CopySnapshotRequest{SnapshotID: executorSnapshotID(source.ID)})
+	if err != nil {
+		return nil, executorError(err)
+	}
+	id := snapshotIDPrefix + string(snapID)
+
+	// Copies of encrypted snapshots are always encrypted
+	encrypted := sourceSnapshot.Encrypted || (req.Encrypted != nil && *req.Encrypted)
+	description := req.Description
+	if description == "" {
+		description = fmt.Sprintf("[Copied %s from %s]", source.ID, req.SourceRegion)
+	}
+	attrs := []storage.Attribute{
+		{Key: attributeNameSnapshotVolumeID, Value: copiedSnapshotVolumeID},
+		{Key: attributeNameSnapshotVolumeSize, Value: strconv.Itoa(sourceSnapshot.VolumeSize)},
+		{Key: attributeNameSnapshotStartTime, Value: startTime.Format(time.RFC3339Nano)},
+		{Key: attributeNameEncrypted, Value: strconv.FormatBool(encrypted)},
+		{Key: attributeNameSnapshotDescription, Value: description},
+	}
+	var tags []api.Tag
+	for _, spec := range req.TagSpecifications {
+		for _, tag := range spec.Tags {
+			attrs = append(attrs, storage.Attribute{Key: storage.TagAttributeName(tag.Key), Value: tag.Value})
+			tags = append(tags, tag)
+		}
+	}
+	if err := d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeSnapshot, ID: id}); err != nil {
+		return nil, fmt.Errorf("registering snapshot %s: %w", id, err)
+	}
+	if err := d.storage.SetResourceAttributes(id, attrs); err != nil {
+		return nil, fmt.Errorf("storing snapshot attributes: %w", err)
+	}
+	api.Logger(ctx).Info(
+		"copied snapshot",
+		slog.String("snapshot_id", id),
+		slog.String("source_snapshot_id", source.ID),
+	)
+	return &api.CopySnapshotResponse{SnapshotID: id, Tags: tags}, nil
+}
+
+func (d *Dispatcher) dispatchDeleteSnapshot(ctx context.Context, req *api.DeleteSnapshotRequest) (*api.DeleteSnapshotResponse, error) {
+	snapshot, err := d.findSnapshot(ctx, req.SnapshotID)
+	if err != nil {
+		return nil, err
+	}
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
+	if err := d.exe.DeleteSnapshot(ctx, executor.DeleteSnapshotRequest{SnapshotID: executorSnapshotID(snapshot.ID)}); err != nil {
+		return nil, executorError(err)
+	}
+	if err := d.storage.RemoveResource(snapshot.ID); err != nil {
+		return nil, fmt.Errorf("deleting snapshot from storage: %w", err)
+	}
+	api.Logger(ctx).Info("deleted snapshot", slog.String("snapshot_id", snapshot.ID))
+	return &api.DeleteSnapshotResponse{}, nil
+}
+
+func (d *Dispatcher) dispatchDescribeSnapshots(ctx context.Context, req *api.DescribeSnapshotsRequest) (*api.DescribeSnapshotsResponse, error) {
+	tagFilters, snapshotFilters, err := splitSnapshotFilters(req.Filters)
+	if err != nil {
+		return nil, err
+	}
+	for _, snapshotID := range req.SnapshotIDs {
+		if _, err := d.findSnapshot(ctx, snapshotID); err != nil {
+			return nil, err
+		}
+	}
+	snapshotIDs, err := d.applyFilters(types.ResourceTypeSnapshot, req.SnapshotIDs, tagFilters)
+	if err != nil {
+		return nil, err
+	}
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
+
+	snapshots := make([]api.Snapshot, 0, len(snapshotIDs))
+	for _, id := range snapshotIDs {
+		snapshot, err := d.describeSnapshot(id)
+		if err != nil {
+			return nil, err
+		}
+		if snapshotMatchesFilters(snapshot, snapshotFilters) {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	slices.SortFunc(snapshots, func(a, b api.Snapshot) int {
+		return strings.Compare(a.SnapshotID, b.SnapshotID)
+	})
+
+	snapshots, nextToken, err := applyNextToken(snapshots, req.NextToken, req.MaxResults)
+	if err != nil {
+		return nil, err
+	}
+	return &api.DescribeSnapshotsResponse{
+		Snapshots: snapshots,
+		NextToken: nextToken,
+	}, nil
+}
+
+func (d *Dispatcher) findSnapshot(ctx context.Context, snapshotID string) (*storage.Resource, error) {
+	snapshot, err := d.findResource(ctx, types.ResourceTypeSnapshot, snapshotID)
+	if err != nil {
+		if errors.As(err, &storage.ErrResourceNotFound{}) {
+			return nil, api.ErrWithCode("InvalidSnapshot.NotFound", fmt.Errorf("The snapshot '%s' does not exist.", snapshotID)) //nolint
+		}
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+func (d *Dispatcher) describeSnapshot(snapshotID string) (api.Snapshot, error) {
+	attrs, err := d.storage.ResourceAttributes(snapshotID)
+	if err != nil {
+		return api.Snapshot{}, fmt.Errorf("retrieving snapshot attributes: %w", err)
+	}
+	volumeID, _ := attrs.Key(attributeNameSnapshotVolumeID)
+	volumeSize, err := parseAttr(attrs, attributeNameSnapshotVolumeSize, strconv.Atoi)
+	if err != nil {
+		return api.Snapshot{}, fmt.Errorf("invalid snapshot volume size: %w", err)
+	}
+	startTime, err := parseAttr(attrs, attributeNameSnapshotStartTime, parseTime)
+	if err != nil {
+		return api.Snapshot{}, fmt.Errorf("invalid snapshot start time: %w", err)
+	}
+	encrypted, err := parseAttr(attrs, attributeNameEncrypted, strconv.ParseBool)
+	if err != nil {
+		return api.Snapshot{}, fmt.Errorf("invalid snapshot encrypted attribute: %w", err)
+	}
+	description, _ := attrs.Key(attributeNameSnapshotDescription)
+	var tags []api.Tag
+	for _, attr := range attrs {
+		if attr.IsTag() {
+			tags = append(tags, api.Tag{Key: attr.TagKey(), Value: attr.Value})
+		}
+	}
+	// Snapshots are copied synchronously, so they're complete as soon as
+	// they're created.
+	return api.Snapshot{
+		SnapshotID:  snapshotID,
+		VolumeID:    volumeID,
+		State:       types.SnapshotStateCompleted,
+		StartTime:   startTime,
+		Progress:    snapshotProgressCompleted,
+		OwnerID:     defaultSecurityGroupOwnerID,
+		Description: description,
+		VolumeSize:  volumeSize,
+		Encrypted:   encrypted,
+		Tags:        tags,
+	}, nil
+}
+
+func splitSnapshotFilters(filters []api.Filter) ([]api.Filter, []api.Filter, error) {
+	var tagFilters, snapshotFilters []api.Filter
+	for _, filter := range filters {
+		if filter.Name == nil {
+			return nil, nil, api.InvalidParameterValueError("Filter.Name", "<missing>")
+		}
+		switch name := *filter.Name; {
+		case strings.HasPrefix(name, "tag:"), name == "tag-key":
+			tagFilters = append(tagFilters, filter)
+		case name == "snapshot-id", name == "volume-id", name == "status", name == "volume-size":
+			snapshotFilters = append(snapshotFilters, filter)
+		default:
+			return nil, nil, api.InvalidParameterValueError("Filter.Name", name)
+		}
+	}
+	return tagFilters, snapshotFilters, nil
+}
+
+func snapshotMatchesFilters(snapshot api.Snapshot, filters []api.Filter) bool {
+	for _, filter := range filters {
+		var value string
+		switch *filter.Name {
+		case "snapshot-id":
+			value = snapshot.SnapshotID
+		case "volume-id":
+			value = snapshot.VolumeID
+		case "status":
+			value = string(snapshot.State)
+		case "volume-size":
+			value = strconv.Itoa(snapshot.VolumeSize)
+		}
+		if !slices.Contains(filter.Values, value) {
+			return false
+		}
+	}
+	return true
+}
+
+func executorSnapshotID(snapshotID string) executor.SnapshotID {
+	return executor.SnapshotID(strings.TrimPrefix(snapshotID, snapshotIDPrefix))
+}
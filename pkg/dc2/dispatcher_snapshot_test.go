@@ -0,0 +1,174 @@
+package dc2
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/executor"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+func TestDescribeSnapshots(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	d := &Dispatcher{
+		exe:     &exitCleanupExecutor{},
+		storage: storage.NewMemoryStorage(),
+	}
+	snapshots := []struct {
+		id       string
+		volumeID string
+		size     string
+	}{
+		{id: "snap-00000000000000001", volumeID: "vol-00000000000000001", size: "1"},
+		{id: "snap-00000000000000002", volumeID: "vol-00000000000000001", size: "1"},
+		{id: "snap-00000000000000003", volumeID: "vol-00000000000000002", size: "8"},
+	}
+	for _, s := range snapshots {
+		require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeSnapshot, ID: s.id}))
+		require.NoError(t, d.storage.SetResourceAttributes(s.id, []storage.Attribute{
+			{Key: attributeNameSnapshotVolumeID, Value: s.volumeID},
+			{Key: attributeNameSnapshotVolumeSize, Value: s.size},
+			{Key: attributeNameSnapshotStartTime, Value: time.Now().UTC().Format(time.RFC3339Nano)},
+			{Key: attributeNameEncrypted, Value: "false"},
+		}))
+	}
+	snapshotIDs := func(resp *api.DescribeSnapshotsResponse) []string {
+		var ids []string
+		for _, s := range resp.Snapshots {
+			ids = append(ids, s.SnapshotID)
+		}
+		return ids
+	}
+
+	resp, err := d.dispatchDescribeSnapshots(ctx, &api.DescribeSnapshotsRequest{
+		Filters: []api.Filter{{Name: new("volume-id"), Values: []string{"vol-00000000000000001"}}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"snap-00000000000000001", "snap-00000000000000002"}, snapshotIDs(resp))
+	assert.Equal(t, types.SnapshotStateCompleted, resp.Snapshots[0].State)
+
+	resp, err = d.dispatchDescribeSnapshots(ctx, &api.DescribeSnapshotsRequest{
+		Filters: []api.Filter{{Name: new("volume-size"), Values: []string{"8"}}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"snap-00000000000000003"}, snapshotIDs(resp))
+
+	resp, err = d.dispatchDescribeSnapshots(ctx, &api.DescribeSnapshotsRequest{
+		PaginableRequest: api.PaginableRequest{MaxResults: new(2)},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"snap-00000000000000001", "snap-00000000000000002"}, snapshotIDs(resp))
+	require.NotNil(t, resp.NextToken)
+	resp, err = d.dispatchDescribeSnapshots(ctx, &api.DescribeSnapshotsRequest{
+		PaginableRequest: api.PaginableRequest{MaxResults: new(2), NextToken: resp.NextToken},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"snap-00000000000000003"}, snapshotIDs(resp))
+	assert.Nil(t, resp.NextToken)
+
+	_, err = d.dispatchDescribeSnapshots(ctx, &api.DescribeSnapshotsRequest{
+		SnapshotIDs: []string{"snap-0000000000000000f"},
+	})
+	var apiErr *api.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "InvalidSnapshot.NotFound", apiErr.Code)
+
+	_, err = d.dispatchDescribeSnapshots(ctx, &api.DescribeSnapshotsRequest{
+		Filters: []api.Filter{{Name: new("owner-alias"), Values: []string{"amazon"}}},
+	})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, api.ErrorCodeInvalidParameterValue, apiErr.Code)
+}
+
+// copySnapshotExecutor records the snapshots copied by CopySnapshot.
+type copySnapshotExecutor struct {
+	*exitCleanupExecutor
+	copies map[executor.SnapshotID]executor.SnapshotID
+}
+
+func (e *copySnapshotExecutor) CopySnapshot(_ context.Context, req executor.CopySnapshotRequest) (executor.SnapshotID, error) {
+	id := executor.SnapshotID(fmt.Sprintf("%017x", len(e.copies)+0xa0))
+	e.copies[id] = req.SnapshotID
+	return id, nil
+}
+
+func TestCopySnapshot(t *testing.T) {
+	t.Parallel()
+
+	const sourceID = "snap-00000000000000001"
+	ctx := t.Context()
+	exe := &copySnapshotExecutor{
+		exitCleanupExecutor: &exitCleanupExecutor{},
+		copies:              make(map[executor.SnapshotID]executor.SnapshotID),
+	}
+	d := &Dispatcher{
+		opts:    DispatcherOptions{Region: "us-east-1"},
+		exe:     exe,
+		storage: storage.NewMemoryStorage(),
+	}
+	require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeSnapshot, ID: sourceID}))
+	require.NoError(t, d.storage.SetResourceAttributes(sourceID, []storage.Attribute{
+		{Key: attributeNameSnapshotVolumeID, Value: "vol-00000000000000001"},
+		{Key: attributeNameSnapshotVolumeSize, Value: "8"},
+		{Key: attributeNameSnapshotStartTime, Value: time.Now().UTC().Format(time.RFC3339Nano)},
+		{Key: attributeNameEncrypted, Value: "false"},
+		{Key: storage.TagAttributeName("Source"), Value: "true"},
+	}))
+
+	resp, err := d.dispatchCopySnapshot(ctx, &api.CopySnapshotRequest{
+		SourceSnapshotID: sourceID,
+		SourceRegion:     "us-east-1",
+		TagSpecifications: []api.TagSpecification{
+			{ResourceType: types.ResourceTypeSnapshot, Tags: []api.Tag{{Key: "Copy", Value: "true"}}},
+		},
+	})
+	require.NoError(t, err)
+	assert.Regexp(t, `^snap-[0-9a-f]{17}$`, resp.SnapshotID)
+	assert.Equal(t, []api.Tag{{Key: "Copy", Value: "true"}}, resp.Tags)
+	assert.Equal(t, executorSnapshotID(sourceID), exe.copies[executorSnapshotID(resp.SnapshotID)])
+
+	describeResp, err := d.dispatchDescribeSnapshots(ctx, &api.DescribeSnapshotsRequest{SnapshotIDs: []string{resp.SnapshotID}})
+	require.NoError(t, err)
+	require.Len(t, describeResp.Snapshots, 1)
+	snapshot := describeResp.Snapshots[0]
+	assert.Equal(t, 8, snapshot.VolumeSize)
+	assert.Equal(t, copiedSnapshotVolumeID, snapshot.VolumeID)
+	assert.Equal(t, "[Copied "+sourceID+" from us-east-1]", snapshot.Description)
+	assert.False(t, snapshot.Encrypted)
+	assert.Equal(t, types.SnapshotStateCompleted, snapshot.State)
+	assert.Equal(t, []api.Tag{{Key: "Copy", Value: "true"}}, snapshot.Tags)
+
+	// Copies are independent snapshots
+	encrypted, err := d.dispatchCopySnapshot(ctx, &api.CopySnapshotRequest{
+		SourceSnapshotID: resp.SnapshotID,
+		SourceRegion:     "us-east-1",
+		Description:      "encrypted copy",
+		Encrypted:        new(true),
+	})
+	require.NoError(t, err)
+	_, err = d.dispatchDeleteSnapshot(ctx, &api.DeleteSnapshotRequest{SnapshotID: resp.SnapshotID})
+	require.NoError(t, err)
+	describeResp, err = d.dispatchDescribeSnapshots(ctx, &api.DescribeSnapshotsRequest{SnapshotIDs: []string{encrypted.SnapshotID}})
+	require.NoError(t, err)
+	require.Len(t, describeResp.Snapshots, 1)
+	assert.Equal(t, "encrypted copy", describeResp.Snapshots[0].Description)
+	assert.True(t, describeResp.Snapshots[0].Encrypted)
+	assert.Equal(t, 8, describeResp.Snapshots[0].VolumeSize)
+
+	var apiErr *api.Error
+	_, err = d.dispatchCopySnapshot(ctx, &api.CopySnapshotRequest{SourceSnapshotID: resp.SnapshotID, SourceRegion: "us-east-1"})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "InvalidSnapshot.NotFound", apiErr.Code)
+	_, err = d.dispatchCopySnapshot(ctx, &api.CopySnapshotRequest{SourceSnapshotID: sourceID, SourceRegion: "eu-west-1"})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, api.ErrorCodeInvalidParameterValue, apiErr.Code)
+}
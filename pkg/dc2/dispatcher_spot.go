@@ -25,6 +25,9 @@ const (
 	spotInterruptionBehaviorStop      = "stop"
 	spotInterruptionBehaviorHibernate = "hibernate"
 
+	// AWS gives spot instances a two-minute interruption notice
+	spotInterruptionNotice = 2 * time.Minute
+
 	attributeNameInstanceMarketType = "InstanceMarketType"
 	attributeNameSpotRequestID      = "SpotInstanceRequestID"
 	attributeNameSpotMaxPrice       = "SpotMaxPrice"
@@ -44,9 +47,10 @@ const (
 	stateReasonSpotTerminationCode    = "Server.SpotInstanceTermination"
 	stateReasonSpotTerminationMessage = "Server.SpotInstanceTermination: Instance terminated due to spot interruption"
 
-	spotRequestTypeOneTime = "one-time"
-	spotRequestStateActive = "active"
-	spotRequestStateClosed = "closed"
+	spotRequestTypeOneTime    = "one-time"
+	spotRequestStateActive    = "active"
+	spotRequestStateClosed    = "closed"
+	spotRequestStateCancelled = "cancelled"
 
 	spotRequestStatusFulfilledCode         = "fulfilled"
 	spotRequestStatusFulfilledMessage      = "Your spot request is fulfilled."
@@ -56,6 +60,8 @@ const (
 	spotRequestStatusNoCapacityMessage     = "Instance terminated by simulated capacity interruption."
 	spotRequestStatusServiceTerminatedCode = "instance-terminated-by-service"
 	spotRequestStatusServiceTerminatedMsg  = "Instance terminated by service."
+	spotRequestStatusCancelledCode         = "request-canceled-and-instance-running"
+	spotRequestStatusCancelledMessage      = "Spot Instance request was canceled and the instance is still running."
 )
 
 func normalizeMarketType(raw string) (string, error) {
@@ -220,9 +226,22 @@ func (d *Dispatcher) closeSpotRequestForInstance(instanceID string, code string,
 	if !ok || spotRequestID == "" {
 		return nil
 	}
+	requestAttrs, err := d.storage.ResourceAttributes(spotRequestID)
+	if err != nil {
+		if errors.As(err, &storage.ErrResourceNotFound{}) {
+			return nil
+		}
+		return fmt.Errorf("retrieving spot request attributes for %s: %w", spotRequestID, err)
+	}
+	// A cancelled request stays cancelled, only its status reflects the
+	// instance termination.
+	state := spotRequestStateClosed
+	if attrOrDefault(requestAttrs, attributeNameSpotRequestState, spotRequestStateActive) == spotRequestStateCancelled {
+		state = spotRequestStateCancelled
+	}
 	now := time.Now().UTC().Format(time.RFC3339Nano)
 	if err := d.storage.SetResourceAttributes(spotRequestID, []storage.Attribute{
-		{Key: attributeNameSpotRequestState, Value: spotRequestStateClosed},
+		{Key: attributeNameSpotRequestState, Value: state},
 		{Key: attributeNameSpotRequestStatusCode, Value: code},
 		{Key: attributeNameSpotRequestStatusMessage, Value: message},
 		{Key: attributeNameSpotRequestStatusUpdatedAt, Value: now},
@@ -235,6 +254,89 @@ func (d *Dispatcher) closeSpotRequestForInstance(instanceID string, code string,
 	return nil
 }
 
+// dispatchRequestSpotInstances launches one spot instance per requested
+// instance through the RunInstances spot path. Capacity is always available,
+// so requests move from open to active within the call and are returned
+// already fulfilled.
+func (d *Dispatcher) dispatchRequestSpotInstances(ctx context.Context, req *api.RequestSpotInstancesRequest) (*api.RequestSpotInstancesResponse, error) {
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
+	if spotType := strings.TrimSpace(req.Type); spotType != "" && spotType != spotRequestTypeOneTime {
+		return nil, api.InvalidParameterValueError("Type", req.Type)
+	}
+	count := 1
+	if req.InstanceCount != nil {
+		if *req.InstanceCount < 1 {
+			return nil, api.InvalidParameterValueError("InstanceCount", strconv.Itoa(*req.InstanceCount))
+		}
+		count = *req.InstanceCount
+	}
+	launchSpec := req.LaunchSpecification
+	if launchSpec.ImageID == "" {
+		return nil, api.InvalidParameterValueError("LaunchSpecification.ImageId", "")
+	}
+	if launchSpec.InstanceType == "" {
+		return nil, api.InvalidParameterValueError("LaunchSpecification.InstanceType", "")
+	}
+	for i, spec := range req.TagSpecifications {
+		if spec.ResourceType != types.ResourceTypeSpotInstancesRequest {
+			return nil, api.InvalidParameterValueError(
+				fmt.Sprintf("TagSpecification.%d.ResourceType", i+1),
+				string(spec.ResourceType),
+			)
+		}
+	}
+
+	runResp, err := d.dispatchRunInstances(ctx, &api.RunInstancesRequest{
+		CommonRequest:       req.CommonRequest,
+		ImageID:             launchSpec.ImageID,
+		InstanceType:        launchSpec.InstanceType,
+		KeyName:             launchSpec.KeyName,
+		SecurityGroupIDs:    launchSpec.SecurityGroupIDs,
+		SecurityGroups:      launchSpec.SecurityGroups,
+		UserData:            launchSpec.UserData,
+		SubnetID:            launchSpec.SubnetID,
+		Placement:           launchSpec.Placement,
+		BlockDeviceMappings: launchSpec.BlockDeviceMappings,
+		MinCount:            count,
+		MaxCount:            count,
+		InstanceMarketOptions: &api.RunInstancesInstanceMarketOptions{
+			MarketType: instanceMarketTypeSpot,
+			SpotOptions: &api.RunInstancesSpotOptions{
+				MaxPrice:                     req.SpotPrice,
+				InstanceInterruptionBehavior: req.InstanceInterruptionBehavior,
+			},
+		},
+		TagSpecifications: req.TagSpecifications,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	spotRequests := make([]api.SpotInstanceRequest, 0, len(runResp.InstancesSet))
+	for _, instance := range runResp.InstancesSet {
+		instanceAttrs, err := d.storage.ResourceAttributes(instance.InstanceID)
+		if err != nil {
+			return nil, fmt.Errorf("retrieving instance attributes for %s: %w", instance.InstanceID, err)
+		}
+		requestID, ok := instanceAttrs.Key(attributeNameSpotRequestID)
+		if !ok || requestID == "" {
+			return nil, fmt.Errorf("instance %s has no spot request", instance.InstanceID)
+		}
+		attrs, err := d.storage.ResourceAttributes(requestID)
+		if err != nil {
+			return nil, fmt.Errorf("retrieving spot request attributes for %s: %w", requestID, err)
+		}
+		spotRequest, err := apiSpotRequestFromStorage(requestID, attrs)
+		if err != nil {
+			return nil, err
+		}
+		spotRequests = append(spotRequests, spotRequest)
+	}
+	return &api.RequestSpotInstancesResponse{SpotInstanceRequests: spotRequests}, nil
+}
+
 func (d *Dispatcher) dispatchDescribeSpotInstanceRequests(_ context.Context, req *api.DescribeSpotInstanceRequestsRequest) (*api.DescribeSpotInstanceRequestsResponse, error) {
 	if req.DryRun {
 		return nil, api.DryRunError()
@@ -281,6 +383,48 @@ func (d *Dispatcher) dispatchDescribeSpotInstanceRequests(_ context.Context, req
 	}, nil
 }
 
+// dispatchCancelSpotInstanceRequests cancels active spot requests. Like AWS,
+// fulfilled instances keep running and must be terminated separately.
+func (d *Dispatcher) dispatchCancelSpotInstanceRequests(ctx context.Context, req *api.CancelSpotInstanceRequestsRequest) (*api.CancelSpotInstanceRequestsResponse, error) {
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
+	for _, requestID := range req.SpotInstanceRequestIDs {
+		if _, err := d.findResource(ctx, types.ResourceTypeSpotInstancesRequest, requestID); err != nil {
+			if errors.As(err, &storage.ErrResourceNotFound{}) {
+				return nil, api.ErrWithCode("InvalidSpotInstanceRequestID.NotFound", fmt.Errorf("The spot instance request ID '%s' does not exist", requestID)) //nolint
+			}
+			return nil, err
+		}
+	}
+	cancelled := make([]api.CancelledSpotInstanceRequest, 0, len(req.SpotInstanceRequestIDs))
+	for _, requestID := range req.SpotInstanceRequestIDs {
+		attrs, err := d.storage.ResourceAttributes(requestID)
+		if err != nil {
+			return nil, fmt.Errorf("retrieving spot request attributes for %s: %w", requestID, err)
+		}
+		state := attrOrDefault(attrs, attributeNameSpotRequestState, spotRequestStateActive)
+		if state == spotRequestStateActive {
+			state = spotRequestStateCancelled
+			now := time.Now().UTC().Format(time.RFC3339Nano)
+			if err := d.storage.SetResourceAttributes(requestID, []storage.Attribute{
+				{Key: attributeNameSpotRequestState, Value: state},
+				{Key: attributeNameSpotRequestStatusCode, Value: spotRequestStatusCancelledCode},
+				{Key: attributeNameSpotRequestStatusMessage, Value: spotRequestStatusCancelledMessage},
+				{Key: attributeNameSpotRequestStatusUpdatedAt, Value: now},
+			}); err != nil {
+				return nil, fmt.Errorf("setting spot request cancel state for %s: %w", requestID, err)
+			}
+			api.Logger(ctx).Info("cancelled spot instance request", slog.String("spot_instance_request_id", requestID))
+		}
+		cancelled = append(cancelled, api.CancelledSpotInstanceRequest{
+			SpotInstanceRequestID: requestID,
+			State:                 state,
+		})
+	}
+	return &api.CancelSpotInstanceRequestsResponse{CancelledSpotInstanceRequests: cancelled}, nil
+}
+
 func splitSpotRequestFilters(filters []api.Filter) ([]api.Filter, []api.Filter, error) {
 	tagFilters := make([]api.Filter, 0, len(filters))
 	reqFilters := make([]api.Filter, 0, len(filters))
@@ -528,6 +672,42 @@ func (d *Dispatcher) scheduleSpotReclaim(instanceID string, plan spotReclaimPlan
 	}()
 }
 
+// InterruptInstance simulates a spot interruption for the given instance: the
+// IMDS instance-action document is published right away and the instance is
+// reclaimed, according to its interruption behavior, once the notice window
+// (two minutes when zero) elapses. Instances in an Auto Scaling group are
+// replaced by the next reconciliation. It returns the reclaim time.
+func (d *Dispatcher) InterruptInstance(ctx context.Context, instanceID string, notice time.Duration) (time.Time, error) {
+	if notice < 0 {
+		return time.Time{}, fmt.Errorf("invalid interruption notice %s", notice)
+	}
+	if notice == 0 {
+		notice = spotInterruptionNotice
+	}
+
+	d.dispatchMu.Lock()
+	defer d.dispatchMu.Unlock()
+
+	if _, err := d.findInstance(ctx, instanceID); err != nil {
+		return time.Time{}, err
+	}
+	running, err := d.withoutTerminatedInstances([]string{instanceID})
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(running) == 0 {
+		return time.Time{}, api.ErrWithCode(api.ErrorCodeIncorrectInstanceState, fmt.Errorf("instance %s is terminated", instanceID))
+	}
+	d.scheduleSpotReclaim(instanceID, spotReclaimPlan{After: notice, Notice: notice})
+	reclaimAt := time.Now().UTC().Add(notice)
+	api.Logger(ctx).Info(
+		"scheduled spot interruption",
+		slog.String("instance_id", instanceID),
+		slog.Time("reclaim_at", reclaimAt),
+	)
+	return reclaimAt, nil
+}
+
 func (d *Dispatcher) cancelSpotReclaim(instanceID string) {
 	d.spotReclaimMu.Lock()
 	defer d.spotReclaimMu.Unlock()
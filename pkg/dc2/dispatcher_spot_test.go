@@ -1,6 +1,7 @@
 package dc2
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -8,7 +9,9 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
 	"github.com/fiam/dc2/pkg/dc2/testprofile"
+	"github.com/fiam/dc2/pkg/dc2/types"
 )
 
 func TestNormalizeMarketType(t *testing.T) {
@@ -182,3 +185,149 @@ func TestResolveRunInstancesSpotOptions(t *testing.T) {
 		assert.Contains(t, err.Error(), "InstanceMarketOptions")
 	})
 }
+
+func TestCancelSpotInstanceRequestsKeepsStateOnTermination(t *testing.T) {
+	t.Parallel()
+
+	const instanceID = "i-00000000000000001"
+	ctx := context.Background()
+	d := &Dispatcher{storage: storage.NewMemoryStorage()}
+	requestID, err := d.registerSpotRequestForInstance(instanceID, "c6i.large", spotLaunchOptions{
+		InterruptionBehavior: spotInterruptionBehaviorTerminate,
+	}, nil)
+	require.NoError(t, err)
+	require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeInstance, ID: instanceID}))
+	require.NoError(t, d.storage.SetResourceAttributes(instanceID, []storage.Attribute{
+		{Key: attributeNameSpotRequestID, Value: requestID},
+	}))
+
+	cancelResp, err := d.dispatchCancelSpotInstanceRequests(ctx, &api.CancelSpotInstanceRequestsRequest{
+		SpotInstanceRequestIDs: []string{requestID},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []api.CancelledSpotInstanceRequest{
+		{SpotInstanceRequestID: requestID, State: spotRequestStateCancelled},
+	}, cancelResp.CancelledSpotInstanceRequests)
+
+	describeResp, err := d.dispatchDescribeSpotInstanceRequests(ctx, &api.DescribeSpotInstanceRequestsRequest{
+		SpotInstanceRequestIDs: []string{requestID},
+	})
+	require.NoError(t, err)
+	require.Len(t, describeResp.SpotInstanceRequests, 1)
+	assert.Equal(t, spotRequestStateCancelled, describeResp.SpotInstanceRequests[0].State)
+	assert.Equal(t, spotRequestStatusCancelledCode, describeResp.SpotInstanceRequests[0].Status.Code)
+	assert.Equal(t, instanceID, *describeResp.SpotInstanceRequests[0].InstanceID)
+
+	require.NoError(t, d.closeSpotRequestForInstance(instanceID, spotRequestStatusByUserCode, spotRequestStatusByUserMessage))
+	describeResp, err = d.dispatchDescribeSpotInstanceRequests(ctx, &api.DescribeSpotInstanceRequestsRequest{
+		SpotInstanceRequestIDs: []string{requestID},
+	})
+	require.NoError(t, err)
+	require.Len(t, describeResp.SpotInstanceRequests, 1)
+	assert.Equal(t, spotRequestStateCancelled, describeResp.SpotInstanceRequests[0].State)
+	assert.Equal(t, spotRequestStatusByUserCode, describeResp.SpotInstanceRequests[0].Status.Code)
+
+	// Cancelling again is a no-op that reports the current state.
+	cancelResp, err = d.dispatchCancelSpotInstanceRequests(ctx, &api.CancelSpotInstanceRequestsRequest{
+		SpotInstanceRequestIDs: []string{requestID},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, spotRequestStateCancelled, cancelResp.CancelledSpotInstanceRequests[0].State)
+
+	_, err = d.dispatchCancelSpotInstanceRequests(ctx, &api.CancelSpotInstanceRequestsRequest{
+		SpotInstanceRequestIDs: []string{"sir-missing"},
+	})
+	var apiErr *api.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "InvalidSpotInstanceRequestID.NotFound", apiErr.Code)
+}
+
+func TestRequestSpotInstancesValidation(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	d := &Dispatcher{storage: storage.NewMemoryStorage()}
+	launchSpec := &api.RequestSpotLaunchSpecification{ImageID: "nginx", InstanceType: "c6i.large"}
+	testCases := []struct {
+		name      string
+		req       *api.RequestSpotInstancesRequest
+		parameter string
+	}{
+		{
+			name:      "persistent requests",
+			req:       &api.RequestSpotInstancesRequest{Type: "persistent", LaunchSpecification: launchSpec},
+			parameter: "Type",
+		},
+		{
+			name:      "zero instance count",
+			req:       &api.RequestSpotInstancesRequest{InstanceCount: new(0), LaunchSpecification: launchSpec},
+			parameter: "InstanceCount",
+		},
+		{
+			name:      "missing image",
+			req:       &api.RequestSpotInstancesRequest{LaunchSpecification: &api.RequestSpotLaunchSpecification{InstanceType: "c6i.large"}},
+			parameter: "LaunchSpecification.ImageId",
+		},
+		{
+			name: "instance tags",
+			req: &api.RequestSpotInstancesRequest{
+				LaunchSpecification: launchSpec,
+				TagSpecifications:   []api.TagSpecification{{ResourceType: types.ResourceTypeInstance}},
+			},
+			parameter: "TagSpecification.1.ResourceType",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := d.dispatchRequestSpotInstances(ctx, tc.req)
+			var apiErr *api.Error
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, api.ErrorCodeInvalidParameterValue, apiErr.Code)
+			assert.Equal(t, tc.parameter, apiErr.Parameter)
+		})
+	}
+}
+
+func TestInterruptInstance(t *testing.T) {
+	t.Parallel()
+
+	const (
+		instanceID           = "i-00000000000000001"
+		terminatedInstanceID = "i-00000000000000002"
+	)
+	ctx := context.Background()
+	d := &Dispatcher{
+		imds:               &imdsController{},
+		storage:            storage.NewMemoryStorage(),
+		spotReclaimCancels: map[string]context.CancelFunc{},
+	}
+	t.Cleanup(d.cancelAllSpotReclaims)
+	require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeInstance, ID: instanceID}))
+	require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeInstance, ID: terminatedInstanceID}))
+	require.NoError(t, d.storage.SetResourceAttributes(terminatedInstanceID, []storage.Attribute{
+		{Key: attributeNameInstanceTerminatedAt, Value: time.Now().UTC().Format(time.RFC3339Nano)},
+	}))
+
+	var apiErr *api.Error
+	_, err := d.InterruptInstance(ctx, "i-00000000000000003", 0)
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, api.ErrorCodeInvalidParameterValue, apiErr.Code)
+
+	_, err = d.InterruptInstance(ctx, terminatedInstanceID, 0)
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, api.ErrorCodeIncorrectInstanceState, apiErr.Code)
+
+	_, err = d.InterruptInstance(ctx, instanceID, -time.Second)
+	require.Error(t, err)
+
+	// The notice defaults to two minutes and is published to IMDS right away
+	reclaimAt, err := d.InterruptInstance(ctx, instanceID, 0)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(spotInterruptionNotice), reclaimAt, 5*time.Second)
+	runtimeID := string(executorInstanceID(instanceID))
+	assert.Eventually(t, func() bool {
+		action, found := d.imds.spotActions.Load(runtimeID)
+		return found && action.(imdsSpotAction).Action == "terminate"
+	}, time.Second, 10*time.Millisecond)
+}
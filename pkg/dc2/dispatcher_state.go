@@ -0,0 +1,186 @@
+package dc2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/executor"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+// openStorage returns the dispatcher storage, persisted at statePath when it's
+// not empty. The returned bool reports whether existing state was loaded.
+func openStorage(statePath string) (storage.Storage, bool, error) {
+	if statePath == "" {
+		return storage.NewMemoryStorage(), false, nil
+	}
+	_, err := os.Stat(statePath)
+	exists := err == nil
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, false, fmt.Errorf("checking state file %q: %w", statePath, err)
+	}
+	store, err := storage.NewFileStorage(statePath)
+	if err != nil {
+		return nil, false, err
+	}
+	return store, exists, nil
+}
+
+// restoreState reconciles resources loaded from the state file with the
+// executor. Instances whose containers still exist get their IMDS state back,
+// while records for instances, volumes and snapshots whose backing resources
+// are gone are dropped.
+func (d *Dispatcher) restoreState(ctx context.Context) error {
+	if err := d.restoreSecurityGroups(); err != nil {
+		return err
+	}
+	if err := d.restoreInstances(ctx); err != nil {
+		return err
+	}
+	if err := d.restoreVolumes(ctx); err != nil {
+		return err
+	}
+	return d.restoreSnapshots(ctx)
+}
+
+func (d *Dispatcher) restoreInstances(ctx context.Context) error {
+	resources, err := d.storage.RegisteredResources(types.ResourceTypeInstance)
+	if err != nil {
+		return fmt.Errorf("retrieving instances: %w", err)
+	}
+	if len(resources) == 0 {
+		return nil
+	}
+	instanceIDs := make([]string, len(resources))
+	for i, r := range resources {
+		instanceIDs[i] = r.ID
+	}
+	descs, err := d.exe.DescribeInstances(ctx, executor.DescribeInstancesRequest{
+		InstanceIDs: executorInstanceIDs(instanceIDs),
+	})
+	if err != nil {
+		return executorError(err)
+	}
+	existing := make(map[string]struct{}, len(descs))
+	for _, desc := range descs {
+		existing[apiInstanceID(desc.InstanceID)] = struct{}{}
+	}
+	var restored []string
+	for _, instanceID := range instanceIDs {
+		if _, found := existing[instanceID]; found {
+			restored = append(restored, instanceID)
+			continue
+		}
+		if err := d.storage.RemoveResource(instanceID); err != nil {
+			return fmt.Errorf("removing instance %s without container: %w", instanceID, err)
+		}
+		api.Logger(ctx).Info("dropped stored instance without container", slog.String("instance_id", instanceID))
+	}
+	if err := d.syncIMDSTagsForResources(restored); err != nil {
+		return err
+	}
+	api.Logger(ctx).Info("restored instances from state", slog.Any("instance_ids", restored))
+	return nil
+}
+
+// restoreVolumes drops the volumes the executor can't describe anymore, like
+// the ones of a Docker executor whose main volume wasn't kept across
+// restarts. Volumes are described one at a time, since describing a missing
+// volume fails the whole call.
+func (d *Dispatcher) restoreVolumes(ctx context.Context) error {
+	resources, err := d.storage.RegisteredResources(types.ResourceTypeVolume)
+	if err != nil {
+		return fmt.Errorf("retrieving volumes: %w", err)
+	}
+	for _, r := range resources {
+		descs, describeErr := d.exe.DescribeVolumes(ctx, executor.DescribeVolumesRequest{
+			VolumeIDs: []executor.VolumeID{executorVolumeID(r.ID)},
+		})
+		if describeErr == nil {
+			// Record the attachment, which state saved by older versions lacks
+			for _, desc := range descs {
+				for _, attachment := range desc.Attachments {
+					if err := d.storage.SetResourceAttributes(r.ID, []storage.Attribute{
+						{Key: attributeNameVolumeAttachedInstanceID, Value: apiInstanceID(attachment.InstanceID)},
+					}); err != nil {
+						return fmt.Errorf("storing attachment for volume %s: %w", r.ID, err)
+					}
+				}
+			}
+			continue
+		}
+		if err := d.storage.RemoveResource(r.ID); err != nil {
+			return fmt.Errorf("removing volume %s without backing storage: %w", r.ID, err)
+		}
+		api.Logger(ctx).Info("dropped stored volume without backing storage", slog.String("volume_id", r.ID), slog.Any("error", describeErr))
+	}
+	return nil
+}
+
+func (d *Dispatcher) restoreSnapshots(ctx context.Context) error {
+	resources, err := d.storage.RegisteredResources(types.ResourceTypeSnapshot)
+	if err != nil {
+		return fmt.Errorf("retrieving snapshots: %w", err)
+	}
+	if len(resources) == 0 {
+		return nil
+	}
+	snapshotIDs := make([]executor.SnapshotID, len(resources))
+	for i, r := range resources {
+		snapshotIDs[i] = executorSnapshotID(r.ID)
+	}
+	descs, err := d.exe.DescribeSnapshots(ctx, executor.DescribeSnapshotsRequest{SnapshotIDs: snapshotIDs})
+	if err != nil {
+		return executorError(err)
+	}
+	existing := make(map[executor.SnapshotID]struct{}, len(descs))
+	for _, desc := range descs {
+		existing[desc.SnapshotID] = struct{}{}
+	}
+	for i, r := range resources {
+		if _, found := existing[snapshotIDs[i]]; found {
+			continue
+		}
+		if err := d.storage.RemoveResource(r.ID); err != nil {
+			return fmt.Errorf("removing snapshot %s without backing storage: %w", r.ID, err)
+		}
+		api.Logger(ctx).Info("dropped stored snapshot without backing storage", slog.String("snapshot_id", r.ID))
+	}
+	return nil
+}
+
+func (d *Dispatcher) restoreSecurityGroups() error {
+	resources, err := d.storage.RegisteredResources(types.ResourceTypeSecurityGroup)
+	if err != nil {
+		return fmt.Errorf("retrieving security groups: %w", err)
+	}
+	d.ensureSecurityGroupMap()
+	for _, r := range resources {
+		// The default group is only stored to keep its rules
+		if r.ID == defaultSecurityGroupID {
+			continue
+		}
+		attrs, err := d.storage.ResourceAttributes(r.ID)
+		if err != nil {
+			return fmt.Errorf("retrieving security group attributes: %w", err)
+		}
+		groupID := r.ID
+		groupName, _ := attrs.Key(attributeNameSecurityGroupName)
+		description, _ := attrs.Key(attributeNameSecurityGroupDescription)
+		ownerID := attrOrDefault(attrs, attributeNameSecurityGroupOwnerID, defaultSecurityGroupOwnerID)
+		vpcID := attrOrDefault(attrs, attributeNameSecurityGroupVPCID, defaultSecurityGroupVPCID)
+		d.securityGroups[groupID] = api.SecurityGroup{
+			GroupID:          &groupID,
+			GroupName:        &groupName,
+			GroupDescription: &description,
+			OwnerID:          &ownerID,
+			VPCID:            &vpcID,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package dc2
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/executor"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+type existingInstancesExecutor struct {
+	*exitCleanupExecutor
+	instanceIDs []executor.InstanceID
+}
+
+func (e *existingInstancesExecutor) DescribeInstances(_ context.Context, req executor.DescribeInstancesRequest) ([]executor.InstanceDescription, error) {
+	var descs []executor.InstanceDescription
+	for _, instanceID := range req.InstanceIDs {
+		if slices.Contains(e.instanceIDs, instanceID) {
+			descs = append(descs, executor.InstanceDescription{InstanceID: instanceID, InstanceState: api.InstanceStateRunning})
+		}
+	}
+	return descs, nil
+}
+
+func TestRestoreStateReconcilesWithExecutor(t *testing.T) {
+	t.Parallel()
+
+	const (
+		keptInstanceID    = "i-00000000000000001"
+		droppedInstanceID = "i-00000000000000002"
+	)
+	ctx := context.Background()
+	statePath := filepath.Join(t.TempDir(), "state.json")
+
+	store, restored, err := openStorage(statePath)
+	require.NoError(t, err)
+	assert.False(t, restored)
+	first := &Dispatcher{
+		exe:     &exitCleanupExecutor{},
+		imds:    &imdsController{},
+		storage: store,
+	}
+	createResp, err := first.dispatchCreateSecurityGroup(ctx, &api.CreateSecurityGroupRequest{
+		GroupName:   "web",
+		Description: "web servers",
+	})
+	require.NoError(t, err)
+	for _, instanceID := range []string{keptInstanceID, droppedInstanceID} {
+		require.NoError(t, store.RegisterResource(storage.Resource{Type: types.ResourceTypeInstance, ID: instanceID}))
+		require.NoError(t, store.SetResourceAttributes(instanceID, []storage.Attribute{
+			{Key: storage.TagAttributeName("Name"), Value: instanceID},
+		}))
+	}
+
+	store, restored, err = openStorage(statePath)
+	require.NoError(t, err)
+	assert.True(t, restored)
+	second := &Dispatcher{
+		exe: &existingInstancesExecutor{
+			exitCleanupExecutor: &exitCleanupExecutor{},
+			instanceIDs:         []executor.InstanceID{executorInstanceID(keptInstanceID)},
+		},
+		imds:    &imdsController{},
+		storage: store,
+	}
+	require.NoError(t, second.restoreState(ctx))
+
+	instances, err := store.RegisteredResources(types.ResourceTypeInstance)
+	require.NoError(t, err)
+	assert.Equal(t, []storage.Resource{{Type: types.ResourceTypeInstance, ID: keptInstanceID}}, instances)
+	assert.Equal(t, map[string]string{"Name": keptInstanceID}, second.imds.tags(string(executorInstanceID(keptInstanceID))))
+
+	groupID, ok := second.resolveSecurityGroupID(nil, new("web"))
+	require.True(t, ok)
+	assert.Equal(t, *createResp.GroupID, groupID)
+	describeResp, err := second.dispatchDescribeSecurityGroups(ctx, &api.DescribeSecurityGroupsRequest{
+		GroupIDs: []string{groupID},
+	})
+	require.NoError(t, err)
+	require.Len(t, describeResp.SecurityGroups, 1)
+	assert.Equal(t, "web servers", securityGroupStringValue(describeResp.SecurityGroups[0].GroupDescription))
+}
+
+// volumeFilesExecutor keeps volumes and snapshots like the Docker executor
+// keeps their files, failing to describe the volumes that are gone.
+type volumeFilesExecutor struct {
+	*volumeSizesExecutor
+	snapshotIDs []executor.SnapshotID
+}
+
+func (e *volumeFilesExecutor) DescribeVolumes(_ context.Context, req executor.DescribeVolumesRequest) ([]executor.VolumeDescription, error) {
+	descs := make([]executor.VolumeDescription, len(req.VolumeIDs))
+	for i, id := range req.VolumeIDs {
+		size, found := e.sizes[id]
+		if !found {
+			return nil, fmt.Errorf("volume %s doesn't exist", id)
+		}
+		descs[i] = executor.VolumeDescription{VolumeID: id, Size: size}
+	}
+	return descs, nil
+}
+
+func (e *volumeFilesExecutor) CreateSnapshot(context.Context, executor.CreateSnapshotRequest) (executor.SnapshotID, error) {
+	id := executor.SnapshotID(fmt.Sprintf("%017x", len(e.snapshotIDs)+1))
+	e.snapshotIDs = append(e.snapshotIDs, id)
+	return id, nil
+}
+
+func (e *volumeFilesExecutor) DescribeSnapshots(_ context.Context, req executor.DescribeSnapshotsRequest) ([]executor.SnapshotDescription, error) {
+	var descs []executor.SnapshotDescription
+	for _, id := range req.SnapshotIDs {
+		if slices.Contains(e.snapshotIDs, id) {
+			descs = append(descs, executor.SnapshotDescription{SnapshotID: id})
+		}
+	}
+	return descs, nil
+}
+
+func TestRestoreStateDropsVolumesWithoutBackingStorage(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	exe := &volumeFilesExecutor{
+		volumeSizesExecutor: &volumeSizesExecutor{
+			exitCleanupExecutor: &exitCleanupExecutor{},
+			sizes:               make(map[executor.VolumeID]int64),
+		},
+	}
+
+	store, _, err := openStorage(statePath)
+	require.NoError(t, err)
+	first := &Dispatcher{
+		exe:     exe,
+		imds:    &imdsController{},
+		storage: store,
+	}
+	var volumeIDs, snapshotIDs []string
+	for range 2 {
+		created, err := first.dispatchCreateVolume(ctx, &api.CreateVolumeRequest{
+			AvailabilityZone: "us-east-1a",
+			Size:             new(1),
+			VolumeType:       types.VolumeTypeGp3,
+		})
+		require.NoError(t, err)
+		volumeIDs = append(volumeIDs, *created.VolumeID)
+		snapshot, err := first.dispatchCreateSnapshot(ctx, &api.CreateSnapshotRequest{VolumeID: *created.VolumeID})
+		require.NoError(t, err)
+		snapshotIDs = append(snapshotIDs, snapshot.SnapshotID)
+	}
+
+	// The executor restarted without the files of the second volume and
+	// snapshot
+	delete(exe.sizes, executorVolumeID(volumeIDs[1]))
+	exe.snapshotIDs = exe.snapshotIDs[:1]
+	store, restored, err := openStorage(statePath)
+	require.NoError(t, err)
+	assert.True(t, restored)
+	second := &Dispatcher{
+		exe:     exe,
+		imds:    &imdsController{},
+		storage: store,
+	}
+	require.NoError(t, second.restoreState(ctx))
+
+	volumesResp, err := second.dispatchDescribeVolumes(ctx, &api.DescribeVolumesRequest{})
+	require.NoError(t, err)
+	require.Len(t, volumesResp.Volumes, 1)
+	assert.Equal(t, volumeIDs[0], *volumesResp.Volumes[0].VolumeID)
+	snapshotsResp, err := second.dispatchDescribeSnapshots(ctx, &api.DescribeSnapshotsRequest{})
+	require.NoError(t, err)
+	require.Len(t, snapshotsResp.Snapshots, 1)
+	assert.Equal(t, snapshotIDs[0], snapshotsResp.Snapshots[0].SnapshotID)
+}
@@ -2,18 +2,108 @@ package dc2
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
+	"net/netip"
 	"slices"
 	"strconv"
 	"strings"
 
 	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
 )
 
 const (
-	defaultSubnetID    = "subnet-00000000000000000"
-	defaultSubnetVPCID = "vpc-00000000000000000"
+	subnetIDPrefix = "subnet-"
+
+	defaultSubnetID        = "subnet-00000000000000000"
+	defaultSubnetVPCID     = "vpc-00000000000000000"
+	defaultSubnetCIDRBlock = "10.0.0.0/24"
+
+	attributeNameSubnetVPCID            = "SubnetVPCID"
+	attributeNameSubnetAvailabilityZone = "SubnetAvailabilityZone"
+	attributeNameSubnetCIDRBlock        = "SubnetCIDRBlock"
 )
 
+type subnetData struct {
+	ID               string
+	VPCID            string
+	AvailabilityZone string
+	CIDRBlock        netip.Prefix
+	IsDefault        bool
+	Tags             []api.Tag
+}
+
+func (d *Dispatcher) dispatchCreateSubnet(ctx context.Context, req *api.CreateSubnetRequest) (*api.CreateSubnetResponse, error) {
+	if err := validateTagSpecifications(req.TagSpecifications, types.ResourceTypeSubnet); err != nil {
+		return nil, err
+	}
+	vpc, err := d.findVPC(req.VPCID)
+	if err != nil {
+		return nil, err
+	}
+	cidrBlock, err := parseVPCCIDRBlock("CidrBlock", req.CIDRBlock, "InvalidSubnet.Range")
+	if err != nil {
+		return nil, err
+	}
+	if !vpc.CIDRBlock.Contains(cidrBlock.Addr()) || cidrBlock.Bits() < vpc.CIDRBlock.Bits() {
+		return nil, api.ErrWithCode("InvalidSubnet.Range", fmt.Errorf("The CIDR '%s' is invalid.", req.CIDRBlock)) //nolint
+	}
+	availabilityZone, err := d.createSubnetAvailabilityZone(req)
+	if err != nil {
+		return nil, err
+	}
+	subnets, err := d.listSubnets()
+	if err != nil {
+		return nil, err
+	}
+	for _, subnet := range subnets {
+		if subnet.VPCID == vpc.ID && subnet.CIDRBlock.Overlaps(cidrBlock) {
+			return nil, api.ErrWithCode(
+				"InvalidSubnet.Conflict",
+				fmt.Errorf("The CIDR '%s' conflicts with another subnet", req.CIDRBlock), //nolint
+			)
+		}
+	}
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
+
+	subnet := subnetData{
+		VPCID:            vpc.ID,
+		AvailabilityZone: availabilityZone,
+		CIDRBlock:        cidrBlock,
+		Tags:             tagSpecsToTags(req.TagSpecifications),
+	}
+	subnet.ID, err = makeID(subnetIDPrefix)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeSubnet, ID: subnet.ID}); err != nil {
+		return nil, fmt.Errorf("registering subnet: %w", err)
+	}
+	attrs := []storage.Attribute{
+		{Key: attributeNameSubnetVPCID, Value: subnet.VPCID},
+		{Key: attributeNameSubnetAvailabilityZone, Value: subnet.AvailabilityZone},
+		{Key: attributeNameSubnetCIDRBlock, Value: subnet.CIDRBlock.String()},
+	}
+	for _, tag := range subnet.Tags {
+		attrs = append(attrs, storage.Attribute{Key: storage.TagAttributeName(tag.Key), Value: tag.Value})
+	}
+	if err := d.storage.SetResourceAttributes(subnet.ID, attrs); err != nil {
+		return nil, fmt.Errorf("saving subnet attributes: %w", err)
+	}
+	api.Logger(ctx).Info(
+		"created subnet",
+		slog.String("subnet_id", subnet.ID),
+		slog.String("vpc_id", subnet.VPCID),
+		slog.String("availability_zone", subnet.AvailabilityZone),
+		slog.String("cidr_block", subnet.CIDRBlock.String()),
+	)
+	return &api.CreateSubnetResponse{Subnet: d.apiSubnet(subnet)}, nil
+}
+
 func (d *Dispatcher) dispatchDescribeSubnets(
 	_ context.Context,
 	req *api.DescribeSubnetsRequest,
@@ -22,38 +112,20 @@ func (d *Dispatcher) dispatchDescribeSubnets(
 		return nil, api.DryRunError()
 	}
 
-	availabilityZone := defaultAvailabilityZone(d.opts.Region)
-	availabilityZoneID := defaultAvailabilityZoneID(d.opts.Region)
-	subnetID := defaultSubnetID
-	vpcID := defaultSubnetVPCID
-	state := "available"
-	cidrBlock := "10.0.0.0/24"
-	availableIPAddressCount := 251
-	defaultForAZ := true
-	mapPublicIPOnLaunch := true
-
-	subnets := []api.Subnet{
-		{
-			SubnetID:                &subnetID,
-			VPCID:                   &vpcID,
-			State:                   &state,
-			AvailabilityZone:        &availabilityZone,
-			AvailabilityZoneID:      &availabilityZoneID,
-			CIDRBlock:               &cidrBlock,
-			AvailableIPAddressCount: &availableIPAddressCount,
-			DefaultForAZ:            &defaultForAZ,
-			MapPublicIPOnLaunch:     &mapPublicIPOnLaunch,
-		},
+	subnets, err := d.listSubnets()
+	if err != nil {
+		return nil, err
 	}
 
 	filtered := make([]api.Subnet, 0, len(subnets))
 	for _, subnet := range subnets {
-		matches, err := subnetMatchesRequest(subnet, req)
+		apiSubnet := d.apiSubnet(subnet)
+		matches, err := subnetMatchesRequest(apiSubnet, req)
 		if err != nil {
 			return nil, err
 		}
 		if matches {
-			filtered = append(filtered, subnet)
+			filtered = append(filtered, apiSubnet)
 		}
 	}
 
@@ -68,6 +140,165 @@ func (d *Dispatcher) dispatchDescribeSubnets(
 	}, nil
 }
 
+func (d *Dispatcher) dispatchDeleteSubnet(ctx context.Context, req *api.DeleteSubnetRequest) (*api.DeleteSubnetResponse, error) {
+	subnet, err := d.findSubnet(req.SubnetID)
+	if err != nil {
+		return nil, err
+	}
+	if subnet == nil {
+		return nil, subnetNotFoundError(req.SubnetID)
+	}
+	if subnet.IsDefault {
+		return nil, api.ErrWithCode(api.ErrorCodeOperationNotPermitted, fmt.Errorf("The default subnet '%s' can't be deleted.", subnet.ID)) //nolint
+	}
+	instanceIDs, err := d.subnetInstanceIDs(subnet.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(instanceIDs) > 0 {
+		return nil, api.ErrWithCode(
+			"DependencyViolation",
+			fmt.Errorf("The subnet '%s' has dependencies and cannot be deleted.", subnet.ID), //nolint
+		)
+	}
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
+	if err := d.storage.RemoveResource(subnet.ID); err != nil {
+		return nil, fmt.Errorf("deleting subnet: %w", err)
+	}
+	api.Logger(ctx).Info("deleted subnet", slog.String("subnet_id", subnet.ID))
+	return &api.DeleteSubnetResponse{Return: true}, nil
+}
+
+func (d *Dispatcher) createSubnetAvailabilityZone(req *api.CreateSubnetRequest) (string, error) {
+	availabilityZone := strings.TrimSpace(valueOrEmpty(req.AvailabilityZone))
+	availabilityZoneID := strings.TrimSpace(valueOrEmpty(req.AvailabilityZoneID))
+	switch {
+	case availabilityZone != "" && availabilityZoneID != "":
+		return "", api.ErrWithCode(
+			"InvalidParameterCombination",
+			fmt.Errorf("AvailabilityZone and AvailabilityZoneId cannot be specified together"),
+		)
+	case availabilityZoneID != "":
+		return availabilityZoneFromID(availabilityZoneID, d.opts.Region)
+	case availabilityZone != "":
+		if err := validateAvailabilityZone(availabilityZone, d.opts.Region); err != nil {
+			return "", err
+		}
+		return availabilityZone, nil
+	default:
+		return defaultAvailabilityZone(d.opts.Region), nil
+	}
+}
+
+// listSubnets returns the default subnet, which always exists, followed by
+// the created ones.
+func (d *Dispatcher) listSubnets() ([]subnetData, error) {
+	resources, err := d.storage.RegisteredResources(types.ResourceTypeSubnet)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving subnets: %w", err)
+	}
+	subnets := make([]subnetData, 0, len(resources)+1)
+	subnets = append(subnets, subnetData{
+		ID:               defaultSubnetID,
+		VPCID:            defaultSubnetVPCID,
+		AvailabilityZone: defaultAvailabilityZone(d.opts.Region),
+		CIDRBlock:        netip.MustParsePrefix(defaultSubnetCIDRBlock),
+		IsDefault:        true,
+	})
+	for _, r := range resources {
+		attrs, err := d.storage.ResourceAttributes(r.ID)
+		if err != nil {
+			return nil, fmt.Errorf("retrieving subnet attributes: %w", err)
+		}
+		subnet := subnetData{ID: r.ID, Tags: tagsFromAttributes(attrs)}
+		subnet.VPCID, _ = attrs.Key(attributeNameSubnetVPCID)
+		subnet.AvailabilityZone, _ = attrs.Key(attributeNameSubnetAvailabilityZone)
+		rawCIDRBlock, _ := attrs.Key(attributeNameSubnetCIDRBlock)
+		subnet.CIDRBlock, err = netip.ParsePrefix(rawCIDRBlock)
+		if err != nil {
+			return nil, fmt.Errorf("parsing subnet cidr block: %w", err)
+		}
+		subnets = append(subnets, subnet)
+	}
+	return subnets, nil
+}
+
+// findSubnet returns the subnet with the given ID, or nil if there's none.
+func (d *Dispatcher) findSubnet(subnetID string) (*subnetData, error) {
+	subnets, err := d.listSubnets()
+	if err != nil {
+		return nil, err
+	}
+	idx := slices.IndexFunc(subnets, func(s subnetData) bool { return s.ID == subnetID })
+	if idx < 0 {
+		return nil, nil
+	}
+	return &subnets[idx], nil
+}
+
+// subnetPlacement returns the VPC and availability zone of a subnet. The zone
+// is empty for subnets that don't constrain placement: the default subnet,
+// which stands in for the default subnet of every zone, and subnets that were
+// never created with CreateSubnet, which RunInstances still accepts with a VPC
+// derived from their ID.
+func (d *Dispatcher) subnetPlacement(subnetID string) (string, string, error) {
+	subnet, err := d.findSubnet(subnetID)
+	if err != nil {
+		return "", "", err
+	}
+	if subnet == nil || subnet.IsDefault {
+		return subnetVPCID(subnetID), "", nil
+	}
+	return subnet.VPCID, subnet.AvailabilityZone, nil
+}
+
+// subnetInstanceIDs returns the non terminated instances launched in the
+// subnet.
+func (d *Dispatcher) subnetInstanceIDs(subnetID string) ([]string, error) {
+	resources, err := d.storage.RegisteredResources(types.ResourceTypeInstance)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving registered instances: %w", err)
+	}
+	var instanceIDs []string
+	for _, r := range resources {
+		attrs, err := d.storage.ResourceAttributes(r.ID)
+		if err != nil {
+			return nil, fmt.Errorf("retrieving instance attributes: %w", err)
+		}
+		if id, _ := attrs.Key(attributeNameSubnetID); id != subnetID {
+			continue
+		}
+		if terminatedAt, _ := attrs.Key(attributeNameInstanceTerminatedAt); terminatedAt != "" {
+			continue
+		}
+		instanceIDs = append(instanceIDs, r.ID)
+	}
+	return instanceIDs, nil
+}
+
+func (d *Dispatcher) apiSubnet(subnet subnetData) api.Subnet {
+	state := "available"
+	availabilityZoneID := availabilityZoneIDFromName(subnet.AvailabilityZone, d.opts.Region)
+	cidrBlock := subnet.CIDRBlock.String()
+	// AWS reserves the first four and the last address of each subnet
+	availableIPAddressCount := 1<<(32-subnet.CIDRBlock.Bits()) - 5
+	mapPublicIPOnLaunch := subnet.IsDefault
+	return api.Subnet{
+		SubnetID:                &subnet.ID,
+		VPCID:                   &subnet.VPCID,
+		State:                   &state,
+		AvailabilityZone:        &subnet.AvailabilityZone,
+		AvailabilityZoneID:      &availabilityZoneID,
+		CIDRBlock:               &cidrBlock,
+		AvailableIPAddressCount: &availableIPAddressCount,
+		DefaultForAZ:            &subnet.IsDefault,
+		MapPublicIPOnLaunch:     &mapPublicIPOnLaunch,
+		Tags:                    subnet.Tags,
+	}
+}
+
 func subnetMatchesRequest(subnet api.Subnet, req *api.DescribeSubnetsRequest) (bool, error) {
 	subnetID := subnetStringValue(subnet.SubnetID)
 	vpcID := subnetStringValue(subnet.VPCID)
@@ -122,9 +353,17 @@ func subnetMatchesRequest(subnet api.Subnet, req *api.DescribeSubnetsRequest) (b
 			if !slices.Contains(filter.Values, cidrBlock) {
 				return false, nil
 			}
-		case filterName == "tag-key", strings.HasPrefix(filterName, "tag:"):
-			// Subnet tags are not modeled yet.
-			return false, nil
+		case filterName == "tag-key":
+			if !slices.ContainsFunc(subnet.Tags, func(tag api.Tag) bool { return slices.Contains(filter.Values, tag.Key) }) {
+				return false, nil
+			}
+		case strings.HasPrefix(filterName, "tag:"):
+			tagKey := strings.TrimPrefix(*filter.Name, "tag:")
+			if !slices.ContainsFunc(subnet.Tags, func(tag api.Tag) bool {
+				return tag.Key == tagKey && slices.Contains(filter.Values, tag.Value)
+			}) {
+				return false, nil
+			}
 		default:
 			// Preserve compatibility for callers that send additional AWS filters.
 			return false, nil
@@ -147,3 +386,7 @@ func subnetBoolValue(value *bool) bool {
 	}
 	return *value
 }
+
+func subnetNotFoundError(subnetID string) error {
+	return api.ErrWithCode("InvalidSubnetID.NotFound", fmt.Errorf("The subnet ID '%s' does not exist", subnetID)) //nolint
+}
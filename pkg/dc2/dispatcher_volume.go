@@ -5,7 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/fiam/dc2/pkg/dc2/api"
@@ -23,6 +25,9 @@ const (
 	attributeNameSnapshotID                          = "SnapshotID"
 	attributeNameVolumeDeleteOnTermination           = "DeleteOnTermination"
 	attributeNameVolumeDeleteOnTerminationInstanceID = "DeleteOnTerminationInstanceID"
+	// attributeNameVolumeAttachedInstanceID records the instance a volume is
+	// attached to, so instance descriptions only describe their own volumes.
+	attributeNameVolumeAttachedInstanceID = "AttachedInstanceID"
 
 	volumeIDPrefix = "vol-"
 
@@ -33,6 +38,25 @@ func (d *Dispatcher) dispatchCreateVolume(ctx context.Context, req *api.CreateVo
 	if err := validateTagSpecifications(req.TagSpecifications, types.ResourceTypeVolume); err != nil {
 		return nil, err
 	}
+	var snapshot *api.Snapshot
+	if req.SnapshotID != "" {
+		if _, err := d.findSnapshot(ctx, req.SnapshotID); err != nil {
+			return nil, err
+		}
+		s, err := d.describeSnapshot(req.SnapshotID)
+		if err != nil {
+			return nil, err
+		}
+		snapshot = &s
+		// Volumes restored from a snapshot default to the snapshot size
+		// and can't be smaller.
+		if req.Size == nil {
+			req.Size = &snapshot.VolumeSize
+		}
+		if *req.Size < snapshot.VolumeSize {
+			return nil, api.InvalidParameterValueError("Size", strconv.Itoa(*req.Size))
+		}
+	}
 	if req.Size == nil || *req.Size == 0 {
 		return nil, api.InvalidParameterValueError("Size", fmt.Sprintf("%v", req.Size))
 	}
@@ -54,7 +78,11 @@ func (d *Dispatcher) dispatchCreateVolume(ctx context.Context, req *api.CreateVo
 	}
 
 	sizeInBytesFromGB := int64(*req.Size) * bytesPerGigaByte
-	volID, err := d.exe.CreateVolume(ctx, executor.CreateVolumeRequest{Size: sizeInBytesFromGB})
+	createReq := executor.CreateVolumeRequest{Size: sizeInBytesFromGB}
+	if snapshot != nil {
+		createReq.SnapshotID = executorSnapshotID(snapshot.SnapshotID)
+	}
+	volID, err := d.exe.CreateVolume(ctx, createReq)
 	if err != nil {
 		return nil, executorError(err)
 	}
@@ -70,6 +98,9 @@ func (d *Dispatcher) dispatchCreateVolume(ctx context.Context, req *api.CreateVo
 	if req.KmsKeyID != nil && *req.KmsKeyID != "" {
 		attrs = append(attrs, storage.Attribute{Key: attributeNameKMSKeyID, Value: *req.KmsKeyID})
 	}
+	if snapshot != nil {
+		attrs = append(attrs, storage.Attribute{Key: attributeNameSnapshotID, Value: snapshot.SnapshotID})
+	}
 	for _, spec := range req.TagSpecifications {
 		for _, tag := range spec.Tags {
 			attrs = append(attrs, storage.Attribute{Key: storage.TagAttributeName(tag.Key), Value: tag.Value})
@@ -93,6 +124,7 @@ func (d *Dispatcher) dispatchCreateVolume(ctx context.Context, req *api.CreateVo
 		slog.String("availability_zone", req.AvailabilityZone),
 		slog.Int("size_gib", *req.Size),
 		slog.String("volume_type", string(req.VolumeType)),
+		slog.String("snapshot_id", req.SnapshotID),
 	)
 	vol, err := d.describeVolume(ctx, id)
 	if err != nil {
@@ -107,16 +139,56 @@ func (d *Dispatcher) dispatchDeleteVolume(ctx context.Context, req *api.DeleteVo
 	if err != nil {
 		return nil, err
 	}
+	if err := d.checkVolumeNotInUse(ctx, vol.ID); err != nil {
+		return nil, err
+	}
+	if err := d.deleteVolume(ctx, vol.ID); err != nil {
+		return nil, err
+	}
+	return &api.DeleteVolumeResponse{}, nil
+}
 
-	if err := d.exe.DeleteVolume(ctx, executor.DeleteVolumeRequest{VolumeID: executorVolumeID(vol.ID)}); err != nil {
-		return nil, executorError(err)
+// checkVolumeNotInUse returns VolumeInUse if the volume is attached to an
+// instance that hasn't been terminated. Attachments to terminated instances
+// are left behind by the executor and don't prevent deletion.
+func (d *Dispatcher) checkVolumeNotInUse(ctx context.Context, volumeID string) error {
+	descs, err := d.exe.DescribeVolumes(ctx, executor.DescribeVolumesRequest{
+		VolumeIDs: []executor.VolumeID{executorVolumeID(volumeID)},
+	})
+	if err != nil {
+		return executorError(err)
+	}
+	for _, desc := range descs {
+		for _, attachment := range desc.Attachments {
+			instanceID := apiInstanceID(attachment.InstanceID)
+			attrs, err := d.storage.ResourceAttributes(instanceID)
+			if err != nil {
+				if errors.As(err, &storage.ErrResourceNotFound{}) {
+					continue
+				}
+				return fmt.Errorf("retrieving instance attributes: %w", err)
+			}
+			if terminatedAt, _ := attrs.Key(attributeNameInstanceTerminatedAt); terminatedAt != "" {
+				continue
+			}
+			return api.ErrWithCode(api.ErrorCodeVolumeInUse, fmt.Errorf("Volume %s is currently attached to %s", volumeID, instanceID)) //nolint
+		}
 	}
+	return nil
+}
 
-	if err := d.storage.RemoveResource(vol.ID); err != nil {
-		return nil, fmt.Errorf("deleting volume from storage: %w", err)
+// deleteVolume deletes a volume regardless of its attachments, for callers
+// that already detached it or are terminating the instance it's attached to.
+func (d *Dispatcher) deleteVolume(ctx context.Context, volumeID string) error {
+	if err := d.exe.DeleteVolume(ctx, executor.DeleteVolumeRequest{VolumeID: executorVolumeID(volumeID)}); err != nil {
+		return executorError(err)
 	}
-	api.Logger(ctx).Info("deleted volume", slog.String("volume_id", vol.ID))
-	return &api.DeleteVolumeResponse{}, nil
+
+	if err := d.storage.RemoveResource(volumeID); err != nil {
+		return fmt.Errorf("deleting volume from storage: %w", err)
+	}
+	api.Logger(ctx).Info("deleted volume", slog.String("volume_id", volumeID))
+	return nil
 }
 
 func (d *Dispatcher) dispatchAttachVolume(ctx context.Context, req *api.AttachVolumeRequest) (*api.AttachVolumeResponse, error) {
@@ -151,7 +223,10 @@ func (d *Dispatcher) dispatchAttachVolume(ctx context.Context, req *api.AttachVo
 	}
 
 	if volumeAZ != instanceAZ {
-		return nil, api.InvalidParameterValueError("AvailabilityZone", fmt.Sprintf("volume %s and instance %s are in different availability zones", vol.ID, instance.ID))
+		return nil, api.ErrWithCode(
+			"InvalidVolume.ZoneMismatch",
+			fmt.Errorf("the volume '%s' is not in the same availability zone as instance '%s'", vol.ID, instance.ID),
+		)
 	}
 
 	if req.DryRun {
@@ -166,6 +241,11 @@ func (d *Dispatcher) dispatchAttachVolume(ctx context.Context, req *api.AttachVo
 	if err != nil {
 		return nil, executorError(err)
 	}
+	if err := d.storage.SetResourceAttributes(vol.ID, []storage.Attribute{
+		{Key: attributeNameVolumeAttachedInstanceID, Value: instance.ID},
+	}); err != nil {
+		return nil, fmt.Errorf("storing attachment for volume %s: %w", vol.ID, err)
+	}
 
 	api.Logger(ctx).Debug("attached volume", slog.String("volume_id", vol.ID), slog.String("instance_id", instance.ID))
 	deleteOnTermination := false
@@ -204,23 +284,33 @@ func (d *Dispatcher) dispatchDetachVolume(ctx context.Context, req *api.DetachVo
 	if err != nil {
 		return nil, executorError(err)
 	}
+	if err := d.storage.RemoveResourceAttributes(vol.ID, []storage.Attribute{
+		{Key: attributeNameVolumeAttachedInstanceID, Value: instance.ID},
+	}); err != nil {
+		return nil, fmt.Errorf("removing attachment for volume %s: %w", vol.ID, err)
+	}
 
 	api.Logger(ctx).Debug("detached volume", slog.String("volume_id", vol.ID), slog.String("instance_id", instance.ID))
 	deleteOnTermination := false
+	// Detaching is synchronous, so the volume is already detached.
 	return &api.DetachVolumeResponse{
 		VolumeAttachment: api.VolumeAttachment{
 			AttachTime:          &attachment.AttachTime,
 			Device:              &req.Device,
 			InstanceID:          &req.InstanceID,
 			VolumeID:            &req.VolumeID,
-			State:               types.VolumeAttachmentStateAttached,
+			State:               types.VolumeAttachmentStateDetached,
 			DeleteOnTermination: &deleteOnTermination,
 		},
 	}, nil
 }
 
 func (d *Dispatcher) dispatchDescribeVolumes(ctx context.Context, req *api.DescribeVolumesRequest) (*api.DescribeVolumesResponse, error) {
-	volumeIDs, err := d.applyFilters(types.ResourceTypeVolume, req.VolumeIDs, req.Filters)
+	tagFilters, volumeFilters, err := splitVolumeFilters(req.Filters)
+	if err != nil {
+		return nil, err
+	}
+	volumeIDs, err := d.applyFilters(types.ResourceTypeVolume, req.VolumeIDs, tagFilters)
 	if err != nil {
 		return nil, err
 	}
@@ -235,7 +325,9 @@ func (d *Dispatcher) dispatchDescribeVolumes(ctx context.Context, req *api.Descr
 		if err != nil {
 			return nil, err
 		}
-		volumes = append(volumes, volume)
+		if volumeMatchesFilters(volume, volumeFilters) {
+			volumes = append(volumes, volume)
+		}
 	}
 
 	volumes, nextToken, err := applyNextToken(volumes, req.NextToken, req.MaxResults)
@@ -300,6 +392,7 @@ func (d *Dispatcher) describeVolume(ctx context.Context, volumeID string) (api.V
 			InstanceID:          &instanceID,
 			State:               types.VolumeAttachmentStateAttached,
 			DeleteOnTermination: &attachmentDeleteOnTermination,
+			VolumeID:            &volumeID,
 		}
 	}
 
@@ -362,7 +455,7 @@ func (d *Dispatcher) describeVolume(ctx context.Context, volumeID string) (api.V
 		MultiAttachEnabled: &multiattachEnabled,
 		Size:               &size,
 		SnapshotID:         snapshotID,
-		State:              types.VolumeStateAvailable,
+		State:              volumeState(len(attachments)),
 		Tags:               tags,
 		Throughput:         &throughput,
 		VolumeID:           &volumeID,
@@ -370,6 +463,52 @@ func (d *Dispatcher) describeVolume(ctx context.Context, volumeID string) (api.V
 	}, nil
 }
 
+func splitVolumeFilters(filters []api.Filter) ([]api.Filter, []api.Filter, error) {
+	var tagFilters, volumeFilters []api.Filter
+	for _, filter := range filters {
+		if filter.Name == nil {
+			return nil, nil, api.InvalidParameterValueError("Filter.Name", "<missing>")
+		}
+		switch name := *filter.Name; {
+		case strings.HasPrefix(name, "tag:"), name == "tag-key":
+			tagFilters = append(tagFilters, filter)
+		case name == "attachment.instance-id", name == "status", name == "availability-zone":
+			volumeFilters = append(volumeFilters, filter)
+		default:
+			return nil, nil, api.InvalidParameterValueError("Filter.Name", name)
+		}
+	}
+	return tagFilters, volumeFilters, nil
+}
+
+func volumeMatchesFilters(volume api.Volume, filters []api.Filter) bool {
+	for _, filter := range filters {
+		var values []string
+		switch *filter.Name {
+		case "attachment.instance-id":
+			for _, attachment := range volume.Attachments {
+				values = append(values, *attachment.InstanceID)
+			}
+		case "status":
+			values = []string{string(volume.State)}
+		case "availability-zone":
+			values = []string{*volume.AvailabilityZone}
+		}
+		if !slices.ContainsFunc(values, func(value string) bool { return slices.Contains(filter.Values, value) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// volumeState derives the EC2 state of a volume from its attachments.
+func volumeState(attachmentCount int) types.VolumeState {
+	if attachmentCount > 0 {
+		return types.VolumeStateInUse
+	}
+	return types.VolumeStateAvailable
+}
+
 func executorVolumeID(volID string) executor.VolumeID {
 	return executor.VolumeID(volID[len(volumeIDPrefix):])
 }
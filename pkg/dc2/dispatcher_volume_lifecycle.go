@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/fiam/dc2/pkg/dc2/api"
 	"github.com/fiam/dc2/pkg/dc2/executor"
@@ -12,6 +14,23 @@ import (
 	"github.com/fiam/dc2/pkg/dc2/types"
 )
 
+const (
+	attributeNameInstanceRootDeviceName = "InstanceRootDeviceName"
+
+	defaultRootDeviceName = "/dev/xvda"
+	rootDeviceTypeEBS     = "ebs"
+)
+
+// isRootDeviceName reports whether a block device mapping targets the
+// instance root device rather than an additional data volume.
+func isRootDeviceName(deviceName string) bool {
+	switch deviceName {
+	case "/dev/xvda", "/dev/sda", "/dev/sda1":
+		return true
+	}
+	return false
+}
+
 func (d *Dispatcher) attachInstanceBlockDeviceMappings(ctx context.Context, ids []executor.InstanceID, availabilityZone string, mappings []api.RunInstancesBlockDeviceMapping) error {
 	for _, instanceID := range ids {
 		apiID := apiInstanceID(instanceID)
@@ -48,7 +67,7 @@ func (d *Dispatcher) attachInstanceBlockDeviceMappings(ctx context.Context, ids
 				VolumeID:   volumeID,
 			})
 			if err != nil {
-				_, deleteErr := d.dispatchDeleteVolume(ctx, &api.DeleteVolumeRequest{VolumeID: volumeID})
+				deleteErr := d.deleteVolume(ctx, volumeID)
 				if deleteErr != nil {
 					err = errors.Join(err, fmt.Errorf("cleaning up unattached volume %s: %w", volumeID, deleteErr))
 				}
@@ -60,12 +79,21 @@ func (d *Dispatcher) attachInstanceBlockDeviceMappings(ctx context.Context, ids
 				{Key: attributeNameVolumeDeleteOnTerminationInstanceID, Value: apiID},
 			})
 			if err != nil {
-				_, deleteErr := d.dispatchDeleteVolume(ctx, &api.DeleteVolumeRequest{VolumeID: volumeID})
+				deleteErr := d.deleteVolume(ctx, volumeID)
 				if deleteErr != nil {
 					err = errors.Join(err, fmt.Errorf("cleaning up volume %s after metadata failure: %w", volumeID, deleteErr))
 				}
 				return fmt.Errorf("setting delete-on-termination metadata for volume %s: %w", volumeID, err)
 			}
+
+			if isRootDeviceName(mapping.DeviceName) {
+				err := d.storage.SetResourceAttributes(apiID, []storage.Attribute{
+					{Key: attributeNameInstanceRootDeviceName, Value: mapping.DeviceName},
+				})
+				if err != nil {
+					return fmt.Errorf("setting root device for instance %s: %w", apiID, err)
+				}
+			}
 		}
 	}
 	return nil
@@ -146,9 +174,75 @@ func (d *Dispatcher) cleanupDeleteOnTerminationVolumesForInstances(ctx context.C
 			continue
 		}
 
-		if _, err := d.dispatchDeleteVolume(ctx, &api.DeleteVolumeRequest{VolumeID: volume.ID}); err != nil {
+		if err := d.deleteVolume(ctx, volume.ID); err != nil {
 			result = errors.Join(result, fmt.Errorf("deleting delete-on-termination volume %s: %w", volume.ID, err))
 		}
 	}
 	return result
 }
+
+// instanceBlockDeviceMappings returns the block devices attached to the
+// given instances, keyed by API instance ID and sorted by device name. Only
+// the volumes stored as attached to them are described, and a volume that
+// can't be described is left out instead of failing the whole description.
+func (d *Dispatcher) instanceBlockDeviceMappings(ctx context.Context, instanceIDs []string) (map[string][]api.InstanceBlockDeviceMapping, error) {
+	volumes, err := d.storage.RegisteredResources(types.ResourceTypeVolume)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving volumes for block device mappings: %w", err)
+	}
+	described := make(map[string]struct{}, len(instanceIDs))
+	for _, instanceID := range instanceIDs {
+		described[instanceID] = struct{}{}
+	}
+
+	mappings := make(map[string][]api.InstanceBlockDeviceMapping)
+	for _, volume := range volumes {
+		attrs, err := d.storage.ResourceAttributes(volume.ID)
+		if err != nil {
+			return nil, fmt.Errorf("retrieving attributes for volume %s: %w", volume.ID, err)
+		}
+		attachedInstanceID, _ := attrs.Key(attributeNameVolumeAttachedInstanceID)
+		if _, found := described[attachedInstanceID]; !found {
+			continue
+		}
+		descs, err := d.exe.DescribeVolumes(ctx, executor.DescribeVolumesRequest{
+			VolumeIDs: []executor.VolumeID{executorVolumeID(volume.ID)},
+		})
+		if err != nil {
+			api.Logger(ctx).Warn("failed to describe attached volume", "volume_id", volume.ID, "instance_id", attachedInstanceID, "error", err)
+			continue
+		}
+		deleteOnTermination := false
+		if raw, _ := attrs.Key(attributeNameVolumeDeleteOnTermination); raw != "" {
+			deleteOnTermination, err = strconv.ParseBool(raw)
+			if err != nil {
+				return nil, fmt.Errorf("parsing delete-on-termination for volume %s: %w", volume.ID, err)
+			}
+		}
+		deleteOnTerminationInstanceID, _ := attrs.Key(attributeNameVolumeDeleteOnTerminationInstanceID)
+		for _, desc := range descs {
+			for _, attachment := range desc.Attachments {
+				instanceID := apiInstanceID(attachment.InstanceID)
+				if _, found := described[instanceID]; !found {
+					continue
+				}
+				attachTime := attachment.AttachTime
+				mappings[instanceID] = append(mappings[instanceID], api.InstanceBlockDeviceMapping{
+					DeviceName: attachment.Device,
+					EBS: &api.EBSInstanceBlockDevice{
+						AttachTime:          &attachTime,
+						DeleteOnTermination: deleteOnTermination && instanceID == deleteOnTerminationInstanceID,
+						Status:              types.VolumeAttachmentStateAttached,
+						VolumeID:            volume.ID,
+					},
+				})
+			}
+		}
+	}
+	for _, instanceMappings := range mappings {
+		slices.SortFunc(instanceMappings, func(a, b api.InstanceBlockDeviceMapping) int {
+			return strings.Compare(a.DeviceName, b.DeviceName)
+		})
+	}
+	return mappings, nil
+}
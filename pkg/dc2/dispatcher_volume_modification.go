@@ -0,0 +1,180 @@
+package dc2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/executor"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+const (
+	attributeNameVolumeModification = "VolumeModification"
+
+	volumeModificationProgressCompleted = 100
+)
+
+func (d *Dispatcher) dispatchModifyVolume(ctx context.Context, req *api.ModifyVolumeRequest) (*api.ModifyVolumeResponse, error) {
+	if req.Size == nil && req.VolumeType == "" && req.Iops == nil && req.Throughput == nil {
+		return nil, api.ErrWithCode(
+			"MissingParameter",
+			fmt.Errorf("at least one of Size, VolumeType, Iops or Throughput must be specified"),
+		)
+	}
+	volume, err := d.describeVolume(ctx, req.VolumeID)
+	if err != nil {
+		return nil, err
+	}
+	// Volumes can only grow, since shrinking the backing file would
+	// discard data.
+	if req.Size != nil && *req.Size <= *volume.Size {
+		return nil, api.InvalidParameterValueError("Size", strconv.Itoa(*req.Size))
+	}
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
+
+	startTime := time.Now().UTC()
+	modification := api.VolumeModification{
+		VolumeID:           req.VolumeID,
+		ModificationState:  types.VolumeModificationStateCompleted,
+		Progress:           volumeModificationProgressCompleted,
+		StartTime:          startTime,
+		OriginalSize:       *volume.Size,
+		OriginalVolumeType: volume.VolumeType,
+		OriginalIOPS:       *volume.IOPS,
+		OriginalThroughput: *volume.Throughput,
+		TargetSize:         *volume.Size,
+		TargetVolumeType:   volume.VolumeType,
+		TargetIOPS:         *volume.IOPS,
+		TargetThroughput:   *volume.Throughput,
+	}
+	if req.Size != nil {
+		modification.TargetSize = *req.Size
+		resizeReq := executor.ResizeVolumeRequest{
+			VolumeID: executorVolumeID(req.VolumeID),
+			Size:     int64(*req.Size) * bytesPerGigaByte,
+		}
+		if err := d.exe.ResizeVolume(ctx, resizeReq); err != nil {
+			return nil, executorError(err)
+		}
+	}
+	if req.VolumeType != "" {
+		modification.TargetVolumeType = req.VolumeType
+	}
+	if req.Iops != nil {
+		modification.TargetIOPS = *req.Iops
+	}
+	if req.Throughput != nil {
+		modification.TargetThroughput = *req.Throughput
+	}
+	// The file is resized synchronously, so the modification completes
+	// right away.
+	endTime := time.Now().UTC()
+	modification.EndTime = &endTime
+
+	raw, err := json.Marshal(modification)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling volume modification: %w", err)
+	}
+	attrs := []storage.Attribute{
+		{Key: attributeNameVolumeType, Value: string(modification.TargetVolumeType)},
+		{Key: attributeNameIOPS, Value: strconv.Itoa(modification.TargetIOPS)},
+		{Key: attributeNameThroughput, Value: strconv.Itoa(modification.TargetThroughput)},
+		{Key: attributeNameVolumeModification, Value: string(raw)},
+	}
+	if err := d.storage.SetResourceAttributes(req.VolumeID, attrs); err != nil {
+		return nil, fmt.Errorf("storing volume modification: %w", err)
+	}
+	api.Logger(ctx).Info(
+		"modified volume",
+		slog.String("volume_id", req.VolumeID),
+		slog.Int("original_size_gib", modification.OriginalSize),
+		slog.Int("target_size_gib", modification.TargetSize),
+	)
+	return &api.ModifyVolumeResponse{VolumeModification: modification}, nil
+}
+
+func (d *Dispatcher) dispatchDescribeVolumesModifications(ctx context.Context, req *api.DescribeVolumesModificationsRequest) (*api.DescribeVolumesModificationsResponse, error) {
+	for _, f := range req.Filters {
+		if f.Name == nil {
+			return nil, api.InvalidParameterValueError("Filter.Name", "<missing>")
+		}
+		switch *f.Name {
+		case "volume-id", "modification-state", "target-size", "original-size":
+		default:
+			return nil, api.InvalidParameterValueError("Filter.Name", *f.Name)
+		}
+	}
+	for _, volumeID := range req.VolumeIDs {
+		if _, err := d.findVolume(ctx, volumeID); err != nil {
+			return nil, err
+		}
+	}
+	volumeIDs, err := d.applyFilters(types.ResourceTypeVolume, req.VolumeIDs, nil)
+	if err != nil {
+		return nil, err
+	}
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
+
+	var modifications []api.VolumeModification
+	for _, volumeID := range volumeIDs {
+		attrs, err := d.storage.ResourceAttributes(volumeID)
+		if err != nil {
+			return nil, fmt.Errorf("retrieving volume attributes: %w", err)
+		}
+		raw, found := attrs.Key(attributeNameVolumeModification)
+		if !found || raw == "" {
+			continue
+		}
+		var modification api.VolumeModification
+		if err := json.Unmarshal([]byte(raw), &modification); err != nil {
+			return nil, fmt.Errorf("unmarshaling modification for volume %s: %w", volumeID, err)
+		}
+		if volumeModificationMatchesFilters(modification, req.Filters) {
+			modifications = append(modifications, modification)
+		}
+	}
+	slices.SortFunc(modifications, func(a, b api.VolumeModification) int {
+		return strings.Compare(a.VolumeID, b.VolumeID)
+	})
+
+	modifications, nextToken, err := applyNextToken(modifications, req.NextToken, req.MaxResults)
+	if err != nil {
+		return nil, err
+	}
+	return &api.DescribeVolumesModificationsResponse{
+		VolumeModifications: modifications,
+		NextToken:           nextToken,
+	}, nil
+}
+
+func volumeModificationMatchesFilters(modification api.VolumeModification, filters []api.Filter) bool {
+	for _, filter := range filters {
+		var value string
+		switch *filter.Name {
+		case "volume-id":
+			value = modification.VolumeID
+		case "modification-state":
+			value = string(modification.ModificationState)
+		case "target-size":
+			value = strconv.Itoa(modification.TargetSize)
+		case "original-size":
+			value = strconv.Itoa(modification.OriginalSize)
+		}
+		if !slices.Contains(filter.Values, value) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,104 @@
+package dc2
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/executor"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+type volumeSizesExecutor struct {
+	*exitCleanupExecutor
+	sizes map[executor.VolumeID]int64
+}
+
+func (e *volumeSizesExecutor) CreateVolume(_ context.Context, req executor.CreateVolumeRequest) (executor.VolumeID, error) {
+	id := executor.VolumeID(fmt.Sprintf("%017x", len(e.sizes)+1))
+	e.sizes[id] = req.Size
+	return id, nil
+}
+
+func (e *volumeSizesExecutor) ResizeVolume(_ context.Context, req executor.ResizeVolumeRequest) error {
+	e.sizes[req.VolumeID] = req.Size
+	return nil
+}
+
+func (e *volumeSizesExecutor) DescribeVolumes(_ context.Context, req executor.DescribeVolumesRequest) ([]executor.VolumeDescription, error) {
+	descs := make([]executor.VolumeDescription, len(req.VolumeIDs))
+	for i, id := range req.VolumeIDs {
+		descs[i] = executor.VolumeDescription{VolumeID: id, Size: e.sizes[id]}
+	}
+	return descs, nil
+}
+
+func TestModifyVolume(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	d := &Dispatcher{
+		exe: &volumeSizesExecutor{
+			exitCleanupExecutor: &exitCleanupExecutor{},
+			sizes:               make(map[executor.VolumeID]int64),
+		},
+		storage: storage.NewMemoryStorage(),
+	}
+	created, err := d.dispatchCreateVolume(ctx, &api.CreateVolumeRequest{
+		AvailabilityZone: "us-east-1a",
+		Size:             new(1),
+		VolumeType:       types.VolumeTypeGp2,
+	})
+	require.NoError(t, err)
+	volumeID := *created.VolumeID
+
+	modifications := func() []api.VolumeModification {
+		resp, err := d.dispatchDescribeVolumesModifications(ctx, &api.DescribeVolumesModificationsRequest{})
+		require.NoError(t, err)
+		return resp.VolumeModifications
+	}
+	assert.Empty(t, modifications())
+
+	resp, err := d.dispatchModifyVolume(ctx, &api.ModifyVolumeRequest{
+		VolumeID:   volumeID,
+		Size:       new(2),
+		VolumeType: types.VolumeTypeGp3,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, types.VolumeModificationStateCompleted, resp.VolumeModification.ModificationState)
+	assert.Equal(t, 1, resp.VolumeModification.OriginalSize)
+	assert.Equal(t, 2, resp.VolumeModification.TargetSize)
+	assert.Equal(t, types.VolumeTypeGp2, resp.VolumeModification.OriginalVolumeType)
+	assert.Equal(t, types.VolumeTypeGp3, resp.VolumeModification.TargetVolumeType)
+
+	volume, err := d.describeVolume(ctx, volumeID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, *volume.Size)
+	assert.Equal(t, types.VolumeTypeGp3, volume.VolumeType)
+
+	described := modifications()
+	require.Len(t, described, 1)
+	assert.Equal(t, volumeID, described[0].VolumeID)
+	assert.Equal(t, 2, described[0].TargetSize)
+
+	filtered, err := d.dispatchDescribeVolumesModifications(ctx, &api.DescribeVolumesModificationsRequest{
+		Filters: []api.Filter{{Name: new("target-size"), Values: []string{"3"}}},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, filtered.VolumeModifications)
+
+	for _, size := range []int{1, 2} {
+		_, err = d.dispatchModifyVolume(ctx, &api.ModifyVolumeRequest{
+			VolumeID: volumeID,
+			Size:     new(size),
+		})
+		var apiErr *api.Error
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, api.ErrorCodeInvalidParameterValue, apiErr.Code)
+	}
+}
@@ -0,0 +1,94 @@
+package dc2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/executor"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+func TestVolumeMatchesFilters(t *testing.T) {
+	t.Parallel()
+
+	volume := api.Volume{
+		AvailabilityZone: new("us-east-1a"),
+		State:            types.VolumeStateInUse,
+		Attachments: []api.VolumeAttachment{
+			{InstanceID: new("i-00000000000000001")},
+		},
+	}
+	filter := func(name string, values ...string) []api.Filter {
+		return []api.Filter{{Name: &name, Values: values}}
+	}
+
+	assert.True(t, volumeMatchesFilters(volume, nil))
+	assert.True(t, volumeMatchesFilters(volume, filter("attachment.instance-id", "i-00000000000000002", "i-00000000000000001")))
+	assert.False(t, volumeMatchesFilters(volume, filter("attachment.instance-id", "i-00000000000000002")))
+	assert.True(t, volumeMatchesFilters(volume, filter("status", "in-use")))
+	assert.False(t, volumeMatchesFilters(volume, filter("status", "available")))
+	assert.True(t, volumeMatchesFilters(volume, filter("availability-zone", "us-east-1a")))
+	assert.False(t, volumeMatchesFilters(volume, filter("availability-zone", "us-east-1b")))
+	assert.False(t, volumeMatchesFilters(api.Volume{}, filter("attachment.instance-id", "i-00000000000000001")))
+
+	_, _, err := splitVolumeFilters(filter("volume-kind", "x"))
+	var apiErr *api.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, api.ErrorCodeInvalidParameterValue, apiErr.Code)
+}
+
+func TestDeleteVolumeRefusesAttachedVolumes(t *testing.T) {
+	t.Parallel()
+
+	const (
+		instanceID = "i-0123456789abcdef0"
+		volumeID   = "vol-0123456789abcdef0"
+	)
+	ctx := t.Context()
+	attachment := executor.VolumeAttachment{
+		Device:     "/dev/sdf",
+		InstanceID: executorInstanceID(instanceID),
+		AttachTime: time.Now(),
+	}
+	exe := &attachedVolumesExecutor{
+		runningInstancesExecutor: &runningInstancesExecutor{exitCleanupExecutor: &exitCleanupExecutor{}},
+		volumes: []executor.VolumeDescription{{
+			VolumeID:    executorVolumeID(volumeID),
+			Attachments: []executor.VolumeAttachment{attachment},
+		}},
+	}
+	d := &Dispatcher{
+		exe:     exe,
+		storage: storage.NewMemoryStorage(),
+	}
+	require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeInstance, ID: instanceID}))
+	registerVolume := func() {
+		t.Helper()
+		require.NoError(t, d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeVolume, ID: volumeID}))
+	}
+	registerVolume()
+
+	_, err := d.dispatchDeleteVolume(ctx, &api.DeleteVolumeRequest{VolumeID: volumeID})
+	var apiErr *api.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, api.ErrorCodeVolumeInUse, apiErr.Code)
+
+	// Once detached, the volume can be deleted
+	exe.volumes[0].Attachments = nil
+	_, err = d.dispatchDeleteVolume(ctx, &api.DeleteVolumeRequest{VolumeID: volumeID})
+	require.NoError(t, err)
+
+	// Attachments left behind by terminated instances don't count
+	registerVolume()
+	exe.volumes[0].Attachments = []executor.VolumeAttachment{attachment}
+	require.NoError(t, d.storage.SetResourceAttributes(instanceID, []storage.Attribute{
+		{Key: attributeNameInstanceTerminatedAt, Value: time.Now().UTC().Format(time.RFC3339Nano)},
+	}))
+	_, err = d.dispatchDeleteVolume(ctx, &api.DeleteVolumeRequest{VolumeID: volumeID})
+	require.NoError(t, err)
+}
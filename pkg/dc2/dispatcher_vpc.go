@@ -0,0 +1,263 @@
+package dc2
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+const (
+	vpcIDPrefix = "vpc-"
+
+	defaultVPCCIDRBlock       = "10.0.0.0/16"
+	defaultVPCDHCPOptionsID   = "dopt-00000000000000000"
+	vpcStateAvailable         = "available"
+	vpcInstanceTenancyDefault = "default"
+
+	// AWS accepts VPC and subnet CIDR blocks between /16 and /28.
+	minVPCCIDRPrefixBits = 16
+	maxVPCCIDRPrefixBits = 28
+
+	attributeNameVPCCIDRBlock       = "VPCCIDRBlock"
+	attributeNameVPCInstanceTenancy = "VPCInstanceTenancy"
+)
+
+type vpcData struct {
+	ID              string
+	CIDRBlock       netip.Prefix
+	InstanceTenancy string
+	IsDefault       bool
+	Tags            []api.Tag
+}
+
+func (d *Dispatcher) dispatchCreateVpc(ctx context.Context, req *api.CreateVpcRequest) (*api.CreateVpcResponse, error) {
+	if err := validateTagSpecifications(req.TagSpecifications, types.ResourceTypeVpc); err != nil {
+		return nil, err
+	}
+	cidrBlock, err := parseVPCCIDRBlock("CidrBlock", req.CIDRBlock, "InvalidVpc.Range")
+	if err != nil {
+		return nil, err
+	}
+	instanceTenancy := vpcInstanceTenancyDefault
+	if tenancy := valueOrEmpty(req.InstanceTenancy); tenancy != "" {
+		if tenancy != vpcInstanceTenancyDefault && tenancy != "dedicated" {
+			return nil, api.InvalidParameterValueError("InstanceTenancy", tenancy)
+		}
+		instanceTenancy = tenancy
+	}
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
+
+	vpc := vpcData{
+		CIDRBlock:       cidrBlock,
+		InstanceTenancy: instanceTenancy,
+		Tags:            tagSpecsToTags(req.TagSpecifications),
+	}
+	vpc.ID, err = makeID(vpcIDPrefix)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.storage.RegisterResource(storage.Resource{Type: types.ResourceTypeVpc, ID: vpc.ID}); err != nil {
+		return nil, fmt.Errorf("registering vpc: %w", err)
+	}
+	attrs := []storage.Attribute{
+		{Key: attributeNameVPCCIDRBlock, Value: vpc.CIDRBlock.String()},
+		{Key: attributeNameVPCInstanceTenancy, Value: vpc.InstanceTenancy},
+	}
+	for _, tag := range vpc.Tags {
+		attrs = append(attrs, storage.Attribute{Key: storage.TagAttributeName(tag.Key), Value: tag.Value})
+	}
+	if err := d.storage.SetResourceAttributes(vpc.ID, attrs); err != nil {
+		return nil, fmt.Errorf("saving vpc attributes: %w", err)
+	}
+	api.Logger(ctx).Info("created vpc", slog.String("vpc_id", vpc.ID), slog.String("cidr_block", vpc.CIDRBlock.String()))
+	return &api.CreateVpcResponse{Vpc: apiVpc(vpc)}, nil
+}
+
+func (d *Dispatcher) dispatchDescribeVpcs(_ context.Context, req *api.DescribeVpcsRequest) (*api.DescribeVpcsResponse, error) {
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
+	vpcs, err := d.listVPCs()
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range req.VPCIDs {
+		if !slices.ContainsFunc(vpcs, func(v vpcData) bool { return v.ID == id }) {
+			return nil, vpcNotFoundError(id)
+		}
+	}
+
+	out := make([]api.Vpc, 0, len(vpcs))
+	for _, vpc := range vpcs {
+		if len(req.VPCIDs) > 0 && !slices.Contains(req.VPCIDs, vpc.ID) {
+			continue
+		}
+		apiVPC := apiVpc(vpc)
+		matches, err := vpcMatchesFilters(apiVPC, req.Filters)
+		if err != nil {
+			return nil, err
+		}
+		if matches {
+			out = append(out, apiVPC)
+		}
+	}
+	paged, nextToken, err := applyNextToken(out, req.NextToken, req.MaxResults)
+	if err != nil {
+		return nil, api.InvalidParameterValueError("NextToken", stringValue(req.NextToken))
+	}
+	return &api.DescribeVpcsResponse{Vpcs: paged, NextToken: nextToken}, nil
+}
+
+func (d *Dispatcher) dispatchDeleteVpc(ctx context.Context, req *api.DeleteVpcRequest) (*api.DeleteVpcResponse, error) {
+	vpc, err := d.findVPC(req.VPCID)
+	if err != nil {
+		return nil, err
+	}
+	if vpc.IsDefault {
+		return nil, api.ErrWithCode(api.ErrorCodeOperationNotPermitted, fmt.Errorf("The default VPC '%s' can't be deleted.", vpc.ID)) //nolint
+	}
+	subnets, err := d.listSubnets()
+	if err != nil {
+		return nil, err
+	}
+	if slices.ContainsFunc(subnets, func(s subnetData) bool { return s.VPCID == vpc.ID }) {
+		return nil, api.ErrWithCode(
+			"DependencyViolation",
+			fmt.Errorf("The vpc '%s' has dependencies and cannot be deleted.", vpc.ID), //nolint
+		)
+	}
+	if req.DryRun {
+		return nil, api.DryRunError()
+	}
+	if err := d.storage.RemoveResource(vpc.ID); err != nil {
+		return nil, fmt.Errorf("deleting vpc: %w", err)
+	}
+	api.Logger(ctx).Info("deleted vpc", slog.String("vpc_id", vpc.ID))
+	return &api.DeleteVpcResponse{Return: true}, nil
+}
+
+// listVPCs returns the default VPC, which always exists, followed by the
+// created ones.
+func (d *Dispatcher) listVPCs() ([]vpcData, error) {
+	resources, err := d.storage.RegisteredResources(types.ResourceTypeVpc)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving vpcs: %w", err)
+	}
+	vpcs := make([]vpcData, 0, len(resources)+1)
+	vpcs = append(vpcs, vpcData{
+		ID:              defaultSubnetVPCID,
+		CIDRBlock:       netip.MustParsePrefix(defaultVPCCIDRBlock),
+		InstanceTenancy: vpcInstanceTenancyDefault,
+		IsDefault:       true,
+	})
+	for _, r := range resources {
+		attrs, err := d.storage.ResourceAttributes(r.ID)
+		if err != nil {
+			return nil, fmt.Errorf("retrieving vpc attributes: %w", err)
+		}
+		vpc := vpcData{ID: r.ID, Tags: tagsFromAttributes(attrs)}
+		rawCIDRBlock, _ := attrs.Key(attributeNameVPCCIDRBlock)
+		vpc.CIDRBlock, err = netip.ParsePrefix(rawCIDRBlock)
+		if err != nil {
+			return nil, fmt.Errorf("parsing vpc cidr block: %w", err)
+		}
+		vpc.InstanceTenancy, _ = attrs.Key(attributeNameVPCInstanceTenancy)
+		vpcs = append(vpcs, vpc)
+	}
+	return vpcs, nil
+}
+
+func (d *Dispatcher) findVPC(vpcID string) (*vpcData, error) {
+	vpcs, err := d.listVPCs()
+	if err != nil {
+		return nil, err
+	}
+	idx := slices.IndexFunc(vpcs, func(v vpcData) bool { return v.ID == vpcID })
+	if idx < 0 {
+		return nil, vpcNotFoundError(vpcID)
+	}
+	return &vpcs[idx], nil
+}
+
+// parseVPCCIDRBlock parses an IPv4 CIDR block for a VPC or a subnet, which
+// must be in canonical form and have a size AWS accepts. Sizes out of range
+// are reported with rangeErrorCode.
+func parseVPCCIDRBlock(param string, value string, rangeErrorCode string) (netip.Prefix, error) {
+	prefix, err := netip.ParsePrefix(strings.TrimSpace(value))
+	if err != nil || !prefix.Addr().Is4() || prefix.Masked() != prefix {
+		return netip.Prefix{}, api.InvalidParameterValueError(param, value)
+	}
+	if prefix.Bits() < minVPCCIDRPrefixBits || prefix.Bits() > maxVPCCIDRPrefixBits {
+		return netip.Prefix{}, api.ErrWithCode(rangeErrorCode, fmt.Errorf("The CIDR '%s' is invalid.", value)) //nolint
+	}
+	return prefix, nil
+}
+
+func apiVpc(vpc vpcData) api.Vpc {
+	return api.Vpc{
+		VPCID:           vpc.ID,
+		State:           vpcStateAvailable,
+		CIDRBlock:       vpc.CIDRBlock.String(),
+		DHCPOptionsID:   defaultVPCDHCPOptionsID,
+		InstanceTenancy: vpc.InstanceTenancy,
+		IsDefault:       vpc.IsDefault,
+		OwnerID:         defaultSecurityGroupOwnerID,
+		Tags:            vpc.Tags,
+	}
+}
+
+func vpcMatchesFilters(vpc api.Vpc, filters []api.Filter) (bool, error) {
+	for _, filter := range filters {
+		if filter.Name == nil {
+			return false, api.InvalidParameterValueError("Filter.Name", "<missing>")
+		}
+		var value string
+		switch name := *filter.Name; {
+		case name == "vpc-id":
+			value = vpc.VPCID
+		case name == "cidr", name == "cidr-block-association.cidr-block":
+			value = vpc.CIDRBlock
+		case name == "state":
+			value = vpc.State
+		case name == "is-default":
+			value = strconv.FormatBool(vpc.IsDefault)
+		case name == "owner-id":
+			value = vpc.OwnerID
+		case name == "dhcp-options-id":
+			value = vpc.DHCPOptionsID
+		case name == "tag-key":
+			if !slices.ContainsFunc(vpc.Tags, func(tag api.Tag) bool { return slices.Contains(filter.Values, tag.Key) }) {
+				return false, nil
+			}
+			continue
+		case strings.HasPrefix(name, "tag:"):
+			tagKey := strings.TrimPrefix(name, "tag:")
+			if !slices.ContainsFunc(vpc.Tags, func(tag api.Tag) bool {
+				return tag.Key == tagKey && slices.Contains(filter.Values, tag.Value)
+			}) {
+				return false, nil
+			}
+			continue
+		default:
+			return false, api.InvalidParameterValueError("Filter.Name", name)
+		}
+		if !slices.Contains(filter.Values, value) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func vpcNotFoundError(vpcID string) error {
+	return api.ErrWithCode("InvalidVpcID.NotFound", fmt.Errorf("The vpc ID '%s' does not exist", vpcID)) //nolint
+}
@@ -0,0 +1,141 @@
+package dc2
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+)
+
+func TestVPCsAndSubnets(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	d := &Dispatcher{
+		opts:    DispatcherOptions{Region: "us-east-1"},
+		exe:     newScalingExecutor(),
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+	vpcResp, err := d.dispatchCreateVpc(ctx, &api.CreateVpcRequest{CIDRBlock: "10.1.0.0/16"})
+	require.NoError(t, err)
+	vpcID := vpcResp.Vpc.VPCID
+	assert.Regexp(t, `^vpc-[0-9a-f]{17}$`, vpcID)
+
+	var apiErr *api.Error
+	_, err = d.dispatchCreateVpc(ctx, &api.CreateVpcRequest{CIDRBlock: "10.0.0.0/8"})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "InvalidVpc.Range", apiErr.Code)
+
+	createSubnet := func(cidrBlock string, availabilityZone string) string {
+		t.Helper()
+		resp, err := d.dispatchCreateSubnet(ctx, &api.CreateSubnetRequest{
+			VPCID:            vpcID,
+			CIDRBlock:        cidrBlock,
+			AvailabilityZone: &availabilityZone,
+		})
+		require.NoError(t, err)
+		return *resp.Subnet.SubnetID
+	}
+	subnetA := createSubnet("10.1.0.0/24", "us-east-1a")
+	subnetB := createSubnet("10.1.1.0/24", "us-east-1b")
+
+	_, err = d.dispatchCreateSubnet(ctx, &api.CreateSubnetRequest{VPCID: vpcID, CIDRBlock: "10.1.1.128/25"})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "InvalidSubnet.Conflict", apiErr.Code)
+	_, err = d.dispatchCreateSubnet(ctx, &api.CreateSubnetRequest{VPCID: vpcID, CIDRBlock: "10.2.0.0/24"})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "InvalidSubnet.Range", apiErr.Code)
+
+	describeResp, err := d.dispatchDescribeSubnets(ctx, &api.DescribeSubnetsRequest{
+		Filters: []api.Filter{{Name: new("vpc-id"), Values: []string{vpcID}}},
+	})
+	require.NoError(t, err)
+	zones := map[string]string{}
+	for _, subnet := range describeResp.Subnets {
+		zones[*subnet.SubnetID] = *subnet.AvailabilityZone
+	}
+	assert.Equal(t, map[string]string{subnetA: "us-east-1a", subnetB: "us-east-1b"}, zones)
+
+	// Instances in a subnet take its zone
+	runResp, err := d.dispatchRunInstances(ctx, &api.RunInstancesRequest{
+		ImageID:      "nginx",
+		InstanceType: "my-type",
+		MinCount:     1,
+		MaxCount:     1,
+		SubnetID:     subnetB,
+	})
+	require.NoError(t, err)
+	require.Len(t, runResp.InstancesSet, 1)
+	assert.Equal(t, subnetB, runResp.InstancesSet[0].SubnetID)
+	assert.Equal(t, vpcID, runResp.InstancesSet[0].VPCID)
+	assert.Equal(t, "us-east-1b", runResp.InstancesSet[0].Placement.AvailabilityZone)
+	_, err = d.dispatchRunInstances(ctx, &api.RunInstancesRequest{
+		ImageID:      "nginx",
+		InstanceType: "my-type",
+		MinCount:     1,
+		MaxCount:     1,
+		SubnetID:     subnetB,
+		Placement:    &api.Placement{AvailabilityZone: "us-east-1a"},
+	})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, api.ErrorCodeInvalidParameterValue, apiErr.Code)
+
+	_, err = d.dispatchDeleteSubnet(ctx, &api.DeleteSubnetRequest{SubnetID: subnetB})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "DependencyViolation", apiErr.Code)
+	_, err = d.dispatchDeleteVpc(ctx, &api.DeleteVpcRequest{VPCID: vpcID})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "DependencyViolation", apiErr.Code)
+	_, err = d.dispatchDeleteVpc(ctx, &api.DeleteVpcRequest{VPCID: defaultSubnetVPCID})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, api.ErrorCodeOperationNotPermitted, apiErr.Code)
+
+	_, err = d.dispatchDeleteSubnet(ctx, &api.DeleteSubnetRequest{SubnetID: subnetA})
+	require.NoError(t, err)
+	describeResp, err = d.dispatchDescribeSubnets(ctx, &api.DescribeSubnetsRequest{SubnetIDs: []string{subnetA}})
+	require.NoError(t, err)
+	assert.Empty(t, describeResp.Subnets)
+}
+
+func TestAutoScalingGroupSubnetPlacements(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	d := &Dispatcher{
+		opts:    DispatcherOptions{Region: "us-east-1"},
+		exe:     newScalingExecutor(),
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+	vpcResp, err := d.dispatchCreateVpc(ctx, &api.CreateVpcRequest{CIDRBlock: "10.1.0.0/16"})
+	require.NoError(t, err)
+	var subnetIDs []string
+	for i, availabilityZone := range []string{"us-east-1a", "us-east-1b"} {
+		resp, err := d.dispatchCreateSubnet(ctx, &api.CreateSubnetRequest{
+			VPCID:            vpcResp.Vpc.VPCID,
+			CIDRBlock:        []string{"10.1.0.0/24", "10.1.1.0/24"}[i],
+			AvailabilityZone: &availabilityZone,
+		})
+		require.NoError(t, err)
+		subnetIDs = append(subnetIDs, *resp.Subnet.SubnetID)
+	}
+	vpcZoneIdentifier := subnetIDs[0] + "," + subnetIDs[1]
+
+	var apiErr *api.Error
+	err = d.validateAutoScalingPlacement(new(subnetIDs[0]+",subnet-0123456789abcdef0"), nil)
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "ValidationError", apiErr.Code)
+	require.NoError(t, d.validateAutoScalingPlacement(&vpcZoneIdentifier, nil))
+
+	group := &autoScalingGroupData{Name: "asg", VPCZoneIdentifier: &vpcZoneIdentifier}
+	placements, err := d.autoScalingLaunchPlacements(group, 3, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []autoScalingLaunchPlacement{
+		{AvailabilityZone: "us-east-1a", SubnetID: subnetIDs[0], Count: 2},
+		{AvailabilityZone: "us-east-1b", SubnetID: subnetIDs[1], Count: 1},
+	}, placements)
+}
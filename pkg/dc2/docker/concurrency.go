@@ -0,0 +1,53 @@
+package docker
+
+import (
+	"context"
+	"sync"
+)
+
+// defaultStateChangeConcurrency is the number of containers started or
+// stopped at the same time when ExecutorOptions.StateChangeConcurrency is
+// not set.
+const defaultStateChangeConcurrency = 8
+
+// forEachConcurrently calls fn for every index in [0, n), running at most
+// concurrency calls at the same time. The first error cancels the context
+// passed to the other calls, stops starting new ones and is returned once
+// the running calls finish.
+func forEachConcurrently(ctx context.Context, n int, concurrency int, fn func(ctx context.Context, i int) error) error {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	sem := make(chan struct{}, concurrency)
+	for i := range n {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Go(func() {
+			defer func() { <-sem }()
+			if err := fn(ctx, i); err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		})
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
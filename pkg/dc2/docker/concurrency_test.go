@@ -0,0 +1,81 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForEachConcurrently(t *testing.T) {
+	t.Parallel()
+
+	const (
+		count = 10
+		delay = 50 * time.Millisecond
+	)
+	results := make([]int, count)
+	var running, maxRunning atomic.Int32
+	start := time.Now()
+	err := forEachConcurrently(t.Context(), count, defaultStateChangeConcurrency, func(ctx context.Context, i int) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			current := maxRunning.Load()
+			if n <= current || maxRunning.CompareAndSwap(current, n) {
+				break
+			}
+		}
+		// Finish in reverse order, so ordering doesn't depend on timing
+		time.Sleep(delay + time.Duration(count-i)*time.Millisecond)
+		results[i] = i
+		return nil
+	})
+	elapsed := time.Since(start)
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, results)
+	assert.LessOrEqual(t, maxRunning.Load(), int32(defaultStateChangeConcurrency))
+	// Sequentially, this would take over count*delay
+	assert.Less(t, elapsed, count*delay/2)
+}
+
+func TestForEachConcurrentlyError(t *testing.T) {
+	t.Parallel()
+
+	errFailed := errors.New("failed")
+	var canceled, started atomic.Int32
+	err := forEachConcurrently(t.Context(), 10, 2, func(ctx context.Context, i int) error {
+		started.Add(1)
+		if i == 1 {
+			return errFailed
+		}
+		select {
+		case <-ctx.Done():
+			canceled.Add(1)
+		case <-time.After(5 * time.Second):
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, errFailed)
+	assert.Equal(t, int32(1), canceled.Load())
+	assert.Less(t, started.Load(), int32(10))
+}
+
+func BenchmarkForEachConcurrently(b *testing.B) {
+	for _, concurrency := range []int{1, defaultStateChangeConcurrency} {
+		b.Run(fmt.Sprintf("concurrency=%d", concurrency), func(b *testing.B) {
+			for b.Loop() {
+				_ = forEachConcurrently(b.Context(), 10, concurrency, func(context.Context, int) error {
+					time.Sleep(time.Millisecond)
+					return nil
+				})
+			}
+		})
+	}
+}
@@ -9,13 +9,17 @@ import (
 	"io"
 	"log/slog"
 	"maps"
+	"math"
 	"net/netip"
 	"os"
+	"path"
+	"path/filepath"
 	"runtime"
 	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	cerrdefs "github.com/containerd/errdefs"
@@ -26,6 +30,7 @@ import (
 	"github.com/moby/moby/api/types/network"
 	"github.com/moby/moby/api/types/volume"
 	"github.com/moby/moby/client"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 
 	"github.com/fiam/dc2/pkg/dc2/api"
 	"github.com/fiam/dc2/pkg/dc2/executor"
@@ -44,8 +49,8 @@ const (
 
 const (
 	mainResourceNamePrefix = "dc2"
-	mainVolumePath         = "/dc2"
-	mainContainerImageName = "alpine:3.23.3"
+	defaultVolumeRoot      = "/dc2"
+	defaultMainImage       = "alpine:3.23.3"
 	mainContainerNameBase  = "dc2-main"
 	loopDevicePrefix       = "/dev/loop"
 
@@ -71,7 +76,11 @@ const (
 	imdsProxyRetryDelay    = 100 * time.Millisecond
 	imdsProxyReadyTimeout  = 60 * time.Second
 
+	dockerPingTimeout = 5 * time.Second
+
 	maxAuxResourcePrefixLength = 55
+	// maxConsoleOutputBytes matches the output size returned by GetConsoleOutput.
+	maxConsoleOutputBytes = 64 * 1024
 )
 
 var (
@@ -85,18 +94,67 @@ var (
 var _ executor.Executor = (*Executor)(nil)
 
 type Executor struct {
-	cli                  *client.Client
-	mainVolume           volume.Volume
-	mainContainerID      string
+	cli                *client.Client
+	mainVolume         volume.Volume
+	mainVolumeHostPath string
+	mainContainerID    string
+	// volumeRoot is the path the main volume is mounted at in the main
+	// container and in instances.
+	volumeRoot           string
 	dc2RuntimeMode       string
 	instanceNetwork      string
 	ownsInstanceNetwork  bool
 	imdsBackendHostValue string
+	// disableResourceLimits skips applying instance type CPU and memory
+	// limits to instance containers.
+	disableResourceLimits bool
+	// hostCPUs caps CPU limits, since Docker rejects limits above the CPUs
+	// available to the daemon.
+	hostCPUs int
+	// stateChangeConcurrency is the number of containers started or
+	// stopped at the same time.
+	stateChangeConcurrency int
+	// publishPort is the container port instances publish on their public
+	// IP, or zero when publishing is disabled.
+	publishPort int
+	// publishMu serializes public IP allocation, so concurrent launches
+	// don't pick the same address.
+	publishMu sync.Mutex
+	// inspectGeneration is increased by mutating calls, invalidating the
+	// inspections cached by WithInspectCache.
+	inspectGeneration atomic.Uint64
+
+	// volumeAttachmentMu serializes loop device allocation and attachment
+	// record updates, which are shared by all instances.
+	volumeAttachmentMu sync.Mutex
 }
 
 type ExecutorOptions struct {
 	IMDSBackendPort int
 	InstanceNetwork string
+	// MainVolumeHostPath, when set, is an absolute directory on the Docker
+	// host bind-mounted as the main volume instead of a Docker volume, so EBS
+	// volume files live on the host filesystem. The directory must exist and
+	// be writable, and it's left in place when the executor is closed.
+	MainVolumeHostPath string
+	// DisableResourceLimits launches instances without CPU and memory
+	// limits, for environments where cgroups can't be enforced.
+	DisableResourceLimits bool
+	// StateChangeConcurrency is the number of containers started or stopped
+	// at the same time by StartInstances and StopInstances. Zero or a negative
+	// value uses defaultStateChangeConcurrency.
+	StateChangeConcurrency int
+	// MainImage is the image of the main container, which manages EBS
+	// volume files. It must provide a shell and coreutils. Empty uses
+	// defaultMainImage.
+	MainImage string
+	// VolumeRoot is the absolute path the main volume is mounted at inside
+	// the main container and instances. Empty uses defaultVolumeRoot.
+	VolumeRoot string
+	// PublishPort, when positive, publishes the given container TCP port of
+	// every instance on the same port of a loopback address allocated for
+	// it, which is then reported as the instance public IP.
+	PublishPort int
 }
 
 func imdsNetwork() string {
@@ -162,12 +220,14 @@ func createContainer(
 	hostConfig *container.HostConfig,
 	networkingConfig *network.NetworkingConfig,
 	name string,
+	platform *ocispec.Platform,
 ) (client.ContainerCreateResult, error) {
 	return cli.ContainerCreate(ctx, client.ContainerCreateOptions{
 		Config:           containerConfig,
 		HostConfig:       hostConfig,
 		NetworkingConfig: networkingConfig,
 		Name:             name,
+		Platform:         platform,
 	})
 }
 
@@ -181,6 +241,16 @@ func stopContainer(ctx context.Context, cli *client.Client, containerID string,
 	return err
 }
 
+func restartContainer(ctx context.Context, cli *client.Client, containerID string) error {
+	_, err := cli.ContainerRestart(ctx, containerID, client.ContainerRestartOptions{})
+	return err
+}
+
+func renameContainer(ctx context.Context, cli *client.Client, containerID string, name string) error {
+	_, err := cli.ContainerRename(ctx, containerID, client.ContainerRenameOptions{NewName: name})
+	return err
+}
+
 func removeContainer(ctx context.Context, cli *client.Client, containerID string, force bool) error {
 	_, err := cli.ContainerRemove(ctx, containerID, client.ContainerRemoveOptions{
 		Force:         force,
@@ -495,7 +565,7 @@ func resolveIMDSBackendHost(ctx context.Context, cli *client.Client) (host strin
 func ensureIMDSProxyContainer(ctx context.Context, cli *client.Client, imageName string, runtimeMode string) error {
 	networkName := imdsNetwork()
 
-	if err := pullImage(ctx, cli, imageName); err != nil {
+	if err := pullImage(ctx, cli, imageName, nil); err != nil {
 		return fmt.Errorf("pulling IMDS proxy image: %w", err)
 	}
 
@@ -845,7 +915,7 @@ exec /usr/local/openresty/bin/openresty -g 'daemon off;' -c /etc/nginx/nginx.con
 			},
 		},
 	}
-	cont, err := createContainer(ctx, cli, containerConfig, hostConfig, networkingConfig, imdsProxyContainerName)
+	cont, err := createContainer(ctx, cli, containerConfig, hostConfig, networkingConfig, imdsProxyContainerName, nil)
 	if err == nil {
 		return cont.ID, true, nil
 	}
@@ -1120,6 +1190,23 @@ func imdsProxyContainerNeedsRecreate(info *container.InspectResponse, networkNam
 	return false
 }
 
+// resolveVolumeRoot validates the path the main volume is mounted at,
+// returning defaultVolumeRoot when it's empty.
+func resolveVolumeRoot(volumeRoot string) (string, error) {
+	volumeRoot = strings.TrimSpace(volumeRoot)
+	if volumeRoot == "" {
+		return defaultVolumeRoot, nil
+	}
+	if !path.IsAbs(volumeRoot) {
+		return "", fmt.Errorf("volume root %q must be absolute", volumeRoot)
+	}
+	volumeRoot = path.Clean(volumeRoot)
+	if volumeRoot == "/" {
+		return "", fmt.Errorf("volume root %q must not be the root directory", volumeRoot)
+	}
+	return volumeRoot, nil
+}
+
 func resolveIMDSProxyImage() string {
 	if value := strings.TrimSpace(os.Getenv(imdsProxyImageEnvVar)); value != "" {
 		return value
@@ -1131,19 +1218,51 @@ func NewExecutor(ctx context.Context, opts ExecutorOptions) (*Executor, error) {
 	if opts.IMDSBackendPort <= 0 {
 		return nil, fmt.Errorf("invalid IMDS backend port %d", opts.IMDSBackendPort)
 	}
+	mainVolumeHostPath := strings.TrimSpace(opts.MainVolumeHostPath)
+	if mainVolumeHostPath != "" && !filepath.IsAbs(mainVolumeHostPath) {
+		return nil, fmt.Errorf("main volume host path %q must be absolute", mainVolumeHostPath)
+	}
+	mainImage := strings.TrimSpace(opts.MainImage)
+	if mainImage == "" {
+		mainImage = defaultMainImage
+	}
+	volumeRoot, err := resolveVolumeRoot(opts.VolumeRoot)
+	if err != nil {
+		return nil, err
+	}
+	if opts.PublishPort < 0 || opts.PublishPort > math.MaxUint16 {
+		return nil, fmt.Errorf("invalid publish port %d", opts.PublishPort)
+	}
+	stateChangeConcurrency := opts.StateChangeConcurrency
+	if stateChangeConcurrency <= 0 {
+		stateChangeConcurrency = defaultStateChangeConcurrency
+	}
 	cli, err := client.New(client.FromEnv)
 	if err != nil {
 		return nil, fmt.Errorf("creating Docker client: %w", err)
 	}
 
-	pingContext, cancel := context.WithTimeout(ctx, 5*time.Second)
+	pingContext, cancel := context.WithTimeout(ctx, dockerPingTimeout)
 	defer cancel()
 	if _, err := cli.Ping(pingContext, client.PingOptions{}); err != nil {
 		return nil, fmt.Errorf("pinging Docker daemon: %w", err)
 	}
+	// Pull the main image before creating any resources, so an image that
+	// can't be pulled fails at startup without leaving anything behind
+	if err := pullImage(ctx, cli, mainImage, nil); err != nil {
+		return nil, fmt.Errorf("pulling main image %s: %w", mainImage, err)
+	}
 	if err := ensureIMDSNetwork(ctx, cli); err != nil {
 		return nil, err
 	}
+	var hostCPUs int
+	if !opts.DisableResourceLimits {
+		info, err := cli.Info(ctx, client.InfoOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("retrieving Docker daemon info: %w", err)
+		}
+		hostCPUs = info.Info.NCPU
+	}
 	imdsBackendHost, dc2RuntimeMode, err := resolveIMDSBackendHost(ctx, cli)
 	if err != nil {
 		return nil, fmt.Errorf("resolving IMDS backend host: %w", err)
@@ -1153,6 +1272,9 @@ func NewExecutor(ctx context.Context, opts ExecutorOptions) (*Executor, error) {
 	if err != nil {
 		return nil, err
 	}
+	if opts.PublishPort > 0 && (instanceNetwork == hostNetworkMode || instanceNetwork == noneNetworkMode) {
+		return nil, fmt.Errorf("instance ports can't be published on the %s network", instanceNetwork)
+	}
 	ownsInstanceNetwork, err := ensureInstanceNetwork(ctx, cli, instanceNetwork)
 	if err != nil {
 		return nil, err
@@ -1167,18 +1289,21 @@ func NewExecutor(ctx context.Context, opts ExecutorOptions) (*Executor, error) {
 	mainVolumeResourceName := resourcePrefix + suffix
 	mainContainerResourceName := mainContainerNameBase + suffix
 
-	// Creating an already existing volume is a valid operation
-	vol, err := createVolume(ctx, cli, mainVolumeResourceName)
-
-	if err != nil {
-		return nil, fmt.Errorf("creating dc2 master volume")
+	var vol volume.Volume
+	if mainVolumeHostPath == "" {
+		// Creating an already existing volume is a valid operation
+		vol, err = createVolume(ctx, cli, mainVolumeResourceName)
+		if err != nil {
+			return nil, fmt.Errorf("creating dc2 master volume")
+		}
 	}
 
 	id, err := createMainContainer(
 		ctx,
 		cli,
 		mainContainerResourceName,
-		mainVolumeResourceName,
+		mainImage,
+		dc2Mounts(vol.Name, mainVolumeHostPath, volumeRoot),
 		opts.IMDSBackendPort,
 		imdsBackendHost,
 		dc2RuntimeMode,
@@ -1187,30 +1312,59 @@ func NewExecutor(ctx context.Context, opts ExecutorOptions) (*Executor, error) {
 	if err != nil {
 		return nil, fmt.Errorf("creating main container: %w", err)
 	}
-	if err := ensureIMDSProxyContainer(ctx, cli, imdsProxyImage, dc2RuntimeMode); err != nil {
+	cleanupOnInitError := func() {
 		if removeErr := removeContainer(ctx, cli, id, true); removeErr != nil && !cerrdefs.IsNotFound(removeErr) {
-			slog.Warn("failed to clean up main container after IMDS initialization failure", slog.String("container_id", id), slog.Any("error", removeErr))
+			slog.Warn("failed to clean up main container after initialization failure", slog.String("container_id", id), slog.Any("error", removeErr))
 		}
-		if removeErr := removeVolume(ctx, cli, vol.Name, true); removeErr != nil && !cerrdefs.IsNotFound(removeErr) {
-			slog.Warn("failed to clean up main volume after IMDS initialization failure", slog.String("volume", vol.Name), slog.Any("error", removeErr))
+		if vol.Name != "" {
+			if removeErr := removeVolume(ctx, cli, vol.Name, true); removeErr != nil && !cerrdefs.IsNotFound(removeErr) {
+				slog.Warn("failed to clean up main volume after initialization failure", slog.String("volume", vol.Name), slog.Any("error", removeErr))
+			}
 		}
 		if ownsInstanceNetwork {
 			if removeErr := removeNetwork(ctx, cli, instanceNetwork); removeErr != nil && !cerrdefs.IsNotFound(removeErr) {
-				slog.Warn("failed to clean up instance network after IMDS initialization failure", slog.String("network", instanceNetwork), slog.Any("error", removeErr))
+				slog.Warn("failed to clean up instance network after initialization failure", slog.String("network", instanceNetwork), slog.Any("error", removeErr))
 			}
 		}
+	}
+
+	e := &Executor{
+		cli:                    cli,
+		mainVolume:             vol,
+		mainVolumeHostPath:     mainVolumeHostPath,
+		mainContainerID:        id,
+		volumeRoot:             volumeRoot,
+		dc2RuntimeMode:         dc2RuntimeMode,
+		instanceNetwork:        instanceNetwork,
+		ownsInstanceNetwork:    ownsInstanceNetwork,
+		imdsBackendHostValue:   imdsBackendHost,
+		disableResourceLimits:  opts.DisableResourceLimits,
+		hostCPUs:               hostCPUs,
+		stateChangeConcurrency: stateChangeConcurrency,
+		publishPort:            opts.PublishPort,
+	}
+	if mainVolumeHostPath != "" {
+		if err := e.checkMainVolumeWritable(ctx); err != nil {
+			cleanupOnInitError()
+			return nil, fmt.Errorf("main volume host path %q is not writable: %w", mainVolumeHostPath, err)
+		}
+	}
+	if err := ensureIMDSProxyContainer(ctx, cli, imdsProxyImage, dc2RuntimeMode); err != nil {
+		cleanupOnInitError()
 		return nil, fmt.Errorf("initializing IMDS infrastructure: %w", err)
 	}
+	return e, nil
+}
 
-	return &Executor{
-		cli:                  cli,
-		mainVolume:           vol,
-		mainContainerID:      id,
-		dc2RuntimeMode:       dc2RuntimeMode,
-		instanceNetwork:      instanceNetwork,
-		ownsInstanceNetwork:  ownsInstanceNetwork,
-		imdsBackendHostValue: imdsBackendHost,
-	}, nil
+// checkMainVolumeWritable creates and removes a file in the main volume from
+// the main container, which runs with the same privileges as instances.
+func (e *Executor) checkMainVolumeWritable(ctx context.Context) error {
+	probePath := e.volumeRoot + "/.dc2-write-check-" + e.mainContainerID[:12]
+	cmd := []string{"sh", "-c", fmt.Sprintf("touch %s && rm %s", probePath, probePath)}
+	if _, _, err := e.execInMainContainer(ctx, cmd); err != nil {
+		return err
+	}
+	return nil
 }
 
 func (e *Executor) Close(ctx context.Context) error {
@@ -1223,8 +1377,10 @@ func (e *Executor) Close(ctx context.Context) error {
 			fmt.Errorf("removing main container %s: %w", e.mainContainerID, err),
 		)
 	}
-	if err := removeVolume(ctx, e.cli, e.mainVolume.Name, true); err != nil && !cerrdefs.IsNotFound(err) {
-		closeErr = errors.Join(closeErr, fmt.Errorf("removing main volume %s: %w", e.mainContainerID, err))
+	if e.mainVolume.Name != "" {
+		if err := removeVolume(ctx, e.cli, e.mainVolume.Name, true); err != nil && !cerrdefs.IsNotFound(err) {
+			closeErr = errors.Join(closeErr, fmt.Errorf("removing main volume %s: %w", e.mainContainerID, err))
+		}
 	}
 	removedIMDSProxy, err := e.removeIMDSProxyIfUnused(ctx, ignoreMainContainerID)
 	if err != nil {
@@ -1251,6 +1407,16 @@ func (e *Executor) Disconnect() error {
 	return e.cli.Close()
 }
 
+// Ping checks that the Docker daemon is reachable.
+func (e *Executor) Ping(ctx context.Context) error {
+	pingContext, cancel := context.WithTimeout(ctx, dockerPingTimeout)
+	defer cancel()
+	if _, err := e.cli.Ping(pingContext, client.PingOptions{}); err != nil {
+		return fmt.Errorf("pinging Docker daemon: %w", err)
+	}
+	return nil
+}
+
 func (e *Executor) ListOwnedInstances(ctx context.Context) ([]executor.InstanceID, error) {
 	containers, err := listContainers(
 		ctx,
@@ -1400,9 +1566,23 @@ func (e *Executor) removeInstanceNetworkIfUnused(ctx context.Context, ignoreMain
 }
 
 func (e *Executor) CreateInstances(ctx context.Context, req executor.CreateInstancesRequest) ([]executor.InstanceID, error) {
-	if err := pullImage(ctx, e.cli, req.ImageID); err != nil {
+	defer e.invalidateInspectCaches()
+	platform := dockerPlatform(req.Architecture)
+	if err := pullImage(ctx, e.cli, req.ImageID, platform); err != nil {
 		return nil, fmt.Errorf("pulling image: %w", err)
 	}
+	var publishedAddrs map[netip.Addr]bool
+	if e.publishPort > 0 {
+		// Hold the lock until the containers are created, so their
+		// addresses are listed by the next allocation
+		e.publishMu.Lock()
+		defer e.publishMu.Unlock()
+		var err error
+		publishedAddrs, err = e.publishedAddresses(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
 	instanceIDs := make([]executor.InstanceID, req.Count)
 	for i := range req.Count {
 		instanceID, err := idgen.Hex(idgen.AWSLikeHexIDLength)
@@ -1419,6 +1599,9 @@ func (e *Executor) CreateInstances(ctx context.Context, req executor.CreateInsta
 		if req.UserData != "" {
 			labels[LabelDC2UserData] = req.UserData
 		}
+		if platform != nil {
+			labels[LabelDC2Architecture] = req.Architecture
+		}
 
 		containerConfig := &container.Config{
 			Image:  req.ImageID,
@@ -1428,13 +1611,25 @@ func (e *Executor) CreateInstances(ctx context.Context, req executor.CreateInsta
 		hostConfig := &container.HostConfig{
 			// Allow mounting block devices to attach volumes
 			Privileged: true,
-			Mounts:     dc2Mounts(e.mainVolume.Name),
+			Mounts:     dc2Mounts(e.mainVolume.Name, e.mainVolumeHostPath, e.volumeRoot),
+		}
+		if !e.disableResourceLimits {
+			hostConfig.Resources = instanceResources(req.VCPUs, req.MemoryMiB, e.hostCPUs)
 		}
 		if e.instanceNetwork != "" && e.instanceNetwork != defaultInstanceNetwork {
 			hostConfig.NetworkMode = container.NetworkMode(e.instanceNetwork)
 		}
+		if e.publishPort > 0 {
+			addr, err := randomPublishAddress(publishedAddrs)
+			if err != nil {
+				return nil, err
+			}
+			publishedAddrs[addr] = true
+			labels[LabelDC2PublicIP] = addr.String()
+			containerConfig.ExposedPorts, hostConfig.PortBindings = publishedPorts(e.publishPort, addr)
+		}
 		networkingConfig := &network.NetworkingConfig{}
-		cont, err := createContainer(ctx, e.cli, containerConfig, hostConfig, networkingConfig, "")
+		cont, err := createContainer(ctx, e.cli, containerConfig, hostConfig, networkingConfig, "", platform)
 		if err != nil {
 			return nil, fmt.Errorf("creating container: %w", err)
 		}
@@ -1447,18 +1642,36 @@ func (e *Executor) CreateInstances(ctx context.Context, req executor.CreateInsta
 }
 
 func (e *Executor) DescribeInstances(ctx context.Context, req executor.DescribeInstancesRequest) ([]executor.InstanceDescription, error) {
+	if len(req.InstanceIDs) == 0 {
+		return nil, nil
+	}
+	// List all the instance containers once instead of once per instance,
+	// which makes describing many instances significantly cheaper.
+	containerIDs, err := e.instanceContainerIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	images := make(map[string]client.ImageInspectResult)
 	var descriptions []executor.InstanceDescription
 	for _, id := range req.InstanceIDs {
-		info, err := e.findContainer(ctx, id)
+		info, err := e.inspectInstanceContainer(ctx, containerIDs[id])
 		if err != nil {
-			// Specifying non-existing IDs is not an error
-			var apiErr *api.Error
-			if errors.As(err, &apiErr) && apiErr.Code == api.ErrorCodeInstanceNotFound {
-				continue
-			}
 			return nil, fmt.Errorf("getting spec for instance %s: %w", id, err)
 		}
-		desc, err := e.instanceDescription(ctx, info)
+		if info == nil {
+			// Not in the listing, check it individually in case the instance
+			// was created after the containers were listed.
+			info, err = e.findContainer(ctx, id)
+			if err != nil {
+				// Specifying non-existing IDs is not an error
+				var apiErr *api.Error
+				if errors.As(err, &apiErr) && apiErr.Code == api.ErrorCodeInstanceNotFound {
+					continue
+				}
+				return nil, fmt.Errorf("getting spec for instance %s: %w", id, err)
+			}
+		}
+		desc, err := e.instanceDescription(ctx, info, images)
 		if err != nil {
 			return nil, err
 		}
@@ -1468,41 +1681,59 @@ func (e *Executor) DescribeInstances(ctx context.Context, req executor.DescribeI
 }
 
 func (e *Executor) StartInstances(ctx context.Context, req executor.StartInstancesRequest) ([]executor.InstanceStateChange, error) {
+	defer e.invalidateInspectCaches()
 	containers, err := e.findContainers(ctx, req.InstanceIDs)
 	if err != nil {
 		return nil, err
 	}
+	// Containers are started concurrently, but changes keep the order of
+	// the request.
 	changes := make([]executor.InstanceStateChange, len(containers))
-	for i, c := range containers {
+	err = forEachConcurrently(ctx, len(containers), e.stateChangeConcurrency, func(ctx context.Context, i int) error {
+		c := containers[i]
 		previousState, err := instanceState(c.State)
 		if err != nil {
-			return nil, fmt.Errorf("determining previous state for instance %s: %w", c.ID, err)
+			return fmt.Errorf("determining previous state for instance %s: %w", c.ID, err)
 		}
-		if err := startContainer(ctx, e.cli, c.ID); err != nil {
-			return nil, fmt.Errorf("starting instance %s: %w", c.ID, err)
-		}
-		info, err := inspectContainer(ctx, e.cli, c.ID)
+		instanceID, err := instanceIDFromContainer(c)
 		if err != nil {
-			return nil, fmt.Errorf("inspecting container %s: %w", c.ID, err)
+			return err
 		}
-		currentState, err := instanceState(info.State)
+		containerID := c.ID
+		// Running instances keep their filesystem, like starting a running
+		// EC2 instance is a no-op.
+		if slices.Contains(req.ResetInstanceIDs, instanceID) && (c.State == nil || (!c.State.Running && !c.State.Restarting)) {
+			containerID, err = e.replaceContainer(ctx, instanceID, c, c.Config, c.HostConfig)
+			if err != nil {
+				return err
+			}
+		}
+		if err := startContainer(ctx, e.cli, containerID); err != nil {
+			return fmt.Errorf("starting instance %s: %w", containerID, err)
+		}
+		info, err := inspectContainer(ctx, e.cli, containerID)
 		if err != nil {
-			return nil, fmt.Errorf("determining current state for instance %s: %w", c.ID, err)
+			return fmt.Errorf("inspecting container %s: %w", containerID, err)
 		}
-		instanceID, err := instanceIDFromContainer(c)
+		currentState, err := instanceState(info.State)
 		if err != nil {
-			return nil, err
+			return fmt.Errorf("determining current state for instance %s: %w", containerID, err)
 		}
 		changes[i] = executor.InstanceStateChange{
 			InstanceID:    instanceID,
 			PreviousState: previousState,
 			CurrentState:  currentState,
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return changes, nil
 }
 
 func (e *Executor) StopInstances(ctx context.Context, req executor.StopInstancesRequest) ([]executor.InstanceStateChange, error) {
+	defer e.invalidateInspectCaches()
 	containers, err := e.findContainers(ctx, req.InstanceIDs)
 	if err != nil {
 		return nil, err
@@ -1512,37 +1743,216 @@ func (e *Executor) StopInstances(ctx context.Context, req executor.StopInstances
 		zero := 0
 		timeout = &zero
 	}
+	// Containers are stopped concurrently, but changes keep the order of
+	// the request.
 	changes := make([]executor.InstanceStateChange, len(containers))
-	for i, c := range containers {
+	err = forEachConcurrently(ctx, len(containers), e.stateChangeConcurrency, func(ctx context.Context, i int) error {
+		c := containers[i]
 		previousState, err := instanceState(c.State)
 		if err != nil {
-			return nil, fmt.Errorf("determining previous state for instance %s: %w", c.ID, err)
+			return fmt.Errorf("determining previous state for instance %s: %w", c.ID, err)
 		}
 		if err := stopContainer(ctx, e.cli, c.ID, timeout); err != nil {
-			return nil, fmt.Errorf("stopping instance %s: %w", c.ID, err)
+			return fmt.Errorf("stopping instance %s: %w", c.ID, err)
 		}
 		info, err := inspectContainer(ctx, e.cli, c.ID)
 		if err != nil {
-			return nil, fmt.Errorf("inspecting container %s: %w", c.ID, err)
+			return fmt.Errorf("inspecting container %s: %w", c.ID, err)
 		}
 		currentState, err := instanceState(info.State)
 		if err != nil {
-			return nil, fmt.Errorf("determining current state for instance %s: %w", c.ID, err)
+			return fmt.Errorf("determining current state for instance %s: %w", c.ID, err)
 		}
 		instanceID, err := instanceIDFromContainer(c)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		changes[i] = executor.InstanceStateChange{
 			InstanceID:    instanceID,
 			PreviousState: previousState,
 			CurrentState:  currentState,
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return changes, nil
 }
 
+func (e *Executor) RebootInstances(ctx context.Context, req executor.RebootInstancesRequest) error {
+	defer e.invalidateInspectCaches()
+	containers, err := e.findContainers(ctx, req.InstanceIDs)
+	if err != nil {
+		return err
+	}
+	for _, c := range containers {
+		if err := restartContainer(ctx, e.cli, c.ID); err != nil {
+			return fmt.Errorf("rebooting instance %s: %w", c.ID, err)
+		}
+	}
+	return nil
+}
+
+// InstanceConsoleOutput returns the combined stdout and stderr of the
+// container backing the instance, keeping only the most recent
+// maxConsoleOutputBytes like EC2 does.
+func (e *Executor) InstanceConsoleOutput(ctx context.Context, instanceID executor.InstanceID) (executor.InstanceConsoleOutput, error) {
+	containers, err := e.findContainers(ctx, []executor.InstanceID{instanceID})
+	if err != nil {
+		return executor.InstanceConsoleOutput{}, err
+	}
+	reader, err := e.cli.ContainerLogs(ctx, containers[0].ID, client.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Timestamps: true,
+	})
+	if err != nil {
+		return executor.InstanceConsoleOutput{}, fmt.Errorf("reading console output for instance %s: %w", instanceID, err)
+	}
+	defer reader.Close()
+
+	var logs bytes.Buffer
+	if _, err := stdcopy.StdCopy(&logs, &logs, reader); err != nil {
+		return executor.InstanceConsoleOutput{}, fmt.Errorf("copying console output for instance %s: %w", instanceID, err)
+	}
+	output, timestamp := splitLogTimestamps(logs.Bytes())
+	if len(output) > maxConsoleOutputBytes {
+		output = output[len(output)-maxConsoleOutputBytes:]
+	}
+	return executor.InstanceConsoleOutput{
+		Output:    output,
+		Timestamp: timestamp,
+	}, nil
+}
+
+// splitLogTimestamps strips the timestamps Docker prepends to each log line,
+// returning the remaining output and the time of the last line.
+func splitLogTimestamps(logs []byte) ([]byte, time.Time) {
+	var output []byte
+	var latest time.Time
+	for line := range bytes.Lines(logs) {
+		prefix, rest, found := bytes.Cut(line, []byte{' '})
+		if !found {
+			prefix, rest = bytes.TrimRight(line, "\n"), []byte("\n")
+		}
+		timestamp, err := time.Parse(time.RFC3339Nano, string(prefix))
+		if err != nil {
+			output = append(output, line...)
+			continue
+		}
+		if timestamp.After(latest) {
+			latest = timestamp
+		}
+		output = append(output, rest...)
+	}
+	return output, latest
+}
+
+// ModifyInstanceAttribute recreates the container backing a stopped instance
+// with updated labels, since Docker can't change labels in place. The new
+// container keeps the instance ID, name, configuration and mounts, so
+// attached volumes and DNS names survive the change.
+func (e *Executor) ModifyInstanceAttribute(ctx context.Context, req executor.ModifyInstanceAttributeRequest) error {
+	defer e.invalidateInspectCaches()
+	info, err := e.findContainer(ctx, req.InstanceID)
+	if err != nil {
+		return err
+	}
+	if info.State != nil && (info.State.Running || info.State.Restarting) {
+		return api.ErrWithCode(api.ErrorCodeIncorrectInstanceState, fmt.Errorf("instance %s is not stopped", req.InstanceID))
+	}
+
+	containerConfig := *info.Config
+	containerConfig.Labels = maps.Clone(info.Config.Labels)
+	if req.InstanceType != nil {
+		containerConfig.Labels[LabelDC2InstanceType] = *req.InstanceType
+	}
+	if req.UserData != nil {
+		if *req.UserData == "" {
+			delete(containerConfig.Labels, LabelDC2UserData)
+		} else {
+			containerConfig.Labels[LabelDC2UserData] = *req.UserData
+		}
+	}
+
+	hostConfig := info.HostConfig
+	if req.InstanceType != nil && !e.disableResourceLimits {
+		// Resize the container to the new instance type
+		resized := *info.HostConfig
+		resources := instanceResources(req.VCPUs, req.MemoryMiB, e.hostCPUs)
+		resized.NanoCPUs = resources.NanoCPUs
+		resized.Memory = resources.Memory
+		// Docker derived the swap limit from the previous memory limit, let
+		// it derive it again
+		resized.MemorySwap = 0
+		hostConfig = &resized
+	}
+
+	_, err = e.replaceContainer(ctx, req.InstanceID, info, &containerConfig, hostConfig)
+	return err
+}
+
+// replaceContainer replaces the stopped container backing an instance with a
+// new one created from containerConfig and hostConfig, keeping its name. The
+// new container starts from a clean copy of its image, so any filesystem
+// changes made to the replaced one are lost. It returns the ID of the new
+// container.
+func (e *Executor) replaceContainer(ctx context.Context, instanceID executor.InstanceID, info *container.InspectResponse, containerConfig *container.Config, hostConfig *container.HostConfig) (string, error) {
+	// Free the name first so the replacement can take it over.
+	name := strings.TrimPrefix(info.Name, "/")
+	replacedName := name + "-replaced"
+	if err := renameContainer(ctx, e.cli, info.ID, replacedName); err != nil {
+		return "", fmt.Errorf("renaming container %s: %w", info.ID, err)
+	}
+	// Keep the platform requested at launch, the image tag might point to a
+	// different one now.
+	platform := dockerPlatform(containerConfig.Labels[LabelDC2Architecture])
+	cont, err := createContainer(ctx, e.cli, containerConfig, hostConfig, replacementNetworkingConfig(info), name, platform)
+	if err != nil {
+		_ = renameContainer(ctx, e.cli, info.ID, name)
+		return "", fmt.Errorf("creating replacement container for instance %s: %w", instanceID, err)
+	}
+	if err := connectNetwork(ctx, e.cli, imdsNetwork(), cont.ID, nil); err != nil && !strings.Contains(err.Error(), "already exists") {
+		_ = removeContainer(ctx, e.cli, cont.ID, true)
+		_ = renameContainer(ctx, e.cli, info.ID, name)
+		return "", fmt.Errorf("connecting instance %s to IMDS network: %w", cont.ID, err)
+	}
+	if err := removeContainer(ctx, e.cli, info.ID, false); err != nil {
+		_ = removeContainer(ctx, e.cli, cont.ID, true)
+		_ = renameContainer(ctx, e.cli, info.ID, name)
+		return "", fmt.Errorf("removing replaced container for instance %s: %w", instanceID, err)
+	}
+	return cont.ID, nil
+}
+
+// replacementNetworkingConfig returns the networks a replacement for the
+// container described by info must be connected to, keeping the aliases and
+// addresses configured for each of them but none of their operational data.
+func replacementNetworkingConfig(info *container.InspectResponse) *network.NetworkingConfig {
+	cfg := &network.NetworkingConfig{}
+	if info.NetworkSettings == nil || len(info.NetworkSettings.Networks) == 0 {
+		return cfg
+	}
+	cfg.EndpointsConfig = make(map[string]*network.EndpointSettings, len(info.NetworkSettings.Networks))
+	for name, endpoint := range info.NetworkSettings.Networks {
+		if endpoint == nil {
+			continue
+		}
+		endpoint = endpoint.Copy()
+		cfg.EndpointsConfig[name] = &network.EndpointSettings{
+			IPAMConfig: endpoint.IPAMConfig,
+			Links:      endpoint.Links,
+			Aliases:    endpoint.Aliases,
+			DriverOpts: endpoint.DriverOpts,
+			GwPriority: endpoint.GwPriority,
+		}
+	}
+	return cfg
+}
+
 func (e *Executor) TerminateInstances(ctx context.Context, req executor.TerminateInstancesRequest) ([]executor.InstanceStateChange, error) {
+	defer e.invalidateInspectCaches()
 	containers, err := e.findContainers(ctx, req.InstanceIDs)
 	if err != nil {
 		return nil, err
@@ -1576,16 +1986,24 @@ func (e *Executor) TerminateInstances(ctx context.Context, req executor.Terminat
 }
 
 func (e *Executor) CreateVolume(ctx context.Context, req executor.CreateVolumeRequest) (executor.VolumeID, error) {
+	defer e.invalidateInspectCaches()
 	id, err := idgen.Hex(idgen.AWSLikeHexIDLength)
 	if err != nil {
 		return "", fmt.Errorf("generating volume id: %w", err)
 	}
 	volumeID := executor.VolumeID(id)
-	volumeFileCmd := []string{"truncate", "-s", strconv.FormatInt(req.Size, 10), internalVolumeFilePath(volumeID)}
+	if req.SnapshotID != "" {
+		copyCmd := []string{"cp", e.internalSnapshotFilePath(req.SnapshotID), e.internalVolumeFilePath(volumeID)}
+		if _, _, err := e.execInMainContainer(ctx, copyCmd); err != nil {
+			return "", fmt.Errorf("executing command to copy snapshot %s: %w", req.SnapshotID, err)
+		}
+	}
+	// When restoring from a snapshot, this grows the copy to the requested size
+	volumeFileCmd := []string{"truncate", "-s", strconv.FormatInt(req.Size, 10), e.internalVolumeFilePath(volumeID)}
 	if _, _, err := e.execInMainContainer(ctx, volumeFileCmd); err != nil {
 		return "", fmt.Errorf("executing command to create volume file: %w", err)
 	}
-	attachmentsFileCmd := []string{"touch", internalVolumeAttachmentInfoPath(volumeID)}
+	attachmentsFileCmd := []string{"touch", e.internalVolumeAttachmentInfoPath(volumeID)}
 	if _, _, err := e.execInMainContainer(ctx, attachmentsFileCmd); err != nil {
 		return "", fmt.Errorf("executing command to create volume attachments file: %w", err)
 	}
@@ -1593,22 +2011,196 @@ func (e *Executor) CreateVolume(ctx context.Context, req executor.CreateVolumeRe
 }
 
 func (e *Executor) DeleteVolume(ctx context.Context, req executor.DeleteVolumeRequest) error {
-	deleteVolumeCmd := []string{"rm", internalVolumeFilePath(req.VolumeID)}
+	defer e.invalidateInspectCaches()
+	deleteVolumeCmd := []string{"rm", e.internalVolumeFilePath(req.VolumeID)}
 	if _, _, err := e.execInMainContainer(ctx, deleteVolumeCmd); err != nil {
 		return fmt.Errorf("executing command to delete volume: %w", err)
 	}
-	deleteAttachmentsCmd := []string{"rm", "-f", internalVolumeAttachmentInfoPath(req.VolumeID)}
+	deleteAttachmentsCmd := []string{"rm", "-f", e.internalVolumeAttachmentInfoPath(req.VolumeID)}
 	if _, _, err := e.execInMainContainer(ctx, deleteAttachmentsCmd); err != nil {
 		return fmt.Errorf("executing command to delete volume attachments: %w", err)
 	}
 	return nil
 }
 
+// ResizeVolume grows the file backing the volume and refreshes the loop
+// devices of its attachments, so attached instances see the new size.
+func (e *Executor) ResizeVolume(ctx context.Context, req executor.ResizeVolumeRequest) error {
+	defer e.invalidateInspectCaches()
+	e.volumeAttachmentMu.Lock()
+	defer e.volumeAttachmentMu.Unlock()
+	resizeCmd := []string{"truncate", "-s", strconv.FormatInt(req.Size, 10), e.internalVolumeFilePath(req.VolumeID)}
+	if _, _, err := e.execInMainContainer(ctx, resizeCmd); err != nil {
+		return fmt.Errorf("executing command to resize volume file: %w", err)
+	}
+	attachments, err := e.findVolumeAttachments(ctx, req.VolumeID)
+	if err != nil {
+		return fmt.Errorf("finding volume attachments: %w", err)
+	}
+	for _, attachment := range attachments {
+		instanceContainer, err := e.findContainer(ctx, attachment.InstanceID)
+		if err != nil {
+			return err
+		}
+		capacityCmd := []string{"losetup", "-c", attachment.Device}
+		if _, _, err := e.execInContainer(ctx, instanceContainer.ID, capacityCmd); err != nil {
+			return fmt.Errorf("refreshing capacity of device %s: %w", attachment.Device, err)
+		}
+	}
+	return nil
+}
+
+// CreateSnapshot copies the file backing the volume, so later writes to the
+// volume don't change the snapshot.
+func (e *Executor) CreateSnapshot(ctx context.Context, req executor.CreateSnapshotRequest) (executor.SnapshotID, error) {
+	defer e.invalidateInspectCaches()
+	id, err := idgen.Hex(idgen.AWSLikeHexIDLength)
+	if err != nil {
+		return "", fmt.Errorf("generating snapshot id: %w", err)
+	}
+	snapshotID := executor.SnapshotID(id)
+	copyCmd := []string{"cp", e.internalVolumeFilePath(req.VolumeID), e.internalSnapshotFilePath(snapshotID)}
+	if _, _, err := e.execInMainContainer(ctx, copyCmd); err != nil {
+		return "", fmt.Errorf("executing command to create snapshot file: %w", err)
+	}
+	return snapshotID, nil
+}
+
+func (e *Executor) DeleteSnapshot(ctx context.Context, req executor.DeleteSnapshotRequest) error {
+	defer e.invalidateInspectCaches()
+	deleteSnapshotCmd := []string{"rm", e.internalSnapshotFilePath(req.SnapshotID)}
+	if _, _, err := e.execInMainContainer(ctx, deleteSnapshotCmd); err != nil {
+		return fmt.Errorf("executing command to delete snapshot: %w", err)
+	}
+	return nil
+}
+
+// CopySnapshot copies the file backing the snapshot into a new snapshot.
+func (e *Executor) CopySnapshot(ctx context.Context, req executor.CopySnapshotRequest) (executor.SnapshotID, error) {
+	id, err := idgen.Hex(idgen.AWSLikeHexIDLength)
+	if err != nil {
+		return "", fmt.Errorf("generating snapshot id: %w", err)
+	}
+	snapshotID := executor.SnapshotID(id)
+	copyCmd := []string{"cp", e.internalSnapshotFilePath(req.SnapshotID), e.internalSnapshotFilePath(snapshotID)}
+	if _, _, err := e.execInMainContainer(ctx, copyCmd); err != nil {
+		return "", fmt.Errorf("executing command to copy snapshot %s: %w", req.SnapshotID, err)
+	}
+	return snapshotID, nil
+}
+
+// DescribeSnapshots returns the snapshots whose files exist in the main
+// volume, listing it once instead of checking every snapshot.
+func (e *Executor) DescribeSnapshots(ctx context.Context, req executor.DescribeSnapshotsRequest) ([]executor.SnapshotDescription, error) {
+	if len(req.SnapshotIDs) == 0 {
+		return nil, nil
+	}
+	stdout, _, err := e.execInMainContainer(ctx, []string{"ls", "-1", e.volumeRoot})
+	if err != nil {
+		return nil, fmt.Errorf("listing volume files: %w", err)
+	}
+	files := strings.Fields(stdout)
+	var descs []executor.SnapshotDescription
+	for _, id := range req.SnapshotIDs {
+		if slices.Contains(files, path.Base(e.internalSnapshotFilePath(id))) {
+			descs = append(descs, executor.SnapshotDescription{SnapshotID: id})
+		}
+	}
+	return descs, nil
+}
+
+// CreateImage commits the container backing the instance as a new image.
+// User data isn't part of the image, so it's cleared from the labels the
+// image inherits from the container.
+func (e *Executor) CreateImage(ctx context.Context, req executor.CreateImageRequest) error {
+	defer e.invalidateInspectCaches()
+	info, err := e.findContainer(ctx, req.InstanceID)
+	if err != nil {
+		return err
+	}
+	if _, err := e.cli.ContainerCommit(ctx, info.ID, client.ContainerCommitOptions{
+		Reference: req.ImageID,
+		Config: &container.Config{
+			Labels: map[string]string{LabelDC2UserData: ""},
+		},
+	}); err != nil {
+		return fmt.Errorf("committing instance %s as image %s: %w", req.InstanceID, req.ImageID, err)
+	}
+	return nil
+}
+
+func (e *Executor) DeleteImage(ctx context.Context, req executor.DeleteImageRequest) error {
+	defer e.invalidateInspectCaches()
+	if _, err := e.cli.ImageRemove(ctx, req.ImageID, client.ImageRemoveOptions{PruneChildren: true}); err != nil {
+		if cerrdefs.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("removing image %s: %w", req.ImageID, err)
+	}
+	return nil
+}
+
+// CopyImage tags the source image with the new image ID. Images are
+// immutable, so the copy is independent from the source, which can be
+// removed without affecting it.
+func (e *Executor) CopyImage(ctx context.Context, req executor.CopyImageRequest) error {
+	if _, err := e.cli.ImageTag(ctx, client.ImageTagOptions{Source: req.SourceImageID, Target: req.ImageID}); err != nil {
+		return fmt.Errorf("tagging image %s as %s: %w", req.SourceImageID, req.ImageID, err)
+	}
+	return nil
+}
+
+// ListImages returns an entry for each tag of the locally present images.
+// Images tagged as latest are reported without the tag, matching how they're
+// usually referenced when launching instances.
+func (e *Executor) ListImages(ctx context.Context) ([]executor.ImageDescription, error) {
+	list, err := e.cli.ImageList(ctx, client.ImageListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("listing images: %w", err)
+	}
+	var images []executor.ImageDescription
+	for _, summary := range list.Items {
+		var tags []string
+		for _, tag := range summary.RepoTags {
+			if tag != "" && tag != "<none>:<none>" {
+				tags = append(tags, strings.TrimSuffix(tag, ":latest"))
+			}
+		}
+		if len(tags) == 0 {
+			continue
+		}
+		image, err := e.cli.ImageInspect(ctx, summary.ID)
+		if err != nil {
+			if cerrdefs.IsNotFound(err) {
+				// Removed after being listed
+				continue
+			}
+			return nil, fmt.Errorf("inspecting image %s: %w", summary.ID, err)
+		}
+		for _, tag := range tags {
+			images = append(images, executor.ImageDescription{
+				ImageID:      tag,
+				Architecture: awsArchFromDockerArch(image.Architecture),
+				Platform:     image.Os,
+				Size:         summary.Size,
+				CreationDate: time.Unix(summary.Created, 0).UTC(),
+			})
+		}
+	}
+	return images, nil
+}
+
 func (e *Executor) AttachVolume(ctx context.Context, req executor.AttachVolumeRequest) (*executor.VolumeAttachment, error) {
+	defer e.invalidateInspectCaches()
 	instanceContainer, err := e.findContainer(ctx, req.InstanceID)
 	if err != nil {
 		return nil, err
 	}
+	// Hold the lock from picking the next free loop device until the
+	// attachment is recorded, so concurrent attaches never claim the same
+	// device.
+	e.volumeAttachmentMu.Lock()
+	defer e.volumeAttachmentMu.Unlock()
 	attachments, err := e.findVolumeAttachments(ctx, req.VolumeID)
 	if err != nil {
 		return nil, fmt.Errorf("finding volume attachments: %w", err)
@@ -1658,7 +2250,7 @@ func (e *Executor) AttachVolume(ctx context.Context, req executor.AttachVolumeRe
 			return nil, fmt.Errorf("creating device %s: %w", req.Device, err)
 		}
 
-		setupCmd := []string{"losetup", req.Device, internalVolumeFilePath(req.VolumeID)}
+		setupCmd := []string{"losetup", req.Device, e.internalVolumeFilePath(req.VolumeID)}
 		if _, _, err := e.execInContainer(ctx, instanceContainer.ID, setupCmd); err != nil {
 			_, _, _ = e.execInContainer(ctx, instanceContainer.ID, []string{"rm", "-f", req.Device})
 			if attempt+1 < maxAttachAttempts && strings.Contains(strings.ToLower(err.Error()), "device or resource busy") {
@@ -1690,10 +2282,13 @@ func (e *Executor) AttachVolume(ctx context.Context, req executor.AttachVolumeRe
 }
 
 func (e *Executor) DetachVolume(ctx context.Context, req executor.DetachVolumeRequest) (*executor.VolumeAttachment, error) {
+	defer e.invalidateInspectCaches()
 	instanceContainer, err := e.findContainer(ctx, req.InstanceID)
 	if err != nil {
 		return nil, err
 	}
+	e.volumeAttachmentMu.Lock()
+	defer e.volumeAttachmentMu.Unlock()
 	var attachment *deviceAttachment
 	atts, err := e.findVolumeAttachments(ctx, req.VolumeID)
 	if err != nil {
@@ -1729,7 +2324,7 @@ func (e *Executor) DetachVolume(ctx context.Context, req executor.DetachVolumeRe
 func (e *Executor) DescribeVolumes(ctx context.Context, req executor.DescribeVolumesRequest) ([]executor.VolumeDescription, error) {
 	descs := make([]executor.VolumeDescription, len(req.VolumeIDs))
 	for i, id := range req.VolumeIDs {
-		cmd := []string{"du", "-b", internalVolumeFilePath(id)}
+		cmd := []string{"du", "-b", e.internalVolumeFilePath(id)}
 		stdout, _, err := e.execInMainContainer(ctx, cmd)
 		if err != nil {
 			return nil, err
@@ -1792,7 +2387,7 @@ func (e *Executor) findContainer(ctx context.Context, instanceID executor.Instan
 	if len(containers) > 1 {
 		return nil, fmt.Errorf("found %d containers for instance %s", len(containers), instanceID)
 	}
-	info, err := inspectContainer(ctx, e.cli, containers[0].ID)
+	info, err := e.inspectContainerCached(ctx, containers[0].ID)
 	if err != nil {
 		if cerrdefs.IsNotFound(err) {
 			return nil, api.ErrWithCode(api.ErrorCodeInstanceNotFound, fmt.Errorf("instance %s doesn't exist: %w", instanceID, err))
@@ -1805,6 +2400,56 @@ func (e *Executor) findContainer(ctx context.Context, instanceID executor.Instan
 	return &info, nil
 }
 
+// instanceContainerIDs returns the container IDs of all the instances, keyed
+// by instance ID. Instances with more than one container are omitted, so
+// callers can report the error via findContainer.
+func (e *Executor) instanceContainerIDs(ctx context.Context) (map[executor.InstanceID]string, error) {
+	containers, err := listContainers(
+		ctx,
+		e.cli,
+		dockerFilters("label", LabelDC2Enabled+"=true"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("listing instance containers: %w", err)
+	}
+	containerIDs := make(map[executor.InstanceID]string, len(containers))
+	duplicated := make(map[executor.InstanceID]bool)
+	for _, c := range containers {
+		instanceID := executor.InstanceID(c.Labels[LabelDC2InstanceID])
+		if instanceID == "" {
+			continue
+		}
+		if _, found := containerIDs[instanceID]; found {
+			duplicated[instanceID] = true
+		}
+		containerIDs[instanceID] = c.ID
+	}
+	for instanceID := range duplicated {
+		delete(containerIDs, instanceID)
+	}
+	return containerIDs, nil
+}
+
+// inspectInstanceContainer inspects a container returned by
+// instanceContainerIDs. It returns nil when containerID is empty or the
+// container is gone.
+func (e *Executor) inspectInstanceContainer(ctx context.Context, containerID string) (*container.InspectResponse, error) {
+	if containerID == "" {
+		return nil, nil
+	}
+	info, err := e.inspectContainerCached(ctx, containerID)
+	if err != nil {
+		if cerrdefs.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("inspecting container %s: %w", containerID, err)
+	}
+	if !isDc2Container(info) {
+		return nil, nil
+	}
+	return &info, nil
+}
+
 func (e *Executor) findContainers(ctx context.Context, instanceIDs []executor.InstanceID) ([]*container.InspectResponse, error) {
 	var containers []*container.InspectResponse
 	// Validate all the instances first
@@ -1818,15 +2463,26 @@ func (e *Executor) findContainers(ctx context.Context, instanceIDs []executor.In
 	return containers, nil
 }
 
-func (e *Executor) instanceDescription(ctx context.Context, info *container.InspectResponse) (executor.InstanceDescription, error) {
+// instanceDescription describes the instance running in the given container.
+// Image inspections are cached in images, since instances commonly share
+// their image.
+func (e *Executor) instanceDescription(
+	ctx context.Context,
+	info *container.InspectResponse,
+	images map[string]client.ImageInspectResult,
+) (executor.InstanceDescription, error) {
 	created, err := time.Parse(time.RFC3339Nano, info.Created)
 	if err != nil {
 		return executor.InstanceDescription{}, fmt.Errorf("parsing container creation time: %w", err)
 	}
 	labels := info.Config.Labels
-	image, err := e.cli.ImageInspect(ctx, info.Image)
-	if err != nil {
-		return executor.InstanceDescription{}, fmt.Errorf("inspecting image: %w", err)
+	image, found := images[info.Image]
+	if !found {
+		image, err = e.cli.ImageInspect(ctx, info.Image)
+		if err != nil {
+			return executor.InstanceDescription{}, fmt.Errorf("inspecting image: %w", err)
+		}
+		images[info.Image] = image
 	}
 	imageID := labels[LabelDC2ImageID]
 	state, err := instanceState(info.State)
@@ -1839,7 +2495,12 @@ func (e *Executor) instanceDescription(ctx context.Context, info *container.Insp
 	privateIP := primaryContainerIPv4Address(info, imdsNetwork())
 	// We expose the same reachable container address for both private/public
 	// fields so EC2 clients expecting PublicIpAddress can operate in tests.
+	// When the instance publishes a port, its public IP is the host address
+	// it's published on instead.
 	publicIP := privateIP
+	if addr := labels[LabelDC2PublicIP]; addr != "" && state.Name == api.InstanceStateRunning.Name {
+		publicIP = addr
+	}
 	healthStatus := executor.InstanceHealthStatusUnknown
 	if info.State != nil && info.State.Health != nil {
 		healthStatus = executor.InstanceHealthStatus(strings.ToLower(strings.TrimSpace(string(info.State.Health.Status))))
@@ -1848,6 +2509,10 @@ func (e *Executor) instanceDescription(ctx context.Context, info *container.Insp
 	if err != nil {
 		return executor.InstanceDescription{}, err
 	}
+	architecture := labels[LabelDC2Architecture]
+	if architecture == "" {
+		architecture = awsArchFromDockerArch(image.Architecture)
+	}
 	return executor.InstanceDescription{
 		InstanceID:     instanceID,
 		ImageID:        imageID,
@@ -1857,7 +2522,8 @@ func (e *Executor) instanceDescription(ctx context.Context, info *container.Insp
 		PrivateIP:      privateIP,
 		PublicIP:       publicIP,
 		InstanceType:   instanceType,
-		Architecture:   awsArchFromDockerArch(image.Architecture),
+		Architecture:   architecture,
+		Platform:       image.Os,
 		LaunchTime:     created,
 	}, nil
 }
@@ -1925,7 +2591,7 @@ func (e *Executor) execInContainer(ctx context.Context, containerID string, cmd
 }
 
 func (e *Executor) recordAttachment(ctx context.Context, vol executor.VolumeID, info deviceAttachment) error {
-	recordCmd := []string{"sh", "-c", fmt.Sprintf("echo %s >> %s", info.String(), internalVolumeAttachmentInfoPath(vol))}
+	recordCmd := []string{"sh", "-c", fmt.Sprintf("echo %s >> %s", info.String(), e.internalVolumeAttachmentInfoPath(vol))}
 	if _, _, err := e.execInMainContainer(ctx, recordCmd); err != nil {
 		return fmt.Errorf("recording attachment: %w", err)
 	}
@@ -1933,7 +2599,7 @@ func (e *Executor) recordAttachment(ctx context.Context, vol executor.VolumeID,
 }
 
 func (e *Executor) deleteAttachment(ctx context.Context, vol executor.VolumeID, info deviceAttachment) error {
-	deleteCmd := []string{"sh", "-c", fmt.Sprintf("sed -i '#%s#d' %s", info.String(), internalVolumeAttachmentInfoPath(vol))}
+	deleteCmd := []string{"sh", "-c", fmt.Sprintf("sed -i '\\#%s#d' %s", info.String(), e.internalVolumeAttachmentInfoPath(vol))}
 	if _, _, err := e.execInMainContainer(ctx, deleteCmd); err != nil {
 		return fmt.Errorf("deleting attachment: %w", err)
 	}
@@ -1941,7 +2607,7 @@ func (e *Executor) deleteAttachment(ctx context.Context, vol executor.VolumeID,
 }
 
 func (e *Executor) findVolumeAttachments(ctx context.Context, vol executor.VolumeID) ([]deviceAttachment, error) {
-	stdout, _, err := e.execInMainContainer(ctx, []string{"cat", internalVolumeAttachmentInfoPath(vol)})
+	stdout, _, err := e.execInMainContainer(ctx, []string{"cat", e.internalVolumeAttachmentInfoPath(vol)})
 	if err != nil {
 		return nil, fmt.Errorf("reading volume attachments: %w", err)
 	}
@@ -2008,20 +2674,32 @@ func awsArchFromDockerArch(arch string) string {
 	return arch
 }
 
+// dockerPlatform returns the Linux platform for the given EC2 architecture,
+// or nil when arch is empty.
+func dockerPlatform(arch string) *ocispec.Platform {
+	switch arch {
+	case "":
+		return nil
+	case "x86_64":
+		return &ocispec.Platform{OS: "linux", Architecture: "amd64"}
+	case "i386":
+		return &ocispec.Platform{OS: "linux", Architecture: "386"}
+	default:
+		return &ocispec.Platform{OS: "linux", Architecture: arch}
+	}
+}
+
 func createMainContainer(
 	ctx context.Context,
 	cli *client.Client,
 	name string,
-	mainVolumeName string,
+	image string,
+	mounts []mount.Mount,
 	imdsBackendPort int,
 	imdsBackendHost string,
 	runtimeMode string,
 	instanceNetwork string,
 ) (string, error) {
-	if err := pullImage(ctx, cli, mainContainerImageName); err != nil {
-		return "", fmt.Errorf("pulling image for main container: %w", err)
-	}
-
 	labels := map[string]string{
 		LabelDC2Main:     "true",
 		LabelDC2IMDSHost: imdsBackendHost,
@@ -2031,17 +2709,17 @@ func createMainContainer(
 		labels[LabelDC2InstanceNet] = instanceNetwork
 	}
 	containerConfig := &container.Config{
-		Image:  mainContainerImageName,
+		Image:  image,
 		Cmd:    []string{"sleep", "infinity"},
 		Env:    []string{dc2RuntimeEnv(runtimeMode)},
 		Labels: labels,
 	}
 	hostConfig := &container.HostConfig{
 		AutoRemove: true,
-		Mounts:     dc2Mounts(mainVolumeName),
+		Mounts:     mounts,
 	}
 	networkingConfig := &network.NetworkingConfig{}
-	cont, err := createContainer(ctx, cli, containerConfig, hostConfig, networkingConfig, name)
+	cont, err := createContainer(ctx, cli, containerConfig, hostConfig, networkingConfig, name, nil)
 	if err != nil {
 		return "", fmt.Errorf("creating main container: %w", err)
 	}
@@ -2053,14 +2731,23 @@ func createMainContainer(
 	return cont.ID, nil
 }
 
-func pullImage(ctx context.Context, cli *client.Client, imageName string) error {
+// pullImage pulls the given image, unless it's already available locally.
+// When platform is not nil, a local image for a different architecture is
+// pulled again for the given platform.
+func pullImage(ctx context.Context, cli *client.Client, imageName string, platform *ocispec.Platform) error {
 	api.Logger(ctx).Debug("pulling image", slog.String("name", imageName))
-	if _, err := cli.ImageInspect(ctx, imageName); err == nil {
-		return nil
+	if image, err := cli.ImageInspect(ctx, imageName); err == nil {
+		if platform == nil || image.Architecture == platform.Architecture {
+			return nil
+		}
 	} else if !cerrdefs.IsNotFound(err) {
 		return fmt.Errorf("inspecting local image %s: %w", imageName, err)
 	}
-	pullProgress, err := cli.ImagePull(ctx, imageName, client.ImagePullOptions{})
+	var opts client.ImagePullOptions
+	if platform != nil {
+		opts.Platforms = []ocispec.Platform{*platform}
+	}
+	pullProgress, err := cli.ImagePull(ctx, imageName, opts)
 	if err != nil {
 		return fmt.Errorf("starting pull for %s: %w", imageName, err)
 	}
@@ -2074,7 +2761,33 @@ func pullImage(ctx context.Context, cli *client.Client, imageName string) error
 	return nil
 }
 
-func dc2Mounts(volumeName string) []mount.Mount {
+// instanceResources returns the container resources for an instance with the
+// given vCPUs and memory. Zero values leave the resource unlimited. CPUs are
+// capped to hostCPUs, when positive.
+func instanceResources(vcpus int, memoryMiB int, hostCPUs int) container.Resources {
+	var resources container.Resources
+	if hostCPUs > 0 {
+		vcpus = min(vcpus, hostCPUs)
+	}
+	if vcpus > 0 {
+		resources.NanoCPUs = int64(vcpus) * 1e9
+	}
+	if memoryMiB > 0 {
+		resources.Memory = int64(memoryMiB) * 1024 * 1024
+	}
+	return resources
+}
+
+func dc2Mounts(volumeName string, hostPath string, volumeRoot string) []mount.Mount {
+	if hostPath != "" {
+		return []mount.Mount{
+			{
+				Type:   mount.TypeBind,
+				Source: hostPath,
+				Target: volumeRoot,
+			},
+		}
+	}
 	sourceVolume := strings.TrimSpace(volumeName)
 	if sourceVolume == "" {
 		sourceVolume = mainResourceNamePrefix
@@ -2083,13 +2796,13 @@ func dc2Mounts(volumeName string) []mount.Mount {
 		{
 			Type:   mount.TypeVolume,
 			Source: sourceVolume,
-			Target: mainVolumePath,
+			Target: volumeRoot,
 		},
 	}
 }
 
-func internalVolumeFilePath(id executor.VolumeID) string {
-	return fmt.Sprintf("%s/%s", mainVolumePath, id)
+func (e *Executor) internalVolumeFilePath(id executor.VolumeID) string {
+	return fmt.Sprintf("%s/%s", e.volumeRoot, id)
 }
 
 type deviceAttachment struct {
@@ -2103,6 +2816,10 @@ func (i *deviceAttachment) String() string {
 	return fmt.Sprintf("%s:%s:%d:%d", i.InstanceID, i.Device, i.LoopDeviceNum, i.AttachTime.UnixNano())
 }
 
-func internalVolumeAttachmentInfoPath(id executor.VolumeID) string {
-	return fmt.Sprintf("%s.attachments", internalVolumeFilePath(id))
+func (e *Executor) internalVolumeAttachmentInfoPath(id executor.VolumeID) string {
+	return fmt.Sprintf("%s.attachments", e.internalVolumeFilePath(id))
+}
+
+func (e *Executor) internalSnapshotFilePath(id executor.SnapshotID) string {
+	return fmt.Sprintf("%s/%s.snapshot", e.volumeRoot, id)
 }
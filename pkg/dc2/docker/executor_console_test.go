@@ -0,0 +1,24 @@
+package docker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitLogTimestamps(t *testing.T) {
+	t.Parallel()
+
+	logs := []byte("2026-01-02T03:04:05.000000001Z booting\n" +
+		"2026-01-02T03:04:06.5Z \n" +
+		"2026-01-02T03:04:07Z ready to serve\n" +
+		"no timestamp\n")
+	output, timestamp := splitLogTimestamps(logs)
+	assert.Equal(t, "booting\n\nready to serve\nno timestamp\n", string(output))
+	assert.Equal(t, time.Date(2026, 1, 2, 3, 4, 7, 0, time.UTC), timestamp)
+
+	output, timestamp = splitLogTimestamps(nil)
+	assert.Empty(t, output)
+	assert.True(t, timestamp.IsZero())
+}
@@ -0,0 +1,196 @@
+package docker
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/network"
+	"github.com/moby/moby/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/executor"
+)
+
+// fakeReplaceDaemon serves the subset of the Docker API used to replace the
+// container of a stopped instance, recording the created containers.
+type fakeReplaceDaemon struct {
+	instanceID executor.InstanceID
+	hostConfig container.HostConfig
+	networks   map[string]*network.EndpointSettings
+
+	mu                sync.Mutex
+	created           []container.HostConfig
+	createdNetworking []network.NetworkingConfig
+}
+
+func (d *fakeReplaceDaemon) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path
+	labels := map[string]string{
+		LabelDC2Enabled:      "true",
+		LabelDC2InstanceID:   string(d.instanceID),
+		LabelDC2InstanceType: "t3.micro",
+	}
+	var resp any
+	switch {
+	case r.Method == http.MethodGet && strings.HasSuffix(path, "/containers/json"):
+		resp = []container.Summary{{ID: "container-old", Labels: labels}}
+	case r.Method == http.MethodGet && strings.HasSuffix(path, "/containers/container-old/json"):
+		resp = container.InspectResponse{
+			ID:         "container-old",
+			Created:    time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC).Format(time.RFC3339Nano),
+			Name:       "/" + string(d.instanceID),
+			Image:      "nginx",
+			State:      &container.State{Status: container.StateExited},
+			Config:     &container.Config{Image: "nginx", Labels: labels},
+			HostConfig: &d.hostConfig,
+			NetworkSettings: &container.NetworkSettings{
+				Networks: d.networks,
+			},
+		}
+	case r.Method == http.MethodPost && strings.HasSuffix(path, "/containers/create"):
+		var body struct {
+			HostConfig       container.HostConfig
+			NetworkingConfig network.NetworkingConfig
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		d.mu.Lock()
+		d.created = append(d.created, body.HostConfig)
+		d.createdNetworking = append(d.createdNetworking, body.NetworkingConfig)
+		d.mu.Unlock()
+		resp = container.CreateResponse{ID: "container-new"}
+	case r.Method == http.MethodPost && (strings.HasSuffix(path, "/rename") || strings.HasSuffix(path, "/connect")),
+		r.Method == http.MethodDelete:
+		w.WriteHeader(http.StatusNoContent)
+		return
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (d *fakeReplaceDaemon) createdHostConfigs() []container.HostConfig {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]container.HostConfig(nil), d.created...)
+}
+
+func (d *fakeReplaceDaemon) createdNetworkingConfigs() []network.NetworkingConfig {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]network.NetworkingConfig(nil), d.createdNetworking...)
+}
+
+func newFakeReplaceExecutor(t *testing.T, daemon *fakeReplaceDaemon) *Executor {
+	t.Helper()
+
+	srv := httptest.NewServer(daemon)
+	t.Cleanup(srv.Close)
+	cli, err := client.New(
+		client.WithHost("tcp://"+strings.TrimPrefix(srv.URL, "http://")),
+		client.WithAPIVersion(client.MaxAPIVersion),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = cli.Close() })
+	return &Executor{cli: cli, hostCPUs: 4}
+}
+
+func TestModifyInstanceAttributeResizesContainer(t *testing.T) {
+	t.Parallel()
+
+	const instanceID = executor.InstanceID("0123456789abcdef0")
+	oldResources := instanceResources(2, 1024, 4)
+	oldResources.MemorySwap = 2 * oldResources.Memory
+	hostConfig := container.HostConfig{Privileged: true, Resources: oldResources}
+
+	t.Run("instance type", func(t *testing.T) {
+		t.Parallel()
+
+		daemon := &fakeReplaceDaemon{instanceID: instanceID, hostConfig: hostConfig}
+		e := newFakeReplaceExecutor(t, daemon)
+		err := e.ModifyInstanceAttribute(t.Context(), executor.ModifyInstanceAttributeRequest{
+			InstanceID:   instanceID,
+			InstanceType: new("t3.2xlarge"),
+			VCPUs:        8,
+			MemoryMiB:    32768,
+		})
+		require.NoError(t, err)
+		created := daemon.createdHostConfigs()
+		require.Len(t, created, 1)
+		assert.True(t, created[0].Privileged)
+		// CPUs are capped to the host CPUs
+		assert.Equal(t, int64(4e9), created[0].NanoCPUs)
+		assert.Equal(t, int64(32768)*1024*1024, created[0].Memory)
+		assert.Zero(t, created[0].MemorySwap)
+	})
+
+	t.Run("user data", func(t *testing.T) {
+		t.Parallel()
+
+		daemon := &fakeReplaceDaemon{instanceID: instanceID, hostConfig: hostConfig}
+		e := newFakeReplaceExecutor(t, daemon)
+		err := e.ModifyInstanceAttribute(t.Context(), executor.ModifyInstanceAttributeRequest{
+			InstanceID: instanceID,
+			UserData:   new("#!/bin/sh"),
+		})
+		require.NoError(t, err)
+		created := daemon.createdHostConfigs()
+		require.Len(t, created, 1)
+		assert.Equal(t, oldResources.NanoCPUs, created[0].NanoCPUs)
+		assert.Equal(t, oldResources.Memory, created[0].Memory)
+		assert.Equal(t, oldResources.MemorySwap, created[0].MemorySwap)
+	})
+}
+
+func TestReplaceContainerKeepsNetworks(t *testing.T) {
+	t.Parallel()
+
+	const instanceID = executor.InstanceID("0123456789abcdef0")
+	daemon := &fakeReplaceDaemon{
+		instanceID: instanceID,
+		networks: map[string]*network.EndpointSettings{
+			"dc2-custom": {
+				Aliases: []string{"web", "web.internal"},
+				IPAMConfig: &network.EndpointIPAMConfig{
+					IPv4Address: netip.MustParseAddr("172.30.0.10"),
+				},
+				NetworkID:  "network-custom",
+				EndpointID: "endpoint-custom",
+				IPAddress:  netip.MustParseAddr("172.30.0.10"),
+			},
+			"bridge": {
+				NetworkID: "network-bridge",
+				IPAddress: netip.MustParseAddr("172.17.0.2"),
+			},
+		},
+	}
+	e := newFakeReplaceExecutor(t, daemon)
+	err := e.ModifyInstanceAttribute(t.Context(), executor.ModifyInstanceAttributeRequest{
+		InstanceID: instanceID,
+		UserData:   new("#!/bin/sh"),
+	})
+	require.NoError(t, err)
+	created := daemon.createdNetworkingConfigs()
+	require.Len(t, created, 1)
+	assert.Equal(t, map[string]*network.EndpointSettings{
+		"dc2-custom": {
+			Aliases: []string{"web", "web.internal"},
+			IPAMConfig: &network.EndpointIPAMConfig{
+				IPv4Address: netip.MustParseAddr("172.30.0.10"),
+			},
+		},
+		"bridge": {},
+	}, created[0].EndpointsConfig)
+}
@@ -0,0 +1,105 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/moby/moby/api/types/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+)
+
+func TestInstanceState(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		state    *container.State
+		expected api.InstanceState
+	}{
+		{
+			name:     "created container is pending",
+			state:    &container.State{Status: container.StateCreated},
+			expected: api.InstanceStatePending,
+		},
+		{
+			name:     "running container is running",
+			state:    &container.State{Status: container.StateRunning, Running: true},
+			expected: api.InstanceStateRunning,
+		},
+		{
+			name:     "paused container is stopping",
+			state:    &container.State{Status: container.StatePaused, Running: true, Paused: true},
+			expected: api.InstanceStateStopping,
+		},
+		{
+			name:     "exited container is stopped",
+			state:    &container.State{Status: container.StateExited},
+			expected: api.InstanceStateStopped,
+		},
+		{
+			name:     "removing container is shutting down",
+			state:    &container.State{Status: container.StateRemoving},
+			expected: api.InstanceStateShuttingDown,
+		},
+		{
+			name:     "dead container is terminated",
+			state:    &container.State{Status: container.StateDead, Dead: true},
+			expected: api.InstanceStateTerminated,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := instanceState(tc.state)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+
+	t.Run("nil state is an error", func(t *testing.T) {
+		t.Parallel()
+		_, err := instanceState(nil)
+		require.Error(t, err)
+	})
+}
+
+func TestInstanceResources(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		vcpus     int
+		memoryMiB int
+		hostCPUs  int
+		expected  container.Resources
+	}{
+		{
+			name:     "unknown instance type is unlimited",
+			hostCPUs: 4,
+		},
+		{
+			name:      "limits match the instance type",
+			vcpus:     1,
+			memoryMiB: 512,
+			hostCPUs:  4,
+			expected:  container.Resources{NanoCPUs: 1e9, Memory: 512 * 1024 * 1024},
+		},
+		{
+			name:      "CPUs are capped to the host",
+			vcpus:     96,
+			memoryMiB: 1024,
+			hostCPUs:  4,
+			expected:  container.Resources{NanoCPUs: 4e9, Memory: 1024 * 1024 * 1024},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, instanceResources(tc.vcpus, tc.memoryMiB, tc.hostCPUs))
+		})
+	}
+}
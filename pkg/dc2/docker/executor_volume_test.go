@@ -0,0 +1,49 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/moby/moby/api/types/mount"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveVolumeRoot(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		value    string
+		expected string
+	}{
+		{value: "", expected: defaultVolumeRoot},
+		{value: "  ", expected: defaultVolumeRoot},
+		{value: "/var/lib/dc2", expected: "/var/lib/dc2"},
+		{value: "/var/lib/dc2/", expected: "/var/lib/dc2"},
+	} {
+		volumeRoot, err := resolveVolumeRoot(tc.value)
+		require.NoError(t, err, tc.value)
+		assert.Equal(t, tc.expected, volumeRoot, tc.value)
+	}
+
+	for _, value := range []string{"dc2", "./dc2", "/", "//"} {
+		_, err := resolveVolumeRoot(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestVolumeRootPaths(t *testing.T) {
+	t.Parallel()
+
+	const volumeRoot = "/var/lib/dc2-volumes"
+	e := &Executor{volumeRoot: volumeRoot}
+	assert.Equal(t, volumeRoot+"/0123", e.internalVolumeFilePath("0123"))
+	assert.Equal(t, volumeRoot+"/0123.attachments", e.internalVolumeAttachmentInfoPath("0123"))
+	assert.Equal(t, volumeRoot+"/4567.snapshot", e.internalSnapshotFilePath("4567"))
+
+	assert.Equal(t, []mount.Mount{
+		{Type: mount.TypeVolume, Source: "dc2-volume", Target: volumeRoot},
+	}, dc2Mounts("dc2-volume", "", volumeRoot))
+	assert.Equal(t, []mount.Mount{
+		{Type: mount.TypeBind, Source: "/srv/dc2", Target: volumeRoot},
+	}, dc2Mounts("", "/srv/dc2", volumeRoot))
+}
@@ -0,0 +1,69 @@
+package docker
+
+import (
+	"context"
+	"sync"
+
+	"github.com/moby/moby/api/types/container"
+)
+
+type inspectCacheKey struct{}
+
+// inspectCache holds the container inspections made while describing
+// instances with a context returned by WithInspectCache. Entries are only
+// valid for the executor generation they were made in.
+type inspectCache struct {
+	mu         sync.Mutex
+	generation uint64
+	containers map[string]container.InspectResponse
+}
+
+// WithInspectCache returns a context that makes the Executor reuse container
+// inspections while describing instances, so repeated describes within a
+// single dispatch don't inspect the same containers again. Any mutating
+// executor call invalidates the cached inspections. If ctx already has a
+// cache, it's returned unchanged.
+func WithInspectCache(ctx context.Context) context.Context {
+	if _, found := ctx.Value(inspectCacheKey{}).(*inspectCache); found {
+		return ctx
+	}
+	return context.WithValue(ctx, inspectCacheKey{}, &inspectCache{})
+}
+
+// invalidateInspectCaches discards the inspections cached in every context.
+// It must be called after any call that might change instance containers.
+func (e *Executor) invalidateInspectCaches() {
+	e.inspectGeneration.Add(1)
+}
+
+// inspectContainerCached works like inspectContainer, but reuses the
+// inspections cached in ctx, if any. The returned value must not be
+// modified.
+func (e *Executor) inspectContainerCached(ctx context.Context, containerID string) (container.InspectResponse, error) {
+	cache, found := ctx.Value(inspectCacheKey{}).(*inspectCache)
+	if !found {
+		return inspectContainer(ctx, e.cli, containerID)
+	}
+	generation := e.inspectGeneration.Load()
+	cache.mu.Lock()
+	if cache.generation != generation || cache.containers == nil {
+		cache.generation = generation
+		cache.containers = make(map[string]container.InspectResponse)
+	}
+	info, found := cache.containers[containerID]
+	cache.mu.Unlock()
+	if found {
+		return info, nil
+	}
+	info, err := inspectContainer(ctx, e.cli, containerID)
+	if err != nil {
+		return info, err
+	}
+	cache.mu.Lock()
+	// Don't cache the result if the containers changed while inspecting
+	if cache.generation == generation && e.inspectGeneration.Load() == generation {
+		cache.containers[containerID] = info
+	}
+	cache.mu.Unlock()
+	return info, nil
+}
@@ -0,0 +1,147 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/executor"
+)
+
+// fakeInstanceDaemon serves the subset of the Docker API used to describe
+// instances, counting container inspections.
+type fakeInstanceDaemon struct {
+	instanceIDs []executor.InstanceID
+	inspects    atomic.Int64
+}
+
+func (d *fakeInstanceDaemon) labels(instanceID executor.InstanceID) map[string]string {
+	return map[string]string{
+		LabelDC2Enabled:      "true",
+		LabelDC2InstanceID:   string(instanceID),
+		LabelDC2InstanceType: "t3.micro",
+		LabelDC2ImageID:      "ami-0123456789abcdef0",
+	}
+}
+
+func (d *fakeInstanceDaemon) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path
+	var resp any
+	switch {
+	case strings.HasSuffix(path, "/containers/json"):
+		items := make([]container.Summary, 0, len(d.instanceIDs))
+		for _, id := range d.instanceIDs {
+			items = append(items, container.Summary{ID: "container-" + string(id), Labels: d.labels(id)})
+		}
+		resp = items
+	case strings.Contains(path, "/containers/container-") && strings.HasSuffix(path, "/json"):
+		d.inspects.Add(1)
+		containerID := strings.TrimSuffix(path[strings.LastIndex(path, "/containers/")+len("/containers/"):], "/json")
+		instanceID := executor.InstanceID(strings.TrimPrefix(containerID, "container-"))
+		resp = container.InspectResponse{
+			ID:      containerID,
+			Created: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC).Format(time.RFC3339Nano),
+			Name:    "/" + string(instanceID),
+			Image:   "nginx",
+			State:   &container.State{Status: container.StateRunning, Running: true},
+			Config:  &container.Config{Labels: d.labels(instanceID)},
+		}
+	case strings.Contains(path, "/images/"):
+		resp = map[string]string{"Id": "sha256:0123", "Architecture": "amd64", "Os": "linux"}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func newFakeInstanceExecutor(tb testing.TB, instances int) (*Executor, *fakeInstanceDaemon) {
+	tb.Helper()
+
+	daemon := &fakeInstanceDaemon{}
+	for i := range instances {
+		daemon.instanceIDs = append(daemon.instanceIDs, executor.InstanceID(fmt.Sprintf("i-%017x", i)))
+	}
+	srv := httptest.NewServer(daemon)
+	tb.Cleanup(srv.Close)
+	cli, err := client.New(
+		client.WithHost("tcp://"+strings.TrimPrefix(srv.URL, "http://")),
+		client.WithAPIVersion(client.MaxAPIVersion),
+	)
+	require.NoError(tb, err)
+	tb.Cleanup(func() { _ = cli.Close() })
+	return &Executor{cli: cli}, daemon
+}
+
+// describeGroupWithWarmPool describes the instances like DescribeWarmPool
+// does: first the in service instances, then every instance in the group.
+func describeGroupWithWarmPool(ctx context.Context, e *Executor, instanceIDs []executor.InstanceID) error {
+	inService := instanceIDs[:len(instanceIDs)/2]
+	for _, ids := range [][]executor.InstanceID{inService, instanceIDs, instanceIDs} {
+		if _, err := e.DescribeInstances(ctx, executor.DescribeInstancesRequest{InstanceIDs: ids}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestInspectCache(t *testing.T) {
+	t.Parallel()
+
+	const instances = 10
+	e, daemon := newFakeInstanceExecutor(t, instances)
+
+	// Without a cache, every describe inspects the containers again
+	require.NoError(t, describeGroupWithWarmPool(t.Context(), e, daemon.instanceIDs))
+	assert.Equal(t, int64(instances/2+2*instances), daemon.inspects.Load())
+
+	daemon.inspects.Store(0)
+	ctx := WithInspectCache(t.Context())
+	assert.Equal(t, ctx, WithInspectCache(ctx))
+	require.NoError(t, describeGroupWithWarmPool(ctx, e, daemon.instanceIDs))
+	assert.Equal(t, int64(instances), daemon.inspects.Load())
+
+	descriptions, err := e.DescribeInstances(ctx, executor.DescribeInstancesRequest{InstanceIDs: daemon.instanceIDs})
+	require.NoError(t, err)
+	require.Len(t, descriptions, instances)
+	for i, desc := range descriptions {
+		assert.Equal(t, daemon.instanceIDs[i], desc.InstanceID)
+	}
+	assert.Equal(t, int64(instances), daemon.inspects.Load())
+
+	// Mutating calls invalidate the cache
+	e.invalidateInspectCaches()
+	_, err = e.DescribeInstances(ctx, executor.DescribeInstancesRequest{InstanceIDs: daemon.instanceIDs})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2*instances), daemon.inspects.Load())
+}
+
+func BenchmarkDescribeGroupWithWarmPool(b *testing.B) {
+	for _, cached := range []bool{false, true} {
+		b.Run(fmt.Sprintf("cached=%t", cached), func(b *testing.B) {
+			e, daemon := newFakeInstanceExecutor(b, 50)
+			for b.Loop() {
+				ctx := b.Context()
+				if cached {
+					ctx = WithInspectCache(ctx)
+				}
+				if err := describeGroupWithWarmPool(ctx, e, daemon.instanceIDs); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(daemon.inspects.Load())/float64(b.N), "inspects/op")
+		})
+	}
+}
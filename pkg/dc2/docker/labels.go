@@ -3,6 +3,7 @@ package docker
 import "github.com/moby/moby/api/types/container"
 
 const (
+	LabelDC2Architecture = "dc2:architecture"
 	LabelDC2Enabled      = "dc2:enabled"
 	LabelDC2ImageID      = "dc2:image-id"
 	LabelDC2InstanceID   = "dc2:instance-id"
@@ -13,6 +14,7 @@ const (
 	LabelDC2InstanceType = "dc2:instance-type"
 	LabelDC2KeyName      = "dc2:key-name"
 	LabelDC2OwnedNetwork = "dc2:owned-network"
+	LabelDC2PublicIP     = "dc2:public-ip"
 	LabelDC2UserData     = "dc2:user-data"
 	LabelDC2Main         = "dc2:main"
 )
@@ -0,0 +1,75 @@
+package docker
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/netip"
+
+	"github.com/moby/moby/api/types/network"
+)
+
+// publishAddressAttempts is the number of random addresses tried before
+// giving up on finding one that's not in use.
+const publishAddressAttempts = 64
+
+var (
+	// publishAddressPrefix is the range instances publish their port on. On
+	// Linux, every address in it routes to the loopback interface, so each
+	// instance gets its own address and all of them can publish the same
+	// port, like EC2 public IPs do.
+	publishAddressPrefix = netip.MustParsePrefix("127.0.0.0/8")
+	// hostLoopbackAddr is never allocated, since services on the host
+	// commonly listen on it.
+	hostLoopbackAddr = netip.MustParseAddr("127.0.0.1")
+)
+
+// publishedAddresses returns the addresses used by the instances publishing
+// a port, including the ones launched by other dc2 servers sharing the same
+// Docker daemon.
+func (e *Executor) publishedAddresses(ctx context.Context) (map[netip.Addr]bool, error) {
+	containers, err := listContainers(ctx, e.cli, dockerFilters("label", LabelDC2PublicIP))
+	if err != nil {
+		return nil, fmt.Errorf("listing published instance containers: %w", err)
+	}
+	used := make(map[netip.Addr]bool, len(containers))
+	for _, c := range containers {
+		if addr, err := netip.ParseAddr(c.Labels[LabelDC2PublicIP]); err == nil {
+			used[addr] = true
+		}
+	}
+	return used, nil
+}
+
+// randomPublishAddress returns a random address in publishAddressPrefix
+// that's not in used. Random addresses make collisions between dc2 servers
+// sharing the Docker daemon unlikely, since they can't coordinate their
+// allocations.
+func randomPublishAddress(used map[netip.Addr]bool) (netip.Addr, error) {
+	base := publishAddressPrefix.Masked().Addr().As4()
+	for range publishAddressAttempts {
+		var b [4]byte
+		_, _ = rand.Read(b[1:])
+		b[0] = base[0]
+		addr := netip.AddrFrom4(b)
+		// Skip network and broadcast-like addresses, which some tools
+		// refuse to connect to
+		if b[3] == 0 || b[3] == 255 || addr == hostLoopbackAddr || used[addr] {
+			continue
+		}
+		return addr, nil
+	}
+	return netip.Addr{}, errors.New("no address available to publish instance port")
+}
+
+// publishedPorts returns the exposed ports and bindings that publish the
+// given container TCP port on the same port at addr.
+func publishedPorts(port int, addr netip.Addr) (network.PortSet, network.PortMap) {
+	containerPort, _ := network.PortFrom(uint16(port), network.TCP)
+	exposed := network.PortSet{containerPort: struct{}{}}
+	bindings := network.PortMap{
+		containerPort: {{HostIP: addr, HostPort: containerPort.Port()}},
+	}
+	return exposed, bindings
+}
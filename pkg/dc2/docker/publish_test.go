@@ -0,0 +1,36 @@
+package docker
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/moby/moby/api/types/network"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRandomPublishAddress(t *testing.T) {
+	t.Parallel()
+
+	used := make(map[netip.Addr]bool)
+	for range 100 {
+		addr, err := randomPublishAddress(used)
+		require.NoError(t, err)
+		assert.True(t, publishAddressPrefix.Contains(addr), addr)
+		assert.NotEqual(t, hostLoopbackAddr, addr)
+		assert.False(t, used[addr], addr)
+		used[addr] = true
+	}
+}
+
+func TestPublishedPorts(t *testing.T) {
+	t.Parallel()
+
+	addr := netip.MustParseAddr("127.1.2.3")
+	exposed, bindings := publishedPorts(80, addr)
+	port := network.MustParsePort("80/tcp")
+	assert.Equal(t, network.PortSet{port: struct{}{}}, exposed)
+	assert.Equal(t, network.PortMap{
+		port: {{HostIP: addr, HostPort: "80"}},
+	}, bindings)
+}
@@ -0,0 +1,194 @@
+package dc2
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/executor"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+// StateChangeEventType identifies the kind of change a StateChangeEvent
+// describes.
+type StateChangeEventType string
+
+const (
+	// EventTypeInstanceStateChange is emitted when an instance changes its
+	// state (e.g. from pending to running). States are the EC2 instance
+	// state names.
+	EventTypeInstanceStateChange StateChangeEventType = "InstanceStateChange"
+	// EventTypeAutoScalingGroupCapacityChange is emitted when an auto
+	// scaling group scales out or in. States are the number of instances in
+	// service before and after scaling.
+	EventTypeAutoScalingGroupCapacityChange StateChangeEventType = "AutoScalingGroupCapacityChange"
+	// EventTypeWarmPoolInstanceChange is emitted when an instance enters or
+	// leaves the warm pool of an auto scaling group. States are the
+	// instance lifecycle states (e.g. Warmed:Stopped or InService), with
+	// an empty previous state for instances launched into the pool and
+	// Terminated for instances removed from it.
+	EventTypeWarmPoolInstanceChange StateChangeEventType = "WarmPoolInstanceChange"
+)
+
+// warmPoolTerminatedLifecycleState is the lifecycle state reported for
+// instances terminated from a warm pool.
+const warmPoolTerminatedLifecycleState = "Terminated"
+
+// stateChangeEventBufferSize is the number of events buffered for each
+// subscriber. Events are dropped for subscribers that fall further behind.
+const stateChangeEventBufferSize = 256
+
+// StateChangeEvent is a resource state change, streamed as JSON by the
+// /events endpoint when enabled with WithEventStream.
+type StateChangeEvent struct {
+	Type                 StateChangeEventType `json:"type"`
+	ResourceType         types.ResourceType   `json:"resourceType"`
+	ResourceID           string               `json:"resourceId"`
+	PreviousState        string               `json:"previousState,omitempty"`
+	State                string               `json:"state"`
+	AutoScalingGroupName string               `json:"autoScalingGroupName,omitempty"`
+	Timestamp            time.Time            `json:"timestamp"`
+}
+
+// stateChangeEvents fans out state change events to the /events
+// subscribers. A nil *stateChangeEvents discards every event.
+type stateChangeEvents struct {
+	mu          sync.Mutex
+	closed      bool
+	subscribers map[chan StateChangeEvent]struct{}
+}
+
+func newStateChangeEvents() *stateChangeEvents {
+	return &stateChangeEvents{
+		subscribers: make(map[chan StateChangeEvent]struct{}),
+	}
+}
+
+// subscribe returns a channel receiving the events published from now on
+// and a function to stop receiving them. The channel is closed when the
+// subscription is cancelled or the events are closed.
+func (e *stateChangeEvents) subscribe() (<-chan StateChangeEvent, func()) {
+	ch := make(chan StateChangeEvent, stateChangeEventBufferSize)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	e.subscribers[ch] = struct{}{}
+	return ch, func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		if _, found := e.subscribers[ch]; found {
+			delete(e.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// publish sends the events to every subscriber without blocking.
+func (e *stateChangeEvents) publish(events ...StateChangeEvent) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for ch := range e.subscribers {
+		for _, event := range events {
+			select {
+			case ch <- event:
+			default:
+			}
+		}
+	}
+}
+
+// close ends every subscription, so /events streams finish on shutdown.
+func (e *stateChangeEvents) close() {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.closed = true
+	for ch := range e.subscribers {
+		delete(e.subscribers, ch)
+		close(ch)
+	}
+}
+
+func (d *Dispatcher) publishStateChangeEvents(events ...StateChangeEvent) {
+	if d.stateChangeEvents == nil || len(events) == 0 {
+		return
+	}
+	now := d.now().UTC()
+	for i := range events {
+		events[i].Timestamp = now
+	}
+	d.stateChangeEvents.publish(events...)
+}
+
+// publishInstanceStateChanges publishes the changes that moved an instance
+// to a different state.
+func (d *Dispatcher) publishInstanceStateChanges(changes []executor.InstanceStateChange) {
+	if d.stateChangeEvents == nil {
+		return
+	}
+	events := make([]StateChangeEvent, 0, len(changes))
+	for _, change := range changes {
+		if change.PreviousState.Name == change.CurrentState.Name {
+			continue
+		}
+		events = append(events, StateChangeEvent{
+			Type:          EventTypeInstanceStateChange,
+			ResourceType:  types.ResourceTypeInstance,
+			ResourceID:    apiInstanceID(change.InstanceID),
+			PreviousState: change.PreviousState.Name,
+			State:         change.CurrentState.Name,
+		})
+	}
+	d.publishStateChangeEvents(events...)
+}
+
+func (d *Dispatcher) publishAutoScalingGroupCapacityChange(groupName string, previous int, current int) {
+	if previous == current {
+		return
+	}
+	d.publishStateChangeEvents(StateChangeEvent{
+		Type:                 EventTypeAutoScalingGroupCapacityChange,
+		ResourceType:         types.ResourceTypeAutoScalingGroup,
+		ResourceID:           groupName,
+		PreviousState:        strconv.Itoa(previous),
+		State:                strconv.Itoa(current),
+		AutoScalingGroupName: groupName,
+	})
+}
+
+func (d *Dispatcher) publishWarmPoolInstanceChanges(groupName string, instanceIDs []string, previous string, current string) {
+	if d.stateChangeEvents == nil {
+		return
+	}
+	events := make([]StateChangeEvent, 0, len(instanceIDs))
+	for _, instanceID := range instanceIDs {
+		events = append(events, StateChangeEvent{
+			Type:                 EventTypeWarmPoolInstanceChange,
+			ResourceType:         types.ResourceTypeInstance,
+			ResourceID:           instanceID,
+			PreviousState:        previous,
+			State:                current,
+			AutoScalingGroupName: groupName,
+		})
+	}
+	d.publishStateChangeEvents(events...)
+}
+
+// warmPoolLifecycleState returns the lifecycle state of the instances in the
+// warm pool of group once they reach its pool state.
+func warmPoolLifecycleState(group *autoScalingGroupData) string {
+	instanceState := api.InstanceStateStopped.Name
+	if group.WarmPoolState == warmPoolStateRunning {
+		instanceState = api.InstanceStateRunning.Name
+	}
+	return autoScalingWarmPoolLifecycleState(instanceState, group.WarmPoolState)
+}
@@ -0,0 +1,139 @@
+package dc2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+)
+
+// receivedEvents returns the events buffered in events.
+func receivedEvents(events <-chan StateChangeEvent) []StateChangeEvent {
+	var out []StateChangeEvent
+	for {
+		select {
+		case event := <-events:
+			out = append(out, event)
+		default:
+			return out
+		}
+	}
+}
+
+func eventsOfType(events []StateChangeEvent, eventType StateChangeEventType) []StateChangeEvent {
+	var out []StateChangeEvent
+	for _, event := range events {
+		if event.Type == eventType {
+			out = append(out, event)
+		}
+	}
+	return out
+}
+
+func TestAutoScalingGroupStateChangeEvents(t *testing.T) {
+	t.Parallel()
+
+	const groupName = "asg"
+	ctx := t.Context()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	d := &Dispatcher{
+		exe:               newScalingExecutor(),
+		imds:              &imdsController{},
+		storage:           storage.NewMemoryStorage(),
+		opts:              DispatcherOptions{Region: "us-east-1"},
+		clock:             func() time.Time { return now },
+		stateChangeEvents: newStateChangeEvents(),
+	}
+	events, cancel := d.stateChangeEvents.subscribe()
+	defer cancel()
+
+	createResp, err := d.dispatchCreateLaunchTemplate(ctx, &api.CreateLaunchTemplateRequest{
+		LaunchTemplateName: "lt",
+		LaunchTemplateData: api.LaunchTemplateData{ImageID: "nginx", InstanceType: "t3.micro"},
+	})
+	require.NoError(t, err)
+	_, err = d.Dispatch(ctx, &api.CreateAutoScalingGroupRequest{
+		AutoScalingGroupName: groupName,
+		MinSize:              new(0),
+		MaxSize:              new(3),
+		DesiredCapacity:      new(2),
+		LaunchTemplate: &api.AutoScalingLaunchTemplateSpecification{
+			LaunchTemplateID: createResp.LaunchTemplate.LaunchTemplateID,
+		},
+	})
+	require.NoError(t, err)
+
+	received := receivedEvents(events)
+	instanceEvents := eventsOfType(received, EventTypeInstanceStateChange)
+	require.Len(t, instanceEvents, 2)
+	for _, event := range instanceEvents {
+		assert.Equal(t, api.InstanceStatePending.Name, event.PreviousState)
+		assert.Equal(t, api.InstanceStateRunning.Name, event.State)
+	}
+	capacityEvents := eventsOfType(received, EventTypeAutoScalingGroupCapacityChange)
+	require.Len(t, capacityEvents, 1)
+	assert.Equal(t, groupName, capacityEvents[0].ResourceID)
+	assert.Equal(t, "0", capacityEvents[0].PreviousState)
+	assert.Equal(t, "2", capacityEvents[0].State)
+
+	// Warm pool instances are launched, stopped and then promoted
+	_, err = d.Dispatch(ctx, &api.PutWarmPoolRequest{AutoScalingGroupName: groupName})
+	require.NoError(t, err)
+	received = receivedEvents(events)
+	warmPoolEvents := eventsOfType(received, EventTypeWarmPoolInstanceChange)
+	require.Len(t, warmPoolEvents, 1)
+	warmInstanceID := warmPoolEvents[0].ResourceID
+	assert.Empty(t, warmPoolEvents[0].PreviousState)
+	assert.Equal(t, autoScalingWarmLifecycleStateStopped, warmPoolEvents[0].State)
+	assert.Equal(t, groupName, warmPoolEvents[0].AutoScalingGroupName)
+	assert.Contains(t, eventsOfType(received, EventTypeInstanceStateChange), StateChangeEvent{
+		Type:          EventTypeInstanceStateChange,
+		ResourceType:  warmPoolEvents[0].ResourceType,
+		ResourceID:    warmInstanceID,
+		PreviousState: api.InstanceStateRunning.Name,
+		State:         api.InstanceStateStopped.Name,
+		Timestamp:     now,
+	})
+
+	_, err = d.Dispatch(ctx, &api.SetDesiredCapacityRequest{AutoScalingGroupName: groupName, DesiredCapacity: new(3)})
+	require.NoError(t, err)
+	received = receivedEvents(events)
+	warmPoolEvents = eventsOfType(received, EventTypeWarmPoolInstanceChange)
+	require.NotEmpty(t, warmPoolEvents)
+	assert.Equal(t, warmInstanceID, warmPoolEvents[0].ResourceID)
+	assert.Equal(t, autoScalingWarmLifecycleStateStopped, warmPoolEvents[0].PreviousState)
+	assert.Equal(t, autoScalingLifecycleState, warmPoolEvents[0].State)
+	capacityEvents = eventsOfType(received, EventTypeAutoScalingGroupCapacityChange)
+	require.Len(t, capacityEvents, 1)
+	assert.Equal(t, "2", capacityEvents[0].PreviousState)
+	assert.Equal(t, "3", capacityEvents[0].State)
+}
+
+func TestStateChangeEventsSubscriptions(t *testing.T) {
+	t.Parallel()
+
+	var disabled *stateChangeEvents
+	disabled.publish(StateChangeEvent{Type: EventTypeInstanceStateChange})
+	disabled.close()
+
+	events := newStateChangeEvents()
+	ch, cancel := events.subscribe()
+	for range stateChangeEventBufferSize + 1 {
+		events.publish(StateChangeEvent{Type: EventTypeInstanceStateChange})
+	}
+	// Events that don't fit in the buffer are dropped
+	assert.Len(t, receivedEvents(ch), stateChangeEventBufferSize)
+	cancel()
+	cancel()
+	_, open := <-ch
+	assert.False(t, open)
+
+	events.close()
+	ch, _ = events.subscribe()
+	_, open = <-ch
+	assert.False(t, open)
+}
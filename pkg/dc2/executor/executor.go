@@ -14,10 +14,21 @@ type CreateInstancesRequest struct {
 	InstanceType string
 	Count        int
 	UserData     string
+	// VCPUs and MemoryMiB limit the resources available to each instance.
+	// Zero means unlimited.
+	VCPUs     int
+	MemoryMiB int
+	// Architecture is the EC2 architecture (e.g. x86_64 or arm64) to run
+	// the image for. Empty uses the image default.
+	Architecture string
 }
 
 type StartInstancesRequest struct {
 	InstanceIDs []InstanceID
+	// ResetInstanceIDs lists the instances among InstanceIDs whose
+	// filesystem is reset to their original image before starting, like
+	// instance store volumes lose their data across a stop.
+	ResetInstanceIDs []InstanceID
 }
 
 type StopInstancesRequest struct {
@@ -25,6 +36,22 @@ type StopInstancesRequest struct {
 	Force       bool
 }
 
+type RebootInstancesRequest struct {
+	InstanceIDs []InstanceID
+}
+
+// ModifyInstanceAttributeRequest changes attributes of a stopped instance.
+// Nil fields are left unchanged.
+type ModifyInstanceAttributeRequest struct {
+	InstanceID   InstanceID
+	InstanceType *string
+	// VCPUs and MemoryMiB are the resources of InstanceType, applied when
+	// it's set. Zero values leave the resource unlimited.
+	VCPUs     int
+	MemoryMiB int
+	UserData  *string
+}
+
 type TerminateInstancesRequest struct {
 	InstanceIDs []InstanceID
 	Force       bool
@@ -58,15 +85,26 @@ type InstanceDescription struct {
 	PublicIP       string
 	InstanceType   string
 	Architecture   string
+	Platform       string
 	LaunchTime     time.Time
 }
 
+// InstanceConsoleOutput is the console output of an instance, along with
+// the time its most recent line was written.
+type InstanceConsoleOutput struct {
+	Output    []byte
+	Timestamp time.Time
+}
+
 type InstanceExecutor interface {
 	CreateInstances(ctx context.Context, req CreateInstancesRequest) ([]InstanceID, error)
 	DescribeInstances(ctx context.Context, req DescribeInstancesRequest) ([]InstanceDescription, error)
 	StartInstances(ctx context.Context, req StartInstancesRequest) ([]InstanceStateChange, error)
 	StopInstances(ctx context.Context, req StopInstancesRequest) ([]InstanceStateChange, error)
+	RebootInstances(ctx context.Context, req RebootInstancesRequest) error
+	ModifyInstanceAttribute(ctx context.Context, req ModifyInstanceAttributeRequest) error
 	TerminateInstances(ctx context.Context, req TerminateInstancesRequest) ([]InstanceStateChange, error)
+	InstanceConsoleOutput(ctx context.Context, instanceID InstanceID) (InstanceConsoleOutput, error)
 }
 
 type VolumeID string
@@ -74,6 +112,9 @@ type VolumeID string
 type CreateVolumeRequest struct {
 	// Size is the volume size in bytes
 	Size int64
+	// SnapshotID optionally names the snapshot to copy the volume
+	// contents from
+	SnapshotID SnapshotID
 }
 
 type DeleteVolumeRequest struct {
@@ -109,18 +150,91 @@ type VolumeDescription struct {
 	Attachments []VolumeAttachment
 }
 
+type ResizeVolumeRequest struct {
+	VolumeID VolumeID
+	// Size is the new volume size in bytes
+	Size int64
+}
+
+type SnapshotID string
+
+type CreateSnapshotRequest struct {
+	VolumeID VolumeID
+}
+
+type DeleteSnapshotRequest struct {
+	SnapshotID SnapshotID
+}
+
+type CopySnapshotRequest struct {
+	SnapshotID SnapshotID
+}
+
+type DescribeSnapshotsRequest struct {
+	SnapshotIDs []SnapshotID
+}
+
+type SnapshotDescription struct {
+	SnapshotID SnapshotID
+}
+
 type VolumeExecutor interface {
 	CreateVolume(ctx context.Context, req CreateVolumeRequest) (VolumeID, error)
 	DeleteVolume(ctx context.Context, req DeleteVolumeRequest) error
 	DescribeVolumes(ctx context.Context, req DescribeVolumesRequest) ([]VolumeDescription, error)
 	AttachVolume(ctx context.Context, req AttachVolumeRequest) (*VolumeAttachment, error)
 	DetachVolume(ctx context.Context, req DetachVolumeRequest) (*VolumeAttachment, error)
+	ResizeVolume(ctx context.Context, req ResizeVolumeRequest) error
+	CreateSnapshot(ctx context.Context, req CreateSnapshotRequest) (SnapshotID, error)
+	DeleteSnapshot(ctx context.Context, req DeleteSnapshotRequest) error
+	CopySnapshot(ctx context.Context, req CopySnapshotRequest) (SnapshotID, error)
+	// DescribeSnapshots describes the given snapshots, omitting the ones
+	// that don't exist
+	DescribeSnapshots(ctx context.Context, req DescribeSnapshotsRequest) ([]SnapshotDescription, error)
+}
+
+type CreateImageRequest struct {
+	InstanceID InstanceID
+	// ImageID is the reference the new image is stored as, which can be
+	// used as the CreateInstancesRequest.ImageID of later launches
+	ImageID string
+}
+
+type DeleteImageRequest struct {
+	ImageID string
+}
+
+type CopyImageRequest struct {
+	// SourceImageID is the reference of the image to copy
+	SourceImageID string
+	// ImageID is the reference the copy is stored as
+	ImageID string
+}
+
+type ImageDescription struct {
+	// ImageID is the reference used to launch instances from the image
+	ImageID      string
+	Architecture string
+	Platform     string
+	// Size is the image size in bytes
+	Size         int64
+	CreationDate time.Time
+}
+
+type ImageExecutor interface {
+	CreateImage(ctx context.Context, req CreateImageRequest) error
+	DeleteImage(ctx context.Context, req DeleteImageRequest) error
+	CopyImage(ctx context.Context, req CopyImageRequest) error
+	ListImages(ctx context.Context) ([]ImageDescription, error)
 }
 
 type Executor interface {
 	Close(ctx context.Context) error
 	Disconnect() error
+	// Ping checks whether the executor can currently run instances.
+	Ping(ctx context.Context) error
 	ListOwnedInstances(ctx context.Context) ([]InstanceID, error)
 	InstanceExecutor
 	VolumeExecutor
+	ImageExecutor
 }
@@ -8,6 +8,7 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 )
 
 var (
@@ -41,6 +42,14 @@ func decodeURLField(nameComponents []string, values []string, rv reflect.Value)
 			return err
 		}
 	case reflect.Struct:
+		if rv.Type() == reflect.TypeFor[time.Time]() {
+			t, err := time.Parse(time.RFC3339Nano, values[0])
+			if err != nil {
+				return fmt.Errorf("parsing time field: %w", err)
+			}
+			rv.Set(reflect.ValueOf(t))
+			break
+		}
 		fieldName := nameComponents[0]
 		f := fieldByName(rv, fieldName)
 		if !f.IsValid() {
@@ -87,6 +96,12 @@ func decodeURLField(nameComponents []string, values []string, rv reflect.Value)
 			return fmt.Errorf("parsing bool field: %w", err)
 		}
 		rv.SetBool(v)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(values[0], rv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("parsing float field: %w", err)
+		}
+		rv.SetFloat(f)
 	default:
 		return fmt.Errorf("cannot set value of type %s", rv.Type())
 	}
@@ -3,6 +3,7 @@ package format
 import (
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -242,6 +243,44 @@ func TestDecodeURLEncoded(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "target tracking scaling policy",
+			values: url.Values{
+				"AutoScalingGroupName": {"asg"},
+				"PolicyName":           {"cpu"},
+				"PolicyType":           {"TargetTrackingScaling"},
+				"TargetTrackingConfiguration.PredefinedMetricSpecification.PredefinedMetricType": {"ASGAverageCPUUtilization"},
+				"TargetTrackingConfiguration.TargetValue":                                        {"42.5"},
+			},
+			output: &api.PutScalingPolicyRequest{},
+			expected: &api.PutScalingPolicyRequest{
+				AutoScalingGroupName: "asg",
+				PolicyName:           "cpu",
+				PolicyType:           new("TargetTrackingScaling"),
+				TargetTrackingConfiguration: &api.TargetTrackingConfiguration{
+					PredefinedMetricSpecification: &api.PredefinedMetricSpecification{
+						PredefinedMetricType: "ASGAverageCPUUtilization",
+					},
+					TargetValue: new(42.5),
+				},
+			},
+		},
+		{
+			name: "scheduled action times",
+			values: url.Values{
+				"AutoScalingGroupName": {"asg"},
+				"ScheduledActionName":  {"scale-up"},
+				"StartTime":            {"2026-10-16T08:30:00Z"},
+				"DesiredCapacity":      {"2"},
+			},
+			output: &api.PutScheduledUpdateGroupActionRequest{},
+			expected: &api.PutScheduledUpdateGroupActionRequest{
+				AutoScalingGroupName: "asg",
+				ScheduledActionName:  "scale-up",
+				StartTime:            new(time.Date(2026, 10, 16, 8, 30, 0, 0, time.UTC)),
+				DesiredCapacity:      intPtr(2),
+			},
+		},
 		{
 			name: "autoscaling mixed instances policy",
 			values: url.Values{
@@ -36,33 +36,84 @@ const (
 )
 
 var requestFactories = map[string]func() api.Request{
-	"RunInstances":                  func() api.Request { return &api.RunInstancesRequest{} },
-	"DescribeInstances":             func() api.Request { return &api.DescribeInstancesRequest{} },
-	"DescribeSpotInstanceRequests":  func() api.Request { return &api.DescribeSpotInstanceRequestsRequest{} },
-	"DescribeInstanceStatus":        func() api.Request { return &api.DescribeInstanceStatusRequest{} },
+	"RunInstances":                 func() api.Request { return &api.RunInstancesRequest{} },
+	"DescribeInstances":            func() api.Request { return &api.DescribeInstancesRequest{} },
+	"DescribeSpotInstanceRequests": func() api.Request { return &api.DescribeSpotInstanceRequestsRequest{} },
+	"CancelSpotInstanceRequests":   func() api.Request { return &api.CancelSpotInstanceRequestsRequest{} },
+	"RequestSpotInstances":         func() api.Request { return &api.RequestSpotInstancesRequest{} },
+	"DescribeInstanceStatus":       func() api.Request { return &api.DescribeInstanceStatusRequest{} },
+	"DescribeInstanceCreditSpecifications": func() api.Request {
+		return &api.DescribeInstanceCreditSpecificationsRequest{}
+	},
+	"ModifyInstanceCreditSpecification": func() api.Request {
+		return &api.ModifyInstanceCreditSpecificationRequest{}
+	},
 	"DescribeSecurityGroups":        func() api.Request { return &api.DescribeSecurityGroupsRequest{} },
 	"CreateSecurityGroup":           func() api.Request { return &api.CreateSecurityGroupRequest{} },
 	"DeleteSecurityGroup":           func() api.Request { return &api.DeleteSecurityGroupRequest{} },
 	"AuthorizeSecurityGroupIngress": func() api.Request { return &api.AuthorizeSecurityGroupIngressRequest{} },
 	"AuthorizeSecurityGroupEgress":  func() api.Request { return &api.AuthorizeSecurityGroupEgressRequest{} },
+	"RevokeSecurityGroupIngress":    func() api.Request { return &api.RevokeSecurityGroupIngressRequest{} },
+	"RevokeSecurityGroupEgress":     func() api.Request { return &api.RevokeSecurityGroupEgressRequest{} },
+	"CreatePlacementGroup":          func() api.Request { return &api.CreatePlacementGroupRequest{} },
+	"DescribePlacementGroups":       func() api.Request { return &api.DescribePlacementGroupsRequest{} },
+	"DeletePlacementGroup":          func() api.Request { return &api.DeletePlacementGroupRequest{} },
+	"AllocateAddress":               func() api.Request { return &api.AllocateAddressRequest{} },
+	"AssociateAddress":              func() api.Request { return &api.AssociateAddressRequest{} },
+	"DisassociateAddress":           func() api.Request { return &api.DisassociateAddressRequest{} },
+	"ReleaseAddress":                func() api.Request { return &api.ReleaseAddressRequest{} },
+	"DescribeAddresses":             func() api.Request { return &api.DescribeAddressesRequest{} },
 	"DescribeSubnets":               func() api.Request { return &api.DescribeSubnetsRequest{} },
+	"CreateSubnet":                  func() api.Request { return &api.CreateSubnetRequest{} },
+	"DeleteSubnet":                  func() api.Request { return &api.DeleteSubnetRequest{} },
+	"CreateVpc":                     func() api.Request { return &api.CreateVpcRequest{} },
+	"DescribeVpcs":                  func() api.Request { return &api.DescribeVpcsRequest{} },
+	"DeleteVpc":                     func() api.Request { return &api.DeleteVpcRequest{} },
+	"DescribeNetworkInterfaces":     func() api.Request { return &api.DescribeNetworkInterfacesRequest{} },
+	"AssignPrivateIpAddresses":      func() api.Request { return &api.AssignPrivateIPAddressesRequest{} },
+	"UnassignPrivateIpAddresses":    func() api.Request { return &api.UnassignPrivateIPAddressesRequest{} },
 	"StopInstances":                 func() api.Request { return &api.StopInstancesRequest{} },
 	"StartInstances":                func() api.Request { return &api.StartInstancesRequest{} },
+	"RebootInstances":               func() api.Request { return &api.RebootInstancesRequest{} },
 	"TerminateInstances":            func() api.Request { return &api.TerminateInstancesRequest{} },
 	"ModifyInstanceMetadataOptions": func() api.Request { return &api.ModifyInstanceMetadataOptionsRequest{} },
+	"GetConsoleOutput":              func() api.Request { return &api.GetConsoleOutputRequest{} },
+	"GetPasswordData":               func() api.Request { return &api.GetPasswordDataRequest{} },
+	"ModifyInstanceAttribute":       func() api.Request { return &api.ModifyInstanceAttributeRequest{} },
+	"DescribeInstanceAttribute":     func() api.Request { return &api.DescribeInstanceAttributeRequest{} },
+	"AssociateIamInstanceProfile": func() api.Request {
+		return &api.AssociateIamInstanceProfileRequest{}
+	},
+	"DisassociateIamInstanceProfile": func() api.Request {
+		return &api.DisassociateIamInstanceProfileRequest{}
+	},
+	"MonitorInstances":              func() api.Request { return &api.MonitorInstancesRequest{} },
+	"UnmonitorInstances":            func() api.Request { return &api.UnmonitorInstancesRequest{} },
+	"CreateImage":                   func() api.Request { return &api.CreateImageRequest{} },
+	"CopyImage":                     func() api.Request { return &api.CopyImageRequest{} },
+	"DescribeImages":                func() api.Request { return &api.DescribeImagesRequest{} },
 	"DescribeInstanceTypes":         func() api.Request { return &api.DescribeInstanceTypesRequest{} },
 	"DescribeInstanceTypeOfferings": func() api.Request { return &api.DescribeInstanceTypeOfferingsRequest{} },
 	"GetInstanceTypesFromInstanceRequirements": func() api.Request {
 		return &api.GetInstanceTypesFromInstanceRequirementsRequest{}
 	},
-	"CreateFleet":                 func() api.Request { return &api.CreateFleetRequest{} },
-	"CreateTags":                  func() api.Request { return &api.CreateTagsRequest{} },
-	"DeleteTags":                  func() api.Request { return &api.DeleteTagsRequest{} },
-	"CreateVolume":                func() api.Request { return &api.CreateVolumeRequest{} },
-	"DeleteVolume":                func() api.Request { return &api.DeleteVolumeRequest{} },
-	"AttachVolume":                func() api.Request { return &api.AttachVolumeRequest{} },
-	"DetachVolume":                func() api.Request { return &api.DetachVolumeRequest{} },
-	"DescribeVolumes":             func() api.Request { return &api.DescribeVolumesRequest{} },
+	"CreateFleet":     func() api.Request { return &api.CreateFleetRequest{} },
+	"CreateTags":      func() api.Request { return &api.CreateTagsRequest{} },
+	"DeleteTags":      func() api.Request { return &api.DeleteTagsRequest{} },
+	"DescribeTags":    func() api.Request { return &api.DescribeTagsRequest{} },
+	"CreateVolume":    func() api.Request { return &api.CreateVolumeRequest{} },
+	"DeleteVolume":    func() api.Request { return &api.DeleteVolumeRequest{} },
+	"AttachVolume":    func() api.Request { return &api.AttachVolumeRequest{} },
+	"DetachVolume":    func() api.Request { return &api.DetachVolumeRequest{} },
+	"DescribeVolumes": func() api.Request { return &api.DescribeVolumesRequest{} },
+	"DescribeVolumesModifications": func() api.Request {
+		return &api.DescribeVolumesModificationsRequest{}
+	},
+	"ModifyVolume":                func() api.Request { return &api.ModifyVolumeRequest{} },
+	"CreateSnapshot":              func() api.Request { return &api.CreateSnapshotRequest{} },
+	"CopySnapshot":                func() api.Request { return &api.CopySnapshotRequest{} },
+	"DeleteSnapshot":              func() api.Request { return &api.DeleteSnapshotRequest{} },
+	"DescribeSnapshots":           func() api.Request { return &api.DescribeSnapshotsRequest{} },
 	"CreateLaunchTemplate":        func() api.Request { return &api.CreateLaunchTemplateRequest{} },
 	"DescribeLaunchTemplates":     func() api.Request { return &api.DescribeLaunchTemplatesRequest{} },
 	"DeleteLaunchTemplate":        func() api.Request { return &api.DeleteLaunchTemplateRequest{} },
@@ -70,6 +121,10 @@ var requestFactories = map[string]func() api.Request{
 	"DescribeLaunchTemplateVersions": func() api.Request {
 		return &api.DescribeLaunchTemplateVersionsRequest{}
 	},
+	"CreateKeyPair":          func() api.Request { return &api.CreateKeyPairRequest{} },
+	"ImportKeyPair":          func() api.Request { return &api.ImportKeyPairRequest{} },
+	"DescribeKeyPairs":       func() api.Request { return &api.DescribeKeyPairsRequest{} },
+	"DeleteKeyPair":          func() api.Request { return &api.DeleteKeyPairRequest{} },
 	"ModifyLaunchTemplate":   func() api.Request { return &api.ModifyLaunchTemplateRequest{} },
 	"CreateOrUpdateTags":     func() api.Request { return &api.CreateOrUpdateAutoScalingTagsRequest{} },
 	"CreateAutoScalingGroup": func() api.Request { return &api.CreateAutoScalingGroupRequest{} },
@@ -80,10 +135,59 @@ var requestFactories = map[string]func() api.Request{
 	"UpdateAutoScalingGroup": func() api.Request { return &api.UpdateAutoScalingGroupRequest{} },
 	"SetDesiredCapacity":     func() api.Request { return &api.SetDesiredCapacityRequest{} },
 	"DetachInstances":        func() api.Request { return &api.DetachInstancesRequest{} },
+	"AttachInstances":        func() api.Request { return &api.AttachInstancesRequest{} },
+	"EnterStandby":           func() api.Request { return &api.EnterStandbyRequest{} },
+	"ExitStandby":            func() api.Request { return &api.ExitStandbyRequest{} },
 	"DeleteAutoScalingGroup": func() api.Request { return &api.DeleteAutoScalingGroupRequest{} },
 	"PutWarmPool":            func() api.Request { return &api.PutWarmPoolRequest{} },
 	"DescribeWarmPool":       func() api.Request { return &api.DescribeWarmPoolRequest{} },
 	"DeleteWarmPool":         func() api.Request { return &api.DeleteWarmPoolRequest{} },
+	"PutLifecycleHook":       func() api.Request { return &api.PutLifecycleHookRequest{} },
+	"DescribeLifecycleHooks": func() api.Request { return &api.DescribeLifecycleHooksRequest{} },
+	"DeleteLifecycleHook":    func() api.Request { return &api.DeleteLifecycleHookRequest{} },
+	"DescribeLifecycleHookTypes": func() api.Request {
+		return &api.DescribeLifecycleHookTypesRequest{}
+	},
+	"CompleteLifecycleAction": func() api.Request {
+		return &api.CompleteLifecycleActionRequest{}
+	},
+	"RecordLifecycleActionHeartbeat": func() api.Request {
+		return &api.RecordLifecycleActionHeartbeatRequest{}
+	},
+	"PutScheduledUpdateGroupAction": func() api.Request {
+		return &api.PutScheduledUpdateGroupActionRequest{}
+	},
+	"DescribeScheduledActions": func() api.Request {
+		return &api.DescribeScheduledActionsRequest{}
+	},
+	"SetInstanceProtection": func() api.Request {
+		return &api.SetInstanceProtectionRequest{}
+	},
+	"DescribeAutoScalingInstances": func() api.Request {
+		return &api.DescribeAutoScalingInstancesRequest{}
+	},
+	"DescribeScalingActivities": func() api.Request {
+		return &api.DescribeScalingActivitiesRequest{}
+	},
+	"PutScalingPolicy": func() api.Request {
+		return &api.PutScalingPolicyRequest{}
+	},
+	"DescribePolicies": func() api.Request {
+		return &api.DescribePoliciesRequest{}
+	},
+	"DeletePolicy": func() api.Request {
+		return &api.DeletePolicyRequest{}
+	},
+	"ExecutePolicy": func() api.Request {
+		return &api.ExecutePolicyRequest{}
+	},
+	"DeleteScheduledAction": func() api.Request {
+		return &api.DeleteScheduledActionRequest{}
+	},
+	"SetInstanceHealth": func() api.Request { return &api.SetInstanceHealthRequest{} },
+	"TerminateInstanceInAutoScalingGroup": func() api.Request {
+		return &api.TerminateInstanceInAutoScalingGroupRequest{}
+	},
 }
 
 func (f *XML) DecodeRequest(r *http.Request) (api.Request, error) {
@@ -222,10 +326,31 @@ func errorXMLProtocol(action string) responseProtocol {
 		"UpdateAutoScalingGroup",
 		"SetDesiredCapacity",
 		"DetachInstances",
+		"AttachInstances",
+		"EnterStandby",
+		"ExitStandby",
 		"DeleteAutoScalingGroup",
 		"PutWarmPool",
 		"DescribeWarmPool",
-		"DeleteWarmPool":
+		"DeleteWarmPool",
+		"DescribeAutoScalingInstances",
+		"PutLifecycleHook",
+		"DescribeLifecycleHooks",
+		"DeleteLifecycleHook",
+		"DescribeLifecycleHookTypes",
+		"CompleteLifecycleAction",
+		"RecordLifecycleActionHeartbeat",
+		"PutScheduledUpdateGroupAction",
+		"DescribeScheduledActions",
+		"DeleteScheduledAction",
+		"DescribeScalingActivities",
+		"PutScalingPolicy",
+		"DescribePolicies",
+		"DeletePolicy",
+		"ExecutePolicy",
+		"SetInstanceHealth",
+		"SetInstanceProtection",
+		"TerminateInstanceInAutoScalingGroup":
 		return responseProtocolAutoScaling
 	default:
 		return responseProtocolEC2
@@ -277,10 +402,31 @@ func responseXMLProtocol(resp api.Response) responseProtocol {
 		api.UpdateAutoScalingGroupResponse, *api.UpdateAutoScalingGroupResponse,
 		api.SetDesiredCapacityResponse, *api.SetDesiredCapacityResponse,
 		api.DetachInstancesResponse, *api.DetachInstancesResponse,
+		api.AttachInstancesResponse, *api.AttachInstancesResponse,
+		api.EnterStandbyResponse, *api.EnterStandbyResponse,
+		api.ExitStandbyResponse, *api.ExitStandbyResponse,
 		api.DeleteAutoScalingGroupResponse, *api.DeleteAutoScalingGroupResponse,
 		api.PutWarmPoolResponse, *api.PutWarmPoolResponse,
 		api.DescribeWarmPoolResponse, *api.DescribeWarmPoolResponse,
-		api.DeleteWarmPoolResponse, *api.DeleteWarmPoolResponse:
+		api.DeleteWarmPoolResponse, *api.DeleteWarmPoolResponse,
+		api.DescribeAutoScalingInstancesResponse, *api.DescribeAutoScalingInstancesResponse,
+		api.PutLifecycleHookResponse, *api.PutLifecycleHookResponse,
+		api.DescribeLifecycleHooksResponse, *api.DescribeLifecycleHooksResponse,
+		api.DeleteLifecycleHookResponse, *api.DeleteLifecycleHookResponse,
+		api.DescribeLifecycleHookTypesResponse, *api.DescribeLifecycleHookTypesResponse,
+		api.CompleteLifecycleActionResponse, *api.CompleteLifecycleActionResponse,
+		api.RecordLifecycleActionHeartbeatResponse, *api.RecordLifecycleActionHeartbeatResponse,
+		api.PutScheduledUpdateGroupActionResponse, *api.PutScheduledUpdateGroupActionResponse,
+		api.DescribeScheduledActionsResponse, *api.DescribeScheduledActionsResponse,
+		api.DeleteScheduledActionResponse, *api.DeleteScheduledActionResponse,
+		api.DescribeScalingActivitiesResponse, *api.DescribeScalingActivitiesResponse,
+		api.PutScalingPolicyResponse, *api.PutScalingPolicyResponse,
+		api.DescribePoliciesResponse, *api.DescribePoliciesResponse,
+		api.DeletePolicyResponse, *api.DeletePolicyResponse,
+		api.ExecutePolicyResponse, *api.ExecutePolicyResponse,
+		api.SetInstanceHealthResponse, *api.SetInstanceHealthResponse,
+		api.SetInstanceProtectionResponse, *api.SetInstanceProtectionResponse,
+		api.TerminateInstanceInAutoScalingGroupResponse, *api.TerminateInstanceInAutoScalingGroupResponse:
 		return responseProtocolAutoScaling
 	default:
 		return responseProtocolEC2
@@ -33,10 +33,20 @@ const (
 	imdsTokenHeader    = "X-aws-ec2-metadata-token"
 	imdsTokenTTLHeader = "X-aws-ec2-metadata-token-ttl-seconds"
 
+	imdsHTTPTokensOptional = "optional"
+	imdsHTTPTokensRequired = "required"
+
+	imdsDefaultPutResponseHopLimit = 1
+	imdsMinPutResponseHopLimit     = 1
+	imdsMaxPutResponseHopLimit     = 64
+
 	imdsTokenMinTTLSeconds = 1
 	imdsTokenMaxTTLSeconds = 21600
 	imdsTokenBytes         = 32
 
+	imdsMetadataBaseURL     = "/latest/meta-data/"
+	imdsIAMInfoURL          = "/latest/meta-data/iam/info"
+	imdsIAMCredentialsURL   = "/latest/meta-data/iam/security-credentials/"
 	imdsMetadataTagsBaseURL = "/latest/meta-data/tags/instance"
 	imdsSpotActionBaseURL   = "/latest/meta-data/spot/instance-action"
 	imdsSpotTerminationURL  = "/latest/meta-data/spot/termination-time"
@@ -44,22 +54,74 @@ const (
 
 var errIMDSInstanceNotFound = errors.New("imds instance not found")
 
+// imdsMetadataValues maps the metadata paths resolved from the instance
+// description (relative to imdsMetadataBaseURL) to their values.
+var imdsMetadataValues = map[string]func(imdsInstanceMetadata) string{
+	"hostname":                    func(m imdsInstanceMetadata) string { return m.LocalHostname },
+	"local-hostname":              func(m imdsInstanceMetadata) string { return m.LocalHostname },
+	"local-ipv4":                  func(m imdsInstanceMetadata) string { return m.LocalIPv4 },
+	"mac":                         func(m imdsInstanceMetadata) string { return m.MAC },
+	"placement/availability-zone": func(m imdsInstanceMetadata) string { return m.AvailabilityZone },
+	"placement/region":            func(m imdsInstanceMetadata) string { return m.Region },
+	"public-hostname":             func(m imdsInstanceMetadata) string { return m.PublicHostname },
+	"public-ipv4":                 func(m imdsInstanceMetadata) string { return m.PublicIPv4 },
+}
+
 type imdsToken struct {
 	containerID string
 	expiresAt   time.Time
 }
 
+// imdsMetadataOptions are the per-instance IMDS settings changed with
+// ModifyInstanceMetadataOptions.
+type imdsMetadataOptions struct {
+	// HTTPTokens is either imdsHTTPTokensRequired, rejecting requests
+	// without a session token, or imdsHTTPTokensOptional, which also
+	// allows IMDSv1 requests.
+	HTTPTokens string
+	// HTTPPutResponseHopLimit is reported, but not enforced.
+	HTTPPutResponseHopLimit int
+}
+
+var defaultIMDSMetadataOptions = imdsMetadataOptions{
+	HTTPTokens:              imdsHTTPTokensRequired,
+	HTTPPutResponseHopLimit: imdsDefaultPutResponseHopLimit,
+}
+
 type imdsSpotAction struct {
 	Action          string
 	TerminationTime time.Time
 }
 
+// imdsInstanceMetadata contains the placement and network details of an
+// instance, matching what DescribeInstances reports for it.
+type imdsInstanceMetadata struct {
+	AvailabilityZone string
+	Region           string
+	LocalIPv4        string
+	PublicIPv4       string
+	LocalHostname    string
+	PublicHostname   string
+	MAC              string
+	// InstanceProfileArn is empty when the instance has no IAM instance
+	// profile associated.
+	InstanceProfileArn string
+	InstanceProfileID  string
+}
+
+type imdsInstanceMetadataResolver func(ctx context.Context, containerID string) (imdsInstanceMetadata, error)
+
 type imdsController struct {
 	cli      *client.Client
 	server   *http.Server
 	listener net.Listener
 
+	configMu                   sync.RWMutex
+	metadataResolver           imdsInstanceMetadataResolver
+	instanceProfileCredentials InstanceProfileCredentials
+
 	disabledInstances sync.Map
+	metadataOptions   sync.Map
 	tokens            sync.Map
 	instanceTags      sync.Map
 	spotActions       sync.Map
@@ -85,6 +147,15 @@ func newIMDSController() (*imdsController, error) {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/latest/api/token", controller.handleToken)
 	mux.HandleFunc("/latest/meta-data/instance-id", controller.handleInstanceID)
+	mux.HandleFunc(imdsMetadataBaseURL+"{$}", controller.handleMetadataListing(""))
+	mux.HandleFunc(imdsMetadataBaseURL+"placement/{$}", controller.handleMetadataListing("placement/"))
+	mux.HandleFunc(imdsMetadataBaseURL+"iam/{$}", controller.handleMetadataListing("iam/"))
+	mux.HandleFunc(imdsIAMCredentialsURL+"{$}", controller.handleMetadataListing("iam/security-credentials/"))
+	mux.HandleFunc(imdsIAMInfoURL, controller.handleIAMInfo)
+	mux.HandleFunc(imdsIAMCredentialsURL+"{role}", controller.handleIAMSecurityCredentials)
+	for path, value := range imdsMetadataValues {
+		mux.HandleFunc(imdsMetadataBaseURL+path, controller.handleMetadataValue(value))
+	}
 	mux.HandleFunc("/latest/user-data", controller.handleUserData)
 	mux.HandleFunc(imdsMetadataTagsBaseURL, controller.handleInstanceTagKeys)
 	mux.HandleFunc(imdsMetadataTagsBaseURL+"/", controller.handleInstanceTagValue)
@@ -140,6 +211,28 @@ func (c *imdsController) Enabled(containerID string) bool {
 	return !disabled
 }
 
+func (c *imdsController) SetMetadataOptions(containerID string, options imdsMetadataOptions) error {
+	if options == defaultIMDSMetadataOptions {
+		c.metadataOptions.Delete(containerID)
+		return nil
+	}
+	c.metadataOptions.Store(containerID, options)
+	return nil
+}
+
+func (c *imdsController) MetadataOptions(containerID string) imdsMetadataOptions {
+	v, ok := c.metadataOptions.Load(containerID)
+	if !ok {
+		return defaultIMDSMetadataOptions
+	}
+	options, ok := v.(imdsMetadataOptions)
+	if !ok {
+		c.metadataOptions.Delete(containerID)
+		return defaultIMDSMetadataOptions
+	}
+	return options
+}
+
 func (c *imdsController) RevokeTokens(containerID string) error {
 	c.revokeTokensLocal(containerID)
 	return nil
@@ -169,6 +262,32 @@ func (c *imdsController) ClearSpotInstanceAction(containerID string) error {
 	return nil
 }
 
+// SetInstanceMetadataResolver sets the function used to look up the
+// placement and network details of an instance.
+func (c *imdsController) SetInstanceMetadataResolver(resolver imdsInstanceMetadataResolver) {
+	c.configMu.Lock()
+	defer c.configMu.Unlock()
+	c.metadataResolver = resolver
+}
+
+// SetInstanceProfileCredentials sets the credentials served to instances
+// with an IAM instance profile.
+func (c *imdsController) SetInstanceProfileCredentials(credentials InstanceProfileCredentials) {
+	c.configMu.Lock()
+	defer c.configMu.Unlock()
+	c.instanceProfileCredentials = credentials
+}
+
+func (c *imdsController) instanceMetadata(ctx context.Context, containerID string) (imdsInstanceMetadata, error) {
+	c.configMu.RLock()
+	resolver := c.metadataResolver
+	c.configMu.RUnlock()
+	if resolver == nil {
+		return imdsInstanceMetadata{}, errIMDSInstanceNotFound
+	}
+	return resolver(ctx, containerID)
+}
+
 func (c *imdsController) handleInstanceID(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -187,6 +306,162 @@ func (c *imdsController) handleInstanceID(w http.ResponseWriter, r *http.Request
 	_, _ = w.Write([]byte(apiInstanceID(executor.InstanceID(instanceRuntimeID))))
 }
 
+func (c *imdsController) handleMetadataValue(value func(imdsInstanceMetadata) string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		metadata, ok := c.resolveInstanceMetadata(w, r)
+		if !ok {
+			return
+		}
+		v := value(metadata)
+		if v == "" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte(v))
+	}
+}
+
+// handleMetadataListing returns a handler enumerating the metadata entries
+// under prefix, with subdirectories suffixed by "/" like EC2 does.
+func (c *imdsController) handleMetadataListing(prefix string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		info, ok := c.resolveMetadataRequest(w, r)
+		if !ok {
+			return
+		}
+		instanceRuntimeID, ok := imdsInstanceRuntimeID(info)
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		metadata, err := c.instanceMetadata(r.Context(), instanceRuntimeID)
+		if err != nil && !errors.Is(err, errIMDSInstanceNotFound) {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		paths := []string{"instance-id"}
+		if len(c.tags(instanceRuntimeID)) > 0 {
+			paths = append(paths, "tags/instance")
+		}
+		if _, ok := c.spotAction(instanceRuntimeID); ok {
+			paths = append(paths, "spot/instance-action", "spot/termination-time")
+		}
+		if metadata.InstanceProfileArn != "" {
+			paths = append(paths, "iam/info", "iam/security-credentials/"+instanceProfileName(metadata.InstanceProfileArn))
+		}
+		for path, value := range imdsMetadataValues {
+			if value(metadata) != "" {
+				paths = append(paths, path)
+			}
+		}
+		entries := imdsMetadataListing(prefix, paths)
+		if len(entries) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte(strings.Join(entries, "\n")))
+	}
+}
+
+// imdsMetadataListing returns the sorted, unique entries directly under
+// prefix among paths. Entries with further path components are returned
+// as directories, with a trailing "/".
+func imdsMetadataListing(prefix string, paths []string) []string {
+	seen := make(map[string]struct{}, len(paths))
+	var entries []string
+	for _, path := range paths {
+		rest, ok := strings.CutPrefix(path, prefix)
+		if !ok || rest == "" {
+			continue
+		}
+		if dir, _, nested := strings.Cut(rest, "/"); nested {
+			rest = dir + "/"
+		}
+		if _, dup := seen[rest]; dup {
+			continue
+		}
+		seen[rest] = struct{}{}
+		entries = append(entries, rest)
+	}
+	sort.Strings(entries)
+	return entries
+}
+
+func (c *imdsController) handleIAMInfo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	metadata, ok := c.resolveInstanceMetadata(w, r)
+	if !ok {
+		return
+	}
+	if metadata.InstanceProfileArn == "" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"Code":               "Success",
+		"LastUpdated":        time.Now().UTC().Format(time.RFC3339),
+		"InstanceProfileArn": metadata.InstanceProfileArn,
+		"InstanceProfileId":  metadata.InstanceProfileID,
+	})
+}
+
+func (c *imdsController) handleIAMSecurityCredentials(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	metadata, ok := c.resolveInstanceMetadata(w, r)
+	if !ok {
+		return
+	}
+	// dc2 doesn't model IAM roles, the instance profile name doubles as
+	// its role name.
+	if metadata.InstanceProfileArn == "" || r.PathValue("role") != instanceProfileName(metadata.InstanceProfileArn) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	c.configMu.RLock()
+	credentials := c.instanceProfileCredentials
+	c.configMu.RUnlock()
+
+	w.Header().Set("Content-Type", "text/plain")
+	_ = json.NewEncoder(w).Encode(imdsSecurityCredentials(credentials, time.Now()))
+}
+
+// imdsSecurityCredentials returns the credentials document served by IMDS
+// for an instance profile. Without an explicit expiration, credentials are
+// reported as expiring after defaultInstanceProfileCredentialsTTL, so SDKs
+// keep refreshing them.
+func imdsSecurityCredentials(credentials InstanceProfileCredentials, now time.Time) map[string]string {
+	expiration := credentials.Expiration
+	if expiration.IsZero() {
+		expiration = now.Add(defaultInstanceProfileCredentialsTTL)
+	}
+	return map[string]string{
+		"Code":            "Success",
+		"LastUpdated":     now.UTC().Format(time.RFC3339),
+		"Type":            "AWS-HMAC",
+		"AccessKeyId":     credentials.AccessKeyID,
+		"SecretAccessKey": credentials.SecretAccessKey,
+		"Token":           credentials.Token,
+		"Expiration":      expiration.UTC().Format(time.RFC3339),
+	}
+}
+
 func (c *imdsController) handleUserData(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -344,13 +619,46 @@ func (c *imdsController) resolveMetadataRequest(w http.ResponseWriter, r *http.R
 		w.WriteHeader(http.StatusNotFound)
 		return nil, false
 	}
-	if !c.hasValidToken(r, instanceRuntimeID) {
+	if !c.authorized(r, instanceRuntimeID) {
 		w.WriteHeader(http.StatusUnauthorized)
 		return nil, false
 	}
 	return info, true
 }
 
+// authorized reports whether a metadata request may proceed. Requests with a
+// token always need a valid one, while requests without it (IMDSv1) are only
+// accepted when the instance has HttpTokens set to optional.
+func (c *imdsController) authorized(r *http.Request, containerID string) bool {
+	if strings.TrimSpace(r.Header.Get(imdsTokenHeader)) == "" {
+		return c.MetadataOptions(containerID).HTTPTokens == imdsHTTPTokensOptional
+	}
+	return c.hasValidToken(r, containerID)
+}
+
+func (c *imdsController) resolveInstanceMetadata(w http.ResponseWriter, r *http.Request) (imdsInstanceMetadata, bool) {
+	info, ok := c.resolveMetadataRequest(w, r)
+	if !ok {
+		return imdsInstanceMetadata{}, false
+	}
+	instanceRuntimeID, ok := imdsInstanceRuntimeID(info)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return imdsInstanceMetadata{}, false
+	}
+	metadata, err := c.instanceMetadata(r.Context(), instanceRuntimeID)
+	if err != nil {
+		if errors.Is(err, errIMDSInstanceNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return imdsInstanceMetadata{}, false
+		}
+		slog.Error("resolving IMDS instance metadata", slog.String("container_id", instanceRuntimeID), slog.Any("error", err))
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return imdsInstanceMetadata{}, false
+	}
+	return metadata, true
+}
+
 func (c *imdsController) handleToken(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPut {
 		w.WriteHeader(http.StatusMethodNotAllowed)
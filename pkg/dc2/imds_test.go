@@ -1,6 +1,7 @@
 package dc2
 
 import (
+	"net/http"
 	"net/http/httptest"
 	"net/netip"
 	"testing"
@@ -208,3 +209,75 @@ func TestIMDSControllerSpotActions(t *testing.T) {
 	_, exists := c.spotActions.Load("container-b")
 	assert.False(t, exists)
 }
+
+func TestIMDSMetadataListing(t *testing.T) {
+	t.Parallel()
+
+	paths := []string{
+		"instance-id",
+		"local-ipv4",
+		"placement/availability-zone",
+		"placement/region",
+		"spot/instance-action",
+		"tags/instance",
+	}
+
+	assert.Equal(t, []string{"instance-id", "local-ipv4", "placement/", "spot/", "tags/"}, imdsMetadataListing("", paths))
+	assert.Equal(t, []string{"availability-zone", "region"}, imdsMetadataListing("placement/", paths))
+	assert.Empty(t, imdsMetadataListing("network/", paths))
+}
+
+func TestIMDSSecurityCredentials(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	credentials := InstanceProfileCredentials{
+		AccessKeyID:     "ASIAEXAMPLE",
+		SecretAccessKey: "secret",
+		Token:           "token",
+	}
+
+	doc := imdsSecurityCredentials(credentials, now)
+	assert.Equal(t, "Success", doc["Code"])
+	assert.Equal(t, "ASIAEXAMPLE", doc["AccessKeyId"])
+	assert.Equal(t, "secret", doc["SecretAccessKey"])
+	assert.Equal(t, "token", doc["Token"])
+	assert.Equal(t, "2026-01-02T09:04:05Z", doc["Expiration"])
+
+	credentials.Expiration = now.Add(time.Hour)
+	doc = imdsSecurityCredentials(credentials, now)
+	assert.Equal(t, "2026-01-02T04:04:05Z", doc["Expiration"])
+}
+
+func TestIMDSAuthorized(t *testing.T) {
+	t.Parallel()
+
+	const containerID = "container"
+	c := &imdsController{}
+	token, err := c.issueToken(containerID, 60)
+	require.NoError(t, err)
+
+	request := func(token string) *http.Request {
+		req := httptest.NewRequest("GET", "http://localhost/latest/meta-data/instance-id", nil)
+		if token != "" {
+			req.Header.Set(imdsTokenHeader, token)
+		}
+		return req
+	}
+
+	assert.False(t, c.authorized(request(""), containerID))
+	assert.False(t, c.authorized(request("invalid"), containerID))
+	assert.True(t, c.authorized(request(token), containerID))
+
+	require.NoError(t, c.SetMetadataOptions(containerID, imdsMetadataOptions{
+		HTTPTokens:              imdsHTTPTokensOptional,
+		HTTPPutResponseHopLimit: 2,
+	}))
+	assert.True(t, c.authorized(request(""), containerID))
+	assert.False(t, c.authorized(request("invalid"), containerID))
+	assert.True(t, c.authorized(request(token), containerID))
+
+	require.NoError(t, c.SetMetadataOptions(containerID, defaultIMDSMetadataOptions))
+	assert.Equal(t, defaultIMDSMetadataOptions, c.MetadataOptions(containerID))
+	assert.False(t, c.authorized(request(""), containerID))
+}
@@ -0,0 +1,870 @@
+// Package kubernetes implements an executor that runs instances as pods and
+// volumes as persistent volume claims in a Kubernetes namespace.
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/executor"
+	"github.com/fiam/dc2/pkg/dc2/idgen"
+)
+
+const (
+	instanceNamePrefix    = "dc2-instance-"
+	instanceContainerName = "instance"
+	podSuffixLength       = 5
+	nodeArchitectureLabel = "kubernetes.io/arch"
+	instancePlatform      = "linux"
+
+	kubernetesPingTimeout = 5 * time.Second
+
+	// maxConsoleOutputBytes matches the output size returned by GetConsoleOutput.
+	maxConsoleOutputBytes = 64 * 1024
+)
+
+var _ executor.Executor = (*Executor)(nil)
+
+// Executor runs every instance as a pod, recreated on each start since pods
+// can't be stopped. A ConfigMap records the launch parameters of each
+// instance, so stopped instances survive without a pod.
+type Executor struct {
+	client    kubernetes.Interface
+	namespace string
+	// owner labels the instances launched by this executor, so they're told
+	// apart from the ones of other dc2 servers sharing the namespace.
+	owner            string
+	storageClassName string
+	// disableResourceLimits skips applying instance type CPU and memory
+	// requests and limits to instance pods.
+	disableResourceLimits bool
+	// publishPort is the container port instances publish with a
+	// LoadBalancer service, or zero when publishing is disabled.
+	publishPort int
+}
+
+type ExecutorOptions struct {
+	// Client is the Kubernetes client. Nil loads the client configuration
+	// like kubectl does, from KUBECONFIG, ~/.kube/config or the in-cluster
+	// service account.
+	Client kubernetes.Interface
+	// Namespace is the namespace instances and volumes are created in.
+	// Empty uses the namespace of the loaded configuration, or default.
+	Namespace string
+	// StorageClassName is the storage class of the claims backing volumes.
+	// It must provision block volumes, and support cloning for snapshots.
+	// Empty uses the default storage class.
+	StorageClassName string
+	// DisableResourceLimits launches instances without CPU and memory
+	// requests and limits.
+	DisableResourceLimits bool
+	// PublishPort, when positive, publishes the given container TCP port of
+	// every instance with a LoadBalancer service, whose ingress IP is then
+	// reported as the instance public IP.
+	PublishPort int
+}
+
+func NewExecutor(ctx context.Context, opts ExecutorOptions) (*Executor, error) {
+	if opts.PublishPort < 0 || opts.PublishPort > math.MaxUint16 {
+		return nil, fmt.Errorf("invalid publish port %d", opts.PublishPort)
+	}
+	client := opts.Client
+	namespace := strings.TrimSpace(opts.Namespace)
+	if client == nil {
+		clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+			clientcmd.NewDefaultClientConfigLoadingRules(),
+			&clientcmd.ConfigOverrides{},
+		)
+		restConfig, err := clientConfig.ClientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("loading Kubernetes client configuration: %w", err)
+		}
+		client, err = kubernetes.NewForConfig(restConfig)
+		if err != nil {
+			return nil, fmt.Errorf("creating Kubernetes client: %w", err)
+		}
+		if namespace == "" {
+			namespace, _, err = clientConfig.Namespace()
+			if err != nil {
+				return nil, fmt.Errorf("resolving Kubernetes namespace: %w", err)
+			}
+		}
+	}
+	if namespace == "" {
+		namespace = metav1.NamespaceDefault
+	}
+	u, err := uuid.NewRandom()
+	if err != nil {
+		return nil, fmt.Errorf("generating executor owner: %w", err)
+	}
+	e := &Executor{
+		client:                client,
+		namespace:             namespace,
+		owner:                 u.String()[:8],
+		storageClassName:      strings.TrimSpace(opts.StorageClassName),
+		disableResourceLimits: opts.DisableResourceLimits,
+		publishPort:           opts.PublishPort,
+	}
+	if err := e.Ping(ctx); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Close releases the executor. Instances and volumes are left in place,
+// since they're removed by the server according to its exit resource mode.
+func (e *Executor) Close(ctx context.Context) error {
+	return e.Disconnect()
+}
+
+// Disconnect is a no-op, since Kubernetes clients hold no connection that
+// needs to be closed.
+func (e *Executor) Disconnect() error {
+	return nil
+}
+
+// Ping checks that the namespace pods can be listed.
+func (e *Executor) Ping(ctx context.Context) error {
+	pingContext, cancel := context.WithTimeout(ctx, kubernetesPingTimeout)
+	defer cancel()
+	if _, err := e.client.CoreV1().Pods(e.namespace).List(pingContext, metav1.ListOptions{Limit: 1}); err != nil {
+		return fmt.Errorf("pinging Kubernetes API server: %w", err)
+	}
+	return nil
+}
+
+func (e *Executor) ListOwnedInstances(ctx context.Context) ([]executor.InstanceID, error) {
+	records, err := e.client.CoreV1().ConfigMaps(e.namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=true,%s=%s,%s", LabelDC2Enabled, LabelDC2Owner, e.owner, LabelDC2InstanceID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing owned instances: %w", err)
+	}
+	ids := make([]executor.InstanceID, 0, len(records.Items))
+	for _, record := range records.Items {
+		ids = append(ids, executor.InstanceID(record.Labels[LabelDC2InstanceID]))
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+func (e *Executor) CreateInstances(ctx context.Context, req executor.CreateInstancesRequest) ([]executor.InstanceID, error) {
+	instanceIDs := make([]executor.InstanceID, req.Count)
+	for i := range req.Count {
+		id, err := idgen.Hex(idgen.AWSLikeHexIDLength)
+		if err != nil {
+			return nil, fmt.Errorf("generating instance id: %w", err)
+		}
+		instanceID := executor.InstanceID(id)
+		record := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   instanceName(instanceID),
+				Labels: e.instanceLabels(instanceID),
+			},
+			Data: map[string]string{
+				instanceDataImageID:      req.ImageID,
+				instanceDataInstanceType: req.InstanceType,
+				instanceDataVCPUs:        strconv.Itoa(req.VCPUs),
+				instanceDataMemoryMiB:    strconv.Itoa(req.MemoryMiB),
+				instanceDataArchitecture: req.Architecture,
+				instanceDataLaunchTime:   time.Now().UTC().Format(time.RFC3339Nano),
+				instanceDataState:        instanceRecordCreated,
+			},
+		}
+		if req.UserData != "" {
+			record.Data[instanceDataUserData] = req.UserData
+		}
+		if _, err := e.client.CoreV1().ConfigMaps(e.namespace).Create(ctx, record, metav1.CreateOptions{}); err != nil {
+			return nil, fmt.Errorf("creating record for instance %s: %w", instanceID, err)
+		}
+		if e.publishPort > 0 {
+			if err := e.createPublishService(ctx, instanceID); err != nil {
+				return nil, err
+			}
+		}
+		instanceIDs[i] = instanceID
+	}
+	return instanceIDs, nil
+}
+
+func (e *Executor) DescribeInstances(ctx context.Context, req executor.DescribeInstancesRequest) ([]executor.InstanceDescription, error) {
+	if len(req.InstanceIDs) == 0 {
+		return nil, nil
+	}
+	// List all the instance pods once instead of once per instance, which
+	// makes describing many instances significantly cheaper.
+	pods, err := e.client.CoreV1().Pods(e.namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: LabelDC2Enabled + "=true",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing instance pods: %w", err)
+	}
+	podsByInstance := make(map[executor.InstanceID][]corev1.Pod)
+	for _, pod := range pods.Items {
+		instanceID := executor.InstanceID(pod.Labels[LabelDC2InstanceID])
+		podsByInstance[instanceID] = append(podsByInstance[instanceID], pod)
+	}
+	nodeArchitectures := make(map[string]string)
+	var descriptions []executor.InstanceDescription
+	for _, id := range req.InstanceIDs {
+		record, err := e.findRecord(ctx, id)
+		if err != nil {
+			// Specifying non-existing IDs is not an error
+			var apiErr *api.Error
+			if errors.As(err, &apiErr) && apiErr.Code == api.ErrorCodeInstanceNotFound {
+				continue
+			}
+			return nil, err
+		}
+		desc, err := e.instanceDescription(ctx, record, currentPod(podsByInstance[id]), nodeArchitectures)
+		if err != nil {
+			return nil, err
+		}
+		descriptions = append(descriptions, desc)
+	}
+	return descriptions, nil
+}
+
+// StartInstances creates a pod for every instance without a live one. Pods
+// always start from a clean copy of their image, so instances lose their
+// filesystem changes across a stop, regardless of ResetInstanceIDs.
+func (e *Executor) StartInstances(ctx context.Context, req executor.StartInstancesRequest) ([]executor.InstanceStateChange, error) {
+	records, err := e.findRecords(ctx, req.InstanceIDs)
+	if err != nil {
+		return nil, err
+	}
+	changes := make([]executor.InstanceStateChange, len(records))
+	for i, record := range records {
+		instanceID := recordInstanceID(record)
+		pods, err := e.instancePods(ctx, instanceID)
+		if err != nil {
+			return nil, err
+		}
+		pod := currentPod(pods)
+		previousState := instanceState(record, pod)
+		if !isLivePod(pod) {
+			if pod != nil && pod.DeletionTimestamp == nil {
+				// The instance shut down by itself, remove its finished pod
+				if err := e.deletePod(ctx, pod.Name, nil); err != nil {
+					return nil, err
+				}
+			}
+			record, err = e.updateRecordState(ctx, record, instanceRecordStarted)
+			if err != nil {
+				return nil, err
+			}
+			pod, err = e.createPod(ctx, record)
+			if err != nil {
+				return nil, err
+			}
+		}
+		changes[i] = executor.InstanceStateChange{
+			InstanceID:    instanceID,
+			PreviousState: previousState,
+			CurrentState:  instanceState(record, pod),
+		}
+	}
+	return changes, nil
+}
+
+// StopInstances deletes the pods of the instances, keeping their records.
+func (e *Executor) StopInstances(ctx context.Context, req executor.StopInstancesRequest) ([]executor.InstanceStateChange, error) {
+	records, err := e.findRecords(ctx, req.InstanceIDs)
+	if err != nil {
+		return nil, err
+	}
+	var gracePeriod *int64
+	if req.Force {
+		gracePeriod = new(int64(0))
+	}
+	changes := make([]executor.InstanceStateChange, len(records))
+	for i, record := range records {
+		instanceID := recordInstanceID(record)
+		pods, err := e.instancePods(ctx, instanceID)
+		if err != nil {
+			return nil, err
+		}
+		previousState := instanceState(record, currentPod(pods))
+		record, err = e.updateRecordState(ctx, record, instanceRecordStopped)
+		if err != nil {
+			return nil, err
+		}
+		for _, pod := range pods {
+			if pod.DeletionTimestamp == nil {
+				if err := e.deletePod(ctx, pod.Name, gracePeriod); err != nil {
+					return nil, err
+				}
+			}
+		}
+		// Pods terminate asynchronously, so the instance might still be
+		// stopping
+		pods, err = e.instancePods(ctx, instanceID)
+		if err != nil {
+			return nil, err
+		}
+		changes[i] = executor.InstanceStateChange{
+			InstanceID:    instanceID,
+			PreviousState: previousState,
+			CurrentState:  instanceState(record, currentPod(pods)),
+		}
+	}
+	return changes, nil
+}
+
+// RebootInstances replaces the pods of the running instances, since the
+// containers of a pod can't be restarted through the Kubernetes API.
+func (e *Executor) RebootInstances(ctx context.Context, req executor.RebootInstancesRequest) error {
+	records, err := e.findRecords(ctx, req.InstanceIDs)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		if err := e.replaceLivePod(ctx, record); err != nil {
+			return fmt.Errorf("rebooting instance %s: %w", recordInstanceID(record), err)
+		}
+	}
+	return nil
+}
+
+// ModifyInstanceAttribute updates the record of a stopped instance. The new
+// attributes, including the resources of a new instance type, apply to the
+// pod created when the instance starts again.
+func (e *Executor) ModifyInstanceAttribute(ctx context.Context, req executor.ModifyInstanceAttributeRequest) error {
+	record, err := e.findRecord(ctx, req.InstanceID)
+	if err != nil {
+		return err
+	}
+	pods, err := e.instancePods(ctx, req.InstanceID)
+	if err != nil {
+		return err
+	}
+	if isLivePod(currentPod(pods)) {
+		return api.ErrWithCode(api.ErrorCodeIncorrectInstanceState, fmt.Errorf("instance %s is not stopped", req.InstanceID))
+	}
+	record = record.DeepCopy()
+	if req.InstanceType != nil {
+		record.Data[instanceDataInstanceType] = *req.InstanceType
+		record.Data[instanceDataVCPUs] = strconv.Itoa(req.VCPUs)
+		record.Data[instanceDataMemoryMiB] = strconv.Itoa(req.MemoryMiB)
+	}
+	if req.UserData != nil {
+		if *req.UserData == "" {
+			delete(record.Data, instanceDataUserData)
+		} else {
+			record.Data[instanceDataUserData] = *req.UserData
+		}
+	}
+	if _, err := e.client.CoreV1().ConfigMaps(e.namespace).Update(ctx, record, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("updating record for instance %s: %w", req.InstanceID, err)
+	}
+	return nil
+}
+
+func (e *Executor) TerminateInstances(ctx context.Context, req executor.TerminateInstancesRequest) ([]executor.InstanceStateChange, error) {
+	records, err := e.findRecords(ctx, req.InstanceIDs)
+	if err != nil {
+		return nil, err
+	}
+	var gracePeriod *int64
+	if req.Force {
+		gracePeriod = new(int64(0))
+	}
+	changes := make([]executor.InstanceStateChange, len(records))
+	for i, record := range records {
+		instanceID := recordInstanceID(record)
+		pods, err := e.instancePods(ctx, instanceID)
+		if err != nil {
+			return nil, err
+		}
+		previousState := instanceState(record, currentPod(pods))
+		for _, pod := range pods {
+			if err := e.deletePod(ctx, pod.Name, gracePeriod); err != nil {
+				return nil, err
+			}
+		}
+		if e.publishPort > 0 {
+			err := e.client.CoreV1().Services(e.namespace).Delete(ctx, instanceName(instanceID), metav1.DeleteOptions{})
+			if err != nil && !apierrors.IsNotFound(err) {
+				return nil, fmt.Errorf("removing service for instance %s: %w", instanceID, err)
+			}
+		}
+		if err := e.releaseVolumes(ctx, instanceID); err != nil {
+			return nil, err
+		}
+		if err := e.client.CoreV1().ConfigMaps(e.namespace).Delete(ctx, record.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("removing record for instance %s: %w", instanceID, err)
+		}
+		changes[i] = executor.InstanceStateChange{
+			InstanceID:    instanceID,
+			PreviousState: previousState,
+			CurrentState:  api.InstanceStateTerminated,
+		}
+	}
+	return changes, nil
+}
+
+// InstanceConsoleOutput returns the logs of the current pod of the
+// instance, keeping only the most recent maxConsoleOutputBytes like EC2
+// does. Instances without a pod have no output.
+func (e *Executor) InstanceConsoleOutput(ctx context.Context, instanceID executor.InstanceID) (executor.InstanceConsoleOutput, error) {
+	if _, err := e.findRecord(ctx, instanceID); err != nil {
+		return executor.InstanceConsoleOutput{}, err
+	}
+	pods, err := e.instancePods(ctx, instanceID)
+	if err != nil {
+		return executor.InstanceConsoleOutput{}, err
+	}
+	pod := currentPod(pods)
+	if pod == nil {
+		return executor.InstanceConsoleOutput{}, nil
+	}
+	stream, err := e.client.CoreV1().Pods(e.namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+		Container:  instanceContainerName,
+		Timestamps: true,
+	}).Stream(ctx)
+	if err != nil {
+		return executor.InstanceConsoleOutput{}, fmt.Errorf("reading console output for instance %s: %w", instanceID, err)
+	}
+	defer stream.Close()
+	logs, err := io.ReadAll(stream)
+	if err != nil {
+		return executor.InstanceConsoleOutput{}, fmt.Errorf("copying console output for instance %s: %w", instanceID, err)
+	}
+	output, timestamp := splitLogTimestamps(logs)
+	if len(output) > maxConsoleOutputBytes {
+		output = output[len(output)-maxConsoleOutputBytes:]
+	}
+	return executor.InstanceConsoleOutput{
+		Output:    output,
+		Timestamp: timestamp,
+	}, nil
+}
+
+// splitLogTimestamps strips the timestamps Kubernetes prepends to each log
+// line, returning the remaining output and the time of the last line.
+func splitLogTimestamps(logs []byte) ([]byte, time.Time) {
+	var output []byte
+	var latest time.Time
+	for line := range bytes.Lines(logs) {
+		prefix, rest, found := bytes.Cut(line, []byte{' '})
+		if !found {
+			prefix, rest = bytes.TrimRight(line, "\n"), []byte("\n")
+		}
+		timestamp, err := time.Parse(time.RFC3339Nano, string(prefix))
+		if err != nil {
+			output = append(output, line...)
+			continue
+		}
+		if timestamp.After(latest) {
+			latest = timestamp
+		}
+		output = append(output, rest...)
+	}
+	return output, latest
+}
+
+// CreateImage is not supported, since Kubernetes can't commit the
+// filesystem of a container as an image.
+func (e *Executor) CreateImage(ctx context.Context, req executor.CreateImageRequest) error {
+	return api.ErrWithCode("UnsupportedOperation", errors.New("creating images is not supported by the Kubernetes executor"))
+}
+
+// DeleteImage is a no-op, since images can't be created.
+func (e *Executor) DeleteImage(ctx context.Context, req executor.DeleteImageRequest) error {
+	return nil
+}
+
+// CopyImage is not supported, since Kubernetes can't tag images.
+func (e *Executor) CopyImage(ctx context.Context, req executor.CopyImageRequest) error {
+	return api.ErrWithCode("UnsupportedOperation", errors.New("copying images is not supported by the Kubernetes executor"))
+}
+
+// ListImages returns no images, since the images available to the cluster
+// nodes can't be listed through the Kubernetes API.
+func (e *Executor) ListImages(ctx context.Context) ([]executor.ImageDescription, error) {
+	return nil, nil
+}
+
+func instanceName(instanceID executor.InstanceID) string {
+	return instanceNamePrefix + string(instanceID)
+}
+
+func recordInstanceID(record *corev1.ConfigMap) executor.InstanceID {
+	return executor.InstanceID(record.Labels[LabelDC2InstanceID])
+}
+
+func (e *Executor) instanceLabels(instanceID executor.InstanceID) map[string]string {
+	return map[string]string{
+		LabelDC2Enabled:    "true",
+		LabelDC2InstanceID: string(instanceID),
+		LabelDC2Owner:      e.owner,
+	}
+}
+
+func (e *Executor) findRecord(ctx context.Context, instanceID executor.InstanceID) (*corev1.ConfigMap, error) {
+	record, err := e.client.CoreV1().ConfigMaps(e.namespace).Get(ctx, instanceName(instanceID), metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, api.ErrWithCode(api.ErrorCodeInstanceNotFound, fmt.Errorf("instance %s doesn't exist", instanceID))
+		}
+		return nil, fmt.Errorf("retrieving record for instance %s: %w", instanceID, err)
+	}
+	if record.Labels[LabelDC2Enabled] != "true" {
+		return nil, api.ErrWithCode(api.ErrorCodeInstanceNotFound, fmt.Errorf("instance %s doesn't exist", instanceID))
+	}
+	return record, nil
+}
+
+func (e *Executor) findRecords(ctx context.Context, instanceIDs []executor.InstanceID) ([]*corev1.ConfigMap, error) {
+	var records []*corev1.ConfigMap
+	// Validate all the instances first
+	for _, id := range instanceIDs {
+		record, err := e.findRecord(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func (e *Executor) updateRecordState(ctx context.Context, record *corev1.ConfigMap, state string) (*corev1.ConfigMap, error) {
+	if record.Data[instanceDataState] == state {
+		return record, nil
+	}
+	record = record.DeepCopy()
+	record.Data[instanceDataState] = state
+	updated, err := e.client.CoreV1().ConfigMaps(e.namespace).Update(ctx, record, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("updating record for instance %s: %w", recordInstanceID(record), err)
+	}
+	return updated, nil
+}
+
+func (e *Executor) instancePods(ctx context.Context, instanceID executor.InstanceID) ([]corev1.Pod, error) {
+	pods, err := e.client.CoreV1().Pods(e.namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=true,%s=%s", LabelDC2Enabled, LabelDC2InstanceID, instanceID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing pods for instance %s: %w", instanceID, err)
+	}
+	return pods.Items, nil
+}
+
+// currentPod returns the pod that determines the state of an instance: the
+// one not being deleted or, when all of them are, the newest one. It returns
+// nil when there are no pods.
+func currentPod(pods []corev1.Pod) *corev1.Pod {
+	var current *corev1.Pod
+	for i := range pods {
+		pod := &pods[i]
+		switch {
+		case current == nil:
+			current = pod
+		case (current.DeletionTimestamp == nil) != (pod.DeletionTimestamp == nil):
+			if pod.DeletionTimestamp == nil {
+				current = pod
+			}
+		case current.CreationTimestamp.Before(&pod.CreationTimestamp):
+			current = pod
+		}
+	}
+	return current
+}
+
+// isLivePod returns whether pod is running or about to, as opposed to being
+// deleted or finished.
+func isLivePod(pod *corev1.Pod) bool {
+	if pod == nil || pod.DeletionTimestamp != nil {
+		return false
+	}
+	return pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed
+}
+
+// instanceState maps the current pod of an instance to its state. Pods are
+// created with RestartPolicyNever, so a finished pod means the instance
+// shut down. Without a pod, the instance is stopped, unless it was never
+// started.
+func instanceState(record *corev1.ConfigMap, pod *corev1.Pod) api.InstanceState {
+	switch {
+	case pod == nil && record.Data[instanceDataState] == instanceRecordCreated:
+		return api.InstanceStatePending
+	case pod == nil:
+		return api.InstanceStateStopped
+	case pod.DeletionTimestamp != nil:
+		return api.InstanceStateStopping
+	}
+	switch pod.Status.Phase {
+	case corev1.PodRunning:
+		return api.InstanceStateRunning
+	case corev1.PodSucceeded, corev1.PodFailed:
+		return api.InstanceStateStopped
+	default:
+		// Pending, or Unknown while the node is unreachable
+		return api.InstanceStatePending
+	}
+}
+
+func (e *Executor) createPod(ctx context.Context, record *corev1.ConfigMap) (*corev1.Pod, error) {
+	instanceID := recordInstanceID(record)
+	pod, err := e.instancePod(ctx, record)
+	if err != nil {
+		return nil, err
+	}
+	created, err := e.client.CoreV1().Pods(e.namespace).Create(ctx, pod, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("creating pod for instance %s: %w", instanceID, err)
+	}
+	return created, nil
+}
+
+// instancePod returns the pod running the instance recorded in record, with
+// its attached volumes as block devices. Every pod gets a new name, so it
+// can be created while the previous one is still terminating.
+func (e *Executor) instancePod(ctx context.Context, record *corev1.ConfigMap) (*corev1.Pod, error) {
+	instanceID := recordInstanceID(record)
+	suffix, err := idgen.Hex(podSuffixLength)
+	if err != nil {
+		return nil, fmt.Errorf("generating pod name: %w", err)
+	}
+	container := corev1.Container{
+		Name:  instanceContainerName,
+		Image: record.Data[instanceDataImageID],
+	}
+	if !e.disableResourceLimits {
+		vcpus, _ := strconv.Atoi(record.Data[instanceDataVCPUs])
+		memoryMiB, _ := strconv.Atoi(record.Data[instanceDataMemoryMiB])
+		container.Resources = instanceResources(vcpus, memoryMiB)
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   record.Name + "-" + suffix,
+			Labels: e.instanceLabels(instanceID),
+		},
+		Spec: corev1.PodSpec{
+			Hostname:      record.Name,
+			RestartPolicy: corev1.RestartPolicyNever,
+		},
+	}
+	if arch := kubernetesArch(record.Data[instanceDataArchitecture]); arch != "" {
+		pod.Spec.NodeSelector = map[string]string{nodeArchitectureLabel: arch}
+	}
+	claims, err := e.attachedClaims(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
+	for _, claim := range claims {
+		volumeName := "volume-" + claim.Labels[LabelDC2VolumeID]
+		pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+			Name: volumeName,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: claim.Name},
+			},
+		})
+		container.VolumeDevices = append(container.VolumeDevices, corev1.VolumeDevice{
+			Name:       volumeName,
+			DevicePath: claim.Annotations[AnnotationDC2AttachedDevice],
+		})
+	}
+	pod.Spec.Containers = []corev1.Container{container}
+	return pod, nil
+}
+
+// replaceLivePod replaces the live pod of an instance, if any, with a new
+// one, applying changes to its attached volumes.
+func (e *Executor) replaceLivePod(ctx context.Context, record *corev1.ConfigMap) error {
+	pods, err := e.instancePods(ctx, recordInstanceID(record))
+	if err != nil {
+		return err
+	}
+	pod := currentPod(pods)
+	if !isLivePod(pod) {
+		return nil
+	}
+	if err := e.deletePod(ctx, pod.Name, nil); err != nil {
+		return err
+	}
+	_, err = e.createPod(ctx, record)
+	return err
+}
+
+func (e *Executor) deletePod(ctx context.Context, name string, gracePeriod *int64) error {
+	err := e.client.CoreV1().Pods(e.namespace).Delete(ctx, name, metav1.DeleteOptions{GracePeriodSeconds: gracePeriod})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("deleting pod %s: %w", name, err)
+	}
+	return nil
+}
+
+// instanceResources returns the container requests and limits for an
+// instance with the given vCPUs and memory. Zero values leave the resource
+// unlimited. Requests match the limits, so instances get the resources of
+// their instance type.
+func instanceResources(vcpus int, memoryMiB int) corev1.ResourceRequirements {
+	resources := make(corev1.ResourceList)
+	if vcpus > 0 {
+		resources[corev1.ResourceCPU] = *resource.NewQuantity(int64(vcpus), resource.DecimalSI)
+	}
+	if memoryMiB > 0 {
+		resources[corev1.ResourceMemory] = *resource.NewQuantity(int64(memoryMiB)*1024*1024, resource.BinarySI)
+	}
+	if len(resources) == 0 {
+		return corev1.ResourceRequirements{}
+	}
+	return corev1.ResourceRequirements{
+		Requests: resources,
+		Limits:   resources.DeepCopy(),
+	}
+}
+
+func (e *Executor) createPublishService(ctx context.Context, instanceID executor.InstanceID) error {
+	port := int32(e.publishPort)
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   instanceName(instanceID),
+			Labels: e.instanceLabels(instanceID),
+		},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeLoadBalancer,
+			Selector: map[string]string{
+				LabelDC2Enabled:    "true",
+				LabelDC2InstanceID: string(instanceID),
+			},
+			Ports: []corev1.ServicePort{{
+				Protocol:   corev1.ProtocolTCP,
+				Port:       port,
+				TargetPort: intstr.FromInt32(port),
+			}},
+		},
+	}
+	if _, err := e.client.CoreV1().Services(e.namespace).Create(ctx, service, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("creating service for instance %s: %w", instanceID, err)
+	}
+	return nil
+}
+
+// publishedAddress returns the ingress IP of the service publishing the
+// instance port, or an empty string when it has none yet.
+func (e *Executor) publishedAddress(ctx context.Context, instanceID executor.InstanceID) (string, error) {
+	service, err := e.client.CoreV1().Services(e.namespace).Get(ctx, instanceName(instanceID), metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("retrieving service for instance %s: %w", instanceID, err)
+	}
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		if ingress.IP != "" {
+			return ingress.IP, nil
+		}
+	}
+	return "", nil
+}
+
+// instanceDescription describes the instance recorded in record, running in
+// pod. Node architectures are cached in nodeArchitectures, since instances
+// commonly share their node.
+func (e *Executor) instanceDescription(
+	ctx context.Context,
+	record *corev1.ConfigMap,
+	pod *corev1.Pod,
+	nodeArchitectures map[string]string,
+) (executor.InstanceDescription, error) {
+	instanceID := recordInstanceID(record)
+	launchTime, err := time.Parse(time.RFC3339Nano, record.Data[instanceDataLaunchTime])
+	if err != nil {
+		return executor.InstanceDescription{}, fmt.Errorf("parsing launch time for instance %s: %w", instanceID, err)
+	}
+	state := instanceState(record, pod)
+	var privateIP string
+	if isLivePod(pod) {
+		privateIP = pod.Status.PodIP
+	}
+	// Like the Docker executor, the pod IP is reported as the public IP too,
+	// unless the instance publishes a port
+	publicIP := privateIP
+	if e.publishPort > 0 && state.Name == api.InstanceStateRunning.Name {
+		addr, err := e.publishedAddress(ctx, instanceID)
+		if err != nil {
+			return executor.InstanceDescription{}, err
+		}
+		if addr != "" {
+			publicIP = addr
+		}
+	}
+	architecture := record.Data[instanceDataArchitecture]
+	if architecture == "" && pod != nil && pod.Spec.NodeName != "" {
+		architecture = e.nodeArchitecture(ctx, pod.Spec.NodeName, nodeArchitectures)
+	}
+	return executor.InstanceDescription{
+		InstanceID:     instanceID,
+		ImageID:        record.Data[instanceDataImageID],
+		InstanceState:  state,
+		HealthStatus:   executor.InstanceHealthStatusUnknown,
+		PrivateDNSName: record.Name,
+		PrivateIP:      privateIP,
+		PublicIP:       publicIP,
+		InstanceType:   record.Data[instanceDataInstanceType],
+		Architecture:   architecture,
+		Platform:       instancePlatform,
+		LaunchTime:     launchTime,
+	}, nil
+}
+
+// nodeArchitecture returns the EC2 architecture of the given node, or an
+// empty string when it can't be determined. Reading nodes requires cluster
+// wide permissions, so failures leave the architecture unknown instead of
+// failing the description.
+func (e *Executor) nodeArchitecture(ctx context.Context, nodeName string, cache map[string]string) string {
+	if arch, found := cache[nodeName]; found {
+		return arch
+	}
+	var arch string
+	if node, err := e.client.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{}); err == nil {
+		arch = awsArchFromKubernetesArch(node.Labels[nodeArchitectureLabel])
+	}
+	cache[nodeName] = arch
+	return arch
+}
+
+func awsArchFromKubernetesArch(arch string) string {
+	switch arch {
+	case "amd64":
+		return "x86_64"
+	case "386":
+		return "i386"
+	default:
+		return arch
+	}
+}
+
+// kubernetesArch returns the node architecture for the given EC2
+// architecture, or an empty string when arch is empty.
+func kubernetesArch(arch string) string {
+	switch arch {
+	case "x86_64":
+		return "amd64"
+	case "i386":
+		return "386"
+	default:
+		return arch
+	}
+}
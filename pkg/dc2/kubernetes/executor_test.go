@@ -0,0 +1,449 @@
+package kubernetes
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/executor"
+)
+
+const testNamespace = "dc2-test"
+
+func newFakeExecutor(t *testing.T, opts ExecutorOptions) (*Executor, *fake.Clientset) {
+	t.Helper()
+
+	client := fake.NewClientset()
+	opts.Client = client
+	opts.Namespace = testNamespace
+	e, err := NewExecutor(t.Context(), opts)
+	require.NoError(t, err)
+	return e, client
+}
+
+// instancePodsOf returns the pods of the given instance, failing the test
+// when listing them fails.
+func instancePodsOf(t *testing.T, e *Executor, instanceID executor.InstanceID) []corev1.Pod {
+	t.Helper()
+
+	pods, err := e.instancePods(t.Context(), instanceID)
+	require.NoError(t, err)
+	return pods
+}
+
+// setPodRunning moves the only pod of the instance to the Running phase,
+// like the kubelet does once its containers start.
+func setPodRunning(t *testing.T, e *Executor, client *fake.Clientset, instanceID executor.InstanceID, podIP string) {
+	t.Helper()
+
+	pods := instancePodsOf(t, e, instanceID)
+	require.Len(t, pods, 1)
+	pod := pods[0]
+	pod.Status.Phase = corev1.PodRunning
+	pod.Status.PodIP = podIP
+	_, err := client.CoreV1().Pods(testNamespace).UpdateStatus(t.Context(), &pod, metav1.UpdateOptions{})
+	require.NoError(t, err)
+}
+
+func describeInstance(t *testing.T, e *Executor, instanceID executor.InstanceID) executor.InstanceDescription {
+	t.Helper()
+
+	descs, err := e.DescribeInstances(t.Context(), executor.DescribeInstancesRequest{
+		InstanceIDs: []executor.InstanceID{instanceID},
+	})
+	require.NoError(t, err)
+	require.Len(t, descs, 1)
+	return descs[0]
+}
+
+func requireErrorCode(t *testing.T, err error, code string) {
+	t.Helper()
+
+	var apiErr *api.Error
+	require.True(t, errors.As(err, &apiErr), "expected an API error, got %v", err)
+	assert.Equal(t, code, apiErr.Code)
+}
+
+func TestInstanceLifecycle(t *testing.T) {
+	t.Parallel()
+
+	e, client := newFakeExecutor(t, ExecutorOptions{})
+	ctx := t.Context()
+
+	ids, err := e.CreateInstances(ctx, executor.CreateInstancesRequest{
+		ImageID:      "nginx",
+		InstanceType: "t4g.large",
+		Count:        2,
+		UserData:     "#!/bin/sh",
+		VCPUs:        2,
+		MemoryMiB:    8192,
+	})
+	require.NoError(t, err)
+	require.Len(t, ids, 2)
+
+	owned, err := e.ListOwnedInstances(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, slices.Sorted(slices.Values(ids)), owned)
+
+	instanceID := ids[0]
+	desc := describeInstance(t, e, instanceID)
+	assert.Equal(t, api.InstanceStatePending, desc.InstanceState)
+	assert.Equal(t, "nginx", desc.ImageID)
+	assert.Equal(t, "t4g.large", desc.InstanceType)
+	assert.Equal(t, "linux", desc.Platform)
+	assert.Equal(t, instanceName(instanceID), desc.PrivateDNSName)
+	assert.Empty(t, desc.PrivateIP)
+	assert.Empty(t, instancePodsOf(t, e, instanceID))
+
+	changes, err := e.StartInstances(ctx, executor.StartInstancesRequest{InstanceIDs: []executor.InstanceID{instanceID}})
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, api.InstanceStatePending, changes[0].PreviousState)
+	assert.Equal(t, api.InstanceStatePending, changes[0].CurrentState)
+
+	pods := instancePodsOf(t, e, instanceID)
+	require.Len(t, pods, 1)
+	pod := pods[0]
+	assert.Equal(t, corev1.RestartPolicyNever, pod.Spec.RestartPolicy)
+	assert.Equal(t, instanceName(instanceID), pod.Spec.Hostname)
+	require.Len(t, pod.Spec.Containers, 1)
+	container := pod.Spec.Containers[0]
+	assert.Equal(t, "nginx", container.Image)
+	assert.True(t, resource.MustParse("2").Equal(container.Resources.Limits[corev1.ResourceCPU]))
+	assert.True(t, resource.MustParse("8Gi").Equal(container.Resources.Limits[corev1.ResourceMemory]))
+	assert.Equal(t, container.Resources.Limits, container.Resources.Requests)
+
+	setPodRunning(t, e, client, instanceID, "10.1.2.3")
+	desc = describeInstance(t, e, instanceID)
+	assert.Equal(t, api.InstanceStateRunning, desc.InstanceState)
+	assert.Equal(t, "10.1.2.3", desc.PrivateIP)
+	assert.Equal(t, "10.1.2.3", desc.PublicIP)
+
+	err = e.ModifyInstanceAttribute(ctx, executor.ModifyInstanceAttributeRequest{
+		InstanceID:   instanceID,
+		InstanceType: new("t4g.xlarge"),
+	})
+	requireErrorCode(t, err, api.ErrorCodeIncorrectInstanceState)
+
+	changes, err = e.StopInstances(ctx, executor.StopInstancesRequest{InstanceIDs: []executor.InstanceID{instanceID}})
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, api.InstanceStateRunning, changes[0].PreviousState)
+	assert.Equal(t, api.InstanceStateStopped, changes[0].CurrentState)
+	assert.Empty(t, instancePodsOf(t, e, instanceID))
+	desc = describeInstance(t, e, instanceID)
+	assert.Equal(t, api.InstanceStateStopped, desc.InstanceState)
+	assert.Empty(t, desc.PrivateIP)
+
+	err = e.ModifyInstanceAttribute(ctx, executor.ModifyInstanceAttributeRequest{
+		InstanceID: instanceID,
+		UserData:   new("#!/bin/bash"),
+	})
+	require.NoError(t, err)
+	changes, err = e.StartInstances(ctx, executor.StartInstancesRequest{InstanceIDs: []executor.InstanceID{instanceID}})
+	require.NoError(t, err)
+	assert.Equal(t, api.InstanceStateStopped, changes[0].PreviousState)
+	pods = instancePodsOf(t, e, instanceID)
+	require.Len(t, pods, 1)
+	assert.NotEqual(t, pod.Name, pods[0].Name)
+	assert.Equal(t, container.Resources, pods[0].Spec.Containers[0].Resources)
+	record, err := e.findRecord(ctx, instanceID)
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/bash", record.Data[instanceDataUserData])
+
+	changes, err = e.TerminateInstances(ctx, executor.TerminateInstancesRequest{InstanceIDs: ids})
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	assert.Equal(t, api.InstanceStatePending, changes[0].PreviousState)
+	assert.Equal(t, api.InstanceStateTerminated, changes[0].CurrentState)
+	assert.Equal(t, api.InstanceStatePending, changes[1].PreviousState)
+	assert.Empty(t, instancePodsOf(t, e, instanceID))
+
+	descs, err := e.DescribeInstances(ctx, executor.DescribeInstancesRequest{InstanceIDs: ids})
+	require.NoError(t, err)
+	assert.Empty(t, descs)
+	owned, err = e.ListOwnedInstances(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, owned)
+
+	_, err = e.StartInstances(ctx, executor.StartInstancesRequest{InstanceIDs: ids})
+	requireErrorCode(t, err, api.ErrorCodeInstanceNotFound)
+}
+
+func TestStartInstancesReplacesFinishedPod(t *testing.T) {
+	t.Parallel()
+
+	e, client := newFakeExecutor(t, ExecutorOptions{DisableResourceLimits: true})
+	ctx := t.Context()
+
+	ids, err := e.CreateInstances(ctx, executor.CreateInstancesRequest{ImageID: "nginx", Count: 1, VCPUs: 2})
+	require.NoError(t, err)
+	instanceID := ids[0]
+	_, err = e.StartInstances(ctx, executor.StartInstancesRequest{InstanceIDs: ids})
+	require.NoError(t, err)
+
+	// The instance shuts down by itself
+	pod := instancePodsOf(t, e, instanceID)[0]
+	assert.Empty(t, pod.Spec.Containers[0].Resources)
+	pod.Status.Phase = corev1.PodSucceeded
+	_, err = client.CoreV1().Pods(testNamespace).UpdateStatus(ctx, &pod, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, api.InstanceStateStopped, describeInstance(t, e, instanceID).InstanceState)
+
+	changes, err := e.StartInstances(ctx, executor.StartInstancesRequest{InstanceIDs: ids})
+	require.NoError(t, err)
+	assert.Equal(t, api.InstanceStateStopped, changes[0].PreviousState)
+	assert.Equal(t, api.InstanceStatePending, changes[0].CurrentState)
+	pods := instancePodsOf(t, e, instanceID)
+	require.Len(t, pods, 1)
+	assert.NotEqual(t, pod.Name, pods[0].Name)
+}
+
+func TestRebootInstancesReplacesPod(t *testing.T) {
+	t.Parallel()
+
+	e, client := newFakeExecutor(t, ExecutorOptions{})
+	ctx := t.Context()
+
+	ids, err := e.CreateInstances(ctx, executor.CreateInstancesRequest{ImageID: "nginx", Count: 1})
+	require.NoError(t, err)
+	instanceID := ids[0]
+	_, err = e.StartInstances(ctx, executor.StartInstancesRequest{InstanceIDs: ids})
+	require.NoError(t, err)
+	setPodRunning(t, e, client, instanceID, "10.1.2.3")
+	pod := instancePodsOf(t, e, instanceID)[0]
+
+	require.NoError(t, e.RebootInstances(ctx, executor.RebootInstancesRequest{InstanceIDs: ids}))
+	pods := instancePodsOf(t, e, instanceID)
+	require.Len(t, pods, 1)
+	assert.NotEqual(t, pod.Name, pods[0].Name)
+}
+
+func TestModifyInstanceAttributeResizesPod(t *testing.T) {
+	t.Parallel()
+
+	e, _ := newFakeExecutor(t, ExecutorOptions{})
+	ctx := t.Context()
+
+	ids, err := e.CreateInstances(ctx, executor.CreateInstancesRequest{
+		ImageID:      "nginx",
+		InstanceType: "t4g.large",
+		Count:        1,
+		VCPUs:        2,
+		MemoryMiB:    8192,
+	})
+	require.NoError(t, err)
+	instanceID := ids[0]
+
+	// Resources of the new instance type apply to the next pod
+	err = e.ModifyInstanceAttribute(ctx, executor.ModifyInstanceAttributeRequest{
+		InstanceID:   instanceID,
+		InstanceType: new("t4g.xlarge"),
+		VCPUs:        4,
+		MemoryMiB:    16384,
+	})
+	require.NoError(t, err)
+	_, err = e.StartInstances(ctx, executor.StartInstancesRequest{InstanceIDs: ids})
+	require.NoError(t, err)
+	pods := instancePodsOf(t, e, instanceID)
+	require.Len(t, pods, 1)
+	limits := pods[0].Spec.Containers[0].Resources.Limits
+	assert.True(t, resource.MustParse("4").Equal(limits[corev1.ResourceCPU]))
+	assert.True(t, resource.MustParse("16Gi").Equal(limits[corev1.ResourceMemory]))
+	assert.Equal(t, "t4g.xlarge", describeInstance(t, e, instanceID).InstanceType)
+}
+
+func TestCreateInstancesArchitecture(t *testing.T) {
+	t.Parallel()
+
+	e, _ := newFakeExecutor(t, ExecutorOptions{})
+	ctx := t.Context()
+
+	ids, err := e.CreateInstances(ctx, executor.CreateInstancesRequest{ImageID: "nginx", Count: 1, Architecture: "x86_64"})
+	require.NoError(t, err)
+	instanceID := ids[0]
+	assert.Equal(t, "x86_64", describeInstance(t, e, instanceID).Architecture)
+
+	// Pods are scheduled on nodes of the requested architecture
+	_, err = e.StartInstances(ctx, executor.StartInstancesRequest{InstanceIDs: ids})
+	require.NoError(t, err)
+	pods := instancePodsOf(t, e, instanceID)
+	require.Len(t, pods, 1)
+	assert.Equal(t, map[string]string{nodeArchitectureLabel: "amd64"}, pods[0].Spec.NodeSelector)
+}
+
+func TestDescribeInstancesNodeArchitecture(t *testing.T) {
+	t.Parallel()
+
+	e, client := newFakeExecutor(t, ExecutorOptions{})
+	ctx := t.Context()
+
+	_, err := client.CoreV1().Nodes().Create(ctx, &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "node-1",
+			Labels: map[string]string{nodeArchitectureLabel: "amd64"},
+		},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+	ids, err := e.CreateInstances(ctx, executor.CreateInstancesRequest{ImageID: "nginx", Count: 1})
+	require.NoError(t, err)
+	instanceID := ids[0]
+	assert.Empty(t, describeInstance(t, e, instanceID).Architecture)
+
+	// Instances report the architecture of the node running their pod
+	_, err = e.StartInstances(ctx, executor.StartInstancesRequest{InstanceIDs: ids})
+	require.NoError(t, err)
+	pod := instancePodsOf(t, e, instanceID)[0]
+	pod.Spec.NodeName = "node-1"
+	_, err = client.CoreV1().Pods(testNamespace).Update(ctx, &pod, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "x86_64", describeInstance(t, e, instanceID).Architecture)
+}
+
+func TestInstanceState(t *testing.T) {
+	t.Parallel()
+
+	record := func(state string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{Data: map[string]string{instanceDataState: state}}
+	}
+	pod := func(phase corev1.PodPhase, deleting bool) *corev1.Pod {
+		p := &corev1.Pod{Status: corev1.PodStatus{Phase: phase}}
+		if deleting {
+			p.DeletionTimestamp = &metav1.Time{}
+		}
+		return p
+	}
+	for _, tc := range []struct {
+		name     string
+		record   *corev1.ConfigMap
+		pod      *corev1.Pod
+		expected api.InstanceState
+	}{
+		{name: "created", record: record(instanceRecordCreated), expected: api.InstanceStatePending},
+		{name: "stopped", record: record(instanceRecordStopped), expected: api.InstanceStateStopped},
+		{name: "pod gone", record: record(instanceRecordStarted), expected: api.InstanceStateStopped},
+		{name: "pending", record: record(instanceRecordStarted), pod: pod(corev1.PodPending, false), expected: api.InstanceStatePending},
+		{name: "unknown", record: record(instanceRecordStarted), pod: pod(corev1.PodUnknown, false), expected: api.InstanceStatePending},
+		{name: "running", record: record(instanceRecordStarted), pod: pod(corev1.PodRunning, false), expected: api.InstanceStateRunning},
+		{name: "succeeded", record: record(instanceRecordStarted), pod: pod(corev1.PodSucceeded, false), expected: api.InstanceStateStopped},
+		{name: "failed", record: record(instanceRecordStarted), pod: pod(corev1.PodFailed, false), expected: api.InstanceStateStopped},
+		{name: "deleting", record: record(instanceRecordStopped), pod: pod(corev1.PodRunning, true), expected: api.InstanceStateStopping},
+	} {
+		assert.Equal(t, tc.expected, instanceState(tc.record, tc.pod), tc.name)
+	}
+}
+
+func TestCurrentPod(t *testing.T) {
+	t.Parallel()
+
+	deleting := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "deleting", DeletionTimestamp: &metav1.Time{}}}
+	live := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "live"}}
+
+	assert.Nil(t, currentPod(nil))
+	assert.Equal(t, "live", currentPod([]corev1.Pod{deleting, live}).Name)
+	assert.Equal(t, "live", currentPod([]corev1.Pod{live, deleting}).Name)
+	assert.Equal(t, "deleting", currentPod([]corev1.Pod{deleting}).Name)
+}
+
+func TestPublishPort(t *testing.T) {
+	t.Parallel()
+
+	e, client := newFakeExecutor(t, ExecutorOptions{PublishPort: 8080})
+	ctx := t.Context()
+
+	ids, err := e.CreateInstances(ctx, executor.CreateInstancesRequest{ImageID: "nginx", Count: 1})
+	require.NoError(t, err)
+	instanceID := ids[0]
+	service, err := client.CoreV1().Services(testNamespace).Get(ctx, instanceName(instanceID), metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, corev1.ServiceTypeLoadBalancer, service.Spec.Type)
+	assert.Equal(t, string(instanceID), service.Spec.Selector[LabelDC2InstanceID])
+	require.Len(t, service.Spec.Ports, 1)
+	assert.Equal(t, int32(8080), service.Spec.Ports[0].Port)
+	assert.Equal(t, int32(8080), service.Spec.Ports[0].TargetPort.IntVal)
+
+	_, err = e.StartInstances(ctx, executor.StartInstancesRequest{InstanceIDs: ids})
+	require.NoError(t, err)
+	setPodRunning(t, e, client, instanceID, "10.1.2.3")
+	// Without an ingress, the pod IP is reported as the public IP
+	assert.Equal(t, "10.1.2.3", describeInstance(t, e, instanceID).PublicIP)
+
+	service.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "192.0.2.10"}}
+	_, err = client.CoreV1().Services(testNamespace).UpdateStatus(ctx, service, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	desc := describeInstance(t, e, instanceID)
+	assert.Equal(t, "10.1.2.3", desc.PrivateIP)
+	assert.Equal(t, "192.0.2.10", desc.PublicIP)
+
+	_, err = e.TerminateInstances(ctx, executor.TerminateInstancesRequest{InstanceIDs: ids})
+	require.NoError(t, err)
+	services, err := client.CoreV1().Services(testNamespace).List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, services.Items)
+}
+
+func TestNewExecutorRejectsInvalidPublishPort(t *testing.T) {
+	t.Parallel()
+
+	for _, port := range []int{-1, 65536} {
+		_, err := NewExecutor(t.Context(), ExecutorOptions{Client: fake.NewClientset(), PublishPort: port})
+		assert.Error(t, err, port)
+	}
+}
+
+func TestInstanceConsoleOutput(t *testing.T) {
+	t.Parallel()
+
+	e, _ := newFakeExecutor(t, ExecutorOptions{})
+	ctx := t.Context()
+
+	ids, err := e.CreateInstances(ctx, executor.CreateInstancesRequest{ImageID: "nginx", Count: 1})
+	require.NoError(t, err)
+	output, err := e.InstanceConsoleOutput(ctx, ids[0])
+	require.NoError(t, err)
+	assert.Empty(t, output.Output)
+
+	_, err = e.StartInstances(ctx, executor.StartInstancesRequest{InstanceIDs: ids})
+	require.NoError(t, err)
+	// The fake clientset always returns the same logs
+	output, err = e.InstanceConsoleOutput(ctx, ids[0])
+	require.NoError(t, err)
+	assert.Equal(t, "fake logs", string(output.Output))
+
+	_, err = e.InstanceConsoleOutput(ctx, "0123456789abcdef0")
+	requireErrorCode(t, err, api.ErrorCodeInstanceNotFound)
+}
+
+func TestSplitLogTimestamps(t *testing.T) {
+	t.Parallel()
+
+	output, timestamp := splitLogTimestamps([]byte(
+		"2026-01-02T03:04:05.000000001Z booting\n" +
+			"2026-01-02T03:04:06Z ready\n",
+	))
+	assert.Equal(t, "booting\nready\n", string(output))
+	assert.Equal(t, "2026-01-02T03:04:06Z", timestamp.Format("2006-01-02T15:04:05Z07:00"))
+}
+
+func TestImagesUnsupported(t *testing.T) {
+	t.Parallel()
+
+	e, _ := newFakeExecutor(t, ExecutorOptions{})
+	ctx := t.Context()
+
+	err := e.CreateImage(ctx, executor.CreateImageRequest{InstanceID: "0123456789abcdef0", ImageID: "ami-0123"})
+	requireErrorCode(t, err, "UnsupportedOperation")
+	err = e.CopyImage(ctx, executor.CopyImageRequest{SourceImageID: "nginx", ImageID: "ami-0123"})
+	requireErrorCode(t, err, "UnsupportedOperation")
+	images, err := e.ListImages(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, images)
+}
@@ -0,0 +1,38 @@
+package kubernetes
+
+// Kubernetes doesn't allow colons in label keys, so the keys mirror the
+// Docker executor labels with a dc2/ prefix instead.
+const (
+	LabelDC2Enabled          = "dc2/enabled"
+	LabelDC2InstanceID       = "dc2/instance-id"
+	LabelDC2VolumeID         = "dc2/volume-id"
+	LabelDC2SnapshotID       = "dc2/snapshot-id"
+	LabelDC2Owner            = "dc2/owner"
+	LabelDC2AttachedInstance = "dc2/attached-instance"
+)
+
+// Annotations hold the values that aren't valid label values, like devices.
+const (
+	AnnotationDC2AttachedDevice = "dc2/attached-device"
+	AnnotationDC2AttachTime     = "dc2/attach-time"
+)
+
+// Keys of the ConfigMap recording an instance.
+const (
+	instanceDataImageID      = "image-id"
+	instanceDataInstanceType = "instance-type"
+	instanceDataUserData     = "user-data"
+	instanceDataVCPUs        = "vcpus"
+	instanceDataMemoryMiB    = "memory-mib"
+	instanceDataArchitecture = "architecture"
+	instanceDataLaunchTime   = "launch-time"
+	instanceDataState        = "state"
+)
+
+// Values of instanceDataState, which is the state requested for the
+// instance, rather than the state of its pod.
+const (
+	instanceRecordCreated = "created"
+	instanceRecordStarted = "started"
+	instanceRecordStopped = "stopped"
+)
@@ -0,0 +1,320 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/executor"
+	"github.com/fiam/dc2/pkg/dc2/idgen"
+)
+
+const (
+	volumeNamePrefix   = "dc2-volume-"
+	snapshotNamePrefix = "dc2-snapshot-"
+	claimKind          = "PersistentVolumeClaim"
+)
+
+func volumeName(volumeID executor.VolumeID) string {
+	return volumeNamePrefix + string(volumeID)
+}
+
+func snapshotName(snapshotID executor.SnapshotID) string {
+	return snapshotNamePrefix + string(snapshotID)
+}
+
+// CreateVolume creates a block claim of the requested size. Volumes created
+// from a snapshot clone the claim backing it.
+func (e *Executor) CreateVolume(ctx context.Context, req executor.CreateVolumeRequest) (executor.VolumeID, error) {
+	id, err := idgen.Hex(idgen.AWSLikeHexIDLength)
+	if err != nil {
+		return "", fmt.Errorf("generating volume id: %w", err)
+	}
+	volumeID := executor.VolumeID(id)
+	var source string
+	if req.SnapshotID != "" {
+		source = snapshotName(req.SnapshotID)
+	}
+	claim := e.blockClaim(volumeName(volumeID), LabelDC2VolumeID, id, req.Size, source)
+	if _, err := e.client.CoreV1().PersistentVolumeClaims(e.namespace).Create(ctx, claim, metav1.CreateOptions{}); err != nil {
+		return "", fmt.Errorf("creating claim for volume %s: %w", volumeID, err)
+	}
+	return volumeID, nil
+}
+
+func (e *Executor) DeleteVolume(ctx context.Context, req executor.DeleteVolumeRequest) error {
+	if err := e.client.CoreV1().PersistentVolumeClaims(e.namespace).Delete(ctx, volumeName(req.VolumeID), metav1.DeleteOptions{}); err != nil {
+		return fmt.Errorf("deleting claim for volume %s: %w", req.VolumeID, err)
+	}
+	return nil
+}
+
+func (e *Executor) DescribeVolumes(ctx context.Context, req executor.DescribeVolumesRequest) ([]executor.VolumeDescription, error) {
+	descs := make([]executor.VolumeDescription, len(req.VolumeIDs))
+	for i, id := range req.VolumeIDs {
+		claim, err := e.findClaim(ctx, volumeName(id))
+		if err != nil {
+			return nil, err
+		}
+		attachment, err := claimAttachment(claim)
+		if err != nil {
+			return nil, err
+		}
+		var attachments []executor.VolumeAttachment
+		if attachment != nil {
+			attachments = append(attachments, *attachment)
+		}
+		descs[i] = executor.VolumeDescription{
+			VolumeID:    id,
+			Size:        claimSize(claim),
+			Attachments: attachments,
+		}
+	}
+	return descs, nil
+}
+
+// AttachVolume records the attachment in the claim backing the volume and,
+// when the instance is running, replaces its pod with one exposing the
+// volume as a block device, since the volumes of a pod can't change.
+func (e *Executor) AttachVolume(ctx context.Context, req executor.AttachVolumeRequest) (*executor.VolumeAttachment, error) {
+	record, err := e.findRecord(ctx, req.InstanceID)
+	if err != nil {
+		return nil, err
+	}
+	claim, err := e.findClaim(ctx, volumeName(req.VolumeID))
+	if err != nil {
+		return nil, err
+	}
+	attachment, err := claimAttachment(claim)
+	if err != nil {
+		return nil, err
+	}
+	if attachment != nil {
+		if attachment.InstanceID == req.InstanceID && attachment.Device == req.Device {
+			return attachment, nil
+		}
+		return nil, api.ErrWithCode(api.ErrorCodeVolumeInUse, fmt.Errorf("volume %s is attached to instance %s", req.VolumeID, attachment.InstanceID))
+	}
+	attachTime := time.Now()
+	claim = claim.DeepCopy()
+	if claim.Annotations == nil {
+		claim.Annotations = make(map[string]string)
+	}
+	claim.Labels[LabelDC2AttachedInstance] = string(req.InstanceID)
+	claim.Annotations[AnnotationDC2AttachedDevice] = req.Device
+	claim.Annotations[AnnotationDC2AttachTime] = attachTime.UTC().Format(time.RFC3339Nano)
+	if _, err := e.client.CoreV1().PersistentVolumeClaims(e.namespace).Update(ctx, claim, metav1.UpdateOptions{}); err != nil {
+		return nil, fmt.Errorf("recording attachment: %w", err)
+	}
+	if err := e.replaceLivePod(ctx, record); err != nil {
+		return nil, fmt.Errorf("attaching volume %s to instance %s: %w", req.VolumeID, req.InstanceID, err)
+	}
+	return &executor.VolumeAttachment{
+		Device:     req.Device,
+		InstanceID: req.InstanceID,
+		AttachTime: attachTime,
+	}, nil
+}
+
+// DetachVolume removes the attachment from the claim backing the volume
+// and, when the instance is running, replaces its pod with one without it.
+func (e *Executor) DetachVolume(ctx context.Context, req executor.DetachVolumeRequest) (*executor.VolumeAttachment, error) {
+	record, err := e.findRecord(ctx, req.InstanceID)
+	if err != nil {
+		return nil, err
+	}
+	claim, err := e.findClaim(ctx, volumeName(req.VolumeID))
+	if err != nil {
+		return nil, err
+	}
+	attachment, err := claimAttachment(claim)
+	if err != nil {
+		return nil, err
+	}
+	if attachment == nil || attachment.InstanceID != req.InstanceID || attachment.Device != req.Device {
+		return nil, fmt.Errorf("volume %s not attached to instance %s on device %s", req.VolumeID, req.InstanceID, req.Device)
+	}
+	if err := e.releaseClaim(ctx, claim); err != nil {
+		return nil, err
+	}
+	if err := e.replaceLivePod(ctx, record); err != nil {
+		return nil, fmt.Errorf("detaching volume %s from instance %s: %w", req.VolumeID, req.InstanceID, err)
+	}
+	return attachment, nil
+}
+
+// ResizeVolume grows the storage requested by the claim backing the
+// volume. The storage class must allow volume expansion.
+func (e *Executor) ResizeVolume(ctx context.Context, req executor.ResizeVolumeRequest) error {
+	claim, err := e.findClaim(ctx, volumeName(req.VolumeID))
+	if err != nil {
+		return err
+	}
+	claim = claim.DeepCopy()
+	claim.Spec.Resources.Requests[corev1.ResourceStorage] = *resource.NewQuantity(req.Size, resource.BinarySI)
+	if _, err := e.client.CoreV1().PersistentVolumeClaims(e.namespace).Update(ctx, claim, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("resizing claim for volume %s: %w", req.VolumeID, err)
+	}
+	return nil
+}
+
+// CreateSnapshot clones the claim backing the volume, so later writes to the
+// volume don't change the snapshot.
+func (e *Executor) CreateSnapshot(ctx context.Context, req executor.CreateSnapshotRequest) (executor.SnapshotID, error) {
+	return e.cloneSnapshot(ctx, volumeName(req.VolumeID))
+}
+
+func (e *Executor) DeleteSnapshot(ctx context.Context, req executor.DeleteSnapshotRequest) error {
+	if err := e.client.CoreV1().PersistentVolumeClaims(e.namespace).Delete(ctx, snapshotName(req.SnapshotID), metav1.DeleteOptions{}); err != nil {
+		return fmt.Errorf("deleting claim for snapshot %s: %w", req.SnapshotID, err)
+	}
+	return nil
+}
+
+// CopySnapshot clones the claim backing the snapshot into a new snapshot.
+func (e *Executor) CopySnapshot(ctx context.Context, req executor.CopySnapshotRequest) (executor.SnapshotID, error) {
+	return e.cloneSnapshot(ctx, snapshotName(req.SnapshotID))
+}
+
+func (e *Executor) DescribeSnapshots(ctx context.Context, req executor.DescribeSnapshotsRequest) ([]executor.SnapshotDescription, error) {
+	var descs []executor.SnapshotDescription
+	for _, id := range req.SnapshotIDs {
+		_, err := e.client.CoreV1().PersistentVolumeClaims(e.namespace).Get(ctx, snapshotName(id), metav1.GetOptions{})
+		if err != nil {
+			// Specifying non-existing IDs is not an error
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("retrieving claim for snapshot %s: %w", id, err)
+		}
+		descs = append(descs, executor.SnapshotDescription{SnapshotID: id})
+	}
+	return descs, nil
+}
+
+// cloneSnapshot creates a snapshot from a clone of the given claim, which
+// backs either a volume or another snapshot.
+func (e *Executor) cloneSnapshot(ctx context.Context, sourceName string) (executor.SnapshotID, error) {
+	source, err := e.findClaim(ctx, sourceName)
+	if err != nil {
+		return "", err
+	}
+	id, err := idgen.Hex(idgen.AWSLikeHexIDLength)
+	if err != nil {
+		return "", fmt.Errorf("generating snapshot id: %w", err)
+	}
+	snapshotID := executor.SnapshotID(id)
+	claim := e.blockClaim(snapshotName(snapshotID), LabelDC2SnapshotID, id, claimSize(source), sourceName)
+	if _, err := e.client.CoreV1().PersistentVolumeClaims(e.namespace).Create(ctx, claim, metav1.CreateOptions{}); err != nil {
+		return "", fmt.Errorf("creating claim for snapshot %s: %w", snapshotID, err)
+	}
+	return snapshotID, nil
+}
+
+// blockClaim returns a claim for a block volume of the given size, labeled
+// with idLabel. When source is not empty, the claim clones it.
+func (e *Executor) blockClaim(name string, idLabel string, id string, size int64, source string) *corev1.PersistentVolumeClaim {
+	claim := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				LabelDC2Enabled: "true",
+				LabelDC2Owner:   e.owner,
+				idLabel:         id,
+			},
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			VolumeMode:  new(corev1.PersistentVolumeBlock),
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: *resource.NewQuantity(size, resource.BinarySI),
+				},
+			},
+		},
+	}
+	if e.storageClassName != "" {
+		claim.Spec.StorageClassName = new(e.storageClassName)
+	}
+	if source != "" {
+		claim.Spec.DataSource = &corev1.TypedLocalObjectReference{Kind: claimKind, Name: source}
+	}
+	return claim
+}
+
+func (e *Executor) findClaim(ctx context.Context, name string) (*corev1.PersistentVolumeClaim, error) {
+	claim, err := e.client.CoreV1().PersistentVolumeClaims(e.namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("retrieving claim %s: %w", name, err)
+	}
+	return claim, nil
+}
+
+// attachedClaims returns the claims of the volumes attached to the given
+// instance.
+func (e *Executor) attachedClaims(ctx context.Context, instanceID executor.InstanceID) ([]corev1.PersistentVolumeClaim, error) {
+	claims, err := e.client.CoreV1().PersistentVolumeClaims(e.namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=true,%s=%s", LabelDC2Enabled, LabelDC2AttachedInstance, instanceID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing volumes attached to instance %s: %w", instanceID, err)
+	}
+	return claims.Items, nil
+}
+
+// releaseVolumes removes the attachments of the volumes attached to the
+// given instance, like terminating an EC2 instance detaches its volumes.
+func (e *Executor) releaseVolumes(ctx context.Context, instanceID executor.InstanceID) error {
+	claims, err := e.attachedClaims(ctx, instanceID)
+	if err != nil {
+		return err
+	}
+	for i := range claims {
+		if err := e.releaseClaim(ctx, &claims[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Executor) releaseClaim(ctx context.Context, claim *corev1.PersistentVolumeClaim) error {
+	claim = claim.DeepCopy()
+	delete(claim.Labels, LabelDC2AttachedInstance)
+	delete(claim.Annotations, AnnotationDC2AttachedDevice)
+	delete(claim.Annotations, AnnotationDC2AttachTime)
+	_, err := e.client.CoreV1().PersistentVolumeClaims(e.namespace).Update(ctx, claim, metav1.UpdateOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("deleting attachment info: %w", err)
+	}
+	return nil
+}
+
+// claimAttachment returns the attachment recorded in claim, or nil when the
+// volume is not attached.
+func claimAttachment(claim *corev1.PersistentVolumeClaim) (*executor.VolumeAttachment, error) {
+	instanceID := claim.Labels[LabelDC2AttachedInstance]
+	if instanceID == "" {
+		return nil, nil
+	}
+	attachTime, err := time.Parse(time.RFC3339Nano, claim.Annotations[AnnotationDC2AttachTime])
+	if err != nil {
+		return nil, fmt.Errorf("parsing volume attach time: %w", err)
+	}
+	return &executor.VolumeAttachment{
+		Device:     claim.Annotations[AnnotationDC2AttachedDevice],
+		InstanceID: executor.InstanceID(instanceID),
+		AttachTime: attachTime,
+	}, nil
+}
+
+// claimSize returns the storage requested by the claim in bytes.
+func claimSize(claim *corev1.PersistentVolumeClaim) int64 {
+	size := claim.Spec.Resources.Requests[corev1.ResourceStorage]
+	return size.Value()
+}
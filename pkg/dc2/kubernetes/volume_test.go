@@ -0,0 +1,189 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/executor"
+)
+
+const testVolumeSize = 1 << 30
+
+func TestVolumeAttachment(t *testing.T) {
+	t.Parallel()
+
+	e, client := newFakeExecutor(t, ExecutorOptions{StorageClassName: "block"})
+	ctx := t.Context()
+
+	volumeID, err := e.CreateVolume(ctx, executor.CreateVolumeRequest{Size: testVolumeSize})
+	require.NoError(t, err)
+	claim, err := client.CoreV1().PersistentVolumeClaims(testNamespace).Get(ctx, volumeName(volumeID), metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, corev1.PersistentVolumeBlock, *claim.Spec.VolumeMode)
+	assert.Equal(t, "block", *claim.Spec.StorageClassName)
+	assert.Nil(t, claim.Spec.DataSource)
+
+	ids, err := e.CreateInstances(ctx, executor.CreateInstancesRequest{ImageID: "nginx", Count: 2})
+	require.NoError(t, err)
+	instanceID := ids[0]
+	_, err = e.StartInstances(ctx, executor.StartInstancesRequest{InstanceIDs: ids[:1]})
+	require.NoError(t, err)
+	setPodRunning(t, e, client, instanceID, "10.1.2.3")
+	pod := instancePodsOf(t, e, instanceID)[0]
+
+	attachment, err := e.AttachVolume(ctx, executor.AttachVolumeRequest{
+		Device:     "/dev/sdf",
+		VolumeID:   volumeID,
+		InstanceID: instanceID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, instanceID, attachment.InstanceID)
+	assert.Equal(t, "/dev/sdf", attachment.Device)
+
+	// The running pod is replaced by one with the volume as a block device
+	pods := instancePodsOf(t, e, instanceID)
+	require.Len(t, pods, 1)
+	assert.NotEqual(t, pod.Name, pods[0].Name)
+	require.Len(t, pods[0].Spec.Volumes, 1)
+	assert.Equal(t, volumeName(volumeID), pods[0].Spec.Volumes[0].PersistentVolumeClaim.ClaimName)
+	assert.Equal(t, []corev1.VolumeDevice{
+		{Name: pods[0].Spec.Volumes[0].Name, DevicePath: "/dev/sdf"},
+	}, pods[0].Spec.Containers[0].VolumeDevices)
+
+	// Attaching it again is a no-op, attaching it elsewhere fails
+	again, err := e.AttachVolume(ctx, executor.AttachVolumeRequest{Device: "/dev/sdf", VolumeID: volumeID, InstanceID: instanceID})
+	require.NoError(t, err)
+	assert.True(t, attachment.AttachTime.Equal(again.AttachTime))
+	_, err = e.AttachVolume(ctx, executor.AttachVolumeRequest{Device: "/dev/sdf", VolumeID: volumeID, InstanceID: ids[1]})
+	requireErrorCode(t, err, api.ErrorCodeVolumeInUse)
+
+	descs, err := e.DescribeVolumes(ctx, executor.DescribeVolumesRequest{VolumeIDs: []executor.VolumeID{volumeID}})
+	require.NoError(t, err)
+	require.Len(t, descs, 1)
+	assert.Equal(t, int64(testVolumeSize), descs[0].Size)
+	require.Len(t, descs[0].Attachments, 1)
+	assert.Equal(t, instanceID, descs[0].Attachments[0].InstanceID)
+	assert.Equal(t, "/dev/sdf", descs[0].Attachments[0].Device)
+	assert.True(t, attachment.AttachTime.Equal(descs[0].Attachments[0].AttachTime))
+
+	require.NoError(t, e.ResizeVolume(ctx, executor.ResizeVolumeRequest{VolumeID: volumeID, Size: 2 * testVolumeSize}))
+	descs, err = e.DescribeVolumes(ctx, executor.DescribeVolumesRequest{VolumeIDs: []executor.VolumeID{volumeID}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2*testVolumeSize), descs[0].Size)
+
+	_, err = e.DetachVolume(ctx, executor.DetachVolumeRequest{Device: "/dev/sdg", VolumeID: volumeID, InstanceID: instanceID})
+	require.Error(t, err)
+	detached, err := e.DetachVolume(ctx, executor.DetachVolumeRequest{Device: "/dev/sdf", VolumeID: volumeID, InstanceID: instanceID})
+	require.NoError(t, err)
+	assert.Equal(t, instanceID, detached.InstanceID)
+	pods = instancePodsOf(t, e, instanceID)
+	require.Len(t, pods, 1)
+	assert.Empty(t, pods[0].Spec.Volumes)
+	assert.Empty(t, pods[0].Spec.Containers[0].VolumeDevices)
+
+	descs, err = e.DescribeVolumes(ctx, executor.DescribeVolumesRequest{VolumeIDs: []executor.VolumeID{volumeID}})
+	require.NoError(t, err)
+	assert.Empty(t, descs[0].Attachments)
+
+	require.NoError(t, e.DeleteVolume(ctx, executor.DeleteVolumeRequest{VolumeID: volumeID}))
+	_, err = e.DescribeVolumes(ctx, executor.DescribeVolumesRequest{VolumeIDs: []executor.VolumeID{volumeID}})
+	require.Error(t, err)
+}
+
+func TestAttachVolumeToStoppedInstance(t *testing.T) {
+	t.Parallel()
+
+	e, _ := newFakeExecutor(t, ExecutorOptions{})
+	ctx := t.Context()
+
+	volumeID, err := e.CreateVolume(ctx, executor.CreateVolumeRequest{Size: testVolumeSize})
+	require.NoError(t, err)
+	ids, err := e.CreateInstances(ctx, executor.CreateInstancesRequest{ImageID: "nginx", Count: 1})
+	require.NoError(t, err)
+	instanceID := ids[0]
+
+	_, err = e.AttachVolume(ctx, executor.AttachVolumeRequest{Device: "/dev/sdf", VolumeID: volumeID, InstanceID: instanceID})
+	require.NoError(t, err)
+	assert.Empty(t, instancePodsOf(t, e, instanceID))
+
+	// The volume is attached to the pod created on start
+	_, err = e.StartInstances(ctx, executor.StartInstancesRequest{InstanceIDs: ids})
+	require.NoError(t, err)
+	pods := instancePodsOf(t, e, instanceID)
+	require.Len(t, pods, 1)
+	require.Len(t, pods[0].Spec.Containers[0].VolumeDevices, 1)
+	assert.Equal(t, "/dev/sdf", pods[0].Spec.Containers[0].VolumeDevices[0].DevicePath)
+
+	// Terminating the instance detaches the volume
+	_, err = e.TerminateInstances(ctx, executor.TerminateInstancesRequest{InstanceIDs: ids})
+	require.NoError(t, err)
+	descs, err := e.DescribeVolumes(ctx, executor.DescribeVolumesRequest{VolumeIDs: []executor.VolumeID{volumeID}})
+	require.NoError(t, err)
+	assert.Empty(t, descs[0].Attachments)
+}
+
+func TestSnapshotsCloneClaims(t *testing.T) {
+	t.Parallel()
+
+	e, client := newFakeExecutor(t, ExecutorOptions{})
+	ctx := t.Context()
+	claims := client.CoreV1().PersistentVolumeClaims(testNamespace)
+
+	volumeID, err := e.CreateVolume(ctx, executor.CreateVolumeRequest{Size: testVolumeSize})
+	require.NoError(t, err)
+
+	snapshotID, err := e.CreateSnapshot(ctx, executor.CreateSnapshotRequest{VolumeID: volumeID})
+	require.NoError(t, err)
+	snapshot, err := claims.Get(ctx, snapshotName(snapshotID), metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, &corev1.TypedLocalObjectReference{Kind: claimKind, Name: volumeName(volumeID)}, snapshot.Spec.DataSource)
+	assert.Equal(t, int64(testVolumeSize), claimSize(snapshot))
+	assert.Nil(t, snapshot.Spec.StorageClassName)
+
+	restoredID, err := e.CreateVolume(ctx, executor.CreateVolumeRequest{Size: 2 * testVolumeSize, SnapshotID: snapshotID})
+	require.NoError(t, err)
+	restored, err := claims.Get(ctx, volumeName(restoredID), metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, snapshotName(snapshotID), restored.Spec.DataSource.Name)
+	assert.Equal(t, int64(2*testVolumeSize), claimSize(restored))
+
+	descs, err := e.DescribeSnapshots(ctx, executor.DescribeSnapshotsRequest{SnapshotIDs: []executor.SnapshotID{snapshotID, "missing"}})
+	require.NoError(t, err)
+	assert.Equal(t, []executor.SnapshotDescription{{SnapshotID: snapshotID}}, descs)
+
+	require.NoError(t, e.DeleteSnapshot(ctx, executor.DeleteSnapshotRequest{SnapshotID: snapshotID}))
+	_, err = claims.Get(ctx, snapshotName(snapshotID), metav1.GetOptions{})
+	require.Error(t, err)
+	descs, err = e.DescribeSnapshots(ctx, executor.DescribeSnapshotsRequest{SnapshotIDs: []executor.SnapshotID{snapshotID}})
+	require.NoError(t, err)
+	assert.Empty(t, descs)
+}
+
+func TestCopySnapshot(t *testing.T) {
+	t.Parallel()
+
+	e, client := newFakeExecutor(t, ExecutorOptions{})
+	ctx := t.Context()
+	claims := client.CoreV1().PersistentVolumeClaims(testNamespace)
+
+	volumeID, err := e.CreateVolume(ctx, executor.CreateVolumeRequest{Size: testVolumeSize})
+	require.NoError(t, err)
+	snapshotID, err := e.CreateSnapshot(ctx, executor.CreateSnapshotRequest{VolumeID: volumeID})
+	require.NoError(t, err)
+
+	copyID, err := e.CopySnapshot(ctx, executor.CopySnapshotRequest{SnapshotID: snapshotID})
+	require.NoError(t, err)
+	snapshotCopy, err := claims.Get(ctx, snapshotName(copyID), metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, snapshotName(snapshotID), snapshotCopy.Spec.DataSource.Name)
+	assert.Equal(t, int64(testVolumeSize), claimSize(snapshotCopy))
+
+	require.NoError(t, e.DeleteSnapshot(ctx, executor.DeleteSnapshotRequest{SnapshotID: snapshotID}))
+	_, err = e.CopySnapshot(ctx, executor.CopySnapshotRequest{SnapshotID: snapshotID})
+	require.Error(t, err)
+}
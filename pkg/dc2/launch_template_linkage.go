@@ -1,6 +1,11 @@
 package dc2
 
-import "github.com/fiam/dc2/pkg/dc2/storage"
+import (
+	"slices"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+)
 
 const (
 	launchTemplateTagKeyID      = "aws:ec2launchtemplate:id"
@@ -39,3 +44,11 @@ func ensureLaunchTemplateLinkageTags(tags map[string]string, launchTemplateID st
 	}
 	return tags
 }
+
+// instanceLaunchTemplateLinkageMatches reports whether the launch template
+// linkage tag with the given key is set to one of values on the instance.
+func instanceLaunchTemplateLinkageMatches(instance api.Instance, key string, values []string) bool {
+	return slices.ContainsFunc(instance.TagSet, func(tag api.Tag) bool {
+		return tag.Key == key && slices.Contains(values, tag.Value)
+	})
+}
@@ -0,0 +1,101 @@
+package dc2
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+// metricsInternalErrorCode labels errors without an API error code, which
+// are served as 500 responses.
+const metricsInternalErrorCode = "InternalError"
+
+// metricsResourceTypes lists the resources whose counts are exported as
+// dc2_resources.
+var metricsResourceTypes = []struct {
+	label        string
+	resourceType types.ResourceType
+}{
+	{label: "instance", resourceType: types.ResourceTypeInstance},
+	{label: "autoscaling_group", resourceType: types.ResourceTypeAutoScalingGroup},
+	{label: "volume", resourceType: types.ResourceTypeVolume},
+}
+
+// metrics is a minimal registry for the counters served at /metrics in the
+// Prometheus text exposition format, which avoids depending on a metrics
+// client library.
+type metrics struct {
+	mu                       sync.Mutex
+	actions                  map[string]uint64
+	errors                   map[string]uint64
+	warmPoolReconcileCount   uint64
+	warmPoolReconcileSeconds float64
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		actions: make(map[string]uint64),
+		errors:  make(map[string]uint64),
+	}
+}
+
+// recordAction counts a dispatched action and, when it failed, its error
+// code.
+func (m *metrics) recordAction(action string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.actions[action]++
+	if err != nil {
+		code := metricsInternalErrorCode
+		var apiErr *api.Error
+		if errors.As(err, &apiErr) && apiErr.Code != "" {
+			code = apiErr.Code
+		}
+		m.errors[code]++
+	}
+}
+
+func (m *metrics) observeWarmPoolReconcile(duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.warmPoolReconcileCount++
+	m.warmPoolReconcileSeconds += duration.Seconds()
+}
+
+// write serializes the metrics, along with the given resource counts keyed
+// by resource label.
+func (m *metrics) write(w io.Writer, resourceCounts map[string]int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var b strings.Builder
+	writeCounters(&b, "dc2_actions_total", "Dispatched API actions.", "action", m.actions)
+	writeCounters(&b, "dc2_action_errors_total", "API actions that returned an error, by error code.", "code", m.errors)
+	b.WriteString("# HELP dc2_resources Resources currently known to dc2.\n")
+	b.WriteString("# TYPE dc2_resources gauge\n")
+	for _, label := range slices.Sorted(maps.Keys(resourceCounts)) {
+		fmt.Fprintf(&b, "dc2_resources{type=%q} %d\n", label, resourceCounts[label])
+	}
+	b.WriteString("# HELP dc2_warm_pool_reconcile_duration_seconds Time spent reconciling warm pools.\n")
+	b.WriteString("# TYPE dc2_warm_pool_reconcile_duration_seconds summary\n")
+	fmt.Fprintf(&b, "dc2_warm_pool_reconcile_duration_seconds_sum %s\n", strconv.FormatFloat(m.warmPoolReconcileSeconds, 'g', -1, 64))
+	fmt.Fprintf(&b, "dc2_warm_pool_reconcile_duration_seconds_count %d\n", m.warmPoolReconcileCount)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeCounters(b *strings.Builder, name string, help string, label string, values map[string]uint64) {
+	fmt.Fprintf(b, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(b, "# TYPE %s counter\n", name)
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		fmt.Fprintf(b, "%s{%s=%q} %d\n", name, label, key, values[key])
+	}
+}
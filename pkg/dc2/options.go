@@ -5,6 +5,10 @@ import (
 	"log/slog"
 	"strings"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/fiam/dc2/pkg/dc2/executor"
 )
 
 const (
@@ -12,6 +16,9 @@ const (
 	defaultInstanceTerminationDuration = 3 * time.Second
 	defaultSpotReclaimNoticeDuration   = 2 * time.Minute
 	defaultRegion                      = "us-east-1"
+	defaultMaxInstanceIDsPerRequest    = 1000
+
+	defaultInstanceProfileCredentialsTTL = 6 * time.Hour
 )
 
 type ExitResourceMode string
@@ -32,18 +39,56 @@ func ParseExitResourceMode(raw string) (ExitResourceMode, error) {
 	}
 }
 
+// InstanceProfileCredentials are the temporary credentials served by IMDS to
+// instances with an IAM instance profile. dc2 doesn't validate them, so any
+// value works as long as the services under test accept it.
+type InstanceProfileCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	Token           string
+	// Expiration is the expiry reported for the credentials. When zero,
+	// credentials are reported as expiring 6 hours after being retrieved.
+	Expiration time.Time
+}
+
+// defaultInstanceProfileCredentials are served when no credentials are
+// configured with WithInstanceProfileCredentials.
+var defaultInstanceProfileCredentials = InstanceProfileCredentials{
+	AccessKeyID:     "ASIADC2EXAMPLEKEYID0",
+	SecretAccessKey: "dc2/EXAMPLE/SecretAccessKey/0000000000",
+	Token:           "dc2-example-session-token",
+}
+
 type options struct {
 	// InstanceShutdownDuration indicates how long an instance takes to transition from shutting-down to terminated
 	InstanceShutdownDuration time.Duration
 	// InstanceTerminationDuration indicates how long an instance stays around after being terminated
-	InstanceTerminationDuration time.Duration
-	InstanceNetwork             string
-	TestProfileInput            string
-	SpotReclaimAfter            time.Duration
-	SpotReclaimNotice           time.Duration
-	ExitResourceMode            ExitResourceMode
-	Region                      string
-	Logger                      *slog.Logger
+	InstanceTerminationDuration    time.Duration
+	InstanceNetwork                string
+	MainVolumeHostPath             string
+	MainImage                      string
+	VolumeRoot                     string
+	PublishPort                    int
+	StatePath                      string
+	TestProfileInput               string
+	SpotReclaimAfter               time.Duration
+	SpotReclaimNotice              time.Duration
+	ScaleInDrainDelay              time.Duration
+	ExitResourceMode               ExitResourceMode
+	AsyncStateTransitions          bool
+	DisableResourceLimits          bool
+	MaxInstanceIDsPerRequest       int
+	InstanceStateChangeConcurrency int
+	DefaultArchitecture            string
+	PasswordDataDelay              time.Duration
+	Region                         string
+	Seed                           *Seed
+	Logger                         *slog.Logger
+	Tracer                         trace.Tracer
+	Metrics                        bool
+	EventStream                    bool
+	Executor                       executor.Executor
+	InstanceProfileCredentials     InstanceProfileCredentials
 }
 
 func defaultOptions() options {
@@ -52,6 +97,8 @@ func defaultOptions() options {
 		InstanceTerminationDuration: defaultInstanceTerminationDuration,
 		SpotReclaimNotice:           defaultSpotReclaimNoticeDuration,
 		ExitResourceMode:            ExitResourceModeCleanup,
+		MaxInstanceIDsPerRequest:    defaultMaxInstanceIDsPerRequest,
+		InstanceProfileCredentials:  defaultInstanceProfileCredentials,
 	}
 }
 
@@ -73,12 +120,80 @@ func WithInstanceNetwork(name string) Option {
 	}
 }
 
+// WithMainVolumeHostPath stores EBS volume files in the given absolute
+// directory on the Docker host instead of a Docker volume. Large sparse volume
+// files then live on the host filesystem rather than in Docker's storage. The
+// directory must exist and be writable.
+func WithMainVolumeHostPath(path string) Option {
+	return func(opt *options) {
+		opt.MainVolumeHostPath = strings.TrimSpace(path)
+	}
+}
+
+// WithMainImage sets the image of the container dc2 uses to manage EBS
+// volume files, e.g. a mirror of the default Alpine image for environments
+// that can't pull from Docker Hub. The image must provide a shell and
+// coreutils, and it's pulled at startup.
+func WithMainImage(image string) Option {
+	return func(opt *options) {
+		opt.MainImage = strings.TrimSpace(image)
+	}
+}
+
+// WithVolumeRoot sets the absolute path EBS volume files are stored at
+// inside the main container and instances. It defaults to /dc2.
+func WithVolumeRoot(path string) Option {
+	return func(opt *options) {
+		opt.VolumeRoot = strings.TrimSpace(path)
+	}
+}
+
+// WithPublishPort publishes the given container TCP port of every instance
+// to the Docker host. Each instance gets its own loopback address (in
+// 127.0.0.0/8), which is reported as its public IP, so the port is reachable
+// at PublicIpAddress:port from the host. Addresses other than 127.0.0.1
+// require a Docker host where the whole range routes to loopback, like Linux.
+func WithPublishPort(port int) Option {
+	return func(opt *options) {
+		opt.PublishPort = port
+	}
+}
+
 func WithLogger(logger *slog.Logger) Option {
 	return func(opt *options) {
 		opt.Logger = logger
 	}
 }
 
+// WithTracer creates a span for every dispatched action, with child spans
+// around the calls to the container backend. Spans carry the action name,
+// the referenced resource IDs and, on failure, the error code. Without a
+// tracer, no spans are created.
+func WithTracer(tracer trace.Tracer) Option {
+	return func(opt *options) {
+		opt.Tracer = tracer
+	}
+}
+
+// WithMetrics serves counters for dispatched actions, API errors, resource
+// counts and warm pool reconciliation at GET /metrics, in the Prometheus text
+// exposition format.
+func WithMetrics() Option {
+	return func(opt *options) {
+		opt.Metrics = true
+	}
+}
+
+// WithEventStream streams instance state transitions, auto scaling group
+// capacity changes and warm pool changes as Server-Sent Events at GET
+// /events, so tests and tools can react to them instead of polling. Each
+// event is a JSON encoded StateChangeEvent.
+func WithEventStream() Option {
+	return func(opt *options) {
+		opt.EventStream = true
+	}
+}
+
 // WithTestProfileInput sets test profile startup input used for injected
 // delays and fault behavior in emulated actions. The input may be either a
 // filesystem path to a YAML document or an inline YAML payload.
@@ -94,6 +209,54 @@ func WithTestProfilePath(path string) Option {
 	return WithTestProfileInput(path)
 }
 
+// WithAsyncStateTransitions makes StopInstances report instances as stopping
+// and stop them in the background, like EC2 does. By default, StopInstances
+// blocks until instances are stopped and reports them as stopped. It also
+// makes DeleteAutoScalingGroup with ForceDelete return immediately, reporting
+// the group with a "Delete in progress" status until its instances are
+// terminated.
+func WithAsyncStateTransitions() Option {
+	return func(opt *options) {
+		opt.AsyncStateTransitions = true
+	}
+}
+
+// WithoutResourceLimits launches instances without CPU and memory limits.
+// By default, instance containers are limited to the vCPUs and memory of
+// their instance type, when known. Use it in environments where Docker can't
+// enforce cgroup limits.
+func WithoutResourceLimits() Option {
+	return func(opt *options) {
+		opt.DisableResourceLimits = true
+	}
+}
+
+// WithMaxInstanceIDsPerRequest sets the maximum number of instance IDs
+// accepted by a single instance API request. Zero or a negative value removes
+// the limit. Defaults to 1000, like EC2.
+func WithMaxInstanceIDsPerRequest(limit int) Option {
+	return func(opt *options) {
+		opt.MaxInstanceIDsPerRequest = limit
+	}
+}
+
+// WithInstanceStateChangeConcurrency sets how many instances are started or
+// stopped at the same time by a single StartInstances or StopInstances
+// request. Zero or a negative value uses the default of 8.
+func WithInstanceStateChangeConcurrency(concurrency int) Option {
+	return func(opt *options) {
+		opt.InstanceStateChangeConcurrency = concurrency
+	}
+}
+
+// WithSeed declares resources created at startup. Seeding errors make
+// NewServer fail. See LoadSeed for loading a seed from a file or inline YAML.
+func WithSeed(seed *Seed) Option {
+	return func(opt *options) {
+		opt.Seed = seed
+	}
+}
+
 // WithSpotReclaimAfter configures automatic simulated reclaim for spot
 // instances. Zero disables simulated reclaim.
 func WithSpotReclaimAfter(duration time.Duration) Option {
@@ -110,6 +273,70 @@ func WithSpotReclaimNotice(duration time.Duration) Option {
 	}
 }
 
+// WithScaleInDrainDelay stops instances removed from an auto scaling group by
+// scale-in and keeps them in the Terminating:Wait lifecycle state for the
+// given duration before terminating them, emulating load balancer connection
+// draining. Zero, the default, terminates them right away. Groups can
+// override it with the ScaleInDrainSeconds extension parameter.
+func WithScaleInDrainDelay(delay time.Duration) Option {
+	return func(opt *options) {
+		opt.ScaleInDrainDelay = delay
+	}
+}
+
+// WithDefaultArchitecture makes instances run the given architecture
+// (x86_64, arm64 or i386) unless RunInstances or the launch template request
+// another one with the Architecture extension parameter. Docker pulls the
+// matching image variant, which requires emulation (e.g. QEMU via binfmt) to
+// run on a host with a different architecture. By default, instances run the
+// architecture of the local image.
+func WithDefaultArchitecture(arch string) Option {
+	return func(opt *options) {
+		opt.DefaultArchitecture = strings.TrimSpace(arch)
+	}
+}
+
+// WithPasswordDataDelay makes GetPasswordData return an empty password until
+// the instance has been running for the given duration, emulating the time
+// Windows instances take to generate their administrator password. Zero, the
+// default, makes the password available as soon as the instance is running.
+func WithPasswordDataDelay(delay time.Duration) Option {
+	return func(opt *options) {
+		opt.PasswordDataDelay = delay
+	}
+}
+
+// WithStatePath persists resource state (launch templates, auto scaling
+// groups, tags, volume metadata, etc.) to a JSON file at path and reloads it
+// on startup. Instance containers still present when the server starts are
+// re-associated with their stored state, while records for instances whose
+// containers are gone are dropped. Combine it with ExitResourceModeKeep,
+// since the other modes remove resources on exit.
+func WithStatePath(path string) Option {
+	return func(opt *options) {
+		opt.StatePath = strings.TrimSpace(path)
+	}
+}
+
+// WithExecutor runs instances and volumes with the given executor instead of
+// Docker, allowing dc2 to be backed by other runtimes. The server closes the
+// executor on shutdown. Options specific to the Docker executor, like
+// WithInstanceNetwork, are ignored.
+func WithExecutor(exe executor.Executor) Option {
+	return func(opt *options) {
+		opt.Executor = exe
+	}
+}
+
+// WithInstanceProfileCredentials sets the credentials IMDS serves at
+// /latest/meta-data/iam/security-credentials/<role> to instances launched
+// with, or associated to, an IAM instance profile.
+func WithInstanceProfileCredentials(credentials InstanceProfileCredentials) Option {
+	return func(opt *options) {
+		opt.InstanceProfileCredentials = credentials
+	}
+}
+
 // WithExitResourceMode sets shutdown behavior for owned resources.
 func WithExitResourceMode(mode ExitResourceMode) Option {
 	return func(opt *options) {
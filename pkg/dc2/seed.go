@@ -0,0 +1,218 @@
+package dc2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+// Seed declares resources created when the server starts. Launch templates
+// are created first, then standalone instances and finally Auto Scaling
+// groups, so instances and groups can reference seeded launch templates by
+// name.
+type Seed struct {
+	LaunchTemplates   []SeedLaunchTemplate   `yaml:"launchTemplates"`
+	Instances         []SeedInstances        `yaml:"instances"`
+	AutoScalingGroups []SeedAutoScalingGroup `yaml:"autoScalingGroups"`
+}
+
+// SeedLaunchTemplate declares a launch template created at startup.
+type SeedLaunchTemplate struct {
+	Name         string `yaml:"name"`
+	ImageID      string `yaml:"imageId"`
+	InstanceType string `yaml:"instanceType"`
+	UserData     string `yaml:"userData"`
+}
+
+// SeedInstances declares a group of standalone instances launched at startup
+// with a single RunInstances call.
+type SeedInstances struct {
+	// Count is the number of instances to launch. Defaults to 1.
+	Count          int               `yaml:"count"`
+	ImageID        string            `yaml:"imageId"`
+	InstanceType   string            `yaml:"instanceType"`
+	LaunchTemplate string            `yaml:"launchTemplate"`
+	KeyName        string            `yaml:"keyName"`
+	UserData       string            `yaml:"userData"`
+	Tags           map[string]string `yaml:"tags"`
+}
+
+// SeedAutoScalingGroup declares an Auto Scaling group created at startup.
+type SeedAutoScalingGroup struct {
+	Name            string            `yaml:"name"`
+	LaunchTemplate  string            `yaml:"launchTemplate"`
+	MinSize         int               `yaml:"minSize"`
+	MaxSize         int               `yaml:"maxSize"`
+	DesiredCapacity *int              `yaml:"desiredCapacity"`
+	Tags            map[string]string `yaml:"tags"`
+}
+
+// LoadSeed loads a seed from either a filesystem path or an inline YAML
+// document. JSON documents are accepted too, since they are valid YAML.
+func LoadSeed(input string) (*Seed, error) {
+	trimmedInput := strings.TrimSpace(input)
+	if trimmedInput == "" {
+		return nil, fmt.Errorf("seed input is empty")
+	}
+
+	info, statErr := os.Stat(trimmedInput)
+	if statErr == nil {
+		if info.IsDir() {
+			return nil, fmt.Errorf("seed path %q is a directory", trimmedInput)
+		}
+		raw, err := os.ReadFile(trimmedInput)
+		if err != nil {
+			return nil, fmt.Errorf("reading seed %q: %w", trimmedInput, err)
+		}
+		return parseSeed(raw, fmt.Sprintf("seed %q", trimmedInput))
+	}
+
+	seed, yamlErr := parseSeed([]byte(trimmedInput), "seed")
+	if yamlErr == nil {
+		return seed, nil
+	}
+	if !errors.Is(statErr, os.ErrNotExist) {
+		return nil, fmt.Errorf("input is neither readable seed path nor valid YAML: path error: %w; yaml error: %v", statErr, yamlErr)
+	}
+	return nil, yamlErr
+}
+
+func parseSeed(raw []byte, source string) (*Seed, error) {
+	decoder := yaml.NewDecoder(strings.NewReader(string(raw)))
+	decoder.KnownFields(true)
+
+	var seed Seed
+	if err := decoder.Decode(&seed); err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", source, err)
+	}
+	if err := seed.validate(); err != nil {
+		return nil, fmt.Errorf("validating %s: %w", source, err)
+	}
+	return &seed, nil
+}
+
+func (s *Seed) validate() error {
+	for i, lt := range s.LaunchTemplates {
+		if lt.Name == "" {
+			return fmt.Errorf("launchTemplates[%d]: name is required", i)
+		}
+	}
+	for i, instances := range s.Instances {
+		if instances.Count < 0 {
+			return fmt.Errorf("instances[%d]: count must be >= 0", i)
+		}
+		if instances.LaunchTemplate == "" && (instances.ImageID == "" || instances.InstanceType == "") {
+			return fmt.Errorf("instances[%d]: imageId and instanceType are required without launchTemplate", i)
+		}
+	}
+	for i, group := range s.AutoScalingGroups {
+		if group.Name == "" {
+			return fmt.Errorf("autoScalingGroups[%d]: name is required", i)
+		}
+		if group.LaunchTemplate == "" {
+			return fmt.Errorf("autoScalingGroups[%d]: launchTemplate is required", i)
+		}
+		if group.MinSize < 0 || group.MaxSize < group.MinSize {
+			return fmt.Errorf("autoScalingGroups[%d]: sizes must satisfy 0 <= minSize <= maxSize", i)
+		}
+	}
+	return nil
+}
+
+// applySeed creates the resources declared by seed through the same
+// dispatcher paths used by API requests.
+func (d *Dispatcher) applySeed(ctx context.Context, seed *Seed) error {
+	if seed == nil {
+		return nil
+	}
+	if err := seed.validate(); err != nil {
+		return fmt.Errorf("validating seed: %w", err)
+	}
+	for i, lt := range seed.LaunchTemplates {
+		req := &api.CreateLaunchTemplateRequest{
+			LaunchTemplateName: lt.Name,
+			LaunchTemplateData: api.LaunchTemplateData{
+				ImageID:      lt.ImageID,
+				InstanceType: lt.InstanceType,
+				UserData:     lt.UserData,
+			},
+		}
+		if _, err := d.Dispatch(ctx, req); err != nil {
+			return fmt.Errorf("creating seed launch template %q (launchTemplates[%d]): %w", lt.Name, i, err)
+		}
+	}
+	for i, instances := range seed.Instances {
+		count := instances.Count
+		if count == 0 {
+			count = 1
+		}
+		req := &api.RunInstancesRequest{
+			ImageID:      instances.ImageID,
+			InstanceType: instances.InstanceType,
+			KeyName:      instances.KeyName,
+			UserData:     instances.UserData,
+			MinCount:     count,
+			MaxCount:     count,
+		}
+		if instances.LaunchTemplate != "" {
+			req.LaunchTemplate = &api.AutoScalingLaunchTemplateSpecification{
+				LaunchTemplateName: &instances.LaunchTemplate,
+			}
+		}
+		if len(instances.Tags) > 0 {
+			req.TagSpecifications = []api.TagSpecification{{
+				ResourceType: types.ResourceTypeInstance,
+				Tags:         seedTags(instances.Tags),
+			}}
+		}
+		if _, err := d.Dispatch(ctx, req); err != nil {
+			return fmt.Errorf("running seed instances (instances[%d]): %w", i, err)
+		}
+	}
+	for i, group := range seed.AutoScalingGroups {
+		req := &api.CreateAutoScalingGroupRequest{
+			AutoScalingGroupName: group.Name,
+			MinSize:              &group.MinSize,
+			MaxSize:              &group.MaxSize,
+			DesiredCapacity:      group.DesiredCapacity,
+			LaunchTemplate: &api.AutoScalingLaunchTemplateSpecification{
+				LaunchTemplateName: &group.LaunchTemplate,
+			},
+		}
+		for _, tag := range seedTags(group.Tags) {
+			propagateAtLaunch := true
+			req.Tags = append(req.Tags, api.AutoScalingTag{
+				Key:               &tag.Key,
+				Value:             &tag.Value,
+				PropagateAtLaunch: &propagateAtLaunch,
+			})
+		}
+		if _, err := d.Dispatch(ctx, req); err != nil {
+			return fmt.Errorf("creating seed auto scaling group %q (autoScalingGroups[%d]): %w", group.Name, i, err)
+		}
+	}
+	return nil
+}
+
+// seedTags returns tags sorted by key, so seeded resources are created
+// deterministically.
+func seedTags(tags map[string]string) []api.Tag {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	out := make([]api.Tag, len(keys))
+	for i, key := range keys {
+		out[i] = api.Tag{Key: key, Value: tags[key]}
+	}
+	return out
+}
@@ -0,0 +1,78 @@
+package dc2
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSeedFromYAML(t *testing.T) {
+	t.Parallel()
+
+	seed, err := LoadSeed(`
+launchTemplates:
+  - name: web
+    imageId: nginx
+    instanceType: t3.micro
+instances:
+  - count: 2
+    launchTemplate: web
+    tags:
+      Name: web
+autoScalingGroups:
+  - name: web-asg
+    launchTemplate: web
+    minSize: 1
+    maxSize: 3
+`)
+	require.NoError(t, err)
+	require.Len(t, seed.LaunchTemplates, 1)
+	assert.Equal(t, "web", seed.LaunchTemplates[0].Name)
+	require.Len(t, seed.Instances, 1)
+	assert.Equal(t, 2, seed.Instances[0].Count)
+	assert.Equal(t, map[string]string{"Name": "web"}, seed.Instances[0].Tags)
+	require.Len(t, seed.AutoScalingGroups, 1)
+	assert.Equal(t, 3, seed.AutoScalingGroups[0].MaxSize)
+	assert.Nil(t, seed.AutoScalingGroups[0].DesiredCapacity)
+}
+
+func TestLoadSeedFromPath(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "seed.json")
+	err := os.WriteFile(path, []byte(`{"instances": [{"imageId": "nginx", "instanceType": "t3.micro"}]}`), 0o600)
+	require.NoError(t, err)
+
+	seed, err := LoadSeed(path)
+	require.NoError(t, err)
+	require.Len(t, seed.Instances, 1)
+	assert.Equal(t, "nginx", seed.Instances[0].ImageID)
+}
+
+func TestLoadSeedRejectsInvalidInput(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "empty", input: "  ", want: "seed input is empty"},
+		{name: "directory", input: t.TempDir(), want: "is a directory"},
+		{name: "unknown field", input: "instance: []", want: "field instance not found"},
+		{name: "missing image", input: "instances: [{instanceType: t3.micro}]", want: "instances[0]: imageId and instanceType are required"},
+		{name: "group without template", input: "autoScalingGroups: [{name: asg, maxSize: 1}]", want: "autoScalingGroups[0]: launchTemplate is required"},
+		{name: "group sizes", input: "autoScalingGroups: [{name: asg, launchTemplate: lt, minSize: 2, maxSize: 1}]", want: "minSize <= maxSize"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := LoadSeed(tc.input)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.want)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package dc2
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -9,6 +10,7 @@ import (
 	"log/slog"
 	"net"
 	"net/http"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -17,6 +19,8 @@ import (
 	"github.com/fiam/dc2/pkg/dc2/format"
 )
 
+const requestIDHeader = "x-amzn-RequestId"
+
 type Server struct {
 	server   *http.Server
 	format   format.Format
@@ -46,15 +50,32 @@ func NewServer(addr string, opts ...Option) (*Server, error) {
 	if err != nil {
 		return nil, fmt.Errorf("initializing IMDS server: %w", err)
 	}
+	imds.SetInstanceProfileCredentials(o.InstanceProfileCredentials)
 
 	dispatcherOpts := DispatcherOptions{
-		Region:            region,
-		IMDSBackendPort:   imds.BackendPort(),
-		InstanceNetwork:   o.InstanceNetwork,
-		TestProfileInput:  o.TestProfileInput,
-		SpotReclaimAfter:  o.SpotReclaimAfter,
-		SpotReclaimNotice: o.SpotReclaimNotice,
-		ExitResourceMode:  o.ExitResourceMode,
+		Region:                         region,
+		IMDSBackendPort:                imds.BackendPort(),
+		InstanceNetwork:                o.InstanceNetwork,
+		MainVolumeHostPath:             o.MainVolumeHostPath,
+		MainImage:                      o.MainImage,
+		VolumeRoot:                     o.VolumeRoot,
+		PublishPort:                    o.PublishPort,
+		StatePath:                      o.StatePath,
+		TestProfileInput:               o.TestProfileInput,
+		SpotReclaimAfter:               o.SpotReclaimAfter,
+		SpotReclaimNotice:              o.SpotReclaimNotice,
+		ExitResourceMode:               o.ExitResourceMode,
+		AsyncStateTransitions:          o.AsyncStateTransitions,
+		DisableResourceLimits:          o.DisableResourceLimits,
+		MaxInstanceIDsPerRequest:       o.MaxInstanceIDsPerRequest,
+		InstanceStateChangeConcurrency: o.InstanceStateChangeConcurrency,
+		ScaleInDrainDelay:              o.ScaleInDrainDelay,
+		DefaultArchitecture:            o.DefaultArchitecture,
+		PasswordDataDelay:              o.PasswordDataDelay,
+		Tracer:                         o.Tracer,
+		Metrics:                        o.Metrics,
+		EventStream:                    o.EventStream,
+		Executor:                       o.Executor,
 	}
 	dispatch, err := NewDispatcher(context.Background(), dispatcherOpts, imds)
 	if err != nil {
@@ -71,9 +92,26 @@ func NewServer(addr string, opts ...Option) (*Server, error) {
 		}
 	}
 
+	// Seeded resources are part of the restored state, so seeding again
+	// would fail with duplicates.
+	if o.Seed != nil && !dispatch.restoredState {
+		seedCtx := context.Background()
+		if o.Logger != nil {
+			seedCtx = api.ContextWithLogger(seedCtx, o.Logger)
+		}
+		if err := dispatch.applySeed(seedCtx, o.Seed); err != nil {
+			err = fmt.Errorf("seeding initial resources: %w", err)
+			if closeErr := dispatch.Close(context.Background()); closeErr != nil {
+				err = errors.Join(err, fmt.Errorf("closing dispatcher: %w", closeErr))
+			}
+			_ = imds.Close(context.Background())
+			return nil, err
+		}
+	}
+
 	mux := http.NewServeMux()
 	httpServer := &http.Server{
-		Handler:     mux,
+		Handler:     withRequestID(mux),
 		Addr:        addr,
 		BaseContext: baseContext,
 	}
@@ -85,35 +123,82 @@ func NewServer(addr string, opts ...Option) (*Server, error) {
 		imds:     imds,
 		opts:     o,
 	}
+	mux.HandleFunc("/healthz", srv.serveHealth)
+	mux.HandleFunc("/readyz", srv.serveHealth)
+	if o.Metrics {
+		mux.HandleFunc("/metrics", srv.serveMetrics)
+	}
+	if o.EventStream {
+		mux.HandleFunc("/events", srv.serveEvents)
+	}
 	mux.HandleFunc("/_dc2/metadata", srv.serveMetadata)
 	mux.HandleFunc("/_dc2/test-profile", srv.serveTestProfile)
-	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/_dc2/cleanup", srv.serveCleanup)
+	mux.HandleFunc("/_dc2/spot-interruption", srv.serveSpotInterruption)
+	mux.HandleFunc("/_dc2/instance-event", srv.serveInstanceEvent)
+	mux.HandleFunc("/", srv.serveAPI)
+	return srv, nil
+}
+
+// withRequestID assigns every request a unique ID, which api.Logger adds to
+// its log lines and responses return in their metadata and in the
+// x-amzn-RequestId header, so client errors can be correlated with the
+// server logs.
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		requestID := uuid.New().String()
-		ctx := api.ContextWithRequestID(r.Context(), requestID)
-		ctx = api.ContextWithAction(ctx, r.FormValue("Action"))
-		r = r.WithContext(ctx)
-		req, err := srv.format.DecodeRequest(r)
-		if err != nil {
-			if err := srv.format.EncodeError(ctx, w, err); err != nil {
-				api.Logger(ctx).Error("serving decoding error to client", slog.Any("error", err))
-				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-			}
-			return
+		w.Header().Set(requestIDHeader, requestID)
+		next.ServeHTTP(w, r.WithContext(api.ContextWithRequestID(r.Context(), requestID)))
+	})
+}
+
+func (s *Server) serveAPI(w http.ResponseWriter, r *http.Request) {
+	ctx := api.ContextWithAction(r.Context(), r.FormValue("Action"))
+	r = r.WithContext(ctx)
+	req, err := s.format.DecodeRequest(r)
+	if err != nil {
+		logAction(ctx, 0, err)
+		if err := s.format.EncodeError(ctx, w, err); err != nil {
+			api.Logger(ctx).Error("serving decoding error to client", slog.Any("error", err))
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		}
-		resp, err := srv.dispatch.Dispatch(ctx, req)
-		if err != nil {
-			if err := srv.format.EncodeError(ctx, w, err); err != nil {
-				api.Logger(ctx).Error("serving error to client", slog.Any("error", err))
-				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-			}
+		return
+	}
+	start := time.Now()
+	resp, err := s.dispatch.Dispatch(ctx, req)
+	logAction(ctx, time.Since(start), err)
+	if err != nil {
+		if err := s.format.EncodeError(ctx, w, err); err != nil {
+			api.Logger(ctx).Error("serving error to client", slog.Any("error", err))
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}
+	} else {
+		if err := s.format.EncodeResponse(ctx, w, resp); err != nil {
+			api.Logger(ctx).Error("serving response to client", slog.Any("error", err))
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}
+	}
+}
+
+// logAction writes the audit log line of an API request. Failures with an
+// API error code are client errors and logged at the same level as
+// successful actions, while the rest are logged as errors.
+func logAction(ctx context.Context, duration time.Duration, err error) {
+	level := slog.LevelInfo
+	attrs := []slog.Attr{
+		slog.String("action", api.RequestAction(ctx)),
+		slog.Duration("duration", duration),
+	}
+	if err != nil {
+		var apiErr *api.Error
+		if errors.As(err, &apiErr) && apiErr.Code != "" {
+			attrs = append(attrs, slog.String("error_code", apiErr.Code))
 		} else {
-			if err := srv.format.EncodeResponse(ctx, w, resp); err != nil {
-				api.Logger(ctx).Error("serving response to client", slog.Any("error", err))
-				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-			}
+			level = slog.LevelError
 		}
-	})
-	return srv, nil
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	api.Logger(ctx).LogAttrs(ctx, level, "handled action", attrs...)
 }
 
 func (s *Server) serveMetadata(w http.ResponseWriter, r *http.Request) {
@@ -137,6 +222,87 @@ func (s *Server) serveMetadata(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// serveHealth responds with 200 once the server can handle requests and
+// with 503 otherwise, so orchestrators and tests can wait for it without
+// issuing AWS actions.
+func (s *Server) serveHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if err := s.dispatch.Ready(r.Context()); err != nil {
+		api.Logger(r.Context()).Warn("server is not ready", slog.Any("error", err))
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if _, err := io.WriteString(w, "ok\n"); err != nil {
+		api.Logger(r.Context()).Error("serving health response", slog.Any("error", err))
+	}
+}
+
+func (s *Server) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var body bytes.Buffer
+	if err := s.dispatch.writeMetrics(&body); err != nil {
+		api.Logger(r.Context()).Error("collecting metrics", slog.Any("error", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if _, err := w.Write(body.Bytes()); err != nil {
+		api.Logger(r.Context()).Error("serving metrics response", slog.Any("error", err))
+	}
+}
+
+// serveEvents streams the dispatcher state change events as Server-Sent
+// Events until the client disconnects or the server shuts down.
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if s.dispatch.stateChangeEvents == nil {
+		http.Error(w, "event stream is not enabled", http.StatusNotFound)
+		return
+	}
+	events, cancel := s.dispatch.stateChangeEvents.subscribe()
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	rc := http.NewResponseController(w)
+	if err := rc.Flush(); err != nil {
+		api.Logger(r.Context()).Error("flushing event stream", slog.Any("error", err))
+		return
+	}
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				api.Logger(r.Context()).Error("encoding event", slog.Any("error", err))
+				return
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
 func (s *Server) serveTestProfile(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
@@ -189,6 +355,139 @@ func (s *Server) serveTestProfile(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// serveCleanup removes the resources matching the tags in the request body,
+// a JSON object like {"tags": {"key": "value"}}.
+func (s *Server) serveCleanup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		Tags map[string]string `json:"tags"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("decoding request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(req.Tags) == 0 {
+		http.Error(w, "at least one tag is required", http.StatusBadRequest)
+		return
+	}
+	result, err := s.dispatch.CleanupTagged(r.Context(), req.Tags)
+	if err != nil {
+		api.Logger(r.Context()).Error("cleaning up tagged resources", slog.Any("error", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		api.Logger(r.Context()).Error("serving cleanup response", slog.Any("error", err))
+	}
+}
+
+// serveSpotInterruption simulates a spot interruption for the instance in the
+// request body, a JSON object like {"instanceId": "i-...", "notice": "2m"}.
+// The notice is optional and defaults to two minutes.
+func (s *Server) serveSpotInterruption(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		InstanceID string `json:"instanceId"`
+		Notice     string `json:"notice"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("decoding request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if req.InstanceID == "" {
+		http.Error(w, "instanceId is required", http.StatusBadRequest)
+		return
+	}
+	var notice time.Duration
+	if req.Notice != "" {
+		var err error
+		notice, err = time.ParseDuration(req.Notice)
+		if err != nil || notice <= 0 {
+			http.Error(w, fmt.Sprintf("invalid notice %q", req.Notice), http.StatusBadRequest)
+			return
+		}
+	}
+	reclaimAt, err := s.dispatch.InterruptInstance(r.Context(), req.InstanceID, notice)
+	if err != nil {
+		var apiErr *api.Error
+		if errors.As(err, &apiErr) {
+			http.Error(w, apiErr.Error(), http.StatusBadRequest)
+			return
+		}
+		api.Logger(r.Context()).Error("interrupting instance", slog.Any("error", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	resp := struct {
+		InstanceID string    `json:"instanceId"`
+		Time       time.Time `json:"time"`
+	}{
+		InstanceID: req.InstanceID,
+		Time:       reclaimAt,
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		api.Logger(r.Context()).Error("serving spot interruption response", slog.Any("error", err))
+	}
+}
+
+// serveInstanceEvent schedules an event for the instance in the request body,
+// a JSON object like {"instanceId": "i-...", "code": "instance-retirement"}
+// with optional "description", "notBefore" and "notAfter" (RFC 3339) fields.
+// The event is reported by DescribeInstanceStatus.
+func (s *Server) serveInstanceEvent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		InstanceEvent
+		InstanceID string `json:"instanceId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("decoding request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if req.InstanceID == "" {
+		http.Error(w, "instanceId is required", http.StatusBadRequest)
+		return
+	}
+	event, err := s.dispatch.ScheduleInstanceEvent(r.Context(), req.InstanceID, InstanceEvent{
+		Code:        req.Code,
+		Description: req.Description,
+		NotBefore:   req.NotBefore,
+		NotAfter:    req.NotAfter,
+	})
+	if err != nil {
+		var apiErr *api.Error
+		if errors.As(err, &apiErr) {
+			http.Error(w, apiErr.Error(), http.StatusBadRequest)
+			return
+		}
+		api.Logger(r.Context()).Error("scheduling instance event", slog.Any("error", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	resp := struct {
+		InstanceEvent
+		InstanceID string `json:"instanceId"`
+	}{
+		InstanceEvent: event,
+		InstanceID:    req.InstanceID,
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		api.Logger(r.Context()).Error("serving instance event response", slog.Any("error", err))
+	}
+}
+
 // Region returns the region identifier that the server is emulating (e.g. us-east-1)
 func (s *Server) Region() string {
 	return s.opts.Region
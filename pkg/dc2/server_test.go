@@ -0,0 +1,227 @@
+package dc2
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/format"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+// pingExecutor fails its health checks until ready is set.
+type pingExecutor struct {
+	*exitCleanupExecutor
+	ready atomic.Bool
+}
+
+func (e *pingExecutor) Ping(context.Context) error {
+	if !e.ready.Load() {
+		return errors.New("executor is not ready")
+	}
+	return nil
+}
+
+func TestServerHealthEndpoints(t *testing.T) {
+	t.Parallel()
+
+	exe := &pingExecutor{exitCleanupExecutor: &exitCleanupExecutor{}}
+	srv := &Server{
+		dispatch: &Dispatcher{
+			exe:     exe,
+			storage: storage.NewMemoryStorage(),
+		},
+	}
+	status := func(method string, path string) int {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		srv.serveHealth(rec, httptest.NewRequestWithContext(t.Context(), method, path, nil))
+		return rec.Code
+	}
+
+	for _, path := range []string{"/healthz", "/readyz"} {
+		assert.Equal(t, http.StatusServiceUnavailable, status(http.MethodGet, path), path)
+	}
+
+	exe.ready.Store(true)
+	for _, path := range []string{"/healthz", "/readyz"} {
+		assert.Equal(t, http.StatusOK, status(http.MethodGet, path), path)
+	}
+	assert.Equal(t, http.StatusMethodNotAllowed, status(http.MethodPost, "/healthz"))
+
+	srv.dispatch.storage = nil
+	assert.Equal(t, http.StatusServiceUnavailable, status(http.MethodGet, "/healthz"))
+}
+
+func TestServerMetrics(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	d := &Dispatcher{
+		exe:     newScalingExecutor(),
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+		metrics: newMetrics(),
+	}
+	srv := &Server{dispatch: d}
+	scrape := func() string {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		srv.serveMetrics(rec, httptest.NewRequestWithContext(ctx, http.MethodGet, "/metrics", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		return rec.Body.String()
+	}
+	assert.NotContains(t, scrape(), `dc2_actions_total{action="RunInstances"}`)
+
+	for range 2 {
+		_, err := d.Dispatch(ctx, &api.RunInstancesRequest{
+			ImageID:      "nginx",
+			InstanceType: "my-type",
+			MinCount:     1,
+			MaxCount:     1,
+		})
+		require.NoError(t, err)
+	}
+	_, err := d.Dispatch(ctx, &api.DescribeInstancesRequest{
+		Filters: []api.Filter{{Name: new("unknown-filter"), Values: []string{"value"}}},
+	})
+	require.Error(t, err)
+
+	body := scrape()
+	assert.Contains(t, body, `dc2_actions_total{action="RunInstances"} 2`)
+	assert.Contains(t, body, `dc2_actions_total{action="DescribeInstances"} 1`)
+	assert.Contains(t, body, `dc2_action_errors_total{code="InvalidParameterValue"} 1`)
+	assert.Contains(t, body, `dc2_resources{type="instance"} 2`)
+	assert.Contains(t, body, `dc2_resources{type="autoscaling_group"} 0`)
+	assert.Contains(t, body, "dc2_warm_pool_reconcile_duration_seconds_count 0")
+}
+
+func TestServerEventStream(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	d := &Dispatcher{
+		exe:               newScalingExecutor(),
+		imds:              &imdsController{},
+		storage:           storage.NewMemoryStorage(),
+		stateChangeEvents: newStateChangeEvents(),
+	}
+	srv := &Server{dispatch: d}
+	httpServer := httptest.NewServer(http.HandlerFunc(srv.serveEvents))
+	t.Cleanup(httpServer.Close)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, httpServer.URL+"/events", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	runResp, err := d.Dispatch(ctx, &api.RunInstancesRequest{
+		ImageID:      "nginx",
+		InstanceType: "my-type",
+		MinCount:     1,
+		MaxCount:     1,
+	})
+	require.NoError(t, err)
+	instanceID := runResp.(*api.RunInstancesResponse).InstancesSet[0].InstanceID
+
+	scanner := bufio.NewScanner(resp.Body)
+	var eventName string
+	var event StateChangeEvent
+	for scanner.Scan() {
+		line := scanner.Text()
+		if name, found := strings.CutPrefix(line, "event: "); found {
+			eventName = name
+		}
+		if data, found := strings.CutPrefix(line, "data: "); found {
+			require.NoError(t, json.Unmarshal([]byte(data), &event))
+			break
+		}
+	}
+	require.NoError(t, scanner.Err())
+	assert.Equal(t, string(EventTypeInstanceStateChange), eventName)
+	assert.Equal(t, EventTypeInstanceStateChange, event.Type)
+	assert.Equal(t, types.ResourceTypeInstance, event.ResourceType)
+	assert.Equal(t, instanceID, event.ResourceID)
+	assert.Equal(t, api.InstanceStatePending.Name, event.PreviousState)
+	assert.Equal(t, api.InstanceStateRunning.Name, event.State)
+	assert.False(t, event.Timestamp.IsZero())
+
+	// Closing the dispatcher ends the stream
+	d.stateChangeEvents.close()
+	_, err = io.Copy(io.Discard, resp.Body)
+	require.NoError(t, err)
+
+	disabled := httptest.NewRecorder()
+	(&Server{dispatch: &Dispatcher{}}).serveEvents(disabled, httptest.NewRequestWithContext(ctx, http.MethodGet, "/events", nil))
+	assert.Equal(t, http.StatusNotFound, disabled.Code)
+}
+
+func TestServerRequestIDInResponseAndLogs(t *testing.T) {
+	t.Parallel()
+
+	srv := &Server{
+		format: &format.XML{},
+		dispatch: &Dispatcher{
+			exe:     newScalingExecutor(),
+			imds:    &imdsController{},
+			storage: storage.NewMemoryStorage(),
+		},
+	}
+	handler := withRequestID(http.HandlerFunc(srv.serveAPI))
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+	serve := func(body string) (*httptest.ResponseRecorder, map[string]any) {
+		t.Helper()
+		logs.Reset()
+		ctx := api.ContextWithLogger(t.Context(), logger)
+		req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		var auditLine map[string]any
+		for line := range strings.Lines(logs.String()) {
+			var entry map[string]any
+			require.NoError(t, json.Unmarshal([]byte(line), &entry))
+			if entry["msg"] == "handled action" {
+				auditLine = entry
+			}
+		}
+		require.NotNil(t, auditLine, "logs: %s", logs.String())
+		return rec, auditLine
+	}
+
+	rec, auditLine := serve("Action=DescribeInstances&Version=2016-11-15")
+	require.Equal(t, http.StatusOK, rec.Code)
+	requestID := rec.Header().Get(requestIDHeader)
+	require.NotEmpty(t, requestID)
+	assert.Contains(t, rec.Body.String(), "<RequestId>"+requestID+"</RequestId>")
+	assert.Equal(t, requestID, auditLine["request_id"])
+	assert.Equal(t, "DescribeInstances", auditLine["action"])
+
+	rec, auditLine = serve("Action=DescribeInstances&Version=2016-11-15&Filter.1.Name=unknown-filter&Filter.1.Value.1=value")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	errorRequestID := rec.Header().Get(requestIDHeader)
+	assert.NotEqual(t, requestID, errorRequestID)
+	assert.Contains(t, rec.Body.String(), "<RequestID>"+errorRequestID+"</RequestID>")
+	assert.Equal(t, errorRequestID, auditLine["request_id"])
+	assert.Equal(t, api.ErrorCodeInvalidParameterValue, auditLine["error_code"])
+}
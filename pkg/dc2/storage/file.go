@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+const fileStorageVersion = 1
+
+type fileStorageState struct {
+	Version   int                   `json:"version"`
+	Resources []fileStorageResource `json:"resources"`
+}
+
+type fileStorageResource struct {
+	Type       types.ResourceType `json:"type"`
+	ID         string             `json:"id"`
+	Attributes map[string]string  `json:"attributes,omitempty"`
+}
+
+// fileStorage keeps resources in memory and writes them to a JSON file after
+// every change, so they survive restarts.
+type fileStorage struct {
+	mu     sync.Mutex
+	path   string
+	memory *memoryStorage
+}
+
+// NewFileStorage returns a Storage persisted at path. If the file exists, its
+// resources are loaded. Otherwise, it's created on the first change.
+func NewFileStorage(path string) (Storage, error) {
+	s := &fileStorage{
+		path: path,
+		memory: &memoryStorage{
+			resources: make(map[string]*resourceStorage),
+		},
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("reading state file %q: %w", path, err)
+	}
+	var state fileStorageState
+	if err := json.Unmarshal(raw, &state); err != nil {
+		return nil, fmt.Errorf("decoding state file %q: %w", path, err)
+	}
+	if state.Version != fileStorageVersion {
+		return nil, fmt.Errorf("state file %q has unsupported version %d", path, state.Version)
+	}
+	for _, r := range state.Resources {
+		if err := s.memory.RegisterResource(Resource{Type: r.Type, ID: r.ID}); err != nil {
+			return nil, fmt.Errorf("loading state file %q: %w", path, err)
+		}
+		s.memory.resources[r.ID].Attrs = r.Attributes
+	}
+	return s, nil
+}
+
+func (s *fileStorage) RegisterResource(r Resource) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.memory.RegisterResource(r); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+func (s *fileStorage) RemoveResource(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.memory.RemoveResource(id); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+func (s *fileStorage) RegisteredResources(rt types.ResourceType) ([]Resource, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.memory.RegisteredResources(rt)
+}
+
+func (s *fileStorage) SetResourceAttributes(id string, attrs []Attribute) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.memory.SetResourceAttributes(id, attrs); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+func (s *fileStorage) RemoveResourceAttributes(id string, attrs []Attribute) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.memory.RemoveResourceAttributes(id, attrs); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+func (s *fileStorage) ResourceAttributes(id string) (Attributes, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.memory.ResourceAttributes(id)
+}
+
+// save writes the whole state to a temporary file and renames it over the
+// state file, so a crash never leaves a partially written state behind.
+// It must be called with s.mu held.
+func (s *fileStorage) save() error {
+	state := fileStorageState{
+		Version:   fileStorageVersion,
+		Resources: make([]fileStorageResource, 0, len(s.memory.resources)),
+	}
+	for id, r := range s.memory.resources {
+		state.Resources = append(state.Resources, fileStorageResource{
+			Type:       r.Type,
+			ID:         id,
+			Attributes: r.Attrs,
+		})
+	}
+	slices.SortFunc(state.Resources, func(a, b fileStorageResource) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	raw, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding state: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("creating temporary state file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck // no-op after a successful rename
+	if _, err := tmp.Write(raw); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("writing temporary state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("closing temporary state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("replacing state file %q: %w", s.path, err)
+	}
+	return nil
+}
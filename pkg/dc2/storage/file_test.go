@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fiam/dc2/pkg/dc2/types"
+)
+
+func TestFileStorageReloadsState(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	s, err := NewFileStorage(path)
+	require.NoError(t, err)
+	require.NoError(t, s.RegisterResource(Resource{Type: types.ResourceTypeLaunchTemplate, ID: "lt-1"}))
+	require.NoError(t, s.RegisterResource(Resource{Type: types.ResourceTypeInstance, ID: "i-1"}))
+	require.NoError(t, s.RegisterResource(Resource{Type: types.ResourceTypeInstance, ID: "i-2"}))
+	require.NoError(t, s.SetResourceAttributes("i-1", []Attribute{
+		{Key: "KeyName", Value: "key"},
+		{Key: TagAttributeName("Name"), Value: "web"},
+		{Key: TagAttributeName("Role"), Value: "web"},
+	}))
+	require.NoError(t, s.RemoveResourceAttributes("i-1", []Attribute{{Key: TagAttributeName("Role")}}))
+	require.NoError(t, s.RemoveResource("i-2"))
+
+	reloaded, err := NewFileStorage(path)
+	require.NoError(t, err)
+	instances, err := reloaded.RegisteredResources(types.ResourceTypeInstance)
+	require.NoError(t, err)
+	assert.Equal(t, []Resource{{Type: types.ResourceTypeInstance, ID: "i-1"}}, instances)
+	templates, err := reloaded.RegisteredResources(types.ResourceTypeLaunchTemplate)
+	require.NoError(t, err)
+	assert.Equal(t, []Resource{{Type: types.ResourceTypeLaunchTemplate, ID: "lt-1"}}, templates)
+	attrs, err := reloaded.ResourceAttributes("i-1")
+	require.NoError(t, err)
+	assert.Equal(t, Attributes{
+		{Key: "KeyName", Value: "key"},
+		{Key: TagAttributeName("Name"), Value: "web"},
+	}, attrs)
+	err = reloaded.RegisterResource(Resource{Type: types.ResourceTypeInstance, ID: "i-1"})
+	assert.ErrorAs(t, err, &ErrDuplicatedResource{})
+}
+
+func TestFileStorageConcurrentSetResourceAttributes(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	s, err := NewFileStorage(path)
+	require.NoError(t, err)
+	require.NoError(t, s.RegisterResource(Resource{Type: types.ResourceTypeInstance, ID: "i-1"}))
+
+	const writers = 16
+	var wg sync.WaitGroup
+	for i := range writers {
+		wg.Go(func() {
+			assert.NoError(t, s.SetResourceAttributes("i-1", []Attribute{
+				{Key: fmt.Sprintf("Key%02d", i), Value: fmt.Sprintf("%d", i)},
+			}))
+		})
+	}
+	wg.Wait()
+
+	reloaded, err := NewFileStorage(path)
+	require.NoError(t, err)
+	attrs, err := reloaded.ResourceAttributes("i-1")
+	require.NoError(t, err)
+	assert.Len(t, attrs, writers)
+}
+
+func TestFileStorageRejectsInvalidState(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	invalidJSON := filepath.Join(dir, "invalid.json")
+	require.NoError(t, os.WriteFile(invalidJSON, []byte("{"), 0o600))
+	_, err := NewFileStorage(invalidJSON)
+	require.ErrorContains(t, err, "decoding state file")
+
+	unknownVersion := filepath.Join(dir, "version.json")
+	require.NoError(t, os.WriteFile(unknownVersion, []byte(`{"version": 99}`), 0o600))
+	_, err = NewFileStorage(unknownVersion)
+	require.ErrorContains(t, err, "unsupported version 99")
+}
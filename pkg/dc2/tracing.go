@@ -0,0 +1,327 @@
+package dc2
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/executor"
+)
+
+const (
+	tracingAttributeAction      = "dc2.action"
+	tracingAttributeRequestID   = "dc2.request_id"
+	tracingAttributeResourceIDs = "dc2.resource_ids"
+	tracingAttributeErrorCode   = "dc2.error_code"
+)
+
+// startDispatchSpan starts the span covering a dispatched action. The
+// returned function ends it, recording the outcome.
+func (d *Dispatcher) startDispatchSpan(ctx context.Context, req api.Request) (context.Context, func(error)) {
+	action := requestActionName(ctx, req)
+	attrs := []attribute.KeyValue{attribute.String(tracingAttributeAction, action)}
+	if requestID := api.RequestID(ctx); requestID != "" {
+		attrs = append(attrs, attribute.String(tracingAttributeRequestID, requestID))
+	}
+	if resourceIDs := requestResourceIDs(req); len(resourceIDs) > 0 {
+		attrs = append(attrs, attribute.StringSlice(tracingAttributeResourceIDs, resourceIDs))
+	}
+	ctx, span := d.opts.Tracer.Start(ctx, "dc2."+action, trace.WithAttributes(attrs...))
+	return ctx, func(err error) {
+		endSpan(span, err)
+	}
+}
+
+// requestActionName returns the name of the action requested by the client,
+// falling back to the request type for requests dispatched internally.
+func requestActionName(ctx context.Context, req api.Request) string {
+	if action := api.RequestAction(ctx); action != "" {
+		return action
+	}
+	return strings.TrimSuffix(reflect.Indirect(reflect.ValueOf(req)).Type().Name(), "Request")
+}
+
+// requestResourceIDs returns the resource IDs and names referenced by the
+// top level fields of a request.
+func requestResourceIDs(req api.Request) []string {
+	rv := reflect.Indirect(reflect.ValueOf(req))
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	var ids []string
+	for i := range rv.NumField() {
+		field := rv.Type().Field(i)
+		if field.Anonymous || !isResourceIDFieldName(field.Name) {
+			continue
+		}
+		value := rv.Field(i)
+		switch {
+		case value.Kind() == reflect.String && value.String() != "":
+			ids = append(ids, value.String())
+		case value.Kind() == reflect.Pointer && !value.IsNil() && value.Elem().Kind() == reflect.String:
+			ids = append(ids, value.Elem().String())
+		case value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.String:
+			for j := range value.Len() {
+				ids = append(ids, value.Index(j).String())
+			}
+		}
+	}
+	return ids
+}
+
+func isResourceIDFieldName(name string) bool {
+	return strings.HasSuffix(name, "ID") ||
+		strings.HasSuffix(name, "IDs") ||
+		name == "AutoScalingGroupName" ||
+		name == "AutoScalingGroupNames"
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		var apiErr *api.Error
+		if errors.As(err, &apiErr) {
+			span.SetAttributes(attribute.String(tracingAttributeErrorCode, apiErr.Code))
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// tracingExecutor wraps an executor.Executor, creating a span around every
+// call. It's only used when a tracer is configured, so there's no overhead
+// otherwise.
+type tracingExecutor struct {
+	exe    executor.Executor
+	tracer trace.Tracer
+}
+
+var _ executor.Executor = (*tracingExecutor)(nil)
+
+func newTracingExecutor(exe executor.Executor, tracer trace.Tracer) *tracingExecutor {
+	return &tracingExecutor{exe: exe, tracer: tracer}
+}
+
+func (e *tracingExecutor) start(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return e.tracer.Start(ctx, "executor."+method, trace.WithAttributes(attrs...))
+}
+
+func instanceIDsAttribute(ids []executor.InstanceID) attribute.KeyValue {
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = apiInstanceID(id)
+	}
+	return attribute.StringSlice(tracingAttributeResourceIDs, values)
+}
+
+func (e *tracingExecutor) Close(ctx context.Context) error {
+	return e.exe.Close(ctx)
+}
+
+func (e *tracingExecutor) Disconnect() error {
+	return e.exe.Disconnect()
+}
+
+func (e *tracingExecutor) Ping(ctx context.Context) error {
+	return e.exe.Ping(ctx)
+}
+
+func (e *tracingExecutor) ListOwnedInstances(ctx context.Context) ([]executor.InstanceID, error) {
+	ctx, span := e.start(ctx, "ListOwnedInstances")
+	ids, err := e.exe.ListOwnedInstances(ctx)
+	endSpan(span, err)
+	return ids, err
+}
+
+func (e *tracingExecutor) CreateInstances(ctx context.Context, req executor.CreateInstancesRequest) ([]executor.InstanceID, error) {
+	ctx, span := e.start(ctx, "CreateInstances",
+		attribute.String("dc2.image_id", req.ImageID),
+		attribute.String("dc2.instance_type", req.InstanceType),
+		attribute.Int("dc2.count", req.Count),
+	)
+	ids, err := e.exe.CreateInstances(ctx, req)
+	if err == nil {
+		span.SetAttributes(instanceIDsAttribute(ids))
+	}
+	endSpan(span, err)
+	return ids, err
+}
+
+func (e *tracingExecutor) DescribeInstances(ctx context.Context, req executor.DescribeInstancesRequest) ([]executor.InstanceDescription, error) {
+	ctx, span := e.start(ctx, "DescribeInstances", instanceIDsAttribute(req.InstanceIDs))
+	descs, err := e.exe.DescribeInstances(ctx, req)
+	endSpan(span, err)
+	return descs, err
+}
+
+func (e *tracingExecutor) StartInstances(ctx context.Context, req executor.StartInstancesRequest) ([]executor.InstanceStateChange, error) {
+	ctx, span := e.start(ctx, "StartInstances", instanceIDsAttribute(req.InstanceIDs))
+	changes, err := e.exe.StartInstances(ctx, req)
+	endSpan(span, err)
+	return changes, err
+}
+
+func (e *tracingExecutor) StopInstances(ctx context.Context, req executor.StopInstancesRequest) ([]executor.InstanceStateChange, error) {
+	ctx, span := e.start(ctx, "StopInstances", instanceIDsAttribute(req.InstanceIDs))
+	changes, err := e.exe.StopInstances(ctx, req)
+	endSpan(span, err)
+	return changes, err
+}
+
+func (e *tracingExecutor) RebootInstances(ctx context.Context, req executor.RebootInstancesRequest) error {
+	ctx, span := e.start(ctx, "RebootInstances", instanceIDsAttribute(req.InstanceIDs))
+	err := e.exe.RebootInstances(ctx, req)
+	endSpan(span, err)
+	return err
+}
+
+func (e *tracingExecutor) ModifyInstanceAttribute(ctx context.Context, req executor.ModifyInstanceAttributeRequest) error {
+	ctx, span := e.start(ctx, "ModifyInstanceAttribute", instanceIDsAttribute([]executor.InstanceID{req.InstanceID}))
+	err := e.exe.ModifyInstanceAttribute(ctx, req)
+	endSpan(span, err)
+	return err
+}
+
+func (e *tracingExecutor) TerminateInstances(ctx context.Context, req executor.TerminateInstancesRequest) ([]executor.InstanceStateChange, error) {
+	ctx, span := e.start(ctx, "TerminateInstances", instanceIDsAttribute(req.InstanceIDs))
+	changes, err := e.exe.TerminateInstances(ctx, req)
+	endSpan(span, err)
+	return changes, err
+}
+
+func (e *tracingExecutor) InstanceConsoleOutput(ctx context.Context, instanceID executor.InstanceID) (executor.InstanceConsoleOutput, error) {
+	ctx, span := e.start(ctx, "InstanceConsoleOutput", instanceIDsAttribute([]executor.InstanceID{instanceID}))
+	output, err := e.exe.InstanceConsoleOutput(ctx, instanceID)
+	endSpan(span, err)
+	return output, err
+}
+
+func (e *tracingExecutor) CreateVolume(ctx context.Context, req executor.CreateVolumeRequest) (executor.VolumeID, error) {
+	ctx, span := e.start(ctx, "CreateVolume", attribute.Int64("dc2.size", req.Size))
+	volumeID, err := e.exe.CreateVolume(ctx, req)
+	if err == nil {
+		span.SetAttributes(attribute.StringSlice(tracingAttributeResourceIDs, []string{string(volumeID)}))
+	}
+	endSpan(span, err)
+	return volumeID, err
+}
+
+func (e *tracingExecutor) DeleteVolume(ctx context.Context, req executor.DeleteVolumeRequest) error {
+	ctx, span := e.start(ctx, "DeleteVolume", attribute.StringSlice(tracingAttributeResourceIDs, []string{string(req.VolumeID)}))
+	err := e.exe.DeleteVolume(ctx, req)
+	endSpan(span, err)
+	return err
+}
+
+func (e *tracingExecutor) DescribeVolumes(ctx context.Context, req executor.DescribeVolumesRequest) ([]executor.VolumeDescription, error) {
+	volumeIDs := make([]string, len(req.VolumeIDs))
+	for i, id := range req.VolumeIDs {
+		volumeIDs[i] = string(id)
+	}
+	ctx, span := e.start(ctx, "DescribeVolumes", attribute.StringSlice(tracingAttributeResourceIDs, volumeIDs))
+	descs, err := e.exe.DescribeVolumes(ctx, req)
+	endSpan(span, err)
+	return descs, err
+}
+
+func (e *tracingExecutor) ResizeVolume(ctx context.Context, req executor.ResizeVolumeRequest) error {
+	ctx, span := e.start(ctx, "ResizeVolume", attribute.StringSlice(tracingAttributeResourceIDs, []string{string(req.VolumeID)}))
+	err := e.exe.ResizeVolume(ctx, req)
+	endSpan(span, err)
+	return err
+}
+
+func (e *tracingExecutor) CreateSnapshot(ctx context.Context, req executor.CreateSnapshotRequest) (executor.SnapshotID, error) {
+	ctx, span := e.start(ctx, "CreateSnapshot", attribute.StringSlice(tracingAttributeResourceIDs, []string{string(req.VolumeID)}))
+	snapshotID, err := e.exe.CreateSnapshot(ctx, req)
+	if err == nil {
+		span.SetAttributes(attribute.StringSlice(tracingAttributeResourceIDs, []string{string(req.VolumeID), string(snapshotID)}))
+	}
+	endSpan(span, err)
+	return snapshotID, err
+}
+
+func (e *tracingExecutor) DeleteSnapshot(ctx context.Context, req executor.DeleteSnapshotRequest) error {
+	ctx, span := e.start(ctx, "DeleteSnapshot", attribute.StringSlice(tracingAttributeResourceIDs, []string{string(req.SnapshotID)}))
+	err := e.exe.DeleteSnapshot(ctx, req)
+	endSpan(span, err)
+	return err
+}
+
+func (e *tracingExecutor) CopySnapshot(ctx context.Context, req executor.CopySnapshotRequest) (executor.SnapshotID, error) {
+	ctx, span := e.start(ctx, "CopySnapshot", attribute.StringSlice(tracingAttributeResourceIDs, []string{string(req.SnapshotID)}))
+	snapshotID, err := e.exe.CopySnapshot(ctx, req)
+	if err == nil {
+		span.SetAttributes(attribute.StringSlice(tracingAttributeResourceIDs, []string{string(req.SnapshotID), string(snapshotID)}))
+	}
+	endSpan(span, err)
+	return snapshotID, err
+}
+
+func (e *tracingExecutor) DescribeSnapshots(ctx context.Context, req executor.DescribeSnapshotsRequest) ([]executor.SnapshotDescription, error) {
+	snapshotIDs := make([]string, len(req.SnapshotIDs))
+	for i, id := range req.SnapshotIDs {
+		snapshotIDs[i] = string(id)
+	}
+	ctx, span := e.start(ctx, "DescribeSnapshots", attribute.StringSlice(tracingAttributeResourceIDs, snapshotIDs))
+	descs, err := e.exe.DescribeSnapshots(ctx, req)
+	endSpan(span, err)
+	return descs, err
+}
+
+func (e *tracingExecutor) AttachVolume(ctx context.Context, req executor.AttachVolumeRequest) (*executor.VolumeAttachment, error) {
+	ctx, span := e.start(ctx, "AttachVolume", attribute.StringSlice(tracingAttributeResourceIDs, []string{
+		string(req.VolumeID),
+		apiInstanceID(req.InstanceID),
+	}))
+	attachment, err := e.exe.AttachVolume(ctx, req)
+	endSpan(span, err)
+	return attachment, err
+}
+
+func (e *tracingExecutor) DetachVolume(ctx context.Context, req executor.DetachVolumeRequest) (*executor.VolumeAttachment, error) {
+	ctx, span := e.start(ctx, "DetachVolume", attribute.StringSlice(tracingAttributeResourceIDs, []string{
+		string(req.VolumeID),
+		apiInstanceID(req.InstanceID),
+	}))
+	attachment, err := e.exe.DetachVolume(ctx, req)
+	endSpan(span, err)
+	return attachment, err
+}
+
+func (e *tracingExecutor) CreateImage(ctx context.Context, req executor.CreateImageRequest) error {
+	ctx, span := e.start(ctx, "CreateImage", attribute.StringSlice(tracingAttributeResourceIDs, []string{
+		apiInstanceID(req.InstanceID),
+		req.ImageID,
+	}))
+	err := e.exe.CreateImage(ctx, req)
+	endSpan(span, err)
+	return err
+}
+
+func (e *tracingExecutor) DeleteImage(ctx context.Context, req executor.DeleteImageRequest) error {
+	ctx, span := e.start(ctx, "DeleteImage", attribute.StringSlice(tracingAttributeResourceIDs, []string{req.ImageID}))
+	err := e.exe.DeleteImage(ctx, req)
+	endSpan(span, err)
+	return err
+}
+
+func (e *tracingExecutor) CopyImage(ctx context.Context, req executor.CopyImageRequest) error {
+	ctx, span := e.start(ctx, "CopyImage", attribute.StringSlice(tracingAttributeResourceIDs, []string{req.SourceImageID, req.ImageID}))
+	err := e.exe.CopyImage(ctx, req)
+	endSpan(span, err)
+	return err
+}
+
+func (e *tracingExecutor) ListImages(ctx context.Context) ([]executor.ImageDescription, error) {
+	ctx, span := e.start(ctx, "ListImages")
+	images, err := e.exe.ListImages(ctx)
+	endSpan(span, err)
+	return images, err
+}
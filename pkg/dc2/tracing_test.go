@@ -0,0 +1,80 @@
+package dc2
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/fiam/dc2/pkg/dc2/api"
+	"github.com/fiam/dc2/pkg/dc2/storage"
+)
+
+func spanAttribute(span sdktrace.ReadOnlySpan, key string) (attribute.Value, bool) {
+	for _, attr := range span.Attributes() {
+		if string(attr.Key) == key {
+			return attr.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestDispatchCreatesSpans(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	tracer := provider.Tracer("dc2-test")
+	d := &Dispatcher{
+		opts:    DispatcherOptions{Tracer: tracer},
+		exe:     newTracingExecutor(&exitCleanupExecutor{}, tracer),
+		imds:    &imdsController{},
+		storage: storage.NewMemoryStorage(),
+	}
+
+	ctx := api.ContextWithAction(context.Background(), "StopInstances")
+	_, err := d.Dispatch(ctx, &api.StopInstancesRequest{
+		DryRunnableRequest: api.DryRunnableRequest{DryRun: true},
+		InstanceIDs:        []string{"i-00000000000000001"},
+	})
+	require.Error(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "dc2.StopInstances", span.Name())
+	assert.Equal(t, codes.Error, span.Status().Code)
+	action, ok := spanAttribute(span, tracingAttributeAction)
+	require.True(t, ok)
+	assert.Equal(t, "StopInstances", action.AsString())
+	resourceIDs, ok := spanAttribute(span, tracingAttributeResourceIDs)
+	require.True(t, ok)
+	assert.Equal(t, []string{"i-00000000000000001"}, resourceIDs.AsStringSlice())
+	errorCode, ok := spanAttribute(span, tracingAttributeErrorCode)
+	require.True(t, ok)
+	assert.Equal(t, api.ErrorCodeDryRunOperation, errorCode.AsString())
+}
+
+func TestTracingExecutorCreatesChildSpans(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	tracer := provider.Tracer("dc2-test")
+	exe := newTracingExecutor(&exitCleanupExecutor{}, tracer)
+
+	ctx, parent := tracer.Start(context.Background(), "parent")
+	_, err := exe.ListOwnedInstances(ctx)
+	require.NoError(t, err)
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "executor.ListOwnedInstances", spans[0].Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+}
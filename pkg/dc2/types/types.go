@@ -14,6 +14,13 @@ const (
 	ResourceTypeAutoScalingGroup     = ResourceType("auto-scaling-group")
 	ResourceTypeNetworkInterface     = ec2types.ResourceTypeNetworkInterface
 	ResourceTypeSpotInstancesRequest = ec2types.ResourceTypeSpotInstancesRequest
+	ResourceTypeKeyPair              = ec2types.ResourceTypeKeyPair
+	ResourceTypeSnapshot             = ec2types.ResourceTypeSnapshot
+	ResourceTypePlacementGroup       = ec2types.ResourceTypePlacementGroup
+	ResourceTypeImage                = ec2types.ResourceTypeImage
+	ResourceTypeAddress              = ec2types.ResourceTypeElasticIp
+	ResourceTypeVpc                  = ec2types.ResourceTypeVpc
+	ResourceTypeSubnet               = ec2types.ResourceTypeSubnet
 )
 
 type VolumeType = ec2types.VolumeType
@@ -48,3 +55,20 @@ const (
 	VolumeAttachmentStateDetached  = ec2types.VolumeAttachmentStateDetached
 	VolumeAttachmentStateBusy      = ec2types.VolumeAttachmentStateBusy
 )
+
+type SnapshotState = ec2types.SnapshotState
+
+const (
+	SnapshotStatePending   = ec2types.SnapshotStatePending
+	SnapshotStateCompleted = ec2types.SnapshotStateCompleted
+	SnapshotStateError     = ec2types.SnapshotStateError
+)
+
+type VolumeModificationState = ec2types.VolumeModificationState
+
+const (
+	VolumeModificationStateModifying  = ec2types.VolumeModificationStateModifying
+	VolumeModificationStateOptimizing = ec2types.VolumeModificationStateOptimizing
+	VolumeModificationStateCompleted  = ec2types.VolumeModificationStateCompleted
+	VolumeModificationStateFailed     = ec2types.VolumeModificationStateFailed
+)